@@ -33,25 +33,38 @@ import (
 	"github.com/horizoncd/horizon/core/config"
 	accessctl "github.com/horizoncd/horizon/core/controller/access"
 	accesstokenctl "github.com/horizoncd/horizon/core/controller/accesstoken"
+	admissionpolicyctl "github.com/horizoncd/horizon/core/controller/admissionpolicy"
 	applicationctl "github.com/horizoncd/horizon/core/controller/application"
 	applicationregionctl "github.com/horizoncd/horizon/core/controller/applicationregion"
+	auditctl "github.com/horizoncd/horizon/core/controller/audit"
 	badgectl "github.com/horizoncd/horizon/core/controller/badge"
 	"github.com/horizoncd/horizon/core/controller/build"
+	buildinsightctl "github.com/horizoncd/horizon/core/controller/buildinsight"
+	canaryctl "github.com/horizoncd/horizon/core/controller/canary"
+	changerequestctl "github.com/horizoncd/horizon/core/controller/changerequest"
 	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
 	codectl "github.com/horizoncd/horizon/core/controller/code"
+	doractl "github.com/horizoncd/horizon/core/controller/dora"
+	driftctl "github.com/horizoncd/horizon/core/controller/drift"
+	elevationctl "github.com/horizoncd/horizon/core/controller/elevation"
 	environmentctl "github.com/horizoncd/horizon/core/controller/environment"
 	environmentregionctl "github.com/horizoncd/horizon/core/controller/environmentregion"
 	envtemplatectl "github.com/horizoncd/horizon/core/controller/envtemplate"
 	eventctl "github.com/horizoncd/horizon/core/controller/event"
+	eventaggregationctl "github.com/horizoncd/horizon/core/controller/eventaggregation"
 	groupctl "github.com/horizoncd/horizon/core/controller/group"
+	hibernationctl "github.com/horizoncd/horizon/core/controller/hibernation"
+	hpactl "github.com/horizoncd/horizon/core/controller/hpa"
 	idpctl "github.com/horizoncd/horizon/core/controller/idp"
 	memberctl "github.com/horizoncd/horizon/core/controller/member"
 	oauthservicectl "github.com/horizoncd/horizon/core/controller/oauth"
 	oauthappctl "github.com/horizoncd/horizon/core/controller/oauthapp"
 	oauthcheckctl "github.com/horizoncd/horizon/core/controller/oauthcheck"
 	prctl "github.com/horizoncd/horizon/core/controller/pipelinerun"
+	portforwardctl "github.com/horizoncd/horizon/core/controller/portforward"
 	regionctl "github.com/horizoncd/horizon/core/controller/region"
 	registryctl "github.com/horizoncd/horizon/core/controller/registry"
+	releaseplanctl "github.com/horizoncd/horizon/core/controller/releaseplan"
 	roltctl "github.com/horizoncd/horizon/core/controller/role"
 	scopectl "github.com/horizoncd/horizon/core/controller/scope"
 	tagctl "github.com/horizoncd/horizon/core/controller/tag"
@@ -84,20 +97,33 @@ import (
 	"github.com/horizoncd/horizon/core/http/api/v1/template"
 	accessv2 "github.com/horizoncd/horizon/core/http/api/v2/access"
 	accesstokenv2 "github.com/horizoncd/horizon/core/http/api/v2/accesstoken"
+	admissionpolicyv2 "github.com/horizoncd/horizon/core/http/api/v2/admissionpolicy"
 	applicationregionv2 "github.com/horizoncd/horizon/core/http/api/v2/applicationregion"
+	auditv2 "github.com/horizoncd/horizon/core/http/api/v2/audit"
 	"github.com/horizoncd/horizon/core/http/api/v2/badge"
+	buildinsightv2 "github.com/horizoncd/horizon/core/http/api/v2/buildinsight"
+	canaryv2 "github.com/horizoncd/horizon/core/http/api/v2/canary"
+	changerequestv2 "github.com/horizoncd/horizon/core/http/api/v2/changerequest"
 	clusterv2 "github.com/horizoncd/horizon/core/http/api/v2/cluster"
 	codev2 "github.com/horizoncd/horizon/core/http/api/v2/code"
+	dorav2 "github.com/horizoncd/horizon/core/http/api/v2/dora"
+	driftv2 "github.com/horizoncd/horizon/core/http/api/v2/drift"
+	elevationv2 "github.com/horizoncd/horizon/core/http/api/v2/elevation"
 	environmentv2 "github.com/horizoncd/horizon/core/http/api/v2/environment"
 	environmentregionv2 "github.com/horizoncd/horizon/core/http/api/v2/environmentregion"
 	eventv2 "github.com/horizoncd/horizon/core/http/api/v2/event"
+	eventaggregationv2 "github.com/horizoncd/horizon/core/http/api/v2/eventaggregation"
 	groupv2 "github.com/horizoncd/horizon/core/http/api/v2/group"
+	hibernationv2 "github.com/horizoncd/horizon/core/http/api/v2/hibernation"
+	hpav2 "github.com/horizoncd/horizon/core/http/api/v2/hpa"
 	idpv2 "github.com/horizoncd/horizon/core/http/api/v2/idp"
 	memberv2 "github.com/horizoncd/horizon/core/http/api/v2/member"
 	oauthappv2 "github.com/horizoncd/horizon/core/http/api/v2/oauthapp"
 	pipelinerunv2 "github.com/horizoncd/horizon/core/http/api/v2/pipelinerun"
+	portforwardv2 "github.com/horizoncd/horizon/core/http/api/v2/portforward"
 	regionv2 "github.com/horizoncd/horizon/core/http/api/v2/region"
 	registryv2 "github.com/horizoncd/horizon/core/http/api/v2/registry"
+	releaseplanv2 "github.com/horizoncd/horizon/core/http/api/v2/releaseplan"
 	rolev2 "github.com/horizoncd/horizon/core/http/api/v2/role"
 	scopev2 "github.com/horizoncd/horizon/core/http/api/v2/scope"
 	tagv2 "github.com/horizoncd/horizon/core/http/api/v2/tag"
@@ -108,20 +134,32 @@ import (
 	"github.com/horizoncd/horizon/core/middleware"
 	"github.com/horizoncd/horizon/core/middleware/auth"
 	"github.com/horizoncd/horizon/core/middleware/requestid"
-	gitlablib "github.com/horizoncd/horizon/lib/gitlab"
 	"github.com/horizoncd/horizon/pkg/admission"
+	auditexporter "github.com/horizoncd/horizon/pkg/audit/exporter"
 	"github.com/horizoncd/horizon/pkg/cd"
 	clustermetrcis "github.com/horizoncd/horizon/pkg/cluster/metrics"
 	admissionconfig "github.com/horizoncd/horizon/pkg/config/admission"
 	"github.com/horizoncd/horizon/pkg/environment/service"
 	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	gitopsdatabase "github.com/horizoncd/horizon/pkg/gitops/database"
 	"github.com/horizoncd/horizon/pkg/grafana"
 	"github.com/horizoncd/horizon/pkg/jobs"
+	auditanchorjob "github.com/horizoncd/horizon/pkg/jobs/auditanchor"
 	"github.com/horizoncd/horizon/pkg/jobs/autofree"
+	buildinsightjob "github.com/horizoncd/horizon/pkg/jobs/buildinsight"
+	canaryjob "github.com/horizoncd/horizon/pkg/jobs/canary"
 	"github.com/horizoncd/horizon/pkg/jobs/clean"
+	driftjob "github.com/horizoncd/horizon/pkg/jobs/drift"
+	elevationjob "github.com/horizoncd/horizon/pkg/jobs/elevation"
 	"github.com/horizoncd/horizon/pkg/jobs/eventhandler"
+	"github.com/horizoncd/horizon/pkg/jobs/gitopsmirror"
 	"github.com/horizoncd/horizon/pkg/jobs/grafanasync"
+	hibernationjob "github.com/horizoncd/horizon/pkg/jobs/hibernation"
+	hpajob "github.com/horizoncd/horizon/pkg/jobs/hpa"
 	"github.com/horizoncd/horizon/pkg/jobs/k8sevent"
+	ldapsyncjob "github.com/horizoncd/horizon/pkg/jobs/ldapsync"
+	releaseplanjob "github.com/horizoncd/horizon/pkg/jobs/releaseplan"
 	jobwebhook "github.com/horizoncd/horizon/pkg/jobs/webhook"
 	prservice "github.com/horizoncd/horizon/pkg/pr/service"
 	"github.com/horizoncd/horizon/pkg/regioninformers"
@@ -142,6 +180,7 @@ import (
 	"github.com/horizoncd/horizon/core/http/health"
 	"github.com/horizoncd/horizon/core/http/metrics"
 	admissionmiddle "github.com/horizoncd/horizon/core/middleware/admission"
+	auditmiddle "github.com/horizoncd/horizon/core/middleware/audit"
 	ginlogmiddle "github.com/horizoncd/horizon/core/middleware/ginlog"
 	logmiddle "github.com/horizoncd/horizon/core/middleware/log"
 	metricsmiddle "github.com/horizoncd/horizon/core/middleware/metrics"
@@ -165,6 +204,7 @@ import (
 	memberservice "github.com/horizoncd/horizon/pkg/member/service"
 	oauthdao "github.com/horizoncd/horizon/pkg/oauth/dao"
 	oauthmanager "github.com/horizoncd/horizon/pkg/oauth/manager"
+	"github.com/horizoncd/horizon/pkg/oauth/oidc"
 	scopeservice "github.com/horizoncd/horizon/pkg/oauth/scope"
 	"github.com/horizoncd/horizon/pkg/param"
 	"github.com/horizoncd/horizon/pkg/param/managerparam"
@@ -234,6 +274,9 @@ func ParseFlags() *Flags {
 
 func InitAdmissionWebhook(config admissionconfig.Admission) {
 	admission.NewHTTPWebhooks(config)
+	if err := admission.NewPolicies(config); err != nil {
+		panic(err)
+	}
 }
 
 func InitLog(flags *Flags) {
@@ -325,24 +368,37 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 
 	// init manager parameter
 	manager := managerparam.InitManager(mysqlDB)
+	admission.NewGroupPolicyWebhooks(manager)
 
-	gitlabGitops, err := gitlablib.New(coreConfig.GitopsRepoConfig.Token, coreConfig.GitopsRepoConfig.URL)
-	if err != nil {
-		panic(err)
-	}
-	// check existence of gitops root group
-	rootGroupPath := coreConfig.GitopsRepoConfig.RootGroupPath
-	rootGroup, err := gitlabGitops.GetGroup(ctx, rootGroupPath)
-	if err != nil {
-		log.Printf("failed to get gitops root group, error: %s, start to create it", err.Error())
-		rootGroup, err = gitlabGitops.CreateGroup(ctx, rootGroupPath, rootGroupPath,
-			nil, coreConfig.GitopsRepoConfig.DefaultVisibility)
+	var (
+		gitopsLib    gitops.Interface
+		gitopsMirror *gitopsdatabase.Mirror
+	)
+	if coreConfig.GitopsRepoConfig.Kind == gitopsdatabase.Kind {
+		// database gitops repos are mirrored to git only if the mirror is configured
+		if coreConfig.GitopsRepoConfig.Mirror != nil {
+			gitopsMirror, err = gitopsdatabase.NewMirror(ctx, manager.GitopsStoreMgr,
+				coreConfig.GitopsRepoConfig.Mirror)
+			if err != nil {
+				panic(err)
+			}
+		}
+		gitopsLib = gitopsdatabase.New(manager.GitopsStoreMgr,
+			coreConfig.GitopsRepoConfig.DefaultBranch, gitopsMirror)
+	} else {
+		gitopsLib, err = gitops.New(ctx, &coreConfig.GitopsRepoConfig)
 		if err != nil {
 			panic(err)
 		}
 	}
+	// check existence of gitops root group, create it if not exists
+	rootGroup, err := gitops.GetRootGroup(ctx, gitopsLib, coreConfig.GitopsRepoConfig.RootGroupPath,
+		coreConfig.GitopsRepoConfig.DefaultVisibility)
+	if err != nil {
+		panic(err)
+	}
 
-	applicationGitRepo, err := gitrepo.NewApplicationGitlabRepo(ctx, gitlabGitops, gitrepo.ApplicationGitRepoConfig{
+	applicationGitRepo, err := gitrepo.NewApplicationGitopsRepo(ctx, gitopsLib, gitrepo.ApplicationGitRepoConfig{
 		RootGroup:         rootGroup,
 		DefaultBranch:     coreConfig.GitopsRepoConfig.DefaultBranch,
 		DefaultVisibility: coreConfig.GitopsRepoConfig.DefaultVisibility,
@@ -357,7 +413,7 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		panic(err)
 	}
 
-	clusterGitRepo, err := clustergitrepo.NewClusterGitlabRepo(ctx, rootGroup, templateRepo, gitlabGitops,
+	clusterGitRepo, err := clustergitrepo.NewClusterGitopsRepo(ctx, rootGroup, templateRepo, gitopsLib,
 		coreConfig.GitopsRepoConfig.DefaultBranch, coreConfig.GitopsRepoConfig.DefaultVisibility)
 	if err != nil {
 		panic(err)
@@ -414,6 +470,11 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		panic(err)
 	}
 
+	oidcService, err := oidc.NewService(coreConfig.Oauth.OIDC, manager)
+	if err != nil {
+		panic(err)
+	}
+
 	autoFreeSvc := service.New(coreConfig.AutoFreeConfig.SupportedEnvs)
 
 	// init build schema controller
@@ -469,6 +530,7 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		TokenSvc:             tokenSvc,
 		RoleService:          roleService,
 		ScopeService:         scopeService,
+		OIDCService:          oidcService,
 		ApplicationGitRepo:   applicationGitRepo,
 		TemplateSchemaGetter: templateSchemaGetter,
 		CD: cd.NewCD(regionInformers, clusterGitRepo, coreConfig.ArgoCDMapper, coreConfig.RegionArgoCDMapper,
@@ -481,15 +543,19 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		GitGetter:      gitGetter,
 		GrafanaService: grafanaService,
 		BuildSchema:    buildSchema,
+		Admit:          admission.Admit,
 	}
 
 	var (
 		authnSkippers = []middleware.Skipper{
 			middleware.MethodAndPathSkipper("*",
 				regexp.MustCompile("(^/apis/front/.*)|(^/health)|(^/metrics)|(^/apis/login)|"+
-					"(^/apis/core/v[12]/roles)|(^/apis/internal/.*)|(^/login/oauth/authorize)|(^/login/oauth/access_token)")),
+					"(^/apis/core/v[12]/roles)|(^/apis/internal/.*)|(^/login/oauth/authorize)|(^/login/oauth/access_token)|"+
+					"(^/login/oauth/device)|(^/login/oauth/userinfo)|(^/login/oauth/introspect)|(^/login/oauth/revoke)|"+
+					"(^/\\.well-known/)")),
 			middleware.MethodAndPathSkipper(http.MethodGet, regexp.MustCompile("^/apis/core/v[12]/idps/endpoints")),
 			middleware.MethodAndPathSkipper(http.MethodGet, regexp.MustCompile("^/apis/core/v[12]/login/callback")),
+			middleware.MethodAndPathSkipper(http.MethodPost, regexp.MustCompile("^/apis/core/v2/login/ldap")),
 			middleware.MethodAndPathSkipper(http.MethodPost, regexp.MustCompile("^/apis/core/v[12]/logout")),
 			middleware.MethodAndPathSkipper(http.MethodPost, regexp.MustCompile("^/apis/core/v[12]/users/login")),
 			middleware.MethodAndPathSkipper(http.MethodGet, regexp.MustCompile("^/apis/core/v[12]/users/self")),
@@ -510,7 +576,7 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		prCtl                = prctl.NewController(coreConfig, parameter)
 		templateCtl          = templatectl.NewController(parameter, templateRepo)
 		roleCtl              = roltctl.NewController(parameter)
-		terminalCtl          = terminalctl.NewController(parameter)
+		terminalCtl          = terminalctl.NewController(coreConfig, parameter)
 		codeGitCtl           = codectl.NewController(gitGetter)
 		tagCtl               = tagctl.NewController(parameter)
 		templateSchemaTagCtl = templateschematagctl.NewController(parameter)
@@ -532,6 +598,19 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		webhookCtl           = webhookctl.NewController(parameter)
 		eventCtl             = eventctl.NewController(parameter)
 		badgeCtl             = badgectl.NewController(parameter)
+		elevationCtl         = elevationctl.NewController(coreConfig, parameter)
+		admissionPolicyCtl   = admissionpolicyctl.NewController(parameter)
+		releasePlanCtl       = releaseplanctl.NewController(coreConfig, parameter, clusterCtl)
+		changeRequestCtl     = changerequestctl.NewController(coreConfig, parameter, clusterCtl)
+		driftCtl             = driftctl.NewController(coreConfig, parameter, regionInformers, templateRepo)
+		canaryCtl            = canaryctl.NewController(coreConfig, parameter, clusterCtl)
+		hpaCtl               = hpactl.NewController(coreConfig, parameter)
+		eventAggregationCtl  = eventaggregationctl.NewController(parameter)
+		auditCtl             = auditctl.NewController(parameter)
+		doraCtl              = doractl.NewController(parameter)
+		buildInsightCtl      = buildinsightctl.NewController(coreConfig, parameter)
+		hibernationCtl       = hibernationctl.NewController(coreConfig, parameter)
+		portForwardCtl       = portforwardctl.NewController(coreConfig, parameter)
 	)
 
 	var (
@@ -557,7 +636,7 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		applicationRegionAPI = applicationregion.NewAPI(applicationRegionCtl)
 		oauthAppAPI          = oauthapp.NewAPI(oauthAppCtl)
 		oauthServerAPI       = oauthserver.NewAPI(oauthServerCtl, oauthAppCtl,
-			coreConfig.Oauth.OauthHTMLLocation, scopeService)
+			coreConfig.Oauth.OauthHTMLLocation, scopeService, oidcService)
 		idpAPI         = idp.NewAPI(idpCtrl, store)
 		accessTokenAPI = accesstoken.NewAPI(accessTokenCtl, roleService, scopeService)
 		scopeAPI       = scope.NewAPI(scopeCtl)
@@ -592,6 +671,19 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		userAPIV2              = userv2.NewAPI(userCtl, store)
 		webhookAPIV2           = webhookv2.NewAPI(webhookCtl)
 		badgeAPIV2             = badge.NewAPI(badgeCtl)
+		elevationAPIV2         = elevationv2.NewAPI(elevationCtl)
+		admissionPolicyAPIV2   = admissionpolicyv2.NewAPI(admissionPolicyCtl)
+		releasePlanAPIV2       = releaseplanv2.NewAPI(releasePlanCtl)
+		changeRequestAPIV2     = changerequestv2.NewAPI(changeRequestCtl)
+		driftAPIV2             = driftv2.NewAPI(driftCtl)
+		canaryAPIV2            = canaryv2.NewAPI(canaryCtl)
+		hpaAPIV2               = hpav2.NewAPI(hpaCtl)
+		eventAggregationAPIV2  = eventaggregationv2.NewAPI(eventAggregationCtl)
+		auditAPIV2             = auditv2.NewAPI(auditCtl)
+		doraAPIV2              = dorav2.NewAPI(doraCtl)
+		buildInsightAPIV2      = buildinsightv2.NewAPI(buildInsightCtl)
+		hibernationAPIV2       = hibernationv2.NewAPI(hibernationCtl)
+		portForwardAPIV2       = portforwardv2.NewAPI(portForwardCtl)
 	)
 
 	// start jobs
@@ -604,9 +696,74 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 	grafanaSyncJob := func(ctx context.Context) {
 		grafanasync.Run(ctx, coreConfig, manager, client)
 	}
-	k8seventJob := k8sevent.New(coreConfig.KubernetesEvent, regionInformers, manager, mysqlDB)
-	go jobs.Run(ctx, &coreConfig.JobConfig, eventHandlerJob, webhookJob,
-		k8seventJob.Run, cleaner.Run, autoFreeJob, grafanaSyncJob)
+	k8seventJob := k8sevent.New(coreConfig.KubernetesEvent, regionInformers, manager)
+	releasePlanJob := func(ctx context.Context) {
+		releaseplanjob.Run(ctx, &coreConfig.ReleasePlanConfig, manager.ReleasePlanMgr, releasePlanCtl)
+	}
+	backgroundJobs := []jobs.Job{eventHandlerJob, webhookJob,
+		k8seventJob.Run, cleaner.Run, autoFreeJob, grafanaSyncJob, releasePlanJob}
+	if coreConfig.ElevationConfig.AccountID != 0 {
+		elevationJob := func(ctx context.Context) {
+			elevationjob.Run(ctx, &coreConfig.ElevationConfig, manager.UserMgr,
+				manager.ElevationMgr, elevationCtl)
+		}
+		backgroundJobs = append(backgroundJobs, elevationJob)
+	} else {
+		log.Printf("elevation.accountID is not configured, elevations cannot be approved")
+	}
+	if gitopsMirror != nil {
+		gitopsMirrorJob := func(ctx context.Context) {
+			gitopsmirror.Run(ctx, coreConfig.GitopsRepoConfig.Mirror, manager.GitopsStoreMgr, gitopsMirror)
+		}
+		backgroundJobs = append(backgroundJobs, gitopsMirrorJob)
+	}
+	if coreConfig.DriftConfig.JobInterval > 0 {
+		driftJob := func(ctx context.Context) {
+			driftjob.Run(ctx, &coreConfig.DriftConfig, manager.UserMgr, manager.ClusterMgr, driftCtl)
+		}
+		backgroundJobs = append(backgroundJobs, driftJob)
+	}
+	if coreConfig.CanaryConfig.JobInterval > 0 {
+		canaryJob := func(ctx context.Context) {
+			canaryjob.Run(ctx, &coreConfig.CanaryConfig, manager.CanaryMgr, canaryCtl)
+		}
+		backgroundJobs = append(backgroundJobs, canaryJob)
+	}
+	if coreConfig.HPAConfig.JobInterval > 0 {
+		hpaJob := func(ctx context.Context) {
+			hpajob.Run(ctx, &coreConfig.HPAConfig, hpaCtl)
+		}
+		backgroundJobs = append(backgroundJobs, hpaJob)
+	}
+	if coreConfig.HibernationConfig.JobInterval > 0 {
+		hibernationJob := func(ctx context.Context) {
+			hibernationjob.Run(ctx, &coreConfig.HibernationConfig, manager.UserMgr, hibernationCtl)
+		}
+		backgroundJobs = append(backgroundJobs, hibernationJob)
+	}
+	if coreConfig.LDAPSyncConfig.JobInterval > 0 {
+		ldapSyncJob := func(ctx context.Context) {
+			ldapsyncjob.Run(ctx, &coreConfig.LDAPSyncConfig, manager.UserMgr, idpCtrl)
+		}
+		backgroundJobs = append(backgroundJobs, ldapSyncJob)
+	}
+	if coreConfig.BuildInsightConfig.JobInterval > 0 {
+		buildInsightJob := func(ctx context.Context) {
+			buildinsightjob.Run(ctx, &coreConfig.BuildInsightConfig, buildInsightCtl)
+		}
+		backgroundJobs = append(backgroundJobs, buildInsightJob)
+	}
+	auditExporters, err := auditexporter.New(coreConfig.AuditConfig)
+	if err != nil {
+		panic(err)
+	}
+	if coreConfig.AuditConfig.Enabled && coreConfig.AuditConfig.AnchorInterval > 0 {
+		auditAnchorJob := func(ctx context.Context) {
+			auditanchorjob.Run(ctx, &coreConfig.AuditConfig, manager.AuditMgr, auditExporters)
+		}
+		backgroundJobs = append(backgroundJobs, auditAnchorJob)
+	}
+	go jobs.Run(ctx, &coreConfig.JobConfig, backgroundJobs...)
 
 	// init server
 	r := gin.New()
@@ -629,13 +786,20 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/apis/front/v1/terminal")),
 			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/apis/front/v2/buildschema")),
 			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/login/oauth/access_token")),
+			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/login/oauth/device/code")),
+			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/login/oauth/userinfo")),
+			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/login/oauth/introspect")),
+			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/login/oauth/revoke")),
+			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/\\.well-known/")),
 			middleware.MethodAndPathSkipper("*", regexp.MustCompile("^/apis/internal/v2/.*")),
 			middleware.MethodAndPathSkipper(http.MethodGet, regexp.MustCompile("^/apis/core/v[12]/idps/endpoints")),
 			middleware.MethodAndPathSkipper(http.MethodPost, regexp.MustCompile("^/apis/core/v[12]/users/login"))),
 		prehandlemiddle.Middleware(r, manager),
+		// audit middleware, record mutating api calls and sensitive reads, including the denied ones
+		auditmiddle.Middleware(coreConfig.AuditConfig, manager.AuditMgr, auditExporters, r),
 		auth.Middleware(rbacAuthorizer, authzSkippers...),
 		tagmiddle.Middleware(),
-		admissionmiddle.Middleware(authzSkippers...),
+		admissionmiddle.Middleware(parameter.EventSvc, authzSkippers...),
 	}
 	r.Use(middlewares...)
 
@@ -705,6 +869,19 @@ func Init(ctx context.Context, flags *Flags, coreConfig *config.Config) {
 		userAPIV2,
 		webhookAPIV2,
 		badgeAPIV2,
+		elevationAPIV2,
+		admissionPolicyAPIV2,
+		releasePlanAPIV2,
+		changeRequestAPIV2,
+		driftAPIV2,
+		canaryAPIV2,
+		hpaAPIV2,
+		eventAggregationAPIV2,
+		auditAPIV2,
+		doraAPIV2,
+		buildInsightAPIV2,
+		hibernationAPIV2,
+		portForwardAPIV2,
 	}
 
 	// start cloud event server
@@ -32,6 +32,7 @@ const (
 	EventType = "eventType"
 	WebhookID = "webhookID"
 	CreatedAt = "createdAt"
+	StartTime = "startTime"
 	Enabled   = "enabled"
 	Orphaned  = "orphaned"
 	OrderBy   = "orderBy"
@@ -110,6 +111,25 @@ const (
 	ResourceWebhookLog = "webhooklogs"
 
 	ResourceMember = "members"
+
+	// ResourceElevation currently elevations do not have direct member info, will
+	// use the member info of the resources that they are requested for
+	ResourceElevation = "elevations"
+
+	// ResourceAdmissionPolicy currently admission policies do not have direct member info, will
+	// use the member info of the groups that they are stored in
+	ResourceAdmissionPolicy = "admissionpolicies"
+
+	// ResourceReleasePlan currently release plans do not have direct member info, will
+	// use the member info of the applications that they belong to
+	ResourceReleasePlan = "releaseplans"
+
+	// ResourceChangeRequest currently change requests do not have direct member info, will
+	// use the member info of the clusters that they belong to
+	ResourceChangeRequest = "changerequests"
+
+	// ResourceUser users do not have member info, events of them are only for auditing
+	ResourceUser = "users"
 )
 
 const (
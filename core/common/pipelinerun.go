@@ -9,4 +9,6 @@ const (
 	MessagePipelinerunExecuted  = "executed pipelinerun"
 	MessagePipelinerunCancelled = "cancelled pipelinerun"
 	MessagePipelinerunReady     = "marked pipelinerun as ready to execute"
+	MessagePipelinerunApproved  = "approved pipelinerun"
+	MessagePipelinerunRejected  = "rejected pipelinerun"
 )
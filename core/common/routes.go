@@ -22,4 +22,5 @@ const (
 	URLOauthAuthorization = "/login/oauth/authorize"
 
 	URLLoginCallback = "/apis/core/v1/login/callback"
+	URLLoginLDAP     = "/apis/core/v2/login/ldap"
 )
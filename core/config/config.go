@@ -16,30 +16,47 @@ package config
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/horizoncd/horizon/pkg/config/admission"
+	"github.com/horizoncd/horizon/pkg/config/approval"
 	"github.com/horizoncd/horizon/pkg/config/argocd"
+	"github.com/horizoncd/horizon/pkg/config/audit"
 	"github.com/horizoncd/horizon/pkg/config/authenticate"
 	"github.com/horizoncd/horizon/pkg/config/autofree"
+	"github.com/horizoncd/horizon/pkg/config/buildinsight"
+	"github.com/horizoncd/horizon/pkg/config/canary"
+	"github.com/horizoncd/horizon/pkg/config/changerequest"
 	"github.com/horizoncd/horizon/pkg/config/clean"
 	"github.com/horizoncd/horizon/pkg/config/db"
+	"github.com/horizoncd/horizon/pkg/config/drift"
+	"github.com/horizoncd/horizon/pkg/config/elevation"
 	"github.com/horizoncd/horizon/pkg/config/eventhandler"
 	"github.com/horizoncd/horizon/pkg/config/git"
 	"github.com/horizoncd/horizon/pkg/config/gitlab"
 	"github.com/horizoncd/horizon/pkg/config/grafana"
+	"github.com/horizoncd/horizon/pkg/config/hibernation"
+	"github.com/horizoncd/horizon/pkg/config/hpa"
 	"github.com/horizoncd/horizon/pkg/config/job"
 	"github.com/horizoncd/horizon/pkg/config/k8sevent"
+	"github.com/horizoncd/horizon/pkg/config/ldapsync"
 	"github.com/horizoncd/horizon/pkg/config/oauth"
+	"github.com/horizoncd/horizon/pkg/config/portforward"
 	"github.com/horizoncd/horizon/pkg/config/pprof"
 	"github.com/horizoncd/horizon/pkg/config/redis"
+	"github.com/horizoncd/horizon/pkg/config/releaseplan"
 	"github.com/horizoncd/horizon/pkg/config/server"
 	"github.com/horizoncd/horizon/pkg/config/session"
 	"github.com/horizoncd/horizon/pkg/config/tekton"
 	"github.com/horizoncd/horizon/pkg/config/template"
 	"github.com/horizoncd/horizon/pkg/config/templaterepo"
+	"github.com/horizoncd/horizon/pkg/config/terminal"
 	"github.com/horizoncd/horizon/pkg/config/token"
 	"github.com/horizoncd/horizon/pkg/config/webhook"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
 
 	"gopkg.in/yaml.v3"
 )
@@ -70,6 +87,19 @@ type Config struct {
 	KubernetesEvent        k8sevent.Config         `yaml:"kubernetesEvent"`
 	Clean                  clean.Config            `yaml:"clean"`
 	Admission              admission.Admission     `yaml:"admission"`
+	ElevationConfig        elevation.Config        `yaml:"elevation"`
+	ReleasePlanConfig      releaseplan.Config      `yaml:"releasePlan"`
+	ChangeRequestConfig    changerequest.Config    `yaml:"changeRequest"`
+	PRApprovalConfig       approval.Config         `yaml:"pipelinerunApproval"`
+	DriftConfig            drift.Config            `yaml:"drift"`
+	CanaryConfig           canary.Config           `yaml:"canary"`
+	HPAConfig              hpa.Config              `yaml:"hpa"`
+	HibernationConfig      hibernation.Config      `yaml:"hibernation"`
+	PortForwardConfig      portforward.Config      `yaml:"portForward"`
+	TerminalConfig         terminal.Config         `yaml:"terminal"`
+	LDAPSyncConfig         ldapsync.Config         `yaml:"ldapSync"`
+	AuditConfig            audit.Config            `yaml:"audit"`
+	BuildInsightConfig     buildinsight.Config     `yaml:"buildInsight"`
 }
 
 func LoadConfig(configFilePath string) (*Config, error) {
@@ -110,6 +140,31 @@ func LoadConfig(configFilePath string) (*Config, error) {
 	}
 	config.TektonMapper = newTektonMapper
 
+	if config.GitopsRepoConfig.Kind == "" {
+		config.GitopsRepoConfig.Kind = "gitlab"
+	}
+	if mirror := config.GitopsRepoConfig.Mirror; mirror != nil {
+		if mirror.Kind == "" {
+			mirror.Kind = "gitlab"
+		}
+		if mirror.Username == "" {
+			mirror.Username = "oauth2"
+		}
+		if mirror.CacheDir == "" {
+			mirror.CacheDir = filepath.Join(os.TempDir(), "horizon-gitops-mirror")
+		}
+		if mirror.JobInterval <= 0 {
+			mirror.JobInterval = 5 * time.Second
+		}
+		if mirror.BatchSize <= 0 {
+			mirror.BatchSize = 50
+		}
+	}
+
+	if config.Oauth.OIDC.IDTokenExpireIn <= 0 {
+		config.Oauth.OIDC.IDTokenExpireIn = time.Hour
+	}
+
 	if config.EventHandlerConfig.BatchEventsCount <= 0 {
 		config.EventHandlerConfig.BatchEventsCount = 5
 	}
@@ -132,5 +187,74 @@ func LoadConfig(configFilePath string) (*Config, error) {
 		config.WebhookConfig.ResponseBodyTruncateSize = 16384
 	}
 
+	if config.ElevationConfig.MaxDuration <= 0 {
+		config.ElevationConfig.MaxDuration = 24 * time.Hour
+	}
+	if config.ElevationConfig.JobInterval <= 0 {
+		config.ElevationConfig.JobInterval = time.Minute
+	}
+	if config.ElevationConfig.BatchSize <= 0 {
+		config.ElevationConfig.BatchSize = 50
+	}
+
+	if config.ReleasePlanConfig.HealthTimeout <= 0 {
+		config.ReleasePlanConfig.HealthTimeout = 30 * time.Minute
+	}
+	if config.ReleasePlanConfig.JobInterval <= 0 {
+		config.ReleasePlanConfig.JobInterval = 10 * time.Second
+	}
+	if config.ReleasePlanConfig.BatchSize <= 0 {
+		config.ReleasePlanConfig.BatchSize = 50
+	}
+	for _, rule := range config.ChangeRequestConfig.Environments {
+		if rule != nil && rule.RequiredApprovals <= 0 {
+			rule.RequiredApprovals = 1
+		}
+	}
+	for _, policy := range config.PRApprovalConfig.Environments {
+		if policy == nil {
+			continue
+		}
+		if policy.RequiredApprovals <= 0 {
+			policy.RequiredApprovals = 1
+		}
+		if policy.Role == "" {
+			policy.Role = role.Maintainer
+		}
+	}
+	if config.DriftConfig.BatchSize <= 0 {
+		config.DriftConfig.BatchSize = 50
+	}
+	if config.DriftConfig.BadgeSvgLink == "" {
+		config.DriftConfig.BadgeSvgLink = "https://img.shields.io/badge/config-drifted-orange"
+	}
+	if config.CanaryConfig.DefaultInterval <= 0 {
+		config.CanaryConfig.DefaultInterval = 5 * time.Minute
+	}
+	if config.CanaryConfig.MaxErrors <= 0 {
+		config.CanaryConfig.MaxErrors = 3
+	}
+	if config.HPAConfig.MaxOverrideDuration <= 0 {
+		config.HPAConfig.MaxOverrideDuration = 7 * 24 * time.Hour
+	}
+	if config.HibernationConfig.BatchSize <= 0 {
+		config.HibernationConfig.BatchSize = 50
+	}
+	if config.PortForwardConfig.ProxyIdleTimeout <= 0 {
+		config.PortForwardConfig.ProxyIdleTimeout = 10 * time.Minute
+	}
+	if config.TerminalConfig.MaxRecordingSize <= 0 {
+		config.TerminalConfig.MaxRecordingSize = 10 * 1024 * 1024
+	}
+	if config.BuildInsightConfig.RecentWindow <= 0 {
+		config.BuildInsightConfig.RecentWindow = 7 * 24 * time.Hour
+	}
+	if config.BuildInsightConfig.BaselineWindow <= 0 {
+		config.BuildInsightConfig.BaselineWindow = 30 * 24 * time.Hour
+	}
+	if config.BuildInsightConfig.Threshold <= 0 {
+		config.BuildInsightConfig.Threshold = 0.5
+	}
+
 	return &config, nil
 }
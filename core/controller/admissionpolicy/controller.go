@@ -0,0 +1,139 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"context"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/admission"
+	admissionmanager "github.com/horizoncd/horizon/pkg/admission/manager"
+	admissionmodels "github.com/horizoncd/horizon/pkg/admission/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Controller interface {
+	// CreatePolicy stores a policy in the group, it applies to all resources in the group and its subgroups
+	CreatePolicy(ctx context.Context, groupID uint, request *CreateOrUpdatePolicyRequest) (*Policy, error)
+	// ListPolicies lists the policies stored directly in the group
+	ListPolicies(ctx context.Context, groupID uint) ([]*Policy, error)
+	GetPolicy(ctx context.Context, id uint) (*Policy, error)
+	UpdatePolicy(ctx context.Context, id uint, request *CreateOrUpdatePolicyRequest) (*Policy, error)
+	DeletePolicy(ctx context.Context, id uint) error
+}
+
+type controller struct {
+	policyMgr admissionmanager.Manager
+	groupMgr  groupmanager.Manager
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(param *param.Param) Controller {
+	return &controller{
+		policyMgr: param.AdmissionPolicyMgr,
+		groupMgr:  param.GroupMgr,
+	}
+}
+
+func (c *controller) CreatePolicy(ctx context.Context, groupID uint,
+	request *CreateOrUpdatePolicyRequest) (*Policy, error) {
+	const op = "admission policy controller: create"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := c.groupMgr.GetByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+	policy, err := validatePolicy(request)
+	if err != nil {
+		return nil, err
+	}
+	policy.GroupID = groupID
+	policy, err = c.policyMgr.Create(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	return ofPolicyModel(policy), nil
+}
+
+func (c *controller) ListPolicies(ctx context.Context, groupID uint) ([]*Policy, error) {
+	const op = "admission policy controller: list"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	policies, err := c.policyMgr.ListByGroupIDs(ctx, []uint{groupID})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Policy, 0, len(policies))
+	for _, policy := range policies {
+		result = append(result, ofPolicyModel(policy))
+	}
+	return result, nil
+}
+
+func (c *controller) GetPolicy(ctx context.Context, id uint) (*Policy, error) {
+	const op = "admission policy controller: get"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	policy, err := c.policyMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ofPolicyModel(policy), nil
+}
+
+func (c *controller) UpdatePolicy(ctx context.Context, id uint,
+	request *CreateOrUpdatePolicyRequest) (*Policy, error) {
+	const op = "admission policy controller: update"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := c.policyMgr.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	policy, err := validatePolicy(request)
+	if err != nil {
+		return nil, err
+	}
+	policy, err = c.policyMgr.UpdateByID(ctx, id, policy)
+	if err != nil {
+		return nil, err
+	}
+	return ofPolicyModel(policy), nil
+}
+
+func (c *controller) DeletePolicy(ctx context.Context, id uint) error {
+	const op = "admission policy controller: delete"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := c.policyMgr.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return c.policyMgr.DeleteByID(ctx, id)
+}
+
+// validatePolicy compiles the policy to report errors before it is stored
+func validatePolicy(request *CreateOrUpdatePolicyRequest) (*admissionmodels.Policy, error) {
+	if request.Name == "" {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "policy name should not be empty")
+	}
+	compiled, err := admission.CompilePolicy(request.Policy)
+	if err != nil {
+		return nil, err
+	}
+	return toPolicyModel(compiled.Config())
+}
@@ -0,0 +1,72 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"encoding/json"
+	"time"
+
+	admissionmodels "github.com/horizoncd/horizon/pkg/admission/models"
+	admissionconfig "github.com/horizoncd/horizon/pkg/config/admission"
+)
+
+// CreateOrUpdatePolicyRequest declares a policy written in CEL, see admissionconfig.Policy for details
+type CreateOrUpdatePolicyRequest struct {
+	admissionconfig.Policy
+}
+
+type Policy struct {
+	ID      uint `json:"id"`
+	GroupID uint `json:"groupID"`
+	admissionconfig.Policy
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func ofPolicyModel(policy *admissionmodels.Policy) *Policy {
+	var rules []admissionconfig.Rule
+	_ = json.Unmarshal([]byte(policy.Rules), &rules)
+	return &Policy{
+		ID:      policy.ID,
+		GroupID: policy.GroupID,
+		Policy: admissionconfig.Policy{
+			Name:          policy.Name,
+			Kind:          policy.Kind,
+			Mode:          admissionconfig.PolicyMode(policy.Mode),
+			FailurePolicy: admissionconfig.FailurePolicy(policy.FailurePolicy),
+			Rules:         rules,
+			Expression:    policy.Expression,
+			Message:       policy.Message,
+		},
+		CreatedAt: policy.CreatedAt,
+		UpdatedAt: policy.UpdatedAt,
+	}
+}
+
+func toPolicyModel(policy admissionconfig.Policy) (*admissionmodels.Policy, error) {
+	rules, err := json.Marshal(policy.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return &admissionmodels.Policy{
+		Name:          policy.Name,
+		Kind:          policy.Kind,
+		Mode:          string(policy.Mode),
+		FailurePolicy: string(policy.FailurePolicy),
+		Rules:         string(rules),
+		Expression:    policy.Expression,
+		Message:       policy.Message,
+	}, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/audit/manager"
+	"github.com/horizoncd/horizon/pkg/audit/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+// _exportBatchSize is the number of logs loaded at a time when exporting
+const _exportBatchSize = 500
+
+// Controller serves the audit logs to admins only
+type Controller interface {
+	// List lists the audit logs matching the query, the latest first
+	List(ctx context.Context, query *models.Query, page *q.Query) ([]*models.Log, int64, error)
+	// Export writes the audit logs matching the query to the writer as json lines, the earliest first
+	Export(ctx context.Context, query *models.Query, w io.Writer) error
+	// Verify walks the hash chain of the audit logs to detect modifications and deletions
+	Verify(ctx context.Context) (*models.Verification, error)
+}
+
+type controller struct {
+	auditMgr manager.Manager
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(param *param.Param) Controller {
+	return &controller{
+		auditMgr: param.AuditMgr,
+	}
+}
+
+func (c *controller) List(ctx context.Context, query *models.Query,
+	page *q.Query) ([]*models.Log, int64, error) {
+	const op = "audit controller: list"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if err := checkAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+	return c.auditMgr.List(ctx, query, page)
+}
+
+func (c *controller) Export(ctx context.Context, query *models.Query, w io.Writer) error {
+	const op = "audit controller: export"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if err := checkAdmin(ctx); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	var afterID uint
+	for {
+		logs, err := c.auditMgr.ListAfter(ctx, query, afterID, _exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if err := encoder.Encode(log); err != nil {
+				return err
+			}
+			afterID = log.ID
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(logs) < _exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func (c *controller) Verify(ctx context.Context) (*models.Verification, error) {
+	const op = "audit controller: verify"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if err := checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return c.auditMgr.Verify(ctx)
+}
+
+func checkAdmin(ctx context.Context) error {
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !currentUser.IsAdmin() {
+		return perror.Wrap(herrors.ErrForbidden, "only admins can access audit logs")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinsight
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	"github.com/horizoncd/horizon/lib/q"
+	applicationmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	"github.com/horizoncd/horizon/pkg/config/buildinsight"
+	eventmanager "github.com/horizoncd/horizon/pkg/event/manager"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/pr/pipeline/insight"
+	pipelinemanager "github.com/horizoncd/horizon/pkg/pr/pipeline/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Query struct {
+	Start time.Time
+	End   time.Time
+	// Step splits the range into series, no series if 0
+	Step time.Duration
+}
+
+type BuildInsights struct {
+	*insight.Report
+	// Regressions are the steps regressed in the recent window ending at the end of the query
+	Regressions []*insight.Regression `json:"regressions"`
+}
+
+type Controller interface {
+	// Get computes the build insights of the application
+	Get(ctx context.Context, applicationID uint, query *Query) (*BuildInsights, error)
+	// DetectRegressions detects the regressed build steps of the applications built recently,
+	// and alerts each regressed step once per recent window
+	DetectRegressions(ctx context.Context) error
+}
+
+type controller struct {
+	config         *buildinsight.Config
+	applicationMgr applicationmanager.Manager
+	pipelineMgr    pipelinemanager.Manager
+	eventMgr       eventmanager.Manager
+	eventSvc       eventservice.Service
+}
+
+type alertKey struct {
+	pipeline, task, step string
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param) Controller {
+	return &controller{
+		config:         &config.BuildInsightConfig,
+		applicationMgr: param.ApplicationMgr,
+		pipelineMgr:    param.PipelineMgr,
+		eventMgr:       param.EventMgr,
+		eventSvc:       param.EventSvc,
+	}
+}
+
+func (c *controller) Get(ctx context.Context, applicationID uint, query *Query) (*BuildInsights, error) {
+	const op = "build insight controller: get"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	application, err := c.applicationMgr.GetByID(ctx, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := c.pipelineMgr.ListSteps(ctx, application.Name, query.Start, query.End)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := c.pipelineMgr.ListTasks(ctx, application.Name, query.Start, query.End)
+	if err != nil {
+		return nil, err
+	}
+	regressions, err := c.regressions(ctx, application.Name, query.End)
+	if err != nil {
+		return nil, err
+	}
+	return &BuildInsights{
+		Report:      insight.Compute(steps, tasks, query.Start, query.End, query.Step),
+		Regressions: regressions,
+	}, nil
+}
+
+func (c *controller) DetectRegressions(ctx context.Context) error {
+	const op = "build insight controller: detect regressions"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	now := time.Now()
+	applications, err := c.pipelineMgr.ListApplications(ctx, now.Add(-c.config.RecentWindow))
+	if err != nil {
+		return err
+	}
+	for _, name := range applications {
+		regressions, err := c.regressions(ctx, name, now)
+		if err != nil {
+			log.WithFiled(ctx, "op", op).
+				Errorf("failed to detect build regressions of application %s, err: %v", name, err.Error())
+			continue
+		}
+		if len(regressions) == 0 {
+			continue
+		}
+		application, err := c.applicationMgr.GetByName(ctx, name)
+		if err != nil {
+			log.WithFiled(ctx, "op", op).
+				Warningf("failed to get application %s, err: %v", name, err.Error())
+			continue
+		}
+		alerted, err := c.alerted(ctx, application.ID, now.Add(-c.config.RecentWindow))
+		if err != nil {
+			log.WithFiled(ctx, "op", op).
+				Errorf("failed to list build regression alerts of application %s, err: %v", name, err.Error())
+			continue
+		}
+		for _, regression := range regressions {
+			if alerted[alertKey{regression.Pipeline, regression.Task, regression.Step}] {
+				continue
+			}
+			extra, err := json.Marshal(regression)
+			if err != nil {
+				continue
+			}
+			extraStr := string(extra)
+			c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceApplication, application.ID,
+				eventmodels.ApplicationBuildSlow, &extraStr)
+		}
+	}
+	return nil
+}
+
+// regressions compares the steps of the application in the recent window ending at end with the baseline window
+func (c *controller) regressions(ctx context.Context, application string,
+	end time.Time) ([]*insight.Regression, error) {
+	recentStart := end.Add(-c.config.RecentWindow)
+	baselineStart := recentStart.Add(-c.config.BaselineWindow)
+	baseline, err := c.pipelineMgr.ListSteps(ctx, application, baselineStart, recentStart)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := c.pipelineMgr.ListSteps(ctx, application, recentStart, end)
+	if err != nil {
+		return nil, err
+	}
+	return insight.DetectRegressions(baseline, recent, insight.RegressionOptions{
+		Threshold:   c.config.Threshold,
+		MinSamples:  c.config.MinSamples,
+		MinDuration: c.config.MinDuration,
+	}), nil
+}
+
+// alerted returns the steps of the application already alerted since the given time,
+// the alerts are read back from the events so that they survive restarts
+func (c *controller) alerted(ctx context.Context, applicationID uint,
+	since time.Time) (map[alertKey]bool, error) {
+	events, err := c.eventMgr.ListEvents(ctx, &q.Query{
+		Keywords: q.KeyWords{
+			common.ParamResourceType: common.ResourceApplication,
+			common.ParamResourceID:   applicationID,
+			common.EventType:         eventmodels.ApplicationBuildSlow,
+			common.StartTime:         since,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	alerted := make(map[alertKey]bool, len(events))
+	for _, event := range events {
+		if event.Extra == nil {
+			continue
+		}
+		var regression insight.Regression
+		if err := json.Unmarshal([]byte(*event.Extra), &regression); err != nil {
+			continue
+		}
+		alerted[alertKey{regression.Pipeline, regression.Task, regression.Step}] = true
+	}
+	return alerted, nil
+}
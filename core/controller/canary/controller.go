@@ -0,0 +1,392 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	appmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	canarymanager "github.com/horizoncd/horizon/pkg/canary/manager"
+	"github.com/horizoncd/horizon/pkg/canary/metrics"
+	"github.com/horizoncd/horizon/pkg/canary/models"
+	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	canaryconfig "github.com/horizoncd/horizon/pkg/config/canary"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
+	trmanager "github.com/horizoncd/horizon/pkg/templaterelease/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+	"github.com/horizoncd/horizon/pkg/workload/rollout"
+)
+
+const (
+	_actionPromote = "promote"
+	_actionAbort   = "abort"
+)
+
+type Controller interface {
+	// GetCanaryAnalysis gets the canary analysis config of the cluster
+	GetCanaryAnalysis(ctx context.Context, clusterID uint) (*CanaryAnalysis, error)
+	// UpdateCanaryAnalysis creates or replaces the canary analysis config of the cluster
+	UpdateCanaryAnalysis(ctx context.Context, clusterID uint,
+		request *UpdateCanaryAnalysisRequest) (*CanaryAnalysis, error)
+	// ListCanaryAnalysisRuns lists the analyses of canary steps deployed by the pipelinerun
+	ListCanaryAnalysisRuns(ctx context.Context, pipelinerunID uint) ([]*CanaryAnalysisRun, error)
+	// AnalyzeCluster observes the canary step the cluster is paused at, once the interval elapses,
+	// the canary is promoted if all the metrics are within thresholds, otherwise it is aborted
+	AnalyzeCluster(ctx context.Context, clusterID uint) error
+}
+
+type controller struct {
+	config             *canaryconfig.Config
+	canaryMgr          canarymanager.Manager
+	applicationMgr     appmanager.Manager
+	clusterMgr         clustermanager.Manager
+	regionMgr          regionmanager.Manager
+	templateReleaseMgr trmanager.Manager
+	prMgr              *prmanager.PRManager
+	clusterGitRepo     gitrepo.ClusterGitRepo
+	clusterCtl         clusterctl.Controller
+	querier            metrics.Querier
+	eventSvc           eventservice.Service
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param, clusterCtl clusterctl.Controller) Controller {
+	return &controller{
+		config:             &config.CanaryConfig,
+		canaryMgr:          param.CanaryMgr,
+		applicationMgr:     param.ApplicationMgr,
+		clusterMgr:         param.ClusterMgr,
+		regionMgr:          param.RegionMgr,
+		templateReleaseMgr: param.TemplateReleaseMgr,
+		prMgr:              param.PRMgr,
+		clusterGitRepo:     param.ClusterGitRepo,
+		clusterCtl:         clusterCtl,
+		querier:            metrics.NewQuerier(),
+		eventSvc:           param.EventSvc,
+	}
+}
+
+func (c *controller) GetCanaryAnalysis(ctx context.Context, clusterID uint) (*CanaryAnalysis, error) {
+	const op = "canary controller: get analysis"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	analysis, err := c.canaryMgr.GetAnalysisByClusterID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return ofAnalysisModel(analysis)
+}
+
+func (c *controller) UpdateCanaryAnalysis(ctx context.Context, clusterID uint,
+	request *UpdateCanaryAnalysisRequest) (*CanaryAnalysis, error) {
+	const op = "canary controller: update analysis"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := c.clusterMgr.GetByID(ctx, clusterID); err != nil {
+		return nil, err
+	}
+	if err := validateMetrics(request.Enabled, request.Metrics); err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(request.Metrics)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	analysis, err := c.canaryMgr.UpsertAnalysis(ctx, &models.Analysis{
+		ClusterID: clusterID,
+		Enabled:   request.Enabled,
+		Interval:  request.Interval,
+		Metrics:   string(content),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ofAnalysisModel(analysis)
+}
+
+func validateMetrics(enabled bool, metrics []*models.Metric) error {
+	if enabled && len(metrics) == 0 {
+		return perror.Wrap(herrors.ErrParamInvalid, "metrics cannot be empty when analysis is enabled")
+	}
+	names := make(map[string]bool)
+	for _, metric := range metrics {
+		if metric == nil || metric.Name == "" {
+			return perror.Wrap(herrors.ErrParamInvalid, "name of metric cannot be empty")
+		}
+		if names[metric.Name] {
+			return perror.Wrapf(herrors.ErrParamInvalid, "metric %s is duplicated", metric.Name)
+		}
+		names[metric.Name] = true
+		if strings.TrimSpace(metric.Query) == "" {
+			return perror.Wrapf(herrors.ErrParamInvalid, "query of metric %s cannot be empty", metric.Name)
+		}
+		if _, err := template.New(metric.Name).Parse(metric.Query); err != nil {
+			return perror.Wrapf(herrors.ErrParamInvalid, "query of metric %s is invalid: %v", metric.Name, err)
+		}
+		if metric.Min == nil && metric.Max == nil {
+			return perror.Wrapf(herrors.ErrParamInvalid, "thresholds of metric %s cannot be empty", metric.Name)
+		}
+		if metric.Min != nil && metric.Max != nil && *metric.Min > *metric.Max {
+			return perror.Wrapf(herrors.ErrParamInvalid, "min of metric %s is greater than max", metric.Name)
+		}
+	}
+	return nil
+}
+
+func (c *controller) ListCanaryAnalysisRuns(ctx context.Context, pipelinerunID uint) ([]*CanaryAnalysisRun, error) {
+	const op = "canary controller: list analysis runs"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := c.prMgr.PipelineRun.GetByID(ctx, pipelinerunID); err != nil {
+		return nil, err
+	}
+	runs, err := c.canaryMgr.ListRunsByPipelinerunID(ctx, pipelinerunID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*CanaryAnalysisRun, 0, len(runs))
+	for _, run := range runs {
+		r, err := ofAnalysisRunModel(run)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, r)
+	}
+	return resp, nil
+}
+
+func (c *controller) AnalyzeCluster(ctx context.Context, clusterID uint) error {
+	const op = "canary controller: analyze cluster"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	analysis, err := c.canaryMgr.GetAnalysisByClusterID(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	if !analysis.Enabled {
+		return nil
+	}
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Status != common.ClusterStatusEmpty {
+		return nil
+	}
+
+	// 1. find the canary step which the pipelinerun is paused at,
+	// the canary paused manually is left to users
+	pipelinerun, err := c.prMgr.PipelineRun.GetLatestByClusterIDAndActions(ctx, clusterID,
+		prmodels.ActionBuildDeploy, prmodels.ActionDeploy, prmodels.ActionRollback, prmodels.ActionRestart)
+	if err != nil || pipelinerun == nil {
+		return err
+	}
+	step, err := c.clusterCtl.GetStep(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	if step.Total == 0 || step.Index >= step.Total || step.ManualPaused || !step.Paused {
+		return nil
+	}
+
+	// 2. observe the step until the interval elapses
+	run, err := c.canaryMgr.GetRun(ctx, pipelinerun.ID, step.Index)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return err
+		}
+		_, err = c.canaryMgr.CreateRun(ctx, &models.AnalysisRun{
+			ClusterID:     clusterID,
+			PipelinerunID: pipelinerun.ID,
+			Step:          step.Index,
+			Status:        models.RunStatusAnalyzing,
+			StartedAt:     time.Now(),
+		})
+		return err
+	}
+	interval := c.interval(analysis)
+	if run.Status != models.RunStatusAnalyzing || time.Since(run.StartedAt) < interval {
+		return nil
+	}
+
+	// 3. promote or abort the canary according to the metrics
+	var metrics []*models.Metric
+	if err := json.Unmarshal([]byte(analysis.Metrics), &metrics); err != nil {
+		return err
+	}
+	results, passed, err := c.evaluate(ctx, cluster, metrics, interval)
+	content, marshalErr := json.Marshal(results)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	run.Results = string(content)
+	if err != nil {
+		// the analysis is inconclusive, retry it by the next job until it fails too many times in a row
+		log.Warningf(ctx, "failed to analyze canary of cluster %s: %v", cluster.Name, err)
+		run.Message = err.Error()
+		run.Errors++
+		if run.Errors < c.config.MaxErrors {
+			return c.canaryMgr.UpdateRun(ctx, run)
+		}
+	} else {
+		run.Message = ""
+		run.Errors = 0
+	}
+	action, eventType := _actionPromote, eventmodels.ClusterCanaryPromoted
+	run.Status = models.RunStatusSucceeded
+	if !passed {
+		action, eventType = _actionAbort, eventmodels.ClusterCanaryAborted
+		run.Status = models.RunStatusFailed
+	}
+	if err := c.clusterCtl.ExecuteAction(ctx, clusterID, action, rollout.GVRRollout); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	run.FinishedAt = &now
+	if err := c.canaryMgr.UpdateRun(ctx, run); err != nil {
+		return err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceCluster, clusterID, eventType, &run.Results)
+	return nil
+}
+
+func (c *controller) interval(analysis *models.Analysis) time.Duration {
+	if analysis.Interval == 0 {
+		return c.config.DefaultInterval
+	}
+	return time.Duration(analysis.Interval) * time.Second
+}
+
+// queryArgs are the variables which can be referred in queries of metrics
+type queryArgs struct {
+	Cluster     string
+	Namespace   string
+	Environment string
+	Region      string
+	// Interval is the range of the step observed, such as 300s
+	Interval string
+}
+
+// evaluate queries the metrics from the prometheus of cluster's region,
+// the canary passes only if all the metrics are within thresholds and fails once any of them is breached,
+// otherwise it is inconclusive and the error is returned if any metric cannot be queried
+func (c *controller) evaluate(ctx context.Context, cluster *clustermodels.Cluster,
+	metrics []*models.Metric, interval time.Duration) ([]*models.MetricResult, bool, error) {
+	results := make([]*models.MetricResult, 0, len(metrics))
+	for _, metric := range metrics {
+		results = append(results, &models.MetricResult{Metric: *metric})
+	}
+	fail := func(err error) ([]*models.MetricResult, bool, error) {
+		for _, result := range results {
+			result.Error = err.Error()
+		}
+		return results, false, err
+	}
+
+	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
+	if err != nil {
+		return fail(err)
+	}
+	tr, err := c.templateReleaseMgr.GetByTemplateNameAndRelease(ctx, cluster.Template, cluster.TemplateRelease)
+	if err != nil {
+		return fail(err)
+	}
+	envValue, err := c.clusterGitRepo.GetEnvValue(ctx, application.Name, cluster.Name, tr.ChartName)
+	if err != nil {
+		return fail(err)
+	}
+	regionEntity, err := c.regionMgr.GetRegionEntity(ctx, cluster.RegionName)
+	if err != nil {
+		return fail(err)
+	}
+	if regionEntity.PrometheusURL == "" {
+		return fail(fmt.Errorf("prometheus of region %s is not configured", regionEntity.Name))
+	}
+
+	args := &queryArgs{
+		Cluster:     cluster.Name,
+		Namespace:   envValue.Namespace,
+		Environment: cluster.EnvironmentName,
+		Region:      cluster.RegionName,
+		Interval:    fmt.Sprintf("%ds", int64(interval.Seconds())),
+	}
+	var (
+		breached bool
+		queryErr error
+	)
+	for _, result := range results {
+		value, err := c.query(ctx, regionEntity.PrometheusURL, result.Query, args)
+		if err != nil {
+			result.Error = err.Error()
+			if queryErr == nil {
+				queryErr = fmt.Errorf("failed to query metric %s: %v", result.Name, err)
+			}
+			continue
+		}
+		// ratio queries return NaN when the canary gets no traffic, which is inconclusive
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			result.Error = fmt.Sprintf("query returns %v", value)
+			if queryErr == nil {
+				queryErr = fmt.Errorf("metric %s is %v", result.Name, value)
+			}
+			continue
+		}
+		result.Value = &value
+		result.Passed = (result.Min == nil || value >= *result.Min) &&
+			(result.Max == nil || value <= *result.Max)
+		breached = breached || !result.Passed
+	}
+	if breached {
+		return results, false, nil
+	}
+	if queryErr != nil {
+		return results, false, queryErr
+	}
+	return results, true, nil
+}
+
+func (c *controller) query(ctx context.Context, address, query string, args *queryArgs) (float64, error) {
+	tmpl, err := template.New("query").Option("missingkey=error").Parse(query)
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, args); err != nil {
+		return 0, err
+	}
+	return c.querier.Query(ctx, address, buf.String())
+}
@@ -0,0 +1,271 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/horizoncd/horizon/core/common"
+	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/canary/models"
+	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	canaryconfig "github.com/horizoncd/horizon/pkg/config/canary"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
+	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
+	trmodels "github.com/horizoncd/horizon/pkg/templaterelease/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+// fakeClusterController returns the step configured, and records the actions executed
+type fakeClusterController struct {
+	clusterctl.Controller
+	step    *clusterctl.GetStepResponse
+	actions []string
+}
+
+func (f *fakeClusterController) GetStep(ctx context.Context, clusterID uint) (*clusterctl.GetStepResponse, error) {
+	return f.step, nil
+}
+
+func (f *fakeClusterController) ExecuteAction(ctx context.Context, clusterID uint,
+	action string, gvr schema.GroupVersionResource) error {
+	f.actions = append(f.actions, action)
+	return nil
+}
+
+type fakeClusterGitRepo struct {
+	gitrepo.ClusterGitRepo
+}
+
+func (f *fakeClusterGitRepo) GetEnvValue(ctx context.Context, application, cluster,
+	templateName string) (*gitrepo.EnvValue, error) {
+	return &gitrepo.EnvValue{Namespace: "canary-ns"}, nil
+}
+
+// fakeQuerier returns the values by queries
+type fakeQuerier struct {
+	values map[string]float64
+}
+
+func (f *fakeQuerier) Query(ctx context.Context, address, query string) (float64, error) {
+	value, ok := f.values[query]
+	if !ok {
+		return 0, perror.Wrapf(herrors.ErrParamInvalid, "unexpected query: %s", query)
+	}
+	return value, nil
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func TestCanaryAnalysis(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "canary",
+		ID:   1,
+	})
+	app, err := manager.ApplicationMgr.Create(ctx, &appmodels.Application{Name: "canary"}, nil)
+	assert.Nil(t, err)
+	registry := &registrymodels.Registry{Name: "canary"}
+	assert.Nil(t, db.Create(registry).Error)
+	assert.Nil(t, db.Create(&regionmodels.Region{
+		Name:          "hz",
+		RegistryID:    registry.ID,
+		PrometheusURL: "http://prometheus",
+	}).Error)
+	_, err = manager.TemplateReleaseMgr.Create(ctx, &trmodels.TemplateRelease{
+		TemplateName: "rollout",
+		Name:         "v1.0.0",
+		ChartName:    "rollout",
+	})
+	assert.Nil(t, err)
+	cluster := &clustermodels.Cluster{
+		ApplicationID:   app.ID,
+		Name:            "canary-online",
+		EnvironmentName: "online",
+		RegionName:      "hz",
+		Template:        "rollout",
+		TemplateRelease: "v1.0.0",
+	}
+	assert.Nil(t, db.Create(cluster).Error)
+	pipelinerun, err := manager.PRMgr.PipelineRun.Create(ctx, &prmodels.Pipelinerun{
+		ClusterID: cluster.ID,
+		Action:    prmodels.ActionDeploy,
+		Status:    string(prmodels.StatusOK),
+	})
+	assert.Nil(t, err)
+
+	clusterCtl := &fakeClusterController{}
+	querier := &fakeQuerier{values: map[string]float64{
+		`error_rate{cluster="canary-online",namespace="canary-ns"}[60s]`: 0.01,
+	}}
+	c := &controller{
+		config:             &canaryconfig.Config{DefaultInterval: time.Minute, MaxErrors: 2},
+		canaryMgr:          manager.CanaryMgr,
+		applicationMgr:     manager.ApplicationMgr,
+		clusterMgr:         manager.ClusterMgr,
+		regionMgr:          manager.RegionMgr,
+		templateReleaseMgr: manager.TemplateReleaseMgr,
+		prMgr:              manager.PRMgr,
+		clusterGitRepo:     &fakeClusterGitRepo{},
+		clusterCtl:         clusterCtl,
+		querier:            querier,
+		eventSvc:           eventservice.New(manager),
+	}
+
+	// invalid configs
+	for _, request := range []*UpdateCanaryAnalysisRequest{
+		{Enabled: true},
+		{Enabled: true, Metrics: []*models.Metric{{Name: "error", Query: "error_rate"}}},
+		{Enabled: true, Metrics: []*models.Metric{{Name: "error", Query: "{{.Cluster", Max: float(1)}}},
+		{Enabled: true, Metrics: []*models.Metric{{Name: "error", Query: "error_rate", Min: float(1), Max: float(0)}}},
+		{Enabled: true, Metrics: []*models.Metric{
+			{Name: "error", Query: "error_rate", Max: float(1)},
+			{Name: "error", Query: "error_rate", Max: float(1)},
+		}},
+	} {
+		_, err := c.UpdateCanaryAnalysis(ctx, cluster.ID, request)
+		assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	}
+	analysis, err := c.UpdateCanaryAnalysis(ctx, cluster.ID, &UpdateCanaryAnalysisRequest{
+		Enabled: true,
+		Metrics: []*models.Metric{{
+			Name:  "error",
+			Query: `error_rate{cluster="{{.Cluster}}",namespace="{{.Namespace}}"}[{{.Interval}}]`,
+			Max:   float(0.05),
+		}},
+	})
+	assert.Nil(t, err)
+	assert.True(t, analysis.Enabled)
+	analysis, err = c.GetCanaryAnalysis(ctx, cluster.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(analysis.Metrics))
+
+	// canary not paused at a step is not analyzed
+	clusterCtl.step = &clusterctl.GetStepResponse{Index: 0, Total: 3}
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	runs, err := c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(runs))
+
+	// the step is observed until the interval elapses
+	clusterCtl.step = &clusterctl.GetStepResponse{Index: 0, Total: 3, Paused: true}
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	runs, err = c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(runs))
+	assert.Equal(t, models.RunStatusAnalyzing, runs[0].Status)
+	assert.Equal(t, 0, len(clusterCtl.actions))
+
+	// healthy canary is promoted
+	assert.Nil(t, db.Model(&models.AnalysisRun{}).Where("id = ?", runs[0].ID).
+		Update("started_at", time.Now().Add(-2*time.Minute)).Error)
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	assert.Equal(t, []string{_actionPromote}, clusterCtl.actions)
+	runs, err = c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.RunStatusSucceeded, runs[0].Status)
+	assert.True(t, runs[0].Results[0].Passed)
+	assert.Equal(t, 0.01, *runs[0].Results[0].Value)
+	assert.NotNil(t, runs[0].FinishedAt)
+
+	// failing canary is aborted
+	querier.values[`error_rate{cluster="canary-online",namespace="canary-ns"}[60s]`] = 0.1
+	clusterCtl.step = &clusterctl.GetStepResponse{Index: 1, Total: 3, Paused: true}
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	runs, err = c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(runs))
+	assert.Nil(t, db.Model(&models.AnalysisRun{}).Where("id = ?", runs[1].ID).
+		Update("started_at", time.Now().Add(-2*time.Minute)).Error)
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	assert.Equal(t, []string{_actionPromote, _actionAbort}, clusterCtl.actions)
+	runs, err = c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.RunStatusFailed, runs[1].Status)
+	assert.False(t, runs[1].Results[0].Passed)
+
+	// the step decided is not analyzed again
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	assert.Equal(t, 2, len(clusterCtl.actions))
+
+	// canary whose metrics cannot be queried or are NaN without traffic is retried,
+	// and aborted after max errors in a row
+	querier.values[`error_rate{cluster="canary-online",namespace="canary-ns"}[60s]`] = math.NaN()
+	clusterCtl.step = &clusterctl.GetStepResponse{Index: 2, Total: 3, Paused: true}
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	runs, err = c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(runs))
+	assert.Nil(t, db.Model(&models.AnalysisRun{}).Where("id = ?", runs[2].ID).
+		Update("started_at", time.Now().Add(-2*time.Minute)).Error)
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	assert.Equal(t, 2, len(clusterCtl.actions))
+	runs, err = c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.RunStatusAnalyzing, runs[2].Status)
+	assert.Equal(t, 1, runs[2].Errors)
+	assert.NotEmpty(t, runs[2].Message)
+	assert.NotEmpty(t, runs[2].Results[0].Error)
+	assert.Nil(t, runs[2].Results[0].Value)
+	delete(querier.values, `error_rate{cluster="canary-online",namespace="canary-ns"}[60s]`)
+	assert.Nil(t, c.AnalyzeCluster(ctx, cluster.ID))
+	assert.Equal(t, []string{_actionPromote, _actionAbort, _actionAbort}, clusterCtl.actions)
+	runs, err = c.ListCanaryAnalysisRuns(ctx, pipelinerun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.RunStatusFailed, runs[2].Status)
+	assert.Equal(t, 2, runs[2].Errors)
+
+	// disabled analysis does nothing
+	_, err = c.UpdateCanaryAnalysis(ctx, cluster.ID, &UpdateCanaryAnalysisRequest{Enabled: false})
+	assert.Nil(t, err)
+	analyses, err := manager.CanaryMgr.ListEnabledAnalyses(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(analyses))
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&appmodels.Application{}, &usermodels.User{}, &clustermodels.Cluster{},
+		&models.Analysis{}, &models.AnalysisRun{}, &prmodels.Pipelinerun{}, &regionmodels.Region{},
+		&registrymodels.Registry{}, &trmodels.TemplateRelease{}, &membermodels.Member{},
+		&eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
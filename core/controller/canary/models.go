@@ -0,0 +1,86 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/canary/models"
+)
+
+type UpdateCanaryAnalysisRequest struct {
+	Enabled bool `json:"enabled"`
+	// Interval is how long in seconds a canary step is observed before it is analyzed,
+	// the default interval is used if it is zero
+	Interval uint             `json:"interval"`
+	Metrics  []*models.Metric `json:"metrics"`
+}
+
+type CanaryAnalysis struct {
+	ClusterID uint             `json:"clusterID"`
+	Enabled   bool             `json:"enabled"`
+	Interval  uint             `json:"interval"`
+	Metrics   []*models.Metric `json:"metrics"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+type CanaryAnalysisRun struct {
+	ID            uint                   `json:"id"`
+	PipelinerunID uint                   `json:"pipelinerunID"`
+	Step          int                    `json:"step"`
+	Status        models.RunStatus       `json:"status"`
+	Results       []*models.MetricResult `json:"results"`
+	Message       string                 `json:"message"`
+	Errors        int                    `json:"errors"`
+	StartedAt     time.Time              `json:"startedAt"`
+	FinishedAt    *time.Time             `json:"finishedAt,omitempty"`
+}
+
+func ofAnalysisModel(a *models.Analysis) (*CanaryAnalysis, error) {
+	analysis := &CanaryAnalysis{
+		ClusterID: a.ClusterID,
+		Enabled:   a.Enabled,
+		Interval:  a.Interval,
+		Metrics:   []*models.Metric{},
+		UpdatedAt: a.UpdatedAt,
+	}
+	if a.Metrics != "" {
+		if err := json.Unmarshal([]byte(a.Metrics), &analysis.Metrics); err != nil {
+			return nil, err
+		}
+	}
+	return analysis, nil
+}
+
+func ofAnalysisRunModel(r *models.AnalysisRun) (*CanaryAnalysisRun, error) {
+	run := &CanaryAnalysisRun{
+		ID:            r.ID,
+		PipelinerunID: r.PipelinerunID,
+		Step:          r.Step,
+		Status:        r.Status,
+		Results:       []*models.MetricResult{},
+		Message:       r.Message,
+		Errors:        r.Errors,
+		StartedAt:     r.StartedAt,
+		FinishedAt:    r.FinishedAt,
+	}
+	if r.Results != "" {
+		if err := json.Unmarshal([]byte(r.Results), &run.Results); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
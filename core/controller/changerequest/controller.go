@@ -0,0 +1,401 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	appmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	changerequestmanager "github.com/horizoncd/horizon/pkg/changerequest/manager"
+	"github.com/horizoncd/horizon/pkg/changerequest/models"
+	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	changerequestconfig "github.com/horizoncd/horizon/pkg/config/changerequest"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	memberservice "github.com/horizoncd/horizon/pkg/member/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Controller interface {
+	// CreateChangeRequest commits the config change of cluster to a branch of its own,
+	// the change is applied only after the change request is approved and merged
+	CreateChangeRequest(ctx context.Context, clusterID uint,
+		request *CreateChangeRequestRequest) (*ChangeRequest, error)
+	// GetChangeRequest gets the change request with its diff against gitops branch and reviews
+	GetChangeRequest(ctx context.Context, id uint) (*ChangeRequest, error)
+	ListChangeRequests(ctx context.Context, clusterID uint,
+		query *q.Query) ([]*ChangeRequest, int64, error)
+	// ApproveChangeRequest approves the change request, only owners of cluster
+	// other than the author can review it
+	ApproveChangeRequest(ctx context.Context, id uint,
+		request *ReviewChangeRequestRequest) (*ChangeRequest, error)
+	RejectChangeRequest(ctx context.Context, id uint,
+		request *ReviewChangeRequestRequest) (*ChangeRequest, error)
+	// MergeChangeRequest merges the branch into gitops branch and applies the change to cluster,
+	// it requires as many approvals as the environment of cluster requires
+	MergeChangeRequest(ctx context.Context, id uint) (*ChangeRequest, error)
+	// CloseChangeRequest closes the change request without merging,
+	// only the author or owners of cluster can close it
+	CloseChangeRequest(ctx context.Context, id uint) (*ChangeRequest, error)
+}
+
+type controller struct {
+	config           *changerequestconfig.Config
+	changeRequestMgr changerequestmanager.Manager
+	applicationMgr   appmanager.Manager
+	clusterMgr       clustermanager.Manager
+	clusterGitRepo   gitrepo.ClusterGitRepo
+	userMgr          usermanager.Manager
+	memberSvc        memberservice.Service
+	clusterCtl       clusterctl.Controller
+	eventSvc         eventservice.Service
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param, clusterCtl clusterctl.Controller) Controller {
+	return &controller{
+		config:           &config.ChangeRequestConfig,
+		changeRequestMgr: param.ChangeRequestMgr,
+		applicationMgr:   param.ApplicationMgr,
+		clusterMgr:       param.ClusterMgr,
+		clusterGitRepo:   param.ClusterGitRepo,
+		userMgr:          param.UserMgr,
+		memberSvc:        param.MemberService,
+		clusterCtl:       clusterCtl,
+		eventSvc:         param.EventSvc,
+	}
+}
+
+func (c *controller) CreateChangeRequest(ctx context.Context, clusterID uint,
+	request *CreateChangeRequestRequest) (*ChangeRequest, error) {
+	const op = "change request controller: create"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. validate request
+	if request.Title == "" {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "title cannot be empty")
+	}
+	if request.Request == nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "request cannot be empty")
+	}
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	requiredApprovals := 1
+	if rule, ok := c.config.Environments[cluster.EnvironmentName]; ok && rule != nil &&
+		rule.RequiredApprovals > 0 {
+		requiredApprovals = rule.RequiredApprovals
+	}
+	requestBytes, err := json.Marshal(request.Request)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+
+	// 2. create change request, the branch is named after its id
+	changeRequest, err := c.changeRequestMgr.Create(ctx, &models.ChangeRequest{
+		ClusterID:         clusterID,
+		Title:             request.Title,
+		Description:       request.Description,
+		Request:           string(requestBytes),
+		MergePatch:        request.MergePatch,
+		RequiredApprovals: requiredApprovals,
+		Status:            models.StatusOpen,
+		CreatedBy:         currentUser.GetID(),
+		UpdatedBy:         currentUser.GetID(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	branch := fmt.Sprintf("change-request-%d", changeRequest.ID)
+	if err := c.changeRequestMgr.UpdateByID(ctx, changeRequest.ID,
+		&models.ChangeRequest{Branch: branch}); err != nil {
+		return nil, err
+	}
+
+	// 3. commit the change to branch, the change request is removed if the change is invalid
+	if err := c.clusterCtl.CommitClusterChangeV2(ctx, clusterID, request.Request,
+		request.MergePatch, branch); err != nil {
+		if err := c.changeRequestMgr.DeleteByID(ctx, changeRequest.ID); err != nil {
+			log.Warningf(ctx, "failed to delete change request %d: %v", changeRequest.ID, err)
+		}
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceChangeRequest, changeRequest.ID,
+		eventmodels.ChangeRequestCreated, nil)
+	return c.GetChangeRequest(ctx, changeRequest.ID)
+}
+
+func (c *controller) GetChangeRequest(ctx context.Context, id uint) (*ChangeRequest, error) {
+	const op = "change request controller: get"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	changeRequest, err := c.changeRequestMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := c.changeRequestMgr.ListReviews(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := []uint{changeRequest.CreatedBy}
+	for _, review := range reviews {
+		userIDs = append(userIDs, review.CreatedBy)
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	resp := ofChangeRequestModel(changeRequest, users)
+	resp.Approvals = countApprovals(reviews)
+	for _, review := range reviews {
+		resp.Reviews = append(resp.Reviews, &Review{
+			Approved:  review.Approved,
+			Comment:   review.Comment,
+			CreatedBy: usermodels.ToUser(users[review.CreatedBy]),
+			CreatedAt: review.CreatedAt,
+		})
+	}
+
+	// the branch is kept after the change request is merged or closed,
+	// but only the diff of open change request is meaningful
+	if changeRequest.Status == models.StatusOpen {
+		cluster, err := c.clusterMgr.GetByID(ctx, changeRequest.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
+		if err != nil {
+			return nil, err
+		}
+		gitOpsBranch := gitrepo.GitOpsBranch
+		resp.Diff, err = c.clusterGitRepo.CompareConfig(ctx, application.Name, cluster.Name,
+			&gitOpsBranch, &changeRequest.Branch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (c *controller) ListChangeRequests(ctx context.Context, clusterID uint,
+	query *q.Query) ([]*ChangeRequest, int64, error) {
+	const op = "change request controller: list"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	changeRequests, total, err := c.changeRequestMgr.ListByClusterID(ctx, clusterID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	userIDs := make([]uint, 0, len(changeRequests))
+	for _, changeRequest := range changeRequests {
+		userIDs = append(userIDs, changeRequest.CreatedBy)
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]*ChangeRequest, 0, len(changeRequests))
+	for _, changeRequest := range changeRequests {
+		resp = append(resp, ofChangeRequestModel(changeRequest, users))
+	}
+	return resp, total, nil
+}
+
+// checkReviewer checks that the change request is open,
+// and the reviewer is an owner of cluster and is not the author
+func (c *controller) checkReviewer(ctx context.Context, changeRequest *models.ChangeRequest) (uint, error) {
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if changeRequest.Status != models.StatusOpen {
+		return 0, perror.Wrapf(herrors.ErrParamInvalid,
+			"change request %d is %s, only open change request can be reviewed",
+			changeRequest.ID, changeRequest.Status)
+	}
+	if currentUser.GetID() == changeRequest.CreatedBy {
+		return 0, perror.Wrap(herrors.ErrForbidden, "cannot review the change request created by yourself")
+	}
+	if err := c.memberSvc.RequirePermissionEqualOrHigher(ctx, role.Owner,
+		common.ResourceCluster, changeRequest.ClusterID); err != nil {
+		return 0, err
+	}
+	return currentUser.GetID(), nil
+}
+
+func (c *controller) ApproveChangeRequest(ctx context.Context, id uint,
+	request *ReviewChangeRequestRequest) (*ChangeRequest, error) {
+	const op = "change request controller: approve"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	changeRequest, err := c.changeRequestMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := c.checkReviewer(ctx, changeRequest)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.changeRequestMgr.CreateReview(ctx, &models.ChangeRequestReview{
+		ChangeRequestID: id,
+		Approved:        true,
+		Comment:         request.Comment,
+		CreatedBy:       reviewer,
+	}); err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceChangeRequest, id,
+		eventmodels.ChangeRequestApproved, nil)
+	return c.GetChangeRequest(ctx, id)
+}
+
+func (c *controller) RejectChangeRequest(ctx context.Context, id uint,
+	request *ReviewChangeRequestRequest) (*ChangeRequest, error) {
+	const op = "change request controller: reject"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	changeRequest, err := c.changeRequestMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := c.checkReviewer(ctx, changeRequest)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.changeRequestMgr.CreateReview(ctx, &models.ChangeRequestReview{
+		ChangeRequestID: id,
+		Approved:        false,
+		Comment:         request.Comment,
+		CreatedBy:       reviewer,
+	}); err != nil {
+		return nil, err
+	}
+	if err := c.changeRequestMgr.UpdateStatus(ctx, id,
+		models.StatusOpen, models.StatusRejected); err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceChangeRequest, id,
+		eventmodels.ChangeRequestRejected, nil)
+	return c.GetChangeRequest(ctx, id)
+}
+
+func (c *controller) MergeChangeRequest(ctx context.Context, id uint) (*ChangeRequest, error) {
+	const op = "change request controller: merge"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	changeRequest, err := c.changeRequestMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changeRequest.Status != models.StatusOpen {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"change request is %s, only open change request can be merged", changeRequest.Status)
+	}
+	reviews, err := c.changeRequestMgr.ListReviews(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	approvals := countApprovals(reviews)
+	if approvals < changeRequest.RequiredApprovals {
+		return nil, perror.Wrapf(herrors.ErrForbidden,
+			"change request has %d approvals, %d approvals are required",
+			approvals, changeRequest.RequiredApprovals)
+	}
+	var request clusterctl.UpdateClusterRequestV2
+	if err := json.Unmarshal([]byte(changeRequest.Request), &request); err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"failed to unmarshal request of change request %d: %v", id, err)
+	}
+
+	// mark the change request as merged first, so that it cannot be merged twice concurrently
+	if err := c.changeRequestMgr.UpdateStatus(ctx, id,
+		models.StatusOpen, models.StatusMerged); err != nil {
+		return nil, err
+	}
+	if err := c.clusterCtl.MergeClusterChangeV2(ctx, changeRequest.ClusterID, &request,
+		changeRequest.MergePatch, changeRequest.Branch); err != nil {
+		if err := c.changeRequestMgr.UpdateStatus(ctx, id,
+			models.StatusMerged, models.StatusOpen); err != nil {
+			log.Warningf(ctx, "failed to reopen change request %d: %v", id, err)
+		}
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceChangeRequest, id,
+		eventmodels.ChangeRequestMerged, nil)
+	return c.GetChangeRequest(ctx, id)
+}
+
+func (c *controller) CloseChangeRequest(ctx context.Context, id uint) (*ChangeRequest, error) {
+	const op = "change request controller: close"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changeRequest, err := c.changeRequestMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changeRequest.Status != models.StatusOpen {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"change request is %s, only open change request can be closed", changeRequest.Status)
+	}
+	if currentUser.GetID() != changeRequest.CreatedBy {
+		if err := c.memberSvc.RequirePermissionEqualOrHigher(ctx, role.Owner,
+			common.ResourceCluster, changeRequest.ClusterID); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.changeRequestMgr.UpdateStatus(ctx, id,
+		models.StatusOpen, models.StatusClosed); err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceChangeRequest, id,
+		eventmodels.ChangeRequestClosed, nil)
+	return c.GetChangeRequest(ctx, id)
+}
+
+// countApprovals counts the distinct reviewers approving the change request
+func countApprovals(reviews []*models.ChangeRequestReview) int {
+	reviewers := make(map[uint]struct{}, len(reviews))
+	for _, review := range reviews {
+		if review.Approved {
+			reviewers[review.CreatedBy] = struct{}{}
+		}
+	}
+	return len(reviewers)
+}
@@ -0,0 +1,235 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/changerequest/models"
+	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	changerequestconfig "github.com/horizoncd/horizon/pkg/config/changerequest"
+	roleconfig "github.com/horizoncd/horizon/pkg/config/role"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	memberservice "github.com/horizoncd/horizon/pkg/member/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
+	"github.com/horizoncd/horizon/pkg/rbac/types"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+func userContext(id uint, name string) context.Context {
+	return context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: name,
+		ID:   id,
+	})
+}
+
+// fakeClusterController records the branches committed to and merged
+type fakeClusterController struct {
+	clusterctl.Controller
+	committed []string
+	merged    []string
+}
+
+func (f *fakeClusterController) CommitClusterChangeV2(ctx context.Context, clusterID uint,
+	r *clusterctl.UpdateClusterRequestV2, mergePatch bool, branch string) error {
+	if r.TemplateConfig == nil {
+		return perror.Wrap(herrors.ErrParamInvalid, "template config is empty")
+	}
+	f.committed = append(f.committed, branch)
+	return nil
+}
+
+func (f *fakeClusterController) MergeClusterChangeV2(ctx context.Context, clusterID uint,
+	r *clusterctl.UpdateClusterRequestV2, mergePatch bool, branch string) error {
+	f.merged = append(f.merged, branch)
+	return nil
+}
+
+type fakeClusterGitRepo struct {
+	gitrepo.ClusterGitRepo
+}
+
+func (f *fakeClusterGitRepo) CompareConfig(ctx context.Context, application, cluster string,
+	from, to *string) (string, error) {
+	return *from + ".." + *to, nil
+}
+
+func TestChangeRequest(t *testing.T) {
+	authorCtx := userContext(1, "author")
+	ownerCtx := userContext(2, "owner")
+	maintainerCtx := userContext(3, "maintainer")
+
+	for _, name := range []string{"author", "owner", "maintainer"} {
+		_, err := manager.UserMgr.Create(authorCtx, &usermodels.User{Name: name})
+		assert.Nil(t, err)
+	}
+	app, err := manager.ApplicationMgr.Create(authorCtx, &appmodels.Application{Name: "changerequest"}, nil)
+	assert.Nil(t, err)
+	cluster := &clustermodels.Cluster{
+		ApplicationID:   app.ID,
+		Name:            "changerequest-online",
+		EnvironmentName: "online",
+	}
+	assert.Nil(t, db.Create(cluster).Error)
+	for _, m := range []*membermodels.Member{
+		{MemberNameID: 1, Role: role.Owner},
+		{MemberNameID: 2, Role: role.Owner},
+		{MemberNameID: 3, Role: role.Maintainer},
+	} {
+		m.ResourceType = membermodels.TypeApplicationCluster
+		m.ResourceID = cluster.ID
+		m.MemberType = membermodels.MemberUser
+		_, err := manager.MemberMgr.Create(authorCtx, m)
+		assert.Nil(t, err)
+	}
+
+	roleSvc, err := role.NewFileRoleFrom2(context.Background(), roleconfig.Config{
+		RolePriorityRankDesc: []string{role.Owner, role.Maintainer, role.Guest},
+		Roles: []types.Role{
+			{Name: role.Owner}, {Name: role.Maintainer}, {Name: role.Guest},
+		},
+	})
+	assert.Nil(t, err)
+	clusterCtl := &fakeClusterController{}
+	ctl := NewController(&config.Config{
+		ChangeRequestConfig: changerequestconfig.Config{
+			Environments: map[string]*changerequestconfig.ApprovalRule{
+				"online": {RequiredApprovals: 1},
+			},
+		},
+	}, &param.Param{
+		Manager:        manager,
+		MemberService:  memberservice.NewService(roleSvc, nil, manager),
+		ClusterGitRepo: &fakeClusterGitRepo{},
+		EventSvc:       eventservice.New(manager),
+	}, clusterCtl)
+
+	// invalid requests, the change request is removed if the change cannot be committed
+	_, err = ctl.CreateChangeRequest(authorCtx, cluster.ID, &CreateChangeRequestRequest{Title: "scale out"})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	_, err = ctl.CreateChangeRequest(authorCtx, cluster.ID, &CreateChangeRequestRequest{
+		Title:   "scale out",
+		Request: &clusterctl.UpdateClusterRequestV2{},
+	})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	_, total, err := ctl.ListChangeRequests(authorCtx, cluster.ID, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), total)
+
+	request := &CreateChangeRequestRequest{
+		Title:      "scale out",
+		MergePatch: true,
+		Request: &clusterctl.UpdateClusterRequestV2{
+			TemplateConfig: map[string]interface{}{"replicas": 3},
+		},
+	}
+	changeRequest, err := ctl.CreateChangeRequest(authorCtx, cluster.ID, request)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusOpen, changeRequest.Status)
+	assert.Equal(t, 1, changeRequest.RequiredApprovals)
+	assert.Equal(t, []string{changeRequest.Branch}, clusterCtl.committed)
+	assert.Equal(t, gitrepo.GitOpsBranch+".."+changeRequest.Branch, changeRequest.Diff)
+
+	// cannot be merged before approved
+	_, err = ctl.MergeChangeRequest(authorCtx, changeRequest.ID)
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+
+	// only owners other than the author can review
+	_, err = ctl.ApproveChangeRequest(authorCtx, changeRequest.ID, &ReviewChangeRequestRequest{})
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+	_, err = ctl.ApproveChangeRequest(maintainerCtx, changeRequest.ID, &ReviewChangeRequestRequest{})
+	assert.Equal(t, herrors.ErrNoPrivilege, perror.Cause(err))
+
+	changeRequest, err = ctl.ApproveChangeRequest(ownerCtx, changeRequest.ID,
+		&ReviewChangeRequestRequest{Comment: "lgtm"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, changeRequest.Approvals)
+	assert.Equal(t, "lgtm", changeRequest.Reviews[0].Comment)
+
+	// reviews of the same reviewer are counted once
+	changeRequest, err = ctl.ApproveChangeRequest(ownerCtx, changeRequest.ID,
+		&ReviewChangeRequestRequest{Comment: "lgtm again"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, changeRequest.Approvals)
+	assert.Equal(t, 1, len(changeRequest.Reviews))
+	assert.Equal(t, "lgtm again", changeRequest.Reviews[0].Comment)
+	assert.Equal(t, 1, countApprovals([]*models.ChangeRequestReview{
+		{CreatedBy: 1, Approved: true}, {CreatedBy: 1, Approved: true},
+	}))
+
+	changeRequest, err = ctl.MergeChangeRequest(maintainerCtx, changeRequest.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusMerged, changeRequest.Status)
+	assert.Equal(t, []string{changeRequest.Branch}, clusterCtl.merged)
+	assert.Empty(t, changeRequest.Diff)
+
+	_, err = ctl.MergeChangeRequest(maintainerCtx, changeRequest.ID)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	// reject
+	changeRequest, err = ctl.CreateChangeRequest(authorCtx, cluster.ID, request)
+	assert.Nil(t, err)
+	changeRequest, err = ctl.RejectChangeRequest(ownerCtx, changeRequest.ID, &ReviewChangeRequestRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusRejected, changeRequest.Status)
+	_, err = ctl.ApproveChangeRequest(ownerCtx, changeRequest.ID, &ReviewChangeRequestRequest{})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	// close, only the author or owners can close
+	changeRequest, err = ctl.CreateChangeRequest(authorCtx, cluster.ID, request)
+	assert.Nil(t, err)
+	_, err = ctl.CloseChangeRequest(maintainerCtx, changeRequest.ID)
+	assert.Equal(t, herrors.ErrNoPrivilege, perror.Cause(err))
+	changeRequest, err = ctl.CloseChangeRequest(authorCtx, changeRequest.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusClosed, changeRequest.Status)
+
+	changeRequests, total, err := ctl.ListChangeRequests(authorCtx, cluster.ID, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, changeRequest.ID, changeRequests[0].ID)
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&appmodels.Application{}, &usermodels.User{}, &clustermodels.Cluster{},
+		&groupmodels.Group{}, &membermodels.Member{}, &models.ChangeRequest{}, &models.ChangeRequestReview{},
+		&eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
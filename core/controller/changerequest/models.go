@@ -0,0 +1,76 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+import (
+	"time"
+
+	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
+	"github.com/horizoncd/horizon/pkg/changerequest/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+type CreateChangeRequestRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// MergePatch whether the config in request is merged into the current config of cluster
+	MergePatch bool `json:"mergePatch"`
+	// Request the cluster update to apply once the change request is merged
+	Request *clusterctl.UpdateClusterRequestV2 `json:"request"`
+}
+
+type ReviewChangeRequestRequest struct {
+	Comment string `json:"comment"`
+}
+
+type ChangeRequest struct {
+	ID                uint                  `json:"id"`
+	ClusterID         uint                  `json:"clusterID"`
+	Title             string                `json:"title"`
+	Description       string                `json:"description"`
+	Branch            string                `json:"branch"`
+	MergePatch        bool                  `json:"mergePatch"`
+	RequiredApprovals int                   `json:"requiredApprovals"`
+	Approvals         int                   `json:"approvals"`
+	Status            models.Status         `json:"status"`
+	Diff              string                `json:"diff,omitempty"`
+	Reviews           []*Review             `json:"reviews,omitempty"`
+	CreatedBy         *usermodels.UserBasic `json:"createdBy,omitempty"`
+	CreatedAt         time.Time             `json:"createdAt"`
+	UpdatedAt         time.Time             `json:"updatedAt"`
+}
+
+type Review struct {
+	Approved  bool                  `json:"approved"`
+	Comment   string                `json:"comment"`
+	CreatedBy *usermodels.UserBasic `json:"createdBy,omitempty"`
+	CreatedAt time.Time             `json:"createdAt"`
+}
+
+func ofChangeRequestModel(cr *models.ChangeRequest, users map[uint]*usermodels.User) *ChangeRequest {
+	return &ChangeRequest{
+		ID:                cr.ID,
+		ClusterID:         cr.ClusterID,
+		Title:             cr.Title,
+		Description:       cr.Description,
+		Branch:            cr.Branch,
+		MergePatch:        cr.MergePatch,
+		RequiredApprovals: cr.RequiredApprovals,
+		Status:            cr.Status,
+		CreatedBy:         usermodels.ToUser(users[cr.CreatedBy]),
+		CreatedAt:         cr.CreatedAt,
+		UpdatedAt:         cr.UpdatedAt,
+	}
+}
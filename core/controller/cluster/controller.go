@@ -27,6 +27,7 @@ import (
 	"github.com/horizoncd/horizon/core/config"
 	"github.com/horizoncd/horizon/core/controller/build"
 	"github.com/horizoncd/horizon/lib/q"
+	admissionmodels "github.com/horizoncd/horizon/pkg/admission/models"
 	appgitrepo "github.com/horizoncd/horizon/pkg/application/gitrepo"
 	appmanager "github.com/horizoncd/horizon/pkg/application/manager"
 	applicationservice "github.com/horizoncd/horizon/pkg/application/service"
@@ -37,6 +38,8 @@ import (
 	registryfty "github.com/horizoncd/horizon/pkg/cluster/registry/factory"
 	"github.com/horizoncd/horizon/pkg/cluster/tekton/factory"
 	collectionmanager "github.com/horizoncd/horizon/pkg/collection/manager"
+	"github.com/horizoncd/horizon/pkg/config/approval"
+	"github.com/horizoncd/horizon/pkg/config/changerequest"
 	"github.com/horizoncd/horizon/pkg/config/grafana"
 	"github.com/horizoncd/horizon/pkg/config/template"
 	"github.com/horizoncd/horizon/pkg/config/token"
@@ -47,11 +50,13 @@ import (
 	grafanaservice "github.com/horizoncd/horizon/pkg/grafana"
 	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
 	groupsvc "github.com/horizoncd/horizon/pkg/group/service"
+	hpamanager "github.com/horizoncd/horizon/pkg/hpa/manager"
 	"github.com/horizoncd/horizon/pkg/param"
 	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
 	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
 	pipelinemanager "github.com/horizoncd/horizon/pkg/pr/pipeline/manager"
 	prservice "github.com/horizoncd/horizon/pkg/pr/service"
+	"github.com/horizoncd/horizon/pkg/rbac"
 	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
 	tagmanager "github.com/horizoncd/horizon/pkg/tag/manager"
 	trmanager "github.com/horizoncd/horizon/pkg/templaterelease/manager"
@@ -111,11 +116,20 @@ type Controller interface {
 
 	GetPodEvents(ctx context.Context, clusterID uint, podName string) (interface{}, error)
 	GetContainers(ctx context.Context, clusterID uint, podName string) (interface{}, error)
+	// ListJobRuns lists the recent jobs of the cluster's cronjob or job, the latest first
+	ListJobRuns(ctx context.Context, clusterID uint, limit int) ([]cd.JobRun, error)
 	GetGrafanaDashBoard(c context.Context, clusterID uint) (*GetGrafanaDashboardsResponse, error)
 
 	CreateClusterV2(ctx context.Context, params *CreateClusterParamsV2) (*CreateClusterResponseV2, error)
+	CloneCluster(ctx context.Context, clusterID uint, r *CloneClusterRequest) (*CreateClusterResponseV2, error)
 	GetClusterV2(ctx context.Context, clusterID uint) (*GetClusterResponseV2, error)
 	UpdateClusterV2(ctx context.Context, clusterID uint, r *UpdateClusterRequestV2, mergePatch bool) error
+	// CommitClusterChangeV2 validates the update and commits the config to branch without applying it
+	CommitClusterChangeV2(ctx context.Context, clusterID uint, r *UpdateClusterRequestV2,
+		mergePatch bool, branch string) error
+	// MergeClusterChangeV2 merges the config committed to branch into gitops branch and applies the update
+	MergeClusterChangeV2(ctx context.Context, clusterID uint, r *UpdateClusterRequestV2,
+		mergePatch bool, branch string) error
 	// InternalDeployV2 deploy only used by internal system
 	InternalDeployV2(ctx context.Context, clusterID uint,
 		r *InternalDeployRequestV2) (_ *InternalDeployResponseV2, err error)
@@ -169,6 +183,11 @@ type controller struct {
 	templateUpgradeMapper template.UpgradeMapper
 	collectionManager     collectionmanager.Manager
 	clusterSvc            clusterservice.Service
+	changeRequestConfig   changerequest.Config
+	prApprovalConfig      approval.Config
+	hpaOverrideMgr        hpamanager.Manager
+	authorizer            rbac.Authorizer
+	admit                 admissionmodels.AdmitFunc
 }
 
 var _ Controller = (*controller)(nil)
@@ -213,5 +232,10 @@ func NewController(config *config.Config, param *param.Param) Controller {
 		templateUpgradeMapper: config.TemplateUpgradeMapper,
 		collectionManager:     param.CollectionMgr,
 		clusterSvc:            param.ClusterSvc,
+		changeRequestConfig:   config.ChangeRequestConfig,
+		prApprovalConfig:      config.PRApprovalConfig,
+		hpaOverrideMgr:        param.HPAOverrideMgr,
+		authorizer:            rbac.NewAuthorizer(param.RoleService, param.MemberService),
+		admit:                 param.Admit,
 	}
 }
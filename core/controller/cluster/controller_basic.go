@@ -569,6 +569,11 @@ func (c *controller) UpdateCluster(ctx context.Context, clusterID uint,
 	if err != nil {
 		return nil, err
 	}
+	if r.changesConfig() {
+		if err := c.checkChangeRequestRequired(cluster); err != nil {
+			return nil, err
+		}
+	}
 
 	// 2. get application that this cluster belongs to
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
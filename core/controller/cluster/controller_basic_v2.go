@@ -25,6 +25,7 @@ import (
 	appmodels "github.com/horizoncd/horizon/pkg/application/models"
 	"github.com/horizoncd/horizon/pkg/cd"
 	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
 	collectionmodels "github.com/horizoncd/horizon/pkg/collection/models"
 	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
 	"github.com/horizoncd/horizon/pkg/git"
@@ -33,6 +34,7 @@ import (
 	"github.com/horizoncd/horizon/pkg/templaterelease/models"
 	templateschema "github.com/horizoncd/horizon/pkg/templaterelease/schema"
 	"github.com/horizoncd/horizon/pkg/util/jsonschema"
+	"github.com/horizoncd/horizon/pkg/util/log"
 	"github.com/horizoncd/horizon/pkg/util/mergemap"
 	"github.com/horizoncd/horizon/pkg/util/validate"
 
@@ -92,11 +94,57 @@ func (c *controller) GetClusterStatusV2(ctx context.Context, clusterID uint) (*S
 		if resp.Status == "" {
 			resp.Status = cdStatus.Status
 		}
+		resp.HPAs = c.getHPAStatuses(ctx, cluster, regionEntity)
 	}
 
 	return resp, nil
 }
 
+// getHPAStatuses gets the horizontal pod autoscalers in the resource tree of cluster,
+// errors are only logged, so that the status of cluster can still be returned
+func (c *controller) getHPAStatuses(ctx context.Context, cluster *clustermodels.Cluster,
+	regionEntity *regionmodels.RegionEntity) []*HPAStatus {
+	nodes, err := c.cd.GetResourceTree(ctx, &cd.GetResourceTreeParams{
+		Environment:  cluster.EnvironmentName,
+		Cluster:      cluster.Name,
+		RegionEntity: regionEntity,
+	})
+	if err != nil {
+		log.Warningf(ctx, "failed to get resource tree of cluster %s: %v", cluster.Name, err)
+		return nil
+	}
+
+	var hpas []*HPAStatus
+	for _, node := range nodes {
+		if node.Group != cd.GKHPA.Group || node.Kind != cd.GKHPA.Kind {
+			continue
+		}
+		status, err := c.k8sutil.GetHPAStatus(ctx, &cd.GetHPAParams{
+			RegionEntity: regionEntity,
+			Namespace:    node.Namespace,
+			Name:         node.Name,
+		})
+		if err != nil {
+			log.Warningf(ctx, "failed to get status of hpa %s: %v", node.Name, err)
+			continue
+		}
+		hpa := &HPAStatus{HPAStatus: status}
+		override, err := c.hpaOverrideMgr.GetActive(ctx, cluster.ID, node.Name)
+		if err == nil {
+			hpa.Override = &HPAOverride{
+				ID:          override.ID,
+				MinReplicas: override.MinReplicas,
+				MaxReplicas: override.MaxReplicas,
+				ExpireAt:    override.ExpireAt,
+			}
+		} else if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			log.Warningf(ctx, "failed to get override of hpa %s: %v", node.Name, err)
+		}
+		hpas = append(hpas, hpa)
+	}
+	return hpas
+}
+
 func (c *controller) CreateClusterV2(ctx context.Context,
 	params *CreateClusterParamsV2) (*CreateClusterResponseV2, error) {
 	const op = "cluster controller: create cluster v2"
@@ -368,26 +416,108 @@ func (c *controller) UpdateClusterV2(ctx context.Context, clusterID uint,
 	const op = "cluster controller: update cluster v2"
 	defer wlog.Start(ctx, op).StopPrint()
 
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	if r.changesConfig() {
+		if err := c.checkChangeRequestRequired(cluster); err != nil {
+			return err
+		}
+	}
+
+	update, err := c.prepareUpdateV2(ctx, cluster, r, mergePatch)
+	if err != nil {
+		return err
+	}
+	if err := c.writeUpdateV2(ctx, update, gitrepo.GitOpsBranch); err != nil {
+		return err
+	}
+	return c.applyUpdateV2(ctx, update)
+}
+
+// checkChangeRequestRequired rejects the config changes of clusters in environments protected by change requests,
+// which must be approved through change requests
+func (c *controller) checkChangeRequestRequired(cluster *clustermodels.Cluster) error {
+	if _, ok := c.changeRequestConfig.Environments[cluster.EnvironmentName]; ok {
+		return perror.Wrapf(herrors.ErrChangeRequestRequired,
+			"config of clusters in environment %s can only be changed by change requests",
+			cluster.EnvironmentName)
+	}
+	return nil
+}
+
+func (c *controller) CommitClusterChangeV2(ctx context.Context, clusterID uint,
+	r *UpdateClusterRequestV2, mergePatch bool, branch string) error {
+	const op = "cluster controller: commit cluster change v2"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	update, err := c.prepareUpdateV2(ctx, cluster, r, mergePatch)
+	if err != nil {
+		return err
+	}
+	return c.writeUpdateV2(ctx, update, branch)
+}
+
+func (c *controller) MergeClusterChangeV2(ctx context.Context, clusterID uint,
+	r *UpdateClusterRequestV2, mergePatch bool, branch string) error {
+	const op = "cluster controller: merge cluster change v2"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	update, err := c.prepareUpdateV2(ctx, cluster, r, mergePatch)
+	if err != nil {
+		return err
+	}
+	if _, err := c.clusterGitRepo.MergeBranch(ctx, update.application.Name, cluster.Name,
+		branch, gitrepo.GitOpsBranch, nil); err != nil {
+		return err
+	}
+	return c.applyUpdateV2(ctx, update)
+}
+
+// clusterUpdateV2 is a validated update of cluster
+type clusterUpdateV2 struct {
+	request         *UpdateClusterRequestV2
+	cluster         *clustermodels.Cluster
+	application     *appmodels.Application
+	regionEntity    *regionmodels.RegionEntity
+	environmentName string
+	regionName      string
+	expireSeconds   uint
+	templateInfo    *codemodels.TemplateInfo
+	templateRelease *models.TemplateRelease
+	buildConfig     map[string]interface{}
+	templateConfig  map[string]interface{}
+}
+
+// prepareUpdateV2 validates the request and assembles the config of cluster to update
+func (c *controller) prepareUpdateV2(ctx context.Context, cluster *clustermodels.Cluster,
+	r *UpdateClusterRequestV2, mergePatch bool) (*clusterUpdateV2, error) {
 	// validate request
 	if r.Git != nil && r.Git.URL != "" {
 		if err := validate.CheckGitURL(r.Git.URL); err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if r.Image != nil && *r.Image != "" {
 		if err := validate.CheckImageURL(*r.Image); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// 1. get cluster and application from db
-	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
-	if err != nil {
-		return err
-	}
+	// 1. get application from db
+	clusterID := cluster.ID
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 2. check if we should update region and env
@@ -405,11 +535,11 @@ func (c *controller) UpdateClusterV2(ctx context.Context, clusterID uint,
 		}
 		regionEntity, err = c.regionMgr.GetRegionEntity(ctx, regionName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		_, err = c.envRegionMgr.GetByEnvironmentAndRegion(ctx, environmentName, regionName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -418,7 +548,7 @@ func (c *controller) UpdateClusterV2(ctx context.Context, clusterID uint,
 	if r.ExpireTime != "" {
 		expireSeconds, err = c.toExpireSeconds(ctx, r.ExpireTime, environmentName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -441,7 +571,7 @@ func (c *controller) UpdateClusterV2(ctx context.Context, clusterID uint,
 		return templateInfo, tr, nil
 	}()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	buildConfig, templateConfig, err := func() (map[string]interface{}, map[string]interface{}, error) {
@@ -474,7 +604,7 @@ func (c *controller) UpdateClusterV2(ctx context.Context, clusterID uint,
 		return buildConfig, templateConfig, nil
 	}()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 5. validate update Request
@@ -491,44 +621,65 @@ func (c *controller) UpdateClusterV2(ctx context.Context, clusterID uint,
 		return info.Validate(ctx, c.templateSchemaGetter, renderValues, c.buildSchema)
 	}()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &clusterUpdateV2{
+		request:         r,
+		cluster:         cluster,
+		application:     application,
+		regionEntity:    regionEntity,
+		environmentName: environmentName,
+		regionName:      regionName,
+		expireSeconds:   expireSeconds,
+		templateInfo:    templateInfo,
+		templateRelease: templateRelease,
+		buildConfig:     buildConfig,
+		templateConfig:  templateConfig,
+	}, nil
+}
 
-	// 6. update in git repo
-	if err = c.clusterGitRepo.UpdateCluster(ctx, &gitrepo.UpdateClusterParams{
+// writeUpdateV2 writes the config of cluster to branch, the branch is created from gitops branch if not exists
+func (c *controller) writeUpdateV2(ctx context.Context, update *clusterUpdateV2, branch string) error {
+	return c.clusterGitRepo.UpdateCluster(ctx, &gitrepo.UpdateClusterParams{
 		BaseParams: &gitrepo.BaseParams{
-			ClusterID:           cluster.ID,
-			Cluster:             cluster.Name,
-			PipelineJSONBlob:    buildConfig,
-			ApplicationJSONBlob: templateConfig,
-			TemplateRelease:     templateRelease,
-			Application:         application,
-			Environment:         environmentName,
-			RegionEntity:        regionEntity,
+			ClusterID:           update.cluster.ID,
+			Cluster:             update.cluster.Name,
+			PipelineJSONBlob:    update.buildConfig,
+			ApplicationJSONBlob: update.templateConfig,
+			TemplateRelease:     update.templateRelease,
+			Application:         update.application,
+			Environment:         update.environmentName,
+			RegionEntity:        update.regionEntity,
 			Version:             common.MetaVersion2,
-		}}); err != nil {
-		return err
-	}
+		},
+		Branch: branch,
+	})
+}
+
+// applyUpdateV2 records the update of cluster and saves it to db
+func (c *controller) applyUpdateV2(ctx context.Context, update *clusterUpdateV2) error {
+	cluster, clusterID, r := update.cluster, update.cluster.ID, update.request
 
-	// 7. record event
+	// 1. record event
 	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceCluster, cluster.ID,
 		eventmodels.ClusterUpdated, nil)
 
-	// 8. update cluster in db
-	clusterModel, tags := r.toClusterModel(cluster, expireSeconds, environmentName,
-		regionName, templateInfo.Name, templateInfo.Release)
-	_, err = c.clusterMgr.UpdateByID(ctx, clusterID, clusterModel)
+	// 2. update cluster in db
+	clusterModel, tags := r.toClusterModel(cluster, update.expireSeconds, update.environmentName,
+		update.regionName, update.templateInfo.Name, update.templateInfo.Release)
+	_, err := c.clusterMgr.UpdateByID(ctx, clusterID, clusterModel)
 	if err != nil {
 		return err
 	}
 
-	// 9. update cluster tags
+	// 3. update cluster tags
 	tagsInDB, err := c.tagMgr.ListByResourceTypeID(ctx, common.ResourceCluster, clusterID)
 	if err != nil {
 		return err
 	}
 	if r.Tags != nil && !tagmodels.Tags(tags).Eq(tagsInDB) {
-		if err := c.clusterGitRepo.UpdateTags(ctx, application.Name, cluster.Name, cluster.Template, tags); err != nil {
+		if err := c.clusterGitRepo.UpdateTags(ctx, update.application.Name, cluster.Name,
+			cluster.Template, tags); err != nil {
 			return err
 		}
 		if err := c.tagMgr.UpsertByResourceTypeID(ctx, common.ResourceCluster, clusterID, r.Tags); err != nil {
@@ -672,6 +823,10 @@ func (c *controller) CreatePipelineRun(ctx context.Context, clusterID uint,
 
 	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourcePipelinerun, pipelineRun.ID,
 		eventmodels.PipelinerunCreated, nil)
+	if pipelineRun.RequiredApprovals > 0 {
+		c.eventSvc.CreateEventIgnoreError(ctx, common.ResourcePipelinerun, pipelineRun.ID,
+			eventmodels.PipelinerunToApprove, nil)
+	}
 
 	firstCanRollbackPipelinerun, err := c.prMgr.PipelineRun.GetFirstCanRollbackPipelinerun(ctx, pipelineRun.ClusterID)
 	if err != nil {
@@ -690,14 +845,15 @@ func (c *controller) createPipelineRun(ctx context.Context, clusterID uint,
 	}
 
 	var (
-		title        = r.Title
-		action       string
-		gitURL       = cluster.GitURL
-		gitRefType   = cluster.GitRefType
-		gitRef       = cluster.GitRef
-		codeCommitID string
-		imageURL     = cluster.Image
-		rollbackFrom *uint
+		title          = r.Title
+		action         string
+		gitURL         = cluster.GitURL
+		gitRefType     = cluster.GitRefType
+		gitRef         = cluster.GitRef
+		codeCommitID   string
+		codeCommitTime *time.Time
+		imageURL       = cluster.Image
+		rollbackFrom   *uint
 	)
 
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
@@ -745,6 +901,7 @@ func (c *controller) createPipelineRun(ctx context.Context, clusterID uint,
 			}
 		}
 		codeCommitID = commit.ID
+		codeCommitTime = commit.CommittedAt
 
 		imageURL = assembleImageURL(regionEntity, application.Name, cluster.Name, gitRef, commit.ID)
 
@@ -765,6 +922,7 @@ func (c *controller) createPipelineRun(ctx context.Context, clusterID uint,
 			commit, err := c.commitGetter.GetCommit(ctx, cluster.GitURL, cluster.GitRefType, cluster.GitRef)
 			if err == nil {
 				codeCommitID = commit.ID
+				codeCommitTime = commit.CommittedAt
 			}
 		} else if cluster.Image != "" {
 			imageURL, err = getDeployImage(cluster.Image, r.ImageTag)
@@ -798,6 +956,7 @@ func (c *controller) createPipelineRun(ctx context.Context, clusterID uint,
 		gitRefType = pipelinerun.GitRefType
 		gitRef = pipelinerun.GitRef
 		codeCommitID = pipelinerun.GitCommit
+		codeCommitTime = pipelinerun.GitCommitTime
 		imageURL = pipelinerun.ImageURL
 		rollbackFrom = &pipelinerun.ID
 		configCommitSHA = configCommit.Master
@@ -814,19 +973,27 @@ func (c *controller) createPipelineRun(ctx context.Context, clusterID uint,
 		return nil, perror.Wrapf(herrors.ErrParamInvalid, "unsupported action %v", r.Action)
 	}
 
+	// pipelineruns of protected environments must be approved before executed
+	requiredApprovals := 0
+	if policy := c.prApprovalConfig.Environments[cluster.EnvironmentName]; policy != nil {
+		requiredApprovals = policy.RequiredApprovals
+	}
+
 	return &prmodels.Pipelinerun{
-		ClusterID:        clusterID,
-		Action:           action,
-		Status:           string(prmodels.StatusPending),
-		Title:            title,
-		Description:      r.Description,
-		GitURL:           gitURL,
-		GitRefType:       gitRefType,
-		GitRef:           gitRef,
-		GitCommit:        codeCommitID,
-		ImageURL:         imageURL,
-		LastConfigCommit: lastConfigCommitSHA,
-		ConfigCommit:     configCommitSHA,
-		RollbackFrom:     rollbackFrom,
+		ClusterID:         clusterID,
+		Action:            action,
+		Status:            string(prmodels.StatusPending),
+		Title:             title,
+		Description:       r.Description,
+		GitURL:            gitURL,
+		GitRefType:        gitRefType,
+		GitRef:            gitRef,
+		GitCommit:         codeCommitID,
+		GitCommitTime:     codeCommitTime,
+		ImageURL:          imageURL,
+		LastConfigCommit:  lastConfigCommitSHA,
+		ConfigCommit:      configCommitSHA,
+		RollbackFrom:      rollbackFrom,
+		RequiredApprovals: requiredApprovals,
 	}, nil
 }
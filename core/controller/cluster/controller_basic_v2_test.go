@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
 	"github.com/horizoncd/horizon/lib/orm"
 	mock_code "github.com/horizoncd/horizon/mock/pkg/cluster/code"
 	mock_gitrepo "github.com/horizoncd/horizon/mock/pkg/cluster/gitrepo"
@@ -16,6 +17,9 @@ import (
 	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
 	clustergitrepo "github.com/horizoncd/horizon/pkg/cluster/gitrepo"
 	"github.com/horizoncd/horizon/pkg/cluster/models"
+	"github.com/horizoncd/horizon/pkg/config/approval"
+	"github.com/horizoncd/horizon/pkg/config/changerequest"
+	perror "github.com/horizoncd/horizon/pkg/errors"
 	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
 	"github.com/horizoncd/horizon/pkg/git"
 	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
@@ -25,6 +29,7 @@ import (
 	prservice "github.com/horizoncd/horizon/pkg/pr/service"
 	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
 	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
+	tagmodels "github.com/horizoncd/horizon/pkg/tag/models"
 	usermodel "github.com/horizoncd/horizon/pkg/user/models"
 )
 
@@ -151,3 +156,96 @@ func TestCreatePipelineRun(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "pending", pipelineBuildDeployPending.Status)
 }
+
+func TestChangeRequestRequired(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	if err := db.AutoMigrate(&models.Cluster{}); err != nil {
+		panic(err)
+	}
+	param := managerparam.InitManager(db)
+	ctx := context.Background()
+
+	cluster := &models.Cluster{Name: "cluster-cr", EnvironmentName: "online"}
+	assert.NoError(t, db.Create(cluster).Error)
+
+	c := &controller{
+		clusterMgr: param.ClusterMgr,
+		changeRequestConfig: changerequest.Config{
+			Environments: map[string]*changerequest.ApprovalRule{"online": {RequiredApprovals: 1}},
+		},
+	}
+
+	_, err := c.UpdateCluster(ctx, cluster.ID, &UpdateClusterRequest{
+		Base: &Base{TemplateInput: &TemplateInput{}},
+	}, false)
+	assert.Equal(t, herrors.ErrChangeRequestRequired, perror.Cause(err))
+
+	err = c.UpdateClusterV2(ctx, cluster.ID, &UpdateClusterRequestV2{
+		TemplateConfig: map[string]interface{}{},
+	}, false)
+	assert.Equal(t, herrors.ErrChangeRequestRequired, perror.Cause(err))
+
+	environment, region := "test", "hz"
+	for _, r := range []*UpdateClusterRequestV2{
+		{Tags: tagmodels.TagsBasic{{Key: "k", Value: "v"}}},
+		{Environment: &environment},
+		{Region: &region},
+	} {
+		err = c.UpdateClusterV2(ctx, cluster.ID, r, false)
+		assert.Equal(t, herrors.ErrChangeRequestRequired, perror.Cause(err))
+	}
+	for _, r := range []*UpdateClusterRequest{
+		{Base: &Base{Tags: []*tagmodels.TagBasic{{Key: "k", Value: "v"}}}},
+		{Environment: environment},
+		{Region: region},
+	} {
+		_, err = c.UpdateCluster(ctx, cluster.ID, r, false)
+		assert.Equal(t, herrors.ErrChangeRequestRequired, perror.Cause(err))
+	}
+
+	err = c.Upgrade(ctx, cluster.ID)
+	assert.Equal(t, herrors.ErrChangeRequestRequired, perror.Cause(err))
+}
+
+func TestApprovalRequired(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	if err := db.AutoMigrate(&models.Cluster{}, &prmodels.Pipelinerun{}); err != nil {
+		panic(err)
+	}
+	param := managerparam.InitManager(db)
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "Tony",
+		ID:   1,
+	})
+
+	cluster := &models.Cluster{Name: "cluster-approval", EnvironmentName: "online",
+		GitURL: "ssh://git@cloudnative.com:22/demo/demo.git"}
+	assert.NoError(t, db.Create(cluster).Error)
+	pr, err := param.PRMgr.PipelineRun.Create(ctx, &prmodels.Pipelinerun{
+		ClusterID:    cluster.ID,
+		Action:       prmodels.ActionDeploy,
+		Status:       string(prmodels.StatusOK),
+		ConfigCommit: "config-commit",
+	})
+	assert.NoError(t, err)
+
+	c := &controller{
+		clusterMgr: param.ClusterMgr,
+		prMgr:      param.PRMgr,
+		prApprovalConfig: approval.Config{
+			Environments: map[string]*approval.Policy{"online": {RequiredApprovals: 1}},
+		},
+	}
+
+	_, err = c.Deploy(ctx, cluster.ID, &DeployRequest{})
+	assert.Equal(t, herrors.ErrApprovalRequired, perror.Cause(err))
+
+	_, err = c.BuildDeploy(ctx, cluster.ID, &BuildDeployRequest{})
+	assert.Equal(t, herrors.ErrApprovalRequired, perror.Cause(err))
+
+	_, err = c.Rollback(ctx, cluster.ID, &RollbackRequest{PipelinerunID: pr.ID})
+	assert.Equal(t, herrors.ErrApprovalRequired, perror.Cause(err))
+
+	_, err = c.Restart(ctx, cluster.ID)
+	assert.Equal(t, herrors.ErrApprovalRequired, perror.Cause(err))
+}
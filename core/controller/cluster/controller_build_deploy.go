@@ -55,6 +55,9 @@ func (c *controller) BuildDeploy(ctx context.Context, clusterID uint,
 	if cluster.GitURL == "" {
 		return nil, herrors.ErrBuildDeployNotSupported
 	}
+	if err := c.checkApprovalRequired(cluster); err != nil {
+		return nil, err
+	}
 
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
 	if err != nil {
@@ -107,6 +110,7 @@ func (c *controller) BuildDeploy(ctx context.Context, clusterID uint,
 		GitRefType:       gitRefType,
 		GitRef:           gitRef,
 		GitCommit:        commit.ID,
+		GitCommitTime:    commit.CommittedAt,
 		ImageURL:         imageURL,
 		LastConfigCommit: configCommit.Master,
 		ConfigCommit:     configCommit.Gitops,
@@ -0,0 +1,265 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	admissionmodels "github.com/horizoncd/horizon/pkg/admission/models"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	"github.com/horizoncd/horizon/pkg/auth"
+	codemodels "github.com/horizoncd/horizon/pkg/cluster/code"
+	hctx "github.com/horizoncd/horizon/pkg/context"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	tagmodels "github.com/horizoncd/horizon/pkg/tag/models"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/mergemap"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+func (c *controller) CloneCluster(ctx context.Context, clusterID uint,
+	r *CloneClusterRequest) (*CreateClusterResponseV2, error) {
+	const op = "cluster controller: clone cluster"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if r.Environment == "" || r.Region == "" {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "environment and region cannot be empty")
+	}
+
+	// 1. get source cluster and its application
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+	// the permission to clone the source cluster does not imply the permission to create
+	// clusters in the target environment, so check it as the create cluster api does
+	scope := fmt.Sprintf("%s/%s", r.Environment, r.Region)
+	if err := c.checkCreateClusterPermission(ctx, application, scope); err != nil {
+		return nil, err
+	}
+
+	// 2. get values of source cluster and apply overrides
+	files, err := c.clusterGitRepo.GetCluster(ctx, application.Name, cluster.Name, cluster.Template)
+	if err != nil {
+		return nil, err
+	}
+	buildConfig := files.PipelineJSONBlob
+	if r.BuildConfigOverride != nil {
+		buildConfig, err = mergemap.Merge(buildConfig, r.BuildConfigOverride)
+		if err != nil {
+			return nil, err
+		}
+	}
+	templateConfig := files.ApplicationJSONBlob
+	if r.TemplateConfigOverride != nil {
+		templateConfig, err = mergemap.Merge(templateConfig, r.TemplateConfigOverride)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. get the output of the last build, so that the image can be promoted without rebuilding
+	var pipelineOutput interface{}
+	if r.ReuseImage {
+		pipelineOutput, err = c.clusterGitRepo.GetPipelineOutput(ctx,
+			application.Name, cluster.Name, cluster.Template)
+		if err != nil {
+			if perror.Cause(err) == herrors.ErrPipelineOutputEmpty {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid,
+					"cluster %s has not been built yet, image cannot be reused", cluster.Name)
+			}
+			return nil, err
+		}
+		if pipelineOutput == nil {
+			return nil, perror.Wrapf(herrors.ErrParamInvalid,
+				"cluster %s has not been built yet, image cannot be reused", cluster.Name)
+		}
+	}
+
+	// 4. get tags and members
+	tags, err := c.tagMgr.ListByResourceTypeID(ctx, common.ResourceCluster, cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	extraMembers, err := c.listClusterMemberEmails(ctx, cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. create the new cluster
+	request := &CreateClusterRequestV2{
+		Name:        r.Name,
+		Description: r.Description,
+		ExpireTime:  r.ExpireTime,
+		Tags:        tagmodels.Tags(tags).IntoTagsBasic(),
+		TemplateInfo: &codemodels.TemplateInfo{
+			Name:    cluster.Template,
+			Release: cluster.TemplateRelease,
+		},
+		BuildConfig:    buildConfig,
+		TemplateConfig: templateConfig,
+		ExtraMembers:   extraMembers,
+	}
+	if request.Description == "" {
+		request.Description = cluster.Description
+	}
+	if cluster.GitURL != "" {
+		request.Git = codemodels.NewGit(cluster.GitURL, cluster.GitSubfolder,
+			cluster.GitRefType, cluster.GitRef)
+		// keep inheriting git url from application
+		if cluster.GitURL == application.GitURL {
+			request.Git.URL = ""
+		}
+	}
+	if cluster.Image != "" {
+		image := cluster.Image
+		request.Image = &image
+	}
+	request, err = c.admitCreateCluster(ctx, application, scope, request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.CreateClusterV2(ctx, &CreateClusterParamsV2{
+		CreateClusterRequestV2: request,
+		ApplicationID:          application.ID,
+		Environment:            r.Environment,
+		Region:                 r.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. promote the image of source cluster
+	if r.ReuseImage {
+		tr, err := c.templateReleaseMgr.GetByTemplateNameAndRelease(ctx,
+			cluster.Template, cluster.TemplateRelease)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := c.clusterGitRepo.UpdatePipelineOutput(ctx, application.Name, resp.Name,
+			tr.ChartName, pipelineOutput); err != nil {
+			return nil, err
+		}
+	}
+	log.Infof(ctx, "cluster %s cloned from cluster %s", resp.Name, cluster.Name)
+	return resp, nil
+}
+
+// checkCreateClusterPermission requires the current user to be allowed to create clusters
+// in the scope of application
+func (c *controller) checkCreateClusterPermission(ctx context.Context,
+	application *appmodels.Application, scope string) error {
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	decision, reason, err := c.authorizer.Authorize(ctx, auth.AttributesRecord{
+		User:            currentUser,
+		Verb:            "create",
+		APIGroup:        common.GroupCore,
+		APIVersion:      "v2",
+		Resource:        common.ResourceApplication,
+		SubResource:     common.ResourceCluster,
+		Name:            strconv.FormatUint(uint64(application.ID), 10),
+		Scope:           scope,
+		ResourceRequest: true,
+		Path:            fmt.Sprintf("/apis/core/v2/applications/%d/clusters", application.ID),
+	})
+	if err != nil {
+		return err
+	}
+	if decision != auth.DecisionAllow {
+		return perror.Wrapf(herrors.ErrForbidden,
+			"no permission to create clusters of application %s in %s: %s", application.Name, scope, reason)
+	}
+	return nil
+}
+
+// admitCreateCluster passes the request to create cluster through the admission webhooks,
+// and returns the request mutated by them
+func (c *controller) admitCreateCluster(ctx context.Context, application *appmodels.Application,
+	scope string, request *CreateClusterRequestV2) (*CreateClusterRequestV2, error) {
+	if c.admit == nil {
+		return request, nil
+	}
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	var object interface{}
+	if err := json.Unmarshal(requestBytes, &object); err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	object, err = c.admit(ctx, admissionmodels.OperationCreate, common.ResourceApplication,
+		strconv.FormatUint(uint64(application.ID), 10), common.ResourceCluster, "v2",
+		object, map[string]interface{}{hctx.ParamScope: scope})
+	if err != nil {
+		extra := err.Error()
+		c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceApplication, application.ID,
+			eventmodels.AdmissionDenied, &extra)
+		return nil, perror.WithMessage(err, "admission failed")
+	}
+	if object == nil {
+		return request, nil
+	}
+	mutatedBytes, err := json.Marshal(object)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	var mutated *CreateClusterRequestV2
+	if err := json.Unmarshal(mutatedBytes, &mutated); err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid mutated request: %v", err)
+	}
+	return mutated, nil
+}
+
+// listClusterMemberEmails returns the direct user members of cluster as email to role
+func (c *controller) listClusterMemberEmails(ctx context.Context, clusterID uint) (map[string]string, error) {
+	members, err := c.memberManager.ListDirectMember(ctx, membermodels.TypeApplicationCluster, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		if member.MemberType == membermodels.MemberUser {
+			userIDs = append(userIDs, member.MemberNameID)
+		}
+	}
+	userMap, err := c.userManager.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	emails := make(map[string]string, len(userIDs))
+	for _, member := range members {
+		if member.MemberType != membermodels.MemberUser {
+			continue
+		}
+		if user, ok := userMap[member.MemberNameID]; ok {
+			emails[user.Email] = member.Role
+		}
+	}
+	return emails, nil
+}
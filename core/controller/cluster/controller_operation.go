@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -49,6 +50,9 @@ func (c *controller) Restart(ctx context.Context, clusterID uint) (_ *Pipelineru
 	if err != nil {
 		return nil, err
 	}
+	if err := c.checkApprovalRequired(cluster); err != nil {
+		return nil, err
+	}
 
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
 	if err != nil {
@@ -129,6 +133,9 @@ func (c *controller) Deploy(ctx context.Context, clusterID uint,
 	if err != nil {
 		return nil, err
 	}
+	if err := c.checkApprovalRequired(cluster); err != nil {
+		return nil, err
+	}
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
 	if err != nil {
 		return nil, err
@@ -146,6 +153,7 @@ func (c *controller) Deploy(ctx context.Context, clusterID uint,
 		return nil, err
 	}
 	codeCommitID := cluster.GitRef
+	var codeCommitTime *time.Time
 	imageURL := cluster.Image
 
 	if cluster.GitURL != "" {
@@ -156,6 +164,7 @@ func (c *controller) Deploy(ctx context.Context, clusterID uint,
 		commit, err := c.commitGetter.GetCommit(ctx, cluster.GitURL, cluster.GitRefType, cluster.GitRef)
 		if err == nil {
 			codeCommitID = commit.ID
+			codeCommitTime = commit.CommittedAt
 		}
 	} else if cluster.Image != "" {
 		imageURL, err = getDeployImage(cluster.Image, r.ImageTag)
@@ -172,6 +181,7 @@ func (c *controller) Deploy(ctx context.Context, clusterID uint,
 		GitRefType:       cluster.GitRefType,
 		GitRef:           cluster.GitRef,
 		GitCommit:        codeCommitID,
+		GitCommitTime:    codeCommitTime,
 		ImageURL:         imageURL,
 		LastConfigCommit: configCommit.Master,
 		ConfigCommit:     configCommit.Gitops,
@@ -271,6 +281,16 @@ func (c *controller) checkAllowDeploy(ctx context.Context,
 	return nil
 }
 
+// checkApprovalRequired rejects the direct deployments of clusters in environments protected by
+// pipelinerun approvals, which must be deployed by pipelineruns approved by cluster members
+func (c *controller) checkApprovalRequired(cluster *cmodels.Cluster) error {
+	if policy := c.prApprovalConfig.Environments[cluster.EnvironmentName]; policy != nil {
+		return perror.Wrapf(herrors.ErrApprovalRequired,
+			"clusters in environment %s can only be deployed by approved pipelineruns", cluster.EnvironmentName)
+	}
+	return nil
+}
+
 func getDeployImage(imageURL, deployTag string) (string, error) {
 	imageRef, err := name.ParseReference(imageURL)
 	if err != nil {
@@ -308,6 +328,9 @@ func (c *controller) Rollback(ctx context.Context,
 		return nil, perror.Wrapf(herrors.ErrParamInvalid,
 			"the pipelinerun with id: %v is not belongs to cluster: %v", r.PipelinerunID, clusterID)
 	}
+	if err := c.checkApprovalRequired(cluster); err != nil {
+		return nil, err
+	}
 
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
 	if err != nil {
@@ -330,6 +353,7 @@ func (c *controller) Rollback(ctx context.Context,
 		GitRefType:       pipelinerun.GitRefType,
 		GitRef:           pipelinerun.GitRef,
 		GitCommit:        pipelinerun.GitCommit,
+		GitCommitTime:    pipelinerun.GitCommitTime,
 		ImageURL:         pipelinerun.ImageURL,
 		LastConfigCommit: lastConfigCommit.Master,
 		ConfigCommit:     lastConfigCommit.Master,
@@ -655,6 +679,9 @@ func (c *controller) Upgrade(ctx context.Context, clusterID uint) error {
 	if err != nil {
 		return err
 	}
+	if err := c.checkChangeRequestRequired(cluster); err != nil {
+		return err
+	}
 	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
 	if err != nil {
 		return err
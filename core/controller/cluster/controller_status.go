@@ -371,6 +371,20 @@ func (c *controller) GetContainerLog(ctx context.Context, clusterID uint, podNam
 	return c.k8sutil.GetContainerLog(ctx, &param)
 }
 
+func (c *controller) ListJobRuns(ctx context.Context, clusterID uint, limit int) ([]cd.JobRun, error) {
+	cluster, _, _, regionEntity, envValue, err := c.retrieveClusterCtx(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.k8sutil.ListJobRuns(ctx, &cd.ListJobRunsParams{
+		RegionEntity: regionEntity,
+		Namespace:    envValue.Namespace,
+		Name:         cluster.Name,
+		Limit:        limit,
+	})
+}
+
 func (c *controller) GetPodEvents(ctx context.Context, clusterID uint, podName string) (interface{}, error) {
 	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
 	if err != nil {
@@ -533,6 +547,7 @@ func (c *controller) GetStep(ctx context.Context, clusterID uint) (resp *GetStep
 			Index:        steps.Index,
 			Replicas:     steps.Replicas,
 			ManualPaused: steps.ManualPaused,
+			Paused:       steps.Paused,
 			AutoPromote:  steps.AutoPromote,
 			Extra:        steps.Extra,
 		}
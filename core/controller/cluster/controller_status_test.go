@@ -16,6 +16,7 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,7 @@ import (
 	"github.com/horizoncd/horizon/pkg/cd"
 	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
 	perror "github.com/horizoncd/horizon/pkg/errors"
+	hpamodels "github.com/horizoncd/horizon/pkg/hpa/models"
 	"github.com/horizoncd/horizon/pkg/param/managerparam"
 	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
 	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
@@ -41,8 +43,9 @@ func testGetClusterStatusV2(t *testing.T) {
 	clusterManagerMock := clustermanagermock.NewMockManager(mockCtl)
 	appManagerMock := applicationmanangermock.NewMockManager(mockCtl)
 	mockCD := cdmock.NewMockCD(mockCtl)
+	mockK8sUtil := cdmock.NewMockK8sUtil(mockCtl)
 	db, _ := orm.NewSqliteDB("")
-	_ = db.AutoMigrate(&regionmodels.Region{}, &registrymodels.Registry{})
+	_ = db.AutoMigrate(&regionmodels.Region{}, &registrymodels.Registry{}, &hpamodels.Override{})
 	manager := managerparam.InitManager(db)
 
 	regionName := "test"
@@ -53,6 +56,8 @@ func testGetClusterStatusV2(t *testing.T) {
 		applicationMgr: appManagerMock,
 		regionMgr:      manager.RegionMgr,
 		cd:             mockCD,
+		k8sutil:        mockK8sUtil,
+		hpaOverrideMgr: manager.HPAOverrideMgr,
 	}
 
 	_, err := manager.RegistryMgr.Create(ctx, &registrymodels.Registry{
@@ -85,13 +90,35 @@ func testGetClusterStatusV2(t *testing.T) {
 	mockCD.EXPECT().GetClusterState(gomock.Any(), gomock.Any()).Times(1).
 		Return(nil, perror.Wrap(herrors.NewErrNotFound(herrors.ApplicationInArgo, ""), ""))
 
+	hpaNode := cd.ResourceNode{}
+	hpaNode.Group, hpaNode.Kind, hpaNode.Namespace, hpaNode.Name =
+		cd.GKHPA.Group, cd.GKHPA.Kind, "ns", "hpa"
+	mockCD.EXPECT().GetResourceTree(gomock.Any(), gomock.Any()).Times(1).
+		Return([]cd.ResourceNode{hpaNode}, nil)
+	mockCD.EXPECT().GetResourceTree(gomock.Any(), gomock.Any()).Times(1).
+		Return([]cd.ResourceNode{}, nil)
+	mockK8sUtil.EXPECT().GetHPAStatus(gomock.Any(), gomock.Any()).Times(1).
+		Return(&cd.HPAStatus{Name: "hpa", MaxReplicas: 10, CurrentReplicas: 2, DesiredReplicas: 3}, nil)
+	_, err = manager.HPAOverrideMgr.Create(ctx, &hpamodels.Override{
+		ClusterID:   0,
+		Name:        "hpa",
+		MaxReplicas: 20,
+		ExpireAt:    time.Now().Add(time.Hour),
+	})
+	assert.Nil(t, err)
+
 	resp, err := c.GetClusterStatusV2(ctx, 1)
 	assert.Nil(t, err)
 	assert.Equal(t, status, resp.Status)
+	assert.Equal(t, 1, len(resp.HPAs))
+	assert.Equal(t, int32(3), resp.HPAs[0].DesiredReplicas)
+	assert.NotNil(t, resp.HPAs[0].Override)
+	assert.Equal(t, int32(20), resp.HPAs[0].Override.MaxReplicas)
 
 	resp, err = c.GetClusterStatusV2(ctx, 1)
 	assert.Nil(t, err)
 	assert.Equal(t, common.ClusterStatusCreating, resp.Status)
+	assert.Equal(t, 0, len(resp.HPAs))
 
 	resp, err = c.GetClusterStatusV2(ctx, 1)
 	assert.Nil(t, err)
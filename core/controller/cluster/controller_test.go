@@ -25,7 +25,10 @@ import (
 	"time"
 
 	tektoncollectormock "github.com/horizoncd/horizon/mock/pkg/cluster/tekton/collector"
+	admissionwebhook "github.com/horizoncd/horizon/pkg/admission"
+	admissionmodels "github.com/horizoncd/horizon/pkg/admission/models"
 	appservice "github.com/horizoncd/horizon/pkg/application/service"
+	"github.com/horizoncd/horizon/pkg/auth"
 	badgemodels "github.com/horizoncd/horizon/pkg/badge/models"
 	clustercd "github.com/horizoncd/horizon/pkg/cd"
 	eventservice "github.com/horizoncd/horizon/pkg/event/service"
@@ -1432,6 +1435,14 @@ func testV2(t *testing.T) {
 		cd:                   mockCd,
 		eventSvc:             eventservice.New(manager),
 		memberManager:        manager.MemberMgr,
+		admit:                admissionwebhook.Admit,
+		authorizer: authorizerFunc(func(_ context.Context, attr auth.Attributes) (auth.Decision, string, error) {
+			// only allowed to create clusters in test2
+			if attr.GetScope() == "test2/hz" {
+				return auth.DecisionAllow, "", nil
+			}
+			return auth.DecisionDeny, "", nil
+		}),
 	}
 	applicationGitRepo.EXPECT().GetApplication(gomock.Any(), applicationName, gomock.Any()).
 		Return(&appgitrepo.GetResponse{
@@ -1552,6 +1563,81 @@ func testV2(t *testing.T) {
 	t.Logf("%+v", err)
 	assert.Nil(t, err)
 
+	// clone cluster
+	cloneClusterName := "app-cluster2-clone"
+	_, err = c.CloneCluster(ctx, getClusterResp.ID, &CloneClusterRequest{
+		Name:        cloneClusterName,
+		Environment: "online",
+		Region:      "hz",
+	})
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+
+	// clone denied by admission webhooks creates no cluster
+	deniedClusterName := "app-cluster2-denied"
+	admissionwebhook.Register(admissionmodels.KindValidating, &denyWebhook{clusterName: deniedClusterName})
+	clusterGitRepo.EXPECT().GetCluster(ctx, applicationName, createClusterName, templateName).Return(&gitrepo.ClusterFiles{
+		PipelineJSONBlob:    pipelineJSONBlob,
+		ApplicationJSONBlob: applicationJSONBlob,
+		Manifest:            manifest,
+	}, nil).Times(1)
+	_, err = c.CloneCluster(ctx, getClusterResp.ID, &CloneClusterRequest{
+		Name:        deniedClusterName,
+		Environment: "test2",
+		Region:      "hz",
+	})
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+	_, err = manager.ClusterMgr.GetByName(ctx, deniedClusterName)
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	pipelineOutput := map[string]interface{}{"image": "harbor.com/app/cluster:v1"}
+	var sourceApplicationJSONBlob map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(applicationJSONStr), &sourceApplicationJSONBlob))
+	clusterGitRepo.EXPECT().GetCluster(ctx, applicationName, createClusterName, templateName).Return(&gitrepo.ClusterFiles{
+		PipelineJSONBlob:    pipelineJSONBlob,
+		ApplicationJSONBlob: sourceApplicationJSONBlob,
+		Manifest:            manifest,
+	}, nil).Times(1)
+	clusterGitRepo.EXPECT().GetPipelineOutput(ctx, applicationName, createClusterName, templateName).
+		Return(pipelineOutput, nil).Times(1)
+	applicationGitRepo.EXPECT().GetApplication(gomock.Any(), applicationName, gomock.Any()).
+		Return(&appgitrepo.GetResponse{
+			BuildConf:    pipelineJSONBlob,
+			TemplateConf: applicationJSONBlob,
+		}, nil).Times(1)
+	clusterGitRepo.EXPECT().CreateCluster(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, params *gitrepo.CreateClusterParams) error {
+			assert.Equal(t, "test2", params.Environment)
+			assert.Equal(t, "cloned", params.ApplicationJSONBlob["app"].(map[string]interface{})["description"])
+			return nil
+		}).Times(1)
+	templateSchemaGetter.EXPECT().GetTemplateSchema(gomock.Any(), templateName, "v1.0.0", gomock.Any()).
+		Return(&trschema.Schemas{
+			Application: &trschema.Schema{
+				JSONSchema: applicationSchema,
+			},
+			Pipeline: &trschema.Schema{
+				JSONSchema: pipelineSchema,
+			},
+		}, nil).Times(1)
+	clusterGitRepo.EXPECT().UpdatePipelineOutput(ctx, applicationName, cloneClusterName, templateName,
+		pipelineOutput).Return("", nil).Times(1)
+	cloneResp, err := c.CloneCluster(ctx, getClusterResp.ID, &CloneClusterRequest{
+		Name:        cloneClusterName,
+		Environment: "test2",
+		Region:      "hz",
+		TemplateConfigOverride: map[string]interface{}{
+			"app": map[string]interface{}{"description": "cloned"},
+		},
+		ReuseImage: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, cloneClusterName, cloneResp.Name)
+	clonedTags, err := manager.TagMgr.ListByResourceTypeID(ctx, common.ResourceCluster, cloneResp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(clonedTags))
+	assert.Equal(t, "value2", clonedTags[0].Value)
+
 	registry := registrymock.NewMockRegistry(mockCtl)
 	registryFty.EXPECT().GetRegistryByConfig(gomock.Any(), gomock.Any()).Return(registry, nil).Times(1)
 	registry.EXPECT().DeleteImage(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
@@ -1564,6 +1650,34 @@ func testV2(t *testing.T) {
 	time.Sleep(time.Second * 5)
 }
 
+// authorizerFunc adapts a function to rbac.Authorizer
+type authorizerFunc func(ctx context.Context, attr auth.Attributes) (auth.Decision, string, error)
+
+func (f authorizerFunc) Authorize(ctx context.Context, attr auth.Attributes) (auth.Decision, string, error) {
+	return f(ctx, attr)
+}
+
+// denyWebhook denies the creation of the cluster with specified name
+type denyWebhook struct {
+	clusterName string
+}
+
+func (w *denyWebhook) Name() string { return "deny" }
+
+func (w *denyWebhook) Handle(context.Context, *admissionwebhook.Request) (*admissionwebhook.Response, error) {
+	allowed := false
+	return &admissionwebhook.Response{Allowed: &allowed, Result: "denied"}, nil
+}
+
+func (w *denyWebhook) IgnoreError() bool { return false }
+
+func (w *denyWebhook) Timeout() time.Duration { return time.Second }
+
+func (w *denyWebhook) Interest(req *admissionwebhook.Request) bool {
+	object, ok := req.Object.(map[string]interface{})
+	return ok && req.Operation.Eq(admissionmodels.OperationCreate) && object["name"] == w.clusterName
+}
+
 func testUpgrade(t *testing.T) {
 	// for test
 	conf := config.Config{}
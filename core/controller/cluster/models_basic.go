@@ -56,6 +56,12 @@ type UpdateClusterRequest struct {
 	ExpireTime  string `json:"expireTime"`
 }
 
+// changesConfig returns whether the request changes the config of cluster in git repo
+func (r *UpdateClusterRequest) changesConfig() bool {
+	return r.Environment != "" || r.Region != "" ||
+		r.Base != nil && (r.TemplateInput != nil || r.Template != nil || r.Tags != nil)
+}
+
 type GetClusterResponse struct {
 	*CreateClusterRequest
 
@@ -161,6 +161,12 @@ type UpdateClusterRequestV2 struct {
 	TemplateConfig map[string]interface{}   `json:"templateConfig"`
 }
 
+// changesConfig returns whether the request changes the config of cluster in git repo
+func (r *UpdateClusterRequestV2) changesConfig() bool {
+	return r.BuildConfig != nil || r.TemplateInfo != nil || r.TemplateConfig != nil ||
+		r.Tags != nil || r.Environment != nil || r.Region != nil
+}
+
 func (r *UpdateClusterRequestV2) toClusterModel(cluster *models.Cluster, expireSeconds uint, environmentName,
 	regionName, templateName, templateRelease string) (*models.Cluster, []*tagmodels.Tag) {
 	var gitURL, gitSubFolder, gitRef, gitRefType, image string
@@ -0,0 +1,31 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+// CloneClusterRequest clones a cluster into another environment/region,
+// the values of the source cluster are merged with the override documents.
+type CloneClusterRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Environment string `json:"environment"`
+	Region      string `json:"region"`
+	ExpireTime  string `json:"expireTime"`
+
+	BuildConfigOverride    map[string]interface{} `json:"buildConfigOverride"`
+	TemplateConfigOverride map[string]interface{} `json:"templateConfigOverride"`
+
+	// ReuseImage promotes the image built for the source cluster instead of rebuilding it
+	ReuseImage bool `json:"reuseImage"`
+}
@@ -15,7 +15,10 @@
 package cluster
 
 import (
+	"time"
+
 	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	"github.com/horizoncd/horizon/pkg/cd"
 	"github.com/horizoncd/horizon/pkg/grafana"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -32,6 +35,21 @@ type Revision struct {
 
 type StatusResponseV2 struct {
 	Status string `json:"status"`
+	// HPAs are the horizontal pod autoscalers in the resource tree of cluster
+	HPAs []*HPAStatus `json:"hpas,omitempty"`
+}
+
+type HPAStatus struct {
+	*cd.HPAStatus
+	Override *HPAOverride `json:"override,omitempty"`
+}
+
+// HPAOverride is the active override of min and max replicas of a horizontal pod autoscaler
+type HPAOverride struct {
+	ID          uint      `json:"id"`
+	MinReplicas *int32    `json:"minReplicas,omitempty"`
+	MaxReplicas int32     `json:"maxReplicas"`
+	ExpireAt    time.Time `json:"expireAt"`
 }
 
 type PipelinerunStatusResponse struct {
@@ -83,6 +101,7 @@ type GetStepResponse struct {
 	Total        int     `json:"total"`
 	Replicas     []int   `json:"replicas"`
 	ManualPaused bool    `json:"manualPaused"`
+	Paused       bool    `json:"paused"`
 	AutoPromote  bool    `json:"autoPromote"`
 	Extra        *string `json:"extra"`
 }
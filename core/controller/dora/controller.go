@@ -0,0 +1,94 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dora
+
+import (
+	"context"
+	"time"
+
+	applicationmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/pr/dora"
+	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Query struct {
+	// Environment selects the deployments of the environment, all environments if empty
+	Environment string
+	Start       time.Time
+	End         time.Time
+	// Step splits the range into series, no series if 0
+	Step time.Duration
+}
+
+type Controller interface {
+	// GetApplicationMetrics computes the DORA metrics of the deployments of the application
+	GetApplicationMetrics(ctx context.Context, applicationID uint, query *Query) (*dora.Report, error)
+	// GetGroupMetrics computes the DORA metrics of the deployments of the group and its subgroups
+	GetGroupMetrics(ctx context.Context, groupID uint, query *Query) (*dora.Report, error)
+}
+
+type controller struct {
+	applicationMgr applicationmanager.Manager
+	groupMgr       groupmanager.Manager
+	prMgr          *prmanager.PRManager
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(param *param.Param) Controller {
+	return &controller{
+		applicationMgr: param.ApplicationMgr,
+		groupMgr:       param.GroupMgr,
+		prMgr:          param.PRMgr,
+	}
+}
+
+func (c *controller) GetApplicationMetrics(ctx context.Context, applicationID uint,
+	query *Query) (*dora.Report, error) {
+	const op = "dora controller: get application metrics"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := c.applicationMgr.GetByID(ctx, applicationID); err != nil {
+		return nil, err
+	}
+	return c.compute(ctx, &prmodels.DeploymentQuery{ApplicationID: applicationID}, query)
+}
+
+func (c *controller) GetGroupMetrics(ctx context.Context, groupID uint, query *Query) (*dora.Report, error) {
+	const op = "dora controller: get group metrics"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	group, err := c.groupMgr.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return c.compute(ctx, &prmodels.DeploymentQuery{GroupTraversalIDs: group.TraversalIDs}, query)
+}
+
+func (c *controller) compute(ctx context.Context, deploymentQuery *prmodels.DeploymentQuery,
+	query *Query) (*dora.Report, error) {
+	deploymentQuery.Environment = query.Environment
+	deploymentQuery.Start = query.Start
+	deploymentQuery.End = query.End
+	deployments, err := c.prMgr.PipelineRun.ListDeployments(ctx, deploymentQuery)
+	if err != nil {
+		return nil, err
+	}
+	return dora.Compute(deployments, query.Start, query.End, query.Step), nil
+}
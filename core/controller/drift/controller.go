@@ -0,0 +1,362 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	appmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	badgemanager "github.com/horizoncd/horizon/pkg/badge/manager"
+	badgemodels "github.com/horizoncd/horizon/pkg/badge/models"
+	"github.com/horizoncd/horizon/pkg/cd"
+	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	driftconfig "github.com/horizoncd/horizon/pkg/config/drift"
+	"github.com/horizoncd/horizon/pkg/drift/detect"
+	driftmanager "github.com/horizoncd/horizon/pkg/drift/manager"
+	"github.com/horizoncd/horizon/pkg/drift/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
+	"github.com/horizoncd/horizon/pkg/regioninformers"
+	trmanager "github.com/horizoncd/horizon/pkg/templaterelease/manager"
+	"github.com/horizoncd/horizon/pkg/templaterepo"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+// BadgeName is the name of badge shown on drifted clusters
+const BadgeName = "drift"
+
+type Controller interface {
+	// GetClusterDrift gets the latest drift detected for the cluster
+	GetClusterDrift(ctx context.Context, clusterID uint) (*ClusterDrift, error)
+	// DetectClusterDrift compares the live objects of cluster with the objects rendered from gitops repo,
+	// drifted clusters are marked by a badge and reverted if their environment requires
+	DetectClusterDrift(ctx context.Context, clusterID uint) (*ClusterDrift, error)
+	// RevertClusterDrift syncs the cluster to the latest revision deployed to revert the drift
+	RevertClusterDrift(ctx context.Context, clusterID uint) (*ClusterDrift, error)
+}
+
+// Informers gets the live objects in kubernetes, it is implemented by regioninformers.RegionInformers
+type Informers interface {
+	GetDynamicFactory(regionID uint, operation regioninformers.DynamicFactoryOperation) error
+	GetDynamicInformer(regionID uint, gvr schema.GroupVersionResource,
+		operation regioninformers.DynamicInformerOperation) error
+	GVK2GVR(regionID uint, gvk schema.GroupVersionKind) (schema.GroupVersionResource, error)
+	Namespaced(regionID uint, gvk schema.GroupVersionKind) (bool, error)
+}
+
+type controller struct {
+	config             *driftconfig.Config
+	driftMgr           driftmanager.Manager
+	applicationMgr     appmanager.Manager
+	clusterMgr         clustermanager.Manager
+	regionMgr          regionmanager.Manager
+	templateReleaseMgr trmanager.Manager
+	badgeMgr           badgemanager.Manager
+	userMgr            usermanager.Manager
+	clusterGitRepo     gitrepo.ClusterGitRepo
+	templateRepo       templaterepo.TemplateRepo
+	cd                 cd.CD
+	informers          Informers
+	eventSvc           eventservice.Service
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param, informers Informers,
+	templateRepo templaterepo.TemplateRepo) Controller {
+	return &controller{
+		config:             &config.DriftConfig,
+		driftMgr:           param.ClusterDriftMgr,
+		applicationMgr:     param.ApplicationMgr,
+		clusterMgr:         param.ClusterMgr,
+		regionMgr:          param.RegionMgr,
+		templateReleaseMgr: param.TemplateReleaseMgr,
+		badgeMgr:           param.BadgeMgr,
+		userMgr:            param.UserMgr,
+		clusterGitRepo:     param.ClusterGitRepo,
+		templateRepo:       templateRepo,
+		cd:                 param.CD,
+		informers:          informers,
+		eventSvc:           param.EventSvc,
+	}
+}
+
+func (c *controller) GetClusterDrift(ctx context.Context, clusterID uint) (*ClusterDrift, error) {
+	const op = "drift controller: get"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	drift, err := c.driftMgr.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, []uint{drift.RevertedBy})
+	if err != nil {
+		return nil, err
+	}
+	return ofClusterDriftModel(drift, users)
+}
+
+func (c *controller) DetectClusterDrift(ctx context.Context, clusterID uint) (*ClusterDrift, error) {
+	const op = "drift controller: detect"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Status != common.ClusterStatusEmpty {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"cluster is %s, drift can only be detected for running cluster", cluster.Status)
+	}
+	former, err := c.driftMgr.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		former = nil
+	}
+
+	// 1. compare the live objects with the desired ones,
+	// failures of rendering and fetching are recorded rather than returned
+	drift := &models.ClusterDrift{
+		ClusterID:  clusterID,
+		DetectedAt: time.Now(),
+	}
+	resources, err := c.detect(ctx, cluster)
+	if err != nil {
+		log.Warningf(ctx, "failed to detect drift of cluster %s: %v", cluster.Name, err)
+		drift.Message = err.Error()
+		if former != nil {
+			// keep the drift detected last time
+			drift.Drifted = former.Drifted
+			drift.Resources = former.Resources
+		}
+	} else if len(resources) > 0 {
+		content, err := json.Marshal(resources)
+		if err != nil {
+			return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+		}
+		drift.Drifted = true
+		drift.Resources = string(content)
+	}
+	if _, err := c.driftMgr.Upsert(ctx, drift); err != nil {
+		return nil, err
+	}
+
+	// 2. mark the drifted cluster by badge, and notify once the drift changes
+	if err := c.updateBadge(ctx, clusterID, drift.Drifted); err != nil {
+		return nil, err
+	}
+	if drift.Drifted && (former == nil || former.Resources != drift.Resources) {
+		c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceCluster, clusterID,
+			eventmodels.ClusterDriftDetected, &drift.Resources)
+		for _, environment := range c.config.AutoRevertEnvironments {
+			if environment == cluster.EnvironmentName {
+				return c.RevertClusterDrift(ctx, clusterID)
+			}
+		}
+	}
+	return c.GetClusterDrift(ctx, clusterID)
+}
+
+// detect renders the objects of cluster from the revision deployed, and compares them with the live ones
+func (c *controller) detect(ctx context.Context, cluster *clustermodels.Cluster) ([]*detect.Resource, error) {
+	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+	regionEntity, err := c.regionMgr.GetRegionEntity(ctx, cluster.RegionName)
+	if err != nil {
+		return nil, err
+	}
+	templateRelease, err := c.templateReleaseMgr.GetByTemplateNameAndRelease(ctx,
+		cluster.Template, cluster.TemplateRelease)
+	if err != nil {
+		return nil, err
+	}
+	templateChart, err := c.templateRepo.GetChart(templateRelease.ChartName,
+		templateRelease.ChartVersion, templateRelease.LastSyncAt)
+	if err != nil {
+		return nil, err
+	}
+	// clusters are deployed with the default branch after gitops branch is merged into it
+	revision := c.clusterGitRepo.DefaultBranch()
+	values, err := c.clusterGitRepo.GetValues(ctx, application.Name, cluster.Name, &revision)
+	if err != nil {
+		return nil, err
+	}
+	envValue, err := c.clusterGitRepo.GetEnvValue(ctx, application.Name, cluster.Name, cluster.Template)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := detect.Render(templateChart, cluster.Name, envValue.Namespace, values)
+	if err != nil {
+		return nil, err
+	}
+
+	// informers of region are created if not exist
+	if err := c.informers.GetDynamicFactory(regionEntity.ID,
+		func(dynamicinformer.DynamicSharedInformerFactory) error { return nil }); err != nil {
+		return nil, err
+	}
+	resources := make([]*detect.Resource, 0)
+	for _, object := range objects {
+		resource := &detect.Resource{
+			APIVersion: object.GetAPIVersion(),
+			Kind:       object.GetKind(),
+			Namespace:  object.GetNamespace(),
+			Name:       object.GetName(),
+		}
+		live, err := c.getLive(regionEntity.ID, object, resource)
+		if err != nil {
+			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+				return nil, err
+			}
+			resource.Missing = true
+			resources = append(resources, resource)
+			continue
+		}
+		if fields := detect.Compare(object, live, c.config.IgnoredFields); len(fields) > 0 {
+			resource.Fields = fields
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+// getLive gets the live object from informers, namespace of resource is cleared if it is cluster scoped
+func (c *controller) getLive(regionID uint, object *unstructured.Unstructured,
+	resource *detect.Resource) (*unstructured.Unstructured, error) {
+	gvk := object.GroupVersionKind()
+	gvr, err := c.informers.GVK2GVR(regionID, gvk)
+	if err != nil {
+		return nil, err
+	}
+	namespaced, err := c.informers.Namespaced(regionID, gvk)
+	if err != nil {
+		return nil, err
+	}
+	if !namespaced {
+		resource.Namespace = ""
+	}
+
+	var obj runtime.Object
+	if err := c.informers.GetDynamicInformer(regionID, gvr, func(informer informers.GenericInformer) error {
+		if namespaced {
+			obj, err = informer.Lister().ByNamespace(resource.Namespace).Get(resource.Name)
+		} else {
+			obj, err = informer.Lister().Get(resource.Name)
+		}
+		return err
+	}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, herrors.NewErrNotFound(herrors.ResourceInK8S,
+				fmt.Sprintf("%s %s/%s", resource.Kind, resource.Namespace, resource.Name))
+		}
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "failed to get %s %s: %v",
+			resource.Kind, resource.Name, err)
+	}
+	live, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "unexpected object of %s", resource.Kind)
+	}
+	return live, nil
+}
+
+func (c *controller) updateBadge(ctx context.Context, clusterID uint, drifted bool) error {
+	if !drifted {
+		return c.badgeMgr.DeleteByName(ctx, common.ResourceCluster, clusterID, BadgeName)
+	}
+	_, err := c.badgeMgr.GetByName(ctx, common.ResourceCluster, clusterID, BadgeName)
+	if err == nil {
+		return nil
+	}
+	if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+		return err
+	}
+	_, err = c.badgeMgr.Create(ctx, &badgemodels.Badge{
+		ResourceType: common.ResourceCluster,
+		ResourceID:   clusterID,
+		Name:         BadgeName,
+		SvgLink:      c.config.BadgeSvgLink,
+	})
+	return err
+}
+
+func (c *controller) RevertClusterDrift(ctx context.Context, clusterID uint) (*ClusterDrift, error) {
+	const op = "drift controller: revert"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	drift, err := c.driftMgr.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if !drift.Drifted {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "cluster has not drifted")
+	}
+	application, err := c.applicationMgr.GetByID(ctx, cluster.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := c.clusterGitRepo.GetConfigCommit(ctx, application.Name, cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	// syncing to the revision deployed overwrites the changes made in kubernetes
+	if err := c.cd.DeployCluster(ctx, &cd.DeployClusterParams{
+		Environment: cluster.EnvironmentName,
+		Cluster:     cluster.Name,
+		Revision:    commit.Master,
+		Region:      cluster.RegionName,
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reverted := &models.ClusterDrift{RevertedAt: &now}
+	if currentUser, err := common.UserFromContext(ctx); err == nil {
+		reverted.RevertedBy = currentUser.GetID()
+	}
+	if err := c.driftMgr.UpdateReverted(ctx, clusterID, reverted); err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceCluster, clusterID,
+		eventmodels.ClusterDriftReverted, nil)
+	return c.GetClusterDrift(ctx, clusterID)
+}
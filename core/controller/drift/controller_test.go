@@ -0,0 +1,144 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	badgemodels "github.com/horizoncd/horizon/pkg/badge/models"
+	"github.com/horizoncd/horizon/pkg/cd"
+	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	"github.com/horizoncd/horizon/pkg/drift/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
+	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+// fakeCD records the revisions deployed
+type fakeCD struct {
+	cd.CD
+	deployed []string
+}
+
+func (f *fakeCD) DeployCluster(ctx context.Context, params *cd.DeployClusterParams) error {
+	f.deployed = append(f.deployed, params.Revision)
+	return nil
+}
+
+type fakeClusterGitRepo struct {
+	gitrepo.ClusterGitRepo
+}
+
+func (f *fakeClusterGitRepo) GetConfigCommit(ctx context.Context, application,
+	cluster string) (*gitrepo.ClusterCommit, error) {
+	return &gitrepo.ClusterCommit{Master: "master-commit", Gitops: "gitops-commit"}, nil
+}
+
+func TestDrift(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "drift",
+		ID:   1,
+	})
+	_, err := manager.UserMgr.Create(ctx, &usermodels.User{Name: "drift"})
+	assert.Nil(t, err)
+	app, err := manager.ApplicationMgr.Create(ctx, &appmodels.Application{Name: "drift"}, nil)
+	assert.Nil(t, err)
+	cluster := &clustermodels.Cluster{
+		ApplicationID:   app.ID,
+		Name:            "drift-online",
+		EnvironmentName: "online",
+		RegionName:      "not-exists",
+	}
+	assert.Nil(t, db.Create(cluster).Error)
+
+	fakeCD := &fakeCD{}
+	c := NewController(&config.Config{}, &param.Param{
+		Manager:        manager,
+		ClusterGitRepo: &fakeClusterGitRepo{},
+		CD:             fakeCD,
+		EventSvc:       eventservice.New(manager),
+	}, nil, nil)
+
+	// nothing detected yet
+	_, err = c.GetClusterDrift(ctx, cluster.ID)
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	// drift detected last time is kept when detection fails
+	_, err = manager.ClusterDriftMgr.Upsert(ctx, &models.ClusterDrift{
+		ClusterID: cluster.ID,
+		Drifted:   true,
+		Resources: `[{"apiVersion":"apps/v1","kind":"Deployment","name":"drift-online",` +
+			`"fields":[{"path":"spec.template.spec.containers[name=app].image","desired":"v1","live":"v2"}]}]`,
+	})
+	assert.Nil(t, err)
+	drift, err := c.DetectClusterDrift(ctx, cluster.ID)
+	assert.Nil(t, err)
+	assert.True(t, drift.Drifted)
+	assert.NotEmpty(t, drift.Message)
+	assert.Equal(t, 1, len(drift.Resources))
+	assert.Equal(t, "v2", drift.Resources[0].Fields[0].Live)
+	_, err = manager.BadgeMgr.GetByName(ctx, common.ResourceCluster, cluster.ID, BadgeName)
+	assert.Nil(t, err)
+
+	// revert syncs the cluster to the revision deployed
+	drift, err = c.RevertClusterDrift(ctx, cluster.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"master-commit"}, fakeCD.deployed)
+	assert.NotNil(t, drift.RevertedAt)
+	assert.Equal(t, uint(1), drift.RevertedBy.ID)
+
+	// drift cannot be reverted once gone
+	_, err = manager.ClusterDriftMgr.Upsert(ctx, &models.ClusterDrift{ClusterID: cluster.ID})
+	assert.Nil(t, err)
+	_, err = c.RevertClusterDrift(ctx, cluster.ID)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	// freed cluster is not detected
+	assert.Nil(t, db.Model(cluster).Update("status", common.ClusterStatusFreed).Error)
+	_, err = c.DetectClusterDrift(ctx, cluster.ID)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&appmodels.Application{}, &usermodels.User{}, &clustermodels.Cluster{},
+		&models.ClusterDrift{}, &badgemodels.Badge{}, &regionmodels.Region{}, &registrymodels.Registry{},
+		&membermodels.Member{}, &eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,51 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/drift/detect"
+	"github.com/horizoncd/horizon/pkg/drift/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+type ClusterDrift struct {
+	ClusterID  uint                  `json:"clusterID"`
+	Drifted    bool                  `json:"drifted"`
+	Resources  []*detect.Resource    `json:"resources,omitempty"`
+	Message    string                `json:"message,omitempty"`
+	DetectedAt time.Time             `json:"detectedAt"`
+	RevertedAt *time.Time            `json:"revertedAt,omitempty"`
+	RevertedBy *usermodels.UserBasic `json:"revertedBy,omitempty"`
+}
+
+func ofClusterDriftModel(d *models.ClusterDrift, users map[uint]*usermodels.User) (*ClusterDrift, error) {
+	drift := &ClusterDrift{
+		ClusterID:  d.ClusterID,
+		Drifted:    d.Drifted,
+		Message:    d.Message,
+		DetectedAt: d.DetectedAt,
+		RevertedAt: d.RevertedAt,
+		RevertedBy: usermodels.ToUser(users[d.RevertedBy]),
+	}
+	if d.Resources != "" {
+		if err := json.Unmarshal([]byte(d.Resources), &drift.Resources); err != nil {
+			return nil, err
+		}
+	}
+	return drift, nil
+}
@@ -0,0 +1,374 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"context"
+	"time"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	appmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	elevationconfig "github.com/horizoncd/horizon/pkg/config/elevation"
+	elevationmanager "github.com/horizoncd/horizon/pkg/elevation/manager"
+	"github.com/horizoncd/horizon/pkg/elevation/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
+	membermanager "github.com/horizoncd/horizon/pkg/member/manager"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	memberservice "github.com/horizoncd/horizon/pkg/member/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Controller interface {
+	// CreateElevation requests an elevated role on the resource for the current user
+	CreateElevation(ctx context.Context, resourceType string, resourceID uint,
+		request *CreateElevationRequest) (*Elevation, error)
+	GetElevation(ctx context.Context, id uint) (*Elevation, error)
+	ListElevations(ctx context.Context, resourceType string, resourceID uint,
+		query *q.Query) ([]*Elevation, int64, error)
+	// ApproveElevation grants the requested role to the requester until the elevation expires
+	ApproveElevation(ctx context.Context, id uint) (*Elevation, error)
+	RejectElevation(ctx context.Context, id uint) (*Elevation, error)
+	// ExpireElevation revokes the elevated role and restores the previous one if any
+	ExpireElevation(ctx context.Context, id uint) error
+}
+
+type controller struct {
+	config         *elevationconfig.Config
+	elevationMgr   elevationmanager.Manager
+	memberMgr      membermanager.Manager
+	userMgr        usermanager.Manager
+	groupMgr       groupmanager.Manager
+	applicationMgr appmanager.Manager
+	clusterMgr     clustermanager.Manager
+	memberSvc      memberservice.Service
+	roleSvc        role.Service
+	eventSvc       eventservice.Service
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param) Controller {
+	return &controller{
+		config:         &config.ElevationConfig,
+		elevationMgr:   param.ElevationMgr,
+		memberMgr:      param.MemberMgr,
+		userMgr:        param.UserMgr,
+		groupMgr:       param.GroupMgr,
+		applicationMgr: param.ApplicationMgr,
+		clusterMgr:     param.ClusterMgr,
+		memberSvc:      param.MemberService,
+		roleSvc:        param.RoleService,
+		eventSvc:       param.EventSvc,
+	}
+}
+
+func (c *controller) checkResource(ctx context.Context, resourceType string, resourceID uint) error {
+	switch resourceType {
+	case common.ResourceGroup:
+		if _, err := c.groupMgr.GetByID(ctx, resourceID); err != nil {
+			return err
+		}
+	case common.ResourceApplication:
+		if _, err := c.applicationMgr.GetByID(ctx, resourceID); err != nil {
+			return err
+		}
+	case common.ResourceCluster:
+		if _, err := c.clusterMgr.GetByID(ctx, resourceID); err != nil {
+			return err
+		}
+	default:
+		return perror.Wrapf(herrors.ErrParamInvalid, "invalid resource type: %s", resourceType)
+	}
+	return nil
+}
+
+func (c *controller) CreateElevation(ctx context.Context, resourceType string, resourceID uint,
+	request *CreateElevationRequest) (*Elevation, error) {
+	const op = "elevation controller: create"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. validate request
+	if err := c.checkResource(ctx, resourceType, resourceID); err != nil {
+		return nil, err
+	}
+	if _, err := c.roleSvc.GetRole(ctx, request.Role); err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid role: %s", request.Role)
+	}
+	duration := time.Duration(request.DurationSeconds) * time.Second
+	if duration <= 0 || duration > c.config.MaxDuration {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"duration should be positive and no longer than %v", c.config.MaxDuration)
+	}
+	if request.Justification == "" {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "justification should not be empty")
+	}
+
+	// 2. no need to elevate if the user already holds the role
+	if err := c.memberSvc.RequirePermissionEqualOrHigher(ctx, request.Role,
+		resourceType, resourceID); err == nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"you already have the privilege of role %s", request.Role)
+	} else if perror.Cause(err) != herrors.ErrNoPrivilege {
+		return nil, err
+	}
+
+	// 3. only one pending elevation is allowed for a user on a resource
+	_, total, err := c.elevationMgr.List(ctx, resourceType, resourceID, q.New(q.KeyWords{
+		common.ElevationQueryByStatus: models.StatusPending,
+		common.ElevationQueryByUser:   currentUser.GetID(),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "there is already a pending elevation")
+	}
+
+	elevation, err := c.elevationMgr.Create(ctx, &models.Elevation{
+		ResourceType:  resourceType,
+		ResourceID:    resourceID,
+		UserID:        currentUser.GetID(),
+		Role:          request.Role,
+		Duration:      duration,
+		Justification: request.Justification,
+		Status:        models.StatusPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceElevation, elevation.ID,
+		eventmodels.ElevationRequested, nil)
+	return c.toElevation(ctx, elevation)
+}
+
+func (c *controller) GetElevation(ctx context.Context, id uint) (*Elevation, error) {
+	const op = "elevation controller: get"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	elevation, err := c.elevationMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.toElevation(ctx, elevation)
+}
+
+func (c *controller) ListElevations(ctx context.Context, resourceType string, resourceID uint,
+	query *q.Query) ([]*Elevation, int64, error) {
+	const op = "elevation controller: list"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	elevations, total, err := c.elevationMgr.List(ctx, resourceType, resourceID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	userIDs := make([]uint, 0, len(elevations)*2)
+	for _, e := range elevations {
+		userIDs = append(userIDs, e.UserID, e.ReviewedBy)
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]*Elevation, 0, len(elevations))
+	for _, e := range elevations {
+		result = append(result, ofElevationModel(e, users))
+	}
+	return result, total, nil
+}
+
+// checkReviewer checks that the reviewer is an owner of the resource, holds the requested role
+// and is not the requester
+func (c *controller) checkReviewer(ctx context.Context, elevation *models.Elevation) (uint, error) {
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if elevation.Status != models.StatusPending {
+		return 0, perror.Wrapf(herrors.ErrParamInvalid,
+			"elevation %d is %s, only pending elevation can be reviewed", elevation.ID, elevation.Status)
+	}
+	if currentUser.GetID() == elevation.UserID {
+		return 0, perror.Wrap(herrors.ErrForbidden, "cannot review the elevation requested by yourself")
+	}
+	for _, r := range []string{role.Owner, elevation.Role} {
+		if err := c.memberSvc.RequirePermissionEqualOrHigher(ctx, r,
+			elevation.ResourceType, elevation.ResourceID); err != nil {
+			return 0, err
+		}
+	}
+	return currentUser.GetID(), nil
+}
+
+func (c *controller) ApproveElevation(ctx context.Context, id uint) (*Elevation, error) {
+	const op = "elevation controller: approve"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	elevation, err := c.elevationMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := c.checkReviewer(ctx, elevation)
+	if err != nil {
+		return nil, err
+	}
+	// elevations are revoked on expiry by the job running as the account, which is disabled without it
+	if c.config.AccountID == 0 {
+		return nil, perror.Wrap(herrors.ErrForbidden,
+			"elevations cannot be approved because the account to revoke expired elevations is not configured")
+	}
+
+	// 1. bind the role directly, and remember the previous direct role to restore on expiry
+	var (
+		member       *membermodels.Member
+		previousRole string
+		eventType    string
+	)
+	existing, err := c.memberMgr.Get(ctx, membermodels.ResourceType(elevation.ResourceType),
+		elevation.ResourceID, membermodels.MemberUser, elevation.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		previousRole = existing.Role
+		member, err = c.memberMgr.UpdateByID(ctx, existing.ID, elevation.Role)
+		eventType = eventmodels.MemberUpdated
+	} else {
+		member, err = c.memberMgr.Create(ctx, &membermodels.Member{
+			ResourceType: membermodels.ResourceType(elevation.ResourceType),
+			ResourceID:   elevation.ResourceID,
+			Role:         elevation.Role,
+			MemberType:   membermodels.MemberUser,
+			MemberNameID: elevation.UserID,
+			GrantedBy:    reviewer,
+			CreatedBy:    reviewer,
+		})
+		eventType = eventmodels.MemberCreated
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceMember, member.ID, eventType, nil)
+
+	// 2. mark the elevation as approved
+	now := time.Now()
+	expiredAt := now.Add(elevation.Duration)
+	elevation, err = c.elevationMgr.UpdateByID(ctx, id, &models.Elevation{
+		Status:       models.StatusApproved,
+		MemberID:     member.ID,
+		PreviousRole: previousRole,
+		ReviewedBy:   reviewer,
+		ReviewedAt:   &now,
+		ExpiredAt:    &expiredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceElevation, elevation.ID,
+		eventmodels.ElevationApproved, nil)
+	return c.toElevation(ctx, elevation)
+}
+
+func (c *controller) RejectElevation(ctx context.Context, id uint) (*Elevation, error) {
+	const op = "elevation controller: reject"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	elevation, err := c.elevationMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := c.checkReviewer(ctx, elevation)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	elevation, err = c.elevationMgr.UpdateByID(ctx, id, &models.Elevation{
+		Status:     models.StatusRejected,
+		ReviewedBy: reviewer,
+		ReviewedAt: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceElevation, elevation.ID,
+		eventmodels.ElevationRejected, nil)
+	return c.toElevation(ctx, elevation)
+}
+
+func (c *controller) ExpireElevation(ctx context.Context, id uint) error {
+	const op = "elevation controller: expire"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	elevation, err := c.elevationMgr.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if elevation.Status != models.StatusApproved {
+		return nil
+	}
+
+	// the member binding is left untouched if it has been changed by someone else after approval
+	member, err := c.memberMgr.GetByID(ctx, elevation.MemberID)
+	if err != nil {
+		return err
+	}
+	if member != nil && member.Role == elevation.Role {
+		if elevation.PreviousRole != "" {
+			if _, err := c.memberMgr.UpdateByID(ctx, member.ID, elevation.PreviousRole); err != nil {
+				return err
+			}
+			c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceMember, member.ID,
+				eventmodels.MemberUpdated, nil)
+		} else {
+			if err := c.memberMgr.DeleteMember(ctx, member.ID); err != nil {
+				return err
+			}
+			c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceMember, member.ID,
+				eventmodels.MemberDeleted, nil)
+		}
+	}
+
+	if _, err := c.elevationMgr.UpdateByID(ctx, id, &models.Elevation{
+		Status: models.StatusExpired,
+	}); err != nil {
+		return err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceElevation, elevation.ID,
+		eventmodels.ElevationExpired, nil)
+	return nil
+}
+
+func (c *controller) toElevation(ctx context.Context, elevation *models.Elevation) (*Elevation, error) {
+	users, err := c.userMgr.GetUserMapByIDs(ctx, []uint{elevation.UserID, elevation.ReviewedBy})
+	if err != nil {
+		return nil, err
+	}
+	return ofElevationModel(elevation, users), nil
+}
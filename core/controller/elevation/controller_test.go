@@ -0,0 +1,167 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	elevationconfig "github.com/horizoncd/horizon/pkg/config/elevation"
+	roleconfig "github.com/horizoncd/horizon/pkg/config/role"
+	"github.com/horizoncd/horizon/pkg/elevation/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	memberservice "github.com/horizoncd/horizon/pkg/member/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
+	"github.com/horizoncd/horizon/pkg/rbac/types"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+func userContext(id uint, name string) context.Context {
+	return context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: name,
+		ID:   id,
+	})
+}
+
+func TestElevation(t *testing.T) {
+	ownerCtx := userContext(1, "owner")
+	requesterCtx := userContext(2, "requester")
+
+	for _, name := range []string{"owner", "requester"} {
+		_, err := manager.UserMgr.Create(ownerCtx, &usermodels.User{Name: name})
+		assert.Nil(t, err)
+	}
+	group, err := manager.GroupMgr.Create(ownerCtx, &groupmodels.Group{Name: "elevation", Path: "elevation"})
+	assert.Nil(t, err)
+	for _, m := range []*membermodels.Member{
+		{MemberNameID: 1, Role: role.Owner},
+		{MemberNameID: 2, Role: role.Guest},
+	} {
+		m.ResourceType = membermodels.TypeGroup
+		m.ResourceID = group.ID
+		m.MemberType = membermodels.MemberUser
+		_, err := manager.MemberMgr.Create(ownerCtx, m)
+		assert.Nil(t, err)
+	}
+
+	roleSvc, err := role.NewFileRoleFrom2(context.Background(), roleconfig.Config{
+		RolePriorityRankDesc: []string{role.Owner, role.Maintainer, role.Guest},
+		Roles: []types.Role{
+			{Name: role.Owner}, {Name: role.Maintainer}, {Name: role.Guest},
+		},
+	})
+	assert.Nil(t, err)
+	ctl := NewController(&config.Config{
+		ElevationConfig: elevationconfig.Config{MaxDuration: time.Hour, AccountID: 1},
+	}, &param.Param{
+		Manager:       manager,
+		MemberService: memberservice.NewService(roleSvc, nil, manager),
+		RoleService:   roleSvc,
+		EventSvc:      eventservice.New(manager),
+	})
+
+	// invalid requests
+	_, err = ctl.CreateElevation(requesterCtx, common.ResourceGroup, group.ID, &CreateElevationRequest{
+		Role: role.Maintainer, DurationSeconds: 7200, Justification: "fix online issue",
+	})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	_, err = ctl.CreateElevation(requesterCtx, common.ResourceGroup, group.ID, &CreateElevationRequest{
+		Role: role.Guest, DurationSeconds: 600, Justification: "fix online issue",
+	})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	// request and approve
+	elevation, err := ctl.CreateElevation(requesterCtx, common.ResourceGroup, group.ID, &CreateElevationRequest{
+		Role: role.Maintainer, DurationSeconds: 600, Justification: "fix online issue",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusPending, elevation.Status)
+
+	_, err = ctl.ApproveElevation(requesterCtx, elevation.ID)
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+
+	// elevations would never expire without the account of the expiry job
+	noAccountCtl := NewController(&config.Config{
+		ElevationConfig: elevationconfig.Config{MaxDuration: time.Hour},
+	}, &param.Param{
+		Manager:       manager,
+		MemberService: memberservice.NewService(roleSvc, nil, manager),
+		RoleService:   roleSvc,
+		EventSvc:      eventservice.New(manager),
+	})
+	_, err = noAccountCtl.ApproveElevation(ownerCtx, elevation.ID)
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+
+	elevation, err = ctl.ApproveElevation(ownerCtx, elevation.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusApproved, elevation.Status)
+	assert.NotNil(t, elevation.ExpiredAt)
+
+	member, err := manager.MemberMgr.Get(ownerCtx, membermodels.TypeGroup, group.ID, membermodels.MemberUser, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, role.Maintainer, member.Role)
+
+	expired, err := manager.ElevationMgr.ListExpired(ownerCtx, time.Now().Add(time.Hour), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(expired))
+
+	// expire restores the previous role
+	assert.Nil(t, ctl.ExpireElevation(ownerCtx, elevation.ID))
+	member, err = manager.MemberMgr.Get(ownerCtx, membermodels.TypeGroup, group.ID, membermodels.MemberUser, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, role.Guest, member.Role)
+
+	elevation, err = ctl.GetElevation(ownerCtx, elevation.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusExpired, elevation.Status)
+
+	// reject
+	elevation, err = ctl.CreateElevation(requesterCtx, common.ResourceGroup, group.ID, &CreateElevationRequest{
+		Role: role.Owner, DurationSeconds: 600, Justification: "transfer application",
+	})
+	assert.Nil(t, err)
+	elevation, err = ctl.RejectElevation(ownerCtx, elevation.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusRejected, elevation.Status)
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&groupmodels.Group{}, &appmodels.Application{}, &usermodels.User{}, &membermodels.Member{},
+		&models.Elevation{}, &eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
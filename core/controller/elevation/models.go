@@ -0,0 +1,69 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/elevation/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+type CreateElevationRequest struct {
+	// Role the role to elevate to, such as owner
+	Role string `json:"role"`
+	// DurationSeconds how long the elevated role is held after approval
+	DurationSeconds uint `json:"durationSeconds"`
+	// Justification why the elevation is needed
+	Justification string `json:"justification"`
+}
+
+type Elevation struct {
+	ID              uint                  `json:"id"`
+	ResourceType    string                `json:"resourceType"`
+	ResourceID      uint                  `json:"resourceID"`
+	Role            string                `json:"role"`
+	DurationSeconds uint                  `json:"durationSeconds"`
+	Justification   string                `json:"justification"`
+	Status          models.Status         `json:"status"`
+	MemberID        uint                  `json:"memberID,omitempty"`
+	PreviousRole    string                `json:"previousRole,omitempty"`
+	User            *usermodels.UserBasic `json:"user,omitempty"`
+	ReviewedBy      *usermodels.UserBasic `json:"reviewedBy,omitempty"`
+	ReviewedAt      *time.Time            `json:"reviewedAt,omitempty"`
+	ExpiredAt       *time.Time            `json:"expiredAt,omitempty"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	UpdatedAt       time.Time             `json:"updatedAt"`
+}
+
+func ofElevationModel(e *models.Elevation, users map[uint]*usermodels.User) *Elevation {
+	return &Elevation{
+		ID:              e.ID,
+		ResourceType:    e.ResourceType,
+		ResourceID:      e.ResourceID,
+		Role:            e.Role,
+		DurationSeconds: uint(e.Duration / time.Second),
+		Justification:   e.Justification,
+		Status:          e.Status,
+		MemberID:        e.MemberID,
+		PreviousRole:    e.PreviousRole,
+		User:            usermodels.ToUser(users[e.UserID]),
+		ReviewedBy:      usermodels.ToUser(users[e.ReviewedBy]),
+		ReviewedAt:      e.ReviewedAt,
+		ExpiredAt:       e.ExpiredAt,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventaggregation
+
+import (
+	"context"
+
+	"github.com/horizoncd/horizon/lib/q"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	"github.com/horizoncd/horizon/pkg/eventaggregation/manager"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Controller interface {
+	// List lists the aggregated kubernetes events of the cluster, the latest seen first.
+	// All severities are listed if severity is empty
+	List(ctx context.Context, clusterID uint, severity string, query *q.Query) ([]*Aggregation, int64, error)
+}
+
+type controller struct {
+	aggregationMgr manager.Manager
+	clusterMgr     clustermanager.Manager
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(param *param.Param) Controller {
+	return &controller{
+		aggregationMgr: param.EventAggregationMgr,
+		clusterMgr:     param.ClusterMgr,
+	}
+}
+
+func (c *controller) List(ctx context.Context, clusterID uint, severity string,
+	query *q.Query) ([]*Aggregation, int64, error) {
+	const op = "event aggregation controller: list"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := c.clusterMgr.GetByID(ctx, clusterID); err != nil {
+		return nil, 0, err
+	}
+	aggregations, total, err := c.aggregationMgr.ListByClusterID(ctx, clusterID, severity, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]*Aggregation, 0, len(aggregations))
+	for _, aggregation := range aggregations {
+		resp = append(resp, ofAggregationModel(aggregation))
+	}
+	return resp, total, nil
+}
@@ -0,0 +1,56 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventaggregation
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/eventaggregation/models"
+)
+
+type Aggregation struct {
+	ID        uint      `json:"id"`
+	ClusterID uint      `json:"clusterID"`
+	Reason    string    `json:"reason"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	Count     int32     `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// EventID is the horizon event emitted for the aggregation
+	EventID uint `json:"eventID"`
+}
+
+func ofAggregationModel(a *models.Aggregation) *Aggregation {
+	return &Aggregation{
+		ID:        a.ID,
+		ClusterID: a.ClusterID,
+		Reason:    a.Reason,
+		Kind:      a.Kind,
+		Namespace: a.Namespace,
+		Name:      a.Name,
+		Type:      a.Type,
+		Severity:  a.Severity,
+		Message:   a.Message,
+		Count:     a.Count,
+		FirstSeen: a.FirstSeen,
+		LastSeen:  a.LastSeen,
+		EventID:   a.EventID,
+	}
+}
@@ -0,0 +1,525 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hibernation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/cd"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	hibernationconfig "github.com/horizoncd/horizon/pkg/config/hibernation"
+	envmanager "github.com/horizoncd/horizon/pkg/environment/manager"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	hibernationmanager "github.com/horizoncd/horizon/pkg/hibernation/manager"
+	"github.com/horizoncd/horizon/pkg/hibernation/models"
+	"github.com/horizoncd/horizon/pkg/param"
+	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+// workloadKinds are the kinds of workloads scaled to zero when the cluster is hibernated
+var workloadKinds = []schema.GroupKind{
+	{Group: "apps", Kind: "Deployment"},
+	{Group: "apps", Kind: "StatefulSet"},
+	{Group: "argoproj.io", Kind: "Rollout"},
+}
+
+type Controller interface {
+	// GetClusterHibernation gets the hibernation state and the effective schedule of the cluster
+	GetClusterHibernation(ctx context.Context, clusterID uint) (*ClusterHibernation, error)
+	// HibernateCluster scales all the workloads of the cluster to zero, and records their replicas
+	HibernateCluster(ctx context.Context, clusterID uint) (*ClusterHibernation, error)
+	// WakeCluster restores the replicas of workloads recorded when the cluster was hibernated
+	WakeCluster(ctx context.Context, clusterID uint) (*ClusterHibernation, error)
+	// GetSchedule gets the hibernation schedule of an environment or a cluster
+	GetSchedule(ctx context.Context, resourceType string, resourceID uint) (*Schedule, error)
+	// UpdateSchedule creates or updates the hibernation schedule of an environment or a cluster
+	UpdateSchedule(ctx context.Context, resourceType string, resourceID uint,
+		r *UpdateScheduleRequest) (*Schedule, error)
+	DeleteSchedule(ctx context.Context, resourceType string, resourceID uint) error
+	// RunSchedules hibernates or wakes up the clusters whose schedules fire after since and until now
+	RunSchedules(ctx context.Context, since, now time.Time) error
+}
+
+type controller struct {
+	config         *hibernationconfig.Config
+	hibernationMgr hibernationmanager.Manager
+	clusterMgr     clustermanager.Manager
+	envMgr         envmanager.Manager
+	regionMgr      regionmanager.Manager
+	userMgr        usermanager.Manager
+	cd             cd.CD
+	k8sutil        cd.K8sUtil
+	eventSvc       eventservice.Service
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param) Controller {
+	return &controller{
+		config:         &config.HibernationConfig,
+		hibernationMgr: param.HibernationMgr,
+		clusterMgr:     param.ClusterMgr,
+		envMgr:         param.EnvMgr,
+		regionMgr:      param.RegionMgr,
+		userMgr:        param.UserMgr,
+		cd:             param.CD,
+		k8sutil:        param.K8sUtil,
+		eventSvc:       param.EventSvc,
+	}
+}
+
+func (c *controller) GetClusterHibernation(ctx context.Context, clusterID uint) (*ClusterHibernation, error) {
+	const op = "hibernation controller: get cluster hibernation"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	hibernation, err := c.hibernationMgr.GetHibernation(ctx, clusterID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		hibernation = &models.Hibernation{ClusterID: clusterID, Status: models.StatusAwake}
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, []uint{hibernation.HibernatedBy, hibernation.WokenBy})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ofHibernationModel(hibernation, users)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := c.getEffectiveSchedule(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if schedule != nil {
+		resp.Schedule = ofScheduleModel(schedule, time.Now())
+	}
+	return resp, nil
+}
+
+func (c *controller) HibernateCluster(ctx context.Context, clusterID uint) (*ClusterHibernation, error) {
+	const op = "hibernation controller: hibernate cluster"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Status != common.ClusterStatusEmpty {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"cluster in status %s cannot be hibernated", cluster.Status)
+	}
+	hibernation, err := c.hibernationMgr.GetHibernation(ctx, clusterID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+	} else if hibernation.Status == models.StatusHibernated {
+		return c.GetClusterHibernation(ctx, clusterID)
+	}
+	// the workloads scaled by the last partial hibernation keep their replicas before hibernation
+	workloads := make([]*models.Workload, 0)
+	if hibernation != nil && hibernation.Status == models.StatusHibernating && hibernation.Workloads != "" {
+		if err := json.Unmarshal([]byte(hibernation.Workloads), &workloads); err != nil {
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid workloads of hibernation: %v", err)
+		}
+	}
+	scaled := make(map[string]bool, len(workloads))
+	for _, workload := range workloads {
+		scaled[workloadKey(workload.Group, workload.Kind, workload.Namespace, workload.Name)] = true
+	}
+
+	regionEntity, err := c.regionMgr.GetRegionEntity(ctx, cluster.RegionName)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := c.cd.GetResourceTree(ctx, &cd.GetResourceTreeParams{
+		Environment:  cluster.EnvironmentName,
+		Cluster:      cluster.Name,
+		RegionEntity: regionEntity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the workloads scaled are recorded even if some of them fail, so that they can be woken up,
+	// and the cluster is left hibernating to retry the failed ones
+	var scaleErr error
+	for _, node := range nodes {
+		if !isWorkload(node.Group, node.Kind) {
+			continue
+		}
+		replicas, err := c.k8sutil.ScaleWorkload(ctx, &cd.ScaleWorkloadParams{
+			RegionEntity: regionEntity,
+			GVK:          schema.GroupVersionKind{Group: node.Group, Version: node.Version, Kind: node.Kind},
+			Namespace:    node.Namespace,
+			Name:         node.Name,
+			Replicas:     0,
+		})
+		if err != nil {
+			log.Errorf(ctx, "failed to scale %s %s of cluster %s to zero: %v", node.Kind, node.Name, cluster.Name, err)
+			scaleErr = err
+			continue
+		}
+		if scaled[workloadKey(node.Group, node.Kind, node.Namespace, node.Name)] {
+			continue
+		}
+		workloads = append(workloads, &models.Workload{
+			Group:     node.Group,
+			Version:   node.Version,
+			Kind:      node.Kind,
+			Namespace: node.Namespace,
+			Name:      node.Name,
+			Replicas:  replicas,
+		})
+	}
+
+	bts, err := json.Marshal(workloads)
+	if err != nil {
+		return nil, err
+	}
+	status := models.StatusHibernated
+	if scaleErr != nil {
+		status = models.StatusHibernating
+	}
+	now := time.Now()
+	if _, err := c.hibernationMgr.UpsertHibernation(ctx, &models.Hibernation{
+		ClusterID:    clusterID,
+		Status:       status,
+		Workloads:    string(bts),
+		HibernatedAt: &now,
+		HibernatedBy: currentUserID(ctx),
+	}); err != nil {
+		return nil, err
+	}
+	if scaleErr != nil {
+		return nil, scaleErr
+	}
+	c.createEvent(ctx, clusterID, eventmodels.ClusterHibernated, workloads)
+	return c.GetClusterHibernation(ctx, clusterID)
+}
+
+func (c *controller) WakeCluster(ctx context.Context, clusterID uint) (*ClusterHibernation, error) {
+	const op = "hibernation controller: wake cluster"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	hibernation, err := c.hibernationMgr.GetHibernation(ctx, clusterID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		return c.GetClusterHibernation(ctx, clusterID)
+	}
+	if hibernation.Status != models.StatusHibernated && hibernation.Status != models.StatusHibernating {
+		return c.GetClusterHibernation(ctx, clusterID)
+	}
+	var workloads []*models.Workload
+	if hibernation.Workloads != "" {
+		if err := json.Unmarshal([]byte(hibernation.Workloads), &workloads); err != nil {
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid workloads of hibernation: %v", err)
+		}
+	}
+
+	regionEntity, err := c.regionMgr.GetRegionEntity(ctx, cluster.RegionName)
+	if err != nil {
+		return nil, err
+	}
+	for _, workload := range workloads {
+		_, err := c.k8sutil.ScaleWorkload(ctx, &cd.ScaleWorkloadParams{
+			RegionEntity: regionEntity,
+			GVK: schema.GroupVersionKind{
+				Group:   workload.Group,
+				Version: workload.Version,
+				Kind:    workload.Kind,
+			},
+			Namespace: workload.Namespace,
+			Name:      workload.Name,
+			Replicas:  workload.Replicas,
+		})
+		// the workload may have been removed while the cluster was hibernated
+		if err != nil {
+			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	if _, err := c.hibernationMgr.UpsertHibernation(ctx, &models.Hibernation{
+		ClusterID:    clusterID,
+		Status:       models.StatusAwake,
+		Workloads:    hibernation.Workloads,
+		HibernatedAt: hibernation.HibernatedAt,
+		HibernatedBy: hibernation.HibernatedBy,
+		WokenAt:      &now,
+		WokenBy:      currentUserID(ctx),
+	}); err != nil {
+		return nil, err
+	}
+	c.createEvent(ctx, clusterID, eventmodels.ClusterWoken, workloads)
+	return c.GetClusterHibernation(ctx, clusterID)
+}
+
+func (c *controller) GetSchedule(ctx context.Context, resourceType string, resourceID uint) (*Schedule, error) {
+	const op = "hibernation controller: get schedule"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	schedule, err := c.hibernationMgr.GetSchedule(ctx, resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return ofScheduleModel(schedule, time.Now()), nil
+}
+
+func (c *controller) UpdateSchedule(ctx context.Context, resourceType string, resourceID uint,
+	r *UpdateScheduleRequest) (*Schedule, error) {
+	const op = "hibernation controller: update schedule"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if _, err := cron.ParseStandard(r.HibernateCron); err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid hibernateCron %s: %v", r.HibernateCron, err)
+	}
+	if _, err := cron.ParseStandard(r.WakeCron); err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid wakeCron %s: %v", r.WakeCron, err)
+	}
+	switch resourceType {
+	case models.ResourceTypeCluster:
+		if _, err := c.clusterMgr.GetByID(ctx, resourceID); err != nil {
+			return nil, err
+		}
+	case models.ResourceTypeEnvironment:
+		if _, err := c.envMgr.GetByID(ctx, resourceID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "unsupported resource type %s", resourceType)
+	}
+
+	userID := currentUserID(ctx)
+	schedule, err := c.hibernationMgr.UpsertSchedule(ctx, &models.Schedule{
+		ResourceType:  resourceType,
+		ResourceID:    resourceID,
+		Enabled:       r.Enabled,
+		HibernateCron: r.HibernateCron,
+		WakeCron:      r.WakeCron,
+		CreatedBy:     userID,
+		UpdatedBy:     userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ofScheduleModel(schedule, time.Now()), nil
+}
+
+func (c *controller) DeleteSchedule(ctx context.Context, resourceType string, resourceID uint) error {
+	const op = "hibernation controller: delete schedule"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	return c.hibernationMgr.DeleteSchedule(ctx, resourceType, resourceID)
+}
+
+func (c *controller) RunSchedules(ctx context.Context, since, now time.Time) error {
+	const op = "hibernation controller: run schedules"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	schedules, err := c.hibernationMgr.ListEnabledSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		hibernate, err := fired(schedule, since, now)
+		if err != nil {
+			log.Errorf(ctx, "invalid hibernation schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if hibernate == nil {
+			continue
+		}
+
+		clusterIDs, err := c.listScheduledClusters(ctx, schedule)
+		if err != nil {
+			log.Errorf(ctx, "failed to list clusters of hibernation schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		for _, clusterID := range clusterIDs {
+			if *hibernate {
+				_, err = c.HibernateCluster(ctx, clusterID)
+			} else {
+				_, err = c.WakeCluster(ctx, clusterID)
+			}
+			if err != nil {
+				log.Errorf(ctx, "failed to run hibernation schedule %d for cluster %d: %v",
+					schedule.ID, clusterID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fired tells whether the schedule fires to hibernate or to wake up after since and until now,
+// the later one wins if both of them fire, and nil is returned if none of them fires
+func fired(schedule *models.Schedule, since, now time.Time) (*bool, error) {
+	hibernateCron, err := cron.ParseStandard(schedule.HibernateCron)
+	if err != nil {
+		return nil, err
+	}
+	wakeCron, err := cron.ParseStandard(schedule.WakeCron)
+	if err != nil {
+		return nil, err
+	}
+	hibernateAt, wakeAt := hibernateCron.Next(since), wakeCron.Next(since)
+	hibernateFired, wakeFired := !hibernateAt.After(now), !wakeAt.After(now)
+	if !hibernateFired && !wakeFired {
+		return nil, nil
+	}
+	// the latest fire time of each cron before now is compared
+	for next := hibernateCron.Next(hibernateAt); hibernateFired && !next.After(now); next = hibernateCron.Next(next) {
+		hibernateAt = next
+	}
+	for next := wakeCron.Next(wakeAt); wakeFired && !next.After(now); next = wakeCron.Next(next) {
+		wakeAt = next
+	}
+	hibernate := hibernateFired && (!wakeFired || hibernateAt.After(wakeAt))
+	return &hibernate, nil
+}
+
+// listScheduledClusters lists the clusters hibernated by the schedule,
+// clusters having their own schedules are excluded from the schedule of their environment
+func (c *controller) listScheduledClusters(ctx context.Context, schedule *models.Schedule) ([]uint, error) {
+	if schedule.ResourceType == models.ResourceTypeCluster {
+		return []uint{schedule.ResourceID}, nil
+	}
+	env, err := c.envMgr.GetByID(ctx, schedule.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	query := &q.Query{
+		PageNumber: common.DefaultPageNumber,
+		PageSize:   c.config.BatchSize,
+		Keywords:   q.KeyWords{common.ClusterQueryEnvironment: env.Name},
+	}
+	clusterIDs := make([]uint, 0)
+	for {
+		_, clusters, err := c.clusterMgr.List(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range clusters {
+			if cluster.Status != common.ClusterStatusEmpty {
+				continue
+			}
+			_, err := c.hibernationMgr.GetSchedule(ctx, models.ResourceTypeCluster, cluster.ID)
+			if err == nil {
+				continue
+			}
+			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+				return nil, err
+			}
+			clusterIDs = append(clusterIDs, cluster.ID)
+		}
+		if len(clusters) < query.PageSize {
+			return clusterIDs, nil
+		}
+		query.PageNumber++
+	}
+}
+
+// getEffectiveSchedule gets the schedule of cluster, or the one of its environment
+func (c *controller) getEffectiveSchedule(ctx context.Context,
+	cluster *clustermodels.Cluster) (*models.Schedule, error) {
+	schedule, err := c.hibernationMgr.GetSchedule(ctx, models.ResourceTypeCluster, cluster.ID)
+	if err == nil {
+		return schedule, nil
+	}
+	if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+		return nil, err
+	}
+	env, err := c.envMgr.GetByName(ctx, cluster.EnvironmentName)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	schedule, err = c.hibernationMgr.GetSchedule(ctx, models.ResourceTypeEnvironment, env.ID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (c *controller) createEvent(ctx context.Context, clusterID uint, eventType string,
+	workloads []*models.Workload) {
+	names := make([]string, 0, len(workloads))
+	for _, workload := range workloads {
+		names = append(names, fmt.Sprintf("%s/%s", workload.Kind, workload.Name))
+	}
+	bts, err := json.Marshal(map[string]interface{}{"workloads": names})
+	if err != nil {
+		log.Warningf(ctx, "failed to marshal event extra, err: %s", err.Error())
+		return
+	}
+	extra := string(bts)
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceCluster, clusterID, eventType, &extra)
+}
+
+func workloadKey(group, kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", group, kind, namespace, name)
+}
+
+func isWorkload(group, kind string) bool {
+	for _, gk := range workloadKinds {
+		if gk.Group == group && gk.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// currentUserID returns 0 if the cluster is hibernated or woken up by schedules
+func currentUserID(ctx context.Context) uint {
+	if currentUser, err := common.UserFromContext(ctx); err == nil {
+		return currentUser.GetID()
+	}
+	return 0
+}
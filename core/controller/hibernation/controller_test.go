@@ -0,0 +1,266 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hibernation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/cd"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	hibernationconfig "github.com/horizoncd/horizon/pkg/config/hibernation"
+	envmodels "github.com/horizoncd/horizon/pkg/environment/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/hibernation/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
+	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
+	tagmodels "github.com/horizoncd/horizon/pkg/tag/models"
+	templatemodels "github.com/horizoncd/horizon/pkg/template/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+// fakeCD returns a resource tree with a deployment, a rollout and a service for each cluster
+type fakeCD struct {
+	cd.CD
+}
+
+func (f *fakeCD) GetResourceTree(ctx context.Context, params *cd.GetResourceTreeParams) ([]cd.ResourceNode, error) {
+	nodes := make([]cd.ResourceNode, 3)
+	nodes[0].Group, nodes[0].Version, nodes[0].Kind = "apps", "v1", "Deployment"
+	nodes[1].Group, nodes[1].Version, nodes[1].Kind = "argoproj.io", "v1alpha1", "Rollout"
+	nodes[2].Group, nodes[2].Version, nodes[2].Kind = "", "v1", "Service"
+	for i := range nodes {
+		nodes[i].Namespace, nodes[i].Name = "ns", params.Cluster
+	}
+	return nodes, nil
+}
+
+// fakeK8sUtil keeps the replicas of workloads in memory
+type fakeK8sUtil struct {
+	cd.K8sUtil
+	replicas map[string]int32
+}
+
+func (f *fakeK8sUtil) ScaleWorkload(ctx context.Context, params *cd.ScaleWorkloadParams) (int32, error) {
+	key := fmt.Sprintf("%s/%s", params.GVK.Kind, params.Name)
+	replicas, ok := f.replicas[key]
+	if !ok {
+		return 0, herrors.NewErrNotFound(herrors.ResourceInK8S, key)
+	}
+	f.replicas[key] = params.Replicas
+	return replicas, nil
+}
+
+func TestHibernation(t *testing.T) {
+	user := &usermodels.User{Name: "hibernation"}
+	assert.Nil(t, db.Create(user).Error)
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: user.Name,
+		ID:   user.ID,
+	})
+	registry := &registrymodels.Registry{Name: "hibernation"}
+	assert.Nil(t, db.Create(registry).Error)
+	assert.Nil(t, db.Create(&regionmodels.Region{Name: "hz", RegistryID: registry.ID}).Error)
+	env := &envmodels.Environment{Name: "test"}
+	assert.Nil(t, db.Create(env).Error)
+	app := &appmodels.Application{Name: "hibernation"}
+	assert.Nil(t, db.Create(app).Error)
+	clusters := make([]*clustermodels.Cluster, 0)
+	for _, name := range []string{"hibernation-a", "hibernation-b"} {
+		cluster := &clustermodels.Cluster{ApplicationID: app.ID, Name: name,
+			EnvironmentName: env.Name, RegionName: "hz"}
+		assert.Nil(t, db.Create(cluster).Error)
+		clusters = append(clusters, cluster)
+	}
+
+	k8sutil := &fakeK8sUtil{replicas: map[string]int32{
+		"Deployment/hibernation-a": 2,
+		"Rollout/hibernation-a":    3,
+		"Deployment/hibernation-b": 1,
+		"Rollout/hibernation-b":    1,
+	}}
+	c := &controller{
+		config:         &hibernationconfig.Config{BatchSize: 1},
+		hibernationMgr: manager.HibernationMgr,
+		clusterMgr:     manager.ClusterMgr,
+		envMgr:         manager.EnvMgr,
+		regionMgr:      manager.RegionMgr,
+		userMgr:        manager.UserMgr,
+		cd:             &fakeCD{},
+		k8sutil:        k8sutil,
+		eventSvc:       eventservice.New(manager),
+	}
+
+	// hibernate and wake up on demand
+	hibernation, err := c.GetClusterHibernation(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusAwake, hibernation.Status)
+	assert.Nil(t, hibernation.Schedule)
+
+	hibernation, err = c.HibernateCluster(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusHibernated, hibernation.Status)
+	assert.Equal(t, 2, len(hibernation.Workloads))
+	assert.Equal(t, user.ID, hibernation.HibernatedBy.ID)
+	assert.Equal(t, int32(0), k8sutil.replicas["Deployment/hibernation-a"])
+	assert.Equal(t, int32(0), k8sutil.replicas["Rollout/hibernation-a"])
+
+	// hibernating again keeps the replicas recorded
+	hibernation, err = c.HibernateCluster(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), hibernation.Workloads[0].Replicas)
+
+	hibernation, err = c.WakeCluster(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusAwake, hibernation.Status)
+	assert.NotNil(t, hibernation.WokenAt)
+	assert.Equal(t, int32(2), k8sutil.replicas["Deployment/hibernation-a"])
+	assert.Equal(t, int32(3), k8sutil.replicas["Rollout/hibernation-a"])
+
+	// partial hibernation is left hibernating, and hibernating again retries the failed workloads
+	delete(k8sutil.replicas, "Rollout/hibernation-a")
+	_, err = c.HibernateCluster(ctx, clusters[0].ID)
+	assert.NotNil(t, err)
+	hibernation, err = c.GetClusterHibernation(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusHibernating, hibernation.Status)
+	assert.Equal(t, 1, len(hibernation.Workloads))
+	assert.Equal(t, int32(0), k8sutil.replicas["Deployment/hibernation-a"])
+
+	k8sutil.replicas["Rollout/hibernation-a"] = 3
+	hibernation, err = c.HibernateCluster(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusHibernated, hibernation.Status)
+	assert.Equal(t, 2, len(hibernation.Workloads))
+	assert.Equal(t, int32(0), k8sutil.replicas["Rollout/hibernation-a"])
+
+	hibernation, err = c.WakeCluster(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusAwake, hibernation.Status)
+	assert.Equal(t, int32(2), k8sutil.replicas["Deployment/hibernation-a"])
+	assert.Equal(t, int32(3), k8sutil.replicas["Rollout/hibernation-a"])
+
+	// invalid schedules
+	for _, request := range []*UpdateScheduleRequest{
+		{Enabled: true, HibernateCron: "0 20 * *", WakeCron: "0 8 * * *"},
+		{Enabled: true, HibernateCron: "0 20 * * *", WakeCron: ""},
+	} {
+		_, err := c.UpdateSchedule(ctx, models.ResourceTypeEnvironment, env.ID, request)
+		assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	}
+	_, err = c.UpdateSchedule(ctx, "groups", 1, &UpdateScheduleRequest{
+		HibernateCron: "0 20 * * *", WakeCron: "0 8 * * *"})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	// clusters are hibernated at nights by the schedule of environment,
+	// except the one opting out by its own disabled schedule
+	schedule, err := c.UpdateSchedule(ctx, models.ResourceTypeEnvironment, env.ID, &UpdateScheduleRequest{
+		Enabled:       true,
+		HibernateCron: "CRON_TZ=UTC 0 20 * * *",
+		WakeCron:      "CRON_TZ=UTC 0 8 * * *",
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, schedule.NextHibernateAt)
+	_, err = c.UpdateSchedule(ctx, models.ResourceTypeCluster, clusters[1].ID, &UpdateScheduleRequest{
+		Enabled:       false,
+		HibernateCron: "0 20 * * *",
+		WakeCron:      "0 8 * * *",
+	})
+	assert.Nil(t, err)
+	hibernation, err = c.GetClusterHibernation(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ResourceTypeEnvironment, hibernation.Schedule.ResourceType)
+
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, c.RunSchedules(ctx, day.Add(19*time.Hour+59*time.Minute), day.Add(20*time.Hour)))
+	hibernation, err = c.GetClusterHibernation(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusHibernated, hibernation.Status)
+	hibernation, err = c.GetClusterHibernation(ctx, clusters[1].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusAwake, hibernation.Status)
+	assert.Equal(t, int32(1), k8sutil.replicas["Deployment/hibernation-b"])
+
+	// nothing happens if no cron fires
+	assert.Nil(t, c.RunSchedules(ctx, day.Add(20*time.Hour), day.Add(20*time.Hour+time.Minute)))
+	hibernation, err = c.GetClusterHibernation(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusHibernated, hibernation.Status)
+
+	// clusters are woken up in the morning
+	assert.Nil(t, c.RunSchedules(ctx, day.Add(31*time.Hour+59*time.Minute), day.Add(32*time.Hour)))
+	hibernation, err = c.GetClusterHibernation(ctx, clusters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusAwake, hibernation.Status)
+	assert.Equal(t, user.ID, hibernation.WokenBy.ID)
+	assert.Equal(t, int32(2), k8sutil.replicas["Deployment/hibernation-a"])
+
+	assert.Nil(t, c.DeleteSchedule(ctx, models.ResourceTypeCluster, clusters[1].ID))
+	_, ok := perror.Cause(c.DeleteSchedule(ctx, models.ResourceTypeCluster, clusters[1].ID)).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+}
+
+func TestFired(t *testing.T) {
+	schedule := &models.Schedule{HibernateCron: "CRON_TZ=UTC 0 20 * * *", WakeCron: "CRON_TZ=UTC 0 8 * * *"}
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		since, now time.Time
+		expected   *bool
+	}{
+		{day.Add(19 * time.Hour), day.Add(19*time.Hour + 59*time.Minute), nil},
+		{day.Add(19 * time.Hour), day.Add(20 * time.Hour), boolPtr(true)},
+		{day.Add(7 * time.Hour), day.Add(9 * time.Hour), boolPtr(false)},
+		// the later one wins if the job has not run for a long time
+		{day.Add(7 * time.Hour), day.Add(21 * time.Hour), boolPtr(true)},
+		{day.Add(19 * time.Hour), day.Add(33 * time.Hour), boolPtr(false)},
+	} {
+		hibernate, err := fired(schedule, c.since, c.now)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, hibernate)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&appmodels.Application{}, &usermodels.User{}, &clustermodels.Cluster{},
+		&models.Schedule{}, &models.Hibernation{}, &envmodels.Environment{}, &regionmodels.Region{},
+		&registrymodels.Registry{}, &membermodels.Member{}, &tagmodels.Tag{}, &eventmodels.Event{},
+		&templatemodels.Template{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
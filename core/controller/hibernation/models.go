@@ -0,0 +1,97 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hibernation
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/horizoncd/horizon/pkg/hibernation/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+type UpdateScheduleRequest struct {
+	Enabled bool `json:"enabled"`
+	// HibernateCron and WakeCron are standard cron expressions, such as "0 20 * * 1-5",
+	// which can be prefixed with CRON_TZ=<location> to specify the time zone
+	HibernateCron string `json:"hibernateCron"`
+	WakeCron      string `json:"wakeCron"`
+}
+
+type Schedule struct {
+	ResourceType    string     `json:"resourceType"`
+	ResourceID      uint       `json:"resourceID"`
+	Enabled         bool       `json:"enabled"`
+	HibernateCron   string     `json:"hibernateCron"`
+	WakeCron        string     `json:"wakeCron"`
+	NextHibernateAt *time.Time `json:"nextHibernateAt,omitempty"`
+	NextWakeAt      *time.Time `json:"nextWakeAt,omitempty"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+type ClusterHibernation struct {
+	ClusterID    uint                  `json:"clusterID"`
+	Status       models.Status         `json:"status"`
+	Workloads    []*models.Workload    `json:"workloads"`
+	HibernatedAt *time.Time            `json:"hibernatedAt,omitempty"`
+	HibernatedBy *usermodels.UserBasic `json:"hibernatedBy,omitempty"`
+	WokenAt      *time.Time            `json:"wokenAt,omitempty"`
+	WokenBy      *usermodels.UserBasic `json:"wokenBy,omitempty"`
+	// Schedule is the schedule of the cluster, or the one of its environment if the cluster has no schedule
+	Schedule *Schedule `json:"schedule,omitempty"`
+}
+
+func ofScheduleModel(s *models.Schedule, now time.Time) *Schedule {
+	schedule := &Schedule{
+		ResourceType:  s.ResourceType,
+		ResourceID:    s.ResourceID,
+		Enabled:       s.Enabled,
+		HibernateCron: s.HibernateCron,
+		WakeCron:      s.WakeCron,
+		UpdatedAt:     s.UpdatedAt,
+	}
+	if !s.Enabled {
+		return schedule
+	}
+	if hibernate, err := cron.ParseStandard(s.HibernateCron); err == nil {
+		next := hibernate.Next(now)
+		schedule.NextHibernateAt = &next
+	}
+	if wake, err := cron.ParseStandard(s.WakeCron); err == nil {
+		next := wake.Next(now)
+		schedule.NextWakeAt = &next
+	}
+	return schedule
+}
+
+func ofHibernationModel(h *models.Hibernation, users map[uint]*usermodels.User) (*ClusterHibernation, error) {
+	hibernation := &ClusterHibernation{
+		ClusterID:    h.ClusterID,
+		Status:       h.Status,
+		Workloads:    []*models.Workload{},
+		HibernatedAt: h.HibernatedAt,
+		HibernatedBy: usermodels.ToUser(users[h.HibernatedBy]),
+		WokenAt:      h.WokenAt,
+		WokenBy:      usermodels.ToUser(users[h.WokenBy]),
+	}
+	if h.Workloads != "" {
+		if err := json.Unmarshal([]byte(h.Workloads), &hibernation.Workloads); err != nil {
+			return nil, err
+		}
+	}
+	return hibernation, nil
+}
@@ -0,0 +1,343 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hpa
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/cd"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	hpaconfig "github.com/horizoncd/horizon/pkg/config/hpa"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	hpamanager "github.com/horizoncd/horizon/pkg/hpa/manager"
+	"github.com/horizoncd/horizon/pkg/hpa/models"
+	"github.com/horizoncd/horizon/pkg/param"
+	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
+	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Controller interface {
+	// ListOverrides lists the replicas overrides of HorizontalPodAutoscalers of the cluster, the latest first
+	ListOverrides(ctx context.Context, clusterID uint, activeOnly bool) ([]*Override, error)
+	// CreateOverride overrides the min and max replicas of a HorizontalPodAutoscaler of the cluster
+	// until it expires, an active override of the same HorizontalPodAutoscaler is replaced
+	CreateOverride(ctx context.Context, clusterID uint, r *CreateOverrideRequest) (*Override, error)
+	// RevertOverride restores the original replicas of the HorizontalPodAutoscaler,
+	// unless its replicas have been changed during the override
+	RevertOverride(ctx context.Context, clusterID, overrideID uint) error
+	// RevertExpiredOverrides reverts all the overrides which have expired
+	RevertExpiredOverrides(ctx context.Context) error
+}
+
+type controller struct {
+	config     *hpaconfig.Config
+	hpaMgr     hpamanager.Manager
+	clusterMgr clustermanager.Manager
+	regionMgr  regionmanager.Manager
+	userMgr    usermanager.Manager
+	cd         cd.CD
+	k8sutil    cd.K8sUtil
+	eventSvc   eventservice.Service
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param) Controller {
+	return &controller{
+		config:     &config.HPAConfig,
+		hpaMgr:     param.HPAOverrideMgr,
+		clusterMgr: param.ClusterMgr,
+		regionMgr:  param.RegionMgr,
+		userMgr:    param.UserMgr,
+		cd:         param.CD,
+		k8sutil:    param.K8sUtil,
+		eventSvc:   param.EventSvc,
+	}
+}
+
+func (c *controller) ListOverrides(ctx context.Context, clusterID uint, activeOnly bool) ([]*Override, error) {
+	const op = "hpa controller: list overrides"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	overrides, err := c.hpaMgr.ListByClusterID(ctx, clusterID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint, 0, len(overrides)*2)
+	for _, override := range overrides {
+		userIDs = append(userIDs, override.CreatedBy, override.RevertedBy)
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*Override, 0, len(overrides))
+	for _, override := range overrides {
+		resp = append(resp, ofOverrideModel(override, users))
+	}
+	return resp, nil
+}
+
+func (c *controller) CreateOverride(ctx context.Context, clusterID uint,
+	r *CreateOverrideRequest) (*Override, error) {
+	const op = "hpa controller: create override"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validateCreateRequest(r); err != nil {
+		return nil, err
+	}
+
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	regionEntity, err := c.regionMgr.GetRegionEntity(ctx, cluster.RegionName)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := c.findHPA(ctx, cluster, regionEntity, r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// the original replicas are kept if the active override is replaced
+	var originalMin *int32
+	var originalMax int32
+	active, err := c.hpaMgr.GetActive(ctx, clusterID, r.Name)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		status, err := c.k8sutil.GetHPAStatus(ctx, &cd.GetHPAParams{
+			RegionEntity: regionEntity,
+			Namespace:    namespace,
+			Name:         r.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		originalMin, originalMax = status.MinReplicas, status.MaxReplicas
+	} else {
+		originalMin, originalMax = active.OriginalMinReplicas, active.OriginalMaxReplicas
+	}
+
+	if err := c.k8sutil.UpdateHPAReplicas(ctx, &cd.UpdateHPAReplicasParams{
+		RegionEntity: regionEntity,
+		Namespace:    namespace,
+		Name:         r.Name,
+		MinReplicas:  r.MinReplicas,
+		MaxReplicas:  r.MaxReplicas,
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if active != nil {
+		if err := c.hpaMgr.UpdateReverted(ctx, active.ID, now, currentUser.GetID()); err != nil {
+			return nil, err
+		}
+	}
+	override, err := c.hpaMgr.Create(ctx, &models.Override{
+		ClusterID:           clusterID,
+		Namespace:           namespace,
+		Name:                r.Name,
+		MinReplicas:         r.MinReplicas,
+		MaxReplicas:         r.MaxReplicas,
+		OriginalMinReplicas: originalMin,
+		OriginalMaxReplicas: originalMax,
+		Reason:              r.Reason,
+		ExpireAt:            now.Add(time.Duration(r.ExpireSeconds) * time.Second),
+		CreatedBy:           currentUser.GetID(),
+		UpdatedBy:           currentUser.GetID(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.createEvent(ctx, override, eventmodels.ClusterHPAOverridden)
+
+	return ofOverrideModel(override, nil), nil
+}
+
+func (c *controller) RevertOverride(ctx context.Context, clusterID, overrideID uint) error {
+	const op = "hpa controller: revert override"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	override, err := c.hpaMgr.GetByID(ctx, overrideID)
+	if err != nil {
+		return err
+	}
+	if override.ClusterID != clusterID {
+		return herrors.NewErrNotFound(herrors.HPAOverrideInDB, "hpa override not found in cluster")
+	}
+	if override.RevertedAt != nil {
+		return perror.Wrap(herrors.ErrParamInvalid, "hpa override has already been reverted")
+	}
+	return c.revert(ctx, override, currentUser.GetID())
+}
+
+func (c *controller) RevertExpiredOverrides(ctx context.Context) error {
+	const op = "hpa controller: revert expired overrides"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	overrides, err := c.hpaMgr.ListExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, override := range overrides {
+		if err := c.revert(ctx, override, 0); err != nil {
+			log.Errorf(ctx, "failed to revert expired hpa override %d of cluster %d: %v",
+				override.ID, override.ClusterID, err)
+		}
+	}
+	return nil
+}
+
+func (c *controller) revert(ctx context.Context, override *models.Override, revertedBy uint) error {
+	cluster, err := c.clusterMgr.GetByID(ctx, override.ClusterID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return err
+		}
+	} else {
+		regionEntity, err := c.regionMgr.GetRegionEntity(ctx, cluster.RegionName)
+		if err != nil {
+			return err
+		}
+		if err := c.restoreReplicas(ctx, regionEntity, override); err != nil {
+			return err
+		}
+	}
+
+	if err := c.hpaMgr.UpdateReverted(ctx, override.ID, time.Now(), revertedBy); err != nil {
+		return err
+	}
+	c.createEvent(ctx, override, eventmodels.ClusterHPAReverted)
+	return nil
+}
+
+// restoreReplicas restores the original replicas of the HorizontalPodAutoscaler, unless its spec has been
+// changed during the override, e.g. by a deployment rendering new replicas, which must not be overwritten
+func (c *controller) restoreReplicas(ctx context.Context, regionEntity *regionmodels.RegionEntity,
+	override *models.Override) error {
+	status, err := c.k8sutil.GetHPAStatus(ctx, &cd.GetHPAParams{
+		RegionEntity: regionEntity,
+		Namespace:    override.Namespace,
+		Name:         override.Name,
+	})
+	if err != nil {
+		// the HorizontalPodAutoscaler may have been removed from the cluster
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			return nil
+		}
+		return err
+	}
+	if minReplicas(status.MinReplicas) != minReplicas(override.MinReplicas) ||
+		status.MaxReplicas != override.MaxReplicas {
+		log.Infof(ctx, "replicas of hpa %s/%s have been changed during override %d, skip restoring",
+			override.Namespace, override.Name, override.ID)
+		return nil
+	}
+	err = c.k8sutil.UpdateHPAReplicas(ctx, &cd.UpdateHPAReplicasParams{
+		RegionEntity: regionEntity,
+		Namespace:    override.Namespace,
+		Name:         override.Name,
+		MinReplicas:  override.OriginalMinReplicas,
+		MaxReplicas:  override.OriginalMaxReplicas,
+	})
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// minReplicas returns the min replicas of HorizontalPodAutoscaler, which defaults to 1
+func minReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// findHPA checks the HorizontalPodAutoscaler belongs to the cluster, and returns its namespace
+func (c *controller) findHPA(ctx context.Context, cluster *clustermodels.Cluster,
+	regionEntity *regionmodels.RegionEntity, name string) (string, error) {
+	nodes, err := c.cd.GetResourceTree(ctx, &cd.GetResourceTreeParams{
+		Environment:  cluster.EnvironmentName,
+		Cluster:      cluster.Name,
+		RegionEntity: regionEntity,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, node := range nodes {
+		if node.Group == cd.GKHPA.Group && node.Kind == cd.GKHPA.Kind && node.Name == name {
+			return node.Namespace, nil
+		}
+	}
+	return "", perror.Wrapf(herrors.ErrParamInvalid,
+		"horizontal pod autoscaler %s not found in cluster %s", name, cluster.Name)
+}
+
+func (c *controller) validateCreateRequest(r *CreateOverrideRequest) error {
+	if r.Name == "" {
+		return perror.Wrap(herrors.ErrParamInvalid, "name of horizontal pod autoscaler is required")
+	}
+	if r.MaxReplicas < 1 {
+		return perror.Wrap(herrors.ErrParamInvalid, "maxReplicas must be at least 1")
+	}
+	if r.MinReplicas != nil && (*r.MinReplicas < 1 || *r.MinReplicas > r.MaxReplicas) {
+		return perror.Wrap(herrors.ErrParamInvalid, "minReplicas must be between 1 and maxReplicas")
+	}
+	if r.ExpireSeconds == 0 {
+		return perror.Wrap(herrors.ErrParamInvalid, "expireSeconds is required")
+	}
+	if c.config.MaxOverrideDuration > 0 &&
+		time.Duration(r.ExpireSeconds)*time.Second > c.config.MaxOverrideDuration {
+		return perror.Wrapf(herrors.ErrParamInvalid,
+			"override cannot last longer than %s", c.config.MaxOverrideDuration)
+	}
+	return nil
+}
+
+func (c *controller) createEvent(ctx context.Context, override *models.Override, eventType string) {
+	bts, err := json.Marshal(ofOverrideModel(override, nil))
+	if err != nil {
+		log.Warningf(ctx, "failed to marshal event extra, err: %s", err.Error())
+		return
+	}
+	extra := string(bts)
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceCluster, override.ClusterID, eventType, &extra)
+}
@@ -0,0 +1,210 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hpa
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/cd"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	hpaconfig "github.com/horizoncd/horizon/pkg/config/hpa"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/hpa/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
+	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+// fakeCD returns a resource tree with one HorizontalPodAutoscaler
+type fakeCD struct {
+	cd.CD
+}
+
+func (f *fakeCD) GetResourceTree(ctx context.Context, params *cd.GetResourceTreeParams) ([]cd.ResourceNode, error) {
+	node := cd.ResourceNode{}
+	node.Group, node.Kind, node.Namespace, node.Name = cd.GKHPA.Group, cd.GKHPA.Kind, "hpa-ns", "web"
+	return []cd.ResourceNode{node}, nil
+}
+
+// fakeK8sUtil keeps the replicas of HorizontalPodAutoscalers in memory
+type fakeK8sUtil struct {
+	cd.K8sUtil
+	hpas map[string]*cd.HPAStatus
+}
+
+func (f *fakeK8sUtil) GetHPAStatus(ctx context.Context, params *cd.GetHPAParams) (*cd.HPAStatus, error) {
+	status, ok := f.hpas[params.Name]
+	if !ok {
+		return nil, herrors.NewErrNotFound(herrors.ResourceInK8S, "hpa not found")
+	}
+	return status, nil
+}
+
+func (f *fakeK8sUtil) UpdateHPAReplicas(ctx context.Context, params *cd.UpdateHPAReplicasParams) error {
+	status, ok := f.hpas[params.Name]
+	if !ok {
+		return herrors.NewErrNotFound(herrors.ResourceInK8S, "hpa not found")
+	}
+	status.MinReplicas, status.MaxReplicas = params.MinReplicas, params.MaxReplicas
+	return nil
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestHPAOverride(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "hpa",
+		ID:   1,
+	})
+	registry := &registrymodels.Registry{Name: "hpa"}
+	assert.Nil(t, db.Create(registry).Error)
+	assert.Nil(t, db.Create(&regionmodels.Region{Name: "hz", RegistryID: registry.ID}).Error)
+	cluster := &clustermodels.Cluster{Name: "hpa-online", EnvironmentName: "online", RegionName: "hz"}
+	assert.Nil(t, db.Create(cluster).Error)
+
+	k8sutil := &fakeK8sUtil{hpas: map[string]*cd.HPAStatus{
+		"web": {Name: "web", MinReplicas: int32Ptr(2), MaxReplicas: 10},
+	}}
+	c := &controller{
+		config:     &hpaconfig.Config{MaxOverrideDuration: time.Hour},
+		hpaMgr:     manager.HPAOverrideMgr,
+		clusterMgr: manager.ClusterMgr,
+		regionMgr:  manager.RegionMgr,
+		userMgr:    manager.UserMgr,
+		cd:         &fakeCD{},
+		k8sutil:    k8sutil,
+		eventSvc:   eventservice.New(manager),
+	}
+
+	// invalid requests
+	for _, request := range []*CreateOverrideRequest{
+		{Name: "web", MaxReplicas: 0, ExpireSeconds: 60},
+		{Name: "web", MinReplicas: int32Ptr(5), MaxReplicas: 4, ExpireSeconds: 60},
+		{Name: "web", MaxReplicas: 20},
+		{Name: "web", MaxReplicas: 20, ExpireSeconds: 7200},
+		{Name: "api", MaxReplicas: 20, ExpireSeconds: 60},
+	} {
+		_, err := c.CreateOverride(ctx, cluster.ID, request)
+		assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	}
+
+	override, err := c.CreateOverride(ctx, cluster.ID, &CreateOverrideRequest{
+		Name:          "web",
+		MinReplicas:   int32Ptr(5),
+		MaxReplicas:   20,
+		ExpireSeconds: 600,
+		Reason:        "promotion",
+	})
+	assert.Nil(t, err)
+	assert.True(t, override.Active)
+	assert.Equal(t, int32(2), *override.OriginalMinReplicas)
+	assert.Equal(t, int32(10), override.OriginalMaxReplicas)
+	assert.Equal(t, int32(5), *k8sutil.hpas["web"].MinReplicas)
+	assert.Equal(t, int32(20), k8sutil.hpas["web"].MaxReplicas)
+
+	// replacing the active override keeps the original replicas
+	replaced, err := c.CreateOverride(ctx, cluster.ID, &CreateOverrideRequest{
+		Name:          "web",
+		MaxReplicas:   30,
+		ExpireSeconds: 600,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), *replaced.OriginalMinReplicas)
+	assert.Equal(t, int32(10), replaced.OriginalMaxReplicas)
+	overrides, err := c.ListOverrides(ctx, cluster.ID, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(overrides))
+	assert.Equal(t, replaced.ID, overrides[0].ID)
+	assert.False(t, overrides[1].Active)
+	overrides, err = c.ListOverrides(ctx, cluster.ID, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(overrides))
+
+	// revert manually
+	assert.Nil(t, c.RevertOverride(ctx, cluster.ID, replaced.ID))
+	assert.Equal(t, int32(2), *k8sutil.hpas["web"].MinReplicas)
+	assert.Equal(t, int32(10), k8sutil.hpas["web"].MaxReplicas)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(c.RevertOverride(ctx, cluster.ID, replaced.ID)))
+	_, ok := perror.Cause(c.RevertOverride(ctx, cluster.ID+1, replaced.ID)).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	// expired overrides are reverted
+	override, err = c.CreateOverride(ctx, cluster.ID, &CreateOverrideRequest{
+		Name:          "web",
+		MaxReplicas:   15,
+		ExpireSeconds: 600,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.RevertExpiredOverrides(ctx))
+	assert.Equal(t, int32(15), k8sutil.hpas["web"].MaxReplicas)
+	assert.Nil(t, db.Model(&models.Override{}).Where("id = ?", override.ID).
+		Update("expire_at", time.Now().Add(-time.Minute)).Error)
+	assert.Nil(t, c.RevertExpiredOverrides(ctx))
+	assert.Equal(t, int32(10), k8sutil.hpas["web"].MaxReplicas)
+	overrides, err = c.ListOverrides(ctx, cluster.ID, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(overrides))
+
+	// replicas changed during the override, e.g. by a deployment, are not overwritten by reverting
+	override, err = c.CreateOverride(ctx, cluster.ID, &CreateOverrideRequest{
+		Name:          "web",
+		MaxReplicas:   15,
+		ExpireSeconds: 600,
+	})
+	assert.Nil(t, err)
+	k8sutil.hpas["web"].MinReplicas, k8sutil.hpas["web"].MaxReplicas = int32Ptr(3), 12
+	assert.Nil(t, c.RevertOverride(ctx, cluster.ID, override.ID))
+	assert.Equal(t, int32(3), *k8sutil.hpas["web"].MinReplicas)
+	assert.Equal(t, int32(12), k8sutil.hpas["web"].MaxReplicas)
+	overrides, err = c.ListOverrides(ctx, cluster.ID, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(overrides))
+
+	// overrides and reverts are audited by events
+	var count int64
+	assert.Nil(t, db.Model(&eventmodels.Event{}).
+		Where("event_type = ?", eventmodels.ClusterHPAOverridden).Count(&count).Error)
+	assert.Equal(t, int64(4), count)
+	assert.Nil(t, db.Model(&eventmodels.Event{}).
+		Where("event_type = ?", eventmodels.ClusterHPAReverted).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&usermodels.User{}, &clustermodels.Cluster{}, &models.Override{},
+		&regionmodels.Region{}, &registrymodels.Registry{}, &eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
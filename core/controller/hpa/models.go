@@ -0,0 +1,68 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hpa
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/hpa/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+type CreateOverrideRequest struct {
+	// Name is the name of HorizontalPodAutoscaler
+	Name        string `json:"name"`
+	MinReplicas *int32 `json:"minReplicas"`
+	MaxReplicas int32  `json:"maxReplicas"`
+	// ExpireSeconds is how long the override lasts before the original replicas are restored
+	ExpireSeconds uint   `json:"expireSeconds"`
+	Reason        string `json:"reason"`
+}
+
+type Override struct {
+	ID                  uint                  `json:"id"`
+	ClusterID           uint                  `json:"clusterID"`
+	Name                string                `json:"name"`
+	MinReplicas         *int32                `json:"minReplicas,omitempty"`
+	MaxReplicas         int32                 `json:"maxReplicas"`
+	OriginalMinReplicas *int32                `json:"originalMinReplicas,omitempty"`
+	OriginalMaxReplicas int32                 `json:"originalMaxReplicas"`
+	Reason              string                `json:"reason"`
+	ExpireAt            time.Time             `json:"expireAt"`
+	Active              bool                  `json:"active"`
+	RevertedAt          *time.Time            `json:"revertedAt,omitempty"`
+	RevertedBy          *usermodels.UserBasic `json:"revertedBy,omitempty"`
+	CreatedAt           time.Time             `json:"createdAt"`
+	CreatedBy           *usermodels.UserBasic `json:"createdBy,omitempty"`
+}
+
+func ofOverrideModel(o *models.Override, users map[uint]*usermodels.User) *Override {
+	return &Override{
+		ID:                  o.ID,
+		ClusterID:           o.ClusterID,
+		Name:                o.Name,
+		MinReplicas:         o.MinReplicas,
+		MaxReplicas:         o.MaxReplicas,
+		OriginalMinReplicas: o.OriginalMinReplicas,
+		OriginalMaxReplicas: o.OriginalMaxReplicas,
+		Reason:              o.Reason,
+		ExpireAt:            o.ExpireAt,
+		Active:              o.RevertedAt == nil,
+		RevertedAt:          o.RevertedAt,
+		RevertedBy:          usermodels.ToUser(users[o.RevertedBy]),
+		CreatedAt:           o.CreatedAt,
+		CreatedBy:           usermodels.ToUser(users[o.CreatedBy]),
+	}
+}
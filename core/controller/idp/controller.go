@@ -27,13 +27,25 @@ import (
 	idpconst "github.com/horizoncd/horizon/core/common/idp"
 	herrors "github.com/horizoncd/horizon/core/errors"
 	"github.com/horizoncd/horizon/lib/q"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
 	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
+	groupservice "github.com/horizoncd/horizon/pkg/group/service"
+	"github.com/horizoncd/horizon/pkg/idp/ldap"
 	"github.com/horizoncd/horizon/pkg/idp/manager"
+	"github.com/horizoncd/horizon/pkg/idp/models"
 	"github.com/horizoncd/horizon/pkg/idp/utils"
+	membermanager "github.com/horizoncd/horizon/pkg/member/manager"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
 	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
 	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
 	usermodel "github.com/horizoncd/horizon/pkg/user/models"
 	linkmanager "github.com/horizoncd/horizon/pkg/userlink/manager"
+	linkmodels "github.com/horizoncd/horizon/pkg/userlink/models"
+	"github.com/horizoncd/horizon/pkg/util/log"
 )
 
 var (
@@ -50,19 +62,34 @@ type Controller interface {
 	Delete(c context.Context, idpID uint) error
 	Update(c context.Context, id uint, updateParam *UpdateIDPRequest) (*IdentityProvider, error)
 	GetDiscovery(ctx context.Context, s Discovery) (*DiscoveryConfig, error)
+	// LoginWithLDAP authenticates the user by the ldap identity provider, and signs in or links like LoginOrLink
+	LoginWithLDAP(ctx context.Context, request *LDAPLoginRequest) (*usermodel.User, error)
+	// SyncLDAPGroups grants the mapped roles of horizon groups to the members of ldap groups,
+	// and revokes the roles granted by current user from those who have left the ldap groups
+	SyncLDAPGroups(ctx context.Context) error
 }
 
 type controller struct {
-	idpManager  manager.Manager
-	userManager usermanager.Manager
-	linkManager linkmanager.Manager
+	idpManager    manager.Manager
+	userManager   usermanager.Manager
+	linkManager   linkmanager.Manager
+	memberManager membermanager.Manager
+	groupManager  groupmanager.Manager
+	roleService   role.Service
+	eventSvc      eventservice.Service
+	newLDAPClient func(config *models.LDAPConfig) ldap.Client
 }
 
 func NewController(param *param.Param) Controller {
 	return &controller{
-		idpManager:  param.IdpMgr,
-		userManager: param.UserMgr,
-		linkManager: param.UserLinksMgr,
+		idpManager:    param.IdpMgr,
+		userManager:   param.UserMgr,
+		linkManager:   param.UserLinksMgr,
+		memberManager: param.MemberMgr,
+		groupManager:  param.GroupMgr,
+		roleService:   param.RoleService,
+		eventSvc:      param.EventSvc,
+		newLDAPClient: ldap.NewClient,
 	}
 }
 
@@ -77,7 +104,12 @@ func (c *controller) ListAuthEndpoints(ctx context.Context, redirectURL string)
 		res  = make([]*AuthInfo, 0)
 	)
 	for _, idp := range idps {
-		info := &AuthInfo{ID: idp.ID, Name: idp.Name, DisplayName: idp.DisplayName}
+		info := &AuthInfo{ID: idp.ID, Name: idp.Name, DisplayName: idp.DisplayName, Type: typeOf(idp)}
+		// users sign in ldap identity providers with username and password rather than redirecting
+		if info.Type == models.TypeLDAP {
+			res = append(res, info)
+			continue
+		}
 		conf, err = utils.MakeOuath2Config(ctx, idp, oidc.ScopeOpenID)
 		if err != nil {
 			return nil, err
@@ -134,6 +166,10 @@ func (c *controller) LoginOrLink(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	if typeOf(idp) != models.TypeOIDC {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"identity provider %s is not an oidc provider", idp.Name)
+	}
 
 	var claims *utils.Claims
 	claims, err = utils.HandleOIDC(ctx, idp, code, redirectURL)
@@ -141,9 +177,18 @@ func (c *controller) LoginOrLink(ctx context.Context,
 		return nil, err
 	}
 
+	v, ok := stateMap[linkKey]
+	return c.loginOrLink(ctx, idp, claims, ok && len(v) == 1 && v[0] == "true")
+}
+
+// loginOrLink links the identity to current user if link is true, otherwise signs in the user linked to the
+// identity, who is registered at the first time
+func (c *controller) loginOrLink(ctx context.Context, idp *models.IdentityProvider,
+	claims *utils.Claims, link bool) (*usermodel.User, error) {
 	currentUser, _ := common.UserFromContext(ctx)
 	var user *usermodel.User
-	if v, ok := stateMap[linkKey]; ok && len(v) == 1 && v[0] == "true" && currentUser != nil {
+	var err error
+	if link && currentUser != nil {
 		// for linking
 		user, err = c.userManager.GetUserByID(ctx, currentUser.GetID())
 		if err != nil {
@@ -154,37 +199,174 @@ func (c *controller) LoginOrLink(ctx context.Context,
 			return nil, err
 		}
 	} else {
-		if link, err := c.linkManager.GetByIDPAndSub(ctx, idp.ID, claims.Sub); err != nil {
+		link, err := c.linkManager.GetByIDPAndSub(ctx, idp.ID, claims.Sub)
+		if err != nil {
 			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
 				return nil, err
 			}
 			// for register
-			name := strings.SplitN(claims.Email, "@", 2)[0]
-			if claims.Name == "" {
-				claims.Name = name
-			}
-			user, err = c.userManager.Create(ctx, &usermodel.User{
-				Name:     name,
-				FullName: claims.Name,
-				Email:    claims.Email,
-			})
-			if err != nil {
-				return nil, err
-			}
-			_, err = c.linkManager.CreateLink(ctx, user.ID, idp.ID, claims, false)
+			user, link, err = c.register(ctx, idp, claims)
 			if err != nil {
 				return nil, err
 			}
 		} else {
 			// for signing in
 			user, _ = c.userManager.GetUserByID(ctx, link.UserID)
-			if user != nil {
-				if user.Banned {
-					return nil, perror.Wrapf(herrors.ErrForbidden,
-						"user is banned")
-				}
+			if user == nil {
+				return nil, nil
+			}
+			if user.Banned {
+				return nil, perror.Wrapf(herrors.ErrForbidden,
+					"user is banned")
+			}
+		}
+		if user, err = c.applyClaimMappings(ctx, idp, claims, user, link); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func (c *controller) register(ctx context.Context, idp *models.IdentityProvider,
+	claims *utils.Claims) (*usermodel.User, *linkmodels.UserLink, error) {
+	name := strings.SplitN(claims.Email, "@", 2)[0]
+	if claims.Name == "" {
+		claims.Name = name
+	}
+	user, err := c.userManager.Create(ctx, &usermodel.User{
+		Name:     name,
+		FullName: claims.Name,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	link, err := c.linkManager.CreateLink(ctx, user.ID, idp.ID, claims, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, link, nil
+}
+
+// applyClaimMappings grants the admin flag and group roles matched by the claims, and revokes those granted
+// by the mappings of the identity provider before but no longer matched. Grants made by others are left untouched,
+// including the ones changed by others after being granted by the mappings.
+func (c *controller) applyClaimMappings(ctx context.Context, idp *models.IdentityProvider,
+	claims *utils.Claims, user *usermodel.User, link *linkmodels.UserLink) (*usermodel.User, error) {
+	granted := link.ClaimGrants
+	if len(idp.ClaimMappings) == 0 && granted == nil {
+		return user, nil
+	}
+	if granted == nil {
+		granted = &linkmodels.ClaimGrants{}
+	}
+	// the grants are made by the user's own claims
+	ctx = common.WithContext(ctx, &userauth.DefaultInfo{
+		Name:     user.Name,
+		FullName: user.FullName,
+		ID:       user.ID,
+		Email:    user.Email,
+		Admin:    user.Admin,
+	})
+
+	desiredAdmin := false
+	desiredRoles := make(map[uint]string)
+	for i := range idp.ClaimMappings {
+		mapping := &idp.ClaimMappings[i]
+		if !mapping.Matches(claims.Raw) {
+			continue
+		}
+		if mapping.Admin {
+			desiredAdmin = true
+			continue
+		}
+		if current, ok := desiredRoles[mapping.GroupID]; ok {
+			result, err := c.roleService.RoleCompare(ctx, mapping.Role, current)
+			if err != nil || result != role.RoleBigger {
+				continue
+			}
+		}
+		desiredRoles[mapping.GroupID] = mapping.Role
+	}
+
+	grants := &linkmodels.ClaimGrants{Groups: make(map[uint]string)}
+	switch {
+	case desiredAdmin && !user.Admin:
+		updated, err := c.userManager.UpdateByID(ctx, user.ID, &usermodel.User{Admin: true, Banned: user.Banned})
+		if err != nil {
+			return nil, err
+		}
+		user = updated
+		grants.Admin = true
+		c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceUser, user.ID,
+			eventmodels.UserAdminGranted, &idp.Name)
+	case desiredAdmin:
+		grants.Admin = granted.Admin
+	case granted.Admin && user.Admin:
+		updated, err := c.userManager.UpdateByID(ctx, user.ID, &usermodel.User{Admin: false, Banned: user.Banned})
+		if err != nil {
+			return nil, err
+		}
+		user = updated
+		c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceUser, user.ID,
+			eventmodels.UserAdminRevoked, &idp.Name)
+	}
+
+	for groupID, desired := range desiredRoles {
+		member, err := c.memberManager.Get(ctx, membermodels.TypeGroup, groupID, membermodels.MemberUser, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil {
+			member, err = c.memberManager.Create(ctx, &membermodels.Member{
+				ResourceType: membermodels.TypeGroup,
+				ResourceID:   groupID,
+				Role:         desired,
+				MemberType:   membermodels.MemberUser,
+				MemberNameID: user.ID,
+				GrantedBy:    user.ID,
+				CreatedBy:    user.ID,
+			})
+			if err != nil {
+				return nil, err
+			}
+			grants.Groups[groupID] = desired
+			c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceMember, member.ID,
+				eventmodels.MemberCreated, &idp.Name)
+			continue
+		}
+		if previous, ok := granted.Groups[groupID]; !ok || previous != member.Role {
+			continue
+		}
+		if member.Role != desired {
+			if _, err := c.memberManager.UpdateByID(ctx, member.ID, desired); err != nil {
+				return nil, err
 			}
+			c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceMember, member.ID,
+				eventmodels.MemberUpdated, &idp.Name)
 		}
+		grants.Groups[groupID] = desired
+	}
+	for groupID, previous := range granted.Groups {
+		if _, ok := desiredRoles[groupID]; ok {
+			continue
+		}
+		member, err := c.memberManager.Get(ctx, membermodels.TypeGroup, groupID, membermodels.MemberUser, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil || member.Role != previous {
+			continue
+		}
+		if err := c.memberManager.DeleteMember(ctx, member.ID); err != nil {
+			return nil, err
+		}
+		c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceMember, member.ID,
+			eventmodels.MemberDeleted, &idp.Name)
+	}
+
+	if err := c.linkManager.UpdateClaimGrants(ctx, link.ID, grants); err != nil {
+		return nil, err
 	}
 	return user, nil
 }
@@ -200,6 +382,12 @@ func (c *controller) GetByID(ctx context.Context, id uint) (*IdentityProvider, e
 func (c *controller) Create(ctx context.Context,
 	createParam *CreateIDPRequest) (*IdentityProvider, error) {
 	idp := createParam.toModel()
+	if idp.Type == "" {
+		idp.Type = models.TypeOIDC
+	}
+	if err := c.validate(ctx, idp); err != nil {
+		return nil, err
+	}
 
 	_, err := c.idpManager.GetByCondition(ctx,
 		q.Query{Keywords: map[string]interface{}{idpconst.QueryName: idp.Name}})
@@ -227,6 +415,24 @@ func (c *controller) Delete(ctx context.Context, idpID uint) error {
 func (c *controller) Update(ctx context.Context,
 	id uint, updateParam *UpdateIDPRequest) (*IdentityProvider, error) {
 	updateIDP := updateParam.toModel()
+	existing, err := c.idpManager.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// fields not updated are zero, so validate with the existing ones
+	merged := *updateIDP
+	if merged.Type == "" {
+		merged.Type = existing.Type
+	}
+	if merged.LDAPConfig == nil {
+		merged.LDAPConfig = existing.LDAPConfig
+	}
+	if merged.ClaimMappings == nil {
+		merged.ClaimMappings = existing.ClaimMappings
+	}
+	if err := c.validate(ctx, &merged); err != nil {
+		return nil, err
+	}
 	idp, err := c.idpManager.Update(ctx, id, updateIDP)
 	if err != nil {
 		return nil, err
@@ -254,3 +460,269 @@ func (c *controller) GetDiscovery(ctx context.Context, s Discovery) (*DiscoveryC
 		Issuer:                issuer,
 	}, nil
 }
+
+func typeOf(idp *models.IdentityProvider) string {
+	if idp.Type == "" {
+		return models.TypeOIDC
+	}
+	return idp.Type
+}
+
+func (c *controller) validate(ctx context.Context, idp *models.IdentityProvider) error {
+	if err := c.validateClaimMappings(ctx, idp.ClaimMappings); err != nil {
+		return err
+	}
+	switch idp.Type {
+	case "", models.TypeOIDC:
+		return nil
+	case models.TypeLDAP:
+	default:
+		return perror.Wrapf(herrors.ErrParamInvalid, "unsupported identity provider type %s", idp.Type)
+	}
+
+	config := idp.LDAPConfig
+	if config == nil {
+		return perror.Wrap(herrors.ErrParamInvalid, "ldap config is required")
+	}
+	if !strings.HasPrefix(config.URL, "ldap://") && !strings.HasPrefix(config.URL, "ldaps://") {
+		return perror.Wrapf(herrors.ErrParamInvalid, "ldap url %s should start with ldap:// or ldaps://", config.URL)
+	}
+	if config.UserBaseDN == "" || strings.Count(config.UserFilter, "%s") != 1 {
+		return perror.Wrap(herrors.ErrParamInvalid,
+			"user base dn is required, and user filter should contain exactly one %s for the username")
+	}
+	for _, mapping := range config.GroupMappings {
+		if mapping.GroupDN == "" {
+			return perror.Wrap(herrors.ErrParamInvalid, "group dn of the group mapping is required")
+		}
+		if _, err := c.roleService.GetRole(ctx, mapping.Role); err != nil {
+			return perror.Wrapf(herrors.ErrParamInvalid, "role %s of group %s is invalid", mapping.Role, mapping.GroupDN)
+		}
+		if _, err := c.groupManager.GetByID(ctx, mapping.GroupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateClaimMappings checks the mappings and resolves their group paths to ids in place
+func (c *controller) validateClaimMappings(ctx context.Context, mappings models.ClaimMappings) error {
+	for i := range mappings {
+		mapping := &mappings[i]
+		if mapping.Claim == "" || mapping.Value == "" {
+			return perror.Wrap(herrors.ErrParamInvalid, "claim and value of the claim mapping are required")
+		}
+		if mapping.Admin {
+			continue
+		}
+		if _, err := c.roleService.GetRole(ctx, mapping.Role); err != nil {
+			return perror.Wrapf(herrors.ErrParamInvalid, "role %s of claim %s=%s is invalid",
+				mapping.Role, mapping.Claim, mapping.Value)
+		}
+		if mapping.GroupPath == "" {
+			if _, err := c.groupManager.GetByID(ctx, mapping.GroupID); err != nil {
+				return err
+			}
+			continue
+		}
+		groupID, err := c.getGroupIDByFullPath(ctx, mapping.GroupPath)
+		if err != nil {
+			return err
+		}
+		mapping.GroupID = groupID
+	}
+	return nil
+}
+
+func (c *controller) getGroupIDByFullPath(ctx context.Context, fullPath string) (uint, error) {
+	fullPath = "/" + strings.Trim(fullPath, "/")
+	groups, err := c.groupManager.GetByPaths(ctx, strings.Split(fullPath[1:], "/"))
+	if err != nil {
+		return 0, err
+	}
+	for id, full := range groupservice.GenerateIDToFull(groups) {
+		if full.FullPath == fullPath {
+			return id, nil
+		}
+	}
+	return 0, perror.Wrapf(herrors.NewErrNotFound(herrors.GroupInDB, "group not found"),
+		"group %s not found", fullPath)
+}
+
+func (c *controller) LoginWithLDAP(ctx context.Context, request *LDAPLoginRequest) (*usermodel.User, error) {
+	idp, err := c.idpManager.GetProviderByName(ctx, request.IDP)
+	if err != nil {
+		return nil, err
+	}
+	if typeOf(idp) != models.TypeLDAP || idp.LDAPConfig == nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"identity provider %s is not a ldap provider", idp.Name)
+	}
+
+	claims, err := c.newLDAPClient(idp.LDAPConfig).Authenticate(ctx, request.Username, request.Password)
+	if err != nil {
+		return nil, err
+	}
+	return c.loginOrLink(ctx, idp, claims, request.Link)
+}
+
+func (c *controller) SyncLDAPGroups(ctx context.Context) error {
+	operator, err := common.UserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	idps, err := c.idpManager.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	// roles of users desired in each horizon group, the greater role takes precedence
+	// if the user is in several ldap groups mapped to the same horizon group
+	desired := make(map[uint]map[uint]string)
+	failedGroups := make(map[uint]bool)
+	for _, idp := range idps {
+		if typeOf(idp) != models.TypeLDAP || idp.LDAPConfig == nil {
+			continue
+		}
+		client := c.newLDAPClient(idp.LDAPConfig)
+		for _, mapping := range idp.LDAPConfig.GroupMappings {
+			users, err := c.listLDAPGroupUsers(ctx, idp, client, mapping.GroupDN)
+			if err != nil {
+				// memberships of the group are kept as they are, rather than revoked by mistake
+				log.Errorf(ctx, "failed to list members of ldap group %s of idp %s, err: %v",
+					mapping.GroupDN, idp.Name, err)
+				failedGroups[mapping.GroupID] = true
+				continue
+			}
+			roles, ok := desired[mapping.GroupID]
+			if !ok {
+				roles = make(map[uint]string)
+				desired[mapping.GroupID] = roles
+			}
+			for _, userID := range users {
+				if current, ok := roles[userID]; ok {
+					result, err := c.roleService.RoleCompare(ctx, mapping.Role, current)
+					if err != nil || result != role.RoleBigger {
+						continue
+					}
+				}
+				roles[userID] = mapping.Role
+			}
+		}
+	}
+
+	for groupID, roles := range desired {
+		if failedGroups[groupID] {
+			continue
+		}
+		if err := c.syncGroupMembers(ctx, operator.GetID(), groupID, roles); err != nil {
+			log.Errorf(ctx, "failed to sync members of group %d, err: %v", groupID, err)
+		}
+	}
+	return nil
+}
+
+// listLDAPGroupUsers lists the horizon users linked to the members of the ldap group,
+// and registers the members who have never signed in
+func (c *controller) listLDAPGroupUsers(ctx context.Context, idp *models.IdentityProvider,
+	client ldap.Client, groupDN string) ([]uint, error) {
+	members, err := client.ListGroupMembers(ctx, groupDN)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]uint, 0, len(members))
+	for _, claims := range members {
+		link, err := c.linkManager.GetByIDPAndSub(ctx, idp.ID, claims.Sub)
+		if err == nil {
+			users = append(users, link.UserID)
+			continue
+		}
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		// entries without email, such as nested groups, can not be registered
+		if claims.Email == "" {
+			continue
+		}
+		user, _, err := c.register(ctx, idp, claims)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user.ID)
+	}
+	return users, nil
+}
+
+// syncGroupMembers makes the direct members of the group granted by the sync match the desired roles,
+// members granted by others, or changed by others after granted by the sync, are left untouched
+func (c *controller) syncGroupMembers(ctx context.Context, operatorID uint,
+	groupID uint, roles map[uint]string) error {
+	members, err := c.memberManager.ListDirectMember(ctx, membermodels.TypeGroup, groupID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[uint]membermodels.Member)
+	for _, member := range members {
+		if member.MemberType == membermodels.MemberUser {
+			existing[member.MemberNameID] = member
+		}
+	}
+	grants, err := c.idpManager.ListLDAPGrants(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	granted := make(map[uint]string, len(grants))
+	for _, grant := range grants {
+		granted[grant.UserID] = grant.Role
+	}
+
+	for userID, role := range roles {
+		member, ok := existing[userID]
+		if !ok {
+			if _, err := c.memberManager.Create(ctx, &membermodels.Member{
+				ResourceType: membermodels.TypeGroup,
+				ResourceID:   groupID,
+				Role:         role,
+				MemberType:   membermodels.MemberUser,
+				MemberNameID: userID,
+				GrantedBy:    operatorID,
+				CreatedBy:    operatorID,
+			}); err != nil {
+				return err
+			}
+		} else if previous, ok := granted[userID]; !ok || previous != member.Role {
+			continue
+		} else if member.Role != role {
+			if _, err := c.memberManager.UpdateByID(ctx, member.ID, role); err != nil {
+				return err
+			}
+		}
+		if err := c.idpManager.UpsertLDAPGrant(ctx, &models.LDAPGrant{
+			GroupID: groupID,
+			UserID:  userID,
+			Role:    role,
+		}); err != nil {
+			return err
+		}
+	}
+	for userID, previous := range granted {
+		if _, ok := roles[userID]; ok {
+			if member, ok := existing[userID]; ok && member.Role != previous {
+				// the membership has been changed by others, so it is no longer managed by the sync
+				if err := c.idpManager.DeleteLDAPGrant(ctx, groupID, userID); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if member, ok := existing[userID]; ok && member.Role == previous {
+			if err := c.memberManager.DeleteMember(ctx, member.ID); err != nil {
+				return err
+			}
+		}
+		if err := c.idpManager.DeleteLDAPGrant(ctx, groupID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
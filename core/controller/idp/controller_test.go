@@ -0,0 +1,355 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	roleconfig "github.com/horizoncd/horizon/pkg/config/role"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
+	"github.com/horizoncd/horizon/pkg/idp/ldap"
+	"github.com/horizoncd/horizon/pkg/idp/models"
+	"github.com/horizoncd/horizon/pkg/idp/utils"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
+	"github.com/horizoncd/horizon/pkg/rbac/types"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+	linkmodels "github.com/horizoncd/horizon/pkg/userlink/models"
+)
+
+type fakeLDAPClient struct {
+	passwords map[string]string
+	users     map[string]*utils.Claims
+	groups    map[string][]string
+}
+
+func (f *fakeLDAPClient) Authenticate(ctx context.Context, username, password string) (*utils.Claims, error) {
+	if p, ok := f.passwords[username]; !ok || p != password {
+		return nil, perror.Wrap(herrors.ErrLDAPInvalidCredentials, "")
+	}
+	claims := *f.users[username]
+	return &claims, nil
+}
+
+func (f *fakeLDAPClient) ListGroupMembers(ctx context.Context, groupDN string) ([]*utils.Claims, error) {
+	usernames, ok := f.groups[groupDN]
+	if !ok {
+		return nil, herrors.NewErrNotFound(herrors.LDAPServer, groupDN)
+	}
+	members := make([]*utils.Claims, 0)
+	for _, username := range usernames {
+		claims := *f.users[username]
+		members = append(members, &claims)
+	}
+	return members, nil
+}
+
+func TestLDAP(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	assert.Nil(t, db.AutoMigrate(&models.IdentityProvider{}, &usermodels.User{}, &linkmodels.UserLink{},
+		&groupmodels.Group{}, &membermodels.Member{}, &appmodels.Application{}, &models.LDAPGrant{}))
+	manager := managerparam.InitManager(db)
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "operator",
+		ID:   1,
+	})
+
+	for _, name := range []string{"operator", "manual", "shared"} {
+		_, err := manager.UserMgr.Create(ctx, &usermodels.User{Name: name, Email: name + "@example.com"})
+		assert.Nil(t, err)
+	}
+	group, err := manager.GroupMgr.Create(ctx, &groupmodels.Group{Name: "ldap", Path: "ldap"})
+	assert.Nil(t, err)
+	_, err = manager.MemberMgr.Create(ctx, &membermodels.Member{
+		ResourceType: membermodels.TypeGroup,
+		ResourceID:   group.ID,
+		Role:         role.Guest,
+		MemberType:   membermodels.MemberUser,
+		MemberNameID: 2,
+		GrantedBy:    2,
+	})
+	assert.Nil(t, err)
+	// granted by the operator account of the sync, but not by the sync
+	_, err = manager.MemberMgr.Create(ctx, &membermodels.Member{
+		ResourceType: membermodels.TypeGroup,
+		ResourceID:   group.ID,
+		Role:         role.Guest,
+		MemberType:   membermodels.MemberUser,
+		MemberNameID: 3,
+		GrantedBy:    1,
+	})
+	assert.Nil(t, err)
+
+	roleSvc, err := role.NewFileRoleFrom2(context.Background(), roleconfig.Config{
+		RolePriorityRankDesc: []string{role.Owner, role.Maintainer, role.Guest},
+		Roles: []types.Role{
+			{Name: role.Owner}, {Name: role.Maintainer}, {Name: role.Guest},
+		},
+	})
+	assert.Nil(t, err)
+	client := &fakeLDAPClient{
+		passwords: map[string]string{"alice": "secret"},
+		users: map[string]*utils.Claims{
+			"alice": {Sub: "uid=alice,ou=people,dc=example,dc=com", Name: "Alice", Email: "alice@example.com"},
+			"bob":   {Sub: "uid=bob,ou=people,dc=example,dc=com", Name: "Bob", Email: "bob@example.com"},
+			"group": {Sub: "cn=nested,ou=groups,dc=example,dc=com"},
+		},
+		groups: map[string][]string{
+			"cn=devs,ou=groups,dc=example,dc=com":   {"alice", "bob", "group"},
+			"cn=admins,ou=groups,dc=example,dc=com": {"alice"},
+		},
+	}
+	ctl := NewController(&param.Param{Manager: manager, RoleService: roleSvc}).(*controller)
+	ctl.newLDAPClient = func(config *models.LDAPConfig) ldap.Client {
+		return client
+	}
+
+	ldapConfig := &models.LDAPConfig{
+		URL:        "ldaps://ldap.example.com",
+		UserBaseDN: "ou=people,dc=example,dc=com",
+		UserFilter: "(uid=%s)",
+		GroupMappings: []models.LDAPGroupMapping{
+			{GroupDN: "cn=devs,ou=groups,dc=example,dc=com", GroupID: group.ID, Role: role.Maintainer},
+			{GroupDN: "cn=admins,ou=groups,dc=example,dc=com", GroupID: group.ID, Role: role.Owner},
+		},
+	}
+	request := &CreateIDPRequest{UpdateIDPRequest{Name: "ldap", Type: models.TypeLDAP}}
+
+	// invalid config
+	request.LDAP = &models.LDAPConfig{URL: "https://ldap.example.com", UserBaseDN: "dc=example", UserFilter: "(uid=%s)"}
+	_, err = ctl.Create(ctx, request)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	request.LDAP = &models.LDAPConfig{URL: "ldap://ldap.example.com", UserBaseDN: "dc=example", UserFilter: "(uid=x)"}
+	_, err = ctl.Create(ctx, request)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	request.LDAP = &models.LDAPConfig{URL: "ldap://ldap.example.com", UserBaseDN: "dc=example", UserFilter: "(uid=%s)",
+		GroupMappings: []models.LDAPGroupMapping{{GroupDN: "cn=devs", GroupID: group.ID, Role: "admin"}}}
+	_, err = ctl.Create(ctx, request)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	request.LDAP = ldapConfig
+	idp, err := ctl.Create(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TypeLDAP, idp.Type)
+	idp, err = ctl.GetByID(ctx, idp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, ldapConfig, idp.LDAP)
+
+	endpoints, err := ctl.ListAuthEndpoints(ctx, "https://horizon.example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(endpoints))
+	assert.Equal(t, models.TypeLDAP, endpoints[0].Type)
+	assert.Empty(t, endpoints[0].AuthURL)
+
+	// login
+	_, err = ctl.LoginWithLDAP(context.Background(), &LDAPLoginRequest{IDP: "ldap", Username: "alice", Password: "x"})
+	assert.Equal(t, herrors.ErrLDAPInvalidCredentials, perror.Cause(err))
+	alice, err := ctl.LoginWithLDAP(context.Background(),
+		&LDAPLoginRequest{IDP: "ldap", Username: "alice", Password: "secret"})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", alice.Name)
+	assert.Equal(t, "Alice", alice.FullName)
+	user, err := ctl.LoginWithLDAP(context.Background(),
+		&LDAPLoginRequest{IDP: "ldap", Username: "alice", Password: "secret"})
+	assert.Nil(t, err)
+	assert.Equal(t, alice.ID, user.ID)
+
+	// sync
+	listMembers := func() map[uint]membermodels.Member {
+		members, err := manager.MemberMgr.ListDirectMember(ctx, membermodels.TypeGroup, group.ID)
+		assert.Nil(t, err)
+		res := make(map[uint]membermodels.Member)
+		for _, m := range members {
+			res[m.MemberNameID] = m
+		}
+		return res
+	}
+	assert.Nil(t, ctl.SyncLDAPGroups(ctx))
+	// the creator of the group is left as well
+	members := listMembers()
+	assert.Equal(t, 5, len(members))
+	assert.Equal(t, role.Owner, members[1].Role)
+	assert.Equal(t, role.Owner, members[alice.ID].Role)
+	assert.Equal(t, uint(1), members[alice.ID].GrantedBy)
+	assert.Equal(t, role.Guest, members[2].Role)
+	grants, err := manager.IdpMgr.ListLDAPGrants(ctx, group.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(grants))
+	// bob is registered by the sync
+	links, err := manager.UserLinksMgr.GetByIDPAndSub(ctx, idp.ID, "uid=bob,ou=people,dc=example,dc=com")
+	assert.Nil(t, err)
+	assert.Equal(t, role.Maintainer, members[links.UserID].Role)
+
+	// memberships are kept if the ldap group fails to be listed
+	delete(client.groups, "cn=admins,ou=groups,dc=example,dc=com")
+	client.groups["cn=devs,ou=groups,dc=example,dc=com"] = []string{"alice"}
+	assert.Nil(t, ctl.SyncLDAPGroups(ctx))
+	assert.Equal(t, 5, len(listMembers()))
+
+	// bob has left, and alice is no longer an admin
+	client.groups["cn=admins,ou=groups,dc=example,dc=com"] = []string{}
+	assert.Nil(t, ctl.SyncLDAPGroups(ctx))
+	members = listMembers()
+	assert.Equal(t, 4, len(members))
+	assert.Equal(t, role.Maintainer, members[alice.ID].Role)
+	assert.Equal(t, role.Guest, members[2].Role)
+	assert.Equal(t, role.Guest, members[3].Role)
+	grants, err = manager.IdpMgr.ListLDAPGrants(ctx, group.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(grants))
+
+	// the membership changed by others is no longer managed by the sync
+	_, err = manager.MemberMgr.UpdateByID(ctx, members[alice.ID].ID, role.Guest)
+	assert.Nil(t, err)
+	client.groups["cn=devs,ou=groups,dc=example,dc=com"] = []string{}
+	assert.Nil(t, ctl.SyncLDAPGroups(ctx))
+	members = listMembers()
+	assert.Equal(t, 4, len(members))
+	assert.Equal(t, role.Guest, members[alice.ID].Role)
+	grants, err = manager.IdpMgr.ListLDAPGrants(ctx, group.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(grants))
+}
+
+func TestClaimMappings(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	assert.Nil(t, db.AutoMigrate(&models.IdentityProvider{}, &usermodels.User{}, &linkmodels.UserLink{},
+		&groupmodels.Group{}, &membermodels.Member{}, &appmodels.Application{}, &eventmodels.Event{}))
+	manager := managerparam.InitManager(db)
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "admin",
+		ID:   1,
+	})
+
+	_, err := manager.UserMgr.Create(ctx, &usermodels.User{Name: "admin", Email: "admin@example.com"})
+	assert.Nil(t, err)
+	teamX, err := manager.GroupMgr.Create(ctx, &groupmodels.Group{Name: "team-x", Path: "team-x"})
+	assert.Nil(t, err)
+	teamY, err := manager.GroupMgr.Create(ctx, &groupmodels.Group{Name: "team-y", Path: "team-y"})
+	assert.Nil(t, err)
+
+	roleSvc, err := role.NewFileRoleFrom2(context.Background(), roleconfig.Config{
+		RolePriorityRankDesc: []string{role.Owner, role.Maintainer, role.Guest},
+		Roles: []types.Role{
+			{Name: role.Owner}, {Name: role.Maintainer}, {Name: role.Guest},
+		},
+	})
+	assert.Nil(t, err)
+	client := &fakeLDAPClient{
+		passwords: map[string]string{"alice": "secret"},
+		users: map[string]*utils.Claims{
+			"alice": {Sub: "uid=alice,ou=people,dc=example,dc=com", Name: "Alice", Email: "alice@example.com"},
+		},
+	}
+	ctl := NewController(&param.Param{Manager: manager, RoleService: roleSvc,
+		EventSvc: eventservice.New(manager)}).(*controller)
+	ctl.newLDAPClient = func(config *models.LDAPConfig) ldap.Client {
+		return client
+	}
+
+	request := &CreateIDPRequest{UpdateIDPRequest{Name: "ldap", Type: models.TypeLDAP,
+		LDAP: &models.LDAPConfig{URL: "ldap://ldap.example.com", UserBaseDN: "dc=example", UserFilter: "(uid=%s)"}}}
+	request.ClaimMappings = models.ClaimMappings{{Claim: "groups", Value: "team-x", GroupPath: "/team-z",
+		Role: role.Maintainer}}
+	_, err = ctl.Create(ctx, request)
+	assert.NotNil(t, err)
+	request.ClaimMappings = models.ClaimMappings{{Claim: "groups", Value: "team-x", GroupPath: "/team-x",
+		Role: "admin"}}
+	_, err = ctl.Create(ctx, request)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	request.ClaimMappings = models.ClaimMappings{
+		{Claim: "groups", Value: "platform-admins", Admin: true},
+		{Claim: "groups", Value: "team-x", GroupPath: "/team-x", Role: role.Guest},
+		{Claim: "groups", Value: "team-x-leads", GroupPath: "/team-x", Role: role.Maintainer},
+		{Claim: "groups", Value: "team-y", GroupID: teamY.ID, Role: role.Guest},
+	}
+	idp, err := ctl.Create(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, teamX.ID, idp.ClaimMappings[1].GroupID)
+
+	login := func(groups ...string) *usermodels.User {
+		client.users["alice"].Raw = map[string]interface{}{"groups": groups}
+		user, err := ctl.LoginWithLDAP(context.Background(),
+			&LDAPLoginRequest{IDP: "ldap", Username: "alice", Password: "secret"})
+		assert.Nil(t, err)
+		return user
+	}
+	getRole := func(groupID, userID uint) string {
+		member, err := manager.MemberMgr.Get(ctx, membermodels.TypeGroup, groupID, membermodels.MemberUser, userID)
+		assert.Nil(t, err)
+		if member == nil {
+			return ""
+		}
+		return member.Role
+	}
+
+	// granted at registration, and the greater role wins
+	alice := login("platform-admins", "team-x", "team-x-leads")
+	assert.True(t, alice.Admin)
+	assert.Equal(t, role.Maintainer, getRole(teamX.ID, alice.ID))
+	assert.Equal(t, "", getRole(teamY.ID, alice.ID))
+
+	// roles granted by others are left untouched
+	_, err = manager.MemberMgr.Create(ctx, &membermodels.Member{
+		ResourceType: membermodels.TypeGroup,
+		ResourceID:   teamY.ID,
+		Role:         role.Owner,
+		MemberType:   membermodels.MemberUser,
+		MemberNameID: alice.ID,
+		GrantedBy:    1,
+	})
+	assert.Nil(t, err)
+	alice = login("platform-admins", "team-x", "team-y")
+	assert.True(t, alice.Admin)
+	assert.Equal(t, role.Guest, getRole(teamX.ID, alice.ID))
+	assert.Equal(t, role.Owner, getRole(teamY.ID, alice.ID))
+
+	// revoked when the claims disappear
+	alice = login()
+	assert.False(t, alice.Admin)
+	assert.Equal(t, "", getRole(teamX.ID, alice.ID))
+	assert.Equal(t, role.Owner, getRole(teamY.ID, alice.ID))
+
+	// admin set manually is not revoked
+	_, err = manager.UserMgr.UpdateByID(ctx, alice.ID, &usermodels.User{Admin: true})
+	assert.Nil(t, err)
+	alice = login("platform-admins")
+	assert.True(t, alice.Admin)
+	alice = login()
+	assert.True(t, alice.Admin)
+
+	events, err := manager.EventMgr.ListEventsByRange(ctx, 0, 100)
+	assert.Nil(t, err)
+	eventTypes := make([]string, 0, len(events))
+	for _, e := range events {
+		eventTypes = append(eventTypes, e.EventType)
+	}
+	assert.Equal(t, []string{eventmodels.UserAdminGranted, eventmodels.MemberCreated, eventmodels.MemberUpdated,
+		eventmodels.UserAdminRevoked, eventmodels.MemberDeleted}, eventTypes)
+}
@@ -25,6 +25,8 @@ type AuthInfo struct {
 	AuthURL     string `json:"authURL"`
 	Name        string `json:"name"`
 	DisplayName string `json:"displayName,omitempty"`
+	// Type is ldap if users sign in with username and password instead of redirecting to AuthURL
+	Type string `json:"type"`
 }
 
 type IdentityProvider struct {
@@ -42,6 +44,9 @@ type IdentityProvider struct {
 	Jwks                    string                         `json:"jwks,omitempty"`
 	ClientID                string                         `json:"clientID,omitempty"`
 	ClientSecret            string                         `json:"clientSecret,omitempty"`
+	Type                    string                         `json:"type"`
+	LDAP                    *models.LDAPConfig             `json:"ldap,omitempty"`
+	ClaimMappings           models.ClaimMappings           `json:"claimMappings,omitempty"`
 	CreatedAt               time.Time                      `json:"createdAt"`
 	UpdatedAt               time.Time                      `json:"updatedAt"`
 }
@@ -66,6 +71,9 @@ func ofIDPModel(idp *models.IdentityProvider) *IdentityProvider {
 		Jwks:                    idp.Jwks,
 		ClientID:                idp.ClientID,
 		ClientSecret:            idp.ClientSecret,
+		Type:                    typeOf(idp),
+		LDAP:                    idp.LDAPConfig,
+		ClaimMappings:           idp.ClaimMappings,
 		CreatedAt:               idp.CreatedAt,
 		UpdatedAt:               idp.UpdatedAt,
 	}
@@ -85,21 +93,29 @@ type CreateIDPRequest struct {
 
 func (r *CreateIDPRequest) toModel() *models.IdentityProvider {
 	idp := &models.IdentityProvider{
-		DisplayName:             r.DisplayName,
-		Name:                    r.Name,
-		Avatar:                  r.Avatar,
-		AuthorizationEndpoint:   r.AuthorizationEndpoint,
-		TokenEndpoint:           r.TokenEndpoint,
-		UserinfoEndpoint:        r.UserinfoEndpoint,
-		RevocationEndpoint:      r.RevocationEndpoint,
-		Issuer:                  r.Issuer,
-		Scopes:                  r.Scopes,
-		SigningAlgs:             r.SigningAlgs,
-		TokenEndpointAuthMethod: &r.TokenEndpointAuthMethod,
-		Jwks:                    r.Jwks,
-		ClientID:                r.ClientID,
-		ClientSecret:            r.ClientSecret,
+		DisplayName:           r.DisplayName,
+		Name:                  r.Name,
+		Avatar:                r.Avatar,
+		AuthorizationEndpoint: r.AuthorizationEndpoint,
+		TokenEndpoint:         r.TokenEndpoint,
+		UserinfoEndpoint:      r.UserinfoEndpoint,
+		RevocationEndpoint:    r.RevocationEndpoint,
+		Issuer:                r.Issuer,
+		Scopes:                r.Scopes,
+		SigningAlgs:           r.SigningAlgs,
+		Jwks:                  r.Jwks,
+		ClientID:              r.ClientID,
+		ClientSecret:          r.ClientSecret,
+		Type:                  r.Type,
+		LDAPConfig:            r.LDAP,
+		ClaimMappings:         r.ClaimMappings,
 	}
+	// the zero value is not a valid method to save
+	method := r.TokenEndpointAuthMethod
+	if method == 0 {
+		method = models.ClientSecretSentAsPost
+	}
+	idp.TokenEndpointAuthMethod = &method
 	return idp
 }
 
@@ -118,24 +134,34 @@ type UpdateIDPRequest struct {
 	Jwks                    string                         `json:"jwks,omitempty"`
 	ClientID                string                         `json:"clientID"`
 	ClientSecret            string                         `json:"clientSecret"`
+	// Type is oidc or ldap
+	Type string             `json:"type,omitempty"`
+	LDAP *models.LDAPConfig `json:"ldap,omitempty"`
+	// ClaimMappings are applied at each sign-in, an empty list removes all the mappings
+	ClaimMappings models.ClaimMappings `json:"claimMappings,omitempty"`
 }
 
 func (r *UpdateIDPRequest) toModel() *models.IdentityProvider {
 	idp := &models.IdentityProvider{
-		DisplayName:             r.DisplayName,
-		Name:                    r.Name,
-		Avatar:                  r.Avatar,
-		AuthorizationEndpoint:   r.AuthorizationEndpoint,
-		TokenEndpoint:           r.TokenEndpoint,
-		UserinfoEndpoint:        r.UserinfoEndpoint,
-		RevocationEndpoint:      r.RevocationEndpoint,
-		Issuer:                  r.Issuer,
-		Scopes:                  r.Scopes,
-		SigningAlgs:             r.SigningAlgs,
-		TokenEndpointAuthMethod: &r.TokenEndpointAuthMethod,
-		Jwks:                    r.Jwks,
-		ClientID:                r.ClientID,
-		ClientSecret:            r.ClientSecret,
+		DisplayName:           r.DisplayName,
+		Name:                  r.Name,
+		Avatar:                r.Avatar,
+		AuthorizationEndpoint: r.AuthorizationEndpoint,
+		TokenEndpoint:         r.TokenEndpoint,
+		UserinfoEndpoint:      r.UserinfoEndpoint,
+		RevocationEndpoint:    r.RevocationEndpoint,
+		Issuer:                r.Issuer,
+		Scopes:                r.Scopes,
+		SigningAlgs:           r.SigningAlgs,
+		Jwks:                  r.Jwks,
+		ClientID:              r.ClientID,
+		ClientSecret:          r.ClientSecret,
+		Type:                  r.Type,
+		LDAPConfig:            r.LDAP,
+		ClaimMappings:         r.ClaimMappings,
+	}
+	if r.TokenEndpointAuthMethod != 0 {
+		idp.TokenEndpointAuthMethod = &r.TokenEndpointAuthMethod
 	}
 	return idp
 }
@@ -149,3 +175,13 @@ type DiscoveryConfig struct {
 	TokenEndpoint         string `json:"tokenEndpoint"`
 	Issuer                string `json:"issuer"`
 }
+
+type LDAPLoginRequest struct {
+	// IDP is the name of the ldap identity provider
+	IDP      string `json:"idp"`
+	Username string `json:"username"`
+	// Password is in plain text, since it is verified by binding to the ldap server
+	Password string `json:"password"`
+	// Link links the ldap account to current user instead of signing in
+	Link bool `json:"link,omitempty"`
+}
@@ -15,19 +15,32 @@
 package oauth
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/horizoncd/horizon/core/common"
 	herrors "github.com/horizoncd/horizon/core/errors"
 	perror "github.com/horizoncd/horizon/pkg/errors"
 	"github.com/horizoncd/horizon/pkg/oauth/manager"
 	oauthmodel "github.com/horizoncd/horizon/pkg/oauth/models"
+	"github.com/horizoncd/horizon/pkg/oauth/oidc"
 	"github.com/horizoncd/horizon/pkg/param"
 	"github.com/horizoncd/horizon/pkg/token/generator"
+	tokenmanager "github.com/horizoncd/horizon/pkg/token/manager"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
 	"github.com/horizoncd/horizon/pkg/util/wlog"
 	"golang.org/x/net/context"
 )
 
+const (
+	// clientRobotEmailFormat is the email of the robot user a client acts as in client_credentials grant
+	clientRobotEmailFormat = "oauthapp_%s_robot@noreply.com"
+)
+
 type AuthorizeReq struct {
 	ClientID     string
 	Scope        string
@@ -35,6 +48,12 @@ type AuthorizeReq struct {
 	State        string
 	UserIdentity uint
 
+	// CodeChallenge and CodeChallengeMethod are provided by PKCE clients
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce is provided by OpenID Connect clients
+	Nonce string
+
 	Request *http.Request
 }
 
@@ -55,6 +74,43 @@ type BaseTokenReq struct {
 type AccessTokenReq struct {
 	BaseTokenReq
 	Code string
+	// CodeVerifier is provided by PKCE clients
+	CodeVerifier string
+}
+
+type ClientCredentialsTokenReq struct {
+	BaseTokenReq
+	Scope string
+}
+
+type DeviceTokenReq struct {
+	BaseTokenReq
+	DeviceCode string
+}
+
+type DeviceAuthorizationReq struct {
+	ClientID string
+	Scope    string
+	// VerificationURI is the page where the user enters the user code
+	VerificationURI string
+
+	Request *http.Request
+}
+
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	// ExpiresIn and Interval are in seconds
+	ExpiresIn int `json:"expires_in"`
+	Interval  int `json:"interval"`
+}
+
+type DeviceAuthorization struct {
+	ClientID string
+	UserCode string
+	Scope    string
 }
 
 type RefreshTokenReq struct {
@@ -64,10 +120,31 @@ type RefreshTokenReq struct {
 
 type AccessTokenResponse struct {
 	AccessToken  string        `json:"access_token"`
-	RefreshToken string        `json:"refresh_token"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
 	ExpiresIn    time.Duration `json:"expires_in"`
 	Scope        string        `json:"scope"`
 	TokenType    string        `json:"token_type"`
+	// IDToken is issued when the scope contains openid
+	IDToken string `json:"id_token,omitempty"`
+}
+
+type ClientTokenReq struct {
+	ClientID     string
+	ClientSecret string
+	Token        string
+}
+
+// TokenIntrospectionResponse ref: rfc7662#section-2.2
+type TokenIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
 }
 
 type Controller interface {
@@ -76,16 +153,40 @@ type Controller interface {
 	// GenAccessToken Access Token GenOauthTokensRequest,ref:rfc6750
 	GenAccessToken(ctx context.Context, req *AccessTokenReq) (*AccessTokenResponse, error)
 	RefreshToken(ctx context.Context, req *RefreshTokenReq) (*AccessTokenResponse, error)
+	// GenClientCredentialsToken Access Token for the client acting on its own behalf, ref: rfc6749#section-4.4
+	GenClientCredentialsToken(ctx context.Context, req *ClientCredentialsTokenReq) (*AccessTokenResponse, error)
+	// GenDeviceCode Device Authorization Request, ref: rfc8628
+	GenDeviceCode(ctx context.Context, req *DeviceAuthorizationReq) (*DeviceAuthorizationResponse, error)
+	// GetDeviceAuthorization gets the device authorization waiting for current user by the user code
+	GetDeviceAuthorization(ctx context.Context, userCode string) (*DeviceAuthorization, error)
+	// AuthorizeDevice approves or denies the device authorization on behalf of current user
+	AuthorizeDevice(ctx context.Context, userCode string, approved bool) error
+	// GenDeviceAccessToken Device Access Token Request, ref: rfc8628#section-3.4
+	GenDeviceAccessToken(ctx context.Context, req *DeviceTokenReq) (*AccessTokenResponse, error)
+	// GetUserInfo gets the claims of the user authorized the access token, ref: OpenID Connect Core 1.0
+	GetUserInfo(ctx context.Context, accessToken string) (*oidc.UserInfo, error)
+	// IntrospectToken gets the state of the token for resource servers, ref: rfc7662
+	IntrospectToken(ctx context.Context, req *ClientTokenReq) (*TokenIntrospectionResponse, error)
+	// RevokeToken revokes the token issued to the client, ref: rfc7009
+	RevokeToken(ctx context.Context, req *ClientTokenReq) error
 }
 
 func NewController(param *param.Param) Controller {
-	return &controller{oauthManager: param.OauthManager}
+	return &controller{
+		oauthManager: param.OauthManager,
+		userManager:  param.UserMgr,
+		tokenManager: param.TokenMgr,
+		oidcService:  param.OIDCService,
+	}
 }
 
 var _ Controller = &controller{}
 
 type controller struct {
 	oauthManager manager.Manager
+	userManager  usermanager.Manager
+	tokenManager tokenmanager.Manager
+	oidcService  oidc.Service
 }
 
 func (c *controller) GenAuthorizeCode(ctx context.Context, req *AuthorizeReq) (*AuthorizeCodeResponse, error) {
@@ -101,6 +202,10 @@ func (c *controller) GenAuthorizeCode(ctx context.Context, req *AuthorizeReq) (*
 		Scope:        req.Scope,
 		UserIdentify: req.UserIdentity,
 		Request:      req.Request,
+
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
 	})
 	if err != nil {
 		return nil, err
@@ -140,9 +245,11 @@ func (c *controller) GenAccessToken(ctx context.Context, req *AccessTokenReq) (*
 	refreshTokenGenerator := generator.NewRefreshTokenGenerator()
 
 	tokens, err := c.oauthManager.GenOauthTokens(ctx, &manager.OauthTokensRequest{
+		GrantType:             manager.GrantTypeAuthorizationCode,
 		ClientID:              req.ClientID,
 		ClientSecret:          req.ClientSecret,
 		Code:                  req.Code,
+		CodeVerifier:          req.CodeVerifier,
 		RedirectURL:           req.RedirectURL,
 		Request:               req.Request,
 		AccessTokenGenerator:  accessTokenGenerator,
@@ -151,13 +258,7 @@ func (c *controller) GenAccessToken(ctx context.Context, req *AccessTokenReq) (*
 	if err != nil {
 		return nil, err
 	}
-	return &AccessTokenResponse{
-		AccessToken:  tokens.AccessToken.Code,
-		RefreshToken: tokens.RefreshToken.Code,
-		ExpiresIn:    tokens.AccessToken.ExpiresIn,
-		Scope:        tokens.AccessToken.Scope,
-		TokenType:    "bearer",
-	}, nil
+	return c.toAccessTokenResponse(ctx, req.ClientID, tokens)
 }
 
 func (c *controller) RefreshToken(ctx context.Context,
@@ -180,11 +281,242 @@ func (c *controller) RefreshToken(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	return c.toAccessTokenResponse(ctx, req.ClientID, tokens)
+}
+
+func (c *controller) GenClientCredentialsToken(ctx context.Context,
+	req *ClientCredentialsTokenReq) (*AccessTokenResponse, error) {
+	const op = "oauth controller: GenClientCredentialsToken"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	app, err := c.oauthManager.GetOAuthApp(ctx, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	// authenticate the client before its robot is created
+	if err := c.oauthManager.CheckClientSecret(ctx, req.ClientID, req.ClientSecret); err != nil {
+		return nil, err
+	}
+	robot, err := c.getClientRobot(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := c.oauthManager.GenOauthTokens(ctx, &manager.OauthTokensRequest{
+		GrantType:            manager.GrantTypeClientCredentials,
+		ClientID:             req.ClientID,
+		ClientSecret:         req.ClientSecret,
+		Scope:                req.Scope,
+		ClientUserID:         robot.ID,
+		Request:              req.Request,
+		AccessTokenGenerator: generator.NewClientCredentialsAccessGenerator(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AccessTokenResponse{
+		AccessToken: tokens.AccessToken.Code,
+		ExpiresIn:   tokens.AccessToken.ExpiresIn,
+		Scope:       tokens.AccessToken.Scope,
+		TokenType:   "bearer",
+	}, nil
+}
+
+// getClientRobot gets the robot user the client acts as, the robot is created at the first time,
+// and can be granted roles as members like robots of resource access tokens
+func (c *controller) getClientRobot(ctx context.Context, app *oauthmodel.OauthApp) (*usermodels.User, error) {
+	email := fmt.Sprintf(clientRobotEmailFormat, app.ClientID)
+	users, err := c.userManager.ListByEmail(ctx, []string{email})
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.UserType != usermodels.UserTypeRobot {
+			continue
+		}
+		if user.Banned {
+			return nil, perror.Wrapf(herrors.ErrOAuthReqNotValid, "robot of client %s is banned", app.ClientID)
+		}
+		return user, nil
+	}
+	return c.userManager.Create(ctx, &usermodels.User{
+		Name:     app.ClientID,
+		FullName: app.Name,
+		Email:    email,
+		UserType: usermodels.UserTypeRobot,
+	})
+}
+
+func (c *controller) GenDeviceCode(ctx context.Context,
+	req *DeviceAuthorizationReq) (*DeviceAuthorizationResponse, error) {
+	const op = "oauth controller: GenDeviceCode"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	token, err := c.oauthManager.GenDeviceCode(ctx, &manager.DeviceAuthorizationRequest{
+		ClientID: req.ClientID,
+		Scope:    req.Scope,
+		Request:  req.Request,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceAuthorizationResponse{
+		DeviceCode:              token.Code,
+		UserCode:                token.UserCode,
+		VerificationURI:         req.VerificationURI,
+		VerificationURIComplete: fmt.Sprintf("%s?user_code=%s", req.VerificationURI, token.UserCode),
+		ExpiresIn:               int(token.ExpiresIn / time.Second),
+		Interval:                manager.DevicePollInterval,
+	}, nil
+}
+
+func (c *controller) GetDeviceAuthorization(ctx context.Context, userCode string) (*DeviceAuthorization, error) {
+	const op = "oauth controller: GetDeviceAuthorization"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	token, err := c.oauthManager.GetDeviceCode(ctx, userCode)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceAuthorization{
+		ClientID: token.ClientID,
+		UserCode: token.UserCode,
+		Scope:    token.Scope,
+	}, nil
+}
+
+func (c *controller) AuthorizeDevice(ctx context.Context, userCode string, approved bool) error {
+	const op = "oauth controller: AuthorizeDevice"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	user, err := common.UserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return c.oauthManager.AuthorizeDeviceCode(ctx, userCode, user.GetID(), approved)
+}
+
+func (c *controller) GenDeviceAccessToken(ctx context.Context,
+	req *DeviceTokenReq) (*AccessTokenResponse, error) {
+	accessTokenGenerator, err := c.getAccessTokenGenerator(ctx, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := c.oauthManager.GenOauthTokens(ctx, &manager.OauthTokensRequest{
+		GrantType:             manager.GrantTypeDeviceCode,
+		ClientID:              req.ClientID,
+		ClientSecret:          req.ClientSecret,
+		DeviceCode:            req.DeviceCode,
+		Request:               req.Request,
+		AccessTokenGenerator:  accessTokenGenerator,
+		RefreshTokenGenerator: generator.NewRefreshTokenGenerator(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.toAccessTokenResponse(ctx, req.ClientID, tokens)
+}
+
+// toAccessTokenResponse converts the tokens to the response, with the id token if the scope contains openid
+func (c *controller) toAccessTokenResponse(ctx context.Context, clientID string,
+	tokens *manager.OauthTokensResponse) (*AccessTokenResponse, error) {
+	idToken, err := c.oidcService.GenIDToken(ctx, &oidc.IDTokenRequest{
+		ClientID: clientID,
+		UserID:   tokens.AccessToken.UserID,
+		Scope:    tokens.AccessToken.Scope,
+		Nonce:    tokens.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
 	return &AccessTokenResponse{
 		AccessToken:  tokens.AccessToken.Code,
 		RefreshToken: tokens.RefreshToken.Code,
 		ExpiresIn:    tokens.AccessToken.ExpiresIn,
 		Scope:        tokens.AccessToken.Scope,
 		TokenType:    "bearer",
+		IDToken:      idToken,
 	}, nil
 }
+
+func (c *controller) GetUserInfo(ctx context.Context, accessToken string) (*oidc.UserInfo, error) {
+	const op = "oauth controller: GetUserInfo"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	// only access tokens issued to users by oauth apps are accepted, rather than refresh tokens or codes
+	if !strings.HasPrefix(accessToken, generator.HorizonAppUserToServerAccessTokenPrefix) &&
+		!strings.HasPrefix(accessToken, generator.OauthAPPAccessTokenPrefix) {
+		return nil, perror.Wrap(herrors.ErrTokenInvalid, "not an access token issued by oauth apps")
+	}
+	token, err := c.tokenManager.LoadTokenByCode(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if token.ExpiresIn > 0 && token.CreatedAt.Add(token.ExpiresIn).Before(time.Now()) {
+		return nil, perror.Wrap(herrors.ErrOAuthAccessTokenExpired, "")
+	}
+	return c.oidcService.GetUserInfo(ctx, token.UserID, token.Scope)
+}
+
+func (c *controller) IntrospectToken(ctx context.Context,
+	req *ClientTokenReq) (*TokenIntrospectionResponse, error) {
+	const op = "oauth controller: IntrospectToken"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	inactive := &TokenIntrospectionResponse{Active: false}
+	token, err := c.oauthManager.IntrospectToken(ctx, &manager.ClientTokenRequest{
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Token:        req.Token,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return inactive, nil
+	}
+
+	// tokens of deleted or banned users are no longer active
+	user, err := c.userManager.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			return inactive, nil
+		}
+		return nil, err
+	}
+	if user.Banned {
+		return inactive, nil
+	}
+
+	resp := &TokenIntrospectionResponse{
+		Active:    true,
+		Scope:     token.Scope,
+		ClientID:  token.ClientID,
+		Username:  user.Name,
+		TokenType: "bearer",
+		IssuedAt:  token.CreatedAt.Unix(),
+		Subject:   strconv.Itoa(int(user.ID)),
+	}
+	if strings.HasPrefix(token.Code, generator.RefreshTokenPrefix) {
+		resp.TokenType = "refresh_token"
+	}
+	if token.ExpiresIn > 0 {
+		resp.ExpiresAt = token.CreatedAt.Add(token.ExpiresIn).Unix()
+	}
+	if c.oidcService.Enabled() {
+		resp.Issuer = c.oidcService.Issuer()
+	}
+	return resp, nil
+}
+
+func (c *controller) RevokeToken(ctx context.Context, req *ClientTokenReq) error {
+	const op = "oauth controller: RevokeToken"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	return c.oauthManager.RevokeToken(ctx, &manager.ClientTokenRequest{
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Token:        req.Token,
+	})
+}
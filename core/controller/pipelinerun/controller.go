@@ -37,12 +37,14 @@ import (
 	"github.com/horizoncd/horizon/pkg/cluster/tekton"
 	"github.com/horizoncd/horizon/pkg/cluster/tekton/collector"
 	"github.com/horizoncd/horizon/pkg/cluster/tekton/factory"
+	"github.com/horizoncd/horizon/pkg/config/approval"
 	"github.com/horizoncd/horizon/pkg/config/token"
 	envmanager "github.com/horizoncd/horizon/pkg/environment/manager"
 	perror "github.com/horizoncd/horizon/pkg/errors"
 	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
 	eventservice "github.com/horizoncd/horizon/pkg/event/service"
 	membermanager "github.com/horizoncd/horizon/pkg/member/manager"
+	memberservice "github.com/horizoncd/horizon/pkg/member/service"
 	"github.com/horizoncd/horizon/pkg/param"
 	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
 	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
@@ -77,6 +79,13 @@ type Controller interface {
 	Ready(ctx context.Context, pipelinerunID uint) error
 	// Cancel withdraws a pipelineRun only if its state is pending.
 	Cancel(ctx context.Context, pipelinerunID uint) error
+	// ListApprovals lists the reviews of a pipelineRun and the approvals it requires.
+	ListApprovals(ctx context.Context, pipelinerunID uint) (*Approvals, error)
+	// Approve approves a pipelineRun requiring approvals only if its state is pending or ready.
+	Approve(ctx context.Context, pipelinerunID uint, request *ReviewPipelinerunRequest) (*Approvals, error)
+	// Reject rejects a pipelineRun requiring approvals only if its state is pending or ready,
+	// which cannot be executed any more.
+	Reject(ctx context.Context, pipelinerunID uint, request *ReviewPipelinerunRequest) (*Approvals, error)
 
 	ListCheckRuns(ctx context.Context, pipelinerunID uint) ([]*prmodels.CheckRun, error)
 	CreateCheckRun(ctx context.Context, pipelineRunID uint,
@@ -105,6 +114,8 @@ type controller struct {
 	eventSvc           eventservice.Service
 	cd                 cd.CD
 	clusterSvc         clusterservice.Service
+	memberSvc          memberservice.Service
+	prApprovalConfig   approval.Config
 }
 
 var _ Controller = (*controller)(nil)
@@ -128,6 +139,8 @@ func NewController(config *config.Config, param *param.Param) Controller {
 		eventSvc:           param.EventSvc,
 		cd:                 param.CD,
 		clusterSvc:         param.ClusterSvc,
+		memberSvc:          param.MemberService,
+		prApprovalConfig:   config.PRApprovalConfig,
 	}
 }
 
@@ -391,6 +404,9 @@ func (c *controller) Execute(ctx context.Context, pipelinerunID uint) error {
 	if pr.Status != string(prmodels.StatusReady) {
 		return perror.Wrapf(herrors.ErrParamInvalid, "pipelinerun is not ready to execute")
 	}
+	if err := c.checkApproved(ctx, pr); err != nil {
+		return err
+	}
 
 	err = c.execute(ctx, pr)
 	if err != nil {
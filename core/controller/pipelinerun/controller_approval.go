@@ -0,0 +1,186 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelinerun
+
+import (
+	"context"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+func (c *controller) ListApprovals(ctx context.Context, pipelinerunID uint) (*Approvals, error) {
+	const op = "pipelinerun controller: list approvals"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	pr, err := c.prMgr.PipelineRun.GetByID(ctx, pipelinerunID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := c.prMgr.Approval.List(ctx, pipelinerunID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint, 0, len(reviews))
+	for _, review := range reviews {
+		userIDs = append(userIDs, review.CreatedBy)
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	resp := &Approvals{
+		RequiredApprovals: pr.RequiredApprovals,
+		Approvals:         countApprovals(reviews),
+		Reviews:           make([]*Review, 0, len(reviews)),
+	}
+	for _, review := range reviews {
+		reviewer := User{ID: review.CreatedBy}
+		if user, ok := users[review.CreatedBy]; ok {
+			reviewer.Name = user.Name
+		}
+		resp.Reviews = append(resp.Reviews, &Review{
+			Approved:  review.Approved,
+			Comment:   review.Comment,
+			CreatedBy: reviewer,
+			CreatedAt: review.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (c *controller) Approve(ctx context.Context, pipelinerunID uint,
+	request *ReviewPipelinerunRequest) (*Approvals, error) {
+	const op = "pipelinerun controller: approve pipelinerun"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	pr, err := c.prMgr.PipelineRun.GetByID(ctx, pipelinerunID)
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := c.checkReviewer(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.prMgr.Approval.Create(ctx, &prmodels.Approval{
+		PipelinerunID: pipelinerunID,
+		Approved:      true,
+		Comment:       request.Comment,
+		CreatedBy:     reviewer,
+	}); err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourcePipelinerun, pipelinerunID,
+		eventmodels.PipelinerunApproved, nil)
+	c.prSvc.CreateSystemMessageAsync(ctx, pipelinerunID, common.MessagePipelinerunApproved)
+	return c.ListApprovals(ctx, pipelinerunID)
+}
+
+func (c *controller) Reject(ctx context.Context, pipelinerunID uint,
+	request *ReviewPipelinerunRequest) (*Approvals, error) {
+	const op = "pipelinerun controller: reject pipelinerun"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	pr, err := c.prMgr.PipelineRun.GetByID(ctx, pipelinerunID)
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := c.checkReviewer(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.prMgr.Approval.Create(ctx, &prmodels.Approval{
+		PipelinerunID: pipelinerunID,
+		Approved:      false,
+		Comment:       request.Comment,
+		CreatedBy:     reviewer,
+	}); err != nil {
+		return nil, err
+	}
+	if err := c.prMgr.PipelineRun.UpdateStatusByID(ctx, pipelinerunID, prmodels.StatusRejected); err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourcePipelinerun, pipelinerunID,
+		eventmodels.PipelinerunRejected, nil)
+	c.prSvc.CreateSystemMessageAsync(ctx, pipelinerunID, common.MessagePipelinerunRejected)
+	return c.ListApprovals(ctx, pipelinerunID)
+}
+
+// checkReviewer checks that the pipelinerun requires approvals and is pending or ready,
+// and the reviewer is a member of cluster with the role required by the approval policy of its environment
+func (c *controller) checkReviewer(ctx context.Context, pr *prmodels.Pipelinerun) (uint, error) {
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if pr.RequiredApprovals <= 0 {
+		return 0, perror.Wrapf(herrors.ErrParamInvalid,
+			"pipelinerun %d does not require approvals", pr.ID)
+	}
+	if pr.Status != string(prmodels.StatusPending) && pr.Status != string(prmodels.StatusReady) {
+		return 0, perror.Wrapf(herrors.ErrParamInvalid,
+			"pipelinerun %d is %s, only pending or ready pipelinerun can be reviewed", pr.ID, pr.Status)
+	}
+	cluster, err := c.clusterMgr.GetByID(ctx, pr.ClusterID)
+	if err != nil {
+		return 0, err
+	}
+	// the policy may be removed after the pipelinerun is created, which still requires approvals
+	requiredRole, allowAuthor := role.Maintainer, false
+	if policy := c.prApprovalConfig.Environments[cluster.EnvironmentName]; policy != nil {
+		requiredRole, allowAuthor = policy.Role, policy.AllowAuthor
+	}
+	if !allowAuthor && currentUser.GetID() == pr.CreatedBy {
+		return 0, perror.Wrap(herrors.ErrForbidden, "cannot review the pipelinerun created by yourself")
+	}
+	if err := c.memberSvc.RequirePermissionEqualOrHigher(ctx, requiredRole,
+		common.ResourceCluster, pr.ClusterID); err != nil {
+		return 0, err
+	}
+	return currentUser.GetID(), nil
+}
+
+// checkApproved checks that the pipelinerun has got the approvals it requires
+func (c *controller) checkApproved(ctx context.Context, pr *prmodels.Pipelinerun) error {
+	if pr.RequiredApprovals <= 0 {
+		return nil
+	}
+	reviews, err := c.prMgr.Approval.List(ctx, pr.ID)
+	if err != nil {
+		return err
+	}
+	approvals := countApprovals(reviews)
+	if approvals < pr.RequiredApprovals {
+		return perror.Wrapf(herrors.ErrForbidden,
+			"pipelinerun has %d approvals, %d approvals are required", approvals, pr.RequiredApprovals)
+	}
+	return nil
+}
+
+// countApprovals counts the distinct reviewers approving the pipelinerun
+func countApprovals(reviews []*prmodels.Approval) int {
+	reviewers := make(map[uint]struct{}, len(reviews))
+	for _, review := range reviews {
+		if review.Approved {
+			reviewers[review.CreatedBy] = struct{}{}
+		}
+	}
+	return len(reviewers)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
 	"github.com/horizoncd/horizon/lib/orm"
 	"github.com/horizoncd/horizon/lib/q"
 	applicationmockmanager "github.com/horizoncd/horizon/mock/pkg/application/manager"
@@ -50,16 +51,22 @@ import (
 	clustermodel "github.com/horizoncd/horizon/pkg/cluster/models"
 	"github.com/horizoncd/horizon/pkg/cluster/tekton/collector"
 	"github.com/horizoncd/horizon/pkg/cluster/tekton/log"
+	"github.com/horizoncd/horizon/pkg/config/approval"
+	roleconfig "github.com/horizoncd/horizon/pkg/config/role"
 	"github.com/horizoncd/horizon/pkg/config/token"
 	envmodels "github.com/horizoncd/horizon/pkg/environmentregion/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
 	"github.com/horizoncd/horizon/pkg/git"
 	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
 	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	memberservice "github.com/horizoncd/horizon/pkg/member/service"
 	"github.com/horizoncd/horizon/pkg/param/managerparam"
 	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
 	"github.com/horizoncd/horizon/pkg/pr/models"
 	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
 	prservice "github.com/horizoncd/horizon/pkg/pr/service"
+	"github.com/horizoncd/horizon/pkg/rbac/role"
+	"github.com/horizoncd/horizon/pkg/rbac/types"
 	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
 	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
 	trmodels "github.com/horizoncd/horizon/pkg/templaterelease/models"
@@ -760,3 +767,143 @@ func TestMessage(t *testing.T) {
 	assert.Equal(t, messages[0].Content, "first")
 	assert.Equal(t, messages[1].Content, "second")
 }
+
+func TestApproval(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	if err := db.AutoMigrate(&applicationmodel.Application{}, &clustermodel.Cluster{}, &membermodels.Member{},
+		&usermodel.User{}, &prmodels.Pipelinerun{}, &prmodels.Approval{}, &prmodels.PRMessage{},
+		&eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	param := managerparam.InitManager(db)
+	userContext := func(id uint, name string) context.Context {
+		// nolint
+		return context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+			Name: name,
+			ID:   id,
+		})
+	}
+	authorCtx := userContext(1, "author")
+	ownerCtx := userContext(2, "owner")
+	maintainerCtx := userContext(3, "maintainer")
+	guestCtx := userContext(4, "guest")
+
+	for _, name := range []string{"author", "owner", "maintainer", "guest"} {
+		_, err := param.UserMgr.Create(authorCtx, &usermodel.User{Name: name})
+		assert.NoError(t, err)
+	}
+	app, err := param.ApplicationMgr.Create(authorCtx, &applicationmodel.Application{Name: "approval"}, nil)
+	assert.NoError(t, err)
+	cluster := &clustermodel.Cluster{
+		ApplicationID:   app.ID,
+		Name:            "approval-online",
+		EnvironmentName: "online",
+	}
+	assert.NoError(t, db.Create(cluster).Error)
+	for _, m := range []*membermodels.Member{
+		{MemberNameID: 1, Role: role.Owner},
+		{MemberNameID: 2, Role: role.Owner},
+		{MemberNameID: 3, Role: role.Maintainer},
+		{MemberNameID: 4, Role: role.Guest},
+	} {
+		m.ResourceType = membermodels.TypeApplicationCluster
+		m.ResourceID = cluster.ID
+		m.MemberType = membermodels.MemberUser
+		_, err := param.MemberMgr.Create(authorCtx, m)
+		assert.NoError(t, err)
+	}
+
+	roleSvc, err := role.NewFileRoleFrom2(context.Background(), roleconfig.Config{
+		RolePriorityRankDesc: []string{role.Owner, role.Maintainer, role.Guest},
+		Roles: []types.Role{
+			{Name: role.Owner}, {Name: role.Maintainer}, {Name: role.Guest},
+		},
+	})
+	assert.NoError(t, err)
+	ctrl := controller{
+		prMgr:      param.PRMgr,
+		prSvc:      prservice.NewService(param),
+		clusterMgr: param.ClusterMgr,
+		userMgr:    param.UserMgr,
+		eventSvc:   eventservice.New(param),
+		memberSvc:  memberservice.NewService(roleSvc, nil, param),
+		prApprovalConfig: approval.Config{
+			Environments: map[string]*approval.Policy{
+				"online": {RequiredApprovals: 2, Role: role.Maintainer},
+			},
+		},
+	}
+
+	pr, err := param.PRMgr.PipelineRun.Create(authorCtx, &prmodels.Pipelinerun{
+		ClusterID:         cluster.ID,
+		Action:            prmodels.ActionDeploy,
+		Status:            string(prmodels.StatusReady),
+		RequiredApprovals: 2,
+	})
+	assert.NoError(t, err)
+
+	// cannot be executed before approved
+	err = ctrl.Execute(authorCtx, pr.ID)
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+
+	// only maintainers or higher other than the author can review
+	_, err = ctrl.Approve(authorCtx, pr.ID, &ReviewPipelinerunRequest{})
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+	_, err = ctrl.Approve(guestCtx, pr.ID, &ReviewPipelinerunRequest{})
+	assert.Equal(t, herrors.ErrNoPrivilege, perror.Cause(err))
+
+	approvals, err := ctrl.Approve(ownerCtx, pr.ID, &ReviewPipelinerunRequest{Comment: "lgtm"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, approvals.RequiredApprovals)
+	assert.Equal(t, 1, approvals.Approvals)
+	assert.Equal(t, "lgtm", approvals.Reviews[0].Comment)
+	assert.Equal(t, "owner", approvals.Reviews[0].CreatedBy.Name)
+
+	// approvals of the same reviewer are counted once
+	approvals, err = ctrl.Approve(ownerCtx, pr.ID, &ReviewPipelinerunRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, approvals.Approvals)
+	assert.Equal(t, 1, len(approvals.Reviews))
+	assert.Equal(t, "", approvals.Reviews[0].Comment)
+	assert.Equal(t, 1, countApprovals([]*prmodels.Approval{
+		{CreatedBy: 2, Approved: true}, {CreatedBy: 2, Approved: true},
+	}))
+	err = ctrl.Execute(authorCtx, pr.ID)
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+
+	approvals, err = ctrl.Approve(maintainerCtx, pr.ID, &ReviewPipelinerunRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, approvals.Approvals)
+	assert.NoError(t, ctrl.checkApproved(authorCtx, pr))
+
+	// rejected pipelinerun cannot be reviewed or executed any more
+	pr, err = param.PRMgr.PipelineRun.Create(authorCtx, &prmodels.Pipelinerun{
+		ClusterID:         cluster.ID,
+		Action:            prmodels.ActionDeploy,
+		Status:            string(prmodels.StatusPending),
+		RequiredApprovals: 2,
+	})
+	assert.NoError(t, err)
+	approvals, err = ctrl.Reject(maintainerCtx, pr.ID, &ReviewPipelinerunRequest{Comment: "not now"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, approvals.Approvals)
+	assert.False(t, approvals.Reviews[0].Approved)
+	pr, err = param.PRMgr.PipelineRun.GetByID(authorCtx, pr.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(prmodels.StatusRejected), pr.Status)
+	_, err = ctrl.Approve(ownerCtx, pr.ID, &ReviewPipelinerunRequest{})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	err = ctrl.Execute(authorCtx, pr.ID)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	// pipelinerun not requiring approvals cannot be reviewed
+	pr, err = param.PRMgr.PipelineRun.Create(authorCtx, &prmodels.Pipelinerun{
+		ClusterID: cluster.ID,
+		Action:    prmodels.ActionDeploy,
+		Status:    string(prmodels.StatusReady),
+	})
+	assert.NoError(t, err)
+	_, err = ctrl.Approve(ownerCtx, pr.ID, &ReviewPipelinerunRequest{})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	assert.NoError(t, ctrl.checkApproved(authorCtx, pr))
+}
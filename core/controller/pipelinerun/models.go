@@ -72,3 +72,21 @@ type CreateOrUpdateCheckRunRequest struct {
 	ExternalID string `json:"externalId"`
 	DetailURL  string `json:"detailUrl"`
 }
+
+type ReviewPipelinerunRequest struct {
+	Comment string `json:"comment"`
+}
+
+type Approvals struct {
+	// RequiredApprovals the number of approvals required before executed, 0 if no approval is required
+	RequiredApprovals int       `json:"requiredApprovals"`
+	Approvals         int       `json:"approvals"`
+	Reviews           []*Review `json:"reviews"`
+}
+
+type Review struct {
+	Approved  bool      `json:"approved"`
+	Comment   string    `json:"comment"`
+	CreatedBy User      `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
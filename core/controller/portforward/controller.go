@@ -0,0 +1,315 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package portforward
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/pkg/cd"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	portforwardconfig "github.com/horizoncd/horizon/pkg/config/portforward"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
+	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+// maxCloseReasonLength is the longest reason allowed in a websocket close frame
+const maxCloseReasonLength = 123
+
+type Controller interface {
+	// PortForward returns a websocket handler forwarding the binary messages to the port of a pod of the cluster,
+	// every message received is written to the port as is, and the data read from the port is sent back
+	PortForward(ctx context.Context, clusterID uint, podName string, port uint16) (http.Handler, error)
+	// GetPodProxy returns a handler proxying a request to the port of a pod of the cluster,
+	// requests of a user to the same port are audited as a session until it is idle for a while
+	GetPodProxy(ctx context.Context, clusterID uint, podName string, port uint16,
+		path string) (http.Handler, error)
+}
+
+type controller struct {
+	config     *portforwardconfig.Config
+	clusterMgr clustermanager.Manager
+	regionMgr  regionmanager.Manager
+	cd         cd.CD
+	k8sutil    cd.K8sUtil
+	eventSvc   eventservice.Service
+	upgrader   websocket.Upgrader
+
+	lock          sync.Mutex
+	proxySessions map[string]*proxySession
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param) Controller {
+	return &controller{
+		config:        &config.PortForwardConfig,
+		clusterMgr:    param.ClusterMgr,
+		regionMgr:     param.RegionMgr,
+		cd:            param.CD,
+		k8sutil:       param.K8sUtil,
+		eventSvc:      param.EventSvc,
+		proxySessions: make(map[string]*proxySession),
+	}
+}
+
+// podRef locates a pod of a cluster
+type podRef struct {
+	clusterID    uint
+	regionEntity *regionmodels.RegionEntity
+	namespace    string
+	pod          string
+}
+
+// proxySession is the proxy requests of a user to the port of a pod
+type proxySession struct {
+	ctx        context.Context
+	ref        *podRef
+	port       uint16
+	startedAt  time.Time
+	accessedAt time.Time
+	timer      *time.Timer
+}
+
+func (c *controller) PortForward(ctx context.Context, clusterID uint, podName string,
+	port uint16) (http.Handler, error) {
+	const op = "port forward controller: port forward"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	ref, err := c.getPodRef(ctx, clusterID, podName, port)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := c.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// the upgrader has responded with the error
+			log.Warningf(ctx, "failed to upgrade port forward connection, err: %s", err.Error())
+			return
+		}
+		defer conn.Close()
+
+		startedAt := time.Now()
+		err = c.k8sutil.PortForward(ctx, &cd.PortForwardParams{
+			RegionEntity: ref.regionEntity,
+			Namespace:    ref.namespace,
+			Pod:          ref.pod,
+			Port:         port,
+			Stream:       &wsStream{conn: conn},
+		})
+		code, reason := websocket.CloseNormalClosure, ""
+		if err != nil {
+			log.Warningf(ctx, "failed to forward port %d of pod %s, err: %s", port, ref.pod, err.Error())
+			code, reason = websocket.CloseInternalServerErr, err.Error()
+			if len(reason) > maxCloseReasonLength {
+				reason = reason[:maxCloseReasonLength]
+			}
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+			time.Now().Add(time.Second))
+		c.createEvent(ctx, eventmodels.ClusterPortForwarded, ref, port, startedAt, time.Now())
+	}), nil
+}
+
+func (c *controller) GetPodProxy(ctx context.Context, clusterID uint, podName string, port uint16,
+	path string) (http.Handler, error) {
+	const op = "port forward controller: get pod proxy"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	session, err := c.getProxySession(ctx, clusterID, podName, port)
+	if err != nil {
+		return nil, err
+	}
+	return c.k8sutil.GetPodProxy(ctx, &cd.GetPodProxyParams{
+		RegionEntity: session.ref.regionEntity,
+		Namespace:    session.ref.namespace,
+		Pod:          session.ref.pod,
+		Port:         port,
+		Path:         path,
+	})
+}
+
+// getProxySession gets the proxy session of the current user, and starts a new one if not found,
+// the pod is only located when a session starts
+func (c *controller) getProxySession(ctx context.Context, clusterID uint, podName string,
+	port uint16) (*proxySession, error) {
+	user, err := common.UserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%d:%d:%s:%d", user.GetID(), clusterID, podName, port)
+
+	c.lock.Lock()
+	session, ok := c.proxySessions[key]
+	if ok {
+		session.accessedAt = time.Now()
+		session.timer.Reset(c.config.ProxyIdleTimeout)
+	}
+	c.lock.Unlock()
+	if ok {
+		return session, nil
+	}
+
+	ref, err := c.getPodRef(ctx, clusterID, podName, port)
+	if err != nil {
+		return nil, err
+	}
+	// the session outlives the request, so only the user and the request id are kept
+	sessionCtx := common.WithContext(context.Background(), user)
+	if rid, err := requestid.FromContext(ctx); err == nil {
+		// nolint
+		sessionCtx = context.WithValue(sessionCtx, requestid.HeaderXRequestID, rid)
+	}
+	now := time.Now()
+	session = &proxySession{
+		ctx:        sessionCtx,
+		ref:        ref,
+		port:       port,
+		startedAt:  now,
+		accessedAt: now,
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if existing, ok := c.proxySessions[key]; ok {
+		existing.accessedAt = now
+		existing.timer.Reset(c.config.ProxyIdleTimeout)
+		return existing, nil
+	}
+	session.timer = time.AfterFunc(c.config.ProxyIdleTimeout, func() { c.endProxySession(key) })
+	c.proxySessions[key] = session
+	return session, nil
+}
+
+// endProxySession audits the proxy session if it has been idle long enough
+func (c *controller) endProxySession(key string) {
+	c.lock.Lock()
+	session, ok := c.proxySessions[key]
+	if !ok || time.Since(session.accessedAt) < c.config.ProxyIdleTimeout {
+		// the session is accessed just now, and the timer has been reset
+		c.lock.Unlock()
+		return
+	}
+	delete(c.proxySessions, key)
+	c.lock.Unlock()
+
+	c.createEvent(session.ctx, eventmodels.ClusterPodProxied, session.ref, session.port,
+		session.startedAt, session.accessedAt)
+}
+
+// getPodRef locates the pod in the resource tree of the cluster,
+// so that pods not belonging to the cluster can never be accessed
+func (c *controller) getPodRef(ctx context.Context, clusterID uint, podName string,
+	port uint16) (*podRef, error) {
+	if podName == "" || port == 0 {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "pod name and port are required")
+	}
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	regionEntity, err := c.regionMgr.GetRegionEntity(ctx, cluster.RegionName)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := c.cd.GetResourceTree(ctx, &cd.GetResourceTreeParams{
+		Environment:  cluster.EnvironmentName,
+		Cluster:      cluster.Name,
+		RegionEntity: regionEntity,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.Kind == "Pod" && node.Name == podName {
+			return &podRef{
+				clusterID:    cluster.ID,
+				regionEntity: regionEntity,
+				namespace:    node.Namespace,
+				pod:          podName,
+			}, nil
+		}
+	}
+	return nil, herrors.NewErrNotFound(herrors.PodsInK8S,
+		fmt.Sprintf("pod %s not found in cluster %s", podName, cluster.Name))
+}
+
+func (c *controller) createEvent(ctx context.Context, eventType string, ref *podRef, port uint16,
+	startedAt, endedAt time.Time) {
+	bts, err := json.Marshal(map[string]interface{}{
+		"pod":       ref.pod,
+		"port":      port,
+		"startedAt": startedAt,
+		"duration":  endedAt.Sub(startedAt).Round(time.Second).String(),
+	})
+	if err != nil {
+		log.Warningf(ctx, "failed to marshal event extra, err: %s", err.Error())
+		return
+	}
+	extra := string(bts)
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceCluster, ref.clusterID, eventType, &extra)
+}
+
+// wsStream reads and writes the binary messages of a websocket connection as a stream
+type wsStream struct {
+	conn   *websocket.Conn
+	reader io.Reader
+}
+
+func (s *wsStream) Read(p []byte) (int, error) {
+	for {
+		if s.reader == nil {
+			_, reader, err := s.conn.NextReader()
+			if err != nil {
+				return 0, io.EOF
+			}
+			s.reader = reader
+		}
+		n, err := s.reader.Read(p)
+		if err == io.EOF {
+			s.reader = nil
+			if n == 0 {
+				continue
+			}
+			return n, nil
+		}
+		return n, err
+	}
+}
+
+func (s *wsStream) Write(p []byte) (int, error) {
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
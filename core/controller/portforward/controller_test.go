@@ -0,0 +1,166 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/cd"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	portforwardconfig "github.com/horizoncd/horizon/pkg/config/portforward"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
+	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+// fakeCD returns a resource tree with one pod, and counts the calls
+type fakeCD struct {
+	cd.CD
+	calls int
+}
+
+func (f *fakeCD) GetResourceTree(ctx context.Context, params *cd.GetResourceTreeParams) ([]cd.ResourceNode, error) {
+	f.calls++
+	node := cd.ResourceNode{}
+	node.Kind, node.Namespace, node.Name = "Pod", "pf-ns", "web-0"
+	return []cd.ResourceNode{node}, nil
+}
+
+// fakeK8sUtil echoes the forwarded stream, and responds the path to proxy requests
+type fakeK8sUtil struct {
+	cd.K8sUtil
+}
+
+func (f *fakeK8sUtil) PortForward(ctx context.Context, params *cd.PortForwardParams) error {
+	_, err := io.Copy(params.Stream, params.Stream)
+	return err
+}
+
+func (f *fakeK8sUtil) GetPodProxy(ctx context.Context, params *cd.GetPodProxyParams) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s/%s:%d%s", params.Namespace, params.Pod, params.Port, params.Path)
+	}), nil
+}
+
+func countEvents(t *testing.T, eventType string) int64 {
+	var count int64
+	assert.Nil(t, db.Model(&eventmodels.Event{}).Where("event_type = ?", eventType).Count(&count).Error)
+	return count
+}
+
+func TestPortForward(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "pf",
+		ID:   1,
+	})
+	registry := &registrymodels.Registry{Name: "pf"}
+	assert.Nil(t, db.Create(registry).Error)
+	assert.Nil(t, db.Create(&regionmodels.Region{Name: "hz", RegistryID: registry.ID}).Error)
+	cluster := &clustermodels.Cluster{Name: "pf-online", EnvironmentName: "online", RegionName: "hz"}
+	assert.Nil(t, db.Create(cluster).Error)
+
+	cdFake := &fakeCD{}
+	c := &controller{
+		config:        &portforwardconfig.Config{ProxyIdleTimeout: 100 * time.Millisecond},
+		clusterMgr:    manager.ClusterMgr,
+		regionMgr:     manager.RegionMgr,
+		cd:            cdFake,
+		k8sutil:       &fakeK8sUtil{},
+		eventSvc:      eventservice.New(manager),
+		proxySessions: make(map[string]*proxySession),
+	}
+
+	// pods not in the cluster can never be accessed
+	_, err := c.PortForward(ctx, cluster.ID, "web-0", 0)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	_, err = c.PortForward(ctx, cluster.ID, "other-0", 8080)
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+	_, err = c.GetPodProxy(ctx, cluster.ID, "other-0", 8080, "/")
+	_, ok = perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	// data is forwarded through the websocket
+	handler, err := c.PortForward(ctx, cluster.ID, "web-0", 8080)
+	assert.Nil(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, conn.WriteMessage(websocket.BinaryMessage, []byte("ping")))
+	_, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "ping", string(data))
+	assert.Nil(t, conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	assert.Nil(t, conn.Close())
+	assert.Eventually(t, func() bool {
+		return countEvents(t, eventmodels.ClusterPortForwarded) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// requests to the same port are a proxy session, and the pod is located only once
+	calls := cdFake.calls
+	for _, path := range []string{"/", "/metrics"} {
+		handler, err := c.GetPodProxy(ctx, cluster.ID, "web-0", 8080, path)
+		assert.Nil(t, err)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, "pf-ns/web-0:8080"+path, recorder.Body.String())
+	}
+	assert.Equal(t, calls+1, cdFake.calls)
+	assert.Equal(t, int64(0), countEvents(t, eventmodels.ClusterPodProxied))
+
+	// the session is audited after idle
+	assert.Eventually(t, func() bool {
+		return countEvents(t, eventmodels.ClusterPodProxied) == 1
+	}, time.Second, 10*time.Millisecond)
+	c.lock.Lock()
+	assert.Empty(t, c.proxySessions)
+	c.lock.Unlock()
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&usermodels.User{}, &clustermodels.Cluster{}, &regionmodels.Region{},
+		&registrymodels.Registry{}, &eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,427 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package releaseplan
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/argoproj/gitops-engine/pkg/health"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	appmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	"github.com/horizoncd/horizon/pkg/config/approval"
+	releaseplanconfig "github.com/horizoncd/horizon/pkg/config/releaseplan"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	"github.com/horizoncd/horizon/pkg/param"
+	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	releaseplanmanager "github.com/horizoncd/horizon/pkg/releaseplan/manager"
+	"github.com/horizoncd/horizon/pkg/releaseplan/models"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+type Controller interface {
+	// CreateReleasePlan deploys the clusters of the application wave by wave
+	CreateReleasePlan(ctx context.Context, applicationID uint,
+		request *CreateReleasePlanRequest) (*ReleasePlan, error)
+	// GetReleasePlan gets the release plan with the status of its clusters
+	GetReleasePlan(ctx context.Context, id uint) (*ReleasePlan, error)
+	ListReleasePlans(ctx context.Context, applicationID uint,
+		query *q.Query) ([]*ReleasePlan, int64, error)
+	// CancelReleasePlan stops deploying the following waves,
+	// pipelineruns which have been created are not affected
+	CancelReleasePlan(ctx context.Context, id uint) (*ReleasePlan, error)
+	// ProgressReleasePlan deploys the clusters of current wave, and moves on to
+	// the next wave once all of them are healthy, the plan halts if any of them fails
+	ProgressReleasePlan(ctx context.Context, id uint) error
+}
+
+type controller struct {
+	config           *releaseplanconfig.Config
+	prApprovalConfig approval.Config
+	releasePlanMgr   releaseplanmanager.Manager
+	applicationMgr   appmanager.Manager
+	clusterMgr       clustermanager.Manager
+	userMgr          usermanager.Manager
+	prMgr            *prmanager.PRManager
+	clusterCtl       clusterctl.Controller
+	eventSvc         eventservice.Service
+}
+
+var _ Controller = (*controller)(nil)
+
+func NewController(config *config.Config, param *param.Param, clusterCtl clusterctl.Controller) Controller {
+	return &controller{
+		config:           &config.ReleasePlanConfig,
+		prApprovalConfig: config.PRApprovalConfig,
+		releasePlanMgr:   param.ReleasePlanMgr,
+		applicationMgr:   param.ApplicationMgr,
+		clusterMgr:       param.ClusterMgr,
+		userMgr:          param.UserMgr,
+		prMgr:            param.PRMgr,
+		clusterCtl:       clusterCtl,
+		eventSvc:         param.EventSvc,
+	}
+}
+
+func (c *controller) CreateReleasePlan(ctx context.Context, applicationID uint,
+	request *CreateReleasePlanRequest) (*ReleasePlan, error) {
+	const op = "release plan controller: create"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. validate request
+	if _, err := c.applicationMgr.GetByID(ctx, applicationID); err != nil {
+		return nil, err
+	}
+	if len(request.Waves) == 0 {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "waves cannot be empty")
+	}
+	clusters := make([]*models.ReleasePlanCluster, 0)
+	existed := make(map[uint]bool)
+	for wave, clusterIDs := range request.Waves {
+		if len(clusterIDs) == 0 {
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "wave %d is empty", wave)
+		}
+		for _, clusterID := range clusterIDs {
+			if existed[clusterID] {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid,
+					"cluster %d is duplicated in waves", clusterID)
+			}
+			existed[clusterID] = true
+			cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+			if err != nil {
+				return nil, err
+			}
+			if cluster.ApplicationID != applicationID {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid,
+					"cluster %s does not belong to application %d", cluster.Name, applicationID)
+			}
+			// release plans deploy clusters directly, which is not allowed in protected environments
+			if policy := c.prApprovalConfig.Environments[cluster.EnvironmentName]; policy != nil {
+				return nil, perror.Wrapf(herrors.ErrApprovalRequired,
+					"cluster %s in environment %s can only be deployed by approved pipelineruns",
+					cluster.Name, cluster.EnvironmentName)
+			}
+			clusters = append(clusters, &models.ReleasePlanCluster{
+				ClusterID: clusterID,
+				Wave:      wave,
+				Status:    models.StatusPending,
+			})
+		}
+	}
+
+	// 2. create release plan, its waves are deployed by job
+	plan, err := c.releasePlanMgr.Create(ctx, &models.ReleasePlan{
+		ApplicationID: applicationID,
+		Title:         request.Title,
+		Description:   request.Description,
+		ImageTag:      request.ImageTag,
+		Status:        models.StatusRunning,
+		CreatedBy:     currentUser.GetID(),
+		UpdatedBy:     currentUser.GetID(),
+	}, clusters)
+	if err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceReleasePlan, plan.ID,
+		eventmodels.ReleasePlanCreated, nil)
+	return c.GetReleasePlan(ctx, plan.ID)
+}
+
+func (c *controller) GetReleasePlan(ctx context.Context, id uint) (*ReleasePlan, error) {
+	const op = "release plan controller: get"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	plan, err := c.releasePlanMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, []uint{plan.CreatedBy})
+	if err != nil {
+		return nil, err
+	}
+	resp := ofReleasePlanModel(plan, users)
+
+	planClusters, err := c.releasePlanMgr.ListClusters(ctx, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Summary = make(map[models.Status]int)
+	for _, planCluster := range planClusters {
+		cluster := &Cluster{
+			ID:            planCluster.ClusterID,
+			Wave:          planCluster.Wave,
+			PipelinerunID: planCluster.PipelinerunID,
+			Status:        planCluster.Status,
+			Message:       planCluster.Message,
+			StartedAt:     planCluster.StartedAt,
+		}
+		clusterModel, err := c.clusterMgr.GetByIDIncludeSoftDelete(ctx, planCluster.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		cluster.Name = clusterModel.Name
+		cluster.Environment = clusterModel.EnvironmentName
+		cluster.Region = clusterModel.RegionName
+		resp.Clusters = append(resp.Clusters, cluster)
+		resp.Summary[planCluster.Status]++
+	}
+	return resp, nil
+}
+
+func (c *controller) ListReleasePlans(ctx context.Context, applicationID uint,
+	query *q.Query) ([]*ReleasePlan, int64, error) {
+	const op = "release plan controller: list"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	plans, total, err := c.releasePlanMgr.ListByApplicationID(ctx, applicationID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	userIDs := make([]uint, 0, len(plans))
+	for _, plan := range plans {
+		userIDs = append(userIDs, plan.CreatedBy)
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]*ReleasePlan, 0, len(plans))
+	for _, plan := range plans {
+		resp = append(resp, ofReleasePlanModel(plan, users))
+	}
+	return resp, total, nil
+}
+
+func (c *controller) CancelReleasePlan(ctx context.Context, id uint) (*ReleasePlan, error) {
+	const op = "release plan controller: cancel"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	currentUser, err := common.UserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := c.releasePlanMgr.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.StatusRunning {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"release plan is %s, only running release plan can be cancelled", plan.Status)
+	}
+	if _, err := c.releasePlanMgr.UpdateByID(ctx, id, &models.ReleasePlan{
+		Status:    models.StatusCancelled,
+		Message:   fmt.Sprintf("cancelled by %s", currentUser.GetName()),
+		UpdatedBy: currentUser.GetID(),
+	}); err != nil {
+		return nil, err
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceReleasePlan, id,
+		eventmodels.ReleasePlanCancelled, nil)
+	return c.GetReleasePlan(ctx, id)
+}
+
+func (c *controller) ProgressReleasePlan(ctx context.Context, id uint) error {
+	const op = "release plan controller: progress"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	plan, err := c.releasePlanMgr.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if plan.Status != models.StatusRunning {
+		return nil
+	}
+	planClusters, err := c.releasePlanMgr.ListClusters(ctx, plan.ID)
+	if err != nil {
+		return err
+	}
+
+	// 1. clusters are deployed on behalf of the creator of release plan
+	creator, err := c.userMgr.GetUserByID(ctx, plan.CreatedBy)
+	if err != nil {
+		return err
+	}
+	ctx = common.WithContext(ctx, &userauth.DefaultInfo{
+		Name:     creator.Name,
+		FullName: creator.FullName,
+		ID:       creator.ID,
+		Email:    creator.Email,
+		Admin:    creator.Admin,
+	})
+
+	// 2. progress the clusters of current wave
+	lastWave, healthy := 0, true
+	for _, planCluster := range planClusters {
+		if planCluster.Wave > lastWave {
+			lastWave = planCluster.Wave
+		}
+		if planCluster.Wave != plan.Wave {
+			continue
+		}
+		if err := c.progressCluster(ctx, plan, planCluster); err != nil {
+			return err
+		}
+		switch planCluster.Status {
+		case models.StatusFailed:
+			return c.finish(ctx, plan, models.StatusFailed,
+				fmt.Sprintf("cluster %d failed in wave %d: %s",
+					planCluster.ClusterID, plan.Wave, planCluster.Message))
+		case models.StatusSucceeded:
+		default:
+			healthy = false
+		}
+	}
+	if !healthy {
+		return nil
+	}
+
+	// 3. move on to the next wave
+	if plan.Wave >= lastWave {
+		return c.finish(ctx, plan, models.StatusSucceeded, "")
+	}
+	if _, err := c.releasePlanMgr.UpdateByID(ctx, plan.ID, &models.ReleasePlan{
+		Wave: plan.Wave + 1,
+	}); err != nil {
+		return err
+	}
+	log.Infof(ctx, "release plan %d moves on to wave %d", plan.ID, plan.Wave+1)
+	return c.ProgressReleasePlan(ctx, plan.ID)
+}
+
+// progressCluster deploys the pending cluster, or checks whether the deploying cluster is healthy
+func (c *controller) progressCluster(ctx context.Context, plan *models.ReleasePlan,
+	planCluster *models.ReleasePlanCluster) error {
+	update := &models.ReleasePlanCluster{}
+	switch planCluster.Status {
+	case models.StatusPending:
+		now := time.Now()
+		update.Status = models.StatusDeploying
+		update.StartedAt = &now
+		resp, err := c.clusterCtl.Deploy(ctx, planCluster.ClusterID, &clusterctl.DeployRequest{
+			Title:       plan.Title,
+			Description: plan.Description,
+			ImageTag:    plan.ImageTag,
+		})
+		if err != nil {
+			// nothing to deploy, just wait for the cluster to be healthy
+			if perror.Cause(err) != herrors.ErrClusterNoChange {
+				update.Status = models.StatusFailed
+				update.Message = fmt.Sprintf("failed to deploy: %v", err)
+			}
+		} else {
+			update.PipelinerunID = resp.PipelinerunID
+		}
+	case models.StatusDeploying:
+		status, err := c.deployStatus(ctx, planCluster)
+		if err != nil {
+			return err
+		}
+		if status == models.StatusFailed || status == models.StatusSucceeded {
+			update.Status = status
+		} else if planCluster.StartedAt != nil &&
+			time.Since(*planCluster.StartedAt) > c.config.HealthTimeout {
+			update.Status = models.StatusFailed
+		}
+		if update.Status == models.StatusFailed {
+			update.Message = planCluster.Message
+		}
+	default:
+		return nil
+	}
+	if update.Status == "" {
+		return nil
+	}
+
+	if err := c.releasePlanMgr.UpdateClusterByID(ctx, planCluster.ID, update); err != nil {
+		return err
+	}
+	planCluster.Status = update.Status
+	planCluster.Message = update.Message
+	if update.PipelinerunID != 0 {
+		planCluster.PipelinerunID = update.PipelinerunID
+	}
+	if update.StartedAt != nil {
+		planCluster.StartedAt = update.StartedAt
+	}
+	return nil
+}
+
+// deployStatus checks the pipelinerun and the health of the deploying cluster,
+// the reason is set into message of the cluster if it's not healthy
+func (c *controller) deployStatus(ctx context.Context,
+	planCluster *models.ReleasePlanCluster) (models.Status, error) {
+	if planCluster.PipelinerunID != 0 {
+		pr, err := c.prMgr.PipelineRun.GetByID(ctx, planCluster.PipelinerunID)
+		if err != nil {
+			return "", err
+		}
+		switch prmodels.PipelineStatus(pr.Status) {
+		case prmodels.StatusOK:
+		case prmodels.StatusFailed, prmodels.StatusCancelled:
+			planCluster.Message = fmt.Sprintf("pipelinerun %d is %s", pr.ID, pr.Status)
+			return models.StatusFailed, nil
+		default:
+			planCluster.Message = fmt.Sprintf("timed out waiting for pipelinerun %d, it's %s",
+				pr.ID, pr.Status)
+			return models.StatusDeploying, nil
+		}
+	}
+
+	status, err := c.clusterCtl.GetClusterStatusV2(ctx, planCluster.ClusterID)
+	if err != nil {
+		return "", err
+	}
+	if status.Status == string(health.HealthStatusHealthy) {
+		return models.StatusSucceeded, nil
+	}
+	planCluster.Message = fmt.Sprintf("timed out waiting for the cluster to be healthy, it's %s",
+		status.Status)
+	return models.StatusDeploying, nil
+}
+
+func (c *controller) finish(ctx context.Context, plan *models.ReleasePlan,
+	status models.Status, message string) error {
+	if _, err := c.releasePlanMgr.UpdateByID(ctx, plan.ID, &models.ReleasePlan{
+		Status:  status,
+		Message: message,
+	}); err != nil {
+		return err
+	}
+	eventType := eventmodels.ReleasePlanSucceeded
+	if status == models.StatusFailed {
+		eventType = eventmodels.ReleasePlanFailed
+	}
+	c.eventSvc.CreateEventIgnoreError(ctx, common.ResourceReleasePlan, plan.ID, eventType, nil)
+	log.Infof(ctx, "release plan %d is %s", plan.ID, status)
+	return nil
+}
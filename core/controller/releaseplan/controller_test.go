@@ -0,0 +1,206 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package releaseplan
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/argoproj/gitops-engine/pkg/health"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
+	clusterctl "github.com/horizoncd/horizon/core/controller/cluster"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	"github.com/horizoncd/horizon/pkg/config/approval"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	"github.com/horizoncd/horizon/pkg/releaseplan/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+var (
+	db, _   = orm.NewSqliteDB("")
+	manager = managerparam.InitManager(db)
+)
+
+// fakeClusterController deploys clusters by creating pipelineruns, and reports the configured status
+type fakeClusterController struct {
+	clusterctl.Controller
+	status map[uint]string
+}
+
+func (f *fakeClusterController) Deploy(ctx context.Context, clusterID uint,
+	r *clusterctl.DeployRequest) (*clusterctl.PipelinerunIDResponse, error) {
+	pr, err := manager.PRMgr.PipelineRun.Create(ctx, &prmodels.Pipelinerun{
+		ClusterID: clusterID,
+		Action:    prmodels.ActionDeploy,
+		Status:    string(prmodels.StatusRunning),
+		Title:     r.Title,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &clusterctl.PipelinerunIDResponse{PipelinerunID: pr.ID}, nil
+}
+
+func (f *fakeClusterController) GetClusterStatusV2(ctx context.Context,
+	clusterID uint) (*clusterctl.StatusResponseV2, error) {
+	return &clusterctl.StatusResponseV2{Status: f.status[clusterID]}, nil
+}
+
+func TestReleasePlan(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name: "releaser",
+		ID:   1,
+	})
+	_, err := manager.UserMgr.Create(ctx, &usermodels.User{Name: "releaser"})
+	assert.Nil(t, err)
+	app, err := manager.ApplicationMgr.Create(ctx, &appmodels.Application{Name: "releaseplan"}, nil)
+	assert.Nil(t, err)
+
+	clusterIDs := make([]uint, 0)
+	for i, region := range []string{"hz", "js", "sh", "bj"} {
+		cluster := &clustermodels.Cluster{
+			ApplicationID:   app.ID,
+			Name:            "releaseplan-" + region,
+			EnvironmentName: "online",
+			RegionName:      region,
+		}
+		if i == 3 {
+			cluster.ApplicationID = app.ID + 1
+		}
+		assert.Nil(t, db.Create(cluster).Error)
+		clusterIDs = append(clusterIDs, cluster.ID)
+	}
+
+	protectedCluster := &clustermodels.Cluster{
+		ApplicationID:   app.ID,
+		Name:            "releaseplan-protected",
+		EnvironmentName: "prod",
+		RegionName:      "hz",
+	}
+	assert.Nil(t, db.Create(protectedCluster).Error)
+
+	clusterCtl := &fakeClusterController{status: map[uint]string{}}
+	ctl := NewController(&config.Config{
+		PRApprovalConfig: approval.Config{
+			Environments: map[string]*approval.Policy{"prod": {RequiredApprovals: 1}},
+		},
+	}, &param.Param{
+		Manager:  manager,
+		EventSvc: eventservice.New(manager),
+	}, clusterCtl)
+	ctl.(*controller).config.HealthTimeout = 30 * time.Minute
+
+	// invalid requests
+	_, err = ctl.CreateReleasePlan(ctx, app.ID, &CreateReleasePlanRequest{})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	_, err = ctl.CreateReleasePlan(ctx, app.ID, &CreateReleasePlanRequest{
+		Waves: [][]uint{{clusterIDs[0]}, {clusterIDs[0]}},
+	})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	_, err = ctl.CreateReleasePlan(ctx, app.ID, &CreateReleasePlanRequest{
+		Waves: [][]uint{{clusterIDs[3]}},
+	})
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+	// clusters in protected environments must be deployed by approved pipelineruns
+	_, err = ctl.CreateReleasePlan(ctx, app.ID, &CreateReleasePlanRequest{
+		Waves: [][]uint{{clusterIDs[0]}, {protectedCluster.ID}},
+	})
+	assert.Equal(t, herrors.ErrApprovalRequired, perror.Cause(err))
+
+	plan, err := ctl.CreateReleasePlan(ctx, app.ID, &CreateReleasePlanRequest{
+		Title: "release v1",
+		Waves: [][]uint{{clusterIDs[0]}, {clusterIDs[1], clusterIDs[2]}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusRunning, plan.Status)
+	assert.Equal(t, 3, plan.Summary[models.StatusPending])
+
+	// the first wave is deployed
+	assert.Nil(t, ctl.ProgressReleasePlan(ctx, plan.ID))
+	plan, err = ctl.GetReleasePlan(ctx, plan.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, plan.Wave)
+	assert.Equal(t, models.StatusDeploying, plan.Clusters[0].Status)
+	assert.NotZero(t, plan.Clusters[0].PipelinerunID)
+	assert.Equal(t, 2, plan.Summary[models.StatusPending])
+
+	// the next wave starts after the first one is healthy
+	assert.Nil(t, manager.PRMgr.PipelineRun.UpdateStatusByID(ctx,
+		plan.Clusters[0].PipelinerunID, prmodels.StatusOK))
+	assert.Nil(t, ctl.ProgressReleasePlan(ctx, plan.ID))
+	plan, err = ctl.GetReleasePlan(ctx, plan.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, plan.Wave)
+	assert.Equal(t, models.StatusDeploying, plan.Clusters[0].Status)
+
+	clusterCtl.status[clusterIDs[0]] = string(health.HealthStatusHealthy)
+	assert.Nil(t, ctl.ProgressReleasePlan(ctx, plan.ID))
+	plan, err = ctl.GetReleasePlan(ctx, plan.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, plan.Wave)
+	assert.Equal(t, models.StatusSucceeded, plan.Clusters[0].Status)
+	assert.Equal(t, 2, plan.Summary[models.StatusDeploying])
+
+	// the plan halts when any cluster fails
+	assert.Nil(t, manager.PRMgr.PipelineRun.UpdateStatusByID(ctx,
+		plan.Clusters[2].PipelinerunID, prmodels.StatusFailed))
+	assert.Nil(t, ctl.ProgressReleasePlan(ctx, plan.ID))
+	plan, err = ctl.GetReleasePlan(ctx, plan.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusFailed, plan.Status)
+	assert.Equal(t, models.StatusFailed, plan.Clusters[2].Status)
+	assert.NotEmpty(t, plan.Message)
+
+	_, err = ctl.CancelReleasePlan(ctx, plan.ID)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	// cancel
+	plan, err = ctl.CreateReleasePlan(ctx, app.ID, &CreateReleasePlanRequest{
+		Waves: [][]uint{{clusterIDs[0]}},
+	})
+	assert.Nil(t, err)
+	plan, err = ctl.CancelReleasePlan(ctx, plan.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusCancelled, plan.Status)
+
+	plans, total, err := ctl.ListReleasePlans(ctx, app.ID, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, plan.ID, plans[0].ID)
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&appmodels.Application{}, &usermodels.User{}, &clustermodels.Cluster{},
+		&membermodels.Member{}, &prmodels.Pipelinerun{}, &models.ReleasePlan{}, &models.ReleasePlanCluster{},
+		&eventmodels.Event{}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,76 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package releaseplan
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/releaseplan/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+type CreateReleasePlanRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// ImageTag the image tag to deploy for the clusters deployed by image
+	ImageTag string `json:"imageTag"`
+	// Waves the IDs of clusters to deploy in each wave,
+	// clusters are deployed sequentially if there is one cluster per wave
+	Waves [][]uint `json:"waves"`
+}
+
+type ReleasePlan struct {
+	ID            uint                  `json:"id"`
+	ApplicationID uint                  `json:"applicationID"`
+	Title         string                `json:"title"`
+	Description   string                `json:"description"`
+	ImageTag      string                `json:"imageTag"`
+	Wave          int                   `json:"wave"`
+	Status        models.Status         `json:"status"`
+	Message       string                `json:"message,omitempty"`
+	Summary       map[models.Status]int `json:"summary,omitempty"`
+	Clusters      []*Cluster            `json:"clusters,omitempty"`
+	CreatedBy     *usermodels.UserBasic `json:"createdBy,omitempty"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	UpdatedAt     time.Time             `json:"updatedAt"`
+}
+
+type Cluster struct {
+	ID            uint          `json:"id"`
+	Name          string        `json:"name"`
+	Environment   string        `json:"environment"`
+	Region        string        `json:"region"`
+	Wave          int           `json:"wave"`
+	PipelinerunID uint          `json:"pipelinerunID,omitempty"`
+	Status        models.Status `json:"status"`
+	Message       string        `json:"message,omitempty"`
+	StartedAt     *time.Time    `json:"startedAt,omitempty"`
+}
+
+func ofReleasePlanModel(p *models.ReleasePlan, users map[uint]*usermodels.User) *ReleasePlan {
+	return &ReleasePlan{
+		ID:            p.ID,
+		ApplicationID: p.ApplicationID,
+		Title:         p.Title,
+		Description:   p.Description,
+		ImageTag:      p.ImageTag,
+		Wave:          p.Wave,
+		Status:        p.Status,
+		Message:       p.Message,
+		CreatedBy:     usermodels.ToUser(users[p.CreatedBy]),
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
@@ -22,21 +22,36 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/config"
 	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/lib/q"
 	applicationmanager "github.com/horizoncd/horizon/pkg/application/manager"
 	"github.com/horizoncd/horizon/pkg/cluster/gitrepo"
 	"github.com/horizoncd/horizon/pkg/cluster/kubeclient"
 	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	"github.com/horizoncd/horizon/pkg/cluster/tekton/collector"
+	tektonfactory "github.com/horizoncd/horizon/pkg/cluster/tekton/factory"
+	terminalconfig "github.com/horizoncd/horizon/pkg/config/terminal"
 	envmanager "github.com/horizoncd/horizon/pkg/environment/manager"
 	envregionmanager "github.com/horizoncd/horizon/pkg/environmentregion/manager"
 	perror "github.com/horizoncd/horizon/pkg/errors"
 	"github.com/horizoncd/horizon/pkg/param"
 	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
 	trmanager "github.com/horizoncd/horizon/pkg/templaterelease/manager"
+	terminalsessionmanager "github.com/horizoncd/horizon/pkg/terminalsession/manager"
+	"github.com/horizoncd/horizon/pkg/terminalsession/models"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
 	"github.com/horizoncd/horizon/pkg/util/errors"
+	"github.com/horizoncd/horizon/pkg/util/log"
 	"github.com/horizoncd/horizon/pkg/util/wlog"
 
 	"gopkg.in/igm/sockjs-go.v3/sockjs"
@@ -48,9 +63,14 @@ type Controller interface {
 	// CreateShell returns sessionID and sockJSHandler according to clusterID,podName,containerName
 	CreateShell(ctx context.Context, clusterID uint, podName, containerName string) (sessionID string,
 		sockJSHandler http.Handler, err error)
+	// ListSessions lists the recorded terminal sessions of cluster, the latest first
+	ListSessions(ctx context.Context, clusterID uint, query *q.Query) ([]*TerminalSession, int64, error)
+	// GetRecording gets the recording of a terminal session of cluster in asciicast v2 format
+	GetRecording(ctx context.Context, clusterID, sessionID uint) ([]byte, error)
 }
 
 type controller struct {
+	config             *terminalconfig.Config
 	kubeClientFty      kubeclient.Factory
 	clusterMgr         clustermanager.Manager
 	applicationMgr     applicationmanager.Manager
@@ -58,13 +78,17 @@ type controller struct {
 	envMgr             envmanager.Manager
 	envRegionMgr       envregionmanager.Manager
 	regionMgr          regionmanager.Manager
+	terminalSessionMgr terminalsessionmanager.Manager
+	userMgr            usermanager.Manager
 	clusterGitRepo     gitrepo.ClusterGitRepo
+	tektonFty          tektonfactory.Factory
 }
 
 var _ Controller = (*controller)(nil)
 
-func NewController(param *param.Param) Controller {
+func NewController(config *config.Config, param *param.Param) Controller {
 	return &controller{
+		config:             &config.TerminalConfig,
 		kubeClientFty:      kubeclient.Fty,
 		clusterMgr:         param.ClusterMgr,
 		applicationMgr:     param.ApplicationMgr,
@@ -72,7 +96,10 @@ func NewController(param *param.Param) Controller {
 		envMgr:             param.EnvMgr,
 		envRegionMgr:       param.EnvRegionMgr,
 		regionMgr:          param.RegionMgr,
+		terminalSessionMgr: param.TerminalSessionMgr,
+		userMgr:            param.UserMgr,
 		clusterGitRepo:     param.ClusterGitRepo,
+		tektonFty:          param.TektonFty,
 	}
 }
 
@@ -138,13 +165,15 @@ func (c *controller) GetSockJSHandler(ctx context.Context, sessionID string) (ht
 		RandomID:    randomID,
 	}
 
+	recorder := c.newRecorder(cluster.EnvironmentName)
 	terminalSessions.Set(ref.String(), Session{
 		id:       ref.String(),
 		bound:    make(chan error),
 		sizeChan: make(chan remotecommand.TerminalSize),
+		recorder: recorder,
 	})
 
-	go WaitForTerminal(kubeClient.Basic, kubeConfig, ref)
+	go c.waitForTerminal(newRecordingContext(ctx), cluster, kubeClient.Basic, kubeConfig, ref, recorder)
 
 	handler := sockjs.NewHandler("/apis/front/v1", sockjs.DefaultOptions, handleTerminalSession)
 	return handler, nil
@@ -200,18 +229,144 @@ func (c *controller) CreateShell(ctx context.Context, clusterID uint, podName,
 		RandomID:    randomID,
 	}
 
+	recorder := c.newRecorder(cluster.EnvironmentName)
 	terminalSessions.Set(ref.String(), Session{
 		id:       ref.String(),
 		bound:    make(chan error),
 		sizeChan: make(chan remotecommand.TerminalSize),
+		recorder: recorder,
 	})
 
 	handler := sockjs.NewHandler("/apis/core/v1", sockjs.DefaultOptions, handleShellSession(ctx, ref.String()))
 
-	go WaitForTerminal(kubeClient.Basic, kubeConfig, ref)
+	go c.waitForTerminal(newRecordingContext(ctx), cluster, kubeClient.Basic, kubeConfig, ref, recorder)
 	return randomID, handler, nil
 }
 
+func (c *controller) ListSessions(ctx context.Context, clusterID uint,
+	query *q.Query) ([]*TerminalSession, int64, error) {
+	const op = "terminal controller: list sessions"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	sessions, total, err := c.terminalSessionMgr.ListByClusterID(ctx, clusterID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	userIDs := make([]uint, 0, len(sessions))
+	for _, session := range sessions {
+		userIDs = append(userIDs, session.CreatedBy)
+	}
+	users, err := c.userMgr.GetUserMapByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]*TerminalSession, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, ofSessionModel(session, users))
+	}
+	return resp, total, nil
+}
+
+func (c *controller) GetRecording(ctx context.Context, clusterID, sessionID uint) ([]byte, error) {
+	const op = "terminal controller: get recording"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	session, err := c.terminalSessionMgr.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.ClusterID != clusterID {
+		return nil, herrors.NewErrNotFound(herrors.TerminalSessionInDB,
+			fmt.Sprintf("terminal session %d not found in cluster %d", sessionID, clusterID))
+	}
+	if session.Object == "" {
+		return nil, herrors.NewErrNotFound(herrors.TerminalRecording,
+			fmt.Sprintf("recording of terminal session %d is not stored", sessionID))
+	}
+	cluster, err := c.clusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	tektonCollector, err := c.tektonFty.GetTektonCollector(cluster.EnvironmentName)
+	if err != nil {
+		return nil, err
+	}
+	return tektonCollector.GetTerminalRecording(ctx, session.Object)
+}
+
+// newRecorder returns a recorder if terminal sessions of the environment are recorded, otherwise nil
+func (c *controller) newRecorder(environment string) *Recorder {
+	for _, env := range c.config.RecordEnvironments {
+		if env == environment {
+			return NewRecorder(c.config.MaxRecordingSize)
+		}
+	}
+	return nil
+}
+
+// waitForTerminal waits for the terminal session, and stores the recording after the session ends
+func (c *controller) waitForTerminal(ctx context.Context, cluster *clustermodels.Cluster,
+	k8sClient kubernetes.Interface, cfg *rest.Config, ref ContainerRef, recorder *Recorder) {
+	WaitForTerminal(k8sClient, cfg, ref)
+	if recorder != nil {
+		c.saveRecording(ctx, cluster, ref, recorder)
+	}
+}
+
+// saveRecording stores the recording by the collector of the environment, and records the session,
+// the session is recorded even if the recording failed to be stored
+func (c *controller) saveRecording(ctx context.Context, cluster *clustermodels.Cluster,
+	ref ContainerRef, recorder *Recorder) {
+	content := recorder.Bytes()
+	session := &models.TerminalSession{
+		ClusterID:     cluster.ID,
+		PodName:       ref.Pod,
+		ContainerName: ref.Container,
+		Size:          len(content),
+		Truncated:     recorder.Truncated(),
+		StartedAt:     recorder.StartedAt(),
+		EndedAt:       time.Now(),
+	}
+	operator := ""
+	if user, err := common.UserFromContext(ctx); err == nil {
+		operator = user.GetName()
+		session.CreatedBy = user.GetID()
+	}
+
+	tektonCollector, err := c.tektonFty.GetTektonCollector(cluster.EnvironmentName)
+	if err == nil {
+		session.Object, err = tektonCollector.PutTerminalRecording(ctx, &collector.TerminalRecording{
+			Cluster:   cluster.Name,
+			ClusterID: cluster.ID,
+			Pod:       ref.Pod,
+			Container: ref.Container,
+			Operator:  operator,
+			StartedAt: session.StartedAt,
+			Content:   content,
+		})
+	}
+	if err != nil {
+		log.Errorf(ctx, "failed to store recording of terminal session %s, err: %v", ref.String(), err)
+	}
+	if _, err := c.terminalSessionMgr.Create(ctx, session); err != nil {
+		log.Errorf(ctx, "failed to create terminal session %s, err: %v", ref.String(), err)
+	}
+}
+
+// newRecordingContext returns a context outliving the request,
+// which keeps the user and the request id for storing the recording after the session ends
+func newRecordingContext(ctx context.Context) context.Context {
+	recordingCtx := context.Background()
+	if user, err := common.UserFromContext(ctx); err == nil {
+		recordingCtx = common.WithContext(recordingCtx, user)
+	}
+	if rid, err := requestid.FromContext(ctx); err == nil {
+		// nolint
+		recordingCtx = context.WithValue(recordingCtx, requestid.HeaderXRequestID, rid)
+	}
+	return recordingCtx
+}
+
 func genRandomID() (string, error) {
 	bytes := make([]byte, 5)
 	if _, err := rand.Read(bytes); err != nil {
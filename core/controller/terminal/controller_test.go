@@ -0,0 +1,160 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	"github.com/horizoncd/horizon/lib/q"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	"github.com/horizoncd/horizon/pkg/cluster/tekton"
+	"github.com/horizoncd/horizon/pkg/cluster/tekton/collector"
+	terminalconfig "github.com/horizoncd/horizon/pkg/config/terminal"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	terminalsessionmodels "github.com/horizoncd/horizon/pkg/terminalsession/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+// fakeCollector stores the recordings in memory
+type fakeCollector struct {
+	collector.Interface
+	recordings map[string][]byte
+}
+
+func (f *fakeCollector) PutTerminalRecording(ctx context.Context,
+	recording *collector.TerminalRecording) (string, error) {
+	object := fmt.Sprintf("%s/%s/%d", recording.Cluster, recording.Pod, len(f.recordings))
+	f.recordings[object] = recording.Content
+	return object, nil
+}
+
+func (f *fakeCollector) GetTerminalRecording(ctx context.Context, object string) ([]byte, error) {
+	return f.recordings[object], nil
+}
+
+type fakeFactory struct {
+	collector *fakeCollector
+}
+
+func (f *fakeFactory) GetTekton(environment string) (tekton.Interface, error) {
+	return nil, nil
+}
+
+func (f *fakeFactory) GetTektonCollector(environment string) (collector.Interface, error) {
+	return f.collector, nil
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder(0)
+	recorder.Resize(120, 40)
+	recorder.Input("ls\r")
+	// the chinese character is split into two outputs
+	recorder.Output([]byte("ls\r\n\xe4\xb8"))
+	recorder.Output([]byte("\xad\r\n"))
+	recorder.Resize(100, 30)
+
+	lines := strings.Split(strings.TrimSpace(string(recorder.Bytes())), "\n")
+	assert.Equal(t, 5, len(lines))
+	header := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, float64(2), header["version"])
+	assert.Equal(t, float64(120), header["width"])
+	assert.Equal(t, float64(40), header["height"])
+
+	expected := [][2]string{{"i", "ls\r"}, {"o", "ls\r\n"}, {"o", "中\r\n"}, {"r", "100x30"}}
+	for i, line := range lines[1:] {
+		event := []interface{}{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &event))
+		assert.Equal(t, expected[i][0], event[1])
+		assert.Equal(t, expected[i][1], event[2])
+	}
+	assert.False(t, recorder.Truncated())
+
+	// output exceeding the max size is dropped with a marker, while the input is kept
+	recorder = NewRecorder(64)
+	recorder.Output([]byte("hello"))
+	recorder.Output([]byte(strings.Repeat("x", 64)))
+	recorder.Output([]byte("world"))
+	recorder.Input("exit\r")
+	lines = strings.Split(strings.TrimSpace(string(recorder.Bytes())), "\n")
+	assert.Equal(t, 4, len(lines))
+	assert.Contains(t, lines[1], `"o","hello"`)
+	assert.Contains(t, lines[2], `"m","`+_truncatedMarker+`"`)
+	assert.Contains(t, lines[3], `"i","exit\r"`)
+	assert.True(t, recorder.Truncated())
+}
+
+func TestRecording(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	assert.Nil(t, db.AutoMigrate(&clustermodels.Cluster{}, &usermodels.User{},
+		&terminalsessionmodels.TerminalSession{}))
+	manager := managerparam.InitManager(db)
+
+	user := &usermodels.User{Name: "terminal"}
+	assert.Nil(t, db.Create(user).Error)
+	cluster := &clustermodels.Cluster{Name: "terminal-cluster", EnvironmentName: "online"}
+	assert.Nil(t, db.Create(cluster).Error)
+	ctx := common.WithContext(context.Background(), &userauth.DefaultInfo{
+		Name: user.Name,
+		ID:   user.ID,
+	})
+
+	fakeCollector := &fakeCollector{recordings: map[string][]byte{}}
+	c := &controller{
+		config:             &terminalconfig.Config{RecordEnvironments: []string{"online"}},
+		clusterMgr:         manager.ClusterMgr,
+		terminalSessionMgr: manager.TerminalSessionMgr,
+		userMgr:            manager.UserMgr,
+		tektonFty:          &fakeFactory{collector: fakeCollector},
+	}
+	assert.Nil(t, c.newRecorder("test"))
+	recorder := c.newRecorder("online")
+	assert.NotNil(t, recorder)
+	recorder.Output([]byte("hello"))
+
+	c.saveRecording(newRecordingContext(ctx), cluster, ContainerRef{
+		Pod:       "web-0",
+		Container: "web",
+	}, recorder)
+
+	sessions, total, err := c.ListSessions(ctx, cluster.ID, &q.Query{PageNumber: 1, PageSize: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "web-0", sessions[0].PodName)
+	assert.Equal(t, "web", sessions[0].ContainerName)
+	assert.True(t, sessions[0].Recorded)
+	assert.Equal(t, user.ID, sessions[0].CreatedBy.ID)
+
+	recording, err := c.GetRecording(ctx, cluster.ID, sessions[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, recorder.Bytes(), recording)
+
+	// the session does not belong to other clusters
+	_, err = c.GetRecording(ctx, cluster.ID+1, sessions[0].ID)
+	e, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+	assert.Equal(t, herrors.TerminalSessionInDB, e.Source)
+}
@@ -14,6 +14,42 @@
 
 package terminal
 
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/terminalsession/models"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
 type SessionIDResp struct {
 	ID string `json:"id"`
 }
+
+type TerminalSession struct {
+	ID            uint   `json:"id"`
+	ClusterID     uint   `json:"clusterID"`
+	PodName       string `json:"podName"`
+	ContainerName string `json:"containerName"`
+	// Recorded tells whether the recording is stored and can be replayed
+	Recorded  bool                  `json:"recorded"`
+	Size      int                   `json:"size"`
+	Truncated bool                  `json:"truncated"`
+	StartedAt time.Time             `json:"startedAt"`
+	EndedAt   time.Time             `json:"endedAt"`
+	CreatedBy *usermodels.UserBasic `json:"createdBy,omitempty"`
+}
+
+func ofSessionModel(session *models.TerminalSession, users map[uint]*usermodels.User) *TerminalSession {
+	return &TerminalSession{
+		ID:            session.ID,
+		ClusterID:     session.ClusterID,
+		PodName:       session.PodName,
+		ContainerName: session.ContainerName,
+		Recorded:      session.Object != "",
+		Size:          session.Size,
+		Truncated:     session.Truncated,
+		StartedAt:     session.StartedAt,
+		EndedAt:       session.EndedAt,
+		CreatedBy:     usermodels.ToUser(users[session.CreatedBy]),
+	}
+}
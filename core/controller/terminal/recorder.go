@@ -0,0 +1,162 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	_defaultWidth  = 80
+	_defaultHeight = 24
+
+	_truncatedMarker = "recording truncated, output dropped"
+)
+
+// Recorder records a terminal session in asciicast v2 format,
+// see https://docs.asciinema.org/manual/asciicast/v2/
+type Recorder struct {
+	lock      sync.Mutex
+	startedAt time.Time
+	width     uint16
+	height    uint16
+	events    bytes.Buffer
+	// maxSize limits the output and resize events, the input events are kept until twice of it
+	maxSize   int
+	truncated bool
+	// pending is the incomplete utf-8 sequence at the end of the last output
+	pending []byte
+}
+
+func NewRecorder(maxSize int) *Recorder {
+	return &Recorder{
+		startedAt: time.Now(),
+		maxSize:   maxSize,
+	}
+}
+
+// Output records the output of the process
+func (r *Recorder) Output(p []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	data := append(r.pending, p...)
+	// keep the incomplete utf-8 sequence to the next output, for the events must be valid json strings
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.record("o", string(data[:cut]))
+	}
+}
+
+// Input records the keystrokes typed by the user
+func (r *Recorder) Input(data string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.record("i", data)
+}
+
+// Resize records the new size of the terminal, the first size is the initial size of the recording
+func (r *Recorder) Resize(cols, rows uint16) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.width == 0 && r.height == 0 {
+		r.width, r.height = cols, rows
+		return
+	}
+	r.record("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (r *Recorder) record(code, data string) {
+	// keep the keystrokes after the output is truncated, for they tell what the user has done
+	if r.truncated && code != "i" {
+		return
+	}
+	line, err := r.event(code, data)
+	if err != nil {
+		return
+	}
+	limit := r.maxSize
+	if code == "i" {
+		limit *= 2
+	}
+	if r.maxSize > 0 && r.events.Len()+len(line)+1 > limit {
+		if !r.truncated {
+			r.truncated = true
+			// the marker is always written, so that the replay tells where the recording is truncated
+			if marker, err := r.event("m", _truncatedMarker); err == nil {
+				r.events.Write(marker)
+				r.events.WriteByte('\n')
+			}
+		}
+		return
+	}
+	r.events.Write(line)
+	r.events.WriteByte('\n')
+}
+
+func (r *Recorder) event(code, data string) ([]byte, error) {
+	elapsed := float64(time.Since(r.startedAt).Microseconds()) / float64(time.Second/time.Microsecond)
+	return json.Marshal([]interface{}{elapsed, code, data})
+}
+
+// StartedAt returns the time the recording started
+func (r *Recorder) StartedAt() time.Time {
+	return r.startedAt
+}
+
+// Truncated tells whether the output of the recording has exceeded the max size
+func (r *Recorder) Truncated() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.truncated
+}
+
+// Bytes returns the recording in asciicast v2 format
+func (r *Recorder) Bytes() []byte {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	width, height := r.width, r.height
+	if width == 0 || height == 0 {
+		width, height = _defaultWidth, _defaultHeight
+	}
+	header, _ := json.Marshal(map[string]interface{}{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": r.startedAt.Unix(),
+		"env":       map[string]string{"TERM": "xterm"},
+	})
+	var b bytes.Buffer
+	b.Grow(len(header) + 1 + r.events.Len())
+	b.Write(header)
+	b.WriteByte('\n')
+	b.Write(r.events.Bytes())
+	return b.Bytes()
+}
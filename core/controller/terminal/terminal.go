@@ -53,6 +53,8 @@ type Session struct {
 	sockJSSession sockjs.Session
 	sizeChan      chan remotecommand.TerminalSize
 	doneChan      chan struct{}
+	// recorder records the session if it is not nil
+	recorder *Recorder
 }
 
 // Message is the messaging protocol between ShellController and TerminalSession.
@@ -96,8 +98,14 @@ func (t Session) Read(p []byte) (int, error) {
 
 	switch msg.Op {
 	case "stdin":
+		if t.recorder != nil {
+			t.recorder.Input(msg.Data)
+		}
 		return copy(p, msg.Data), nil
 	case "resize":
+		if t.recorder != nil {
+			t.recorder.Resize(msg.Cols, msg.Rows)
+		}
 		t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}
 		return 0, nil
 	default:
@@ -108,6 +116,9 @@ func (t Session) Read(p []byte) (int, error) {
 // Write handles process->pty stdout
 // Called from remotecommand whenever there is any output
 func (t Session) Write(p []byte) (int, error) {
+	if t.recorder != nil {
+		t.recorder.Output(p)
+	}
 	msg, err := json.Marshal(Message{
 		Op:   "stdout",
 		Data: string(p),
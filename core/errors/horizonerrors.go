@@ -29,6 +29,8 @@ var (
 	GitlabClient              = sourceType{name: "GitlabClient"}
 	GitlabResource            = sourceType{name: "GitlabResource"}
 	GithubResource            = sourceType{name: "GithubResource"}
+	GiteaResource             = sourceType{name: "GiteaResource"}
+	GitRepoInFilesystem       = sourceType{name: "GitRepoInFilesystem"}
 	ClusterInDB               = sourceType{name: "ClusterInDB"}
 	CollectionInDB            = sourceType{name: "CollectionInDB"}
 	ClusterStateInArgo        = sourceType{name: "ClusterStateInArgo"}
@@ -67,6 +69,7 @@ var (
 	KubeConfigInK8S           = sourceType{name: "KubeConfigK8S"}
 	GroupFullPath             = sourceType{name: "GroupFullPath"}
 	IdentityProviderInDB      = sourceType{name: "IdentityProviderInDB"}
+	LDAPGrantInDB             = sourceType{name: "LDAPGrantInDB"}
 	EventInDB                 = sourceType{name: "EventInDB"}
 	EventCursorInDB           = sourceType{name: "EventCursorInDB"}
 	WebhookInDB               = sourceType{name: "WebhookInDB"}
@@ -75,10 +78,32 @@ var (
 	CheckInDB                 = sourceType{name: "CheckInDB"}
 	CheckRunInDB              = sourceType{name: "CheckRunInDB"}
 	PRMessageInDB             = sourceType{name: "PRMessageInDB"}
+	ElevationInDB             = sourceType{name: "ElevationInDB"}
+	AdmissionPolicyInDB       = sourceType{name: "AdmissionPolicyInDB"}
+	ReleasePlanInDB           = sourceType{name: "ReleasePlanInDB"}
+	ReleasePlanClusterInDB    = sourceType{name: "ReleasePlanClusterInDB"}
+	GitopsRepoInDB            = sourceType{name: "GitopsRepoInDB"}
+	GitopsRefInDB             = sourceType{name: "GitopsRefInDB"}
+	GitopsCommitInDB          = sourceType{name: "GitopsCommitInDB"}
+	GitopsBlobInDB            = sourceType{name: "GitopsBlobInDB"}
+	ChangeRequestInDB         = sourceType{name: "ChangeRequestInDB"}
+	ChangeRequestReviewInDB   = sourceType{name: "ChangeRequestReviewInDB"}
+	ClusterDriftInDB          = sourceType{name: "ClusterDriftInDB"}
+	CanaryAnalysisInDB        = sourceType{name: "CanaryAnalysisInDB"}
+	CanaryAnalysisRunInDB     = sourceType{name: "CanaryAnalysisRunInDB"}
+	HPAOverrideInDB           = sourceType{name: "HPAOverrideInDB"}
+	HibernationScheduleInDB   = sourceType{name: "HibernationScheduleInDB"}
+	ClusterHibernationInDB    = sourceType{name: "ClusterHibernationInDB"}
+	TerminalSessionInDB       = sourceType{name: "TerminalSessionInDB"}
+	K8sEventAggregationInDB   = sourceType{name: "K8sEventAggregationInDB"}
+	AuditLogInDB              = sourceType{name: "AuditLogInDB"}
+	PipelinerunApprovalInDB   = sourceType{name: "PipelinerunApprovalInDB"}
 
 	// S3
 	PipelinerunLog = sourceType{name: "PipelinerunLog"}
 	PipelinerunObj = sourceType{name: "PipelinerunObj"}
+	// TerminalRecording is the recording of terminal session in s3 or local storage
+	TerminalRecording = sourceType{name: "TerminalRecording"}
 
 	ArgoCD = sourceType{name: "ArgoCD"}
 
@@ -94,6 +119,7 @@ var (
 	// identity provider
 	Oauth2Token           = sourceType{name: "Oauth2Token"}
 	ProviderFromDiscovery = sourceType{name: "ProviderFromDiscovery"}
+	LDAPServer            = sourceType{name: "LDAPServer"}
 
 	StepInWorkload = sourceType{name: "StepInWorkload"}
 
@@ -226,8 +252,10 @@ var (
 	ErrShouldBuildDeployFirst          = errors.New("clusters with build config should build and deploy first")
 	ErrBuildDeployNotSupported         = errors.New("builddeploy is not supported for this cluster")
 	ErrFreedClusterNotSupportedRestart = errors.New("freed cluster is not supported to restart")
+	ErrChangeRequestRequired           = errors.New("config changes must be approved through change requests")
 
 	// pipelinerun
+	ErrApprovalRequired = errors.New("pipelineruns must be approved before executed")
 
 	// context
 	ErrFailedToGetORM       = errors.New("cannot get the ORM from context")
@@ -248,6 +276,13 @@ var (
 	ErrGitlabResourceNotFound      = errors.New("gitlab resource not found")
 	ErrGitLabDefaultBranchNotMatch = errors.New("gitlab default branch do not match")
 
+	ErrGiteaInternal    = errors.New("gitea internal")
+	ErrGiteaPRNotReady  = errors.New("gitea pull request is not ready and cannot be merged")
+	ErrGithubInternal   = errors.New("github internal")
+	ErrGitCommandFailed = errors.New("git command failed")
+	ErrGitMergeConflict = errors.New("git merge conflict")
+	ErrGitopsRefChanged = errors.New("gitops ref has been changed by another commit")
+
 	// git
 	ErrBranchAndCommitEmpty      = errors.New("branch and commit cannot be empty at the same time")
 	ErrGitlabInterfaceCallFailed = errors.New("failed to call gitlab interface")
@@ -283,6 +318,14 @@ var (
 	ErrOAuthTokenFormatError       = errors.New("Oauth token format error")
 	ErrOAuthNotGroupOwnerType      = errors.New("not group oauth app")
 
+	// ErrOAuthAuthorizationPending the user has not yet authorized the device code
+	ErrOAuthAuthorizationPending = errors.New("authorization pending")
+	// ErrOAuthAccessDenied the user has denied the device code
+	ErrOAuthAccessDenied = errors.New("access denied")
+
+	// ErrLDAPInvalidCredentials the username or password is rejected by the ldap server
+	ErrLDAPInvalidCredentials = errors.New("invalid ldap credentials")
+
 	// ErrRegistryUsedByRegions used when deleting a registry that is still used by regions
 	ErrRegistryUsedByRegions = errors.New("cannot delete a registry when used by regions")
 
@@ -282,6 +282,10 @@ func (a *API) Update(c *gin.Context) {
 			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
 			return
 		}
+		if perror.Cause(err) == herrors.ErrChangeRequestRequired {
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
 
 		if perror.Cause(err) == herrors.ErrParamInvalid {
 			log.WithFiled(c, "op", op).Errorf("err = %+v, request = %+v", err, request)
@@ -68,6 +68,10 @@ func (a *API) BuildDeploy(c *gin.Context) {
 			response.AbortWithRPCError(c, rpcerror.BadRequestError.WithErrMsg(err.Error()))
 			return
 		}
+		if perror.Cause(err) == herrors.ErrApprovalRequired {
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
 		log.WithFiled(c, "op", op).Errorf("%+v", err)
 		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
 		return
@@ -225,6 +229,10 @@ func (a *API) Restart(c *gin.Context) {
 			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
 			return
 		}
+		if perror.Cause(err) == herrors.ErrApprovalRequired {
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
 		log.WithFiled(c, "op", op).Errorf("%+v", err)
 		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
 		return
@@ -263,6 +271,10 @@ func (a *API) Deploy(c *gin.Context) {
 			return
 		}
 
+		if perror.Cause(err) == herrors.ErrApprovalRequired {
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
 		log.WithFiled(c, "op", op).Errorf("%+v", err)
 		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
 		return
@@ -454,6 +466,10 @@ func (a *API) Rollback(c *gin.Context) {
 			response.AbortWithRPCError(c, rpcerror.BadRequestError.WithErrMsg(err.Error()))
 			return
 		}
+		if perror.Cause(err) == herrors.ErrApprovalRequired {
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
 		log.WithFiled(c, "op", op).Errorf("%+v", err)
 		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
 		return
@@ -618,6 +634,10 @@ func (a *API) Upgrade(c *gin.Context) {
 			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
 			return
 		}
+		if perror.Cause(err) == herrors.ErrChangeRequestRequired {
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
 		if perror.Cause(err) == herrors.ErrParamInvalid {
 			response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
 			return
@@ -27,6 +27,8 @@ import (
 	"github.com/horizoncd/horizon/core/controller/oauthapp"
 	herrors "github.com/horizoncd/horizon/core/errors"
 	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/oauth/manager"
+	"github.com/horizoncd/horizon/pkg/oauth/oidc"
 	"github.com/horizoncd/horizon/pkg/oauth/scope"
 	"github.com/horizoncd/horizon/pkg/server/response"
 	"github.com/horizoncd/horizon/pkg/util/log"
@@ -43,27 +45,46 @@ const (
 	KeyRefreshToken = "refresh_token"
 	KeyClientSecret = "client_secret"
 
-	KeyGrantType          = "grant_type"
-	GrantTypeAuthCode     = "authorization_code"
-	GrantTypeRefreshToken = "refresh_token"
+	KeyCodeChallenge       = "code_challenge"
+	KeyCodeChallengeMethod = "code_challenge_method"
+	KeyCodeVerifier        = "code_verifier"
+	KeyDeviceCode          = "device_code"
+	KeyUserCode            = "user_code"
+	KeyNonce               = "nonce"
+	KeyToken               = "token"
+
+	KeyGrantType               = "grant_type"
+	GrantTypeAuthCode          = manager.GrantTypeAuthorizationCode
+	GrantTypeRefreshToken      = manager.GrantTypeRefreshToken
+	GrantTypeClientCredentials = manager.GrantTypeClientCredentials
+	GrantTypeDeviceCode        = manager.GrantTypeDeviceCode
 
 	Authorized = "1"
 )
 
+// error codes of device access token response, ref: rfc8628#section-3.5
+const (
+	ErrorAuthorizationPending = "authorization_pending"
+	ErrorAccessDenied         = "access_denied"
+	ErrorExpiredToken         = "expired_token"
+)
+
 type API struct {
 	oauthAppController oauthapp.Controller
 	oAuthServer        oauth.Controller
 	oauthHTMLLocation  string
 	scopeService       scope.Service
+	oidcService        oidc.Service
 }
 
-func NewAPI(oauthServerController oauth.Controller,
-	oauthAppController oauthapp.Controller, oauthHTMLLocation string, scopeService scope.Service) *API {
+func NewAPI(oauthServerController oauth.Controller, oauthAppController oauthapp.Controller,
+	oauthHTMLLocation string, scopeService scope.Service, oidcService oidc.Service) *API {
 	return &API{
 		oAuthServer:        oauthServerController,
 		oauthAppController: oauthAppController,
 		oauthHTMLLocation:  oauthHTMLLocation,
 		scopeService:       scopeService,
+		oidcService:        oidcService,
 	}
 }
 
@@ -80,6 +101,12 @@ type AuthorizationPageParams struct {
 	Scope       string
 	ClientName  string
 	ScopeBasic  []ScopeBasic
+
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	// UserCode is set when authorizing a device, and the form is posted to the device path
+	UserCode string
 }
 
 func (a *API) HandleAuthorizationGetReq(c *gin.Context) {
@@ -116,33 +143,34 @@ func (a *API) HandleAuthorizationGetReq(c *gin.Context) {
 		response.AbortWithInternalError(c, err.Error())
 		return
 	}
-	scopeRules := a.scopeService.GetRulesByScope(strings.Split(c.Query(KeyScope), " "))
-	scopeInfo := func() []ScopeBasic {
-		scopeBasics := make([]ScopeBasic, 0)
-		for _, scope := range scopeRules {
-			scopeBasics = append(scopeBasics, ScopeBasic{
-				Name: scope.Name,
-				Desc: scope.Desc,
-			})
-		}
-		return scopeBasics
-	}
-
 	currentUser, err := common.UserFromContext(c)
 	if err != nil {
 		response.AbortWithForbiddenError(c, common.Forbidden, err.Error())
 		return
 	}
 
-	params := AuthorizationPageParams{
-		UserName:    currentUser.GetName(),
-		ClientName:  appBasicInfo.AppName,
-		ClientID:    c.Query(KeyClientID),
-		HomeURL:     appBasicInfo.HomeURL,
-		State:       c.Query(KeyState),
-		Scope:       c.Query(KeyScope),
-		RedirectURL: c.Query(KeyRedirectURI),
-		ScopeBasic:  scopeInfo(),
+	a.renderAuthorizationPage(c, AuthorizationPageParams{
+		UserName:            currentUser.GetName(),
+		ClientName:          appBasicInfo.AppName,
+		ClientID:            c.Query(KeyClientID),
+		HomeURL:             appBasicInfo.HomeURL,
+		State:               c.Query(KeyState),
+		Scope:               c.Query(KeyScope),
+		RedirectURL:         c.Query(KeyRedirectURI),
+		CodeChallenge:       c.Query(KeyCodeChallenge),
+		CodeChallengeMethod: c.Query(KeyCodeChallengeMethod),
+		Nonce:               c.Query(KeyNonce),
+	})
+}
+
+func (a *API) renderAuthorizationPage(c *gin.Context, params AuthorizationPageParams) {
+	scopeRules := a.scopeService.GetRulesByScope(strings.Split(params.Scope, " "))
+	params.ScopeBasic = make([]ScopeBasic, 0)
+	for _, scope := range scopeRules {
+		params.ScopeBasic = append(params.ScopeBasic, ScopeBasic{
+			Name: scope.Name,
+			Desc: scope.Desc,
+		})
 	}
 	authTemplate, err := template.ParseFiles(a.oauthHTMLLocation)
 	if err != nil {
@@ -206,6 +234,10 @@ func (a *API) handlerPostAuthorizationReq(c *gin.Context) {
 			State:        c.PostForm(KeyState),
 			UserIdentity: user.GetID(),
 			Request:      c.Request,
+
+			CodeChallenge:       c.PostForm(KeyCodeChallenge),
+			CodeChallengeMethod: c.PostForm(KeyCodeChallengeMethod),
+			Nonce:               c.PostForm(KeyNonce),
 		})
 		if err != nil {
 			causeErr := perror.Cause(err)
@@ -241,16 +273,21 @@ func (a *API) HandleAccessTokenReq(c *gin.Context) {
 		return
 	}
 
-	keys := []string{
-		KeyClientID,
-		KeyClientSecret,
-		KeyRedirectURI,
-	}
-	if grantType == GrantTypeAuthCode {
-		keys = append(keys, KeyCode)
-	} else if grantType == GrantTypeRefreshToken {
-		keys = append(keys, KeyRefreshToken)
-	} else {
+	var keys []string
+	switch grantType {
+	case GrantTypeAuthCode:
+		// public clients using PKCE provide code_verifier instead of client_secret
+		keys = []string{KeyClientID, KeyRedirectURI, KeyCode}
+		if _, ok := c.GetPostForm(KeyCodeVerifier); !ok {
+			keys = append(keys, KeyClientSecret)
+		}
+	case GrantTypeRefreshToken:
+		keys = []string{KeyClientID, KeyClientSecret, KeyRedirectURI, KeyRefreshToken}
+	case GrantTypeClientCredentials:
+		keys = []string{KeyClientID, KeyClientSecret}
+	case GrantTypeDeviceCode:
+		keys = []string{KeyClientID, KeyDeviceCode}
+	default:
 		response.AbortWithRequestError(c, common.InvalidRequestParam, "grant_type not supported")
 		return
 	}
@@ -279,20 +316,46 @@ func (a *API) HandleAccessTokenReq(c *gin.Context) {
 		RedirectURL:  c.PostForm(KeyRedirectURI),
 		Request:      c.Request,
 	}
-	if grantType == GrantTypeAuthCode {
+	switch grantType {
+	case GrantTypeAuthCode:
 		tokenResponse, err = a.oAuthServer.GenAccessToken(c, &oauth.AccessTokenReq{
 			BaseTokenReq: baseTokenReq,
 			Code:         c.PostForm(KeyCode),
+			CodeVerifier: c.PostForm(KeyCodeVerifier),
 		})
-	} else {
+	case GrantTypeRefreshToken:
 		tokenResponse, err = a.oAuthServer.RefreshToken(c, &oauth.RefreshTokenReq{
 			BaseTokenReq: baseTokenReq,
 			RefreshToken: c.PostForm(KeyRefreshToken),
 		})
+	case GrantTypeClientCredentials:
+		tokenResponse, err = a.oAuthServer.GenClientCredentialsToken(c, &oauth.ClientCredentialsTokenReq{
+			BaseTokenReq: baseTokenReq,
+			Scope:        c.PostForm(KeyScope),
+		})
+	case GrantTypeDeviceCode:
+		tokenResponse, err = a.oAuthServer.GenDeviceAccessToken(c, &oauth.DeviceTokenReq{
+			BaseTokenReq: baseTokenReq,
+			DeviceCode:   c.PostForm(KeyDeviceCode),
+		})
 	}
 	if err != nil {
 		causeErr := perror.Cause(err)
 		log.Warning(c, err.Error())
+		// devices polling the token recognize errors by the codes defined in rfc8628
+		if grantType == GrantTypeDeviceCode {
+			switch causeErr {
+			case herrors.ErrOAuthAuthorizationPending:
+				abortWithDeviceError(c, ErrorAuthorizationPending, err.Error())
+				return
+			case herrors.ErrOAuthAccessDenied:
+				abortWithDeviceError(c, ErrorAccessDenied, err.Error())
+				return
+			case herrors.ErrOAuthCodeExpired:
+				abortWithDeviceError(c, ErrorExpiredToken, err.Error())
+				return
+			}
+		}
 		switch causeErr {
 		case herrors.ErrOAuthSecretNotValid, herrors.ErrOAuthReqNotValid:
 			response.AbortWithUnauthorized(c, common.Unauthorized, err.Error())
@@ -314,3 +377,303 @@ func (a *API) HandleAccessTokenReq(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, tokenResponse)
 }
+
+func abortWithDeviceError(c *gin.Context, errorCode, desc string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+		"error":             errorCode,
+		"error_description": desc,
+	})
+}
+
+// HandleDeviceCodeReq handles the device authorization request, ref: rfc8628#section-3.1
+func (a *API) HandleDeviceCodeReq(c *gin.Context) {
+	clientID, ok := c.GetPostForm(KeyClientID)
+	if !ok {
+		response.AbortWithRequestError(c, common.InvalidRequestParam, "client_id not exist")
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	verificationURI := url.URL{Scheme: scheme, Host: c.Request.Host, Path: BasicPath + DevicePath}
+
+	resp, err := a.oAuthServer.GenDeviceCode(c, &oauth.DeviceAuthorizationReq{
+		ClientID:        clientID,
+		Scope:           c.PostForm(KeyScope),
+		VerificationURI: verificationURI.String(),
+		Request:         c.Request,
+	})
+	if err != nil {
+		if e, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			if e.Source == herrors.OAuthInDB {
+				response.AbortWithUnauthorized(c, common.Unauthorized, err.Error())
+				return
+			}
+		}
+		log.Error(c, err.Error())
+		response.AbortWithInternalError(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// HandleDeviceGetReq renders the page for the user to authorize the device with the user code
+func (a *API) HandleDeviceGetReq(c *gin.Context) {
+	currentUser, err := common.UserFromContext(c)
+	if err != nil {
+		response.AbortWithForbiddenError(c, common.Forbidden, err.Error())
+		return
+	}
+	userCode, ok := c.GetQuery(KeyUserCode)
+	if !ok {
+		c.Status(http.StatusOK)
+		if err := userCodeTemplate.Execute(c.Writer, nil); err != nil {
+			log.Errorf(c, "user code html template err, err = %s", err.Error())
+		}
+		return
+	}
+
+	authorization, err := a.oAuthServer.GetDeviceAuthorization(c, userCode)
+	if err != nil {
+		a.abortWithDeviceAuthorizationError(c, err)
+		return
+	}
+	appBasicInfo, err := a.oauthAppController.Get(c, authorization.ClientID)
+	if err != nil {
+		a.abortWithDeviceAuthorizationError(c, err)
+		return
+	}
+	a.renderAuthorizationPage(c, AuthorizationPageParams{
+		UserName:   currentUser.GetName(),
+		ClientName: appBasicInfo.AppName,
+		ClientID:   authorization.ClientID,
+		HomeURL:    appBasicInfo.HomeURL,
+		Scope:      authorization.Scope,
+		UserCode:   authorization.UserCode,
+	})
+}
+
+// HandleDeviceReq approves or denies the device authorization by the user
+func (a *API) HandleDeviceReq(c *gin.Context) {
+	if _, err := common.UserFromContext(c); err != nil {
+		response.AbortWithForbiddenError(c, common.Forbidden, err.Error())
+		return
+	}
+	userCode, ok := c.GetPostForm(KeyUserCode)
+	if !ok {
+		response.AbortWithRequestError(c, common.InvalidRequestBody, "user_code not exist")
+		return
+	}
+	approved := c.PostForm(KeyAuthorize) == Authorized
+	if err := a.oAuthServer.AuthorizeDevice(c, userCode, approved); err != nil {
+		a.abortWithDeviceAuthorizationError(c, err)
+		return
+	}
+	message := "The device has been authorized, you can return to your device now."
+	if !approved {
+		message = "The device has been denied."
+	}
+	c.String(http.StatusOK, message)
+}
+
+func (a *API) abortWithDeviceAuthorizationError(c *gin.Context, err error) {
+	switch perror.Cause(err) {
+	case herrors.ErrOAuthReqNotValid:
+		response.AbortWithRequestError(c, common.InvalidRequestParam, err.Error())
+		return
+	case herrors.ErrOAuthCodeExpired:
+		response.AbortWithUnauthorized(c, common.CodeExpired, err.Error())
+		return
+	}
+	if e, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+		if e.Source == herrors.OAuthInDB || e.Source == herrors.TokenInDB {
+			response.AbortWithNotExistError(c, err.Error())
+			return
+		}
+	}
+	log.Error(c, err.Error())
+	response.AbortWithInternalError(c, err.Error())
+}
+
+// Discovery is the OpenID Provider Metadata, ref: OpenID Connect Discovery 1.0
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+
+	IntrospectionEndpoint                     string   `json:"introspection_endpoint"`
+	IntrospectionEndpointAuthMethodsSupported []string `json:"introspection_endpoint_auth_methods_supported"`
+	RevocationEndpoint                        string   `json:"revocation_endpoint"`
+	RevocationEndpointAuthMethodsSupported    []string `json:"revocation_endpoint_auth_methods_supported"`
+}
+
+func (a *API) HandleDiscoveryReq(c *gin.Context) {
+	if !a.oidcService.Enabled() {
+		response.AbortWithNotExistError(c, "OpenID Connect provider is not enabled")
+		return
+	}
+	issuer := a.oidcService.Issuer()
+	c.JSON(http.StatusOK, &Discovery{
+		Issuer:                      issuer,
+		AuthorizationEndpoint:       issuer + BasicPath + AuthorizePath,
+		TokenEndpoint:               issuer + BasicPath + AccessTokenPath,
+		UserinfoEndpoint:            issuer + BasicPath + UserInfoPath,
+		JWKSURI:                     issuer + JWKSPath,
+		DeviceAuthorizationEndpoint: issuer + BasicPath + DeviceCodePath,
+		ScopesSupported:             append(append([]string{}, oidc.Scopes...), a.scopeService.GetAllScopeNames()...),
+		ClaimsSupported:             oidc.Claims,
+		ResponseTypesSupported:      []string{"code"},
+		GrantTypesSupported: []string{GrantTypeAuthCode, GrantTypeRefreshToken,
+			GrantTypeClientCredentials, GrantTypeDeviceCode},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  a.oidcService.Algorithms(),
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{manager.CodeChallengeMethodPlain, manager.CodeChallengeMethodS256},
+
+		IntrospectionEndpoint:                     issuer + BasicPath + IntrospectPath,
+		IntrospectionEndpointAuthMethodsSupported: clientAuthMethods,
+		RevocationEndpoint:                        issuer + BasicPath + RevokePath,
+		RevocationEndpointAuthMethodsSupported:    clientAuthMethods,
+	})
+}
+
+func (a *API) HandleJWKSReq(c *gin.Context) {
+	if !a.oidcService.Enabled() {
+		response.AbortWithNotExistError(c, "OpenID Connect provider is not enabled")
+		return
+	}
+	c.JSON(http.StatusOK, a.oidcService.JWKS())
+}
+
+// HandleUserInfoReq returns the claims of the user authorized the bearer access token, ref: rfc6750#section-3
+func (a *API) HandleUserInfoReq(c *gin.Context) {
+	token, err := common.GetToken(c)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_request"`)
+		response.AbortWithUnauthorized(c, common.Unauthorized, err.Error())
+		return
+	}
+	userInfo, err := a.oAuthServer.GetUserInfo(c, token)
+	if err != nil {
+		switch perror.Cause(err) {
+		case herrors.ErrTokenInvalid:
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			response.AbortWithUnauthorized(c, common.Unauthorized, err.Error())
+			return
+		case herrors.ErrOAuthAccessTokenExpired:
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			response.AbortWithUnauthorized(c, common.CodeExpired, err.Error())
+			return
+		case herrors.ErrForbidden:
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			response.AbortWithForbiddenError(c, common.Forbidden, err.Error())
+			return
+		}
+		if e, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok && e.Source == herrors.TokenInDB {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			response.AbortWithUnauthorized(c, common.Unauthorized, err.Error())
+			return
+		}
+		log.Error(c, err.Error())
+		response.AbortWithInternalError(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, userInfo)
+}
+
+var clientAuthMethods = []string{"client_secret_basic", "client_secret_post"}
+
+// getClientTokenReq gets the token and the client credentials from the basic authorization header,
+// or from the post form, ref: rfc6749#section-2.3.1
+func getClientTokenReq(c *gin.Context) (*oauth.ClientTokenReq, bool) {
+	token, ok := c.GetPostForm(KeyToken)
+	if !ok || token == "" {
+		response.AbortWithRequestError(c, common.InvalidRequestParam, "token not exist")
+		return nil, false
+	}
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if ok {
+		// the credentials are encoded by application/x-www-form-urlencoded before basic auth
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = c.PostForm(KeyClientID), c.PostForm(KeyClientSecret)
+	}
+	if clientID == "" || clientSecret == "" {
+		c.Header("WWW-Authenticate", `Basic realm="horizon"`)
+		response.AbortWithUnauthorized(c, common.Unauthorized, "client credentials not exist")
+		return nil, false
+	}
+	return &oauth.ClientTokenReq{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Token:        token,
+	}, true
+}
+
+func abortWithClientTokenError(c *gin.Context, err error) {
+	switch perror.Cause(err) {
+	case herrors.ErrOAuthSecretNotValid, herrors.ErrOAuthReqNotValid:
+		log.Warning(c, err.Error())
+		response.AbortWithUnauthorized(c, common.Unauthorized, err.Error())
+		return
+	}
+	log.Error(c, err.Error())
+	response.AbortWithInternalError(c, err.Error())
+}
+
+// HandleIntrospectionReq returns the state of the token for resource servers, ref: rfc7662#section-2
+func (a *API) HandleIntrospectionReq(c *gin.Context) {
+	req, ok := getClientTokenReq(c)
+	if !ok {
+		return
+	}
+	resp, err := a.oAuthServer.IntrospectToken(c, req)
+	if err != nil {
+		abortWithClientTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// HandleRevocationReq revokes the token issued to the client, ref: rfc7009#section-2
+func (a *API) HandleRevocationReq(c *gin.Context) {
+	req, ok := getClientTokenReq(c)
+	if !ok {
+		return
+	}
+	if err := a.oAuthServer.RevokeToken(c, req); err != nil {
+		abortWithClientTokenError(c, err)
+		return
+	}
+	response.Success(c)
+}
+
+var userCodeTemplate = template.Must(template.New("usercode").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Horizon Device Authorization</title></head>
+<body style="background-color: #f6f8fa; text-align: center; padding-top: 100px">
+<h2>Enter the code displayed on your device</h2>
+<form action="" method="GET">
+    <input type="text" name="user_code" placeholder="XXXX-XXXX" autocomplete="off" autofocus>
+    <button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
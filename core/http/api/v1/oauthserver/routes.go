@@ -25,6 +25,14 @@ const (
 	BasicPath       = "/login/oauth"
 	AuthorizePath   = "/authorize"
 	AccessTokenPath = "/access_token"
+	DevicePath      = "/device"
+	DeviceCodePath  = "/device/code"
+	UserInfoPath    = "/userinfo"
+	IntrospectPath  = "/introspect"
+	RevokePath      = "/revoke"
+
+	DiscoveryPath = "/.well-known/openid-configuration"
+	JWKSPath      = "/.well-known/jwks.json"
 )
 
 func (a *API) RegisterRoute(engine *gin.Engine) {
@@ -43,7 +51,47 @@ func (a *API) RegisterRoute(engine *gin.Engine) {
 			Pattern:     AccessTokenPath,
 			Method:      http.MethodPost,
 			HandlerFunc: a.HandleAccessTokenReq,
+		}, {
+			Pattern:     DeviceCodePath,
+			Method:      http.MethodPost,
+			HandlerFunc: a.HandleDeviceCodeReq,
+		}, {
+			Pattern:     DevicePath,
+			Method:      http.MethodGet,
+			HandlerFunc: a.HandleDeviceGetReq,
+		}, {
+			Pattern:     DevicePath,
+			Method:      http.MethodPost,
+			HandlerFunc: a.HandleDeviceReq,
+		}, {
+			Pattern:     UserInfoPath,
+			Method:      http.MethodGet,
+			HandlerFunc: a.HandleUserInfoReq,
+		}, {
+			Pattern:     UserInfoPath,
+			Method:      http.MethodPost,
+			HandlerFunc: a.HandleUserInfoReq,
+		}, {
+			Pattern:     IntrospectPath,
+			Method:      http.MethodPost,
+			HandlerFunc: a.HandleIntrospectionReq,
+		}, {
+			Pattern:     RevokePath,
+			Method:      http.MethodPost,
+			HandlerFunc: a.HandleRevocationReq,
 		},
 	}
 	route.RegisterRoutes(apiGroup, routes)
+
+	route.RegisterRoutes(engine.Group(""), route.Routes{
+		{
+			Pattern:     DiscoveryPath,
+			Method:      http.MethodGet,
+			HandlerFunc: a.HandleDiscoveryReq,
+		}, {
+			Pattern:     JWKSPath,
+			Method:      http.MethodGet,
+			HandlerFunc: a.HandleJWKSReq,
+		},
+	})
 }
@@ -0,0 +1,159 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admissionpolicy
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/admissionpolicy"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	policyCtl admissionpolicy.Controller
+}
+
+func NewAPI(ctl admissionpolicy.Controller) *API {
+	return &API{
+		policyCtl: ctl,
+	}
+}
+
+func (a *API) Create(c *gin.Context) {
+	const op = "admission policy: create"
+	groupIDStr := c.Param(common.ParamGroupID)
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid group id: %s", groupIDStr))
+		return
+	}
+
+	var request admissionpolicy.CreateOrUpdatePolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return
+	}
+
+	resp, err := a.policyCtl.CreatePolicy(c, uint(groupID), &request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) List(c *gin.Context) {
+	const op = "admission policy: list"
+	groupIDStr := c.Param(common.ParamGroupID)
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid group id: %s", groupIDStr))
+		return
+	}
+
+	resp, err := a.policyCtl.ListPolicies(c, uint(groupID))
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "admission policy: get"
+	id, ok := policyID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.policyCtl.GetPolicy(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Update(c *gin.Context) {
+	const op = "admission policy: update"
+	id, ok := policyID(c)
+	if !ok {
+		return
+	}
+
+	var request admissionpolicy.CreateOrUpdatePolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return
+	}
+
+	resp, err := a.policyCtl.UpdatePolicy(c, id, &request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Delete(c *gin.Context) {
+	const op = "admission policy: delete"
+	id, ok := policyID(c)
+	if !ok {
+		return
+	}
+
+	if err := a.policyCtl.DeletePolicy(c, id); err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.Success(c)
+}
+
+func policyID(c *gin.Context) (uint, bool) {
+	idStr := c.Param(_policyIDParam)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid id: %s", idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
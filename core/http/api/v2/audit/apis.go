@@ -0,0 +1,129 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/controller/audit"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/audit/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/request"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	auditCtl audit.Controller
+}
+
+func NewAPI(ctl audit.Controller) *API {
+	return &API{
+		auditCtl: ctl,
+	}
+}
+
+func (a *API) List(c *gin.Context) {
+	const op = "audit: list"
+	query, err := parseQuery(c)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	}
+	pageNumber, pageSize, err := request.GetPageParam(c)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	}
+
+	logs, total, err := a.auditCtl.List(c, query, &q.Query{PageNumber: pageNumber, PageSize: pageSize})
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, response.DataWithTotal{
+		Total: total,
+		Items: logs,
+	})
+}
+
+// Export streams the audit logs as json lines, which can be shipped to SIEM
+func (a *API) Export(c *gin.Context) {
+	const op = "audit: export"
+	query, err := parseQuery(c)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename=auditlogs.jsonl")
+	if err := a.auditCtl.Export(c, query, c.Writer); err != nil {
+		if c.Writer.Written() {
+			// the response is partially written, so the client can only tell the failure by the broken stream
+			log.WithFiled(c, "op", op).Errorf("%+v", err)
+			c.Abort()
+			return
+		}
+		abortWithError(c, op, err)
+	}
+}
+
+func (a *API) Verify(c *gin.Context) {
+	const op = "audit: verify"
+	verification, err := a.auditCtl.Verify(c)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, verification)
+}
+
+func parseQuery(c *gin.Context) (*models.Query, error) {
+	query := &models.Query{
+		ActorName:    c.Query(_actorQuery),
+		Resource:     c.Query(_resourceQuery),
+		ResourceName: c.Query(_resourceNameQuery),
+	}
+	for key, t := range map[string]*time.Time{
+		_startTimeQuery: &query.StartTime,
+		_endTimeQuery:   &query.EndTime,
+	} {
+		if value := c.Query(key); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", key, value)
+			}
+			*t = parsed
+		}
+	}
+	return query, nil
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	if errors.Is(perror.Cause(err), herrors.ErrForbidden) {
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
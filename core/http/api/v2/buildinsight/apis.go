@@ -0,0 +1,108 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinsight
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/buildinsight"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+const (
+	// _defaultRange is the range of insights if the start time is not specified
+	_defaultRange = 30 * 24 * time.Hour
+	_maxRange     = 366 * 24 * time.Hour
+	_minStep      = time.Hour
+)
+
+type API struct {
+	buildInsightCtl buildinsight.Controller
+}
+
+func NewAPI(ctl buildinsight.Controller) *API {
+	return &API{
+		buildInsightCtl: ctl,
+	}
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "build insight: get"
+	idStr := c.Param(common.ParamApplicationID)
+	applicationID, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid %s: %s", common.ParamApplicationID, idStr))
+		return
+	}
+	query, err := parseQuery(c)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	}
+
+	insights, err := a.buildInsightCtl.Get(c, uint(applicationID), query)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+		log.WithFiled(c, "op", op).Errorf("%+v", err)
+		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+		return
+	}
+	response.SuccessWithData(c, insights)
+}
+
+func parseQuery(c *gin.Context) (*buildinsight.Query, error) {
+	query := &buildinsight.Query{
+		End: time.Now(),
+	}
+	if value := c.Query(_endTimeQuery); value != "" {
+		end, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", _endTimeQuery, value)
+		}
+		query.End = end
+	}
+	query.Start = query.End.Add(-_defaultRange)
+	if value := c.Query(_startTimeQuery); value != "" {
+		start, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", _startTimeQuery, value)
+		}
+		query.Start = start
+	}
+	if !query.Start.Before(query.End) || query.End.Sub(query.Start) > _maxRange {
+		return nil, fmt.Errorf("%s must be before %s and within %v", _startTimeQuery, _endTimeQuery, _maxRange)
+	}
+	if value := c.Query(_stepQuery); value != "" {
+		step, err := time.ParseDuration(value)
+		if err != nil || step < _minStep {
+			return nil, fmt.Errorf("invalid %s: %s, which should be at least %v", _stepQuery, value, _minStep)
+		}
+		query.Step = step
+	}
+	return query, nil
+}
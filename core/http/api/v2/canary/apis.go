@@ -0,0 +1,120 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/canary"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	canaryCtl canary.Controller
+}
+
+func NewAPI(ctl canary.Controller) *API {
+	return &API{
+		canaryCtl: ctl,
+	}
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "canary: get analysis"
+	clusterID, ok := parseID(c, common.ParamClusterID)
+	if !ok {
+		return
+	}
+
+	resp, err := a.canaryCtl.GetCanaryAnalysis(c, clusterID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Update(c *gin.Context) {
+	const op = "canary: update analysis"
+	clusterID, ok := parseID(c, common.ParamClusterID)
+	if !ok {
+		return
+	}
+
+	var request canary.UpdateCanaryAnalysisRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return
+	}
+
+	resp, err := a.canaryCtl.UpdateCanaryAnalysis(c, clusterID, &request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) ListRuns(c *gin.Context) {
+	const op = "canary: list analysis runs"
+	pipelinerunID, ok := parseID(c, _pipelinerunIDParam)
+	if !ok {
+		return
+	}
+
+	resp, err := a.canaryCtl.ListCanaryAnalysisRuns(c, pipelinerunID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	idStr := c.Param(param)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid %s: %s", param, idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrForbidden, herrors.ErrNoPrivilege:
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
@@ -0,0 +1,226 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/changerequest"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	changeRequestCtl changerequest.Controller
+}
+
+func NewAPI(ctl changerequest.Controller) *API {
+	return &API{
+		changeRequestCtl: ctl,
+	}
+}
+
+func (a *API) Create(c *gin.Context) {
+	const op = "change request: create"
+	clusterID, ok := clusterID(c)
+	if !ok {
+		return
+	}
+
+	var request changerequest.CreateChangeRequestRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return
+	}
+
+	resp, err := a.changeRequestCtl.CreateChangeRequest(c, clusterID, &request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) List(c *gin.Context) {
+	const op = "change request: list"
+	clusterID, ok := clusterID(c)
+	if !ok {
+		return
+	}
+
+	keywords := q.KeyWords{}
+	if status := c.Query(common.ChangeRequestQueryByStatus); status != "" {
+		keywords[common.ChangeRequestQueryByStatus] = status
+	}
+
+	query := q.New(keywords).WithPagination(c)
+	items, total, err := a.changeRequestCtl.ListChangeRequests(c, clusterID, query)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, response.DataWithTotal{
+		Items: items,
+		Total: total,
+	})
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "change request: get"
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.changeRequestCtl.GetChangeRequest(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Approve(c *gin.Context) {
+	const op = "change request: approve"
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	request, ok := reviewRequest(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.changeRequestCtl.ApproveChangeRequest(c, id, request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Reject(c *gin.Context) {
+	const op = "change request: reject"
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	request, ok := reviewRequest(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.changeRequestCtl.RejectChangeRequest(c, id, request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Merge(c *gin.Context) {
+	const op = "change request: merge"
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.changeRequestCtl.MergeChangeRequest(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Close(c *gin.Context) {
+	const op = "change request: close"
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.changeRequestCtl.CloseChangeRequest(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+// reviewRequest binds the optional comment of review
+func reviewRequest(c *gin.Context) (*changerequest.ReviewChangeRequestRequest, bool) {
+	var request changerequest.ReviewChangeRequestRequest
+	if c.Request.ContentLength == 0 {
+		return &request, true
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return nil, false
+	}
+	return &request, true
+}
+
+func clusterID(c *gin.Context) (uint, bool) {
+	idStr := c.Param(common.ParamClusterID)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid cluster id: %s", idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func changeRequestID(c *gin.Context) (uint, bool) {
+	idStr := c.Param(_changeRequestIDParam)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid id: %s", idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrForbidden, herrors.ErrNoPrivilege:
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrGitMergeConflict:
+		response.AbortWithRPCError(c, rpcerror.ConflictError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
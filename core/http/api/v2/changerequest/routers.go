@@ -0,0 +1,71 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/pkg/server/route"
+)
+
+const (
+	_changeRequestIDParam = "changeRequestID"
+)
+
+func (a *API) RegisterRoute(engine *gin.Engine) {
+	group := engine.Group("/apis/core/v2")
+	var routers = route.Routes{
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/clusters/:%v/changerequests", common.ParamClusterID),
+			HandlerFunc: a.Create,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/clusters/:%v/changerequests", common.ParamClusterID),
+			HandlerFunc: a.List,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/changerequests/:%v", _changeRequestIDParam),
+			HandlerFunc: a.Get,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/changerequests/:%v/approve", _changeRequestIDParam),
+			HandlerFunc: a.Approve,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/changerequests/:%v/reject", _changeRequestIDParam),
+			HandlerFunc: a.Reject,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/changerequests/:%v/merge", _changeRequestIDParam),
+			HandlerFunc: a.Merge,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/changerequests/:%v/close", _changeRequestIDParam),
+			HandlerFunc: a.Close,
+		},
+	}
+	route.RegisterRoutes(group, routers)
+}
@@ -297,6 +297,10 @@ func (a *API) Update(c *gin.Context) {
 			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
 			return
 		}
+		if perror.Cause(err) == herrors.ErrChangeRequestRequired {
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
 
 		if perror.Cause(err) == herrors.ErrParamInvalid {
 			log.WithFiled(c, "op", op).Warningf("err = %+v, request = %+v", err, request)
@@ -333,6 +337,48 @@ func (a *API) Get(c *gin.Context) {
 	response.SuccessWithData(c, resp)
 }
 
+func (a *API) Clone(c *gin.Context) {
+	op := "cluster: clone"
+	clusterIDStr := c.Param(common.ParamClusterID)
+	clusterID, err := strconv.ParseUint(clusterIDStr, 10, 0)
+	if err != nil {
+		response.AbortWithRequestError(c, common.InvalidRequestParam, err.Error())
+		return
+	}
+
+	var request *cluster.CloneClusterRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRequestError(c, common.InvalidRequestBody,
+			fmt.Sprintf("request body is invalid, err: %v", err))
+		return
+	}
+
+	resp, err := a.clusterCtl.CloneCluster(c, uint(clusterID), request)
+	if err != nil {
+		if e, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok && e.Source == herrors.ClusterInDB {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+		if perror.Cause(err) == herrors.ErrParamInvalid {
+			log.WithFiled(c, "op", op).Warningf("err = %+v, request = %+v", err, request)
+			response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+			return
+		} else if perror.Cause(err) == herrors.ErrNameConflict {
+			log.WithFiled(c, "op", op).Warningf("err = %+v, request = %+v", err, request)
+			response.AbortWithRPCError(c, rpcerror.ConflictError.WithErrMsg(err.Error()))
+			return
+		} else if perror.Cause(err) == herrors.ErrForbidden {
+			log.WithFiled(c, "op", op).Warningf("err = %+v, request = %+v", err, request)
+			response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+			return
+		}
+		log.WithFiled(c, "op", op).Errorf("%+v", err)
+		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
 func (a *API) Delete(c *gin.Context) {
 	op := "cluster: delete"
 	clusterIDStr := c.Param(common.ParamClusterID)
@@ -437,6 +483,39 @@ func (a *API) GetContainers(c *gin.Context) {
 	response.SuccessWithData(c, outPut)
 }
 
+func (a *API) ListJobRuns(c *gin.Context) {
+	const op = "cluster: list job runs"
+	clusterIDStr := c.Param(common.ParamClusterID)
+	clusterID, err := strconv.ParseUint(clusterIDStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	}
+
+	limit := 10
+	if limitStr := c.Query(common.ClusterQueryLimit); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsgf("invalid limit: %s", limitStr))
+			return
+		}
+	}
+
+	runs, err := a.clusterCtl.ListJobRuns(c, uint(clusterID), limit)
+	if err != nil {
+		if e, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			if e.Source == herrors.ClusterInDB || e.Source == herrors.ApplicationInDB {
+				response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+				return
+			}
+		}
+		log.WithFiled(c, "op", op).Errorf("%+v", err)
+		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+		return
+	}
+	response.SuccessWithData(c, runs)
+}
+
 func (a *API) GetClusterPod(c *gin.Context) {
 	op := "cluster: get cluster pod"
 	clusterIDStr := c.Param(common.ParamClusterID)
@@ -51,6 +51,10 @@ func (api *API) RegisterRoute(engine *gin.Engine) {
 			Method:      http.MethodDelete,
 			Pattern:     fmt.Sprintf("/clusters/:%v", common.ParamClusterID),
 			HandlerFunc: api.Delete,
+		}, {
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/clusters/:%v/clone", common.ParamClusterID),
+			HandlerFunc: api.Clone,
 		}, {
 			Method:      http.MethodPost,
 			Pattern:     fmt.Sprintf("/clusters/:%v/builddeploy", common.ParamClusterID),
@@ -127,6 +131,10 @@ func (api *API) RegisterRoute(engine *gin.Engine) {
 			Method:      http.MethodGet,
 			Pattern:     fmt.Sprintf("/clusters/:%v/containers", common.ParamClusterID),
 			HandlerFunc: api.GetContainers,
+		}, {
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/clusters/:%v/jobruns", common.ParamClusterID),
+			HandlerFunc: api.ListJobRuns,
 		}, {
 			Method:      http.MethodPost,
 			Pattern:     fmt.Sprintf("/clusters/:%v/favorite", common.ParamClusterID),
@@ -0,0 +1,113 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/drift"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	driftCtl drift.Controller
+}
+
+func NewAPI(ctl drift.Controller) *API {
+	return &API{
+		driftCtl: ctl,
+	}
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "drift: get"
+	clusterID, ok := clusterID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.driftCtl.GetClusterDrift(c, clusterID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Detect(c *gin.Context) {
+	const op = "drift: detect"
+	clusterID, ok := clusterID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.driftCtl.DetectClusterDrift(c, clusterID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Revert(c *gin.Context) {
+	const op = "drift: revert"
+	clusterID, ok := clusterID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.driftCtl.RevertClusterDrift(c, clusterID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func clusterID(c *gin.Context) (uint, bool) {
+	idStr := c.Param(common.ParamClusterID)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid cluster id: %s", idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrForbidden, herrors.ErrNoPrivilege:
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
@@ -0,0 +1,177 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/elevation"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	elevationCtl elevation.Controller
+}
+
+func NewAPI(ctl elevation.Controller) *API {
+	return &API{
+		elevationCtl: ctl,
+	}
+}
+
+func (a *API) Create(c *gin.Context) {
+	const op = "elevation: create"
+	resourceType := c.Param(common.ParamResourceType)
+	resourceIDStr := c.Param(common.ParamResourceID)
+	resourceID, err := strconv.ParseUint(resourceIDStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid resource id: %s", resourceIDStr))
+		return
+	}
+
+	var request elevation.CreateElevationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return
+	}
+
+	resp, err := a.elevationCtl.CreateElevation(c, resourceType, uint(resourceID), &request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) List(c *gin.Context) {
+	const op = "elevation: list"
+	resourceType := c.Param(common.ParamResourceType)
+	resourceIDStr := c.Param(common.ParamResourceID)
+	resourceID, err := strconv.ParseUint(resourceIDStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid resource id: %s", resourceIDStr))
+		return
+	}
+
+	keywords := q.KeyWords{}
+	if status := c.Query(common.ElevationQueryByStatus); status != "" {
+		keywords[common.ElevationQueryByStatus] = status
+	}
+	if userIDStr := c.Query(common.ElevationQueryByUser); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 0)
+		if err != nil {
+			response.AbortWithRPCError(c, rpcerror.ParamError.
+				WithErrMsgf("invalid user id: %s", userIDStr))
+			return
+		}
+		keywords[common.ElevationQueryByUser] = uint(userID)
+	}
+
+	query := q.New(keywords).WithPagination(c)
+	items, total, err := a.elevationCtl.ListElevations(c, resourceType, uint(resourceID), query)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, response.DataWithTotal{
+		Items: items,
+		Total: total,
+	})
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "elevation: get"
+	id, ok := elevationID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.elevationCtl.GetElevation(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Approve(c *gin.Context) {
+	const op = "elevation: approve"
+	id, ok := elevationID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.elevationCtl.ApproveElevation(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Reject(c *gin.Context) {
+	const op = "elevation: reject"
+	id, ok := elevationID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.elevationCtl.RejectElevation(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func elevationID(c *gin.Context) (uint, bool) {
+	idStr := c.Param(_elevationIDParam)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid id: %s", idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrForbidden, herrors.ErrNoPrivilege:
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
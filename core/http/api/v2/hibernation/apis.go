@@ -0,0 +1,189 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hibernation
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/hibernation"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/hibernation/models"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	hibernationCtl hibernation.Controller
+}
+
+func NewAPI(ctl hibernation.Controller) *API {
+	return &API{
+		hibernationCtl: ctl,
+	}
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "hibernation: get"
+	clusterID, ok := parseID(c, common.ParamClusterID)
+	if !ok {
+		return
+	}
+
+	resp, err := a.hibernationCtl.GetClusterHibernation(c, clusterID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Hibernate(c *gin.Context) {
+	const op = "hibernation: hibernate"
+	clusterID, ok := parseID(c, common.ParamClusterID)
+	if !ok {
+		return
+	}
+
+	resp, err := a.hibernationCtl.HibernateCluster(c, clusterID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Wake(c *gin.Context) {
+	const op = "hibernation: wake"
+	clusterID, ok := parseID(c, common.ParamClusterID)
+	if !ok {
+		return
+	}
+
+	resp, err := a.hibernationCtl.WakeCluster(c, clusterID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) GetClusterSchedule(c *gin.Context) {
+	a.getSchedule(c, models.ResourceTypeCluster, common.ParamClusterID)
+}
+
+func (a *API) UpdateClusterSchedule(c *gin.Context) {
+	a.updateSchedule(c, models.ResourceTypeCluster, common.ParamClusterID)
+}
+
+func (a *API) DeleteClusterSchedule(c *gin.Context) {
+	a.deleteSchedule(c, models.ResourceTypeCluster, common.ParamClusterID)
+}
+
+func (a *API) GetEnvironmentSchedule(c *gin.Context) {
+	a.getSchedule(c, models.ResourceTypeEnvironment, _environmentParam)
+}
+
+func (a *API) UpdateEnvironmentSchedule(c *gin.Context) {
+	a.updateSchedule(c, models.ResourceTypeEnvironment, _environmentParam)
+}
+
+func (a *API) DeleteEnvironmentSchedule(c *gin.Context) {
+	a.deleteSchedule(c, models.ResourceTypeEnvironment, _environmentParam)
+}
+
+func (a *API) getSchedule(c *gin.Context, resourceType, param string) {
+	const op = "hibernation: get schedule"
+	resourceID, ok := parseID(c, param)
+	if !ok {
+		return
+	}
+
+	resp, err := a.hibernationCtl.GetSchedule(c, resourceType, resourceID)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) updateSchedule(c *gin.Context, resourceType, param string) {
+	const op = "hibernation: update schedule"
+	resourceID, ok := parseID(c, param)
+	if !ok {
+		return
+	}
+
+	var request hibernation.UpdateScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return
+	}
+
+	resp, err := a.hibernationCtl.UpdateSchedule(c, resourceType, resourceID, &request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) deleteSchedule(c *gin.Context, resourceType, param string) {
+	const op = "hibernation: delete schedule"
+	resourceID, ok := parseID(c, param)
+	if !ok {
+		return
+	}
+
+	if err := a.hibernationCtl.DeleteSchedule(c, resourceType, resourceID); err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.Success(c)
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	idStr := c.Param(param)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid %s: %s", param, idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrForbidden, herrors.ErrNoPrivilege:
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
@@ -0,0 +1,82 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hibernation
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/pkg/server/route"
+)
+
+const (
+	// _environmentParam is the same as the one of environment api, for they share the route tree
+	_environmentParam = "environment"
+)
+
+func (a *API) RegisterRoute(engine *gin.Engine) {
+	group := engine.Group("/apis/core/v2")
+	var routers = route.Routes{
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/clusters/:%v/hibernation", common.ParamClusterID),
+			HandlerFunc: a.Get,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/clusters/:%v/hibernate", common.ParamClusterID),
+			HandlerFunc: a.Hibernate,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/clusters/:%v/wake", common.ParamClusterID),
+			HandlerFunc: a.Wake,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/clusters/:%v/hibernationschedule", common.ParamClusterID),
+			HandlerFunc: a.GetClusterSchedule,
+		},
+		{
+			Method:      http.MethodPut,
+			Pattern:     fmt.Sprintf("/clusters/:%v/hibernationschedule", common.ParamClusterID),
+			HandlerFunc: a.UpdateClusterSchedule,
+		},
+		{
+			Method:      http.MethodDelete,
+			Pattern:     fmt.Sprintf("/clusters/:%v/hibernationschedule", common.ParamClusterID),
+			HandlerFunc: a.DeleteClusterSchedule,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/environments/:%v/hibernationschedule", _environmentParam),
+			HandlerFunc: a.GetEnvironmentSchedule,
+		},
+		{
+			Method:      http.MethodPut,
+			Pattern:     fmt.Sprintf("/environments/:%v/hibernationschedule", _environmentParam),
+			HandlerFunc: a.UpdateEnvironmentSchedule,
+		},
+		{
+			Method:      http.MethodDelete,
+			Pattern:     fmt.Sprintf("/environments/:%v/hibernationschedule", _environmentParam),
+			HandlerFunc: a.DeleteEnvironmentSchedule,
+		},
+	}
+	route.RegisterRoutes(group, routers)
+}
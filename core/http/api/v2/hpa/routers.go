@@ -0,0 +1,53 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hpa
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/pkg/server/route"
+)
+
+const (
+	_overrideIDParam = "overrideID"
+	_activeQuery     = "active"
+)
+
+func (a *API) RegisterRoute(engine *gin.Engine) {
+	group := engine.Group("/apis/core/v2")
+	var routers = route.Routes{
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/clusters/:%v/hpaoverrides", common.ParamClusterID),
+			HandlerFunc: a.ListOverrides,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/clusters/:%v/hpaoverrides", common.ParamClusterID),
+			HandlerFunc: a.CreateOverride,
+		},
+		{
+			Method: http.MethodDelete,
+			Pattern: fmt.Sprintf("/clusters/:%v/hpaoverrides/:%v",
+				common.ParamClusterID, _overrideIDParam),
+			HandlerFunc: a.RevertOverride,
+		},
+	}
+	route.RegisterRoutes(group, routers)
+}
@@ -125,6 +125,64 @@ func (a *API) LoginCallback(c *gin.Context) {
 	response.Success(c)
 }
 
+func (a *API) LoginWithLDAP(c *gin.Context) {
+	var request idp.LDAPLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.IDP == "" {
+		response.AbortWithRPCError(c,
+			rpcerror.ParamError.WithErrMsg("request body is invalid"))
+		return
+	}
+
+	user, err := a.idpCtrl.LoginWithLDAP(c, &request)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c,
+				rpcerror.NotFoundError.WithErrMsgf("idp named %s was not found", request.IDP))
+			return
+		}
+		switch perror.Cause(err) {
+		case herrors.ErrLDAPInvalidCredentials:
+			response.AbortWithRPCError(c,
+				rpcerror.Unauthorized.WithErrMsg("login failed: username or password is incorrect!"))
+			return
+		case herrors.ErrForbidden:
+			response.AbortWithRPCError(c,
+				rpcerror.ForbiddenError.WithErrMsgf(
+					"this account is banned to sign in"))
+			return
+		case herrors.ErrDuplicatedKey:
+			response.AbortWithRPCError(c,
+				rpcerror.ConflictError.WithErrMsgf(
+					"idp already linked by another user"))
+			return
+		case herrors.ErrParamInvalid:
+			response.AbortWithRPCError(c,
+				rpcerror.ParamError.WithErrMsg(err.Error()))
+			return
+		}
+		response.AbortWithRPCError(c,
+			rpcerror.InternalError.WithErrMsg(err.Error()))
+		return
+	}
+
+	session, err := util.GetSession(a.store, c.Request)
+	if err != nil {
+		response.AbortWithRPCError(c,
+			rpcerror.InternalError.WithErrMsg(err.Error()))
+		return
+	}
+
+	if err = util.SetSession(session, c.Request, c.Writer, user); err != nil {
+		response.AbortWithRPCError(c,
+			rpcerror.InternalError.WithErrMsgf(
+				"saving session into backend or response failed:\n"+
+					"err = %v", err))
+		return
+	}
+
+	response.Success(c)
+}
+
 func (a *API) Logout(c *gin.Context) {
 	session, err := util.GetSession(a.store, c.Request)
 	if err != nil {
@@ -19,6 +19,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/horizoncd/horizon/core/common"
 	"github.com/horizoncd/horizon/pkg/server/route"
 )
 
@@ -61,5 +62,6 @@ func (api *API) RegisterRoute(engine *gin.Engine) {
 	}
 	route.RegisterRoutes(apiGroup, routes)
 	engine.GET("/apis/core/v2/login/callback", api.LoginCallback)
+	engine.POST(common.URLLoginLDAP, api.LoginWithLDAP)
 	engine.POST("/apis/core/v2/logout", api.Logout)
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/horizoncd/horizon/pkg/server/response"
 	"github.com/horizoncd/horizon/pkg/server/rpcerror"
 	"github.com/horizoncd/horizon/pkg/util/errors"
+	"github.com/horizoncd/horizon/pkg/util/log"
 
 	"github.com/gin-gonic/gin"
 )
@@ -181,6 +182,10 @@ func (a *API) Execute(c *gin.Context) {
 				response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(e.Error()))
 				return
 			}
+			if perror.Cause(err) == herrors.ErrForbidden {
+				response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+				return
+			}
 			response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
 			return
 		}
@@ -218,6 +223,81 @@ func (a *API) Cancel(c *gin.Context) {
 	})
 }
 
+func (a *API) ListApprovals(c *gin.Context) {
+	const op = "pipelinerun: list approvals"
+	a.withPipelinerunID(c, func(prID uint) {
+		approvals, err := a.prCtl.ListApprovals(c, prID)
+		if err != nil {
+			abortWithReviewError(c, op, err)
+			return
+		}
+		response.SuccessWithData(c, approvals)
+	})
+}
+
+func (a *API) Approve(c *gin.Context) {
+	const op = "pipelinerun: approve"
+	req, ok := reviewRequest(c)
+	if !ok {
+		return
+	}
+	a.withPipelinerunID(c, func(prID uint) {
+		approvals, err := a.prCtl.Approve(c, prID, req)
+		if err != nil {
+			abortWithReviewError(c, op, err)
+			return
+		}
+		response.SuccessWithData(c, approvals)
+	})
+}
+
+func (a *API) Reject(c *gin.Context) {
+	const op = "pipelinerun: reject"
+	req, ok := reviewRequest(c)
+	if !ok {
+		return
+	}
+	a.withPipelinerunID(c, func(prID uint) {
+		approvals, err := a.prCtl.Reject(c, prID, req)
+		if err != nil {
+			abortWithReviewError(c, op, err)
+			return
+		}
+		response.SuccessWithData(c, approvals)
+	})
+}
+
+func reviewRequest(c *gin.Context) (*prctl.ReviewPipelinerunRequest, bool) {
+	var request prctl.ReviewPipelinerunRequest
+	if c.Request.ContentLength == 0 {
+		return &request, true
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return nil, false
+	}
+	return &request, true
+}
+
+func abortWithReviewError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrForbidden, herrors.ErrNoPrivilege:
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
+
 func (a *API) ListCheckRuns(c *gin.Context) {
 	a.withPipelinerunID(c, func(pipelinerunID uint) {
 		checkRuns, err := a.prCtl.ListCheckRuns(c, pipelinerunID)
@@ -94,6 +94,21 @@ func (api *API) RegisterRoute(engine *gin.Engine) {
 			Pattern:     fmt.Sprintf("/pipelineruns/:%v/messages", _pipelinerunIDParam),
 			HandlerFunc: api.CreatePrMessage,
 		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/pipelineruns/:%v/approvals", _pipelinerunIDParam),
+			HandlerFunc: api.ListApprovals,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/pipelineruns/:%v/approve", _pipelinerunIDParam),
+			HandlerFunc: api.Approve,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     fmt.Sprintf("/pipelineruns/:%v/reject", _pipelinerunIDParam),
+			HandlerFunc: api.Reject,
+		},
 	}
 
 	route.RegisterRoutes(apiGroup, routes)
@@ -0,0 +1,121 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package portforward
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/portforward"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+const (
+	_podNameParam = "podName"
+	_portParam    = "port"
+	_pathParam    = "path"
+)
+
+type API struct {
+	portForwardCtl portforward.Controller
+}
+
+func NewAPI(ctl portforward.Controller) *API {
+	return &API{
+		portForwardCtl: ctl,
+	}
+}
+
+// PortForward upgrades the request to a websocket connection bridged to the port of a pod
+func (a *API) PortForward(c *gin.Context) {
+	const op = "port forward: port forward"
+	clusterID, ok := parseID(c, common.ParamClusterID)
+	if !ok {
+		return
+	}
+	port, ok := parsePort(c, c.Query(_portParam))
+	if !ok {
+		return
+	}
+
+	handler, err := a.portForwardCtl.PortForward(c, clusterID, c.Query(_podNameParam), port)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	handler.ServeHTTP(c.Writer, c.Request)
+}
+
+// Proxy proxies the request to the port of a pod
+func (a *API) Proxy(c *gin.Context) {
+	const op = "port forward: proxy"
+	clusterID, ok := parseID(c, common.ParamClusterID)
+	if !ok {
+		return
+	}
+	port, ok := parsePort(c, c.Param(_portParam))
+	if !ok {
+		return
+	}
+
+	handler, err := a.portForwardCtl.GetPodProxy(c, clusterID, c.Param(_podNameParam), port, c.Param(_pathParam))
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	handler.ServeHTTP(c.Writer, c.Request)
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	idStr := c.Param(param)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid %s: %s", param, idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func parsePort(c *gin.Context, portStr string) (uint16, bool) {
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid %s: %s", _portParam, portStr))
+		return 0, false
+	}
+	return uint16(port), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
@@ -0,0 +1,156 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package releaseplan
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/controller/releaseplan"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/response"
+	"github.com/horizoncd/horizon/pkg/server/rpcerror"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+type API struct {
+	releasePlanCtl releaseplan.Controller
+}
+
+func NewAPI(ctl releaseplan.Controller) *API {
+	return &API{
+		releasePlanCtl: ctl,
+	}
+}
+
+func (a *API) Create(c *gin.Context) {
+	const op = "release plan: create"
+	applicationID, ok := applicationID(c)
+	if !ok {
+		return
+	}
+
+	var request releaseplan.CreateReleasePlanRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid request body, err: %s", err.Error()))
+		return
+	}
+
+	resp, err := a.releasePlanCtl.CreateReleasePlan(c, applicationID, &request)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) List(c *gin.Context) {
+	const op = "release plan: list"
+	applicationID, ok := applicationID(c)
+	if !ok {
+		return
+	}
+
+	keywords := q.KeyWords{}
+	if status := c.Query(common.ReleasePlanQueryByStatus); status != "" {
+		keywords[common.ReleasePlanQueryByStatus] = status
+	}
+
+	query := q.New(keywords).WithPagination(c)
+	items, total, err := a.releasePlanCtl.ListReleasePlans(c, applicationID, query)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, response.DataWithTotal{
+		Items: items,
+		Total: total,
+	})
+}
+
+func (a *API) Get(c *gin.Context) {
+	const op = "release plan: get"
+	id, ok := releasePlanID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.releasePlanCtl.GetReleasePlan(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func (a *API) Cancel(c *gin.Context) {
+	const op = "release plan: cancel"
+	id, ok := releasePlanID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := a.releasePlanCtl.CancelReleasePlan(c, id)
+	if err != nil {
+		abortWithError(c, op, err)
+		return
+	}
+	response.SuccessWithData(c, resp)
+}
+
+func applicationID(c *gin.Context) (uint, bool) {
+	idStr := c.Param(common.ParamApplicationID)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid application id: %s", idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func releasePlanID(c *gin.Context) (uint, bool) {
+	idStr := c.Param(_releasePlanIDParam)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.
+			WithErrMsgf("invalid id: %s", idStr))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func abortWithError(c *gin.Context, op string, err error) {
+	switch cause := perror.Cause(err); cause {
+	case herrors.ErrParamInvalid:
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	case herrors.ErrApprovalRequired:
+		response.AbortWithRPCError(c, rpcerror.ForbiddenError.WithErrMsg(err.Error()))
+		return
+	default:
+		if _, ok := cause.(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+	}
+	log.WithFiled(c, "op", op).Errorf("%+v", err)
+	response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+}
@@ -16,12 +16,15 @@ package terminal
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/horizoncd/horizon/core/controller/terminal"
 	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
 	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/server/request"
 	"github.com/horizoncd/horizon/pkg/server/response"
 	"github.com/horizoncd/horizon/pkg/server/rpcerror"
 	"github.com/horizoncd/horizon/pkg/util/log"
@@ -29,6 +32,7 @@ import (
 
 const (
 	_clusterIDParam     = "clusterID"
+	_sessionIDParam     = "sessionID"
 	_podNameQuery       = "podName"
 	_containerNameQuery = "containerName"
 )
@@ -78,3 +82,65 @@ func (a *API) CreateShell(c *gin.Context) {
 	c.Request.URL.Path = fmt.Sprintf("/apis/core/v2/0/%s/websocket", sessionID)
 	sockJS.ServeHTTP(c.Writer, c.Request)
 }
+
+func (a *API) ListSessions(c *gin.Context) {
+	const op = "terminal: list sessions"
+	clusterID, err := parseID(c, _clusterIDParam)
+	if err != nil {
+		return
+	}
+	pageNumber, pageSize, err := request.GetPageParam(c)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(err.Error()))
+		return
+	}
+
+	sessions, total, err := a.terminalCtl.ListSessions(c, clusterID, &q.Query{
+		PageNumber: pageNumber,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		log.WithFiled(c, "op", op).Errorf("%+v", err)
+		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+		return
+	}
+	response.SuccessWithData(c, response.DataWithTotal{
+		Total: total,
+		Items: sessions,
+	})
+}
+
+func (a *API) GetRecording(c *gin.Context) {
+	const op = "terminal: get recording"
+	clusterID, err := parseID(c, _clusterIDParam)
+	if err != nil {
+		return
+	}
+	sessionID, err := parseID(c, _sessionIDParam)
+	if err != nil {
+		return
+	}
+
+	recording, err := a.terminalCtl.GetRecording(c, clusterID, sessionID)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			response.AbortWithRPCError(c, rpcerror.NotFoundError.WithErrMsg(err.Error()))
+			return
+		}
+		log.WithFiled(c, "op", op).Errorf("%+v", err)
+		response.AbortWithRPCError(c, rpcerror.InternalError.WithErrMsg(err.Error()))
+		return
+	}
+	c.Data(http.StatusOK, "application/x-asciicast", recording)
+}
+
+func parseID(c *gin.Context, param string) (uint, error) {
+	idStr := c.Param(param)
+	id, err := strconv.ParseUint(idStr, 10, 0)
+	if err != nil {
+		response.AbortWithRPCError(c, rpcerror.ParamError.WithErrMsg(
+			fmt.Sprintf("invalid %s: %s, err: %s", param, idStr, err.Error())))
+		return 0, err
+	}
+	return uint(id), nil
+}
@@ -32,6 +32,16 @@ func (api *API) RegisterRoute(engine *gin.Engine) {
 			Pattern:     fmt.Sprintf("/clusters/:%v/shell", _clusterIDParam),
 			HandlerFunc: api.CreateShell,
 		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/clusters/:%v/terminalsessions", _clusterIDParam),
+			HandlerFunc: api.ListSessions,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     fmt.Sprintf("/clusters/:%v/terminalsessions/:%v/recording", _clusterIDParam, _sessionIDParam),
+			HandlerFunc: api.GetRecording,
+		},
 	}
 	route.RegisterRoutes(coreGroup, coreRoutes)
 }
@@ -27,11 +27,20 @@ import (
 	_ "github.com/horizoncd/horizon/pkg/git/github"
 	_ "github.com/horizoncd/horizon/pkg/git/gitlab"
 
+	// for gitops repo
+	_ "github.com/horizoncd/horizon/pkg/gitops/filesystem"
+	_ "github.com/horizoncd/horizon/pkg/gitops/gitea"
+	_ "github.com/horizoncd/horizon/pkg/gitops/github"
+	_ "github.com/horizoncd/horizon/pkg/gitops/gitlab"
+
 	// for template repo
 	_ "github.com/horizoncd/horizon/pkg/templaterepo/chartmuseumbase"
 
 	// for k8s workload
+	_ "github.com/horizoncd/horizon/pkg/workload/cronjob"
+	_ "github.com/horizoncd/horizon/pkg/workload/daemonset"
 	_ "github.com/horizoncd/horizon/pkg/workload/deployment"
+	_ "github.com/horizoncd/horizon/pkg/workload/job"
 	_ "github.com/horizoncd/horizon/pkg/workload/kservice"
 	_ "github.com/horizoncd/horizon/pkg/workload/pod"
 	_ "github.com/horizoncd/horizon/pkg/workload/rollout"
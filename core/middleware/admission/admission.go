@@ -5,22 +5,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/mattbaird/jsonpatch"
 
 	"github.com/horizoncd/horizon/core/common"
 	"github.com/horizoncd/horizon/core/middleware"
 	admissionwebhook "github.com/horizoncd/horizon/pkg/admission"
 	admissionmodels "github.com/horizoncd/horizon/pkg/admission/models"
 	"github.com/horizoncd/horizon/pkg/auth"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	eventservice "github.com/horizoncd/horizon/pkg/event/service"
 	"github.com/horizoncd/horizon/pkg/server/response"
 	"github.com/horizoncd/horizon/pkg/server/rpcerror"
 	"github.com/horizoncd/horizon/pkg/util/log"
 )
 
 // Middleware to validate and mutate admission request
-func Middleware(skippers ...middleware.Skipper) gin.HandlerFunc {
+func Middleware(eventSvc eventservice.Service, skippers ...middleware.Skipper) gin.HandlerFunc {
 	return middleware.New(func(c *gin.Context) {
 		// get auth record
 		record, ok := c.Get(common.ContextAuthRecord)
@@ -84,20 +88,36 @@ func Middleware(skippers ...middleware.Skipper) gin.HandlerFunc {
 			return
 		}
 		if err := admissionwebhook.Validating(c, admissionRequest); err != nil {
+			recordEvent(c, eventSvc, admissionRequest, eventmodels.AdmissionDenied, err.Error())
 			response.AbortWithRPCError(c,
 				rpcerror.ParamError.WithErrMsg(fmt.Sprintf("admission validating failed: %v", err)))
 			return
 		}
 		if admissionRequest.Object != nil {
-			bodyBytes, err = json.Marshal(admissionRequest.Object)
+			mutatedBytes, err := json.Marshal(admissionRequest.Object)
 			if err != nil {
 				response.AbortWithRPCError(c,
 					rpcerror.ParamError.WithErrMsg(fmt.Sprintf("marshal request body failed, err: %v", err)))
 				return
 			}
+			if patch, err := jsonpatch.CreatePatch(bodyBytes, mutatedBytes); err == nil && len(patch) > 0 {
+				patchBytes, _ := json.Marshal(patch)
+				recordEvent(c, eventSvc, admissionRequest, eventmodels.AdmissionMutated, string(patchBytes))
+			}
+			bodyBytes = mutatedBytes
 		}
 		// restore the request body
 		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
 		c.Next()
 	}, skippers...)
 }
+
+// recordEvent records the admission decision on the requested resource
+func recordEvent(c *gin.Context, eventSvc eventservice.Service,
+	request *admissionwebhook.Request, eventType, extra string) {
+	resourceID, err := strconv.ParseUint(request.Name, 10, 0)
+	if err != nil || resourceID == 0 {
+		return
+	}
+	eventSvc.CreateEventIgnoreError(c, request.Resource, uint(resourceID), eventType, &extra)
+}
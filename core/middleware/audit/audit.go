@@ -0,0 +1,282 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattbaird/jsonpatch"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/middleware"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/pkg/audit/exporter"
+	"github.com/horizoncd/horizon/pkg/audit/manager"
+	"github.com/horizoncd/horizon/pkg/audit/models"
+	"github.com/horizoncd/horizon/pkg/auth"
+	"github.com/horizoncd/horizon/pkg/config/audit"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+const (
+	_defaultMaxBodySize = 64 * 1024
+	_maskedValue        = "******"
+)
+
+// DefaultSensitiveReads are the reads of logs, shells and pods of clusters, which are audited as mutations
+var DefaultSensitiveReads = []string{
+	`^/apis/core/v[12]/clusters/[^/]+/(containerlog|exec|shell|terminal|portforward|proxy)(/|$)`,
+	`^/apis/core/v[12]/clusters/[^/]+/terminalsessions/[^/]+/recording`,
+	`^/apis/core/v[12]/pipelineruns/[^/]+/log`,
+	`^/apis/front/v1/terminal/`,
+}
+
+// DefaultMaskedFields are the fields of credentials in request bodies and queries,
+// including the parameters of oauth token, introspection and revocation requests
+var DefaultMaskedFields = []string{
+	"password", "bindPassword", "secret", "clientSecret", "token", "accessToken", "refreshToken",
+	"privateKey", "kubeconfig", "certificate", "code", "codeVerifier", "deviceCode", "assertion",
+}
+
+// fieldNameReplacer normalizes field names, so that client_secret matches clientSecret
+var fieldNameReplacer = strings.NewReplacer("_", "", "-", "")
+
+// Middleware records audit logs of mutating api calls and sensitive reads. It should be placed
+// after prehandle, so that resources are identified by ids, and before authorization, so that the
+// denied calls are audited too. The handler serves the gets of resources to snapshot them
+func Middleware(config audit.Config, auditMgr manager.Manager, exporters []exporter.Exporter,
+	handler http.Handler, skippers ...middleware.Skipper) gin.HandlerFunc {
+	if !config.Enabled {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	patterns := config.SensitiveReads
+	if len(patterns) == 0 {
+		patterns = DefaultSensitiveReads
+	}
+	sensitiveReads := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		sensitiveReads = append(sensitiveReads, regexp.MustCompile(pattern))
+	}
+	fields := config.MaskedFields
+	if len(fields) == 0 {
+		fields = DefaultMaskedFields
+	}
+	maskedFields := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		maskedFields[normalizeField(field)] = struct{}{}
+	}
+	maxBodySize := config.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = _defaultMaxBodySize
+	}
+
+	return middleware.New(func(c *gin.Context) {
+		readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead ||
+			c.Request.Method == http.MethodOptions
+		if readOnly && !matchAny(sensitiveReads, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		auditLog := &models.Log{
+			ClientIP:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Method:    c.Request.Method,
+			Path:      maskPath(c.Request.URL, maskedFields),
+		}
+		auditLog.RequestID, _ = requestid.FromContext(c)
+		var attr auth.AttributesRecord
+		if record, ok := c.Get(common.ContextAuthRecord); ok {
+			attr = record.(auth.AttributesRecord)
+			auditLog.Verb = attr.GetVerb()
+			auditLog.APIVersion = attr.GetAPIVersion()
+			auditLog.Resource = attr.GetResource()
+			auditLog.SubResource = attr.GetSubResource()
+			auditLog.ResourceName = attr.GetName()
+		}
+
+		if c.Request.Body != nil {
+			// read at most max body size plus one byte to tell whether the body is truncated,
+			// and restore the request body for the handlers
+			bodyBytes, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, int64(maxBodySize)+1))
+			if err == nil {
+				c.Request.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(bodyBytes), c.Request.Body), c.Request.Body}
+				auditLog.RequestBody = maskBody(bodyBytes, c.ContentType(), maxBodySize, maskedFields)
+			}
+		}
+
+		var before []byte
+		snapshot := config.Snapshot && !readOnly && c.Request.Method != http.MethodPost &&
+			attr.IsResourceRequest() && attr.GetName() != "" && attr.GetSubResource() == ""
+		if snapshot {
+			before = getResource(c, handler)
+		}
+
+		c.Next()
+
+		auditLog.StatusCode = c.Writer.Status()
+		if user, err := common.UserFromContext(c); err == nil {
+			auditLog.ActorID = user.GetID()
+			auditLog.ActorName = user.GetName()
+		}
+		if before != nil && auditLog.StatusCode < http.StatusMultipleChoices {
+			auditLog.Before = string(before)
+			if c.Request.Method != http.MethodDelete {
+				if after := getResource(c, handler); after != nil {
+					patch, err := jsonpatch.CreatePatch(before, after)
+					if err == nil && len(patch) > 0 {
+						patchBytes, _ := json.Marshal(patch)
+						auditLog.Diff = string(patchBytes)
+					}
+				}
+			}
+		}
+
+		// the log failed to be appended is still exported, rather than lost
+		if _, err := auditMgr.Append(c, auditLog); err != nil {
+			log.Errorf(c, "failed to append audit log of %s %s, err: %v", auditLog.Method, auditLog.Path, err)
+		}
+		for _, e := range exporters {
+			if err := e.Export(auditLog); err != nil {
+				log.Warningf(c, "failed to export audit log %d, err: %v", auditLog.ID, err)
+			}
+		}
+	}, skippers...)
+}
+
+func matchAny(patterns []*regexp.Regexp, path string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskPath masks the sensitive parameters of the query of the request uri
+func maskPath(u *url.URL, maskedFields map[string]struct{}) string {
+	if u.RawQuery == "" {
+		return u.RequestURI()
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil || !maskValues(query, maskedFields) {
+		return u.RequestURI()
+	}
+	return u.EscapedPath() + "?" + query.Encode()
+}
+
+// maskBody masks the sensitive fields of json and form bodies. Other bodies are kept as is, unless they are
+// too large, since their sensitive parts cannot be identified once truncated
+func maskBody(body []byte, contentType string, maxBodySize int, maskedFields map[string]struct{}) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if len(body) > maxBodySize {
+		return fmt.Sprintf("(omitted, larger than %d bytes)", maxBodySize)
+	}
+	if contentType == gin.MIMEPOSTForm {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "(omitted, invalid form)"
+		}
+		maskValues(values, maskedFields)
+		return values.Encode()
+	}
+	var object interface{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return string(body)
+	}
+	masked, err := json.Marshal(mask(object, maskedFields))
+	if err != nil {
+		return string(body)
+	}
+	return string(masked)
+}
+
+// maskValues masks the sensitive values of the form or query, and tells whether any of them is masked
+func maskValues(values url.Values, maskedFields map[string]struct{}) bool {
+	masked := false
+	for key, value := range values {
+		if _, ok := maskedFields[normalizeField(key)]; ok {
+			for i := range value {
+				value[i] = _maskedValue
+			}
+			masked = true
+		}
+	}
+	return masked
+}
+
+func mask(object interface{}, maskedFields map[string]struct{}) interface{} {
+	switch v := object.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, ok := maskedFields[normalizeField(key)]; ok && value != nil {
+				v[key] = _maskedValue
+				continue
+			}
+			v[key] = mask(value, maskedFields)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = mask(value, maskedFields)
+		}
+	}
+	return object
+}
+
+func normalizeField(field string) string {
+	return strings.ToLower(fieldNameReplacer.Replace(field))
+}
+
+// getResource gets the resource of the request with the same credential,
+// and returns the data of the response, or nil if failed
+func getResource(c *gin.Context, handler http.Handler) []byte {
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, c.Request.URL.Path, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header = c.Request.Header.Clone()
+	req.Header.Del("Content-Type")
+	req.Header.Del("Content-Length")
+	req.RemoteAddr = c.Request.RemoteAddr
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		return nil
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return resp.Data
+}
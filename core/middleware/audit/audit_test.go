@@ -0,0 +1,217 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/core/middleware/prehandle"
+	"github.com/horizoncd/horizon/lib/orm"
+	"github.com/horizoncd/horizon/pkg/audit/exporter"
+	"github.com/horizoncd/horizon/pkg/audit/manager"
+	"github.com/horizoncd/horizon/pkg/audit/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/config/audit"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+)
+
+func TestMiddleware(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	assert.Nil(t, db.AutoMigrate(&models.Log{}, &models.Head{}))
+	auditMgr := manager.New(db)
+
+	config := audit.Config{
+		Enabled:       true,
+		Snapshot:      true,
+		JSONLinesFile: filepath.Join(t.TempDir(), "audit.jsonl"),
+	}
+	exporters, err := exporter.New(config)
+	assert.Nil(t, err)
+
+	cluster := map[string]interface{}{"name": "cluster", "description": "old"}
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(common.UserContextKey(), &userauth.DefaultInfo{ID: 1, Name: "tony"})
+		c.Next()
+	}, prehandle.Middleware(r, managerparam.InitManager(db)), Middleware(config, auditMgr, exporters, r))
+	r.GET("/apis/core/v2/clusters", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": []interface{}{cluster}})
+	})
+	r.GET("/apis/core/v2/clusters/:clusterID", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": cluster})
+	})
+	r.PUT("/apis/core/v2/clusters/:clusterID", func(c *gin.Context) {
+		var body map[string]interface{}
+		assert.Nil(t, c.ShouldBindJSON(&body))
+		cluster["description"] = body["description"]
+		c.JSON(http.StatusOK, gin.H{"data": cluster})
+	})
+	r.GET("/apis/core/v2/clusters/:clusterID/containerlog", func(c *gin.Context) {
+		c.String(http.StatusOK, "log")
+	})
+
+	serve := func(method, path string, body string) {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	serve(http.MethodPut, "/apis/core/v2/clusters/1", `{"description":"new","password":"secret"}`)
+	serve(http.MethodGet, "/apis/core/v2/clusters", "")
+	serve(http.MethodGet, "/apis/core/v2/clusters/1/containerlog?podName=pod", "")
+	assert.Equal(t, "new", cluster["description"])
+
+	// reads other than the sensitive ones are not audited
+	logs, total, err := auditMgr.List(context.TODO(), nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), total)
+	read, update := logs[0], logs[1]
+	assert.Equal(t, "/apis/core/v2/clusters/1/containerlog?podName=pod", read.Path)
+	assert.Equal(t, "containerlog", read.SubResource)
+	assert.Equal(t, update.Hash, read.PrevHash)
+
+	assert.Equal(t, "tony", update.ActorName)
+	assert.Equal(t, "clusters", update.Resource)
+	assert.Equal(t, "1", update.ResourceName)
+	assert.Equal(t, http.StatusOK, update.StatusCode)
+	assert.Equal(t, `{"description":"new","password":"******"}`, update.RequestBody)
+	assert.Equal(t, `{"description":"old","name":"cluster"}`, update.Before)
+	var patch []map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(update.Diff), &patch))
+	assert.Equal(t, []map[string]interface{}{{"op": "replace", "path": "/description", "value": "new"}}, patch)
+
+	// exported as json lines
+	bts, err := os.ReadFile(config.JSONLinesFile)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(bts)), "\n")
+	assert.Equal(t, 2, len(lines))
+	var exported models.Log
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &exported))
+	assert.Equal(t, update.Hash, exported.Hash)
+
+	verification, err := auditMgr.Verify(context.TODO())
+	assert.Nil(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 2, verification.Verified)
+
+	// the head is exported with the signature
+	anchor, err := auditMgr.Anchor(context.TODO(), "key")
+	assert.Nil(t, err)
+	assert.Equal(t, read.ID, anchor.LogID)
+	assert.Equal(t, read.Hash, anchor.Hash)
+	assert.Equal(t, anchor.Sign("key"), anchor.Signature)
+	assert.NotEqual(t, anchor.Sign("other"), anchor.Signature)
+	for _, e := range exporters {
+		assert.Nil(t, e.ExportAnchor(anchor))
+	}
+	bts, err = os.ReadFile(config.JSONLinesFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(bts), `"kind":"`+models.AnchorKind+`"`)
+
+	// deleted at the end
+	assert.Nil(t, db.Delete(&models.Log{}, read.ID).Error)
+	verification, err = auditMgr.Verify(context.TODO())
+	assert.Nil(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, read.ID, verification.BrokenAt)
+	assert.Equal(t, "logs at the end are missing", verification.Reason)
+	assert.Nil(t, db.Create(read).Error)
+
+	// modified
+	assert.Nil(t, db.Model(&models.Log{}).Where("id = ?", update.ID).Update("status_code", 500).Error)
+	verification, err = auditMgr.Verify(context.TODO())
+	assert.Nil(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, update.ID, verification.BrokenAt)
+	assert.Equal(t, "log is modified", verification.Reason)
+
+	// deleted
+	assert.Nil(t, db.Delete(&models.Log{}, update.ID).Error)
+	verification, err = auditMgr.Verify(context.TODO())
+	assert.Nil(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, read.ID, verification.BrokenAt)
+	assert.Equal(t, "previous log is missing", verification.Reason)
+}
+
+func TestMask(t *testing.T) {
+	maskedFields := make(map[string]struct{})
+	for _, field := range DefaultMaskedFields {
+		maskedFields[normalizeField(field)] = struct{}{}
+	}
+
+	body := maskBody([]byte(`{"clientSecret":"s","client_secret":"s","name":"n"}`),
+		gin.MIMEJSON, 1024, maskedFields)
+	assert.Equal(t, `{"clientSecret":"******","client_secret":"******","name":"n"}`, body)
+
+	// form bodies of oauth token requests
+	body = maskBody([]byte("grant_type=authorization_code&code=c&code_verifier=v&client_id=id&client_secret=s"),
+		gin.MIMEPOSTForm, 1024, maskedFields)
+	assert.Equal(t, "client_id=id&client_secret=%2A%2A%2A%2A%2A%2A&code=%2A%2A%2A%2A%2A%2A&"+
+		"code_verifier=%2A%2A%2A%2A%2A%2A&grant_type=authorization_code", body)
+	body = maskBody([]byte("token=t&token_type_hint=refresh_token"), gin.MIMEPOSTForm, 1024, maskedFields)
+	assert.Equal(t, "token=%2A%2A%2A%2A%2A%2A&token_type_hint=refresh_token", body)
+	body = maskBody([]byte("device_code=d&refresh_token=r"), gin.MIMEPOSTForm, 1024, maskedFields)
+	assert.NotContains(t, body, "=d")
+	assert.NotContains(t, body, "=r")
+	body = maskBody([]byte("%zz"), gin.MIMEPOSTForm, 1024, maskedFields)
+	assert.Equal(t, "(omitted, invalid form)", body)
+
+	// queries
+	u, err := url.Parse("/login/oauth/access_token?code=c&state=s")
+	assert.Nil(t, err)
+	assert.Equal(t, "/login/oauth/access_token?code=%2A%2A%2A%2A%2A%2A&state=s", maskPath(u, maskedFields))
+	u, err = url.Parse("/apis/core/v2/clusters/1/containerlog?podName=pod&container=c")
+	assert.Nil(t, err)
+	assert.Equal(t, "/apis/core/v2/clusters/1/containerlog?podName=pod&container=c", maskPath(u, maskedFields))
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	assert.Nil(t, db.AutoMigrate(&models.Log{}, &models.Head{}))
+	auditMgr := manager.New(db)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := auditMgr.Append(context.TODO(), &models.Log{Method: http.MethodPost, Path: fmt.Sprintf("/%d", i)})
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	verification, err := auditMgr.Verify(context.TODO())
+	assert.Nil(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 20, verification.Verified)
+}
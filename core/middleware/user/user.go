@@ -111,7 +111,8 @@ func Middleware(param *param.Param, store sessions.Store,
 		if c.Writer.Status() != http.StatusOK ||
 			// if not login, call this to login
 			// if signed in, call this to link other api
-			c.Request.URL.Path == common.URLLoginCallback {
+			c.Request.URL.Path == common.URLLoginCallback ||
+			c.Request.URL.Path == common.URLLoginLDAP {
 			c.Next()
 			return
 		}
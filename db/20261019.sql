@@ -0,0 +1,1178 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- check table
+CREATE TABLE `tb_check`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_type` varchar(64)         NOT NULL DEFAULT '' COMMENT 'resource type',
+    `resource_id`   bigint(20) unsigned NOT NULL COMMENT 'resource id',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_resource_deleted` (`resource_type`, `resource_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- check run table
+CREATE TABLE `tb_checkrun`
+(
+    `id`              bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`            varchar(256)        NOT NULL DEFAULT '' COMMENT 'the name of check run',
+    `status`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'the status of check run',
+    `pipeline_run_id` bigint(20) unsigned NOT NULL COMMENT 'pipeline run id',
+    `check_id`        bigint(20) unsigned NOT NULL COMMENT 'check id',
+    `message`         varchar(256)        NOT NULL DEFAULT '',
+    `detail_url`      varchar(256)        NOT NULL DEFAULT '' COMMENT 'the detail url of check run',
+    `created_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`      bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_pipeline_run_id_check_id_deleted` (`pipeline_run_id`, `check_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- pr_msg table
+CREATE TABLE `tb_pr_msg`
+(
+    `id`              bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `pipeline_run_id` bigint(20) unsigned NOT NULL COMMENT 'pipeline run id',
+    `content`         text                NOT NULL COMMENT 'content of message',
+    `created_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_by`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    `deleted_ts`      bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `message_type`    tinyint(1) unsigned NOT NULL DEFAULT 0 COMMENT '0 for user message, 1 for system message',
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- group table
+CREATE TABLE `tb_group`
+(
+    `id`               bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`             varchar(128)        NOT NULL DEFAULT '',
+    `path`             varchar(32)         NOT NULL DEFAULT '',
+    `description`      varchar(256)                 DEFAULT NULL,
+    `visibility_level` varchar(16)         NOT NULL COMMENT 'public or private',
+    `parent_id`        bigint(20)          NOT NULL DEFAULT '0' COMMENT 'ID of the parent group',
+    `traversal_ids`    varchar(32)         NOT NULL DEFAULT '' COMMENT 'ID path from the root, like 1,2,3',
+    `region_selector`  varchar(512)        NOT NULL DEFAULT '' COMMENT 'used for filtering kubernetes',
+    `created_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`       bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_parentId_name_deletedTs` (`parent_id`, `name`, `deleted_ts`),
+    UNIQUE KEY `uk_parentId_path_deletedTs` (`parent_id`, `path`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- user table
+CREATE TABLE `tb_user`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`       varchar(64)         NOT NULL DEFAULT '',
+    `full_name`  varchar(128)                 DEFAULT '',
+    `email`      varchar(64)         NOT NULL DEFAULT '',
+    `phone`      varchar(32)                  DEFAULT NULL,
+    `oidc_id`    varchar(64)         NOT NULL COMMENT 'oidc id, which is a unique index in oidc system.',
+    `oidc_type`  varchar(64)         NOT NULL COMMENT 'oidc type, such as google, github, gitlab etc.',
+    `admin`      tinyint(1)          NOT NULL COMMENT 'is system admin，0-false，1-true',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts` bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by` bigint(20) unsigned NOT NULL DEFAULT 0,
+    `user_type`  tinyint(1) unsigned NOT NULL DEFAULT 0 COMMENT 'the option type is: 0 (common user), 1(robot user)',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_name` (`name`),
+    UNIQUE KEY `idx_email` (`email`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- template table
+CREATE TABLE `tb_template`
+(
+    `id`          bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`        varchar(64)         NOT NULL DEFAULT '' COMMENT 'the name of template',
+    `description` varchar(256)                 DEFAULT NULL COMMENT 'the template description',
+    `repository`  varchar(256)        NOT NULL DEFAULT '',
+    `group_id`    bigint(20) unsigned NOT NULL DEFAULT '0',
+    `chart_name`  varchar(256)                 DEFAULT '',
+    `only_owner`  tinyint(1)          NOT NULL DEFAULT '0',
+    `without_ci`  tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'without_ci configuration, 0 means with ci',
+    `created_at`  datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`  datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`  bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`  bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`  bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_name` (`name`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- template release table
+CREATE TABLE `tb_template_release`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `template_name` varchar(64)         NOT NULL COMMENT 'the name of template',
+    `name`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'the name of template release',
+    `description`   varchar(256)        NOT NULL COMMENT 'description about this template release',
+    `recommended`   tinyint(1)          NOT NULL COMMENT 'is the most recommended template, 0-false, 1-true',
+    `template`      bigint(20) unsigned NOT NULL DEFAULT '0',
+    `chart_name`    varchar(256)        NOT NULL DEFAULT '',
+    `only_owner`    tinyint(1)          NOT NULL DEFAULT '0',
+    `chart_version` varchar(256)        NOT NULL DEFAULT '' COMMENT 'chart version on template repository',
+    `sync_status`   varchar(64)         NOT NULL DEFAULT 'status_unknown' COMMENT 'shows sync status',
+    `failed_reason` varchar(2048)       NOT NULL DEFAULT '' COMMENT 'failed reason at last time',
+    `commit_id`     varchar(256)        NOT NULL DEFAULT '' COMMENT 'commit id at last sync',
+    `last_sync_at`  datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_template_name_name` (`template_name`, `name`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- member table
+CREATE TABLE `tb_member`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_type` varchar(64)         NOT NULL COMMENT 'groupapplicationcluster',
+    `resource_id`   bigint(20) unsigned NOT NULL COMMENT 'resource id',
+    `role`          varchar(64)         NOT NULL COMMENT 'binding role name',
+    `member_type`   tinyint(1)          NOT NULL DEFAULT '0' COMMENT '0-USER, 1-group',
+    `membername_id` bigint(20) unsigned NOT NULL COMMENT 'UserID or GroupID',
+    `granted_by`    bigint(20) unsigned NOT NULL COMMENT 'who grant the role',
+    `created_by`    bigint(20) unsigned NOT NULL COMMENT 'who create the role',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)          NOT NULL DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_resource_member_deleted` (`resource_type`, `resource_id`, `member_type`, `membername_id`,
+        `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- application table
+CREATE TABLE `tb_application`
+(
+    `id`               bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `group_id`         bigint(20) unsigned NOT NULL COMMENT 'group id',
+    `name`             varchar(64)         NOT NULL DEFAULT '' COMMENT 'the name of application',
+    `description`      varchar(256)                 DEFAULT NULL COMMENT 'the description of application',
+    `priority`         varchar(16)         NOT NULL DEFAULT 'P3' COMMENT 'the priority of application',
+    `git_url`          varchar(128)                 DEFAULT NULL COMMENT 'git repo url',
+    `git_subfolder`    varchar(128)                 DEFAULT NULL COMMENT 'git repo subfolder',
+    `git_branch`       varchar(128)                 DEFAULT NULL COMMENT 'git default branch',
+    `git_ref`          varchar(128)                 DEFAULT NULL,
+    `git_ref_type`     varchar(64)                  DEFAULT NULL,
+    `template`         varchar(64)         NOT NULL COMMENT 'template name',
+    `template_release` varchar(64)         NOT NULL COMMENT 'template release',
+    `created_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`       bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name_deletedTs` (`name`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- registry table
+CREATE TABLE `tb_registry`
+(
+    `id`                       bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`                     varchar(128)        NOT NULL DEFAULT '' COMMENT 'name of the harbor registry',
+    `server`                   varchar(256)        NOT NULL DEFAULT '' COMMENT 'harbor server address',
+    `token`                    varchar(512)        NOT NULL DEFAULT '' COMMENT 'harbor server token',
+    `path`                     varchar(256)        NOT NULL DEFAULT '' COMMENT 'path of image',
+    `insecure_skip_tls_verify` tinyint(1)          NOT NULL DEFAULT false COMMENT 'skip tls verify',
+    `kind`                     varchar(256)        NOT NULL DEFAULT 'harbor' COMMENT 'which kind registry it is',
+    `created_at`               datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`               datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`               bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`               bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`               bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 12
+  DEFAULT CHARSET = utf8mb4;
+
+-- environment table
+CREATE TABLE `tb_environment`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`           varchar(128)        NOT NULL DEFAULT '' COMMENT 'env name',
+    `display_name`   varchar(128)        NOT NULL DEFAULT '' COMMENT 'display name',
+    `default_region` varchar(128)                 DEFAULT NULL COMMENT 'default region of the environment',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    `auto_free`      tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'auto free configuration, 0 means disabled',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name_deletedTs` (`name`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- region table
+CREATE TABLE `tb_region`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`           varchar(128)        NOT NULL DEFAULT '' COMMENT 'region name',
+    `display_name`   varchar(128)        NOT NULL DEFAULT '' COMMENT 'region display name',
+    `server`         varchar(256)                 DEFAULT NULL COMMENT 'k8s server url',
+    `certificate`    text COMMENT 'k8s kube config',
+    `ingress_domain` text COMMENT 'k8s ingress domain',
+    `prometheus_url` varchar(128) COMMENT 'prometheus url',
+    `registry_id`    bigint(20) unsigned NOT NULL COMMENT 'registry id',
+    `disabled`       tinyint(1)          NOT NULL DEFAULT '0' COMMENT '0 means not disabled, 1 means disabled',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_name` (`name`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- environment_region table
+CREATE TABLE `tb_environment_region`
+(
+    `id`               bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `environment_name` varchar(128)        NOT NULL DEFAULT '' COMMENT 'environment name',
+    `region_name`      varchar(128)        NOT NULL DEFAULT '' COMMENT 'region name',
+    `is_default`       tinyint(1)          NOT NULL DEFAULT '0' COMMENT '0 means not default region, 1 means default region',
+    `disabled`         tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'is disabled，0-false，1-true',
+    `created_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`       bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_env_region_deletedTs` (`environment_name`, `region_name`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- cluster table
+CREATE TABLE `tb_cluster`
+(
+    `id`               bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `application_id`   bigint(20) unsigned NOT NULL COMMENT 'application id',
+    `name`             varchar(64)         NOT NULL DEFAULT '' COMMENT 'the name of cluster',
+    `environment_name` varchar(128)        NOT NULL DEFAULT '',
+    `region_name`      varchar(128)        NOT NULL DEFAULT '',
+    `description`      varchar(256)                 DEFAULT NULL COMMENT 'the description of cluster',
+    `git_url`          varchar(128)                 DEFAULT NULL COMMENT 'git repo url',
+    `git_subfolder`    varchar(128)                 DEFAULT NULL COMMENT 'git repo subfolder',
+    `git_branch`       varchar(128)                 DEFAULT NULL COMMENT 'git branch',
+    `git_ref`          varchar(128)                 DEFAULT NULL,
+    `git_ref_type`     varchar(64)                  DEFAULT NULL,
+    `template`         varchar(64)         NOT NULL COMMENT 'template name',
+    `template_release` varchar(64)         NOT NULL COMMENT 'template release',
+    `status`           varchar(64)                  DEFAULT NULL,
+    `created_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`       bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    `expire_seconds`   bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'expiration seconds, 0 means permanent',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name_deletedTs` (`name`, `deleted_ts`),
+    KEY `idx_application_id` (`application_id`),
+    KEY `idx_deleted_ts` (`deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- tag table
+CREATE TABLE `tb_tag`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_id`   bigint(20) unsigned NOT NULL COMMENT 'resource id',
+    `resource_type` varchar(64)         NOT NULL DEFAULT '' COMMENT 'resource type',
+    `tag_key`       varchar(64)         NOT NULL DEFAULT '' COMMENT 'key of tag',
+    `tag_value`     varchar(1280)       NOT NULL DEFAULT '' COMMENT 'value of tag',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_rType_cId_tKey` (`resource_type`, `resource_id`, `tag_key`),
+    KEY `idx_cluster_id` (`resource_id`),
+    KEY `idx_key` (`tag_key`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- cluster template schema tag table
+CREATE TABLE `tb_cluster_template_schema_tag`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id` bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `tag_key`    varchar(64)         NOT NULL DEFAULT '' COMMENT 'key of tag',
+    `tag_value`  varchar(1280)       NOT NULL DEFAULT '' COMMENT 'value of tag',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_cluster_id_key` (`cluster_id`, `tag_key`),
+    KEY `idx_key` (`tag_key`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- pipelinerun table
+CREATE TABLE `tb_pipelinerun`
+(
+    `id`                 bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`         bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `action`             varchar(64)         NOT NULL COMMENT 'action',
+    `status`             varchar(64)         NOT NULL DEFAULT '' COMMENT 'the pipelinerun status',
+    `title`              varchar(256)        NOT NULL DEFAULT '' COMMENT 'the title of pipelinerun',
+    `description`        varchar(2048)                DEFAULT NULL COMMENT 'the description of pipelinerun',
+    `git_url`            varchar(128)                 DEFAULT NULL COMMENT 'git repo url',
+    `git_branch`         varchar(128)                 DEFAULT NULL COMMENT 'the branch to build of this pipelinerun',
+    `git_ref`            varchar(128)                 DEFAULT NULL,
+    `git_ref_type`       varchar(64)                  DEFAULT NULL,
+    `git_commit`         varchar(128)                 DEFAULT NULL COMMENT 'the commit to build of this pipelinerun',
+    `image_url`          varchar(256)                 DEFAULT NULL COMMENT 'image url',
+    `last_config_commit` varchar(128)                 DEFAULT NULL COMMENT 'the last commit of cluster config',
+    `config_commit`      varchar(128)                 DEFAULT NULL COMMENT 'the new commit of cluster config',
+    `s3_bucket`          varchar(128)        NOT NULL DEFAULT '' COMMENT 's3 bucket to storage this pipelinerun log',
+    `log_object`         varchar(258)        NOT NULL DEFAULT '' COMMENT 's3 object for log',
+    `pr_object`          varchar(258)        NOT NULL DEFAULT '' COMMENT 's3 object for pipelinerun',
+    `ci_event_id`        varchar(36)         NOT NULL DEFAULT '' COMMENT 'event id returned from ci component',
+    `started_at`         datetime                     DEFAULT NULL COMMENT 'start time of this pipelinerun',
+    `finished_at`        datetime                     DEFAULT NULL COMMENT 'finish time of this pipelinerun',
+    `rollback_from`      bigint(20) unsigned          DEFAULT NULL COMMENT 'the pipelinerun id that this pipelinerun rollback from',
+    `created_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_by`         bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    PRIMARY KEY (`id`),
+    KEY `idx_cluster_action` (`cluster_id`, `action`),
+    KEY `idx_cluster_config_commit` (`cluster_id`, `config_commit`),
+    KEY `idx_ci_event_id` (`ci_event_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- application region table
+CREATE TABLE `tb_application_region`
+(
+    `id`               bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `application_id`   bigint(20) unsigned NOT NULL COMMENT 'application id',
+    `environment_name` varchar(128)        NOT NULL DEFAULT '' COMMENT 'environment name',
+    `region_name`      varchar(128)        NOT NULL DEFAULT '' COMMENT 'default deploy region of the environment',
+    `created_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`       bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_application_environment` (`application_id`, `environment_name`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- tekton pipeline
+CREATE TABLE `tb_pipeline`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `pipelinerun_id` bigint(20) unsigned NOT NULL COMMENT 'pipelinerun id',
+    `application`    varchar(64)         NOT NULL COMMENT 'application name',
+    `cluster`        varchar(64)         NOT NULL COMMENT 'cluster name',
+    `region`         varchar(16)         NOT NULL COMMENT 'region name',
+    `pipeline`       varchar(16)         NOT NULL DEFAULT '' COMMENT 'pipeline name',
+    `result`         varchar(16)         NOT NULL DEFAULT '' COMMENT 'result of the step, ok、failed or others',
+    `duration`       int(16)             NOT NULL COMMENT 'duration',
+    `started_at`     datetime            NOT NULL COMMENT 'start time of this pipelinerun',
+    `finished_at`    datetime            NOT NULL COMMENT 'finish time of this pipelinerun',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_region_application_created_at` (`region`, `application`, `created_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- tekton pipeline task
+CREATE TABLE `tb_task`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `pipelinerun_id` bigint(20) unsigned NOT NULL COMMENT 'pipelinerun id',
+    `application`    varchar(64)         NOT NULL COMMENT 'application name',
+    `cluster`        varchar(64)         NOT NULL COMMENT 'cluster name',
+    `region`         varchar(16)         NOT NULL COMMENT 'region name',
+    `pipeline`       varchar(16)         NOT NULL DEFAULT '' COMMENT 'pipeline name',
+    `task`           varchar(16)         NOT NULL DEFAULT '' COMMENT 'task name',
+    `result`         varchar(16)         NOT NULL DEFAULT '' COMMENT 'result of the step, ok or failed',
+    `duration`       int(16)             NOT NULL COMMENT 'duration',
+    `started_at`     datetime            NOT NULL COMMENT 'start time of this pipelinerun',
+    `finished_at`    datetime            NOT NULL COMMENT 'finish time of this pipelinerun',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_region_application_created_at` (`region`, `application`, `created_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- tekton task step
+CREATE TABLE `tb_step`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `pipelinerun_id` bigint(20) unsigned NOT NULL COMMENT 'pipelinerun id',
+    `application`    varchar(64)         NOT NULL COMMENT 'application name',
+    `cluster`        varchar(64)         NOT NULL COMMENT 'cluster name',
+    `region`         varchar(16)         NOT NULL COMMENT 'region name',
+    `pipeline`       varchar(16)         NOT NULL DEFAULT '' COMMENT 'pipeline name',
+    `task`           varchar(16)         NOT NULL DEFAULT '' COMMENT 'task name',
+    `step`           varchar(16)         NOT NULL DEFAULT '' COMMENT 'step name',
+    `result`         varchar(16)         NOT NULL DEFAULT '' COMMENT 'result of the step, ok or failed',
+    `duration`       int(16)             NOT NULL COMMENT 'duration',
+    `started_at`     datetime            NOT NULL COMMENT 'start time of this pipelinerun',
+    `finished_at`    datetime            NOT NULL COMMENT 'finish time of this pipelinerun',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_region_application_created_at` (`region`, `application`, `created_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- oauth app table
+CREATE TABLE `tb_oauth_app`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`         varchar(128)                 DEFAULT NULL COMMENT 'short name of app client',
+    `client_id`    varchar(128)                 DEFAULT NULL COMMENT 'oauth app client',
+    `redirect_url` varchar(256)                 DEFAULT NULL COMMENT 'the authorization callback url',
+    `home_url`     varchar(256)                 DEFAULT NULL COMMENT 'the oauth app home url',
+    `description`  varchar(256)                 DEFAULT NULL COMMENT 'the desc of app',
+    `app_type`     tinyint(1)          NOT NULL DEFAULT '1' COMMENT '1 for HorizonOAuthAPP, 2 for DirectOAuthAPP',
+    `owner_type`   tinyint(1)          NOT NULL DEFAULT '1' COMMENT '1 for group, 2 for user',
+    `owner_id`     bigint(20)                   DEFAULT NULL COMMENT 'group owner id',
+    `created_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created_at',
+    `created_by`   bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `updated_by`   bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_client_id` (`client_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- oauth client secret table
+CREATE TABLE `tb_oauth_client_secret`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `client_id`     varchar(256)                 DEFAULT NULL COMMENT 'oauth app client',
+    `client_secret` varchar(256)                 DEFAULT NULL COMMENT 'oauth app secret',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_client_id_secret` (`client_id`, `client_secret`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- token table
+CREATE TABLE `tb_token`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `name`         varchar(64)         NOT NULL DEFAULT '',
+    `client_id`    varchar(256)                 DEFAULT NULL COMMENT 'oauth app client',
+    `redirect_uri` varchar(256)                 DEFAULT NULL,
+    `state`        varchar(256)                 DEFAULT NULL COMMENT ' authorize_code state info',
+    `code`         varchar(256)        NOT NULL DEFAULT '' COMMENT 'private-token-code/authorize_code/access_token/refresh-token',
+    `created_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `expires_in`   bigint(20)                   DEFAULT NULL,
+    `scope`        varchar(256)                 DEFAULT NULL,
+    `user_id`      bigint(20) unsigned NOT NULL DEFAULT '0',
+    `created_by`   bigint(20) unsigned NOT NULL DEFAULT '0',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_code` (`code`),
+    KEY `idx_client_id` (`client_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- identity provider table
+create table `tb_identity_provider`
+(
+    `id`                         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `display_name`               varchar(128)        NOT NULL DEFAULT '' COMMENT 'name displayed on web',
+    `name`                       varchar(128)        NOT NULL DEFAULT '' COMMENT 'name to generate index in db, unique',
+    `avatar`                     varchar(256)        NOT NULL DEFAULT '' COMMENT 'link to avatar',
+    `authorization_endpoint`     varchar(256)        NOT NULL DEFAULT '' COMMENT 'authorization endpoint of idp',
+    `token_endpoint`             varchar(256)        NOT NULL DEFAULT '' COMMENT 'token endpoint of idp',
+    `userinfo_endpoint`          varchar(256)        NOT NULL DEFAULT '' COMMENT 'userinfo endpoint of idp',
+    `revocation_endpoint`        varchar(256)        NOT NULL DEFAULT '' COMMENT 'revocation endpoint of idp',
+    `issuer`                     varchar(256)        NOT NULL DEFAULT '' COMMENT 'issuer of idp, generating discovery endpoint',
+    `scopes`                     varchar(256)        NOT NULL DEFAULT '' COMMENT 'scopes when asking for authorization',
+    `signing_algs`               varchar(256)        NOT NULL DEFAULT '' COMMENT 'algs for verifying signing',
+    `token_endpoint_auth_method` varchar(256)        NOT NULL DEFAULT 'client_secret_sent_as_post' COMMENT 'how to carry client secret',
+    `jwks`                       varchar(256)        NOT NULL DEFAULT '' COMMENT 'jwks endpoint, describe how to identify a token',
+    `client_id`                  varchar(256)        NOT NULL DEFAULT '' COMMENT 'client id issued by idp',
+    `client_secret`              varchar(256)        NOT NULL DEFAULT '' COMMENT 'client secret issued by idp',
+    `created_at`                 datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'time of first creating',
+    `updated_at`                 datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'time of last updating',
+    `deleted_ts`                 bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`                 bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'creator',
+    `updated_by`                 bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_name` (`name`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- idp and user relationship table
+create table `tb_idp_user`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `sub`        varchar(256)        NOT NULL DEFAULT '' COMMENT 'user id in idp',
+    `idp_id`     bigint(20)          NOT NULL DEFAULT 0 COMMENT 'refer to tb_identify_provider',
+    `user_id`    bigint(20)          NOT NULL DEFAULT 0 COMMENT 'refer to tb_user',
+    `name`       varchar(256)        NOT NULL DEFAULT '' COMMENT 'user name from idp',
+    `email`      varchar(256)        NOT NULL DEFAULT '' COMMENT 'user email from idp',
+    `deletable`  bool                NOT NULL DEFAULT false COMMENT 'whether this link can be deleted',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'time of first creating',
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'time of last updating',
+    `deleted_ts` bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'creator',
+    `updated_by` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uni_idx_idp_sub` (`idp_id`, `sub`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `tb_event`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `req_id`        varchar(256)        NOT NULL DEFAULT '',
+    `resource_type` varchar(256)        NOT NULL DEFAULT '',
+    `resource_id`   varchar(256)        NOT NULL DEFAULT '',
+    `event_type`    varchar(256)        NOT NULL DEFAULT '',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0',
+    `extra`         varchar(255)        NOT NULL DEFAULT '' COMMENT 'extra infos to describe the event',
+    PRIMARY KEY (`id`),
+    KEY `idx_req_id` (`req_id`),
+    KEY `idx_resource_action` (`resource_id`, `resource_type`, `event_type`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `tb_event_cursor`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `position`   bigint(20)          NOT NULL DEFAULT '0',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_value` (`position`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `tb_webhook`
+(
+    `id`                 bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `enabled`            tinyint(1)          NOT NULL DEFAULT '1',
+    `url`                text                NOT NULL,
+    `ssl_verify_enabled` tinyint(1)          NOT NULL DEFAULT '0',
+    `description`        varchar(256)        NOT NULL DEFAULT '',
+    `secret`             text                NOT NULL,
+    `triggers`           text                NOT NULL,
+    `resource_type`      varchar(256)        NOT NULL DEFAULT '',
+    `resource_id`        bigint(20)          NOT NULL DEFAULT '0',
+    `created_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_by`         bigint(20) unsigned NOT NULL DEFAULT '0',
+    `updated_by`         bigint(20) unsigned NOT NULL DEFAULT '0',
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `tb_webhook_log`
+(
+    `id`               bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `webhook_id`       bigint(20) unsigned NOT NULL,
+    `event_id`         bigint(20) unsigned NOT NULL,
+    `url`              text                NOT NULL,
+    `request_headers`  text                NOT NULL,
+    `request_data`     text                NOT NULL,
+    `response_headers` text                NOT NULL,
+    `response_body`    text                NOT NULL,
+    `status`           varchar(256)        NOT NULL,
+    `error_message`    text                NOT NULL,
+    `created_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by`       bigint(20) unsigned NOT NULL DEFAULT '0',
+    `updated_at`       datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_webhook_id_status` (`webhook_id`, `status`),
+    KEY `idx_event_id` (`event_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- metatag table
+CREATE TABLE `tb_metatag`
+(
+    `tag_key`     varchar(64)  NOT NULL DEFAULT '' comment 'key of the metatag',
+    `tag_value`   varchar(128) NOT NULL DEFAULT '' comment 'value of the metatag',
+    `description` varchar(64)  NOT NULL DEFAULT '' comment 'description',
+    `created_at`  datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`  datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY `idx_key_value` (`tag_key`, `tag_value`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `tb_badge`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `event_id`      bigint(20) unsigned NOT NULL,
+    `resource_type` varchar(64)         NOT NULL DEFAULT '' COMMENT 'resource type',
+    `resource_id`   bigint(20) unsigned NOT NULL COMMENT 'resource id',
+    `name`          varchar(64)        NOT NULL DEFAULT '' COMMENT 'badge name',
+    `svg_link`      varchar(256)        NOT NULL DEFAULT '' COMMENT 'badge svg link',
+    `redirect_link` varchar(256)        NOT NULL DEFAULT '' COMMENT 'badge redirect link',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    UNIQUE KEY `idx_resource_name_deletedTs` (`resource_id`, `resource_type`, `name`, `deleted_ts`),
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- elevation table
+CREATE TABLE `tb_elevation`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_type` varchar(64)         NOT NULL DEFAULT '' COMMENT 'resource type',
+    `resource_id`   bigint(20) unsigned NOT NULL COMMENT 'resource id',
+    `user_id`       bigint(20) unsigned NOT NULL COMMENT 'the user who requests the elevation',
+    `role`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'the role requested',
+    `duration`      bigint(20)          NOT NULL DEFAULT '0' COMMENT 'duration of the elevation in nanoseconds',
+    `justification` varchar(1024)       NOT NULL DEFAULT '' COMMENT 'the reason of the elevation',
+    `status`        varchar(64)         NOT NULL DEFAULT '' COMMENT 'pending/approved/rejected/expired',
+    `member_id`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'the member binding of the elevated role',
+    `previous_role` varchar(64)         NOT NULL DEFAULT '' COMMENT 'the direct role held before elevation',
+    `reviewed_by`   bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reviewer',
+    `reviewed_at`   datetime                     DEFAULT NULL COMMENT 'review time',
+    `expired_at`    datetime                     DEFAULT NULL COMMENT 'expiry time of the elevated role',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_resource` (`resource_type`, `resource_id`),
+    KEY `idx_status_expired_at` (`status`, `expired_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- admission policy table
+CREATE TABLE `tb_admission_policy`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `group_id`       bigint(20) unsigned NOT NULL COMMENT 'the group that the policy is stored in',
+    `name`           varchar(128)        NOT NULL DEFAULT '' COMMENT 'policy name',
+    `kind`           varchar(64)         NOT NULL DEFAULT '' COMMENT 'validating/mutating',
+    `mode`           varchar(64)         NOT NULL DEFAULT '' COMMENT 'enforce/audit',
+    `failure_policy` varchar(64)         NOT NULL DEFAULT '' COMMENT 'fail/ignore',
+    `rules`          text COMMENT 'json encoded resource rules',
+    `expression`     text COMMENT 'CEL expression of the policy',
+    `message`        varchar(1024)       NOT NULL DEFAULT '' COMMENT 'denial message',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_group_id` (`group_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- release plan table
+CREATE TABLE `tb_release_plan`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `application_id` bigint(20) unsigned NOT NULL COMMENT 'application id',
+    `title`          varchar(256)        NOT NULL DEFAULT '' COMMENT 'title of pipelineruns',
+    `description`    varchar(2048)       NOT NULL DEFAULT '' COMMENT 'description of pipelineruns',
+    `image_tag`      varchar(256)        NOT NULL DEFAULT '' COMMENT 'image tag to deploy',
+    `wave`           int(11)             NOT NULL DEFAULT '0' COMMENT 'index of the wave being deployed',
+    `status`         varchar(64)         NOT NULL DEFAULT '' COMMENT 'running/succeeded/failed/cancelled',
+    `message`        text COMMENT 'reason of the status',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_application_id` (`application_id`),
+    KEY `idx_status` (`status`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- clusters of release plan table
+CREATE TABLE `tb_release_plan_cluster`
+(
+    `id`              bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `release_plan_id` bigint(20) unsigned NOT NULL COMMENT 'release plan id',
+    `cluster_id`      bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `wave`            int(11)             NOT NULL DEFAULT '0' COMMENT 'index of the wave',
+    `pipelinerun_id`  bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'pipelinerun of the deployment',
+    `status`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'pending/deploying/succeeded/failed',
+    `message`         text COMMENT 'reason of the status',
+    `started_at`      datetime                     DEFAULT NULL COMMENT 'deploy time',
+    `created_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`      bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    KEY `idx_release_plan_id` (`release_plan_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- gitops repo table, used when gitopsRepo kind is database
+CREATE TABLE `tb_gitops_repo`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `path`           varchar(512)        NOT NULL COMMENT 'full path of the repo',
+    `default_branch` varchar(128)        NOT NULL DEFAULT '' COMMENT 'default branch of the repo',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_path` (`path`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- branches of gitops repo table
+CREATE TABLE `tb_gitops_ref`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `repo_id`      bigint(20) unsigned NOT NULL COMMENT 'gitops repo id',
+    `name`         varchar(128)        NOT NULL COMMENT 'branch name',
+    `commit_sha`   char(40)            NOT NULL COMMENT 'commit the branch points to',
+    `mirrored_sha` char(40)            NOT NULL DEFAULT '' COMMENT 'commit last pushed to the git mirror',
+    `created_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_repo_name` (`repo_id`, `name`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- commits of gitops repo table
+CREATE TABLE `tb_gitops_commit`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `repo_id`      bigint(20) unsigned NOT NULL COMMENT 'gitops repo id',
+    `sha`          char(40)            NOT NULL COMMENT 'git sha of the commit',
+    `tree`         mediumtext          NOT NULL COMMENT 'json map from file path to blob sha',
+    `parents`      varchar(128)        NOT NULL DEFAULT '' COMMENT 'parent commits separated by space',
+    `generation`   int(11)             NOT NULL DEFAULT '0' COMMENT 'max generation of parents plus one',
+    `message`      text COMMENT 'commit message',
+    `author_name`  varchar(128)        NOT NULL DEFAULT '' COMMENT 'author name',
+    `author_email` varchar(256)        NOT NULL DEFAULT '' COMMENT 'author email',
+    `author_time`  bigint(20)          NOT NULL DEFAULT '0' COMMENT 'unix timestamp of the commit',
+    `created_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_repo_sha` (`repo_id`, `sha`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- file contents of gitops repo table, shared by all the repos
+CREATE TABLE `tb_gitops_blob`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `sha`        char(40)            NOT NULL COMMENT 'git sha of the blob',
+    `content`    longtext COMMENT 'file content',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_sha` (`sha`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- config change request table
+CREATE TABLE `tb_change_request`
+(
+    `id`                 bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`         bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `title`              varchar(256)        NOT NULL DEFAULT '' COMMENT 'title of change request',
+    `description`        varchar(2048)       NOT NULL DEFAULT '' COMMENT 'description of change request',
+    `branch`             varchar(128)        NOT NULL DEFAULT '' COMMENT 'branch of the change in gitops repo',
+    `request`            text COMMENT 'json of the cluster update request',
+    `merge_patch`        tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether to merge the request with current config',
+    `required_approvals` int(11)             NOT NULL DEFAULT '1' COMMENT 'number of approvals required',
+    `status`             varchar(64)         NOT NULL DEFAULT '' COMMENT 'open/merged/rejected/closed',
+    `created_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`         bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`         bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`         bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_cluster_id` (`cluster_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- reviews of change request table
+CREATE TABLE `tb_change_request_review`
+(
+    `id`                bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `change_request_id` bigint(20) unsigned NOT NULL COMMENT 'change request id',
+    `approved`          tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'approved or rejected',
+    `comment`           varchar(2048)       NOT NULL DEFAULT '' COMMENT 'comment of the review',
+    `created_at`        datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by`        bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reviewer',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_change_request_reviewer` (`change_request_id`, `created_by`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- drift of cluster between gitops repo and kubernetes table
+CREATE TABLE `tb_cluster_drift`
+(
+    `id`          bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`  bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `drifted`     tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether the live state drifted from gitops',
+    `resources`   longtext COMMENT 'json of drifted resources with field level diffs',
+    `message`     text COMMENT 'reason why drift cannot be detected',
+    `detected_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'last time of detection',
+    `reverted_at` datetime                     DEFAULT NULL COMMENT 'last time of reverting drift',
+    `reverted_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'who reverted drift',
+    `created_at`  datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`  datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`  bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_cluster_id_deleted_ts` (`cluster_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- canary analysis config of cluster table
+CREATE TABLE `tb_canary_analysis`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id` bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `enabled`    tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether canaries of the cluster are analyzed',
+    `interval`   int(11) unsigned    NOT NULL DEFAULT '0' COMMENT 'seconds a canary step is observed before analysis',
+    `metrics`    text COMMENT 'json of metrics queried from prometheus',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts` bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_cluster_id_deleted_ts` (`cluster_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- canary analysis of pipelinerun table
+CREATE TABLE `tb_canary_analysis_run`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`     bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `pipelinerun_id` bigint(20) unsigned NOT NULL COMMENT 'pipelinerun which deployed the canary',
+    `step`           int(11)             NOT NULL DEFAULT '0' COMMENT 'index of canary step analyzed',
+    `status`         varchar(64)         NOT NULL DEFAULT '' COMMENT 'analyzing/succeeded/failed',
+    `results`        text COMMENT 'json of metric results',
+    `message`        text COMMENT 'reason why the metrics cannot be queried',
+    `errors`         int(11)             NOT NULL DEFAULT '0' COMMENT 'consecutive times the metrics cannot be queried',
+    `started_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'when the step started to be observed',
+    `finished_at`    datetime                     DEFAULT NULL COMMENT 'when the canary was promoted or aborted',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_pipelinerun_id_step` (`pipelinerun_id`, `step`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- temporary replicas override of horizontal pod autoscaler table
+CREATE TABLE `tb_hpa_override`
+(
+    `id`                    bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`            bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `namespace`             varchar(128)        NOT NULL DEFAULT '' COMMENT 'namespace of hpa',
+    `name`                  varchar(256)        NOT NULL DEFAULT '' COMMENT 'name of hpa',
+    `min_replicas`          int(11)                      DEFAULT NULL COMMENT 'min replicas overridden',
+    `max_replicas`          int(11)             NOT NULL DEFAULT '0' COMMENT 'max replicas overridden',
+    `original_min_replicas` int(11)                      DEFAULT NULL COMMENT 'min replicas restored after revert',
+    `original_max_replicas` int(11)             NOT NULL DEFAULT '0' COMMENT 'max replicas restored after revert',
+    `reason`                varchar(512)        NOT NULL DEFAULT '' COMMENT 'why the replicas are overridden',
+    `expire_at`             datetime            NOT NULL COMMENT 'when the override is reverted automatically',
+    `reverted_at`           datetime                     DEFAULT NULL COMMENT 'null means the override is active',
+    `reverted_by`           bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reverter, 0 means reverted after expiration',
+    `created_at`            datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`            datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`            bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`            bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`            bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_cluster_id_name` (`cluster_id`, `name`),
+    KEY `idx_expire_at` (`expire_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- hibernation schedule of environment or cluster table
+CREATE TABLE `tb_hibernation_schedule`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_type`  varchar(64)         NOT NULL DEFAULT '' COMMENT 'environments or clusters',
+    `resource_id`    bigint(20) unsigned NOT NULL COMMENT 'id of environment or cluster',
+    `enabled`        tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether the schedule is enabled',
+    `hibernate_cron` varchar(128)        NOT NULL DEFAULT '' COMMENT 'cron to hibernate clusters',
+    `wake_cron`      varchar(128)        NOT NULL DEFAULT '' COMMENT 'cron to wake up clusters',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_resource_deleted_ts` (`resource_type`, `resource_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- hibernation state of cluster table
+CREATE TABLE `tb_cluster_hibernation`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`    bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `status`        varchar(64)         NOT NULL DEFAULT '' COMMENT 'hibernated/hibernating/awake',
+    `workloads`     text COMMENT 'json of workloads with their replicas before hibernation',
+    `hibernated_at` datetime                     DEFAULT NULL COMMENT 'last time the cluster was hibernated',
+    `hibernated_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'user hibernated, 0 means by schedule',
+    `woken_at`      datetime                     DEFAULT NULL COMMENT 'last time the cluster was woken up',
+    `woken_by`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'user woke up, 0 means by schedule',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_cluster_id_deleted_ts` (`cluster_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- terminal session table
+CREATE TABLE `tb_terminal_session`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`     bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `pod_name`       varchar(256)        NOT NULL DEFAULT '' COMMENT 'pod name',
+    `container_name` varchar(256)        NOT NULL DEFAULT '' COMMENT 'container name',
+    `object`         varchar(1024)       NOT NULL DEFAULT '' COMMENT 'recording object in storage, empty if not stored',
+    `size`           int(11)             NOT NULL DEFAULT '0' COMMENT 'size of recording in bytes',
+    `truncated`      tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether the recording is truncated',
+    `started_at`     datetime                     DEFAULT NULL COMMENT 'time the session started',
+    `ended_at`       datetime                     DEFAULT NULL COMMENT 'time the session ended',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'user opened the session',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    KEY `idx_cluster_id` (`cluster_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- PKCE and device authorization grants of oauth
+ALTER TABLE `tb_token`
+    ADD COLUMN `code_challenge`        varchar(256) NOT NULL DEFAULT '' COMMENT 'PKCE code challenge of authorize_code',
+    ADD COLUMN `code_challenge_method` varchar(16)  NOT NULL DEFAULT '' COMMENT 'PKCE code challenge method, plain/S256',
+    ADD COLUMN `user_code`             varchar(16)  NOT NULL DEFAULT '' COMMENT 'user code of device_code',
+    ADD KEY `idx_user_code` (`user_code`);
+
+-- nonce of OpenID Connect authorization request
+ALTER TABLE `tb_token`
+    ADD COLUMN `nonce` varchar(256) NOT NULL DEFAULT '' COMMENT 'nonce of authorize_code passed to id token';
+
+-- ldap identity provider
+ALTER TABLE `tb_identity_provider`
+    ADD COLUMN `type`        varchar(32) NOT NULL DEFAULT 'oidc' COMMENT 'type of idp, oidc or ldap',
+    ADD COLUMN `ldap_config` text COMMENT 'json of connection, user search and group mappings of ldap idp';
+
+-- memberships of horizon groups granted by the ldap group sync
+CREATE TABLE `tb_idp_ldap_grant`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `group_id`   bigint(20) unsigned NOT NULL COMMENT 'horizon group id',
+    `user_id`    bigint(20) unsigned NOT NULL COMMENT 'user id',
+    `role`       varchar(64)         NOT NULL DEFAULT '' COMMENT 'role of the group granted by the sync',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_group_id_user_id` (`group_id`, `user_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- claim mappings of identity providers
+ALTER TABLE `tb_identity_provider`
+    ADD COLUMN `claim_mappings` text COMMENT 'json of rules granting admin or group roles by claims at sign-in';
+ALTER TABLE `tb_idp_user`
+    ADD COLUMN `claim_grants` text COMMENT 'json of admin and group roles granted by claim mappings';
+
+-- aggregations of kubernetes events
+CREATE TABLE `tb_k8s_event_aggregation`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'cluster id, 0 if the object belongs to no cluster',
+    `region_id`  bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'region id',
+    `reason`     varchar(128)        NOT NULL DEFAULT '' COMMENT 'reason of events',
+    `kind`       varchar(128)        NOT NULL DEFAULT '' COMMENT 'kind of the involved object',
+    `namespace`  varchar(128)        NOT NULL DEFAULT '' COMMENT 'namespace of the involved object',
+    `name`       varchar(256)        NOT NULL DEFAULT '' COMMENT 'name of the involved object',
+    `type`       varchar(32)         NOT NULL DEFAULT '' COMMENT 'type of events, Normal or Warning',
+    `severity`   varchar(32)         NOT NULL DEFAULT '' COMMENT 'severity of events',
+    `message`    text COMMENT 'message of the latest event',
+    `count`      int(11)             NOT NULL DEFAULT '0' COMMENT 'occurrences of events',
+    `first_seen` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'time of the first event',
+    `last_seen`  datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'time of the latest event',
+    `sources`    text COMMENT 'json of uids of kubernetes events to their counts',
+    `event_id`   bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'id of the horizon event emitted',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts` bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    KEY `idx_object` (`cluster_id`, `reason`, `kind`, `namespace`, `name`(128), `first_seen`),
+    KEY `idx_cluster_last_seen` (`cluster_id`, `last_seen`),
+    KEY `idx_last_seen` (`last_seen`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- append-only audit logs chained by hashes
+CREATE TABLE `tb_audit_log`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `request_id`    varchar(64)         NOT NULL DEFAULT '' COMMENT 'request id',
+    `actor_id`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'user id, 0 if not authenticated',
+    `actor_name`    varchar(128)        NOT NULL DEFAULT '' COMMENT 'user name',
+    `client_ip`     varchar(64)         NOT NULL DEFAULT '' COMMENT 'ip of the client',
+    `user_agent`    varchar(512)        NOT NULL DEFAULT '' COMMENT 'user agent of the client',
+    `method`        varchar(16)         NOT NULL DEFAULT '' COMMENT 'http method',
+    `path`          varchar(2048)       NOT NULL DEFAULT '' COMMENT 'request uri with the query',
+    `verb`          varchar(32)         NOT NULL DEFAULT '' COMMENT 'verb of the request',
+    `api_version`   varchar(16)         NOT NULL DEFAULT '' COMMENT 'api version of the request',
+    `resource`      varchar(64)         NOT NULL DEFAULT '' COMMENT 'resource type',
+    `sub_resource`  varchar(64)         NOT NULL DEFAULT '' COMMENT 'sub resource',
+    `resource_name` varchar(256)        NOT NULL DEFAULT '' COMMENT 'resource id or name',
+    `status_code`   int(11)             NOT NULL DEFAULT '0' COMMENT 'http status code of the response',
+    `request_body`  mediumtext COMMENT 'request body, truncated and masked',
+    `before`        mediumtext COMMENT 'resource before updated or deleted',
+    `diff`          mediumtext COMMENT 'json patch from the resource before to after updated',
+    `prev_hash`     varchar(64)         NOT NULL DEFAULT '' COMMENT 'hash of the previous log',
+    `hash`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'sha256 of the previous hash and the log',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_prev_hash` (`prev_hash`),
+    KEY `idx_actor_name` (`actor_name`, `created_at`),
+    KEY `idx_resource` (`resource`, `resource_name`(128), `created_at`),
+    KEY `idx_created_at` (`created_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- head of the hash chain of audit logs, locked to serialize appends
+CREATE TABLE `tb_audit_log_head`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `log_id`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'id of the latest audit log',
+    `hash`       varchar(64)         NOT NULL DEFAULT '' COMMENT 'hash of the latest audit log',
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- commit time of pipelineruns for the lead time of DORA metrics
+ALTER TABLE `tb_pipelinerun`
+    ADD COLUMN `git_commit_time` datetime DEFAULT NULL COMMENT 'the commit time of git_commit' AFTER `git_commit`,
+    ADD KEY `idx_finished_at` (`finished_at`);
+
+-- build cache hits of tasks and indexes of step durations for build insights
+ALTER TABLE `tb_task`
+    ADD COLUMN `cache_hit` tinyint(1) DEFAULT NULL COMMENT 'whether the build cache is hit, null if not reported' AFTER `duration`,
+    ADD KEY `idx_application_started_at` (`application`, `started_at`);
+ALTER TABLE `tb_step`
+    ADD KEY `idx_application_started_at` (`application`, `started_at`);
+ALTER TABLE `tb_pipeline`
+    ADD KEY `idx_started_at` (`started_at`);
+
+-- approvals required by pipelineruns of protected environments before executed
+ALTER TABLE `tb_pipelinerun`
+    ADD COLUMN `required_approvals` int(11) NOT NULL DEFAULT '0' COMMENT 'approvals required before executed' AFTER `rollback_from`;
+
+-- approvals of pipelinerun table
+CREATE TABLE `tb_pipelinerun_approval`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `pipelinerun_id` bigint(20) unsigned NOT NULL COMMENT 'pipelinerun id',
+    `approved`       tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'approved or rejected',
+    `comment`        varchar(2048)       NOT NULL DEFAULT '' COMMENT 'comment of the review',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reviewer',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_pipelinerun_reviewer` (`pipelinerun_id`, `created_by`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,36 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- admission policy table
+CREATE TABLE `tb_admission_policy`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `group_id`       bigint(20) unsigned NOT NULL COMMENT 'the group that the policy is stored in',
+    `name`           varchar(128)        NOT NULL DEFAULT '' COMMENT 'policy name',
+    `kind`           varchar(64)         NOT NULL DEFAULT '' COMMENT 'validating/mutating',
+    `mode`           varchar(64)         NOT NULL DEFAULT '' COMMENT 'enforce/audit',
+    `failure_policy` varchar(64)         NOT NULL DEFAULT '' COMMENT 'fail/ignore',
+    `rules`          text COMMENT 'json encoded resource rules',
+    `expression`     text COMMENT 'CEL expression of the policy',
+    `message`        varchar(1024)       NOT NULL DEFAULT '' COMMENT 'denial message',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_group_id` (`group_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
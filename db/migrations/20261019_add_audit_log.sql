@@ -0,0 +1,57 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- append-only audit logs chained by hashes
+CREATE TABLE `tb_audit_log`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `request_id`    varchar(64)         NOT NULL DEFAULT '' COMMENT 'request id',
+    `actor_id`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'user id, 0 if not authenticated',
+    `actor_name`    varchar(128)        NOT NULL DEFAULT '' COMMENT 'user name',
+    `client_ip`     varchar(64)         NOT NULL DEFAULT '' COMMENT 'ip of the client',
+    `user_agent`    varchar(512)        NOT NULL DEFAULT '' COMMENT 'user agent of the client',
+    `method`        varchar(16)         NOT NULL DEFAULT '' COMMENT 'http method',
+    `path`          varchar(2048)       NOT NULL DEFAULT '' COMMENT 'request uri with the query',
+    `verb`          varchar(32)         NOT NULL DEFAULT '' COMMENT 'verb of the request',
+    `api_version`   varchar(16)         NOT NULL DEFAULT '' COMMENT 'api version of the request',
+    `resource`      varchar(64)         NOT NULL DEFAULT '' COMMENT 'resource type',
+    `sub_resource`  varchar(64)         NOT NULL DEFAULT '' COMMENT 'sub resource',
+    `resource_name` varchar(256)        NOT NULL DEFAULT '' COMMENT 'resource id or name',
+    `status_code`   int(11)             NOT NULL DEFAULT '0' COMMENT 'http status code of the response',
+    `request_body`  mediumtext COMMENT 'request body, truncated and masked',
+    `before`        mediumtext COMMENT 'resource before updated or deleted',
+    `diff`          mediumtext COMMENT 'json patch from the resource before to after updated',
+    `prev_hash`     varchar(64)         NOT NULL DEFAULT '' COMMENT 'hash of the previous log',
+    `hash`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'sha256 of the previous hash and the log',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_prev_hash` (`prev_hash`),
+    KEY `idx_actor_name` (`actor_name`, `created_at`),
+    KEY `idx_resource` (`resource`, `resource_name`(128), `created_at`),
+    KEY `idx_created_at` (`created_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- head of the hash chain of audit logs, locked to serialize appends
+CREATE TABLE `tb_audit_log_head`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `log_id`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'id of the latest audit log',
+    `hash`       varchar(64)         NOT NULL DEFAULT '' COMMENT 'hash of the latest audit log',
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,22 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- build cache hits of tasks and indexes of step durations for build insights
+ALTER TABLE `tb_task`
+    ADD COLUMN `cache_hit` tinyint(1) DEFAULT NULL COMMENT 'whether the build cache is hit, null if not reported' AFTER `duration`,
+    ADD KEY `idx_application_started_at` (`application`, `started_at`);
+ALTER TABLE `tb_step`
+    ADD KEY `idx_application_started_at` (`application`, `started_at`);
+ALTER TABLE `tb_pipeline`
+    ADD KEY `idx_started_at` (`started_at`);
@@ -0,0 +1,54 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- canary analysis config of cluster table
+CREATE TABLE `tb_canary_analysis`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id` bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `enabled`    tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether canaries of the cluster are analyzed',
+    `interval`   int(11) unsigned    NOT NULL DEFAULT '0' COMMENT 'seconds a canary step is observed before analysis',
+    `metrics`    text COMMENT 'json of metrics queried from prometheus',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts` bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_cluster_id_deleted_ts` (`cluster_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- canary analysis of pipelinerun table
+CREATE TABLE `tb_canary_analysis_run`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`     bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `pipelinerun_id` bigint(20) unsigned NOT NULL COMMENT 'pipelinerun which deployed the canary',
+    `step`           int(11)             NOT NULL DEFAULT '0' COMMENT 'index of canary step analyzed',
+    `status`         varchar(64)         NOT NULL DEFAULT '' COMMENT 'analyzing/succeeded/failed',
+    `results`        text COMMENT 'json of metric results',
+    `message`        text COMMENT 'reason why the metrics cannot be queried',
+    `errors`         int(11)             NOT NULL DEFAULT '0' COMMENT 'consecutive times the metrics cannot be queried',
+    `started_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'when the step started to be observed',
+    `finished_at`    datetime                     DEFAULT NULL COMMENT 'when the canary was promoted or aborted',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_pipelinerun_id_step` (`pipelinerun_id`, `step`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
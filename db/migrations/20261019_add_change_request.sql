@@ -0,0 +1,51 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- config change request table
+CREATE TABLE `tb_change_request`
+(
+    `id`                 bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`         bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `title`              varchar(256)        NOT NULL DEFAULT '' COMMENT 'title of change request',
+    `description`        varchar(2048)       NOT NULL DEFAULT '' COMMENT 'description of change request',
+    `branch`             varchar(128)        NOT NULL DEFAULT '' COMMENT 'branch of the change in gitops repo',
+    `request`            text COMMENT 'json of the cluster update request',
+    `merge_patch`        tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether to merge the request with current config',
+    `required_approvals` int(11)             NOT NULL DEFAULT '1' COMMENT 'number of approvals required',
+    `status`             varchar(64)         NOT NULL DEFAULT '' COMMENT 'open/merged/rejected/closed',
+    `created_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`         datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`         bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`         bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`         bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_cluster_id` (`cluster_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- reviews of change request table
+CREATE TABLE `tb_change_request_review`
+(
+    `id`                bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `change_request_id` bigint(20) unsigned NOT NULL COMMENT 'change request id',
+    `approved`          tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'approved or rejected',
+    `comment`           varchar(2048)       NOT NULL DEFAULT '' COMMENT 'comment of the review',
+    `created_at`        datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by`        bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reviewer',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_change_request_reviewer` (`change_request_id`, `created_by`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
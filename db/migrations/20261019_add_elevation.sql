@@ -0,0 +1,41 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- elevation table
+CREATE TABLE `tb_elevation`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_type` varchar(64)         NOT NULL DEFAULT '' COMMENT 'resource type',
+    `resource_id`   bigint(20) unsigned NOT NULL COMMENT 'resource id',
+    `user_id`       bigint(20) unsigned NOT NULL COMMENT 'the user who requests the elevation',
+    `role`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'the role requested',
+    `duration`      bigint(20)          NOT NULL DEFAULT '0' COMMENT 'duration of the elevation in nanoseconds',
+    `justification` varchar(1024)       NOT NULL DEFAULT '' COMMENT 'the reason of the elevation',
+    `status`        varchar(64)         NOT NULL DEFAULT '' COMMENT 'pending/approved/rejected/expired',
+    `member_id`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'the member binding of the elevated role',
+    `previous_role` varchar(64)         NOT NULL DEFAULT '' COMMENT 'the direct role held before elevation',
+    `reviewed_by`   bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reviewer',
+    `reviewed_at`   datetime                     DEFAULT NULL COMMENT 'review time',
+    `expired_at`    datetime                     DEFAULT NULL COMMENT 'expiry time of the elevated role',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`    bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_resource` (`resource_type`, `resource_id`),
+    KEY `idx_status_expired_at` (`status`, `expired_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,76 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- gitops repo table, used when gitopsRepo kind is database
+CREATE TABLE `tb_gitops_repo`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `path`           varchar(512)        NOT NULL COMMENT 'full path of the repo',
+    `default_branch` varchar(128)        NOT NULL DEFAULT '' COMMENT 'default branch of the repo',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_path` (`path`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- branches of gitops repo table
+CREATE TABLE `tb_gitops_ref`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `repo_id`      bigint(20) unsigned NOT NULL COMMENT 'gitops repo id',
+    `name`         varchar(128)        NOT NULL COMMENT 'branch name',
+    `commit_sha`   char(40)            NOT NULL COMMENT 'commit the branch points to',
+    `mirrored_sha` char(40)            NOT NULL DEFAULT '' COMMENT 'commit last pushed to the git mirror',
+    `created_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_repo_name` (`repo_id`, `name`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- commits of gitops repo table
+CREATE TABLE `tb_gitops_commit`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `repo_id`      bigint(20) unsigned NOT NULL COMMENT 'gitops repo id',
+    `sha`          char(40)            NOT NULL COMMENT 'git sha of the commit',
+    `tree`         mediumtext          NOT NULL COMMENT 'json map from file path to blob sha',
+    `parents`      varchar(128)        NOT NULL DEFAULT '' COMMENT 'parent commits separated by space',
+    `generation`   int(11)             NOT NULL DEFAULT '0' COMMENT 'max generation of parents plus one',
+    `message`      text COMMENT 'commit message',
+    `author_name`  varchar(128)        NOT NULL DEFAULT '' COMMENT 'author name',
+    `author_email` varchar(256)        NOT NULL DEFAULT '' COMMENT 'author email',
+    `author_time`  bigint(20)          NOT NULL DEFAULT '0' COMMENT 'unix timestamp of the commit',
+    `created_at`   datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_repo_sha` (`repo_id`, `sha`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- file contents of gitops repo table, shared by all the repos
+CREATE TABLE `tb_gitops_blob`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `sha`        char(40)            NOT NULL COMMENT 'git sha of the blob',
+    `content`    longtext COMMENT 'file content',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_sha` (`sha`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
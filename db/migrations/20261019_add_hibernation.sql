@@ -0,0 +1,53 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- hibernation schedule of environment or cluster table
+CREATE TABLE `tb_hibernation_schedule`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_type`  varchar(64)         NOT NULL DEFAULT '' COMMENT 'environments or clusters',
+    `resource_id`    bigint(20) unsigned NOT NULL COMMENT 'id of environment or cluster',
+    `enabled`        tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'whether the schedule is enabled',
+    `hibernate_cron` varchar(128)        NOT NULL DEFAULT '' COMMENT 'cron to hibernate clusters',
+    `wake_cron`      varchar(128)        NOT NULL DEFAULT '' COMMENT 'cron to wake up clusters',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_resource_deleted_ts` (`resource_type`, `resource_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- hibernation state of cluster table
+CREATE TABLE `tb_cluster_hibernation`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`    bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `status`        varchar(64)         NOT NULL DEFAULT '' COMMENT 'hibernated/hibernating/awake',
+    `workloads`     text COMMENT 'json of workloads with their replicas before hibernation',
+    `hibernated_at` datetime                     DEFAULT NULL COMMENT 'last time the cluster was hibernated',
+    `hibernated_by` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'user hibernated, 0 means by schedule',
+    `woken_at`      datetime                     DEFAULT NULL COMMENT 'last time the cluster was woken up',
+    `woken_by`      bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'user woke up, 0 means by schedule',
+    `created_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`    datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`    bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_cluster_id_deleted_ts` (`cluster_id`, `deleted_ts`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,40 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- temporary replicas override of horizontal pod autoscaler table
+CREATE TABLE `tb_hpa_override`
+(
+    `id`                    bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id`            bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `namespace`             varchar(128)        NOT NULL DEFAULT '' COMMENT 'namespace of hpa',
+    `name`                  varchar(256)        NOT NULL DEFAULT '' COMMENT 'name of hpa',
+    `min_replicas`          int(11)                      DEFAULT NULL COMMENT 'min replicas overridden',
+    `max_replicas`          int(11)             NOT NULL DEFAULT '0' COMMENT 'max replicas overridden',
+    `original_min_replicas` int(11)                      DEFAULT NULL COMMENT 'min replicas restored after revert',
+    `original_max_replicas` int(11)             NOT NULL DEFAULT '0' COMMENT 'max replicas restored after revert',
+    `reason`                varchar(512)        NOT NULL DEFAULT '' COMMENT 'why the replicas are overridden',
+    `expire_at`             datetime            NOT NULL COMMENT 'when the override is reverted automatically',
+    `reverted_at`           datetime                     DEFAULT NULL COMMENT 'null means the override is active',
+    `reverted_by`           bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reverter, 0 means reverted after expiration',
+    `created_at`            datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`            datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`            bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`            bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`            bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_cluster_id_name` (`cluster_id`, `name`),
+    KEY `idx_expire_at` (`expire_at`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
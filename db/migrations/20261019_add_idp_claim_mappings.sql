@@ -0,0 +1,19 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- claim mappings of identity providers
+ALTER TABLE `tb_identity_provider`
+    ADD COLUMN `claim_mappings` text COMMENT 'json of rules granting admin or group roles by claims at sign-in';
+ALTER TABLE `tb_idp_user`
+    ADD COLUMN `claim_grants` text COMMENT 'json of admin and group roles granted by claim mappings';
@@ -0,0 +1,42 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- aggregations of kubernetes events
+CREATE TABLE `tb_k8s_event_aggregation`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_id` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'cluster id, 0 if the object belongs to no cluster',
+    `region_id`  bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'region id',
+    `reason`     varchar(128)        NOT NULL DEFAULT '' COMMENT 'reason of events',
+    `kind`       varchar(128)        NOT NULL DEFAULT '' COMMENT 'kind of the involved object',
+    `namespace`  varchar(128)        NOT NULL DEFAULT '' COMMENT 'namespace of the involved object',
+    `name`       varchar(256)        NOT NULL DEFAULT '' COMMENT 'name of the involved object',
+    `type`       varchar(32)         NOT NULL DEFAULT '' COMMENT 'type of events, Normal or Warning',
+    `severity`   varchar(32)         NOT NULL DEFAULT '' COMMENT 'severity of events',
+    `message`    text COMMENT 'message of the latest event',
+    `count`      int(11)             NOT NULL DEFAULT '0' COMMENT 'occurrences of events',
+    `first_seen` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'time of the first event',
+    `last_seen`  datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'time of the latest event',
+    `sources`    text COMMENT 'json of uids of kubernetes events to their counts',
+    `event_id`   bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'id of the horizon event emitted',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts` bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    KEY `idx_object` (`cluster_id`, `reason`, `kind`, `namespace`, `name`(128), `first_seen`),
+    KEY `idx_cluster_last_seen` (`cluster_id`, `last_seen`),
+    KEY `idx_last_seen` (`last_seen`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
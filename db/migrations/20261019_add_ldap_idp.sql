@@ -0,0 +1,33 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- ldap identity provider
+ALTER TABLE `tb_identity_provider`
+    ADD COLUMN `type`        varchar(32) NOT NULL DEFAULT 'oidc' COMMENT 'type of idp, oidc or ldap',
+    ADD COLUMN `ldap_config` text COMMENT 'json of connection, user search and group mappings of ldap idp';
+
+-- memberships of horizon groups granted by the ldap group sync
+CREATE TABLE `tb_idp_ldap_grant`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `group_id`   bigint(20) unsigned NOT NULL COMMENT 'horizon group id',
+    `user_id`    bigint(20) unsigned NOT NULL COMMENT 'user id',
+    `role`       varchar(64)         NOT NULL DEFAULT '' COMMENT 'role of the group granted by the sync',
+    `created_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_group_id_user_id` (`group_id`, `user_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,20 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- PKCE and device authorization grants of oauth
+ALTER TABLE `tb_token`
+    ADD COLUMN `code_challenge`        varchar(256) NOT NULL DEFAULT '' COMMENT 'PKCE code challenge of authorize_code',
+    ADD COLUMN `code_challenge_method` varchar(16)  NOT NULL DEFAULT '' COMMENT 'PKCE code challenge method, plain/S256',
+    ADD COLUMN `user_code`             varchar(16)  NOT NULL DEFAULT '' COMMENT 'user code of device_code',
+    ADD KEY `idx_user_code` (`user_code`);
@@ -0,0 +1,32 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- approvals required by pipelineruns of protected environments before executed
+ALTER TABLE `tb_pipelinerun`
+    ADD COLUMN `required_approvals` int(11) NOT NULL DEFAULT '0' COMMENT 'approvals required before executed' AFTER `rollback_from`;
+
+-- approvals of pipelinerun table
+CREATE TABLE `tb_pipelinerun_approval`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `pipelinerun_id` bigint(20) unsigned NOT NULL COMMENT 'pipelinerun id',
+    `approved`       tinyint(1)          NOT NULL DEFAULT '0' COMMENT 'approved or rejected',
+    `comment`        varchar(2048)       NOT NULL DEFAULT '' COMMENT 'comment of the review',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'reviewer',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_pipelinerun_reviewer` (`pipelinerun_id`, `created_by`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,56 @@
+-- Copyright © 2023 Horizoncd.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- release plan table
+CREATE TABLE `tb_release_plan`
+(
+    `id`             bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `application_id` bigint(20) unsigned NOT NULL COMMENT 'application id',
+    `title`          varchar(256)        NOT NULL DEFAULT '' COMMENT 'title of pipelineruns',
+    `description`    varchar(2048)       NOT NULL DEFAULT '' COMMENT 'description of pipelineruns',
+    `image_tag`      varchar(256)        NOT NULL DEFAULT '' COMMENT 'image tag to deploy',
+    `wave`           int(11)             NOT NULL DEFAULT '0' COMMENT 'index of the wave being deployed',
+    `status`         varchar(64)         NOT NULL DEFAULT '' COMMENT 'running/succeeded/failed/cancelled',
+    `message`        text COMMENT 'reason of the status',
+    `created_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`     datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`     bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    `created_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'creator',
+    `updated_by`     bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'updater',
+    PRIMARY KEY (`id`),
+    KEY `idx_application_id` (`application_id`),
+    KEY `idx_status` (`status`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
+
+-- clusters of release plan table
+CREATE TABLE `tb_release_plan_cluster`
+(
+    `id`              bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `release_plan_id` bigint(20) unsigned NOT NULL COMMENT 'release plan id',
+    `cluster_id`      bigint(20) unsigned NOT NULL COMMENT 'cluster id',
+    `wave`            int(11)             NOT NULL DEFAULT '0' COMMENT 'index of the wave',
+    `pipelinerun_id`  bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'pipelinerun of the deployment',
+    `status`          varchar(64)         NOT NULL DEFAULT '' COMMENT 'pending/deploying/succeeded/failed',
+    `message`         text COMMENT 'reason of the status',
+    `started_at`      datetime                     DEFAULT NULL COMMENT 'deploy time',
+    `created_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`      datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `deleted_ts`      bigint(20)                   DEFAULT '0' COMMENT 'deleted timestamp, 0 means not deleted',
+    PRIMARY KEY (`id`),
+    KEY `idx_release_plan_id` (`release_plan_id`)
+) ENGINE = InnoDB
+  AUTO_INCREMENT = 1
+  DEFAULT CHARSET = utf8mb4;
@@ -41,6 +41,7 @@ import (
 	oauthdao "github.com/horizoncd/horizon/pkg/oauth/dao"
 	oauthmanager "github.com/horizoncd/horizon/pkg/oauth/manager"
 	"github.com/horizoncd/horizon/pkg/oauth/models"
+	"github.com/horizoncd/horizon/pkg/oauth/oidc"
 	"github.com/horizoncd/horizon/pkg/oauth/scope"
 	"github.com/horizoncd/horizon/pkg/param"
 	"github.com/horizoncd/horizon/pkg/param/managerparam"
@@ -150,14 +151,18 @@ func TestServer(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, authGetApp.ClientID, authApp.ClientID)
 
-	oauthServerController := oauth.NewController(&param.Param{Manager: manager, OauthManager: oauthManager})
+	oidcService, err := oidc.NewService(oauthconfig.OIDCProvider{}, manager)
+	assert.Nil(t, err)
+	oauthServerController := oauth.NewController(&param.Param{Manager: manager, OauthManager: oauthManager,
+		OIDCService: oidcService})
 
 	oauthAppController := oauthapp.NewController(&param.Param{Manager: manager})
 
 	authScopeService, err := scope.NewFileScopeService(createOauthScopeConfig())
 	assert.Nil(t, err)
 
-	api := oauthserver.NewAPI(oauthServerController, oauthAppController, "authFileLoc", authScopeService)
+	api := oauthserver.NewAPI(oauthServerController, oauthAppController, "authFileLoc", authScopeService,
+		oidcService)
 
 	userMiddleWare := func(c *gin.Context) {
 		common.SetUser(c, aUser)
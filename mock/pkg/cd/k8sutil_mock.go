@@ -6,6 +6,7 @@ package mock_cd
 
 import (
 	context "context"
+	http "net/http"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -110,6 +111,21 @@ func (mr *MockK8sUtilMockRecorder) GetPod(ctx, params interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPod", reflect.TypeOf((*MockK8sUtil)(nil).GetPod), ctx, params)
 }
 
+// GetPodProxy mocks base method.
+func (m *MockK8sUtil) GetPodProxy(ctx context.Context, params *cd.GetPodProxyParams) (http.Handler, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPodProxy", ctx, params)
+	ret0, _ := ret[0].(http.Handler)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPodProxy indicates an expected call of GetPodProxy.
+func (mr *MockK8sUtilMockRecorder) GetPodProxy(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPodProxy", reflect.TypeOf((*MockK8sUtil)(nil).GetPodProxy), ctx, params)
+}
+
 // GetPodContainers mocks base method.
 func (m *MockK8sUtil) GetPodContainers(ctx context.Context, params *cd.GetPodParams) ([]cd.ContainerDetail, error) {
 	m.ctrl.T.Helper()
@@ -124,3 +140,76 @@ func (mr *MockK8sUtilMockRecorder) GetPodContainers(ctx, params interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPodContainers", reflect.TypeOf((*MockK8sUtil)(nil).GetPodContainers), ctx, params)
 }
+
+// ListJobRuns mocks base method.
+func (m *MockK8sUtil) ListJobRuns(ctx context.Context, params *cd.ListJobRunsParams) ([]cd.JobRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJobRuns", ctx, params)
+	ret0, _ := ret[0].([]cd.JobRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJobRuns indicates an expected call of ListJobRuns.
+func (mr *MockK8sUtilMockRecorder) ListJobRuns(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobRuns", reflect.TypeOf((*MockK8sUtil)(nil).ListJobRuns), ctx, params)
+}
+
+// GetHPAStatus mocks base method.
+func (m *MockK8sUtil) GetHPAStatus(ctx context.Context, params *cd.GetHPAParams) (*cd.HPAStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHPAStatus", ctx, params)
+	ret0, _ := ret[0].(*cd.HPAStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHPAStatus indicates an expected call of GetHPAStatus.
+func (mr *MockK8sUtilMockRecorder) GetHPAStatus(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHPAStatus", reflect.TypeOf((*MockK8sUtil)(nil).GetHPAStatus), ctx, params)
+}
+
+// UpdateHPAReplicas mocks base method.
+func (m *MockK8sUtil) UpdateHPAReplicas(ctx context.Context, params *cd.UpdateHPAReplicasParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHPAReplicas", ctx, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateHPAReplicas indicates an expected call of UpdateHPAReplicas.
+func (mr *MockK8sUtilMockRecorder) UpdateHPAReplicas(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHPAReplicas", reflect.TypeOf((*MockK8sUtil)(nil).UpdateHPAReplicas), ctx, params)
+}
+
+// ScaleWorkload mocks base method.
+func (m *MockK8sUtil) ScaleWorkload(ctx context.Context, params *cd.ScaleWorkloadParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScaleWorkload", ctx, params)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScaleWorkload indicates an expected call of ScaleWorkload.
+func (mr *MockK8sUtilMockRecorder) ScaleWorkload(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScaleWorkload", reflect.TypeOf((*MockK8sUtil)(nil).ScaleWorkload), ctx, params)
+}
+
+// PortForward mocks base method.
+func (m *MockK8sUtil) PortForward(ctx context.Context, params *cd.PortForwardParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PortForward", ctx, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PortForward indicates an expected call of PortForward.
+func (mr *MockK8sUtilMockRecorder) PortForward(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PortForward", reflect.TypeOf((*MockK8sUtil)(nil).PortForward), ctx, params)
+}
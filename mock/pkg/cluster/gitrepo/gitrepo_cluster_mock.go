@@ -242,6 +242,21 @@ func (mr *MockClusterGitRepoMockRecorder) GetRestartTime(ctx, application, clust
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRestartTime", reflect.TypeOf((*MockClusterGitRepo)(nil).GetRestartTime), ctx, application, cluster, template)
 }
 
+// GetValues mocks base method.
+func (m *MockClusterGitRepo) GetValues(ctx context.Context, application, cluster string, commit *string) (map[string]interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetValues", ctx, application, cluster, commit)
+	ret0, _ := ret[0].(map[string]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetValues indicates an expected call of GetValues.
+func (mr *MockClusterGitRepoMockRecorder) GetValues(ctx, application, cluster, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValues", reflect.TypeOf((*MockClusterGitRepo)(nil).GetValues), ctx, application, cluster, commit)
+}
+
 // HardDeleteCluster mocks base method.
 func (m *MockClusterGitRepo) HardDeleteCluster(ctx context.Context, application, cluster string) error {
 	m.ctrl.T.Helper()
@@ -97,3 +97,33 @@ func (mr *MockInterfaceMockRecorder) GetPipelineRunObject(ctx, object interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPipelineRunObject", reflect.TypeOf((*MockInterface)(nil).GetPipelineRunObject), ctx, object)
 }
+
+// GetTerminalRecording mocks base method.
+func (m *MockInterface) GetTerminalRecording(ctx context.Context, object string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTerminalRecording", ctx, object)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTerminalRecording indicates an expected call of GetTerminalRecording.
+func (mr *MockInterfaceMockRecorder) GetTerminalRecording(ctx, object interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTerminalRecording", reflect.TypeOf((*MockInterface)(nil).GetTerminalRecording), ctx, object)
+}
+
+// PutTerminalRecording mocks base method.
+func (m *MockInterface) PutTerminalRecording(ctx context.Context, recording *collector.TerminalRecording) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutTerminalRecording", ctx, recording)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutTerminalRecording indicates an expected call of PutTerminalRecording.
+func (mr *MockInterfaceMockRecorder) PutTerminalRecording(ctx, recording interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutTerminalRecording", reflect.TypeOf((*MockInterface)(nil).PutTerminalRecording), ctx, recording)
+}
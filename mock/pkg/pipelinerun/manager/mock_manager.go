@@ -190,6 +190,21 @@ func (mr *MockPipelineRunManagerMockRecorder) GetLatestSuccessByClusterID(ctx, c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestSuccessByClusterID", reflect.TypeOf((*MockPipelineRunManager)(nil).GetLatestSuccessByClusterID), ctx, clusterID)
 }
 
+// ListDeployments mocks base method.
+func (m *MockPipelineRunManager) ListDeployments(ctx context.Context, query *models.DeploymentQuery) ([]*models.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeployments", ctx, query)
+	ret0, _ := ret[0].([]*models.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeployments indicates an expected call of ListDeployments.
+func (mr *MockPipelineRunManagerMockRecorder) ListDeployments(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeployments", reflect.TypeOf((*MockPipelineRunManager)(nil).ListDeployments), ctx, query)
+}
+
 // UpdateCIEventIDByID mocks base method.
 func (m *MockPipelineRunManager) UpdateCIEventIDByID(ctx context.Context, pipelinerunID uint, ciEventID string) error {
 	m.ctrl.T.Helper()
@@ -109,3 +109,17 @@ func (mr *MockManagerMockRecorder) ListByUserID(ctx, uid interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserID", reflect.TypeOf((*MockManager)(nil).ListByUserID), ctx, uid)
 }
+
+// UpdateClaimGrants mocks base method.
+func (m *MockManager) UpdateClaimGrants(ctx context.Context, id uint, grants *models.ClaimGrants) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateClaimGrants", ctx, id, grants)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimGrants indicates an expected call of UpdateClaimGrants.
+func (mr *MockManagerMockRecorder) UpdateClaimGrants(ctx, id, grants interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateClaimGrants", reflect.TypeOf((*MockManager)(nil).UpdateClaimGrants), ctx, id, grants)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/admission/models"
+)
+
+type DAO interface {
+	Create(ctx context.Context, policy *models.Policy) (*models.Policy, error)
+	GetByID(ctx context.Context, id uint) (*models.Policy, error)
+	ListByGroupIDs(ctx context.Context, groupIDs []uint) ([]*models.Policy, error)
+	UpdateByID(ctx context.Context, id uint, policy *models.Policy) (*models.Policy, error)
+	DeleteByID(ctx context.Context, id uint) error
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Create(ctx context.Context, policy *models.Policy) (*models.Policy, error) {
+	if err := d.db.WithContext(ctx).Create(policy).Error; err != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.AdmissionPolicyInDB, err.Error())
+	}
+	return policy, nil
+}
+
+func (d *dao) GetByID(ctx context.Context, id uint) (*models.Policy, error) {
+	var policy models.Policy
+	if err := d.db.WithContext(ctx).First(&policy, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.AdmissionPolicyInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.AdmissionPolicyInDB, err.Error())
+	}
+	return &policy, nil
+}
+
+func (d *dao) ListByGroupIDs(ctx context.Context, groupIDs []uint) ([]*models.Policy, error) {
+	var policies []*models.Policy
+	if len(groupIDs) == 0 {
+		return policies, nil
+	}
+	if err := d.db.WithContext(ctx).Where("group_id in ?", groupIDs).
+		Order("id asc").Find(&policies).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.AdmissionPolicyInDB, err.Error())
+	}
+	return policies, nil
+}
+
+func (d *dao) UpdateByID(ctx context.Context, id uint, policy *models.Policy) (*models.Policy, error) {
+	if err := d.db.WithContext(ctx).Model(&models.Policy{}).Where("id = ?", id).
+		Select("name", "kind", "mode", "failure_policy", "rules", "expression", "message").
+		Updates(policy).Error; err != nil {
+		return nil, herrors.NewErrUpdateFailed(herrors.AdmissionPolicyInDB, err.Error())
+	}
+	return d.GetByID(ctx, id)
+}
+
+func (d *dao) DeleteByID(ctx context.Context, id uint) error {
+	if err := d.db.WithContext(ctx).Delete(&models.Policy{}, id).Error; err != nil {
+		return herrors.NewErrDeleteFailed(herrors.AdmissionPolicyInDB, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	corecommon "github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	admissionmanager "github.com/horizoncd/horizon/pkg/admission/manager"
+	"github.com/horizoncd/horizon/pkg/admission/models"
+	appmanager "github.com/horizoncd/horizon/pkg/application/manager"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	config "github.com/horizoncd/horizon/pkg/config/admission"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	"github.com/horizoncd/horizon/pkg/util/common"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// GroupPolicyWebhook evaluates the policies stored in the group of the requested resource
+// and in all its ancestor groups
+type GroupPolicyWebhook struct {
+	kind           models.Kind
+	policyMgr      admissionmanager.Manager
+	groupMgr       groupmanager.Manager
+	applicationMgr appmanager.Manager
+	clusterMgr     clustermanager.Manager
+
+	// compiled caches the compiled policies by policy id
+	compiled sync.Map
+}
+
+type compiledPolicy struct {
+	updatedAt time.Time
+	policy    *Policy
+}
+
+var _ Webhook = (*GroupPolicyWebhook)(nil)
+
+// NewGroupPolicyWebhooks registers the webhooks evaluating policies stored in groups
+func NewGroupPolicyWebhooks(manager *managerparam.Manager) {
+	Register(models.KindMutating, NewGroupPolicyWebhook(models.KindMutating, manager))
+	Register(models.KindValidating, NewGroupPolicyWebhook(models.KindValidating, manager))
+}
+
+func NewGroupPolicyWebhook(kind models.Kind, manager *managerparam.Manager) *GroupPolicyWebhook {
+	return &GroupPolicyWebhook{
+		kind:           kind,
+		policyMgr:      manager.AdmissionPolicyMgr,
+		groupMgr:       manager.GroupMgr,
+		applicationMgr: manager.ApplicationMgr,
+		clusterMgr:     manager.ClusterMgr,
+	}
+}
+
+// PolicyConfigFromModel converts the stored policy into policy config
+func PolicyConfigFromModel(policy *models.Policy) (config.Policy, error) {
+	var rules []config.Rule
+	if policy.Rules != "" {
+		if err := json.Unmarshal([]byte(policy.Rules), &rules); err != nil {
+			return config.Policy{}, perror.Wrapf(herrors.ErrParamInvalid,
+				"invalid rules of policy %s: %v", policy.Name, err)
+		}
+	}
+	return config.Policy{
+		Name:          policy.Name,
+		Kind:          policy.Kind,
+		Mode:          config.PolicyMode(policy.Mode),
+		FailurePolicy: config.FailurePolicy(policy.FailurePolicy),
+		Rules:         rules,
+		Expression:    policy.Expression,
+		Message:       policy.Message,
+	}, nil
+}
+
+func (w *GroupPolicyWebhook) Name() string {
+	return "group-policies"
+}
+
+func (w *GroupPolicyWebhook) Handle(ctx context.Context, req *Request) (*Response, error) {
+	policies, err := w.listPolicies(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	object := req.Object
+	for _, policy := range policies {
+		if !policy.Interest(req) {
+			continue
+		}
+		evaluated := *req
+		evaluated.Object = object
+		resp, err := policy.Handle(ctx, &evaluated)
+		if err != nil {
+			if policy.IgnoreError() {
+				log.Warningf(ctx, "failed to evaluate policy %s: %v", policy.Name(), err)
+				continue
+			}
+			return nil, err
+		}
+		if w.kind == models.KindValidating {
+			if resp.Allowed != nil && !*resp.Allowed {
+				return resp, nil
+			}
+			continue
+		}
+		if resp.Patch != nil {
+			if object, err = jsonPatch(object, resp.Patch); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if w.kind == models.KindValidating {
+		return &Response{Allowed: common.BoolPtr(true)}, nil
+	}
+	return mergedPatch(req.Object, object)
+}
+
+func (w *GroupPolicyWebhook) IgnoreError() bool {
+	return false
+}
+
+func (w *GroupPolicyWebhook) Timeout() time.Duration {
+	return DefaultTimeout
+}
+
+func (w *GroupPolicyWebhook) Interest(req *Request) bool {
+	switch req.Resource {
+	case corecommon.ResourceGroup, corecommon.ResourceApplication, corecommon.ResourceCluster:
+		return true
+	}
+	return false
+}
+
+// listPolicies lists the compiled policies of the specified kind which apply to the requested resource
+func (w *GroupPolicyWebhook) listPolicies(ctx context.Context, req *Request) ([]*Policy, error) {
+	groupIDs, err := w.groupIDs(ctx, req)
+	if err != nil || len(groupIDs) == 0 {
+		return nil, err
+	}
+	policyModels, err := w.policyMgr.ListByGroupIDs(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]*Policy, 0, len(policyModels))
+	for _, policyModel := range policyModels {
+		if !policyModel.Kind.Eq(w.kind) {
+			continue
+		}
+		if cached, ok := w.compiled.Load(policyModel.ID); ok &&
+			cached.(*compiledPolicy).updatedAt.Equal(policyModel.UpdatedAt) {
+			policies = append(policies, cached.(*compiledPolicy).policy)
+			continue
+		}
+		policyConfig, err := PolicyConfigFromModel(policyModel)
+		if err != nil {
+			return nil, err
+		}
+		policy, err := CompilePolicy(policyConfig)
+		if err != nil {
+			return nil, err
+		}
+		w.compiled.Store(policyModel.ID, &compiledPolicy{updatedAt: policyModel.UpdatedAt, policy: policy})
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// groupIDs returns the ids of the group that the requested resource belongs to and its ancestors
+func (w *GroupPolicyWebhook) groupIDs(ctx context.Context, req *Request) ([]uint, error) {
+	id, err := strconv.ParseUint(req.Name, 10, 0)
+	if err != nil || id == 0 {
+		return nil, nil
+	}
+	groupID := uint(id)
+	switch req.Resource {
+	case corecommon.ResourceCluster:
+		cluster, err := w.clusterMgr.GetByID(ctx, groupID)
+		if err != nil {
+			return nil, ignoreNotFound(err)
+		}
+		groupID = cluster.ApplicationID
+		fallthrough
+	case corecommon.ResourceApplication:
+		application, err := w.applicationMgr.GetByID(ctx, groupID)
+		if err != nil {
+			return nil, ignoreNotFound(err)
+		}
+		groupID = application.GroupID
+	}
+	group, err := w.groupMgr.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, ignoreNotFound(err)
+	}
+	return groupmanager.FormatIDsFromTraversalIDs(group.TraversalIDs), nil
+}
+
+// ignoreNotFound leaves the not found resource to be handled by the api itself
+func ignoreNotFound(err error) error {
+	if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+		return nil
+	}
+	return err
+}
+
+// mergedPatch creates the json patch from the original object to the mutated one
+func mergedPatch(original, mutated interface{}) (*Response, error) {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	mutatedJSON, err := json.Marshal(mutated)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := createPatch(originalJSON, mutatedJSON)
+	if err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return &Response{}, nil
+	}
+	return &Response{Patch: patch, PatchType: models.PatchTypeJSONPatch}, nil
+}
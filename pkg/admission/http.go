@@ -201,6 +201,14 @@ func NewHTTPWebhook(config config.Webhook) Webhook {
 	}
 }
 
+// Name returns the name of the webhook, which is its url if not configured
+func (m *HTTPAdmissionWebhook) Name() string {
+	if m.config.Name != "" {
+		return m.config.Name
+	}
+	return m.config.ClientConfig.URL
+}
+
 // Handle handles the admission request and returns the response
 func (m *HTTPAdmissionWebhook) Handle(ctx context.Context, req *Request) (*Response, error) {
 	resp, err := m.httpclient.Get(ctx, req)
@@ -215,6 +223,14 @@ func (m *HTTPAdmissionWebhook) IgnoreError() bool {
 	return m.config.FailurePolicy.Eq(config.FailurePolicyIgnore)
 }
 
+// Timeout returns the timeout of calling the webhook, DefaultTimeout if not configured
+func (m *HTTPAdmissionWebhook) Timeout() time.Duration {
+	if m.config.Timeout == 0 {
+		return DefaultTimeout
+	}
+	return m.config.Timeout
+}
+
 // Interest returns true if the request matches the webhook
 func (m *HTTPAdmissionWebhook) Interest(req *Request) bool {
 	return m.matchers.Match(req)
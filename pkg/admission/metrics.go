@@ -0,0 +1,52 @@
+package admission
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/horizoncd/horizon/pkg/admission/models"
+)
+
+const (
+	_webhookLabel = "webhook"
+	_kindLabel    = "kind"
+	_resultLabel  = "result"
+
+	resultAllowed = "allowed"
+	resultDenied  = "denied"
+	resultMutated = "mutated"
+	resultError   = "error"
+)
+
+var (
+	webhookHistogram = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "horizon_admission_webhook_duration_seconds",
+			Help:    "horizon admission webhook duration seconds histogram.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{_webhookLabel, _kindLabel},
+	)
+
+	webhookCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "horizon_admission_webhook_total",
+			Help: "horizon admission webhook total counter by result (allowed/denied/mutated/error).",
+		},
+		[]string{_webhookLabel, _kindLabel, _resultLabel},
+	)
+)
+
+func observe(webhook Webhook, kind models.Kind, result string, duration time.Duration) {
+	webhookHistogram.With(prometheus.Labels{
+		_webhookLabel: webhook.Name(),
+		_kindLabel:    kind.String(),
+	}).Observe(duration.Seconds())
+	webhookCounter.With(prometheus.Labels{
+		_webhookLabel: webhook.Name(),
+		_kindLabel:    kind.String(),
+		_resultLabel:  result,
+	}).Inc()
+}
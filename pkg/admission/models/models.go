@@ -1,6 +1,9 @@
 package models
 
-import "strings"
+import (
+	"context"
+	"strings"
+)
 
 type Kind string
 
@@ -14,6 +17,11 @@ func (k Kind) Eq(other Kind) bool {
 
 type Operation string
 
+// AdmitFunc passes the object of an operation on the resource through the admission webhooks,
+// and returns the object mutated by them
+type AdmitFunc func(ctx context.Context, operation Operation, resource, name, subResource, version string,
+	object interface{}, options map[string]interface{}) (interface{}, error)
+
 func (o Operation) Eq(other Operation) bool {
 	return strings.EqualFold(string(o), string(other))
 }
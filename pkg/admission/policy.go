@@ -0,0 +1,302 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	patchcreator "github.com/mattbaird/jsonpatch"
+	"google.golang.org/protobuf/types/known/structpb"
+	jsonpatch "gopkg.in/evanphx/json-patch.v5"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/admission/models"
+	config "github.com/horizoncd/horizon/pkg/config/admission"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/util/common"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+const (
+	policyVarObject    = "object"
+	policyVarOldObject = "oldObject"
+	policyVarRequest   = "request"
+)
+
+// Policy is a CEL policy evaluated in-process, it works as a webhook
+type Policy struct {
+	config   config.Policy
+	program  cel.Program
+	matchers ResourceMatchers
+}
+
+var _ Webhook = (*Policy)(nil)
+
+// NewPolicies compiles and registers the policies declared in config
+func NewPolicies(config config.Admission) error {
+	for _, policyConfig := range config.Policies {
+		policy, err := CompilePolicy(policyConfig)
+		if err != nil {
+			return err
+		}
+		Register(policy.Kind(), policy)
+	}
+	return nil
+}
+
+// CompilePolicy validates the policy config and compiles its expression
+func CompilePolicy(policy config.Policy) (*Policy, error) {
+	switch {
+	case policy.Kind.Eq(models.KindValidating):
+		policy.Kind = models.KindValidating
+	case policy.Kind.Eq(models.KindMutating):
+		policy.Kind = models.KindMutating
+	default:
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"invalid kind of policy %s: %s", policy.Name, policy.Kind)
+	}
+	switch {
+	case policy.Mode == "" || policy.Mode.Eq(config.PolicyModeEnforce):
+		policy.Mode = config.PolicyModeEnforce
+	case policy.Mode.Eq(config.PolicyModeAudit):
+		policy.Mode = config.PolicyModeAudit
+	default:
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"invalid mode of policy %s: %s", policy.Name, policy.Mode)
+	}
+	switch {
+	case policy.FailurePolicy == "" || policy.FailurePolicy.Eq(config.FailurePolicyFail):
+		policy.FailurePolicy = config.FailurePolicyFail
+	case policy.FailurePolicy.Eq(config.FailurePolicyIgnore):
+		policy.FailurePolicy = config.FailurePolicyIgnore
+	default:
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"invalid failure policy of policy %s: %s", policy.Name, policy.FailurePolicy)
+	}
+
+	env, err := cel.NewEnv(
+		ext.Strings(),
+		cel.Declarations(
+			decls.NewVar(policyVarObject, decls.Dyn),
+			decls.NewVar(policyVarOldObject, decls.Dyn),
+			decls.NewVar(policyVarRequest, decls.NewMapType(decls.String, decls.Dyn)),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(policy.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"failed to compile expression of policy %s: %v", policy.Name, issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"failed to compile expression of policy %s: %v", policy.Name, err)
+	}
+	return &Policy{
+		config:   policy,
+		program:  program,
+		matchers: NewResourceMatchers(policy.Rules),
+	}, nil
+}
+
+func (p *Policy) Name() string {
+	return p.config.Name
+}
+
+func (p *Policy) Kind() models.Kind {
+	return p.config.Kind
+}
+
+// Config returns the normalized config of the policy
+func (p *Policy) Config() config.Policy {
+	return p.config
+}
+
+func (p *Policy) Handle(ctx context.Context, req *Request) (*Response, error) {
+	object, err := toPolicyValue(req.Object)
+	if err != nil {
+		return nil, err
+	}
+	oldObject, err := toPolicyValue(req.OldObject)
+	if err != nil {
+		return nil, err
+	}
+	options, err := toPolicyValue(req.Options)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := p.program.Eval(map[string]interface{}{
+		policyVarObject:    object,
+		policyVarOldObject: oldObject,
+		policyVarRequest: map[string]interface{}{
+			"operation":   string(req.Operation),
+			"resource":    req.Resource,
+			"name":        req.Name,
+			"subResource": req.SubResource,
+			"version":     req.Version,
+			"options":     options,
+		},
+	})
+	if err != nil {
+		return nil, perror.Wrapf(err, "failed to evaluate policy %s", p.config.Name)
+	}
+	if p.config.Kind == models.KindMutating {
+		return p.mutate(ctx, req, object, out)
+	}
+	return p.validate(ctx, req, out)
+}
+
+func (p *Policy) validate(ctx context.Context, req *Request, out ref.Val) (*Response, error) {
+	var message string
+	switch value := out.Value().(type) {
+	case bool:
+		if value {
+			return &Response{Allowed: common.BoolPtr(true)}, nil
+		}
+		message = p.config.Message
+		if message == "" {
+			message = "failed expression: " + p.config.Expression
+		}
+	case string:
+		if value == "" {
+			return &Response{Allowed: common.BoolPtr(true)}, nil
+		}
+		message = value
+	default:
+		return nil, perror.Errorf("policy %s should return bool or string, but got %s",
+			p.config.Name, out.Type().TypeName())
+	}
+	message = "policy " + p.config.Name + ": " + message
+
+	if p.config.Mode == config.PolicyModeAudit {
+		log.Warningf(ctx,
+			"[audit] request (resource: %s, resourceName: %s, subresource: %s, operation: %s) "+
+				"would be denied by %s", req.Resource, req.Name, req.SubResource, req.Operation, message)
+		return &Response{Allowed: common.BoolPtr(true)}, nil
+	}
+	return &Response{Allowed: common.BoolPtr(false), Result: message}, nil
+}
+
+func (p *Policy) mutate(ctx context.Context, req *Request, object interface{}, out ref.Val) (*Response, error) {
+	value, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, perror.Wrapf(err, "policy %s should return a map", p.config.Name)
+	}
+	merge, ok := value.(*structpb.Value).AsInterface().(map[string]interface{})
+	if !ok {
+		return nil, perror.Errorf("policy %s should return a map, but got %s",
+			p.config.Name, out.Type().TypeName())
+	}
+	mergePatch, err := json.Marshal(merge)
+	if err != nil {
+		return nil, err
+	}
+	original, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	mutated, err := jsonpatch.MergePatch(original, mergePatch)
+	if err != nil {
+		return nil, perror.Wrapf(err, "failed to apply the result of policy %s", p.config.Name)
+	}
+	patch, err := createPatch(original, mutated)
+	if err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return &Response{}, nil
+	}
+
+	if p.config.Mode == config.PolicyModeAudit {
+		log.Infof(ctx,
+			"[audit] request (resource: %s, resourceName: %s, subresource: %s, operation: %s) "+
+				"would be mutated by policy %s with patch: %s",
+			req.Resource, req.Name, req.SubResource, req.Operation, p.config.Name, patch)
+		return &Response{}, nil
+	}
+	return &Response{Patch: patch, PatchType: models.PatchTypeJSONPatch}, nil
+}
+
+func (p *Policy) IgnoreError() bool {
+	return p.config.FailurePolicy.Eq(config.FailurePolicyIgnore)
+}
+
+// Timeout of policies is the default one since they are evaluated in-process
+func (p *Policy) Timeout() time.Duration {
+	return DefaultTimeout
+}
+
+func (p *Policy) Interest(req *Request) bool {
+	return p.matchers.Match(req)
+}
+
+// createPatch creates the json patch from the original json to the mutated one,
+// nil is returned if they are the same
+func createPatch(original, mutated []byte) ([]byte, error) {
+	operations, err := patchcreator.CreatePatch(original, mutated)
+	if err != nil {
+		return nil, err
+	}
+	if len(operations) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(operations)
+}
+
+// toPolicyValue converts the object into json values, whole numbers are converted into int
+// so that they can be compared with int literals in expressions
+func toPolicyValue(object interface{}) (interface{}, error) {
+	if object == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return normalizeNumber(value), nil
+}
+
+func normalizeNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int64(v)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeNumber(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeNumber(item)
+		}
+	}
+	return value
+}
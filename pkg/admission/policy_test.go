@@ -0,0 +1,212 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corecommon "github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/lib/orm"
+	"github.com/horizoncd/horizon/pkg/admission/models"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	admissionconfig "github.com/horizoncd/horizon/pkg/config/admission"
+	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+)
+
+func TestPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	rules := []admissionconfig.Rule{
+		{
+			Resources:  []string{"applications/clusters"},
+			Operations: []models.Operation{models.OperationCreate},
+			Versions:   []string{models.MatchAll},
+		},
+	}
+	createRequest := func(replicas int, image string) *Request {
+		return &Request{
+			Operation:   models.OperationCreate,
+			Resource:    "applications",
+			Name:        "1",
+			SubResource: "clusters",
+			Version:     "v2",
+			Object: map[string]interface{}{
+				"name":     "cluster-1",
+				"replicas": replicas,
+				"image":    image,
+			},
+			Options: map[string]interface{}{
+				"scope": "online/hz",
+			},
+		}
+	}
+
+	// invalid policies
+	_, err := CompilePolicy(admissionconfig.Policy{
+		Name:       "invalid-kind",
+		Kind:       "unknown",
+		Expression: "true",
+	})
+	assert.Error(t, err)
+	_, err = CompilePolicy(admissionconfig.Policy{
+		Name:       "invalid-expression",
+		Kind:       models.KindValidating,
+		Expression: "object.replicas >=",
+	})
+	assert.Error(t, err)
+
+	// validating
+	replicas, err := CompilePolicy(admissionconfig.Policy{
+		Name:       "online-replicas",
+		Kind:       models.KindValidating,
+		Rules:      rules,
+		Expression: `!request.options.scope.startsWith("online") || object.replicas >= 2`,
+		Message:    "online clusters must have at least 2 replicas",
+	})
+	assert.NoError(t, err)
+	assert.True(t, replicas.Interest(createRequest(1, "")))
+	assert.False(t, replicas.Interest(&Request{Operation: models.OperationUpdate, Resource: "clusters"}))
+
+	resp, err := replicas.Handle(ctx, createRequest(1, ""))
+	assert.NoError(t, err)
+	assert.False(t, *resp.Allowed)
+	assert.Equal(t, "policy online-replicas: online clusters must have at least 2 replicas", resp.Result)
+
+	resp, err = replicas.Handle(ctx, createRequest(2, ""))
+	assert.NoError(t, err)
+	assert.True(t, *resp.Allowed)
+
+	registry, err := CompilePolicy(admissionconfig.Policy{
+		Name:  "image-registry",
+		Kind:  models.KindValidating,
+		Rules: rules,
+		Expression: `object.image.startsWith("registry.example.com/") ? "" : ` +
+			`"image " + object.image + " is not from registry.example.com"`,
+	})
+	assert.NoError(t, err)
+	resp, err = registry.Handle(ctx, createRequest(2, "docker.io/nginx"))
+	assert.NoError(t, err)
+	assert.False(t, *resp.Allowed)
+	assert.Equal(t, "policy image-registry: image docker.io/nginx is not from registry.example.com", resp.Result)
+
+	// audit mode never denies
+	audit, err := CompilePolicy(admissionconfig.Policy{
+		Name:       "audit-replicas",
+		Kind:       models.KindValidating,
+		Mode:       admissionconfig.PolicyModeAudit,
+		Rules:      rules,
+		Expression: "object.replicas >= 2",
+	})
+	assert.NoError(t, err)
+	resp, err = audit.Handle(ctx, createRequest(1, ""))
+	assert.NoError(t, err)
+	assert.True(t, *resp.Allowed)
+
+	// evaluation error respects the failure policy
+	missing, err := CompilePolicy(admissionconfig.Policy{
+		Name:          "missing-field",
+		Kind:          models.KindValidating,
+		FailurePolicy: admissionconfig.FailurePolicyIgnore,
+		Rules:         rules,
+		Expression:    "object.notExist == 1",
+	})
+	assert.NoError(t, err)
+	_, err = missing.Handle(ctx, createRequest(1, ""))
+	assert.Error(t, err)
+	assert.True(t, missing.IgnoreError())
+
+	// mutating
+	mutating, err := CompilePolicy(admissionconfig.Policy{
+		Name:       "default-replicas",
+		Kind:       models.KindMutating,
+		Rules:      rules,
+		Expression: `object.replicas < 2 ? {"replicas": 2, "tags": [{"key": "scope", "value": "online"}]} : {}`,
+	})
+	assert.NoError(t, err)
+	req := createRequest(1, "")
+	resp, err = mutating.Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PatchTypeJSONPatch, resp.PatchType)
+	obj, err := jsonPatch(req.Object, resp.Patch)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), obj.(map[string]interface{})["replicas"])
+	assert.Equal(t, 1, len(obj.(map[string]interface{})["tags"].([]interface{})))
+
+	resp, err = mutating.Handle(ctx, createRequest(3, ""))
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Patch)
+}
+
+func TestGroupPolicyWebhook(t *testing.T) {
+	ctx := context.WithValue(context.Background(), corecommon.UserContextKey(), &userauth.DefaultInfo{
+		Name: "tony",
+		ID:   1,
+	})
+	db, _ := orm.NewSqliteDB("")
+	assert.NoError(t, db.AutoMigrate(&groupmodels.Group{}, &appmodels.Application{},
+		&membermodels.Member{}, &models.Policy{}))
+	manager := managerparam.InitManager(db)
+
+	parent, err := manager.GroupMgr.Create(ctx, &groupmodels.Group{Name: "parent", Path: "parent"})
+	assert.NoError(t, err)
+	child, err := manager.GroupMgr.Create(ctx, &groupmodels.Group{
+		Name: "child", Path: "child", ParentID: parent.ID,
+	})
+	assert.NoError(t, err)
+	application, err := manager.ApplicationMgr.Create(ctx, &appmodels.Application{
+		GroupID: child.ID, Name: "app",
+	}, nil)
+	assert.NoError(t, err)
+
+	_, err = manager.AdmissionPolicyMgr.Create(ctx, &models.Policy{
+		GroupID:    parent.ID,
+		Name:       "online-replicas",
+		Kind:       models.KindValidating,
+		Rules:      `[{"resources":["applications/clusters"],"operations":["create"],"versions":["*"]}]`,
+		Expression: "object.replicas >= 2",
+	})
+	assert.NoError(t, err)
+
+	webhook := NewGroupPolicyWebhook(models.KindValidating, manager)
+	req := &Request{
+		Operation:   models.OperationCreate,
+		Resource:    "applications",
+		Name:        strconv.Itoa(int(application.ID)),
+		SubResource: "clusters",
+		Version:     "v2",
+		Object:      map[string]interface{}{"replicas": 1},
+	}
+	assert.True(t, webhook.Interest(req))
+	resp, err := webhook.Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.False(t, *resp.Allowed)
+
+	req.Object = map[string]interface{}{"replicas": 2}
+	resp, err = webhook.Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, *resp.Allowed)
+
+	// policies of other kinds are skipped
+	resp, err = NewGroupPolicyWebhook(models.KindMutating, manager).Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Patch)
+}
@@ -3,6 +3,7 @@ package admission
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/runtime"
 
@@ -28,9 +29,10 @@ func Register(kind models.Kind, webhook Webhook) {
 }
 
 type validateResult struct {
-	req  Request
-	err  error
-	resp *Response
+	req     Request
+	webhook Webhook
+	err     error
+	resp    *Response
 }
 
 type Request struct {
@@ -52,14 +54,45 @@ type Response struct {
 }
 
 type Webhook interface {
+	// Name identifies the webhook in logs and metrics
+	Name() string
 	Handle(context.Context, *Request) (*Response, error)
+	// IgnoreError returns true if the failure policy of the webhook is ignore
 	IgnoreError() bool
+	// Timeout is the max duration of a single call to the webhook
+	Timeout() time.Duration
 	Interest(*Request) bool
 }
 
-func Mutating(ctx context.Context, request *Request) (*Request, error) {
-	ctx, cancelFunc := context.WithCancel(ctx)
+// handle calls the webhook within its timeout and reports metrics
+func handle(ctx context.Context, kind models.Kind, webhook Webhook, request *Request) (*Response, error) {
+	timeout := webhook.Timeout()
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancelFunc := context.WithTimeout(ctx, timeout)
 	defer cancelFunc()
+
+	start := time.Now()
+	response, err := webhook.Handle(ctx, request)
+	if err == nil && ctx.Err() != nil {
+		err = perror.Wrapf(ctx.Err(), "webhook %s timed out after %v", webhook.Name(), timeout)
+	}
+	result := resultAllowed
+	switch {
+	case err != nil:
+		result = resultError
+	case response == nil:
+	case kind == models.KindMutating && response.Patch != nil:
+		result = resultMutated
+	case kind == models.KindValidating && response.Allowed != nil && !*response.Allowed:
+		result = resultDenied
+	}
+	observe(webhook, kind, result, time.Since(start))
+	return response, err
+}
+
+func Mutating(ctx context.Context, request *Request) (*Request, error) {
 	if request.Object == nil {
 		return request, nil
 	}
@@ -67,7 +100,7 @@ func Mutating(ctx context.Context, request *Request) (*Request, error) {
 		if !webhook.Interest(request) {
 			continue
 		}
-		response, err := webhook.Handle(ctx, request)
+		response, err := handle(ctx, models.KindMutating, webhook, request)
 		if err = loggingError(ctx, err, webhook); err != nil {
 			return nil, err
 		}
@@ -81,72 +114,89 @@ func Mutating(ctx context.Context, request *Request) (*Request, error) {
 	return request, nil
 }
 
+// Validating calls the interested validating webhooks in parallel,
+// the request is denied as soon as any of them denies it
 func Validating(ctx context.Context, request *Request) error {
 	if len(validatingWebhooks) < 1 {
 		return nil
 	}
 	ctx, cancelFunc := context.WithCancel(ctx)
 	defer cancelFunc()
-	finishedCount := 0
-	resCh := make(chan validateResult)
+	// buffered so that the remaining webhooks never block after an early return
+	resCh := make(chan validateResult, len(validatingWebhooks))
 	for _, webhook := range validatingWebhooks {
 		go func(webhook Webhook) {
 			defer runtime.HandleCrash()
 			if !webhook.Interest(request) {
-				resCh <- validateResult{*request, nil, nil}
+				resCh <- validateResult{req: *request, webhook: webhook}
 				return
 			}
-			response, err := webhook.Handle(ctx, request)
-			if err != nil {
-				if webhook.IgnoreError() {
-					log.Errorf(ctx, "failed to admit request: %v", err)
-					resCh <- validateResult{*request, nil, nil}
-					return
-				}
-				resCh <- validateResult{*request, err, nil}
-				return
+			response, err := handle(ctx, models.KindValidating, webhook, request)
+			if err == nil && (response == nil || response.Allowed == nil) {
+				err = perror.New("response is nil or allowed is nil")
 			}
-			if response == nil || response.Allowed == nil {
+			if err != nil {
 				if webhook.IgnoreError() {
-					log.Errorf(ctx, "failed to admit request: response is nil or allowed is nil")
-					resCh <- validateResult{*request, nil, nil}
+					log.Errorf(ctx, "failed to admit request by webhook %s: %v", webhook.Name(), err)
+					resCh <- validateResult{req: *request, webhook: webhook}
 					return
 				}
-				resCh <- validateResult{*request, perror.New("response is nil or allowed is nil"), nil}
+				resCh <- validateResult{req: *request, webhook: webhook, err: err}
 				return
 			}
-			resCh <- validateResult{*request, nil, response}
+			resCh <- validateResult{req: *request, webhook: webhook, resp: response}
 		}(webhook)
 	}
 
-	for res := range resCh {
-		finishedCount++
+	for range validatingWebhooks {
+		res := <-resCh
 		if res.err != nil {
-			return res.err
+			return perror.WithMessagef(res.err, "failed to admit request by webhook %s", res.webhook.Name())
 		}
 		if res.resp != nil && res.resp.Allowed != nil && !*res.resp.Allowed {
 			log.Infof(ctx,
-				"request (resource: %s, resourceName: %s, subresource: %s, operation: %s) denied by webhook: %s",
+				"request (resource: %s, resourceName: %s, subresource: %s, operation: %s) denied by webhook %s: %s",
 				res.req.Resource, res.req.Name, res.req.SubResource,
-				res.req.Operation, res.resp.Result)
-			return perror.Wrapf(herrors.ErrForbidden, "request denied by webhook: %s", res.resp.Result)
-		}
-		if finishedCount >= len(validatingWebhooks) {
-			close(resCh)
-			break
+				res.req.Operation, res.webhook.Name(), res.resp.Result)
+			return perror.Wrapf(herrors.ErrForbidden, "request denied by webhook %s: %s",
+				res.webhook.Name(), res.resp.Result)
 		}
 	}
 
 	return nil
 }
 
+var _ models.AdmitFunc = Admit
+
+// Admit mutates and then validates the object, it admits the requests made by controllers
+// on behalf of users, which do not pass through the admission middleware
+func Admit(ctx context.Context, operation models.Operation, resource, name, subResource, version string,
+	object interface{}, options map[string]interface{}) (interface{}, error) {
+	request, err := Mutating(ctx, &Request{
+		Operation:   operation,
+		Resource:    resource,
+		Name:        name,
+		SubResource: subResource,
+		Version:     version,
+		Object:      object,
+		Options:     options,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := Validating(ctx, request); err != nil {
+		return nil, err
+	}
+	return request.Object, nil
+}
+
 func loggingError(ctx context.Context, err error, webhook Webhook) error {
 	if err != nil {
 		if webhook.IgnoreError() {
-			log.Warningf(ctx, "failed to admit request: %v", err.Error())
+			log.Warningf(ctx, "failed to admit request by webhook %s: %v", webhook.Name(), err.Error())
 			return nil
 		}
-		log.Errorf(ctx, "failed to admit request: %v", err.Error())
+		log.Errorf(ctx, "failed to admit request by webhook %s: %v", webhook.Name(), err.Error())
 		return err
 	}
 	return nil
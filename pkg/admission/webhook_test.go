@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 
 	clusterctrl "github.com/horizoncd/horizon/core/controller/cluster"
@@ -12,6 +13,7 @@ import (
 	codemodels "github.com/horizoncd/horizon/pkg/cluster/code"
 	admissionconfig "github.com/horizoncd/horizon/pkg/config/admission"
 	tagmodels "github.com/horizoncd/horizon/pkg/tag/models"
+	"github.com/horizoncd/horizon/pkg/util/common"
 )
 
 func TestWebhook(t *testing.T) {
@@ -148,3 +150,44 @@ func TestWebhook(t *testing.T) {
 	err = Validating(ctx, updateRequest)
 	assert.NoError(t, err)
 }
+
+type slowWebhook struct {
+	delay time.Duration
+}
+
+func (w *slowWebhook) Name() string { return "slow" }
+
+func (w *slowWebhook) Handle(ctx context.Context, _ *Request) (*Response, error) {
+	select {
+	case <-time.After(w.delay):
+		return &Response{Allowed: common.BoolPtr(false), Result: "too slow"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (w *slowWebhook) IgnoreError() bool { return false }
+
+func (w *slowWebhook) Timeout() time.Duration { return 10 * time.Millisecond }
+
+func (w *slowWebhook) Interest(*Request) bool { return true }
+
+func TestWebhookTimeout(t *testing.T) {
+	ctx := context.Background()
+	request := &Request{Operation: models.OperationCreate, Resource: "clusters", Name: "1"}
+
+	webhook := &slowWebhook{delay: time.Second}
+	start := time.Now()
+	_, err := handle(ctx, models.KindValidating, webhook, request)
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, float64(1), testutil.ToFloat64(webhookCounter.WithLabelValues(
+		"slow", models.KindValidating.String(), resultError)))
+
+	webhook.delay = 0
+	resp, err := handle(ctx, models.KindValidating, webhook, request)
+	assert.NoError(t, err)
+	assert.False(t, *resp.Allowed)
+	assert.Equal(t, float64(1), testutil.ToFloat64(webhookCounter.WithLabelValues(
+		"slow", models.KindValidating.String(), resultDenied)))
+}
@@ -22,10 +22,11 @@ import (
 	"testing"
 
 	"github.com/horizoncd/horizon/core/common"
-	gitlablib "github.com/horizoncd/horizon/lib/gitlab"
 	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	gitopsgitlab "github.com/horizoncd/horizon/pkg/gitops/gitlab"
 	"github.com/stretchr/testify/assert"
-	"github.com/xanzy/go-gitlab"
 )
 
 /*
@@ -66,13 +67,13 @@ set the env 'defaultBranch' to main
 // nolint
 var (
 	ctx           context.Context
-	g             gitlablib.Interface
+	g             gitops.Interface
 	defaultBranch string
 
 	defaultVisibility string
 
 	rootGroupName string
-	rootGroup     *gitlab.Group
+	rootGroup     *gitops.Group
 	app           = "app"
 
 	pipelineJSONBlob, applicationJSONBlob map[string]interface{}
@@ -174,7 +175,7 @@ func testInit() error {
 
 	defaultVisibility = "public"
 
-	g, err = gitlablib.New(p.Token, p.BaseURL)
+	g, err = gitopsgitlab.New(ctx, &gitlabconfig.GitopsRepoConfig{Token: p.Token, URL: p.BaseURL})
 	if err != nil {
 		panic(err)
 	}
@@ -212,7 +213,7 @@ func TestV2(t *testing.T) {
 		return
 	}
 
-	r, err := NewApplicationGitlabRepo(ctx, g, ApplicationGitRepoConfig{rootGroup, defaultBranch, defaultVisibility})
+	r, err := NewApplicationGitopsRepo(ctx, g, ApplicationGitRepoConfig{rootGroup, defaultBranch, defaultVisibility})
 	assert.Nil(t, err)
 
 	defer func() {
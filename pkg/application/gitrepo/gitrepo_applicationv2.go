@@ -22,12 +22,11 @@ import (
 
 	"github.com/horizoncd/horizon/core/common"
 	herrors "github.com/horizoncd/horizon/core/errors"
-	gitlablib "github.com/horizoncd/horizon/lib/gitlab"
 	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
 	"github.com/horizoncd/horizon/pkg/util/angular"
 	"github.com/horizoncd/horizon/pkg/util/log"
 	"github.com/horizoncd/horizon/pkg/util/wlog"
-	"github.com/xanzy/go-gitlab"
 	"sigs.k8s.io/yaml"
 )
 
@@ -63,35 +62,35 @@ type ApplicationGitRepo interface {
 }
 
 type appGitopsRepo struct {
-	gitlabLib                  gitlablib.Interface
-	applicationsGroup          *gitlab.Group
-	recyclingApplicationsGroup *gitlab.Group
+	gitopsLib                  gitops.Interface
+	applicationsGroup          *gitops.Group
+	recyclingApplicationsGroup *gitops.Group
 	defaultBranch              string
 	defaultVisibility          string
 }
 
 type ApplicationGitRepoConfig struct {
-	RootGroup         *gitlab.Group
+	RootGroup         *gitops.Group
 	DefaultBranch     string
 	DefaultVisibility string
 }
 
 var _ ApplicationGitRepo = &appGitopsRepo{}
 
-func NewApplicationGitlabRepo(ctx context.Context, gitlabLib gitlablib.Interface,
+func NewApplicationGitopsRepo(ctx context.Context, gitopsLib gitops.Interface,
 	config ApplicationGitRepoConfig) (ApplicationGitRepo, error) {
-	applicationsGroup, err := gitlabLib.GetCreatedGroup(ctx, config.RootGroup.ID,
-		config.RootGroup.FullPath, _applications, config.DefaultVisibility)
+	applicationsGroup, err := gitopsLib.GetCreatedGroup(ctx, config.RootGroup,
+		_applications, config.DefaultVisibility)
 	if err != nil {
 		return nil, err
 	}
-	recyclingApplicationsGroup, err := gitlabLib.GetCreatedGroup(ctx, config.RootGroup.ID,
-		config.RootGroup.FullPath, _recyclingApplications, config.DefaultVisibility)
+	recyclingApplicationsGroup, err := gitopsLib.GetCreatedGroup(ctx, config.RootGroup,
+		_recyclingApplications, config.DefaultVisibility)
 	if err != nil {
 		return nil, err
 	}
 	return &appGitopsRepo{
-		gitlabLib:                  gitlabLib,
+		gitopsLib:                  gitopsLib,
 		applicationsGroup:          applicationsGroup,
 		recyclingApplicationsGroup: recyclingApplicationsGroup,
 		defaultBranch:              config.DefaultBranch,
@@ -116,26 +115,26 @@ func (g appGitopsRepo) CreateOrUpdateApplication(ctx context.Context,
 
 	var envProjectExists = false
 	pid := fmt.Sprintf("%v/%v/%v", g.applicationsGroup.FullPath, application, environmentRepoName)
-	var project *gitlab.Project
-	project, err = g.gitlabLib.GetProject(ctx, pid)
+	var project *gitops.Project
+	project, err = g.gitopsLib.GetProject(ctx, pid)
 	if err != nil {
 		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
 			return err
 		}
 		// if not found, test application group exist
 		gid := fmt.Sprintf("%v/%v", g.applicationsGroup.FullPath, application)
-		parentGroup, err := g.gitlabLib.GetGroup(ctx, gid)
+		parentGroup, err := g.gitopsLib.GetGroup(ctx, gid)
 		if err != nil {
 			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
 				return err
 			}
-			parentGroup, err = g.gitlabLib.CreateGroup(ctx, application, application,
-				&g.applicationsGroup.ID, g.defaultVisibility)
+			parentGroup, err = g.gitopsLib.CreateGroup(ctx, application, application,
+				g.applicationsGroup, g.defaultVisibility)
 			if err != nil {
 				return err
 			}
 		}
-		project, err = g.gitlabLib.CreateProject(ctx, environmentRepoName, parentGroup.ID, g.defaultVisibility)
+		project, err = g.gitopsLib.CreateProject(ctx, environmentRepoName, parentGroup, g.defaultVisibility)
 		if err != nil {
 			return err
 		}
@@ -148,9 +147,9 @@ func (g appGitopsRepo) CreateOrUpdateApplication(ctx context.Context,
 	}
 
 	// 2. if env template repo exists, the gitlab action is update, else the action is create
-	var action = gitlablib.FileCreate
+	var action = gitops.FileCreate
 	if envProjectExists {
-		action = gitlablib.FileUpdate
+		action = gitops.FileUpdate
 	}
 
 	// 3. write files
@@ -178,24 +177,24 @@ func (g appGitopsRepo) CreateOrUpdateApplication(ctx context.Context,
 		}
 	}
 
-	actions := func() []gitlablib.CommitAction {
-		actions := make([]gitlablib.CommitAction, 0)
+	actions := func() []gitops.CommitAction {
+		actions := make([]gitops.CommitAction, 0)
 		if req.BuildConf != nil {
-			actions = append(actions, gitlablib.CommitAction{
+			actions = append(actions, gitops.CommitAction{
 				Action:   action,
 				FilePath: _filePathPipeline,
 				Content:  string(buildConfYaml),
 			})
 		}
 		if req.TemplateConf != nil {
-			actions = append(actions, gitlablib.CommitAction{
+			actions = append(actions, gitops.CommitAction{
 				Action:   action,
 				FilePath: _filePathApplication,
 				Content:  string(templateConfYaml),
 			})
 		}
 		if req.Version != "" {
-			actions = append(actions, gitlablib.CommitAction{
+			actions = append(actions, gitops.CommitAction{
 				Action:   action,
 				FilePath: _filePathManifest,
 				Content:  string(manifestYaml),
@@ -215,7 +214,7 @@ func (g appGitopsRepo) CreateOrUpdateApplication(ctx context.Context,
 		Application: req.TemplateConf,
 		Pipeline:    req.BuildConf,
 	})
-	if _, err := g.gitlabLib.WriteFiles(ctx, pid, g.defaultBranch, commitMsg, nil, actions); err != nil {
+	if _, err := g.gitopsLib.WriteFiles(ctx, pid, g.defaultBranch, commitMsg, nil, actions); err != nil {
 		return err
 	}
 	return nil
@@ -235,16 +234,16 @@ func (g appGitopsRepo) GetApplication(ctx context.Context, application, environm
 	}())
 
 	// if env template not exist, use the default one
-	_, err := g.gitlabLib.GetProject(ctx, pid)
+	_, err := g.gitopsLib.GetProject(ctx, pid)
 	if err != nil {
 		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
 			pid = fmt.Sprintf("%v/%v", gid, common.ApplicationRepoDefaultEnv)
 		}
 	}
 
-	manifestBytes, err1 := g.gitlabLib.GetFile(ctx, pid, g.defaultBranch, _filePathManifest)
-	buildConfBytes, err2 := g.gitlabLib.GetFile(ctx, pid, g.defaultBranch, _filePathPipeline)
-	templateConfBytes, err3 := g.gitlabLib.GetFile(ctx, pid, g.defaultBranch, _filePathApplication)
+	manifestBytes, err1 := g.gitopsLib.GetFile(ctx, pid, g.defaultBranch, _filePathManifest)
+	buildConfBytes, err2 := g.gitopsLib.GetFile(ctx, pid, g.defaultBranch, _filePathPipeline)
+	templateConfBytes, err3 := g.gitopsLib.GetFile(ctx, pid, g.defaultBranch, _filePathApplication)
 	for _, err := range []error{err1, err2, err3} {
 		if err != nil {
 			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
@@ -295,5 +294,5 @@ func (g appGitopsRepo) HardDeleteApplication(ctx context.Context, application st
 	defer wlog.Start(ctx, op).StopPrint()
 
 	gid := fmt.Sprintf("%v/%v", g.applicationsGroup.FullPath, application)
-	return g.gitlabLib.DeleteGroup(ctx, gid)
+	return g.gitopsLib.DeleteGroup(ctx, gid)
 }
@@ -0,0 +1,166 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/audit/models"
+)
+
+const (
+	// _appendRetries is the max attempts to append a log when the transaction fails, such as deadlocks
+	_appendRetries = 3
+	// _headID is the id of the only head of the hash chain
+	_headID = 1
+)
+
+type DAO interface {
+	Append(ctx context.Context, log *models.Log) (*models.Log, error)
+	GetHead(ctx context.Context) (*models.Head, error)
+	List(ctx context.Context, query *models.Query, page *q.Query) ([]*models.Log, int64, error)
+	ListAfter(ctx context.Context, query *models.Query, afterID uint, limit int) ([]*models.Log, error)
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Append(ctx context.Context, log *models.Log) (*models.Log, error) {
+	// datetime columns keep seconds only, which must be the same as hashed
+	log.CreatedAt = time.Now().Truncate(time.Second)
+	var err error
+	for i := 0; i < _appendRetries; i++ {
+		log.ID = 0
+		if err = d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return appendLog(tx, log)
+		}); err == nil {
+			return log, nil
+		}
+	}
+	return nil, herrors.NewErrInsertFailed(herrors.AuditLogInDB, err.Error())
+}
+
+// appendLog chains the log after the head, which is locked until the transaction ends,
+// so that concurrent appends are serialized rather than fork the chain
+func appendLog(tx *gorm.DB, log *models.Log) error {
+	head, err := lockHead(tx)
+	if err != nil {
+		return err
+	}
+	log.PrevHash = head.Hash
+	log.Hash = log.ComputeHash()
+	if err := tx.Create(log).Error; err != nil {
+		return err
+	}
+	return tx.Model(head).Updates(map[string]interface{}{"log_id": log.ID, "hash": log.Hash}).Error
+}
+
+func lockHead(tx *gorm.DB) (*models.Head, error) {
+	var head models.Head
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", _headID).Limit(1).Find(&head)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return &head, nil
+	}
+	// the head is created from the latest log at the first append
+	var latest models.Log
+	if err := tx.Order("id desc").Limit(1).Find(&latest).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Head{ID: _headID, LogID: latest.ID, Hash: latest.Hash}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", _headID).
+		First(&head).Error; err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+func (d *dao) GetHead(ctx context.Context) (*models.Head, error) {
+	var head models.Head
+	result := d.db.WithContext(ctx).Where("id = ?", _headID).Limit(1).Find(&head)
+	if result.Error != nil {
+		return nil, herrors.NewErrGetFailed(herrors.AuditLogInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return nil, herrors.NewErrNotFound(herrors.AuditLogInDB, "head of audit logs not found")
+	}
+	return &head, nil
+}
+
+func (d *dao) List(ctx context.Context, query *models.Query, page *q.Query) ([]*models.Log, int64, error) {
+	var (
+		logs  []*models.Log
+		total int64
+	)
+	statement := filter(d.db.WithContext(ctx).Model(&models.Log{}), query)
+	if err := statement.Count(&total).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.AuditLogInDB, err.Error())
+	}
+	if page != nil {
+		statement = statement.Offset(page.Offset()).Limit(page.Limit())
+	}
+	if err := statement.Order("id desc").Find(&logs).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.AuditLogInDB, err.Error())
+	}
+	return logs, total, nil
+}
+
+func (d *dao) ListAfter(ctx context.Context, query *models.Query,
+	afterID uint, limit int) ([]*models.Log, error) {
+	var logs []*models.Log
+	if err := filter(d.db.WithContext(ctx), query).Where("id > ?", afterID).
+		Order("id asc").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.AuditLogInDB, err.Error())
+	}
+	return logs, nil
+}
+
+func filter(statement *gorm.DB, query *models.Query) *gorm.DB {
+	if query == nil {
+		return statement
+	}
+	if query.ActorName != "" {
+		statement = statement.Where("actor_name = ?", query.ActorName)
+	}
+	if query.Resource != "" {
+		statement = statement.Where("resource = ?", query.Resource)
+	}
+	if query.ResourceName != "" {
+		statement = statement.Where("resource_name = ?", query.ResourceName)
+	}
+	if !query.StartTime.IsZero() {
+		statement = statement.Where("created_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		statement = statement.Where("created_at < ?", query.EndTime)
+	}
+	return statement
+}
@@ -0,0 +1,102 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"os"
+	"sync"
+
+	"github.com/horizoncd/horizon/pkg/audit/models"
+	"github.com/horizoncd/horizon/pkg/config/audit"
+)
+
+// Exporter exports audit logs to external systems such as SIEM, in addition to the database
+type Exporter interface {
+	Export(log *models.Log) error
+	// ExportAnchor exports the head of the hash chain, which tells the logs deleted at the end
+	ExportAnchor(anchor *models.Anchor) error
+}
+
+// New creates the exporters enabled in the config
+func New(config audit.Config) ([]Exporter, error) {
+	var exporters []Exporter
+	if config.Syslog.Address != "" {
+		network := config.Syslog.Network
+		if network == "" {
+			network = "udp"
+		}
+		writer, err := syslog.Dial(network, config.Syslog.Address,
+			syslog.LOG_INFO|syslog.LOG_AUTH, config.Syslog.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial syslog %s: %v", config.Syslog.Address, err)
+		}
+		exporters = append(exporters, &syslogExporter{writer: writer})
+	}
+	if config.JSONLinesFile != "" {
+		file, err := os.OpenFile(config.JSONLinesFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %v", config.JSONLinesFile, err)
+		}
+		exporters = append(exporters, &jsonLinesExporter{file: file})
+	}
+	return exporters, nil
+}
+
+type syslogExporter struct {
+	writer *syslog.Writer
+}
+
+func (e *syslogExporter) Export(log *models.Log) error {
+	return e.write(log)
+}
+
+func (e *syslogExporter) ExportAnchor(anchor *models.Anchor) error {
+	return e.write(anchor)
+}
+
+func (e *syslogExporter) write(v interface{}) error {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return e.writer.Info(string(bts))
+}
+
+type jsonLinesExporter struct {
+	sync.Mutex
+	file *os.File
+}
+
+func (e *jsonLinesExporter) Export(log *models.Log) error {
+	return e.write(log)
+}
+
+func (e *jsonLinesExporter) ExportAnchor(anchor *models.Anchor) error {
+	return e.write(anchor)
+}
+
+func (e *jsonLinesExporter) write(v interface{}) error {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.Lock()
+	defer e.Unlock()
+	_, err = e.file.Write(append(bts, '\n'))
+	return err
+}
@@ -0,0 +1,135 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/audit/dao"
+	"github.com/horizoncd/horizon/pkg/audit/models"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+)
+
+// _verifyBatchSize is the number of logs loaded at a time when walking the chain
+const _verifyBatchSize = 500
+
+type Manager interface {
+	// Append appends the log to the end of the hash chain
+	Append(ctx context.Context, log *models.Log) (*models.Log, error)
+	// List lists the logs matching the query, the latest first
+	List(ctx context.Context, query *models.Query, page *q.Query) ([]*models.Log, int64, error)
+	// ListAfter lists at most limit logs matching the query after the id, the earliest first
+	ListAfter(ctx context.Context, query *models.Query, afterID uint, limit int) ([]*models.Log, error)
+	// Verify walks the hash chain from the first log, and stops at the first log breaking the chain
+	Verify(ctx context.Context) (*models.Verification, error)
+	// Anchor returns the head of the hash chain signed by the key, which is exported to be compared with later
+	Anchor(ctx context.Context, key string) (*models.Anchor, error)
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) Append(ctx context.Context, log *models.Log) (*models.Log, error) {
+	return m.dao.Append(ctx, log)
+}
+
+func (m *manager) List(ctx context.Context, query *models.Query, page *q.Query) ([]*models.Log, int64, error) {
+	return m.dao.List(ctx, query, page)
+}
+
+func (m *manager) ListAfter(ctx context.Context, query *models.Query,
+	afterID uint, limit int) ([]*models.Log, error) {
+	return m.dao.ListAfter(ctx, query, afterID, limit)
+}
+
+func (m *manager) Verify(ctx context.Context) (*models.Verification, error) {
+	verification := &models.Verification{}
+	var (
+		afterID  uint
+		prevHash string
+	)
+	for {
+		logs, err := m.dao.ListAfter(ctx, nil, afterID, _verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			if log.PrevHash != prevHash {
+				verification.BrokenAt = log.ID
+				verification.Reason = "previous log is missing"
+				return verification, nil
+			}
+			if log.Hash != log.ComputeHash() {
+				verification.BrokenAt = log.ID
+				verification.Reason = "log is modified"
+				return verification, nil
+			}
+			prevHash = log.Hash
+			afterID = log.ID
+			verification.Verified++
+		}
+		if len(logs) < _verifyBatchSize {
+			break
+		}
+	}
+
+	// the logs deleted at the end do not break the chain, but mismatch the head
+	head, err := m.dao.GetHead(ctx)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		head = &models.Head{}
+	}
+	if head.LogID != afterID || head.Hash != prevHash {
+		verification.BrokenAt = head.LogID
+		verification.Reason = "logs at the end are missing"
+		if head.LogID < afterID {
+			verification.BrokenAt = afterID
+			verification.Reason = "head is modified"
+		}
+		return verification, nil
+	}
+	verification.Valid = true
+	return verification, nil
+}
+
+func (m *manager) Anchor(ctx context.Context, key string) (*models.Anchor, error) {
+	head, err := m.dao.GetHead(ctx)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		head = &models.Head{}
+	}
+	anchor := &models.Anchor{
+		Kind:      models.AnchorKind,
+		LogID:     head.LogID,
+		Hash:      head.Hash,
+		CreatedAt: time.Now(),
+	}
+	anchor.Signature = anchor.Sign(key)
+	return anchor, nil
+}
@@ -0,0 +1,132 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Log is an append-only audit log of an api call. Logs are chained by hashes, each of which covers
+// the content of the log and the hash of the previous one, so that modifying or deleting a log
+// breaks the chain
+type Log struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	RequestID string `json:"requestID"`
+	// ActorID is 0 if the request is not authenticated, such as logging in
+	ActorID   uint   `json:"actorID"`
+	ActorName string `json:"actorName"`
+	ClientIP  string `json:"clientIP"`
+	UserAgent string `json:"userAgent"`
+	Method    string `json:"method"`
+	// Path is the request uri with the query
+	Path         string `json:"path"`
+	Verb         string `json:"verb"`
+	APIVersion   string `json:"apiVersion"`
+	Resource     string `json:"resource"`
+	SubResource  string `json:"subResource"`
+	ResourceName string `json:"resourceName"`
+	StatusCode   int    `json:"statusCode"`
+	// RequestBody is truncated and the sensitive fields are masked
+	RequestBody string `json:"requestBody"`
+	// Before is the resource before updated or deleted, and Diff is the json patch from it to the resource
+	// after updated. Both are empty if snapshot is disabled
+	Before string `json:"before"`
+	Diff   string `json:"diff"`
+
+	// PrevHash is unique so that concurrent appends cannot fork the chain
+	PrevHash string `gorm:"uniqueIndex" json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+func (Log) TableName() string {
+	return "tb_audit_log"
+}
+
+// ComputeHash computes the hash of the log chained after the previous hash.
+// The id is not covered since it is assigned after the hash is computed
+func (l *Log) ComputeHash() string {
+	content, _ := json.Marshal([]interface{}{
+		l.CreatedAt.UTC().Format(time.RFC3339), l.RequestID, l.ActorID, l.ActorName, l.ClientIP, l.UserAgent,
+		l.Method, l.Path, l.Verb, l.APIVersion, l.Resource, l.SubResource, l.ResourceName, l.StatusCode,
+		l.RequestBody, l.Before, l.Diff,
+	})
+	sum := sha256.Sum256(append([]byte(l.PrevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Head is the end of the hash chain. It is locked to serialize appends, and tells whether the logs at the end
+// are deleted, which does not break the chain
+type Head struct {
+	ID        uint `gorm:"primarykey"`
+	LogID     uint
+	Hash      string
+	UpdatedAt time.Time
+}
+
+func (Head) TableName() string {
+	return "tb_audit_log_head"
+}
+
+// AnchorKind tells anchors from logs in the exported lines
+const AnchorKind = "auditAnchor"
+
+// Anchor is the head of the hash chain exported periodically, so that the logs deleted at the end,
+// together with the head, can be told by comparing with the latest anchor exported
+type Anchor struct {
+	Kind      string    `json:"kind"`
+	LogID     uint      `json:"logID"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Signature is the hex of the hmac-sha256 of the anchor by the signing key, empty if no key is configured
+	Signature string `json:"signature,omitempty"`
+}
+
+// Sign computes the signature of the anchor by the key
+func (a *Anchor) Sign(key string) string {
+	if key == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fmt.Sprintf("%d\n%s\n%s", a.LogID, a.Hash, a.CreatedAt.UTC().Format(time.RFC3339))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Query filters audit logs, zero values match all
+type Query struct {
+	ActorName    string
+	Resource     string
+	ResourceName string
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// Verification is the result of walking the hash chain
+type Verification struct {
+	Valid bool `json:"valid"`
+	// Verified is the number of logs verified before the chain breaks
+	Verified int `json:"verified"`
+	// BrokenAt is the id of the first log breaking the chain, whose previous log was deleted
+	// if PrevHash mismatches, or which was modified if Hash mismatches. It is the id of the head
+	// if the logs at the end were deleted
+	BrokenAt uint   `json:"brokenAt,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
@@ -0,0 +1,132 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/canary/models"
+)
+
+type DAO interface {
+	GetAnalysisByClusterID(ctx context.Context, clusterID uint) (*models.Analysis, error)
+	UpsertAnalysis(ctx context.Context, analysis *models.Analysis) (*models.Analysis, error)
+	ListEnabledAnalyses(ctx context.Context) ([]*models.Analysis, error)
+	GetRun(ctx context.Context, pipelinerunID uint, step int) (*models.AnalysisRun, error)
+	CreateRun(ctx context.Context, run *models.AnalysisRun) (*models.AnalysisRun, error)
+	UpdateRun(ctx context.Context, run *models.AnalysisRun) error
+	ListRunsByPipelinerunID(ctx context.Context, pipelinerunID uint) ([]*models.AnalysisRun, error)
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) GetAnalysisByClusterID(ctx context.Context, clusterID uint) (*models.Analysis, error) {
+	var analysis models.Analysis
+	if err := d.db.WithContext(ctx).Where("cluster_id = ?", clusterID).
+		First(&analysis).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.CanaryAnalysisInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.CanaryAnalysisInDB, err.Error())
+	}
+	return &analysis, nil
+}
+
+func (d *dao) UpsertAnalysis(ctx context.Context, analysis *models.Analysis) (*models.Analysis, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existed models.Analysis
+		err := tx.Where("cluster_id = ?", analysis.ClusterID).First(&existed).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return herrors.NewErrGetFailed(herrors.CanaryAnalysisInDB, err.Error())
+			}
+			if err := tx.Create(analysis).Error; err != nil {
+				return herrors.NewErrInsertFailed(herrors.CanaryAnalysisInDB, err.Error())
+			}
+			return nil
+		}
+		// enabled is selected explicitly, so that it can be updated to false
+		if err := tx.Model(&existed).Select("enabled", "interval", "metrics", "updated_by").
+			Updates(analysis).Error; err != nil {
+			return herrors.NewErrUpdateFailed(herrors.CanaryAnalysisInDB, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.GetAnalysisByClusterID(ctx, analysis.ClusterID)
+}
+
+func (d *dao) ListEnabledAnalyses(ctx context.Context) ([]*models.Analysis, error) {
+	var analyses []*models.Analysis
+	if err := d.db.WithContext(ctx).Where("enabled = ?", true).
+		Order("id asc").Find(&analyses).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.CanaryAnalysisInDB, err.Error())
+	}
+	return analyses, nil
+}
+
+func (d *dao) GetRun(ctx context.Context, pipelinerunID uint, step int) (*models.AnalysisRun, error) {
+	var run models.AnalysisRun
+	if err := d.db.WithContext(ctx).Where("pipelinerun_id = ? and step = ?", pipelinerunID, step).
+		First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.CanaryAnalysisRunInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.CanaryAnalysisRunInDB, err.Error())
+	}
+	return &run, nil
+}
+
+func (d *dao) CreateRun(ctx context.Context, run *models.AnalysisRun) (*models.AnalysisRun, error) {
+	if err := d.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.CanaryAnalysisRunInDB, err.Error())
+	}
+	return run, nil
+}
+
+func (d *dao) UpdateRun(ctx context.Context, run *models.AnalysisRun) error {
+	result := d.db.WithContext(ctx).Model(&models.AnalysisRun{}).Where("id = ?", run.ID).
+		Select("status", "results", "message", "errors", "finished_at").Updates(run)
+	if result.Error != nil {
+		return herrors.NewErrUpdateFailed(herrors.CanaryAnalysisRunInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return herrors.NewErrNotFound(herrors.CanaryAnalysisRunInDB,
+			fmt.Sprintf("analysis run %d not found", run.ID))
+	}
+	return nil
+}
+
+func (d *dao) ListRunsByPipelinerunID(ctx context.Context, pipelinerunID uint) ([]*models.AnalysisRun, error) {
+	var runs []*models.AnalysisRun
+	if err := d.db.WithContext(ctx).Where("pipelinerun_id = ?", pipelinerunID).
+		Order("step asc").Find(&runs).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.CanaryAnalysisRunInDB, err.Error())
+	}
+	return runs, nil
+}
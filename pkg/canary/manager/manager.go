@@ -0,0 +1,76 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/pkg/canary/dao"
+	"github.com/horizoncd/horizon/pkg/canary/models"
+)
+
+type Manager interface {
+	// GetAnalysisByClusterID gets the canary analysis config of the cluster
+	GetAnalysisByClusterID(ctx context.Context, clusterID uint) (*models.Analysis, error)
+	// UpsertAnalysis creates or replaces the canary analysis config of the cluster
+	UpsertAnalysis(ctx context.Context, analysis *models.Analysis) (*models.Analysis, error)
+	// ListEnabledAnalyses lists the configs of clusters whose canaries are analyzed
+	ListEnabledAnalyses(ctx context.Context) ([]*models.Analysis, error)
+	// GetRun gets the analysis of a canary step deployed by the pipelinerun
+	GetRun(ctx context.Context, pipelinerunID uint, step int) (*models.AnalysisRun, error)
+	CreateRun(ctx context.Context, run *models.AnalysisRun) (*models.AnalysisRun, error)
+	// UpdateRun updates the status, results, message and finished time of the run
+	UpdateRun(ctx context.Context, run *models.AnalysisRun) error
+	// ListRunsByPipelinerunID lists the analyses of the pipelinerun ordered by step
+	ListRunsByPipelinerunID(ctx context.Context, pipelinerunID uint) ([]*models.AnalysisRun, error)
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) GetAnalysisByClusterID(ctx context.Context, clusterID uint) (*models.Analysis, error) {
+	return m.dao.GetAnalysisByClusterID(ctx, clusterID)
+}
+
+func (m *manager) UpsertAnalysis(ctx context.Context, analysis *models.Analysis) (*models.Analysis, error) {
+	return m.dao.UpsertAnalysis(ctx, analysis)
+}
+
+func (m *manager) ListEnabledAnalyses(ctx context.Context) ([]*models.Analysis, error) {
+	return m.dao.ListEnabledAnalyses(ctx)
+}
+
+func (m *manager) GetRun(ctx context.Context, pipelinerunID uint, step int) (*models.AnalysisRun, error) {
+	return m.dao.GetRun(ctx, pipelinerunID, step)
+}
+
+func (m *manager) CreateRun(ctx context.Context, run *models.AnalysisRun) (*models.AnalysisRun, error) {
+	return m.dao.CreateRun(ctx, run)
+}
+
+func (m *manager) UpdateRun(ctx context.Context, run *models.AnalysisRun) error {
+	return m.dao.UpdateRun(ctx, run)
+}
+
+func (m *manager) ListRunsByPipelinerunID(ctx context.Context, pipelinerunID uint) ([]*models.AnalysisRun, error) {
+	return m.dao.ListRunsByPipelinerunID(ctx, pipelinerunID)
+}
@@ -0,0 +1,62 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+)
+
+// Querier queries a single value from prometheus
+type Querier interface {
+	Query(ctx context.Context, address, query string) (float64, error)
+}
+
+type querier struct{}
+
+func NewQuerier() Querier {
+	return &querier{}
+}
+
+// Query evaluates an instant query, the result must be a scalar or a vector with a single sample
+func (q *querier) Query(ctx context.Context, address, query string) (float64, error) {
+	client, err := api.NewClient(api.Config{Address: address})
+	if err != nil {
+		return 0, perror.Wrapf(herrors.ErrParamInvalid, "invalid prometheus address %s: %v", address, err)
+	}
+	value, _, err := promv1.NewAPI(client).Query(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to query prometheus: %v", err)
+	}
+	switch v := value.(type) {
+	case *model.Scalar:
+		return float64(v.Value), nil
+	case model.Vector:
+		if len(v) != 1 {
+			return 0, fmt.Errorf("query returns %d series, but a single one is expected", len(v))
+		}
+		return float64(v[0].Value), nil
+	default:
+		return 0, fmt.Errorf("unsupported result type %s", value.Type())
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+type RunStatus string
+
+const (
+	// RunStatusAnalyzing the canary step is being observed until the interval elapses
+	RunStatusAnalyzing RunStatus = "analyzing"
+	// RunStatusSucceeded all the metrics are within thresholds, and the canary is promoted
+	RunStatusSucceeded RunStatus = "succeeded"
+	// RunStatusFailed any of the metrics is out of thresholds or cannot be queried, and the canary is aborted
+	RunStatusFailed RunStatus = "failed"
+)
+
+// Analysis is the canary analysis config of a cluster,
+// the metrics are queried between canary steps to decide whether to promote or abort the canary
+type Analysis struct {
+	global.Model
+
+	ClusterID uint
+	Enabled   bool
+	// Interval is how long in seconds a canary step is observed before it is analyzed
+	Interval uint
+	// Metrics is the json of metrics queried from prometheus
+	Metrics string
+
+	CreatedBy uint
+	UpdatedBy uint
+}
+
+func (Analysis) TableName() string {
+	return "tb_canary_analysis"
+}
+
+// Metric is a prometheus query whose result must be within [Min, Max],
+// the query is a go template which can refer to .Cluster, .Namespace, .Environment and .Interval
+type Metric struct {
+	Name  string   `json:"name"`
+	Query string   `json:"query"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// MetricResult is the value queried for a metric
+type MetricResult struct {
+	Metric `json:",inline"`
+	Value  *float64 `json:"value,omitempty"`
+	Passed bool     `json:"passed"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// AnalysisRun is the analysis of a canary step deployed by a pipelinerun
+type AnalysisRun struct {
+	global.Model
+
+	ClusterID     uint
+	PipelinerunID uint
+	// Step is the index of canary step analyzed
+	Step   int
+	Status RunStatus
+	// Results is the json of metric results
+	Results string
+	Message string
+	// Errors is how many times in a row the metrics cannot be queried
+	Errors     int
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+func (AnalysisRun) TableName() string {
+	return "tb_canary_analysis_run"
+}
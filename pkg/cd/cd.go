@@ -61,6 +61,10 @@ var (
 		Group: "",
 		Kind:  "Pod",
 	}
+	GKHPA = schema.GroupKind{
+		Group: "autoscaling",
+		Kind:  "HorizontalPodAutoscaler",
+	}
 )
 
 const (
@@ -296,6 +300,7 @@ func (c *cd) GetStep(ctx context.Context, params *GetStepParams) (*Step, error)
 		Total:        step.Total,
 		Replicas:     step.Replicas,
 		ManualPaused: step.ManualPaused,
+		Paused:       step.Paused,
 		AutoPromote:  step.AutoPromote,
 		Extra:        step.Extra,
 	}, nil
@@ -20,6 +20,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,13 +41,26 @@ import (
 	"github.com/horizoncd/horizon/pkg/util/log"
 	"github.com/horizoncd/horizon/pkg/util/wlog"
 	"github.com/horizoncd/horizon/pkg/workload"
+	"github.com/horizoncd/horizon/pkg/workload/job"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
 )
 
 //go:generate mockgen -source=$GOFILE -destination=../../mock/pkg/cd/k8sutil_mock.go -package=mock_cd
@@ -51,6 +71,18 @@ type K8sUtil interface {
 	GetPodContainers(ctx context.Context, params *GetPodParams) ([]ContainerDetail, error)
 	GetPod(ctx context.Context, params *GetPodParams) (*corev1.Pod, error)
 	GetContainerLog(ctx context.Context, params *GetContainerLogParams) (<-chan string, error)
+	// ListJobRuns lists the recent jobs of a cronjob or job, the latest first
+	ListJobRuns(ctx context.Context, params *ListJobRunsParams) ([]JobRun, error)
+	// GetHPAStatus gets the replicas, metrics and recent scaling events of a HorizontalPodAutoscaler
+	GetHPAStatus(ctx context.Context, params *GetHPAParams) (*HPAStatus, error)
+	// UpdateHPAReplicas updates the min and max replicas of a HorizontalPodAutoscaler
+	UpdateHPAReplicas(ctx context.Context, params *UpdateHPAReplicasParams) error
+	// ScaleWorkload sets the replicas of a workload, and returns the replicas before scaling
+	ScaleWorkload(ctx context.Context, params *ScaleWorkloadParams) (int32, error)
+	// PortForward forwards the stream to the port of a pod, and blocks until either side is closed
+	PortForward(ctx context.Context, params *PortForwardParams) error
+	// GetPodProxy gets a handler proxying requests to the port of a pod through the apiserver
+	GetPodProxy(ctx context.Context, params *GetPodProxyParams) (http.Handler, error)
 }
 
 type util struct {
@@ -157,9 +189,15 @@ func (e *util) ExecuteAction(ctx context.Context,
 				fmt.Sprintf("failed to get %s(%s)", params.ResourceName, params.GVR.String()))
 		}
 
-		workload.LoopAbilities(func(workload workload.Workload) bool {
-			if workload.MatchGK(un.GroupVersionKind().GroupKind()) {
-				un, err = workload.Action(params.Action, un)
+		// the job triggered is created, instead of updating the object
+		var triggered *unstructured.Unstructured
+		workload.LoopAbilities(func(w workload.Workload) bool {
+			if w.MatchGK(un.GroupVersionKind().GroupKind()) {
+				if trigger, ok := w.(workload.JobTrigger); ok && params.Action == workload.ActionTrigger {
+					triggered, err = trigger.TriggerJob(un)
+				} else {
+					un, err = w.Action(params.Action, un)
+				}
 				return false
 			}
 			return true
@@ -169,14 +207,26 @@ func (e *util) ExecuteAction(ctx context.Context,
 				params.Action, params.ResourceName, params.GVR.String())
 		}
 
-		un, err = clientset.Resource(params.GVR).Namespace(params.Namespace).
-			Update(ctx, un, metav1.UpdateOptions{})
-		log.Debugf(ctx, "update %s(%s) with %s: %v", params.ResourceName,
-			params.GVR.String(), params.Action, un)
-		if err != nil {
-			return herrors.NewErrUpdateFailed(herrors.ResourceInK8S,
-				fmt.Sprintf("failed to update gvr(%s), ns(%s), name(%s)",
-					params.GVR.String(), params.Namespace, un.GetName()))
+		if triggered != nil {
+			triggered, err = clientset.Resource(job.GVRJob).Namespace(params.Namespace).
+				Create(ctx, triggered, metav1.CreateOptions{})
+			if err != nil {
+				return herrors.NewErrCreateFailed(herrors.ResourceInK8S,
+					fmt.Sprintf("failed to create job for gvr(%s), ns(%s), name(%s): %v",
+						params.GVR.String(), params.Namespace, params.ResourceName, err))
+			}
+			log.Debugf(ctx, "trigger job %s for %s(%s)", triggered.GetName(),
+				params.ResourceName, params.GVR.String())
+		} else {
+			un, err = clientset.Resource(params.GVR).Namespace(params.Namespace).
+				Update(ctx, un, metav1.UpdateOptions{})
+			log.Debugf(ctx, "update %s(%s) with %s: %v", params.ResourceName,
+				params.GVR.String(), params.Action, un)
+			if err != nil {
+				return herrors.NewErrUpdateFailed(herrors.ResourceInK8S,
+					fmt.Sprintf("failed to update gvr(%s), ns(%s), name(%s)",
+						params.GVR.String(), params.Namespace, un.GetName()))
+			}
 		}
 		bts, err := json.Marshal(map[string]interface{}{
 			"action":       params.Action,
@@ -203,6 +253,84 @@ func (e *util) ExecuteAction(ctx context.Context,
 	return err
 }
 
+func (e *util) ListJobRuns(ctx context.Context, params *ListJobRunsParams) ([]JobRun, error) {
+	const op = "k8sutil: list job runs"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	runs := make([]JobRun, 0)
+	err := e.informerFactories.GetDynamicFactory(params.RegionEntity.ID,
+		func(factory dynamicinformer.DynamicSharedInformerFactory) error {
+			objs, err := factory.ForResource(job.GVRJob).Lister().ByNamespace(params.Namespace).
+				List(labels.Everything())
+			if err != nil {
+				return herrors.NewErrGetFailed(herrors.ResourceInK8S,
+					fmt.Sprintf("failed to list jobs in ns(%s): %v", params.Namespace, err))
+			}
+			jobs := make([]*batchv1.Job, 0)
+			for _, obj := range objs {
+				instance := &batchv1.Job{}
+				if err := workload.ObjUnmarshal(obj, instance); err != nil {
+					return err
+				}
+				if instance.Name == params.Name || ownedByCronJob(instance, params.Name) {
+					jobs = append(jobs, instance)
+				}
+			}
+			sort.SliceStable(jobs, func(i, k int) bool {
+				return jobs[k].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+			})
+			if params.Limit > 0 && len(jobs) > params.Limit {
+				jobs = jobs[:params.Limit]
+			}
+
+			for _, instance := range jobs {
+				pods, err := job.ListPods(instance, factory)
+				if err != nil {
+					return herrors.NewErrGetFailed(herrors.ResourceInK8S,
+						fmt.Sprintf("failed to list pods of job(%s): %v", instance.Name, err))
+				}
+				run := JobRun{
+					Name:   instance.Name,
+					Status: job.Status(instance),
+					Manual: instance.Annotations["cronjob.kubernetes.io/instantiate"] == "manual",
+					Pods:   make([]JobRunPod, 0, len(pods)),
+				}
+				if instance.Status.StartTime != nil {
+					run.StartTime = &instance.Status.StartTime.Time
+				}
+				if instance.Status.CompletionTime != nil {
+					run.CompletionTime = &instance.Status.CompletionTime.Time
+				}
+				for _, pod := range pods {
+					containers := make([]string, 0, len(pod.Spec.Containers))
+					for _, container := range pod.Spec.Containers {
+						containers = append(containers, container.Name)
+					}
+					run.Pods = append(run.Pods, JobRunPod{
+						Name:       pod.Name,
+						Phase:      string(pod.Status.Phase),
+						Containers: containers,
+					})
+				}
+				runs = append(runs, run)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func ownedByCronJob(instance *batchv1.Job, cronJob string) bool {
+	for _, owner := range instance.OwnerReferences {
+		if owner.Kind == "CronJob" && owner.Name == cronJob {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *util) GetPodContainers(ctx context.Context,
 	params *GetPodParams) (containers []ContainerDetail, err error) {
 	pod, err := e.GetPod(ctx, params)
@@ -323,3 +451,383 @@ func (c *cd) traverseResourceTree(resourceTree *applicationV1alpha1.ApplicationT
 		}
 	}
 }
+
+// _hpaEventsLimit is the max number of scaling events returned for a HorizontalPodAutoscaler
+const _hpaEventsLimit = 10
+
+var (
+	gvrHPAV1 = schema.GroupVersionResource{
+		Group:    "autoscaling",
+		Version:  "v1",
+		Resource: "horizontalpodautoscalers",
+	}
+	// autoscaling/v2 has the same schema as autoscaling/v2beta2,
+	// which is the latest version known by the client, and removed since kubernetes 1.26
+	gvrHPAV2 = schema.GroupVersionResource{
+		Group:    "autoscaling",
+		Version:  "v2",
+		Resource: "horizontalpodautoscalers",
+	}
+	gvrHPAV2beta2 = schema.GroupVersionResource{
+		Group:    "autoscaling",
+		Version:  "v2beta2",
+		Resource: "horizontalpodautoscalers",
+	}
+)
+
+func (e *util) GetHPAStatus(ctx context.Context, params *GetHPAParams) (*HPAStatus, error) {
+	const op = "k8sutil: get hpa status"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	var un *unstructured.Unstructured
+	err := e.informerFactories.GetDynamicClientSet(params.RegionEntity.ID, func(clientset dynamic.Interface) error {
+		var err error
+		for _, gvr := range []schema.GroupVersionResource{gvrHPAV2, gvrHPAV2beta2} {
+			un, err = clientset.Resource(gvr).Namespace(params.Namespace).
+				Get(ctx, params.Name, metav1.GetOptions{})
+			if err == nil {
+				return nil
+			}
+		}
+		if k8serrors.IsNotFound(err) {
+			return herrors.NewErrNotFound(herrors.ResourceInK8S,
+				fmt.Sprintf("hpa(%s) not found in ns(%s)", params.Name, params.Namespace))
+		}
+		return herrors.NewErrGetFailed(herrors.ResourceInK8S,
+			fmt.Sprintf("failed to get hpa(%s) in ns(%s): %v", params.Name, params.Namespace, err))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var hpa autoscalingv2beta2.HorizontalPodAutoscaler
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(un.Object, &hpa); err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "failed to convert hpa: %v", err)
+	}
+	status := ofHPA(&hpa)
+
+	err = e.informerFactories.GetClientSet(params.RegionEntity.ID, func(clientset kubernetes.Interface) error {
+		events, err := clientset.CoreV1().Events(params.Namespace).List(ctx, metav1.ListOptions{
+			Limit: kube.DefaultEventsLimit,
+			FieldSelector: fields.SelectorFromSet(map[string]string{
+				"involvedObject.kind": "HorizontalPodAutoscaler",
+				"involvedObject.name": params.Name,
+			}).String(),
+		})
+		if err != nil {
+			return herrors.NewErrListFailed(herrors.PodEventInK8S, err.Error())
+		}
+		for _, event := range events.Items {
+			eventTimestamp := metav1.Time{Time: event.EventTime.Time}
+			if eventTimestamp.IsZero() {
+				eventTimestamp = event.LastTimestamp
+			}
+			status.Events = append(status.Events, Event{
+				Type:           event.Type,
+				Reason:         event.Reason,
+				Message:        event.Message,
+				Count:          event.Count,
+				EventTimestamp: eventTimestamp,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(status.Events, func(i, k int) bool {
+		return status.Events[k].EventTimestamp.Before(&status.Events[i].EventTimestamp)
+	})
+	if len(status.Events) > _hpaEventsLimit {
+		status.Events = status.Events[:_hpaEventsLimit]
+	}
+	return status, nil
+}
+
+func (e *util) UpdateHPAReplicas(ctx context.Context, params *UpdateHPAReplicasParams) error {
+	const op = "k8sutil: update hpa replicas"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	bts, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"minReplicas": params.MinReplicas,
+			"maxReplicas": params.MaxReplicas,
+		},
+	})
+	if err != nil {
+		return perror.Wrapf(herrors.ErrParamInvalid, "failed to marshal patch: %v", err)
+	}
+	return e.informerFactories.GetDynamicClientSet(params.RegionEntity.ID, func(clientset dynamic.Interface) error {
+		// autoscaling/v1 is served by all versions of kubernetes
+		_, err := clientset.Resource(gvrHPAV1).Namespace(params.Namespace).
+			Patch(ctx, params.Name, types.MergePatchType, bts, metav1.PatchOptions{})
+		if err != nil {
+			return herrors.NewErrUpdateFailed(herrors.ResourceInK8S,
+				fmt.Sprintf("failed to update replicas of hpa(%s) in ns(%s): %v",
+					params.Name, params.Namespace, err))
+		}
+		return nil
+	})
+}
+
+func ofHPA(hpa *autoscalingv2beta2.HorizontalPodAutoscaler) *HPAStatus {
+	status := &HPAStatus{
+		Name:            hpa.Name,
+		Namespace:       hpa.Namespace,
+		ScaleTarget:     fmt.Sprintf("%s/%s", hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name),
+		MinReplicas:     hpa.Spec.MinReplicas,
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+		Metrics:         make([]HPAMetric, 0, len(hpa.Spec.Metrics)),
+		Events:          make([]Event, 0),
+	}
+	if hpa.Status.LastScaleTime != nil {
+		status.LastScaleTime = &hpa.Status.LastScaleTime.Time
+	}
+
+	current := make(map[string]string, len(hpa.Status.CurrentMetrics))
+	for _, metric := range hpa.Status.CurrentMetrics {
+		metricType, name, value := ofMetricStatus(metric)
+		current[metricType+"/"+name] = value
+	}
+	for _, metric := range hpa.Spec.Metrics {
+		metricType, name, target := ofMetricSpec(metric)
+		status.Metrics = append(status.Metrics, HPAMetric{
+			Type:    metricType,
+			Name:    name,
+			Current: current[metricType+"/"+name],
+			Target:  target,
+		})
+	}
+	return status
+}
+
+func ofMetricSpec(metric autoscalingv2beta2.MetricSpec) (metricType, name, target string) {
+	metricType = string(metric.Type)
+	switch {
+	case metric.Resource != nil:
+		return metricType, string(metric.Resource.Name), ofMetricTarget(metric.Resource.Target)
+	case metric.ContainerResource != nil:
+		return metricType, string(metric.ContainerResource.Name), ofMetricTarget(metric.ContainerResource.Target)
+	case metric.Pods != nil:
+		return metricType, metric.Pods.Metric.Name, ofMetricTarget(metric.Pods.Target)
+	case metric.Object != nil:
+		return metricType, metric.Object.Metric.Name, ofMetricTarget(metric.Object.Target)
+	case metric.External != nil:
+		return metricType, metric.External.Metric.Name, ofMetricTarget(metric.External.Target)
+	}
+	return metricType, "", ""
+}
+
+func ofMetricStatus(metric autoscalingv2beta2.MetricStatus) (metricType, name, current string) {
+	metricType = string(metric.Type)
+	switch {
+	case metric.Resource != nil:
+		return metricType, string(metric.Resource.Name), ofMetricValue(metric.Resource.Current)
+	case metric.ContainerResource != nil:
+		return metricType, string(metric.ContainerResource.Name), ofMetricValue(metric.ContainerResource.Current)
+	case metric.Pods != nil:
+		return metricType, metric.Pods.Metric.Name, ofMetricValue(metric.Pods.Current)
+	case metric.Object != nil:
+		return metricType, metric.Object.Metric.Name, ofMetricValue(metric.Object.Current)
+	case metric.External != nil:
+		return metricType, metric.External.Metric.Name, ofMetricValue(metric.External.Current)
+	}
+	return metricType, "", ""
+}
+
+func ofMetricTarget(target autoscalingv2beta2.MetricTarget) string {
+	switch {
+	case target.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	case target.AverageValue != nil:
+		return target.AverageValue.String()
+	case target.Value != nil:
+		return target.Value.String()
+	}
+	return ""
+}
+
+func ofMetricValue(value autoscalingv2beta2.MetricValueStatus) string {
+	switch {
+	case value.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *value.AverageUtilization)
+	case value.AverageValue != nil:
+		return value.AverageValue.String()
+	case value.Value != nil:
+		return value.Value.String()
+	}
+	return ""
+}
+
+func (e *util) ScaleWorkload(ctx context.Context, params *ScaleWorkloadParams) (int32, error) {
+	const op = "k8sutil: scale workload"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	// the plural resource guessed is right for workloads, such as deployments, statefulsets and rollouts
+	gvr, _ := meta.UnsafeGuessKindToResource(params.GVK)
+	var previous int32
+	err := e.informerFactories.GetDynamicClientSet(params.RegionEntity.ID, func(clientset dynamic.Interface) error {
+		un, err := clientset.Resource(gvr).Namespace(params.Namespace).Get(ctx, params.Name, metav1.GetOptions{})
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return herrors.NewErrNotFound(herrors.ResourceInK8S,
+					fmt.Sprintf("%s(%s) not found in ns(%s)", params.Name, gvr.String(), params.Namespace))
+			}
+			return herrors.NewErrGetFailed(herrors.ResourceInK8S,
+				fmt.Sprintf("failed to get %s(%s) in ns(%s): %v", params.Name, gvr.String(), params.Namespace, err))
+		}
+		// replicas defaults to 1 if it is not specified
+		replicas, found, err := unstructured.NestedInt64(un.Object, "spec", "replicas")
+		if err != nil {
+			return perror.Wrapf(herrors.ErrParamInvalid, "invalid replicas of %s: %v", params.Name, err)
+		}
+		previous = 1
+		if found {
+			previous = int32(replicas)
+		}
+
+		bts, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"replicas": params.Replicas,
+			},
+		})
+		if err != nil {
+			return perror.Wrapf(herrors.ErrParamInvalid, "failed to marshal patch: %v", err)
+		}
+		_, err = clientset.Resource(gvr).Namespace(params.Namespace).
+			Patch(ctx, params.Name, types.MergePatchType, bts, metav1.PatchOptions{})
+		if err != nil {
+			return herrors.NewErrUpdateFailed(herrors.ResourceInK8S,
+				fmt.Sprintf("failed to scale %s(%s) in ns(%s): %v", params.Name, gvr.String(), params.Namespace, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return previous, nil
+}
+
+func (e *util) PortForward(ctx context.Context, params *PortForwardParams) error {
+	const op = "cd: port forward"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	config, err := e.informerFactories.GetRestConfig(params.RegionEntity.ID)
+	if err != nil {
+		return err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return perror.Wrap(herrors.ErrHTTPRequestFailed, err.Error())
+	}
+	var reqURL *url.URL
+	_ = e.informerFactories.GetClientSet(params.RegionEntity.ID, func(clientset kubernetes.Interface) error {
+		reqURL = clientset.CoreV1().RESTClient().Post().
+			Resource("pods").
+			Namespace(params.Namespace).
+			Name(params.Pod).
+			SubResource("portforward").URL()
+		return nil
+	})
+	if reqURL == nil {
+		return perror.Wrapf(herrors.ErrHTTPRequestFailed, "no clientset for region %d", params.RegionEntity.ID)
+	}
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, reqURL)
+	conn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return perror.Wrapf(herrors.ErrHTTPRequestFailed, "failed to upgrade connection: %v", err)
+	}
+	defer conn.Close()
+
+	// the error stream and the data stream are paired by the request id, see kubectl port-forward
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(int(params.Port)))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return perror.Wrapf(herrors.ErrHTTPRequestFailed, "failed to create error stream: %v", err)
+	}
+	// nothing is written to the error stream
+	_ = errorStream.Close()
+	errorChan := make(chan error, 1)
+	go func() {
+		message, err := ioutil.ReadAll(errorStream)
+		if err != nil {
+			errorChan <- err
+		} else if len(message) > 0 {
+			errorChan <- fmt.Errorf("%s", message)
+		}
+		close(errorChan)
+	}()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return perror.Wrapf(herrors.ErrHTTPRequestFailed, "failed to create data stream: %v", err)
+	}
+	localDone, remoteDone := make(chan struct{}), make(chan struct{})
+	go func() {
+		_, _ = io.Copy(params.Stream, dataStream)
+		close(remoteDone)
+	}()
+	go func() {
+		_, _ = io.Copy(dataStream, params.Stream)
+		_ = dataStream.Close()
+		close(localDone)
+	}()
+	select {
+	case <-remoteDone:
+	case <-localDone:
+	case <-ctx.Done():
+	}
+
+	select {
+	case err := <-errorChan:
+		if err != nil {
+			return perror.Wrapf(herrors.ErrHTTPRequestFailed, "failed to forward port %d: %v", params.Port, err)
+		}
+	default:
+	}
+	return nil
+}
+
+func (e *util) GetPodProxy(ctx context.Context, params *GetPodProxyParams) (http.Handler, error) {
+	config, err := e.informerFactories.GetRestConfig(params.RegionEntity.ID)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrHTTPRequestFailed, err.Error())
+	}
+	target, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrHTTPRequestFailed, err.Error())
+	}
+	location := path.Join(target.Path, "/api/v1/namespaces", params.Namespace, "pods",
+		fmt.Sprintf("%s:%d", params.Pod, params.Port), "proxy")
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = location + "/" + strings.TrimPrefix(params.Path, "/")
+			req.URL.RawPath = ""
+			req.Host = target.Host
+			// credentials of horizon must not be leaked to the pod,
+			// and the authorization of the apiserver is set by the transport
+			req.Header.Del("Authorization")
+			req.Header.Del("Cookie")
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// pages of the pod are served under the origin of horizon,
+			// so they are sandboxed to keep them from the cookies and apis of horizon
+			resp.Header.Set("Content-Security-Policy", "sandbox allow-scripts allow-forms allow-popups allow-modals")
+			return nil
+		},
+		Transport: transport,
+	}, nil
+}
@@ -15,6 +15,9 @@
 package cd
 
 import (
+	"io"
+	"time"
+
 	applicationV1alpha1 "github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
 	"github.com/argoproj/gitops-engine/pkg/health"
 	corev1 "k8s.io/api/core/v1"
@@ -111,6 +114,32 @@ type GetContainerLogParams struct {
 	TailLines    int64
 }
 
+type ListJobRunsParams struct {
+	RegionEntity *regionmodels.RegionEntity
+	Namespace    string
+	// Name is the name of the cronjob or job
+	Name  string
+	Limit int
+}
+
+// JobRun is a job created by cronjob, or the job itself
+type JobRun struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Manual means the job is triggered manually rather than scheduled
+	Manual         bool       `json:"manual"`
+	StartTime      *time.Time `json:"startTime,omitempty"`
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	// Pods are the pods of the job, whose logs can be fetched by the container log api
+	Pods []JobRunPod `json:"pods"`
+}
+
+type JobRunPod struct {
+	Name       string   `json:"name"`
+	Phase      string   `json:"phase"`
+	Containers []string `json:"containers"`
+}
+
 type ExecParams struct {
 	Commands     []string
 	Environment  string
@@ -203,6 +232,7 @@ type Step struct {
 	Total        int     `json:"total"`
 	Replicas     []int   `json:"replicas"`
 	ManualPaused bool    `json:"manualPaused"`
+	Paused       bool    `json:"paused"`
 	AutoPromote  bool    `json:"autoPromote"`
 	Extra        *string `json:"extra"`
 }
@@ -414,3 +444,66 @@ func ContainerCompact(container corev1.Container) CompactContainer {
 		ReadinessProbe: container.ReadinessProbe,
 	}
 }
+
+type GetHPAParams struct {
+	RegionEntity *regionmodels.RegionEntity
+	Namespace    string
+	Name         string
+}
+
+type UpdateHPAReplicasParams struct {
+	RegionEntity *regionmodels.RegionEntity
+	Namespace    string
+	Name         string
+	MinReplicas  *int32
+	MaxReplicas  int32
+}
+
+// HPAStatus is the scaling status of a HorizontalPodAutoscaler
+type HPAStatus struct {
+	Name            string      `json:"name"`
+	Namespace       string      `json:"namespace"`
+	ScaleTarget     string      `json:"scaleTarget"`
+	MinReplicas     *int32      `json:"minReplicas,omitempty"`
+	MaxReplicas     int32       `json:"maxReplicas"`
+	CurrentReplicas int32       `json:"currentReplicas"`
+	DesiredReplicas int32       `json:"desiredReplicas"`
+	LastScaleTime   *time.Time  `json:"lastScaleTime,omitempty"`
+	Metrics         []HPAMetric `json:"metrics"`
+	// Events are the recent scaling events, latest first
+	Events []Event `json:"events"`
+}
+
+// HPAMetric is the current and target value of a metric used by HorizontalPodAutoscaler
+type HPAMetric struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Current string `json:"current,omitempty"`
+	Target  string `json:"target,omitempty"`
+}
+
+type ScaleWorkloadParams struct {
+	RegionEntity *regionmodels.RegionEntity
+	GVK          schema.GroupVersionKind
+	Namespace    string
+	Name         string
+	Replicas     int32
+}
+
+type PortForwardParams struct {
+	RegionEntity *regionmodels.RegionEntity
+	Namespace    string
+	Pod          string
+	Port         uint16
+	// Stream is bridged to the port of the pod until either side is closed
+	Stream io.ReadWriter
+}
+
+type GetPodProxyParams struct {
+	RegionEntity *regionmodels.RegionEntity
+	Namespace    string
+	Pod          string
+	Port         uint16
+	// Path is the path requested on the pod
+	Path string
+}
@@ -0,0 +1,147 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/changerequest/models"
+)
+
+type DAO interface {
+	Create(ctx context.Context, changeRequest *models.ChangeRequest) (*models.ChangeRequest, error)
+	GetByID(ctx context.Context, id uint) (*models.ChangeRequest, error)
+	ListByClusterID(ctx context.Context, clusterID uint,
+		query *q.Query) ([]*models.ChangeRequest, int64, error)
+	UpdateByID(ctx context.Context, id uint, changeRequest *models.ChangeRequest) error
+	UpdateStatus(ctx context.Context, id uint, from, to models.Status) error
+	DeleteByID(ctx context.Context, id uint) error
+	CreateReview(ctx context.Context, review *models.ChangeRequestReview) (*models.ChangeRequestReview, error)
+	ListReviews(ctx context.Context, changeRequestID uint) ([]*models.ChangeRequestReview, error)
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Create(ctx context.Context, changeRequest *models.ChangeRequest) (*models.ChangeRequest, error) {
+	if err := d.db.WithContext(ctx).Create(changeRequest).Error; err != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.ChangeRequestInDB, err.Error())
+	}
+	return changeRequest, nil
+}
+
+func (d *dao) GetByID(ctx context.Context, id uint) (*models.ChangeRequest, error) {
+	var changeRequest models.ChangeRequest
+	if err := d.db.WithContext(ctx).First(&changeRequest, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.ChangeRequestInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.ChangeRequestInDB, err.Error())
+	}
+	return &changeRequest, nil
+}
+
+func (d *dao) ListByClusterID(ctx context.Context, clusterID uint,
+	query *q.Query) ([]*models.ChangeRequest, int64, error) {
+	sql := d.db.WithContext(ctx).Model(&models.ChangeRequest{}).
+		Where("cluster_id = ?", clusterID)
+	if query != nil {
+		if status, ok := query.Keywords[common.ChangeRequestQueryByStatus]; ok {
+			sql = sql.Where("status = ?", status)
+		}
+	} else {
+		query = &q.Query{}
+	}
+
+	var total int64
+	if err := sql.Count(&total).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.ChangeRequestInDB, err.Error())
+	}
+
+	var changeRequests []*models.ChangeRequest
+	if err := sql.Order("id desc").Limit(query.Limit()).Offset(query.Offset()).
+		Find(&changeRequests).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.ChangeRequestInDB, err.Error())
+	}
+	return changeRequests, total, nil
+}
+
+func (d *dao) UpdateByID(ctx context.Context, id uint, changeRequest *models.ChangeRequest) error {
+	where := d.db.WithContext(ctx).Model(&models.ChangeRequest{}).Where("id = ?", id)
+	if err := where.Updates(changeRequest).Error; err != nil {
+		return herrors.NewErrUpdateFailed(herrors.ChangeRequestInDB, err.Error())
+	}
+	return nil
+}
+
+func (d *dao) UpdateStatus(ctx context.Context, id uint, from, to models.Status) error {
+	result := d.db.WithContext(ctx).Model(&models.ChangeRequest{}).
+		Where("id = ? AND status = ?", id, from).Update("status", to)
+	if result.Error != nil {
+		return herrors.NewErrUpdateFailed(herrors.ChangeRequestInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return herrors.NewErrNotFound(herrors.ChangeRequestInDB,
+			"change request is not found or its status has been changed")
+	}
+	return nil
+}
+
+func (d *dao) DeleteByID(ctx context.Context, id uint) error {
+	if err := d.db.WithContext(ctx).Delete(&models.ChangeRequest{}, id).Error; err != nil {
+		return herrors.NewErrDeleteFailed(herrors.ChangeRequestInDB, err.Error())
+	}
+	return nil
+}
+
+// CreateReview saves the review, which replaces the former review of the same reviewer
+func (d *dao) CreateReview(ctx context.Context,
+	review *models.ChangeRequestReview) (*models.ChangeRequestReview, error) {
+	result := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{
+				Name: "change_request_id",
+			}, {
+				Name: "created_by",
+			},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"approved", "comment", "created_at"}),
+	}).Create(review)
+	if result.Error != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.ChangeRequestReviewInDB, result.Error.Error())
+	}
+	return review, nil
+}
+
+func (d *dao) ListReviews(ctx context.Context, changeRequestID uint) ([]*models.ChangeRequestReview, error) {
+	var reviews []*models.ChangeRequestReview
+	if err := d.db.WithContext(ctx).Where("change_request_id = ?", changeRequestID).
+		Order("id asc").Find(&reviews).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.ChangeRequestReviewInDB, err.Error())
+	}
+	return reviews, nil
+}
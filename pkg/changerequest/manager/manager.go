@@ -0,0 +1,88 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/changerequest/dao"
+	"github.com/horizoncd/horizon/pkg/changerequest/models"
+)
+
+type Manager interface {
+	// Create creates a change request
+	Create(ctx context.Context, changeRequest *models.ChangeRequest) (*models.ChangeRequest, error)
+	// GetByID gets a change request by ID
+	GetByID(ctx context.Context, id uint) (*models.ChangeRequest, error)
+	// ListByClusterID lists the change requests of the cluster, filtered by status in query keywords
+	ListByClusterID(ctx context.Context, clusterID uint,
+		query *q.Query) ([]*models.ChangeRequest, int64, error)
+	// UpdateByID updates the non-zero fields of the change request
+	UpdateByID(ctx context.Context, id uint, changeRequest *models.ChangeRequest) error
+	// UpdateStatus changes the status of change request only if it is in status from,
+	// a not found error is returned otherwise
+	UpdateStatus(ctx context.Context, id uint, from, to models.Status) error
+	// DeleteByID deletes a change request
+	DeleteByID(ctx context.Context, id uint) error
+	// CreateReview saves the review, which replaces the former review of the same reviewer
+	CreateReview(ctx context.Context, review *models.ChangeRequestReview) (*models.ChangeRequestReview, error)
+	// ListReviews lists the reviews of change request
+	ListReviews(ctx context.Context, changeRequestID uint) ([]*models.ChangeRequestReview, error)
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) Create(ctx context.Context, changeRequest *models.ChangeRequest) (*models.ChangeRequest, error) {
+	return m.dao.Create(ctx, changeRequest)
+}
+
+func (m *manager) GetByID(ctx context.Context, id uint) (*models.ChangeRequest, error) {
+	return m.dao.GetByID(ctx, id)
+}
+
+func (m *manager) ListByClusterID(ctx context.Context, clusterID uint,
+	query *q.Query) ([]*models.ChangeRequest, int64, error) {
+	return m.dao.ListByClusterID(ctx, clusterID, query)
+}
+
+func (m *manager) UpdateByID(ctx context.Context, id uint, changeRequest *models.ChangeRequest) error {
+	return m.dao.UpdateByID(ctx, id, changeRequest)
+}
+
+func (m *manager) UpdateStatus(ctx context.Context, id uint, from, to models.Status) error {
+	return m.dao.UpdateStatus(ctx, id, from, to)
+}
+
+func (m *manager) DeleteByID(ctx context.Context, id uint) error {
+	return m.dao.DeleteByID(ctx, id)
+}
+
+func (m *manager) CreateReview(ctx context.Context,
+	review *models.ChangeRequestReview) (*models.ChangeRequestReview, error) {
+	return m.dao.CreateReview(ctx, review)
+}
+
+func (m *manager) ListReviews(ctx context.Context, changeRequestID uint) ([]*models.ChangeRequestReview, error) {
+	return m.dao.ListReviews(ctx, changeRequestID)
+}
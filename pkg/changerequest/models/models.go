@@ -0,0 +1,64 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+type Status string
+
+const (
+	// StatusOpen the change request is waiting for approvals
+	StatusOpen Status = "open"
+	// StatusMerged the change has been merged into gitops branch and applied to the cluster
+	StatusMerged Status = "merged"
+	// StatusRejected the change request is rejected by a cluster owner
+	StatusRejected Status = "rejected"
+	// StatusClosed the change request is closed without merging
+	StatusClosed Status = "closed"
+)
+
+// ChangeRequest is a config change of cluster committed to its own branch,
+// which is merged into gitops branch only after being approved by cluster owners
+type ChangeRequest struct {
+	global.Model
+
+	ClusterID   uint
+	Title       string
+	Description string
+	Branch      string
+	// Request is the json of the cluster update request, which is applied when merging
+	Request    string
+	MergePatch bool
+	// RequiredApprovals the number of approvals required by the environment of cluster
+	RequiredApprovals int
+	Status            Status
+
+	CreatedBy uint
+	UpdatedBy uint
+}
+
+// ChangeRequestReview is the approval or rejection of a change request by a cluster owner
+type ChangeRequestReview struct {
+	ID              uint
+	ChangeRequestID uint `gorm:"uniqueIndex:uk_change_request_reviewer"`
+	Approved        bool
+	Comment         string
+	CreatedAt       time.Time
+	CreatedBy       uint `gorm:"uniqueIndex:uk_change_request_reviewer"`
+}
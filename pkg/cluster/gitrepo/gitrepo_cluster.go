@@ -25,21 +25,20 @@ import (
 
 	"github.com/horizoncd/horizon/core/common"
 	herrors "github.com/horizoncd/horizon/core/errors"
-	gitlablib "github.com/horizoncd/horizon/lib/gitlab"
 	"github.com/horizoncd/horizon/pkg/application/models"
 	pkgcommon "github.com/horizoncd/horizon/pkg/common"
 	"github.com/horizoncd/horizon/pkg/config/template"
 	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
 	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
 	tagmodels "github.com/horizoncd/horizon/pkg/tag/models"
 	trmodels "github.com/horizoncd/horizon/pkg/templaterelease/models"
 	"github.com/horizoncd/horizon/pkg/templaterepo"
 	"github.com/horizoncd/horizon/pkg/util/angular"
-	utilcommon "github.com/horizoncd/horizon/pkg/util/common"
 	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/mergemap"
 	timeutil "github.com/horizoncd/horizon/pkg/util/time"
 	"github.com/horizoncd/horizon/pkg/util/wlog"
-	"github.com/xanzy/go-gitlab"
 	"gopkg.in/yaml.v3"
 	kyaml "sigs.k8s.io/yaml"
 )
@@ -71,6 +70,8 @@ type CreateClusterParams struct {
 
 type UpdateClusterParams struct {
 	*BaseParams
+	// Branch is the branch to write, which is created from gitops branch if not exists, defaults to gitops branch
+	Branch string
 }
 
 type RepoInfo struct {
@@ -154,6 +155,10 @@ type ClusterGitRepo interface {
 	// GetManifest returns manifest with specific revision, defaults to gitops branch
 	GetManifest(ctx context.Context, application,
 		cluster string, commit *string) (*pkgcommon.Manifest, error)
+	// GetValues returns the values merged from the value files deployed by cd in order,
+	// with specific revision, defaults to gitops branch
+	GetValues(ctx context.Context, application,
+		cluster string, commit *string) (map[string]interface{}, error)
 	// CheckAndSyncGitOpsBranch checks and sync if gitops branch is not up-to-date with master branch
 	// for internal usage
 	CheckAndSyncGitOpsBranch(ctx context.Context, application, cluster, commit string) error
@@ -161,29 +166,29 @@ type ClusterGitRepo interface {
 	SyncGitOpsBranch(ctx context.Context, application, cluster string) error
 }
 type clusterGitopsRepo struct {
-	gitlabLib              gitlablib.Interface
-	clustersGroup          *gitlab.Group
-	recyclingClustersGroup *gitlab.Group
+	gitopsLib              gitops.Interface
+	clustersGroup          *gitops.Group
+	recyclingClustersGroup *gitops.Group
 	templateRepo           templaterepo.TemplateRepo
 	defaultBranch          string
 	defaultVisibility      string
 }
 
-func NewClusterGitlabRepo(ctx context.Context, rootGroup *gitlab.Group,
+func NewClusterGitopsRepo(ctx context.Context, rootGroup *gitops.Group,
 	templateRepo templaterepo.TemplateRepo,
-	gitlabLib gitlablib.Interface, defaultBranch string, defaultVisibility string) (ClusterGitRepo, error) {
-	clustersGroup, err := gitlabLib.GetCreatedGroup(ctx, rootGroup.ID, rootGroup.FullPath,
+	gitopsLib gitops.Interface, defaultBranch string, defaultVisibility string) (ClusterGitRepo, error) {
+	clustersGroup, err := gitopsLib.GetCreatedGroup(ctx, rootGroup,
 		common.GitopsGroupClusters, defaultVisibility)
 	if err != nil {
 		return nil, err
 	}
-	recyclingClustersGroup, err := gitlabLib.GetCreatedGroup(ctx,
-		rootGroup.ID, rootGroup.FullPath, common.GitopsGroupRecyclingClusters, defaultVisibility)
+	recyclingClustersGroup, err := gitopsLib.GetCreatedGroup(ctx,
+		rootGroup, common.GitopsGroupRecyclingClusters, defaultVisibility)
 	if err != nil {
 		return nil, err
 	}
 	return &clusterGitopsRepo{
-		gitlabLib:              gitlabLib,
+		gitopsLib:              gitopsLib,
 		clustersGroup:          clustersGroup,
 		recyclingClustersGroup: recyclingClustersGroup,
 		templateRepo:           templateRepo,
@@ -206,7 +211,7 @@ func (g *clusterGitopsRepo) GetCluster(ctx context.Context,
 	wg.Add(3)
 	go func() {
 		defer wg.Done()
-		pipelineBytes, err1 = g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFilePipeline)
+		pipelineBytes, err1 = g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFilePipeline)
 		if err1 != nil {
 			return
 		}
@@ -217,7 +222,7 @@ func (g *clusterGitopsRepo) GetCluster(ctx context.Context,
 	}()
 	go func() {
 		defer wg.Done()
-		applicationBytes, err2 = g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileApplication)
+		applicationBytes, err2 = g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileApplication)
 		if err2 != nil {
 			return
 		}
@@ -228,7 +233,7 @@ func (g *clusterGitopsRepo) GetCluster(ctx context.Context,
 	}()
 	go func() {
 		defer wg.Done()
-		manifestBytes, err3 = g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileManifest)
+		manifestBytes, err3 = g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileManifest)
 		if err3 != nil {
 			return
 		}
@@ -351,7 +356,7 @@ func (g *clusterGitopsRepo) GetClusterValueFiles(ctx context.Context,
 	for i := 0; i < len(cases); i++ {
 		go func(index int) {
 			defer wg.Done()
-			cases[index].Bytes, cases[index].Err = g.gitlabLib.GetFile(ctx, pid,
+			cases[index].Bytes, cases[index].Err = g.gitopsLib.GetFile(ctx, pid,
 				g.defaultBranch, cases[index].FileName)
 			if cases[index].Err != nil {
 				log.Warningf(ctx, "get file %s error, err = %s",
@@ -404,7 +409,7 @@ func (g *clusterGitopsRepo) GetClusterTemplate(ctx context.Context, application,
 
 	// 1. get Chart file from git
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
-	file, err := g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileChart)
+	file, err := g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileChart)
 	if err != nil {
 		return nil, err
 	}
@@ -436,20 +441,20 @@ func (g *clusterGitopsRepo) CreateCluster(ctx context.Context, params *CreateClu
 	}
 
 	// 1. create application group if necessary
-	appGroup, err := g.gitlabLib.GetCreatedGroup(ctx, g.clustersGroup.ID,
-		g.clustersGroup.FullPath, params.Application.Name, g.defaultVisibility)
+	appGroup, err := g.gitopsLib.GetCreatedGroup(ctx, g.clustersGroup,
+		params.Application.Name, g.defaultVisibility)
 	if err != nil {
 		return err
 	}
 
 	// 3. create cluster repo under appGroup
-	if _, err := g.gitlabLib.CreateProject(ctx, params.Cluster, appGroup.ID, g.defaultVisibility); err != nil {
+	if _, err := g.gitopsLib.CreateProject(ctx, params.Cluster, appGroup, g.defaultVisibility); err != nil {
 		return err
 	}
 
 	// 3. create gitops branch from master
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, params.Application.Name, params.Cluster)
-	if _, err := g.gitlabLib.CreateBranch(ctx, pid, GitOpsBranch, g.defaultBranch); err != nil {
+	if _, err := g.gitopsLib.CreateBranch(ctx, pid, GitOpsBranch, g.defaultBranch); err != nil {
 		return err
 	}
 
@@ -484,60 +489,60 @@ func (g *clusterGitopsRepo) CreateCluster(ctx context.Context, params *CreateClu
 			return err
 		}
 	}
-	actions := func() []gitlablib.CommitAction {
-		gitActions := []gitlablib.CommitAction{
+	actions := func() []gitops.CommitAction {
+		gitActions := []gitops.CommitAction{
 			{
-				Action:   gitlablib.FileCreate,
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileTags,
 				Content:  string(tagsYAML),
 			}, {
-				Action:   gitlablib.FileCreate,
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileBase,
 				Content:  string(baseValueYAML),
 			}, {
-				Action:   gitlablib.FileCreate,
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileEnv,
 				Content:  string(envValueYAML),
 			}, {
-				Action:   gitlablib.FileCreate,
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileSRE,
 				Content:  string(sreValueYAML),
 			}, {
-				Action:   gitlablib.FileCreate,
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileChart,
 				Content:  string(chartYAML),
 			},
 			// create GitopsFilePipelineOutput file first
 			{
-				Action:   gitlablib.FileCreate,
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFilePipelineOutput,
 				Content:  "",
 			},
 			// create GitopsFileRestart file first
 			{
-				Action:   gitlablib.FileCreate,
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileRestart,
 				Content:  string(restartYAML),
 			},
 		}
 
 		if applicationYAML != nil {
-			gitActions = append(gitActions, gitlablib.CommitAction{
-				Action:   gitlablib.FileCreate,
+			gitActions = append(gitActions, gitops.CommitAction{
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileApplication,
 				Content:  string(applicationYAML),
 			})
 		}
 		if pipelineYAML != nil {
-			gitActions = append(gitActions, gitlablib.CommitAction{
-				Action:   gitlablib.FileCreate,
+			gitActions = append(gitActions, gitops.CommitAction{
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFilePipeline,
 				Content:  string(pipelineYAML),
 			})
 		}
 		if manifestValueYAML != nil {
-			gitActions = append(gitActions, gitlablib.CommitAction{
-				Action:   gitlablib.FileCreate,
+			gitActions = append(gitActions, gitops.CommitAction{
+				Action:   gitops.FileCreate,
 				FilePath: common.GitopsFileManifest,
 				Content:  string(manifestValueYAML),
 			})
@@ -557,7 +562,7 @@ func (g *clusterGitopsRepo) CreateCluster(ctx context.Context, params *CreateClu
 		Pipeline:    params.PipelineJSONBlob,
 	})
 
-	if _, err := g.gitlabLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions); err != nil {
+	if _, err := g.gitopsLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions); err != nil {
 		return err
 	}
 
@@ -600,21 +605,21 @@ func (g *clusterGitopsRepo) UpdateCluster(ctx context.Context, params *UpdateClu
 		}
 	}
 
-	actions, err := func() ([]gitlablib.CommitAction, error) {
-		gitActions := []gitlablib.CommitAction{
+	actions, err := func() ([]gitops.CommitAction, error) {
+		gitActions := []gitops.CommitAction{
 			{
-				Action:   gitlablib.FileUpdate,
+				Action:   gitops.FileUpdate,
 				FilePath: common.GitopsFileBase,
 				Content:  string(baseValueYAML),
 			}, {
-				Action:   gitlablib.FileUpdate,
+				Action:   gitops.FileUpdate,
 				FilePath: common.GitopsFileChart,
 				Content:  string(chartYAML),
 			},
 		}
 
-		templateUpdate, pipelineUpdate, err := func() (gitlablib.FileAction, gitlablib.FileAction, error) {
-			applicationUpdate, pipelineUpdate := gitlablib.FileCreate, gitlablib.FileCreate
+		templateUpdate, pipelineUpdate, err := func() (gitops.FileAction, gitops.FileAction, error) {
+			applicationUpdate, pipelineUpdate := gitops.FileCreate, gitops.FileCreate
 			if applicationYAML != nil || pipelineYAML != nil {
 				files, err := g.GetCluster(ctx, params.Application.Name, params.Cluster,
 					params.TemplateRelease.TemplateName)
@@ -622,10 +627,10 @@ func (g *clusterGitopsRepo) UpdateCluster(ctx context.Context, params *UpdateClu
 					return applicationUpdate, pipelineUpdate, err
 				}
 				if files.ApplicationJSONBlob != nil {
-					applicationUpdate = gitlablib.FileUpdate
+					applicationUpdate = gitops.FileUpdate
 				}
 				if files.PipelineJSONBlob != nil {
-					pipelineUpdate = gitlablib.FileUpdate
+					pipelineUpdate = gitops.FileUpdate
 				}
 			}
 			return applicationUpdate, pipelineUpdate, nil
@@ -635,22 +640,22 @@ func (g *clusterGitopsRepo) UpdateCluster(ctx context.Context, params *UpdateClu
 		}
 
 		if applicationYAML != nil {
-			gitActions = append(gitActions, gitlablib.CommitAction{
+			gitActions = append(gitActions, gitops.CommitAction{
 				Action:   templateUpdate,
 				FilePath: common.GitopsFileApplication,
 				Content:  string(applicationYAML),
 			})
 		}
 		if pipelineYAML != nil {
-			gitActions = append(gitActions, gitlablib.CommitAction{
+			gitActions = append(gitActions, gitops.CommitAction{
 				Action:   pipelineUpdate,
 				FilePath: common.GitopsFilePipeline,
 				Content:  string(pipelineYAML),
 			})
 		}
 		if envValueYAML != nil {
-			gitActions = append(gitActions, gitlablib.CommitAction{
-				Action:   gitlablib.FileUpdate,
+			gitActions = append(gitActions, gitops.CommitAction{
+				Action:   gitops.FileUpdate,
 				FilePath: common.GitopsFileEnv,
 				Content:  string(envValueYAML),
 			})
@@ -672,7 +677,11 @@ func (g *clusterGitopsRepo) UpdateCluster(ctx context.Context, params *UpdateClu
 		Application: params.ApplicationJSONBlob,
 		Pipeline:    params.PipelineJSONBlob,
 	})
-	if _, err := g.gitlabLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions); err != nil {
+	branch, startBranch := GitOpsBranch, (*string)(nil)
+	if params.Branch != "" && params.Branch != GitOpsBranch {
+		branch, startBranch = params.Branch, angular.StringPtr(GitOpsBranch)
+	}
+	if _, err := g.gitopsLib.WriteFiles(ctx, pid, branch, commitMsg, startBranch, actions); err != nil {
 		return err
 	}
 
@@ -685,13 +694,13 @@ func (g *clusterGitopsRepo) DeleteCluster(ctx context.Context,
 	defer wlog.Start(ctx, op).StopPrint()
 
 	// 1. create application group if necessary
-	_, err = g.gitlabLib.GetGroup(ctx, fmt.Sprintf("%v/%v", g.recyclingClustersGroup.FullPath, application))
+	_, err = g.gitopsLib.GetGroup(ctx, fmt.Sprintf("%v/%v", g.recyclingClustersGroup.FullPath, application))
 	if err != nil {
 		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
 			return err
 		}
-		_, err = g.gitlabLib.CreateGroup(ctx, application, application,
-			&g.recyclingClustersGroup.ID, g.defaultVisibility)
+		_, err = g.gitopsLib.CreateGroup(ctx, application, application,
+			g.recyclingClustersGroup, g.defaultVisibility)
 		if err != nil {
 			return err
 		}
@@ -702,13 +711,13 @@ func (g *clusterGitopsRepo) DeleteCluster(ctx context.Context,
 	// 1.1 edit project's name and path to {cluster}-{clusterID}
 	newName := fmt.Sprintf("%v-%d", cluster, clusterID)
 	newPath := newName
-	if err := g.gitlabLib.EditNameAndPathForProject(ctx, pid, &newName, &newPath); err != nil {
+	if err := g.gitopsLib.EditNameAndPathForProject(ctx, pid, &newName, &newPath); err != nil {
 		return err
 	}
 
 	// 1.2 transfer project to RecyclingParent
 	newPid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, newPath)
-	return g.gitlabLib.TransferProject(ctx, newPid,
+	return g.gitopsLib.TransferProject(ctx, newPid,
 		fmt.Sprintf("%v/%v", g.recyclingClustersGroup.FullPath, application))
 }
 
@@ -718,7 +727,7 @@ func (g *clusterGitopsRepo) HardDeleteCluster(ctx context.Context, application,
 	defer wlog.Start(ctx, op).StopPrint()
 
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
-	return g.gitlabLib.DeleteProject(ctx, pid)
+	return g.gitopsLib.DeleteProject(ctx, pid)
 }
 
 func (g *clusterGitopsRepo) CompareConfig(ctx context.Context, application,
@@ -728,20 +737,17 @@ func (g *clusterGitopsRepo) CompareConfig(ctx context.Context, application,
 
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
 
-	var compare *gitlab.Compare
+	var diffs []*gitops.Diff
 	if from == nil || to == nil {
-		compare, err = g.gitlabLib.Compare(ctx, pid, g.defaultBranch, GitOpsBranch, nil)
+		diffs, err = g.gitopsLib.Compare(ctx, pid, g.defaultBranch, GitOpsBranch, false)
 	} else {
-		compare, err = g.gitlabLib.Compare(ctx, pid, *from, *to, nil)
+		diffs, err = g.gitopsLib.Compare(ctx, pid, *from, *to, false)
 	}
 	if err != nil {
 		return "", err
 	}
-	if compare.Diffs == nil {
-		return "", nil
-	}
 	diffStr := ""
-	for _, diff := range compare.Diffs {
+	for _, diff := range diffs {
 		diffStr += "--- " + diff.OldPath + "\n"
 		diffStr += "+++ " + diff.NewPath + "\n"
 		diffStr += diff.Diff + "\n"
@@ -751,7 +757,6 @@ func (g *clusterGitopsRepo) CompareConfig(ctx context.Context, application,
 
 func (g *clusterGitopsRepo) MergeBranch(ctx context.Context, application, cluster,
 	sourceBranch, targetBranch string, pipelineRunID *uint) (_ string, err error) {
-	removeSourceBranch := false
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
 
 	var title string
@@ -762,42 +767,7 @@ func (g *clusterGitopsRepo) MergeBranch(ctx context.Context, application, cluste
 		title = fmt.Sprintf("git merge %v into %v", sourceBranch, targetBranch)
 	}
 
-	var mr *gitlab.MergeRequest
-	mrs, err := g.gitlabLib.ListMRs(ctx, pid, sourceBranch,
-		targetBranch, common.GitopsMergeRequestStateOpen)
-	if err != nil {
-		return "", perror.WithMessage(err, "failed to list merge requests")
-	}
-	if len(mrs) > 0 {
-		// merge old mr when it is existed, because given specified source and target, gitlab only allows 1 mr to exist
-		mr = mrs[0]
-
-		// close the redundant mrs
-		// gitlab has a bug for when concurrency create merge request(will exist 2 more merge request for the same
-		// (source,target), caused we can't merge anymore)
-		if len(mrs) >= 2 {
-			log.Warningf(ctx, "there %d mrs for (src:%s, des:%s), here will kill redundant mrs",
-				len(mrs), sourceBranch, targetBranch)
-			for i := 1; i < len(mrs); i++ {
-				_, err := g.gitlabLib.CloseMR(ctx, pid, mrs[i].IID)
-				if err != nil {
-					return "", err
-				}
-			}
-		}
-	} else {
-		// create new mr
-		mr, err = g.gitlabLib.CreateMR(ctx, pid, sourceBranch, targetBranch, title)
-		if err != nil {
-			return "", perror.WithMessage(err, "failed to create new merge request")
-		}
-	}
-
-	mr, err = g.gitlabLib.AcceptMR(ctx, pid, mr.IID, &title, &removeSourceBranch)
-	if err != nil {
-		return "", perror.WithMessage(err, "failed to accept merge request")
-	}
-	return mr.MergeCommitSHA, nil
+	return g.gitopsLib.MergeBranch(ctx, pid, sourceBranch, targetBranch, title)
 }
 
 func (g *clusterGitopsRepo) GetManifest(ctx context.Context, application,
@@ -809,9 +779,9 @@ func (g *clusterGitopsRepo) GetManifest(ctx context.Context, application,
 	var content []byte
 	var err error
 	if commit != nil {
-		content, err = g.gitlabLib.GetFile(ctx, pid, *commit, common.GitopsFileManifest)
+		content, err = g.gitopsLib.GetFile(ctx, pid, *commit, common.GitopsFileManifest)
 	} else {
-		content, err = g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileManifest)
+		content, err = g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileManifest)
 	}
 	if err != nil {
 		return nil, err
@@ -833,11 +803,43 @@ func (g *clusterGitopsRepo) GetManifest(ctx context.Context, application,
 	return manifest, nil
 }
 
+func (g *clusterGitopsRepo) GetValues(ctx context.Context, application,
+	cluster string, commit *string) (map[string]interface{}, error) {
+	const op = "cluster git repo: get values"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
+	ref := GitOpsBranch
+	if commit != nil {
+		ref = *commit
+	}
+	values := make(map[string]interface{})
+	for _, fileName := range g.GetRepoInfo(ctx, application, cluster).ValueFiles {
+		content, err := g.gitopsLib.GetFile(ctx, pid, ref, fileName)
+		if err != nil {
+			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+				continue
+			}
+			return nil, err
+		}
+		fileValues := make(map[string]interface{})
+		if err := kyaml.Unmarshal(content, &fileValues); err != nil {
+			return nil, perror.Wrapf(herrors.ErrParamInvalid,
+				"yaml Unmarshal err, file = %s", fileName)
+		}
+		// the latter value files override the former ones, as helm does
+		if values, err = mergemap.Merge(values, fileValues); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
 func (g *clusterGitopsRepo) GetPipelineOutput(ctx context.Context, application, cluster string,
 	template string) (interface{}, error) {
 	ret := make(map[string]interface{})
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
-	content, err := g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFilePipelineOutput)
+	content, err := g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFilePipelineOutput)
 	if err != nil {
 		return nil, perror.WithMessage(err, "failed to get gitlab file")
 	}
@@ -860,7 +862,7 @@ func (g *clusterGitopsRepo) GetPipelineOutput(ctx context.Context, application,
 func (g *clusterGitopsRepo) getPipelineOutput(ctx context.Context,
 	application, cluster string) (map[string]map[string]interface{}, error) {
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
-	content, err := g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFilePipelineOutput)
+	content, err := g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFilePipelineOutput)
 	if err != nil {
 		return nil, err
 	}
@@ -923,13 +925,13 @@ func (g *clusterGitopsRepo) UpdatePipelineOutput(ctx context.Context, applicatio
 		return "", perror.Wrap(herrors.ErrPipelineOutPut, err.Error())
 	}
 
-	actions := []gitlablib.CommitAction{
+	actions := []gitops.CommitAction{
 		{
-			Action: func() gitlablib.FileAction {
+			Action: func() gitops.FileAction {
 				if PipelineOutPutFileExist {
-					return gitlablib.FileUpdate
+					return gitops.FileUpdate
 				}
-				return gitlablib.FileCreate
+				return gitops.FileCreate
 			}(),
 			FilePath: common.GitopsFilePipelineOutput,
 			Content:  string(newPipelineOutPutBytes),
@@ -947,7 +949,7 @@ func (g *clusterGitopsRepo) UpdatePipelineOutput(ctx context.Context, applicatio
 	}, pipelineOutput)
 
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
-	commit, err := g.gitlabLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions)
+	commit, err := g.gitopsLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions)
 	if err != nil {
 		return "", perror.WithMessage(err, "failed to write gitlab files")
 	}
@@ -958,7 +960,7 @@ func (g *clusterGitopsRepo) GetRestartTime(ctx context.Context, application, clu
 	template string) (string, error) {
 	ret := make(map[string]map[string]string)
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
-	content, err := g.gitlabLib.GetFile(ctx, pid, g.defaultBranch, common.GitopsFileRestart)
+	content, err := g.gitopsLib.GetFile(ctx, pid, g.defaultBranch, common.GitopsFileRestart)
 	if err != nil {
 		return "", perror.WithMessage(err, "failed to get gitlab file")
 	}
@@ -998,9 +1000,9 @@ func (g *clusterGitopsRepo) UpdateRestartTime(ctx context.Context,
 		return "", err1
 	}
 
-	actions := []gitlablib.CommitAction{
+	actions := []gitops.CommitAction{
 		{
-			Action:   gitlablib.FileUpdate,
+			Action:   gitops.FileUpdate,
 			FilePath: common.GitopsFileRestart,
 			Content:  string(restartYAML),
 		},
@@ -1013,7 +1015,7 @@ func (g *clusterGitopsRepo) UpdateRestartTime(ctx context.Context,
 	}, nil)
 
 	// update in defaultBranch directly
-	commit, err := g.gitlabLib.WriteFiles(ctx, pid, g.defaultBranch, commitMsg, nil, actions)
+	commit, err := g.gitopsLib.WriteFiles(ctx, pid, g.defaultBranch, commitMsg, nil, actions)
 	if err != nil {
 		return "", err
 	}
@@ -1028,17 +1030,17 @@ func (g *clusterGitopsRepo) GetConfigCommit(ctx context.Context,
 
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
 
-	var branchMaster, branchGitops *gitlab.Branch
+	var branchMaster, branchGitops *gitops.Branch
 	var err1, err2 error
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		branchMaster, err1 = g.gitlabLib.GetBranch(ctx, pid, g.defaultBranch)
+		branchMaster, err1 = g.gitopsLib.GetBranch(ctx, pid, g.defaultBranch)
 	}()
 	go func() {
 		defer wg.Done()
-		branchGitops, err2 = g.gitlabLib.GetBranch(ctx, pid, GitOpsBranch)
+		branchGitops, err2 = g.gitopsLib.GetBranch(ctx, pid, GitOpsBranch)
 	}()
 	wg.Wait()
 
@@ -1055,9 +1057,9 @@ func (g *clusterGitopsRepo) GetConfigCommit(ctx context.Context,
 }
 
 func (g *clusterGitopsRepo) GetRepoInfo(ctx context.Context, application, cluster string) *RepoInfo {
-	repoURL := g.gitlabLib.GetHTTPURL(ctx)
+	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
 	return &RepoInfo{
-		GitRepoURL: fmt.Sprintf("%v/%v/%v/%v.git", repoURL, g.clustersGroup.FullPath, application, cluster),
+		GitRepoURL: g.gitopsLib.GetRepoURL(ctx, pid),
 		ValueFiles: []string{common.GitopsFileApplication, common.GitopsFilePipelineOutput,
 			common.GitopsFileEnv, common.GitopsFileBase, common.GitopsFileTags, common.GitopsFileRestart, common.GitopsFileSRE},
 	}
@@ -1070,7 +1072,7 @@ func (g *clusterGitopsRepo) GetEnvValue(ctx context.Context,
 
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
 
-	bytes, err := g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileEnv)
+	bytes, err := g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, common.GitopsFileEnv)
 	if err != nil {
 		return nil, err
 	}
@@ -1110,28 +1112,28 @@ func (g *clusterGitopsRepo) Rollback(ctx context.Context, application, cluster,
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
 
 	// compare commit straight diffs
-	compare, err := g.gitlabLib.Compare(ctx, pid, GitOpsBranch, commit, utilcommon.BoolPtr(true))
+	diffs, err := g.gitopsLib.Compare(ctx, pid, GitOpsBranch, commit, true)
 	if err != nil {
 		return "", err
 	}
-	if compare.Diffs == nil {
+	if len(diffs) == 0 {
 		return "", perror.Wrapf(herrors.ErrParamInvalid,
 			"dose not support empty rollback, rollback commit = %s", commit)
 	}
 
 	type actionCase struct {
-		action *gitlablib.CommitAction
+		action *gitops.CommitAction
 		err    error
 	}
-	cases := make([]actionCase, len(compare.Diffs))
+	cases := make([]actionCase, len(diffs))
 	var wg sync.WaitGroup
-	for i := range compare.Diffs {
+	for i := range diffs {
 		i := i
 		wg.Add(1)
 		// generate a commit action for rollback based on diff
 		go func() {
 			defer wg.Done()
-			action, err := g.revertAction(ctx, application, cluster, commit, *compare.Diffs[i])
+			action, err := g.revertAction(ctx, application, cluster, commit, diffs[i])
 			cases[i] = actionCase{
 				action: action,
 				err:    err,
@@ -1140,7 +1142,7 @@ func (g *clusterGitopsRepo) Rollback(ctx context.Context, application, cluster,
 	}
 	wg.Wait()
 
-	var actions []gitlablib.CommitAction
+	var actions []gitops.CommitAction
 	for _, oneCase := range cases {
 		if oneCase.err != nil {
 			return "", oneCase.err
@@ -1158,7 +1160,7 @@ func (g *clusterGitopsRepo) Rollback(ctx context.Context, application, cluster,
 		Commit: commit,
 	})
 
-	newCommit, err := g.gitlabLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions)
+	newCommit, err := g.gitopsLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions)
 	if err != nil {
 		return "", err
 	}
@@ -1184,9 +1186,9 @@ func (g *clusterGitopsRepo) UpdateTags(ctx context.Context, application, cluster
 		return err
 	}
 
-	actions := []gitlablib.CommitAction{
+	actions := []gitops.CommitAction{
 		{
-			Action:   gitlablib.FileUpdate,
+			Action:   gitops.FileUpdate,
 			FilePath: common.GitopsFileTags,
 			Content:  string(tagsYAML),
 		},
@@ -1215,7 +1217,7 @@ func (g *clusterGitopsRepo) UpdateTags(ctx context.Context, application, cluster
 		}(tags),
 	})
 
-	_, err = g.gitlabLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions)
+	_, err = g.gitopsLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, actions)
 	if err != nil {
 		return err
 	}
@@ -1511,29 +1513,29 @@ func (g *clusterGitopsRepo) UpgradeCluster(ctx context.Context,
 	wgUpdateValue.Wait()
 
 	// 3. write files
-	var gitActions []gitlablib.CommitAction
+	var gitActions []gitops.CommitAction
 	for _, oneCase := range cases {
 		if oneCase.fileName != common.GitopsFileManifest {
 			if oneCase.err != nil {
 				return "", oneCase.err
 			}
 			if oneCase.sourceBytes != nil {
-				gitActions = append(gitActions, gitlablib.CommitAction{
-					Action:   gitlablib.FileUpdate,
+				gitActions = append(gitActions, gitops.CommitAction{
+					Action:   gitops.FileUpdate,
 					FilePath: oneCase.fileName,
 					Content:  string(oneCase.upgradedBytes),
 				})
 			}
 		} else {
 			if oneCase.err != nil {
-				gitActions = append(gitActions, gitlablib.CommitAction{
-					Action:   gitlablib.FileCreate,
+				gitActions = append(gitActions, gitops.CommitAction{
+					Action:   gitops.FileCreate,
 					FilePath: oneCase.fileName,
 					Content:  string(oneCase.upgradedBytes),
 				})
 			} else {
-				gitActions = append(gitActions, gitlablib.CommitAction{
-					Action:   gitlablib.FileUpdate,
+				gitActions = append(gitActions, gitops.CommitAction{
+					Action:   gitops.FileUpdate,
 					FilePath: oneCase.fileName,
 					Content:  string(oneCase.upgradedBytes),
 				})
@@ -1554,7 +1556,7 @@ func (g *clusterGitopsRepo) UpgradeCluster(ctx context.Context,
 			Release: param.TargetRelease.Name,
 		},
 	})
-	newCommit, err := g.gitlabLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, gitActions)
+	newCommit, err := g.gitopsLib.WriteFiles(ctx, pid, GitOpsBranch, commitMsg, nil, gitActions)
 	if err != nil {
 		return "", err
 	}
@@ -1728,11 +1730,11 @@ func (g *clusterGitopsRepo) assembleChart(params *BaseParams) (*Chart, error) {
 //		}
 //	]
 func (g *clusterGitopsRepo) revertAction(ctx context.Context, application, cluster,
-	commit string, diff gitlab.Diff) (*gitlablib.CommitAction, error) {
+	commit string, diff *gitops.Diff) (*gitops.CommitAction, error) {
 	if diff.DeletedFile {
 		// file is deleted from gitops branch to the commit
-		return &gitlablib.CommitAction{
-			Action:   gitlablib.FileDelete,
+		return &gitops.CommitAction{
+			Action:   gitops.FileDelete,
 			FilePath: diff.OldPath,
 		}, nil
 	}
@@ -1742,16 +1744,16 @@ func (g *clusterGitopsRepo) revertAction(ctx context.Context, application, clust
 		if err != nil {
 			return nil, err
 		}
-		return &gitlablib.CommitAction{
-			Action:   gitlablib.FileCreate,
+		return &gitops.CommitAction{
+			Action:   gitops.FileCreate,
 			FilePath: diff.NewPath,
 			Content:  string(file),
 		}, nil
 	}
 	if diff.RenamedFile {
 		// file is renamed from gitops branch to the commit
-		return &gitlablib.CommitAction{
-			Action:       gitlablib.FileMove,
+		return &gitops.CommitAction{
+			Action:       gitops.FileMove,
 			FilePath:     diff.NewPath,
 			PreviousPath: diff.OldPath,
 		}, nil
@@ -1761,8 +1763,8 @@ func (g *clusterGitopsRepo) revertAction(ctx context.Context, application, clust
 	if err != nil {
 		return nil, err
 	}
-	return &gitlablib.CommitAction{
-		Action:   gitlablib.FileUpdate,
+	return &gitops.CommitAction{
+		Action:   gitops.FileUpdate,
 		FilePath: diff.NewPath,
 		Content:  string(file),
 	}, nil
@@ -1773,9 +1775,9 @@ func (g *clusterGitopsRepo) readFile(ctx context.Context, application, cluster,
 	fileName string, commit *string) ([]byte, error) {
 	pid := fmt.Sprintf("%v/%v/%v", g.clustersGroup.FullPath, application, cluster)
 	if commit != nil {
-		return g.gitlabLib.GetFile(ctx, pid, *commit, fileName)
+		return g.gitopsLib.GetFile(ctx, pid, *commit, fileName)
 	}
-	return g.gitlabLib.GetFile(ctx, pid, GitOpsBranch, fileName)
+	return g.gitopsLib.GetFile(ctx, pid, GitOpsBranch, fileName)
 }
 
 // for internal usage
@@ -25,13 +25,15 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/horizoncd/horizon/core/common"
 	herrors "github.com/horizoncd/horizon/core/errors"
-	gitlablib "github.com/horizoncd/horizon/lib/gitlab"
-	gitlablibmock "github.com/horizoncd/horizon/mock/lib/gitlab"
+	gitopsmock "github.com/horizoncd/horizon/mock/pkg/gitops"
 	appmodels "github.com/horizoncd/horizon/pkg/application/models"
 	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
 	templateconfig "github.com/horizoncd/horizon/pkg/config/template"
 	config "github.com/horizoncd/horizon/pkg/config/templaterepo"
 	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	gitopsgitlab "github.com/horizoncd/horizon/pkg/gitops/gitlab"
 	regionmodels "github.com/horizoncd/horizon/pkg/region/models"
 	registrymodels "github.com/horizoncd/horizon/pkg/registry/models"
 	tagmodels "github.com/horizoncd/horizon/pkg/tag/models"
@@ -39,7 +41,6 @@ import (
 	"github.com/horizoncd/horizon/pkg/templaterepo/chartmuseumbase"
 	utilcommon "github.com/horizoncd/horizon/pkg/util/common"
 	"github.com/stretchr/testify/assert"
-	"github.com/xanzy/go-gitlab"
 )
 
 /*
@@ -83,14 +84,14 @@ set the env 'defaultBranch' to main
 // nolint
 var (
 	ctx           context.Context
-	g             gitlablib.Interface
+	g             gitops.Interface
 	defaultBranch string
 
 	defaultVisibility string
 
 	sshURL        string
 	rootGroupName string
-	rootGroup     *gitlab.Group
+	rootGroup     *gitops.Group
 	templateName  string
 
 	pipelineJSONBlob, applicationJSONBlob map[string]interface{}
@@ -157,7 +158,7 @@ func TestMain(m *testing.M) {
 
 	sshURL = "ssh://gitlab.com"
 
-	g, err = gitlablib.New(p.Token, p.BaseURL)
+	g, err = gitopsgitlab.New(ctx, &gitlabconfig.GitopsRepoConfig{Token: p.Token, URL: p.BaseURL})
 	if err != nil {
 		panic(err)
 	}
@@ -182,7 +183,7 @@ func TestMain(m *testing.M) {
 func Test(t *testing.T) {
 	repo, _ := chartmuseumbase.NewRepo(config.Repo{Host: "https://harbor.cloudnative.com"})
 
-	r, err := NewClusterGitlabRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
+	r, err := NewClusterGitopsRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
 	assert.Nil(t, err)
 
 	application := "app"
@@ -313,7 +314,7 @@ func Test(t *testing.T) {
 
 func TestV2(t *testing.T) {
 	repo, _ := chartmuseumbase.NewRepo(config.Repo{Host: "https://harbor.cloudnative.com"})
-	r, err := NewClusterGitlabRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
+	r, err := NewClusterGitopsRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
 	assert.Nil(t, err)
 
 	application := "appv2"
@@ -417,7 +418,7 @@ func TestV2(t *testing.T) {
 
 func TestUpgradeToV2(t *testing.T) {
 	repo, _ := chartmuseumbase.NewRepo(config.Repo{Host: "https://harbor.cloudnative.com"})
-	r, err := NewClusterGitlabRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
+	r, err := NewClusterGitopsRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
 	assert.Nil(t, err)
 
 	application := "appUpgrade"
@@ -535,7 +536,7 @@ func TestHardDeleteCluster(t *testing.T) {
 		BaseParams: baseParams,
 	}
 	repo, _ := chartmuseumbase.NewRepo(config.Repo{Host: "https://harbor.cloudnative.com"})
-	r, err := NewClusterGitlabRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
+	r, err := NewClusterGitopsRepo(ctx, rootGroup, repo, g, defaultBranch, defaultVisibility)
 	assert.Nil(t, err)
 	err = r.CreateCluster(ctx, createParams)
 	assert.Nil(t, err)
@@ -548,12 +549,12 @@ func TestGetClusterValueFile(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
-	gitlabmockLib := gitlablibmock.NewMockInterface(mockCtrl)
-	gitlabmockLib.EXPECT().GetCreatedGroup(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(&gitlab.Group{}, nil).AnyTimes()
+	gitlabmockLib := gitopsmock.NewMockInterface(mockCtrl)
+	gitlabmockLib.EXPECT().GetCreatedGroup(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&gitops.Group{}, nil).AnyTimes()
 
 	var clusterGitRepoInstance ClusterGitRepo // nolint
-	clusterGitRepoInstance, err := NewClusterGitlabRepo(ctx, rootGroup, &chartmuseumbase.Repo{},
+	clusterGitRepoInstance, err := NewClusterGitopsRepo(ctx, rootGroup, &chartmuseumbase.Repo{},
 		gitlabmockLib, defaultBranch, defaultVisibility)
 	assert.Nil(t, err)
 
@@ -597,21 +598,21 @@ func TestClusterGitRepo_UpdatePipelineOutput(t *testing.T) {
 java:
   image: harbor.cloudnative.com/music-job-console/music-job-console-1:dev-d094e34f-20220118150928
 `
-	gitlabmockLib := gitlablibmock.NewMockInterface(mockCtrl)
+	gitlabmockLib := gitopsmock.NewMockInterface(mockCtrl)
 	gitlabmockLib.EXPECT().GetFile(gomock.Any(), gomock.Any(),
 		gomock.Any(), gomock.Any()).Return(
 		[]byte(output), nil).AnyTimes()
 	gitlabmockLib.EXPECT().WriteFiles(gomock.Any(), gomock.Any(),
 		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 		func(ctx, pid, branch, commitMsg, startBranch, actions interface{}) (string, error) {
-			output = actions.([]gitlablib.CommitAction)[0].Content
+			output = actions.([]gitops.CommitAction)[0].Content
 			return "", nil
 		}).AnyTimes()
-	gitlabmockLib.EXPECT().GetCreatedGroup(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(&gitlab.Group{}, nil).AnyTimes()
+	gitlabmockLib.EXPECT().GetCreatedGroup(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&gitops.Group{}, nil).AnyTimes()
 
 	var clusterGitRepoInstance ClusterGitRepo // nolint
-	clusterGitRepoInstance, err := NewClusterGitlabRepo(ctx, rootGroup, &chartmuseumbase.Repo{},
+	clusterGitRepoInstance, err := NewClusterGitopsRepo(ctx, rootGroup, &chartmuseumbase.Repo{},
 		gitlabmockLib, defaultBranch, defaultVisibility)
 	assert.Nil(t, err)
 
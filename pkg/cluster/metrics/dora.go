@@ -0,0 +1,152 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	gmodels "github.com/horizoncd/horizon/pkg/group/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	"github.com/horizoncd/horizon/pkg/pr/dora"
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+const (
+	horizonDORADeploymentFrequency  = "horizon_dora_deployment_frequency"
+	horizonDORALeadTimeSeconds      = "horizon_dora_lead_time_seconds"
+	horizonDORAChangeFailureRate    = "horizon_dora_change_failure_rate"
+	horizonDORATimeToRestoreSeconds = "horizon_dora_time_to_restore_seconds"
+
+	// doraWindow is the trailing window of the DORA metrics
+	doraWindow = 30 * 24 * time.Hour
+	// doraCacheTTL avoids computing the DORA metrics on every scrape
+	doraCacheTTL = 5 * time.Minute
+)
+
+var doraLabels = []string{"application", "group", "environment"}
+
+var (
+	doraDeploymentFrequencyDesc = prometheus.NewDesc(horizonDORADeploymentFrequency,
+		"Successful deployments per day in the last 30 days", doraLabels, nil)
+	doraLeadTimeDesc = prometheus.NewDesc(horizonDORALeadTimeSeconds,
+		"Median seconds from committed to deployed in the last 30 days", doraLabels, nil)
+	doraChangeFailureRateDesc = prometheus.NewDesc(horizonDORAChangeFailureRate,
+		"Ratio of deployments failed or rolled back in the last 30 days", doraLabels, nil)
+	doraTimeToRestoreDesc = prometheus.NewDesc(horizonDORATimeToRestoreSeconds,
+		"Mean seconds to restore failed deployments in the last 30 days", doraLabels, nil)
+)
+
+// DORACollector collects the DORA metrics of each application and environment
+type DORACollector struct {
+	managers *managerparam.Manager
+
+	mu          sync.Mutex
+	collectedAt time.Time
+	metrics     []prometheus.Metric
+}
+
+func (collector *DORACollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- doraDeploymentFrequencyDesc
+	ch <- doraLeadTimeDesc
+	ch <- doraChangeFailureRateDesc
+	ch <- doraTimeToRestoreDesc
+}
+
+func (collector *DORACollector) Collect(ch chan<- prometheus.Metric) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if time.Since(collector.collectedAt) > doraCacheTTL {
+		metrics, err := collector.compute(context.Background())
+		if err != nil {
+			log.Errorf(context.Background(), "Failed to compute dora metrics: %v", err)
+		} else {
+			collector.metrics = metrics
+			collector.collectedAt = time.Now()
+		}
+	}
+	for _, metric := range collector.metrics {
+		ch <- metric
+	}
+}
+
+func (collector *DORACollector) compute(ctx context.Context) ([]prometheus.Metric, error) {
+	end := time.Now()
+	start := end.Add(-doraWindow)
+	deployments, err := collector.managers.PRMgr.PipelineRun.ListDeployments(ctx, &prmodels.DeploymentQuery{
+		Start: start,
+		End:   end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	deploymentsByApp := make(map[uint][]*prmodels.Deployment)
+	appIDs := make([]uint, 0)
+	for _, deployment := range deployments {
+		if _, ok := deploymentsByApp[deployment.ApplicationID]; !ok {
+			appIDs = append(appIDs, deployment.ApplicationID)
+		}
+		deploymentsByApp[deployment.ApplicationID] = append(deploymentsByApp[deployment.ApplicationID], deployment)
+	}
+	if len(appIDs) == 0 {
+		return nil, nil
+	}
+
+	apps, err := collector.managers.ApplicationMgr.GetByIDs(ctx, appIDs)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := collector.managers.GroupMgr.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groupMap := make(map[uint]*gmodels.Group)
+	for _, group := range groups {
+		groupMap[group.ID] = group
+	}
+
+	metrics := make([]prometheus.Metric, 0)
+	for _, app := range apps {
+		var groupPath string
+		if group := groupMap[app.GroupID]; group != nil {
+			g := &groupWithFullPath{Group: group}
+			g.genFullPath(groupMap)
+			groupPath = g.FullPath
+		}
+		report := dora.Compute(deploymentsByApp[app.ID], start, end, 0)
+		for environment, m := range report.Environments {
+			labels := []string{app.Name, groupPath, environment}
+			metrics = append(metrics, prometheus.MustNewConstMetric(doraDeploymentFrequencyDesc,
+				prometheus.GaugeValue, m.DeploymentFrequency, labels...))
+			if m.LeadTimeSeconds != nil {
+				metrics = append(metrics, prometheus.MustNewConstMetric(doraLeadTimeDesc,
+					prometheus.GaugeValue, *m.LeadTimeSeconds, labels...))
+			}
+			if m.ChangeFailureRate != nil {
+				metrics = append(metrics, prometheus.MustNewConstMetric(doraChangeFailureRateDesc,
+					prometheus.GaugeValue, *m.ChangeFailureRate, labels...))
+			}
+			if m.TimeToRestoreSeconds != nil {
+				metrics = append(metrics, prometheus.MustNewConstMetric(doraTimeToRestoreDesc,
+					prometheus.GaugeValue, *m.TimeToRestoreSeconds, labels...))
+			}
+		}
+	}
+	return metrics, nil
+}
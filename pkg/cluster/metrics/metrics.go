@@ -31,6 +31,9 @@ func NewMetrics(
 	prometheus.MustRegister(&Collector{
 		managers: managers,
 	})
+	prometheus.MustRegister(&DORACollector{
+		managers: managers,
+	})
 }
 
 type groupWithFullPath struct {
@@ -25,6 +25,7 @@ var (
 	_prHistogram   *prometheus.HistogramVec
 	_trHistogram   *prometheus.HistogramVec
 	_stepHistogram *prometheus.HistogramVec
+	_cacheCounter  *prometheus.CounterVec
 )
 
 const (
@@ -35,6 +36,7 @@ const (
 	_result      = "result"
 	_template    = "template"
 	_application = "application"
+	_cache       = "cache"
 	_namespace   = "horizon"
 	_subsystem   = ""
 )
@@ -60,6 +62,11 @@ func init() {
 		Help:    "Step duration info",
 		Buckets: buckets,
 	}, []string{_environment, _application, _template, _pipeline, _result, _task, _step})
+
+	_cacheCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(_namespace, _subsystem, "taskrun_cache_total"),
+		Help: "Taskrun build cache hits and misses",
+	}, []string{_environment, _application, _template, _pipeline, _task, _cache})
 }
 
 func Observe(results *PipelineResults, data *global.HorizonMetaData) {
@@ -87,6 +94,21 @@ func Observe(results *PipelineResults, data *global.HorizonMetaData) {
 			_task:        trResult.Task,
 			_result:      trResult.Result,
 		}).Observe(trResult.DurationSeconds)
+
+		if trResult.CacheHit != nil {
+			cache := "miss"
+			if *trResult.CacheHit {
+				cache = "hit"
+			}
+			_cacheCounter.With(prometheus.Labels{
+				_environment: data.Environment,
+				_application: data.Application,
+				_template:    data.Template,
+				_pipeline:    prMetadata.Pipeline,
+				_task:        trResult.Task,
+				_cache:       cache,
+			}).Inc()
+		}
 	}
 
 	for _, stepResult := range stepResults {
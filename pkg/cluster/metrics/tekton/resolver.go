@@ -16,6 +16,8 @@ package tekton
 
 import (
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,6 +51,8 @@ type TrResult struct {
 	CompletionTime  *metav1.Time
 	DurationSeconds float64
 	Result          string
+	// CacheHit is resolved from the task result named cache-hit, nil if the task does not report it
+	CacheHit *bool
 }
 
 type TrResults []*TrResult
@@ -114,7 +118,11 @@ func FormatPipelineResults(pipelineRun *v1beta1.PipelineRun) *PipelineResults {
 	}
 }
 
-const LabelKeyPipeline = "tekton.dev/pipeline"
+const (
+	LabelKeyPipeline = "tekton.dev/pipeline"
+	// ResultCacheHit is the name of the task result telling whether the build cache is hit
+	ResultCacheHit = "cache-hit"
+)
 
 func (wpr *WrappedPipelineRun) ResolveMetadata() *PrMetadata {
 	return &PrMetadata{
@@ -172,6 +180,7 @@ func (wpr *WrappedPipelineRun) ResolveTrAndStepResults() (TrResults, StepResults
 			Result:         string(trResult(trStatus)),
 			StartTime:      trStatus.Status.StartTime,
 			CompletionTime: trStatus.Status.CompletionTime,
+			CacheHit:       cacheHit(trStatus.Status.TaskRunResults),
 		})
 
 		for _, step := range trStatus.Status.Steps {
@@ -229,6 +238,22 @@ func trResult(trStatus *v1beta1.PipelineRunTaskRunStatus) prmodels.PipelineStatu
 	return prmodels.StatusUnknown
 }
 
+// cacheHit resolves whether the build cache is hit from the results of the task, such as
+// `echo -n true > $(results.cache-hit.path)` in a build step
+func cacheHit(results []v1beta1.TaskRunResult) *bool {
+	for _, result := range results {
+		if result.Name != ResultCacheHit {
+			continue
+		}
+		hit, err := strconv.ParseBool(strings.TrimSpace(result.Value))
+		if err != nil {
+			return nil
+		}
+		return &hit
+	}
+	return nil
+}
+
 func durationSeconds(beginTime, endTime *metav1.Time) float64 {
 	if beginTime == nil || endTime == nil {
 		return -1
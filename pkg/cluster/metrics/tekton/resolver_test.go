@@ -668,6 +668,46 @@ func Test_durationSeconds(t *testing.T) {
 	}
 }
 
+func Test_cacheHit(t *testing.T) {
+	hit, miss := true, false
+	tests := []struct {
+		name    string
+		results []v1beta1.TaskRunResult
+		want    *bool
+	}{
+		{
+			name: "hit",
+			results: []v1beta1.TaskRunResult{
+				{Name: "digest", Value: "sha256:1"},
+				{Name: ResultCacheHit, Value: "true\n"},
+			},
+			want: &hit,
+		},
+		{
+			name:    "miss",
+			results: []v1beta1.TaskRunResult{{Name: ResultCacheHit, Value: "false"}},
+			want:    &miss,
+		},
+		{
+			name:    "invalid",
+			results: []v1beta1.TaskRunResult{{Name: ResultCacheHit, Value: "unknown"}},
+			want:    nil,
+		},
+		{
+			name:    "not reported",
+			results: nil,
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheHit(tt.results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cacheHit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func parseTime(str string) *metav1.Time {
 	t, _ := time.Parse(_layout, str)
 	mt := metav1.NewTime(t.Local())
@@ -16,7 +16,9 @@ package collector
 
 import (
 	"context"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -61,6 +63,17 @@ type (
 	}
 )
 
+// TerminalRecording is the recording of a terminal session in asciicast format
+type TerminalRecording struct {
+	Cluster   string
+	ClusterID uint
+	Pod       string
+	Container string
+	Operator  string
+	StartedAt time.Time
+	Content   []byte
+}
+
 type Log struct {
 	LogChannel <-chan log.Log
 	ErrChannel <-chan error
@@ -111,6 +124,12 @@ type Interface interface {
 
 	// GetPipelineRun gets tekton pipelinerun
 	GetPipelineRun(ctx context.Context, pr *prmodels.Pipelinerun) (*v1beta1.PipelineRun, error)
+
+	// PutTerminalRecording stores the recording of terminal session, and returns the object of it
+	PutTerminalRecording(ctx context.Context, recording *TerminalRecording) (string, error)
+
+	// GetTerminalRecording gets the recording of terminal session by its object
+	GetTerminalRecording(ctx context.Context, object string) ([]byte, error)
 }
 
 var _ Interface = (*S3Collector)(nil)
@@ -118,3 +137,8 @@ var _ Interface = (*S3Collector)(nil)
 func resolveObjMetadata(pr *v1beta1.PipelineRun, horizonMetaData *global.HorizonMetaData) *ObjectMeta {
 	return NewObjectMeta(horizonMetaData, pr)
 }
+
+func getPathForTerminalRecording(recording *TerminalRecording) string {
+	return fmt.Sprintf("%s/terminal/%s-%d/%s/%s.cast", recording.StartedAt.Format("200601"),
+		recording.Cluster, recording.ClusterID, recording.Pod, recording.StartedAt.Format("20060102150405.000000"))
+}
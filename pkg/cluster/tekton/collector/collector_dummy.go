@@ -16,6 +16,9 @@ package collector
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 
@@ -28,13 +31,22 @@ import (
 	"github.com/horizoncd/horizon/pkg/util/wlog"
 )
 
+const (
+	_envKeyTerminalRecordingDir = "TERMINAL_RECORDING_DIR"
+
+	_defaultTerminalRecordingDir = "/var/log/terminal"
+)
+
 type DummyCollector struct {
 	tekton tekton.Interface
+	// recordingDir is the local directory storing recordings of terminal sessions
+	recordingDir string
 }
 
 func NewDummyCollector(tekton tekton.Interface) Interface {
 	return &DummyCollector{
-		tekton: tekton,
+		tekton:       tekton,
+		recordingDir: getEnvOrDefault(_envKeyTerminalRecordingDir, _defaultTerminalRecordingDir),
 	}
 }
 
@@ -90,3 +102,35 @@ func (c *DummyCollector) GetPipelineRun(ctx context.Context,
 	}
 	return tektonPipelineRun, nil
 }
+
+func (c *DummyCollector) PutTerminalRecording(ctx context.Context, recording *TerminalRecording) (string, error) {
+	const op = "DummyCollector: putTerminalRecording"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	// no object storage, so the recording is stored in local directory
+	object := getPathForTerminalRecording(recording)
+	filename := filepath.Join(c.recordingDir, filepath.FromSlash(object))
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return "", perror.Wrap(herrors.ErrWriteFailed, err.Error())
+	}
+	if err := ioutil.WriteFile(filename, recording.Content, 0o644); err != nil {
+		return "", perror.Wrap(herrors.ErrWriteFailed, err.Error())
+	}
+	return object, nil
+}
+
+func (c *DummyCollector) GetTerminalRecording(ctx context.Context, object string) ([]byte, error) {
+	const op = "DummyCollector: getTerminalRecording"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	// objects are cleaned as absolute paths, so that they never escape the directory
+	filename := filepath.Join(c.recordingDir, filepath.Clean("/"+filepath.FromSlash(object)))
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, herrors.NewErrNotFound(herrors.TerminalRecording, err.Error())
+		}
+		return nil, perror.Wrap(herrors.ErrReadFailed, err.Error())
+	}
+	return b, nil
+}
@@ -17,12 +17,17 @@ package collector
 import (
 	"context"
 	"encoding/json"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
 	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
 	"github.com/horizoncd/horizon/pkg/server/global"
 
@@ -72,3 +77,28 @@ func TestDummyCollector(t *testing.T) {
 	_, err = c.GetPipelineRun(ctx, prModel)
 	assert.Nil(t, err)
 }
+
+func TestDummyCollector_TerminalRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	c := &DummyCollector{recordingDir: dir}
+
+	object, err := c.PutTerminalRecording(ctx, &TerminalRecording{
+		Cluster:   "cluster",
+		ClusterID: 1,
+		Pod:       "pod",
+		StartedAt: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC),
+		Content:   []byte("recording"),
+	})
+	assert.Nil(t, err)
+	content, err := c.GetTerminalRecording(ctx, object)
+	assert.Nil(t, err)
+	assert.Equal(t, "recording", string(content))
+
+	// objects never escape the directory
+	_, err = c.GetTerminalRecording(ctx, "../../etc/passwd")
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+}
@@ -383,6 +383,38 @@ func (c *S3Collector) getPathForPrLog(metadata *ObjectMeta) string {
 		metadata.PipelineRun.Name)
 }
 
+func (c *S3Collector) PutTerminalRecording(ctx context.Context, recording *TerminalRecording) (string, error) {
+	const op = "s3Collector: putTerminalRecording"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	object := getPathForTerminalRecording(recording)
+	if err := c.s3.PutObject(ctx, object, bytes.NewReader(recording.Content), map[string]string{
+		cluster:     recording.Cluster,
+		operator:    recording.Operator,
+		"pod":       recording.Pod,
+		"container": recording.Container,
+	}); err != nil {
+		return "", perror.Wrap(herrors.ErrS3PutObjFailed, err.Error())
+	}
+	return object, nil
+}
+
+func (c *S3Collector) GetTerminalRecording(ctx context.Context, object string) ([]byte, error) {
+	const op = "s3Collector: getTerminalRecording"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	b, err := c.s3.GetObject(ctx, object)
+	if err != nil {
+		if e, ok := err.(awserr.Error); ok {
+			if e.Code() == awss3.ErrCodeNoSuchKey {
+				return nil, herrors.NewErrNotFound(herrors.TerminalRecording, err.Error())
+			}
+		}
+		return nil, perror.Wrap(herrors.ErrS3GetObjFailed, err.Error())
+	}
+	return b, nil
+}
+
 func cutByteInMiddle(data []byte, limit int, begin int, end int) []byte {
 	l := len(data)
 	if limit > 0 && l < limit {
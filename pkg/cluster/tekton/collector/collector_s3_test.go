@@ -137,3 +137,37 @@ func TestS3Collector_Collect(t *testing.T) {
 		t.Fatalf("pipelineRun objectMeta: expected %v, got %v", objectMeta, obj.Metadata)
 	}
 }
+
+func TestS3Collector_TerminalRecording(t *testing.T) {
+	ctx := context.Background()
+	backend := s3mem.New()
+	_ = backend.CreateBucket("bucket")
+	ts := httptest.NewServer(gofakes3.New(backend).Server())
+	defer ts.Close()
+
+	d, err := s3.NewDriver(s3.Params{
+		AccessKey:        "accessKey",
+		SecretKey:        "secretKey",
+		Region:           "us-east-1",
+		Endpoint:         ts.URL,
+		Bucket:           "bucket",
+		SkipVerify:       true,
+		S3ForcePathStyle: true,
+	})
+	assert.Nil(t, err)
+	c := NewS3Collector(d, nil)
+
+	object, err := c.PutTerminalRecording(ctx, &TerminalRecording{
+		Cluster:   "cluster",
+		ClusterID: 1,
+		Pod:       "pod",
+		Container: "container",
+		StartedAt: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC),
+		Content:   []byte("recording"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "202610/terminal/cluster-1/pod/20261019080000.000000.cast", object)
+	content, err := c.GetTerminalRecording(ctx, object)
+	assert.Nil(t, err)
+	assert.Equal(t, "recording", string(content))
+}
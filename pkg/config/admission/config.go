@@ -18,6 +18,19 @@ const (
 	FailurePolicyFail   FailurePolicy = "fail"
 )
 
+type PolicyMode string
+
+func (m PolicyMode) Eq(other PolicyMode) bool {
+	return strings.EqualFold(string(m), string(other))
+}
+
+const (
+	// PolicyModeEnforce denies or mutates the matched requests
+	PolicyModeEnforce PolicyMode = "enforce"
+	// PolicyModeAudit only logs what the policy would do, it never blocks or mutates requests
+	PolicyModeAudit PolicyMode = "audit"
+)
+
 type ClientConfig struct {
 	URL      string `yaml:"url"`
 	CABundle string `yaml:"caBundle"`
@@ -25,12 +38,14 @@ type ClientConfig struct {
 }
 
 type Rule struct {
-	Resources  []string           `yaml:"resources"`
-	Operations []models.Operation `yaml:"operations"`
-	Versions   []string           `yaml:"versions"`
+	Resources  []string           `yaml:"resources" json:"resources"`
+	Operations []models.Operation `yaml:"operations" json:"operations"`
+	Versions   []string           `yaml:"versions" json:"versions"`
 }
 
 type Webhook struct {
+	// Name identifies the webhook in logs, metrics and events, defaults to the url
+	Name          string        `yaml:"name"`
 	Kind          models.Kind   `yaml:"kind"`
 	FailurePolicy FailurePolicy `yaml:"failurePolicy"`
 	Timeout       time.Duration `yaml:"timeout"`
@@ -38,6 +53,22 @@ type Webhook struct {
 	ClientConfig  ClientConfig  `yaml:"clientConfig"`
 }
 
+// Policy is an admission rule written in CEL and evaluated in-process.
+// The expression can access the variables object, oldObject and request.
+// For validating policies it returns a bool (true means allowed) or a string
+// (non-empty means denied with the string as message);
+// for mutating policies it returns a map which is merged into the object as a JSON merge patch.
+type Policy struct {
+	Name          string        `yaml:"name" json:"name"`
+	Kind          models.Kind   `yaml:"kind" json:"kind"`
+	Mode          PolicyMode    `yaml:"mode" json:"mode"`
+	FailurePolicy FailurePolicy `yaml:"failurePolicy" json:"failurePolicy"`
+	Rules         []Rule        `yaml:"rules" json:"rules"`
+	Expression    string        `yaml:"expression" json:"expression"`
+	Message       string        `yaml:"message" json:"message"`
+}
+
 type Admission struct {
 	Webhooks []Webhook `yaml:"webhooks"`
+	Policies []Policy  `yaml:"policies"`
 }
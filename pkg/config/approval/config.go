@@ -0,0 +1,30 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approval
+
+// Config maps environment name to its approval policy. Pipelineruns of clusters
+// in these environments must be approved before executed.
+type Config struct {
+	Environments map[string]*Policy `yaml:"environments"`
+}
+
+type Policy struct {
+	// RequiredApprovals is the number of cluster members that must approve a pipelinerun, defaults to 1
+	RequiredApprovals int `yaml:"requiredApprovals"`
+	// Role is the lowest role of cluster members allowed to review a pipelinerun, defaults to maintainer
+	Role string `yaml:"role"`
+	// AllowAuthor tells whether the author of a pipelinerun can review it
+	AllowAuthor bool `yaml:"allowAuthor"`
+}
@@ -0,0 +1,51 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import "time"
+
+type Config struct {
+	// Enabled records audit logs of mutating api calls and sensitive reads
+	Enabled bool `yaml:"enabled"`
+	// SensitiveReads are regular expressions of the paths whose GET requests are audited too,
+	// such as container logs and shells of clusters. The default ones are used if empty
+	SensitiveReads []string `yaml:"sensitiveReads"`
+	// MaskedFields are the fields of json or form request bodies and queries masked in audit logs, matched
+	// case-insensitively ignoring underscores and hyphens in any depth. The default ones, such as password
+	// and token, are used if empty
+	MaskedFields []string `yaml:"maskedFields"`
+	// MaxBodySize is the max bytes of a request body kept in an audit log, 65536 by default
+	MaxBodySize int `yaml:"maxBodySize"`
+	// Snapshot records the resource before and after it is updated or deleted by getting it with the
+	// same credential, and keeps the diff in the audit log
+	Snapshot bool `yaml:"snapshot"`
+	// Syslog exports audit logs to the syslog server, disabled if the address is empty
+	Syslog Syslog `yaml:"syslog"`
+	// JSONLinesFile appends audit logs as json lines to the file, which can be collected by log shippers.
+	// Disabled if empty
+	JSONLinesFile string `yaml:"jsonLinesFile"`
+	// AnchorInterval is the interval of exporting the head of the hash chain, so that the logs deleted
+	// at the end can be told by the latest head exported. Disabled if zero
+	AnchorInterval time.Duration `yaml:"anchorInterval"`
+	// SigningKey signs the heads exported by hmac-sha256, so that they cannot be forged without the key
+	SigningKey string `yaml:"signingKey"`
+}
+
+type Syslog struct {
+	// Network is tcp or udp, udp by default
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"`
+}
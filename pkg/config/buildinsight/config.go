@@ -0,0 +1,33 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinsight
+
+import "time"
+
+type Config struct {
+	// JobInterval is the interval of detecting build regressions of applications, the job is disabled if it is zero
+	JobInterval time.Duration `yaml:"jobInterval"`
+	// RecentWindow is the window of recent builds compared with the baseline, and the least interval
+	// between two alerts of a regressed step
+	RecentWindow time.Duration `yaml:"recentWindow"`
+	// BaselineWindow is the window of builds right before the recent window
+	BaselineWindow time.Duration `yaml:"baselineWindow"`
+	// Threshold is the growth ratio of the median duration of a step to alert, e.g. 0.5 for 50%
+	Threshold float64 `yaml:"threshold"`
+	// MinSamples is the min number of successful runs of a step in both windows to compare
+	MinSamples int `yaml:"minSamples"`
+	// MinDuration is the min recent median duration of a step to alert
+	MinDuration time.Duration `yaml:"minDuration"`
+}
@@ -0,0 +1,26 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+// Config maps environment name to its approval rule. Config changes of clusters
+// in these environments must be made through change requests.
+type Config struct {
+	Environments map[string]*ApprovalRule `yaml:"environments"`
+}
+
+type ApprovalRule struct {
+	// RequiredApprovals is the number of cluster owners that must approve a change request, defaults to 1
+	RequiredApprovals int `yaml:"requiredApprovals"`
+}
@@ -0,0 +1,33 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import "time"
+
+type Config struct {
+	// JobInterval is the interval of detecting drift of clusters, the job is disabled if it is zero
+	JobInterval time.Duration `yaml:"jobInterval"`
+	BatchSize   int           `yaml:"batchSize"`
+	// Environments are the environments whose clusters are checked, all environments if empty
+	Environments []string `yaml:"environments"`
+	// AutoRevertEnvironments are the environments whose clusters are synced once drift is detected
+	AutoRevertEnvironments []string `yaml:"autoRevertEnvironments"`
+	// IgnoredFields are the fields not checked, such as spec.replicas managed by hpa
+	IgnoredFields []string `yaml:"ignoredFields"`
+	// BadgeSvgLink is the svg of the badge shown on drifted clusters
+	BadgeSvgLink string `yaml:"badgeSvgLink"`
+	// AccountID is the account used to detect and revert drift of clusters
+	AccountID uint `yaml:"accountID"`
+}
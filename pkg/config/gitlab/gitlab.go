@@ -14,11 +14,31 @@
 
 package gitlab
 
+import "time"
+
 // GitopsRepoConfig gitops repo config
 type GitopsRepoConfig struct {
+	// Kind is the storage of gitops repos, one of gitlab, gitea, github, filesystem and database, defaults to gitlab
+	Kind              string `yaml:"kind"`
 	URL               string `yaml:"url"`
 	Token             string `yaml:"token"`
 	RootGroupPath     string `yaml:"rootGroupPath"`
 	DefaultBranch     string `yaml:"defaultBranch"`
 	DefaultVisibility string `yaml:"defaultVisibility"`
+	// RootDir is the directory to store bare repos when kind is filesystem
+	RootDir string `yaml:"rootDir"`
+	// Mirror pushes the repos to git when kind is database, so that they can be synced by Argo CD
+	Mirror *GitopsMirrorConfig `yaml:"mirror"`
+}
+
+// GitopsMirrorConfig is the git repos mirrored from the database gitops repos.
+// The branches are force pushed, so force push must be allowed for them.
+type GitopsMirrorConfig struct {
+	GitopsRepoConfig `yaml:",inline"`
+	// Username is used with token to push over http, defaults to oauth2
+	Username string `yaml:"username"`
+	// CacheDir is the directory to build git objects before pushing
+	CacheDir    string        `yaml:"cacheDir"`
+	JobInterval time.Duration `yaml:"jobInterval"`
+	BatchSize   int           `yaml:"batchSize"`
 }
@@ -0,0 +1,26 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hibernation
+
+import "time"
+
+type Config struct {
+	// JobInterval is the interval of running hibernation schedules, the job is disabled if it is zero
+	JobInterval time.Duration `yaml:"jobInterval"`
+	// BatchSize is the number of clusters listed at a time for schedules of environments
+	BatchSize int `yaml:"batchSize"`
+	// AccountID is the account used to hibernate and wake up clusters by schedules
+	AccountID uint `yaml:"accountID"`
+}
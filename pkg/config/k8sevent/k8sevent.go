@@ -15,6 +15,8 @@
 package k8sevent
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -30,4 +32,27 @@ type Rule struct {
 
 type Config struct {
 	Rules []Rule `yaml:"rules"`
+
+	// AggregationWindow merges the events of the same reason and object since the first one within the window
+	// into one aggregation, which emits only one horizon event. 10m by default
+	AggregationWindow time.Duration `yaml:"aggregationWindow"`
+	// AggregationRetention is how long aggregations are kept after last seen, 168h by default
+	AggregationRetention time.Duration `yaml:"aggregationRetention"`
+	// Severities classify events by reasons, such as OOMKilled: critical.
+	// Events of other reasons are warning or info according to their types
+	Severities map[string]string `yaml:"severities"`
+	// Alerts are evaluated against the events selected by rules, so their reasons should be selected by rules
+	Alerts []AlertRule `yaml:"alerts"`
+}
+
+// AlertRule fires once if events of the reason occur more than Threshold times within Window for a cluster,
+// such as OOMKilled more than 3 times in 10 minutes
+type AlertRule struct {
+	Name   string `yaml:"name"`
+	Reason string `yaml:"reason"`
+	// Kind of the involved objects, any kind if empty
+	Kind      string        `yaml:"kind"`
+	Threshold int           `yaml:"threshold"`
+	Window    time.Duration `yaml:"window"`
+	Severity  string        `yaml:"severity"`
 }
@@ -0,0 +1,25 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldapsync
+
+import "time"
+
+type Config struct {
+	// JobInterval is the interval of syncing ldap groups into horizon groups, the job is disabled if it is zero
+	JobInterval time.Duration `yaml:"jobInterval"`
+	// AccountID is the account used to grant roles of horizon groups, the job only changes or revokes
+	// the memberships recorded as granted by itself
+	AccountID uint `yaml:"accountID"`
+}
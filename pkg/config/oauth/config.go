@@ -29,4 +29,22 @@ type Server struct {
 	AuthorizeCodeExpireIn time.Duration `yaml:"authorizeCodeExpireIn"`
 	AccessTokenExpireIn   time.Duration `yaml:"accessTokenExpireIn"`
 	RefreshTokenExpireIn  time.Duration `yaml:"refreshTokenExpireIn"`
+	OIDC                  OIDCProvider  `yaml:"oidc"`
+}
+
+// OIDCProvider configures horizon as an OpenID Connect provider,
+// which is disabled when no signing key is configured
+type OIDCProvider struct {
+	// Issuer is the external url of horizon, referred by the discovery document and the id tokens
+	Issuer          string        `yaml:"issuer"`
+	IDTokenExpireIn time.Duration `yaml:"idTokenExpireIn"`
+	// SigningKeys sign the id tokens by the first key, and the others are still published
+	// to verify the id tokens signed before rotating keys
+	SigningKeys []SigningKey `yaml:"signingKeys"`
+}
+
+type SigningKey struct {
+	KeyID string `yaml:"keyID"`
+	// PrivateKeyFile is the pem file of a RSA key for RS256, or an ECDSA P-256 key for ES256
+	PrivateKeyFile string `yaml:"privateKeyFile"`
 }
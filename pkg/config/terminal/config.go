@@ -0,0 +1,23 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terminal
+
+type Config struct {
+	// RecordEnvironments are the environments whose terminal sessions are recorded
+	RecordEnvironments []string `yaml:"recordEnvironments"`
+	// MaxRecordingSize is the max bytes of the output of a recording, the rest of the output of a longer
+	// session is dropped while the input is kept until twice of it
+	MaxRecordingSize int `yaml:"maxRecordingSize"`
+}
@@ -0,0 +1,91 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/drift/models"
+)
+
+type DAO interface {
+	GetByClusterID(ctx context.Context, clusterID uint) (*models.ClusterDrift, error)
+	Upsert(ctx context.Context, drift *models.ClusterDrift) (*models.ClusterDrift, error)
+	UpdateReverted(ctx context.Context, clusterID uint, drift *models.ClusterDrift) error
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) GetByClusterID(ctx context.Context, clusterID uint) (*models.ClusterDrift, error) {
+	var drift models.ClusterDrift
+	if err := d.db.WithContext(ctx).Where("cluster_id = ?", clusterID).
+		First(&drift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.ClusterDriftInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.ClusterDriftInDB, err.Error())
+	}
+	return &drift, nil
+}
+
+func (d *dao) Upsert(ctx context.Context, drift *models.ClusterDrift) (*models.ClusterDrift, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existed models.ClusterDrift
+		err := tx.Where("cluster_id = ?", drift.ClusterID).First(&existed).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return herrors.NewErrGetFailed(herrors.ClusterDriftInDB, err.Error())
+			}
+			if err := tx.Create(drift).Error; err != nil {
+				return herrors.NewErrInsertFailed(herrors.ClusterDriftInDB, err.Error())
+			}
+			return nil
+		}
+		// drifted is selected explicitly, so that it can be updated to false
+		if err := tx.Model(&existed).Select("drifted", "resources", "message", "detected_at").
+			Updates(drift).Error; err != nil {
+			return herrors.NewErrUpdateFailed(herrors.ClusterDriftInDB, err.Error())
+		}
+		drift.ID = existed.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.GetByClusterID(ctx, drift.ClusterID)
+}
+
+func (d *dao) UpdateReverted(ctx context.Context, clusterID uint, drift *models.ClusterDrift) error {
+	result := d.db.WithContext(ctx).Model(&models.ClusterDrift{}).Where("cluster_id = ?", clusterID).
+		Select("reverted_at", "reverted_by").Updates(drift)
+	if result.Error != nil {
+		return herrors.NewErrUpdateFailed(herrors.ClusterDriftInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return herrors.NewErrNotFound(herrors.ClusterDriftInDB,
+			"drift of cluster has not been detected")
+	}
+	return nil
+}
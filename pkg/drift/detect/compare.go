@@ -0,0 +1,205 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Resource is a drifted resource of cluster
+type Resource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Missing the resource is desired but not found in kubernetes
+	Missing bool     `json:"missing,omitempty"`
+	Fields  []*Field `json:"fields,omitempty"`
+}
+
+// Field is a field whose live value differs from the desired one
+type Field struct {
+	Path    string      `json:"path"`
+	Desired interface{} `json:"desired"`
+	Live    interface{} `json:"live"`
+}
+
+// Compare compares the fields set in desired object with the live object.
+// Fields only set in the live object are defaulted or managed by kubernetes, so they are ignored,
+// as well as status and metadata other than labels and annotations.
+// Fields whose path starts with any of ignoredFields are ignored, such as spec.replicas managed by hpa
+func Compare(desired, live *unstructured.Unstructured, ignoredFields []string) []*Field {
+	c := &comparer{ignoredFields: ignoredFields}
+	for key, value := range desired.Object {
+		switch key {
+		case "apiVersion", "kind", "status":
+		case "metadata":
+			metadata, _ := value.(map[string]interface{})
+			for _, field := range []string{"labels", "annotations"} {
+				c.compare(field, metadata[field], nestedValue(live.Object, "metadata", field), "metadata")
+			}
+		default:
+			c.compare(key, value, live.Object[key], "")
+		}
+	}
+	return c.fields
+}
+
+type comparer struct {
+	ignoredFields []string
+	fields        []*Field
+}
+
+func (c *comparer) compare(key string, desired, live interface{}, parent string) {
+	fieldPath := key
+	if parent != "" {
+		fieldPath = parent + "." + key
+	}
+	for _, ignored := range c.ignoredFields {
+		if fieldPath == ignored || strings.HasPrefix(fieldPath, ignored+".") ||
+			strings.HasPrefix(fieldPath, ignored+"[") {
+			return
+		}
+	}
+	if isEmpty(desired) {
+		return
+	}
+
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			c.add(fieldPath, desired, live)
+			return
+		}
+		for k, v := range desiredValue {
+			c.compare(k, v, liveValue[k], fieldPath)
+		}
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok {
+			c.add(fieldPath, desired, live)
+			return
+		}
+		if names, ok := elementNames(desiredValue); ok {
+			// lists of named elements like containers and env are matched by name
+			liveElements := make(map[string]interface{})
+			if liveNames, ok := elementNames(liveValue); ok {
+				for i, name := range liveNames {
+					liveElements[name] = liveValue[i]
+				}
+			}
+			for i, name := range names {
+				c.compare(fmt.Sprintf("[name=%s]", name), desiredValue[i], liveElements[name], fieldPath)
+			}
+			return
+		}
+		if len(desiredValue) != len(liveValue) {
+			c.add(fieldPath, desired, live)
+			return
+		}
+		for i := range desiredValue {
+			c.compare(fmt.Sprintf("[%d]", i), desiredValue[i], liveValue[i], fieldPath)
+		}
+	default:
+		if !scalarEqual(desired, live) {
+			c.add(fieldPath, desired, live)
+		}
+	}
+}
+
+func (c *comparer) add(fieldPath string, desired, live interface{}) {
+	c.fields = append(c.fields, &Field{
+		Path:    strings.ReplaceAll(fieldPath, ".[", "["),
+		Desired: desired,
+		Live:    live,
+	})
+}
+
+// elementNames returns the names of elements if all of them are objects with name
+func elementNames(elements []interface{}) ([]string, bool) {
+	names := make([]string, 0, len(elements))
+	for _, element := range elements {
+		object, ok := element.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := object["name"].(string)
+		if !ok || name == "" {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, len(names) > 0
+}
+
+func nestedValue(object map[string]interface{}, fields ...string) interface{} {
+	value, _, _ := unstructured.NestedFieldNoCopy(object, fields...)
+	return value
+}
+
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// scalarEqual compares scalars in the way kubernetes normalizes them,
+// numbers are compared by value and quantities like 0.5 and 500m are equal
+func scalarEqual(desired, live interface{}) bool {
+	if reflect.DeepEqual(desired, live) {
+		return true
+	}
+	desiredNumber, ok1 := toFloat(desired)
+	liveNumber, ok2 := toFloat(live)
+	if ok1 && ok2 {
+		return desiredNumber == liveNumber
+	}
+	_, desiredIsString := desired.(string)
+	_, liveIsString := live.(string)
+	if (ok1 || desiredIsString) && (ok2 || liveIsString) {
+		desiredQuantity, err1 := resource.ParseQuantity(fmt.Sprint(desired))
+		liveQuantity, err2 := resource.ParseQuantity(fmt.Sprint(live))
+		return err1 == nil && err2 == nil && desiredQuantity.Cmp(liveQuantity) == 0
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
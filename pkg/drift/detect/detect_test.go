@@ -0,0 +1,168 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kyaml "sigs.k8s.io/yaml"
+)
+
+const _deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    app: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.app.replicas }}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: {{ .Values.app.image }}
+          resources:
+            limits:
+              cpu: {{ .Values.app.cpu }}
+          env:
+            - name: ENV
+              value: {{ .Values.env.environment }}
+`
+
+const _hook = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Release.Name }}-migrate
+  annotations:
+    helm.sh/hook: pre-install
+`
+
+func TestRender(t *testing.T) {
+	templateChart := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "javaapp",
+			Version:    "v1.0.0",
+		},
+		Templates: []*chart.File{
+			{Name: "templates/deployment.yaml", Data: []byte(_deployment)},
+			{Name: "templates/hook.yaml", Data: []byte(_hook)},
+			{Name: "templates/NOTES.txt", Data: []byte("{{ .Release.Name }} is deployed")},
+		},
+		Values: map[string]interface{}{
+			"app": map[string]interface{}{"replicas": 1, "cpu": "500m"},
+			"env": map[string]interface{}{"environment": "dev"},
+		},
+	}
+	objects, err := Render(templateChart, "demo", "demo-ns", map[string]interface{}{
+		"javaapp": map[string]interface{}{
+			"app": map[string]interface{}{"replicas": 2, "image": "demo:v1"},
+			"env": map[string]interface{}{"environment": "online"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(objects))
+	deployment := objects[0]
+	assert.Equal(t, "Deployment", deployment.GetKind())
+	assert.Equal(t, "demo", deployment.GetName())
+	assert.Equal(t, "demo-ns", deployment.GetNamespace())
+	replicas, _, _ := unstructured.NestedFieldNoCopy(deployment.Object, "spec", "replicas")
+	assert.Equal(t, float64(2), replicas)
+}
+
+func TestCompare(t *testing.T) {
+	desired := object(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: demo
+  labels:
+    app: demo
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: app
+          image: demo:v1
+          resources:
+            limits:
+              cpu: 0.5
+          env:
+            - name: ENV
+              value: online
+          args: []
+`)
+	live := object(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: demo
+  uid: 7e1f0b3e
+  labels:
+    app: demo
+    app.kubernetes.io/instance: demo
+spec:
+  replicas: 2
+  revisionHistoryLimit: 10
+  template:
+    spec:
+      containers:
+        - name: sidecar
+          image: sidecar:v1
+        - name: app
+          image: demo:v1
+          imagePullPolicy: IfNotPresent
+          resources:
+            limits:
+              cpu: 500m
+          env:
+            - name: ENV
+              value: online
+status:
+  replicas: 2
+`)
+	assert.Empty(t, Compare(desired, live, nil))
+
+	// kubectl edit
+	containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+	containers[1].(map[string]interface{})["image"] = "demo:hotfix"
+	assert.Nil(t, unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers"))
+	assert.Nil(t, unstructured.SetNestedField(live.Object, int64(5), "spec", "replicas"))
+	assert.Nil(t, unstructured.SetNestedField(live.Object, "web", "metadata", "labels", "app"))
+
+	fields := Compare(desired, live, nil)
+	paths := make(map[string]*Field)
+	for _, field := range fields {
+		paths[field.Path] = field
+	}
+	assert.Equal(t, 3, len(fields))
+	assert.Equal(t, "demo:hotfix", paths["spec.template.spec.containers[name=app].image"].Live)
+	assert.Equal(t, int64(5), paths["spec.replicas"].Live)
+	assert.Equal(t, "web", paths["metadata.labels.app"].Live)
+
+	// fields managed by others are ignored
+	assert.Equal(t, 2, len(Compare(desired, live, []string{"spec.replicas"})))
+	assert.Equal(t, 1, len(Compare(desired, live, []string{"spec.replicas", "spec.template"})))
+}
+
+func object(t *testing.T, content string) *unstructured.Unstructured {
+	o := make(map[string]interface{})
+	assert.Nil(t, kyaml.Unmarshal([]byte(content), &o))
+	return &unstructured.Unstructured{Object: o}
+}
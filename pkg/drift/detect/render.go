@@ -0,0 +1,161 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/any"
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	hapichartutil "k8s.io/helm/pkg/chartutil"
+	hapichart "k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/releaseutil"
+	"k8s.io/helm/pkg/renderutil"
+	kyaml "sigs.k8s.io/yaml"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+)
+
+const (
+	_notesFile      = "NOTES.txt"
+	_hookAnnotation = "helm.sh/hook"
+)
+
+// Render renders the objects of cluster as cd does, the cluster chart depends on the template chart
+// and the values are passed through as they are in gitops repo.
+// Hooks are skipped because they are not kept in sync by cd,
+// and namespace of objects defaults to the namespace of cluster
+func Render(templateChart *chart.Chart, cluster, namespace string,
+	values map[string]interface{}) ([]*unstructured.Unstructured, error) {
+	// the engine of helm v3 is not compatible with the kubernetes client in use,
+	// so the chart is converted and rendered by the engine of helm v2
+	dependency, err := convertChart(templateChart)
+	if err != nil {
+		return nil, err
+	}
+	clusterChart := &hapichart.Chart{
+		Metadata: &hapichart.Metadata{
+			ApiVersion: chart.APIVersionV2,
+			Name:       cluster,
+			Version:    "1.0.0",
+		},
+		Dependencies: []*hapichart.Chart{dependency},
+		Values:       &hapichart.Config{Raw: "{}"},
+	}
+	raw, err := kyaml.Marshal(values)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	files, err := renderutil.Render(clusterChart, &hapichart.Config{Raw: string(raw)}, renderutil.Options{
+		ReleaseOptions: hapichartutil.ReleaseOptions{
+			Name:      cluster,
+			Namespace: namespace,
+			Revision:  1,
+			IsInstall: true,
+		},
+	})
+	if err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "failed to render chart: %v", err)
+	}
+
+	fileNames := make([]string, 0, len(files))
+	for fileName := range files {
+		base := path.Base(fileName)
+		if base == _notesFile || strings.HasPrefix(base, "_") {
+			continue
+		}
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	objects := make([]*unstructured.Unstructured, 0)
+	for _, fileName := range fileNames {
+		manifests := releaseutil.SplitManifests(files[fileName])
+		keys := make([]string, 0, len(manifests))
+		for key := range manifests {
+			keys = append(keys, key)
+		}
+		// keys are manifest-0, manifest-1 and so on in the order of documents
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+		for _, key := range keys {
+			content := make(map[string]interface{})
+			if err := kyaml.Unmarshal([]byte(manifests[key]), &content); err != nil {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid,
+					"failed to unmarshal %s: %v", fileName, err)
+			}
+			if len(content) == 0 {
+				continue
+			}
+			object := &unstructured.Unstructured{Object: content}
+			if _, ok := object.GetAnnotations()[_hookAnnotation]; ok {
+				continue
+			}
+			if object.GetNamespace() == "" {
+				object.SetNamespace(namespace)
+			}
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// convertChart converts the chart of helm v3 to the one of helm v2 with its dependencies
+func convertChart(c *chart.Chart) (*hapichart.Chart, error) {
+	raw, err := kyaml.Marshal(c.Values)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	converted := &hapichart.Chart{
+		Metadata: &hapichart.Metadata{
+			ApiVersion:  c.Metadata.APIVersion,
+			Name:        c.Metadata.Name,
+			Version:     c.Metadata.Version,
+			AppVersion:  c.Metadata.AppVersion,
+			Description: c.Metadata.Description,
+			KubeVersion: c.Metadata.KubeVersion,
+			Annotations: c.Metadata.Annotations,
+		},
+		Values: &hapichart.Config{Raw: string(raw)},
+	}
+	for _, template := range c.Templates {
+		converted.Templates = append(converted.Templates, &hapichart.Template{
+			Name: template.Name,
+			Data: template.Data,
+		})
+	}
+	for _, file := range c.Files {
+		converted.Files = append(converted.Files, &any.Any{
+			TypeUrl: file.Name,
+			Value:   file.Data,
+		})
+	}
+	for _, dependency := range c.Dependencies() {
+		convertedDependency, err := convertChart(dependency)
+		if err != nil {
+			return nil, err
+		}
+		converted.Dependencies = append(converted.Dependencies, convertedDependency)
+	}
+	return converted, nil
+}
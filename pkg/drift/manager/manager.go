@@ -0,0 +1,53 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/pkg/drift/dao"
+	"github.com/horizoncd/horizon/pkg/drift/models"
+)
+
+type Manager interface {
+	// GetByClusterID gets the latest drift detected for the cluster
+	GetByClusterID(ctx context.Context, clusterID uint) (*models.ClusterDrift, error)
+	// Upsert saves the latest drift of the cluster, which replaces the former one
+	Upsert(ctx context.Context, drift *models.ClusterDrift) (*models.ClusterDrift, error)
+	// UpdateReverted records who reverted the drift of cluster and when
+	UpdateReverted(ctx context.Context, clusterID uint, drift *models.ClusterDrift) error
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) GetByClusterID(ctx context.Context, clusterID uint) (*models.ClusterDrift, error) {
+	return m.dao.GetByClusterID(ctx, clusterID)
+}
+
+func (m *manager) Upsert(ctx context.Context, drift *models.ClusterDrift) (*models.ClusterDrift, error) {
+	return m.dao.Upsert(ctx, drift)
+}
+
+func (m *manager) UpdateReverted(ctx context.Context, clusterID uint, drift *models.ClusterDrift) error {
+	return m.dao.UpdateReverted(ctx, clusterID, drift)
+}
@@ -0,0 +1,38 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+// ClusterDrift is the latest drift between the desired state in gitops repo
+// and the live state in kubernetes of a cluster
+type ClusterDrift struct {
+	global.Model
+
+	ClusterID uint
+	Drifted   bool
+	// Resources is the json of drifted resources with their field level diffs
+	Resources string
+	// Message is the reason why drift cannot be detected
+	Message    string
+	DetectedAt time.Time
+	// RevertedAt is the last time the drift was reverted by syncing the cluster
+	RevertedAt *time.Time
+	RevertedBy uint
+}
@@ -0,0 +1,112 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/elevation/models"
+)
+
+type DAO interface {
+	Create(ctx context.Context, elevation *models.Elevation) (*models.Elevation, error)
+	GetByID(ctx context.Context, id uint) (*models.Elevation, error)
+	List(ctx context.Context, resourceType string, resourceID uint,
+		query *q.Query) ([]*models.Elevation, int64, error)
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.Elevation, error)
+	UpdateByID(ctx context.Context, id uint, elevation *models.Elevation) (*models.Elevation, error)
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Create(ctx context.Context, elevation *models.Elevation) (*models.Elevation, error) {
+	if err := d.db.WithContext(ctx).Create(elevation).Error; err != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.ElevationInDB, err.Error())
+	}
+	return elevation, nil
+}
+
+func (d *dao) GetByID(ctx context.Context, id uint) (*models.Elevation, error) {
+	var elevation models.Elevation
+	if err := d.db.WithContext(ctx).First(&elevation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.ElevationInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.ElevationInDB, err.Error())
+	}
+	return &elevation, nil
+}
+
+func (d *dao) List(ctx context.Context, resourceType string, resourceID uint,
+	query *q.Query) ([]*models.Elevation, int64, error) {
+	sql := d.db.WithContext(ctx).Model(&models.Elevation{}).
+		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID)
+	if query != nil {
+		for k, v := range query.Keywords {
+			switch k {
+			case common.ElevationQueryByStatus:
+				sql = sql.Where("status = ?", v)
+			case common.ElevationQueryByUser:
+				sql = sql.Where("user_id = ?", v)
+			}
+		}
+	} else {
+		query = &q.Query{}
+	}
+
+	var total int64
+	if err := sql.Count(&total).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.ElevationInDB, err.Error())
+	}
+
+	var elevations []*models.Elevation
+	if err := sql.Order("id desc").Limit(query.Limit()).Offset(query.Offset()).
+		Find(&elevations).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.ElevationInDB, err.Error())
+	}
+	return elevations, total, nil
+}
+
+func (d *dao) ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.Elevation, error) {
+	var elevations []*models.Elevation
+	if err := d.db.WithContext(ctx).
+		Where("status = ? AND expired_at <= ?", models.StatusApproved, before).
+		Order("expired_at asc").Limit(limit).Find(&elevations).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.ElevationInDB, err.Error())
+	}
+	return elevations, nil
+}
+
+func (d *dao) UpdateByID(ctx context.Context, id uint,
+	elevation *models.Elevation) (*models.Elevation, error) {
+	where := d.db.WithContext(ctx).Model(&models.Elevation{}).Where("id = ?", id)
+	if err := where.Updates(elevation).Error; err != nil {
+		return nil, herrors.NewErrUpdateFailed(herrors.ElevationInDB, err.Error())
+	}
+	return d.GetByID(ctx, id)
+}
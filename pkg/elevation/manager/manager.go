@@ -0,0 +1,70 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/elevation/dao"
+	"github.com/horizoncd/horizon/pkg/elevation/models"
+)
+
+type Manager interface {
+	// Create creates a privilege elevation request
+	Create(ctx context.Context, elevation *models.Elevation) (*models.Elevation, error)
+	// GetByID gets an elevation by ID
+	GetByID(ctx context.Context, id uint) (*models.Elevation, error)
+	// List lists the elevations of the resource, filtered by status or user in query keywords
+	List(ctx context.Context, resourceType string, resourceID uint,
+		query *q.Query) ([]*models.Elevation, int64, error)
+	// ListExpired lists the approved elevations whose expiry is before the specified time
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.Elevation, error)
+	// UpdateByID updates the non-zero fields of the elevation
+	UpdateByID(ctx context.Context, id uint, elevation *models.Elevation) (*models.Elevation, error)
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) Create(ctx context.Context, elevation *models.Elevation) (*models.Elevation, error) {
+	return m.dao.Create(ctx, elevation)
+}
+
+func (m *manager) GetByID(ctx context.Context, id uint) (*models.Elevation, error) {
+	return m.dao.GetByID(ctx, id)
+}
+
+func (m *manager) List(ctx context.Context, resourceType string, resourceID uint,
+	query *q.Query) ([]*models.Elevation, int64, error) {
+	return m.dao.List(ctx, resourceType, resourceID, query)
+}
+
+func (m *manager) ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.Elevation, error) {
+	return m.dao.ListExpired(ctx, before, limit)
+}
+
+func (m *manager) UpdateByID(ctx context.Context, id uint,
+	elevation *models.Elevation) (*models.Elevation, error) {
+	return m.dao.UpdateByID(ctx, id, elevation)
+}
@@ -0,0 +1,107 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/lib/orm"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/elevation/models"
+)
+
+var (
+	db, _ = orm.NewSqliteDB("")
+	ctx   context.Context
+	mgr   = New(db)
+)
+
+func TestElevation(t *testing.T) {
+	elevation, err := mgr.Create(ctx, &models.Elevation{
+		ResourceType:  common.ResourceGroup,
+		ResourceID:    1,
+		UserID:        2,
+		Role:          "owner",
+		Duration:      time.Hour,
+		Justification: "incident",
+		Status:        models.StatusPending,
+	})
+	assert.Nil(t, err)
+	assert.NotEqual(t, uint(0), elevation.ID)
+
+	_, err = mgr.Create(ctx, &models.Elevation{
+		ResourceType:  common.ResourceGroup,
+		ResourceID:    1,
+		UserID:        3,
+		Role:          "maintainer",
+		Duration:      time.Hour,
+		Justification: "incident",
+		Status:        models.StatusPending,
+	})
+	assert.Nil(t, err)
+
+	elevations, total, err := mgr.List(ctx, common.ResourceGroup, 1, q.New(q.KeyWords{
+		common.ElevationQueryByUser: uint(2),
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, elevation.ID, elevations[0].ID)
+
+	_, total, err = mgr.List(ctx, common.ResourceGroup, 1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), total)
+
+	now := time.Now()
+	expiredAt := now.Add(-time.Minute)
+	updated, err := mgr.UpdateByID(ctx, elevation.ID, &models.Elevation{
+		Status:     models.StatusApproved,
+		MemberID:   5,
+		ReviewedBy: 1,
+		ReviewedAt: &now,
+		ExpiredAt:  &expiredAt,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusApproved, updated.Status)
+	assert.Equal(t, uint(5), updated.MemberID)
+	assert.Equal(t, "owner", updated.Role)
+
+	expired, err := mgr.ListExpired(ctx, now, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(expired))
+	assert.Equal(t, elevation.ID, expired[0].ID)
+
+	_, err = mgr.UpdateByID(ctx, elevation.ID, &models.Elevation{Status: models.StatusExpired})
+	assert.Nil(t, err)
+	expired, err = mgr.ListExpired(ctx, now, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(expired))
+
+	_, err = mgr.GetByID(ctx, 100)
+	assert.NotNil(t, err)
+}
+
+func TestMain(m *testing.M) {
+	if err := db.AutoMigrate(&models.Elevation{}); err != nil {
+		panic(err)
+	}
+	ctx = context.TODO()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,65 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+type Status string
+
+const (
+	// StatusPending the elevation is waiting for approval
+	StatusPending Status = "pending"
+	// StatusApproved the elevation is approved and the elevated member binding is active
+	StatusApproved Status = "approved"
+	// StatusRejected the elevation is rejected by the approver
+	StatusRejected Status = "rejected"
+	// StatusExpired the elevated member binding has been revoked after expiry
+	StatusExpired Status = "expired"
+)
+
+// Elevation is a just-in-time request from a user to hold a role on a resource for a bounded duration
+type Elevation struct {
+	global.Model
+
+	// ResourceType groups/applications/clusters
+	ResourceType string
+	// ResourceID groupID/applicationID/clusterID
+	ResourceID uint
+	// UserID the user who requests the elevation
+	UserID uint
+	// Role the role requested, such as owner
+	Role string
+	// Duration how long the elevated role is held after approval
+	Duration time.Duration
+	// Justification why the elevation is needed, such as an incident link
+	Justification string
+	Status        Status
+
+	// MemberID the member binding created or updated by the elevation
+	MemberID uint
+	// PreviousRole the role the user held directly on the resource before elevation,
+	// empty means there was no direct member binding
+	PreviousRole string
+
+	ReviewedBy uint
+	ReviewedAt *time.Time
+	ExpiredAt  *time.Time
+	CreatedBy  uint
+	UpdatedBy  uint
+}
@@ -77,6 +77,14 @@ func (d *dao) List(ctx context.Context, query *q.Query) ([]*models.Event, error)
 			statement = statement.Where("id <= ?", v)
 		case common.ReqID:
 			statement = statement.Where("req_id = ?", v)
+		case common.ParamResourceType:
+			statement = statement.Where("resource_type = ?", v)
+		case common.ParamResourceID:
+			statement = statement.Where("resource_id = ?", v)
+		case common.EventType:
+			statement = statement.Where("event_type = ?", v)
+		case common.StartTime:
+			statement = statement.Where("created_at >= ?", v)
 		}
 	}
 
@@ -126,6 +126,7 @@ var supportedEvents = map[string]string{
 	models.ApplicationDeleted:     "Application has been deleted",
 	models.ApplicationTransfered:  "Application has been transferred to another group",
 	models.ApplicationUpdated:     "Application has been updated",
+	models.ApplicationBuildSlow:   "Build step of application has become slower than its baseline",
 	models.ClusterCreated:         "New cluster has been created",
 	models.ClusterDeleted:         "Cluster has been deleted",
 	models.ClusterUpdated:         "Cluster has been updated",
@@ -137,12 +138,43 @@ var supportedEvents = map[string]string{
 	models.ClusterAction:          "Cluster has triggered an action",
 	models.ClusterPodsRescheduled: "Pods has been deleted to reschedule",
 	models.ClusterKubernetesEvent: "Kubernetes event associated with cluster has been triggered",
+	models.ClusterKubernetesAlert: "Kubernetes events associated with cluster have occurred too many times",
+	models.ClusterDriftDetected:   "Live state of cluster has drifted from gitops repo",
+	models.ClusterDriftReverted:   "Drift of cluster has been reverted",
+	models.ClusterHibernated:      "Workloads of cluster have been scaled to zero for hibernation",
+	models.ClusterWoken:           "Cluster has been woken up from hibernation",
+	models.ClusterPortForwarded:   "Port of a pod in cluster has been forwarded",
+	models.ClusterPodProxied:      "Port of a pod in cluster has been accessed through proxy",
+	models.ClusterCanaryPromoted:  "Canary of cluster has passed analysis and been promoted",
+	models.ClusterCanaryAborted:   "Canary of cluster has failed analysis and been aborted",
+	models.ClusterHPAOverridden:   "Replicas of cluster's horizontal pod autoscaler have been overridden temporarily",
+	models.ClusterHPAReverted:     "Replicas override of cluster's horizontal pod autoscaler has been reverted",
 	models.MemberCreated:          "New member has been created",
 	models.MemberUpdated:          "Member has been updated",
 	models.MemberDeleted:          "Member has been deleted",
 	models.PipelinerunCreated:     "New pipelinerun has been created",
 	models.PipelinerunCancelled:   "Pipelinerun has been cancelled",
 	models.PipelinerunExecuted:    "Pipelinerun has been executed",
+	models.PipelinerunToApprove:   "Pipelinerun is waiting for approvals before executed",
+	models.PipelinerunApproved:    "Pipelinerun has been approved by a reviewer",
+	models.PipelinerunRejected:    "Pipelinerun has been rejected by a reviewer",
+	models.ElevationRequested:     "Privilege elevation has been requested",
+	models.ElevationApproved:      "Privilege elevation has been approved",
+	models.ElevationRejected:      "Privilege elevation has been rejected",
+	models.ElevationExpired:       "Privilege elevation has expired and been revoked",
+	models.AdmissionDenied:        "Request has been denied by admission webhooks",
+	models.AdmissionMutated:       "Request has been mutated by admission webhooks",
+	models.ReleasePlanCreated:     "New release plan has been created",
+	models.ReleasePlanSucceeded:   "Release plan has succeeded",
+	models.ReleasePlanFailed:      "Release plan has failed and halted",
+	models.ReleasePlanCancelled:   "Release plan has been cancelled",
+	models.ChangeRequestCreated:   "New config change request has been created",
+	models.ChangeRequestApproved:  "Config change request has been approved",
+	models.ChangeRequestRejected:  "Config change request has been rejected",
+	models.ChangeRequestMerged:    "Config change request has been merged and applied",
+	models.ChangeRequestClosed:    "Config change request has been closed",
+	models.UserAdminGranted:       "User has been granted admin by claims of identity provider",
+	models.UserAdminRevoked:       "User's admin granted by claims of identity provider has been revoked",
 }
 
 func (m *manager) ListSupportEvents() map[string]string {
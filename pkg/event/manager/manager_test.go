@@ -17,6 +17,7 @@ package manager
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/horizoncd/horizon/core/common"
 	"github.com/horizoncd/horizon/lib/orm"
@@ -91,6 +92,23 @@ func Test(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(events))
 
+	events, err = m.ListEvents(ctx, &q.Query{Keywords: q.KeyWords{
+		common.ParamResourceType: common.ResourceCluster,
+		common.ParamResourceID:   1,
+		common.EventType:         eventmodels.ClusterCreated,
+		common.StartTime:         time.Now().Add(-time.Hour),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(events))
+
+	events, err = m.ListEvents(ctx, &q.Query{Keywords: q.KeyWords{common.EventType: eventmodels.ClusterDeleted}})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+
+	events, err = m.ListEvents(ctx, &q.Query{Keywords: q.KeyWords{common.StartTime: time.Now().Add(time.Hour)}})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+
 	ec, err := m.CreateOrUpdateCursor(ctx, &eventmodels.EventCursor{
 		Position: 1,
 	})
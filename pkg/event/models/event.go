@@ -27,6 +27,7 @@ const (
 	ApplicationDeleted     string = "applications_deleted"
 	ApplicationUpdated     string = "applications_updated"
 	ApplicationTransfered  string = "applications_transferred"
+	ApplicationBuildSlow   string = "applications_build_regressed"
 	ClusterCreated         string = "clusters_created"
 	ClusterDeleted         string = "clusters_deleted"
 	ClusterBuildDeployed   string = "clusters_builddeployed"
@@ -37,6 +38,17 @@ const (
 	ClusterUpdated         string = "clusters_updated"
 	ClusterFreed           string = "clusters_freed"
 	ClusterKubernetesEvent string = "clusters_kubernetes_event"
+	ClusterKubernetesAlert string = "clusters_kubernetes_alert"
+	ClusterDriftDetected   string = "clusters_drift_detected"
+	ClusterDriftReverted   string = "clusters_drift_reverted"
+	ClusterCanaryPromoted  string = "clusters_canary_promoted"
+	ClusterCanaryAborted   string = "clusters_canary_aborted"
+	ClusterHPAOverridden   string = "clusters_hpa_overridden"
+	ClusterHPAReverted     string = "clusters_hpa_reverted"
+	ClusterHibernated      string = "clusters_hibernated"
+	ClusterWoken           string = "clusters_woken"
+	ClusterPortForwarded   string = "clusters_port_forwarded"
+	ClusterPodProxied      string = "clusters_pod_proxied"
 	ClusterAction                 = "clusters_action"
 	MemberCreated          string = "members_created"
 	MemberUpdated          string = "members_updated"
@@ -44,6 +56,26 @@ const (
 	PipelinerunCreated     string = "pipelineruns_created"
 	PipelinerunCancelled   string = "pipelineruns_cancelled"
 	PipelinerunExecuted    string = "pipelineruns_executed"
+	PipelinerunToApprove   string = "pipelineruns_approval_requested"
+	PipelinerunApproved    string = "pipelineruns_approved"
+	PipelinerunRejected    string = "pipelineruns_rejected"
+	ElevationRequested     string = "elevations_requested"
+	ElevationApproved      string = "elevations_approved"
+	ElevationRejected      string = "elevations_rejected"
+	ElevationExpired       string = "elevations_expired"
+	AdmissionDenied        string = "admissions_denied"
+	AdmissionMutated       string = "admissions_mutated"
+	ReleasePlanCreated     string = "releaseplans_created"
+	ReleasePlanSucceeded   string = "releaseplans_succeeded"
+	ReleasePlanFailed      string = "releaseplans_failed"
+	ReleasePlanCancelled   string = "releaseplans_cancelled"
+	ChangeRequestCreated   string = "changerequests_created"
+	ChangeRequestApproved  string = "changerequests_approved"
+	ChangeRequestRejected  string = "changerequests_rejected"
+	ChangeRequestMerged    string = "changerequests_merged"
+	ChangeRequestClosed    string = "changerequests_closed"
+	UserAdminGranted       string = "users_admin_granted"
+	UserAdminRevoked       string = "users_admin_revoked"
 	// TODO: add group events
 )
 
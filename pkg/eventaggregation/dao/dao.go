@@ -0,0 +1,111 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/eventaggregation/models"
+)
+
+type DAO interface {
+	Create(ctx context.Context, aggregation *models.Aggregation) (*models.Aggregation, error)
+	GetLatest(ctx context.Context, clusterID uint, reason, kind, namespace, name string) (*models.Aggregation, error)
+	Update(ctx context.Context, aggregation *models.Aggregation) error
+	ListByClusterID(ctx context.Context, clusterID uint, severity string,
+		query *q.Query) ([]*models.Aggregation, int64, error)
+	DeleteLastSeenBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Create(ctx context.Context, aggregation *models.Aggregation) (*models.Aggregation, error) {
+	if err := d.db.WithContext(ctx).Create(aggregation).Error; err != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.K8sEventAggregationInDB, err.Error())
+	}
+	return aggregation, nil
+}
+
+func (d *dao) GetLatest(ctx context.Context, clusterID uint,
+	reason, kind, namespace, name string) (*models.Aggregation, error) {
+	var aggregation models.Aggregation
+	if err := d.db.WithContext(ctx).
+		Where("cluster_id = ? and reason = ? and kind = ? and namespace = ? and name = ?",
+			clusterID, reason, kind, namespace, name).
+		Order("first_seen desc").First(&aggregation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.K8sEventAggregationInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.K8sEventAggregationInDB, err.Error())
+	}
+	return &aggregation, nil
+}
+
+func (d *dao) Update(ctx context.Context, aggregation *models.Aggregation) error {
+	result := d.db.WithContext(ctx).Model(&models.Aggregation{}).
+		Where("id = ?", aggregation.ID).
+		Updates(map[string]interface{}{
+			"message":   aggregation.Message,
+			"count":     aggregation.Count,
+			"last_seen": aggregation.LastSeen,
+			"sources":   aggregation.Sources,
+		})
+	if result.Error != nil {
+		return herrors.NewErrUpdateFailed(herrors.K8sEventAggregationInDB, result.Error.Error())
+	}
+	return nil
+}
+
+func (d *dao) ListByClusterID(ctx context.Context, clusterID uint, severity string,
+	query *q.Query) ([]*models.Aggregation, int64, error) {
+	var (
+		aggregations []*models.Aggregation
+		total        int64
+	)
+	statement := d.db.WithContext(ctx).Model(&models.Aggregation{}).Where("cluster_id = ?", clusterID)
+	if severity != "" {
+		statement = statement.Where("severity = ?", severity)
+	}
+	if err := statement.Count(&total).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.K8sEventAggregationInDB, err.Error())
+	}
+	if query != nil {
+		statement = statement.Offset(query.Offset()).Limit(query.Limit())
+	}
+	if err := statement.Order("last_seen desc").Find(&aggregations).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.K8sEventAggregationInDB, err.Error())
+	}
+	return aggregations, total, nil
+}
+
+func (d *dao) DeleteLastSeenBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := d.db.WithContext(ctx).Unscoped().Where("last_seen < ?", before).Delete(&models.Aggregation{})
+	if result.Error != nil {
+		return 0, herrors.NewErrDeleteFailed(herrors.K8sEventAggregationInDB, result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,69 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/eventaggregation/dao"
+	"github.com/horizoncd/horizon/pkg/eventaggregation/models"
+)
+
+type Manager interface {
+	Create(ctx context.Context, aggregation *models.Aggregation) (*models.Aggregation, error)
+	// GetLatest gets the latest aggregation of the reason and object
+	GetLatest(ctx context.Context, clusterID uint, reason, kind, namespace, name string) (*models.Aggregation, error)
+	// Update updates the message, count, last seen and sources of the aggregation
+	Update(ctx context.Context, aggregation *models.Aggregation) error
+	// ListByClusterID lists the aggregations of cluster, the latest seen first. All severities are listed if empty
+	ListByClusterID(ctx context.Context, clusterID uint, severity string,
+		query *q.Query) ([]*models.Aggregation, int64, error)
+	// DeleteLastSeenBefore deletes the aggregations not seen since the time
+	DeleteLastSeenBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) Create(ctx context.Context, aggregation *models.Aggregation) (*models.Aggregation, error) {
+	return m.dao.Create(ctx, aggregation)
+}
+
+func (m *manager) GetLatest(ctx context.Context, clusterID uint,
+	reason, kind, namespace, name string) (*models.Aggregation, error) {
+	return m.dao.GetLatest(ctx, clusterID, reason, kind, namespace, name)
+}
+
+func (m *manager) Update(ctx context.Context, aggregation *models.Aggregation) error {
+	return m.dao.Update(ctx, aggregation)
+}
+
+func (m *manager) ListByClusterID(ctx context.Context, clusterID uint, severity string,
+	query *q.Query) ([]*models.Aggregation, int64, error) {
+	return m.dao.ListByClusterID(ctx, clusterID, severity, query)
+}
+
+func (m *manager) DeleteLastSeenBefore(ctx context.Context, before time.Time) (int64, error) {
+	return m.dao.DeleteLastSeenBefore(ctx, before)
+}
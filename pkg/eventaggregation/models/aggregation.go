@@ -0,0 +1,83 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Aggregation merges the kubernetes events of the same reason and object within a time window
+type Aggregation struct {
+	global.Model
+
+	// ClusterID is 0 if the involved object does not belong to any cluster
+	ClusterID uint
+	RegionID  uint
+	Reason    string
+	Kind      string
+	Namespace string
+	Name      string
+	// Type is the type of kubernetes events, Normal or Warning
+	Type     string
+	Severity string
+	// Message is the message of the latest event
+	Message   string
+	Count     int32
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Sources maps the uids of kubernetes events to their counts, since a kubernetes event is updated
+	// with the count increased when it occurs again
+	Sources Sources `gorm:"type:text"`
+	// EventID is the id of the horizon event emitted when the aggregation is created
+	EventID uint
+}
+
+func (Aggregation) TableName() string {
+	return "tb_k8s_event_aggregation"
+}
+
+type Sources map[string]int32
+
+func (s *Sources) Scan(value interface{}) error {
+	var bts []byte
+	switch v := value.(type) {
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal Sources from value: %v", value)
+	}
+	return json.Unmarshal(bts, s)
+}
+
+func (s Sources) Value() (driver.Value, error) {
+	bts, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(bts), nil
+}
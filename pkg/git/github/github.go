@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v41/github"
 	herrors "github.com/horizoncd/horizon/core/errors"
@@ -105,8 +106,9 @@ func (h Helper) GetCommit(ctx context.Context, gitURL string, refType string, re
 			return nil, err
 		}
 		return &git.Commit{
-			ID:      commit.GetSHA(),
-			Message: commit.Commit.GetMessage(),
+			ID:          commit.GetSHA(),
+			Message:     commit.Commit.GetMessage(),
+			CommittedAt: committedAt(commit.Commit),
 		}, nil
 	case git.GitRefTypeTag:
 		// todo handle the situation that more than 100 tags exist
@@ -132,8 +134,9 @@ func (h Helper) GetCommit(ctx context.Context, gitURL string, refType string, re
 			return nil, err
 		}
 		return &git.Commit{
-			ID:      commit.GetSHA(),
-			Message: commit.Commit.GetMessage(),
+			ID:          commit.GetSHA(),
+			Message:     commit.Commit.GetMessage(),
+			CommittedAt: committedAt(commit.Commit),
 		}, nil
 	case git.GitRefTypeBranch:
 		branch, _, err := h.client.Repositories.GetBranch(ctx, paths[0], paths[1], ref, true)
@@ -141,8 +144,9 @@ func (h Helper) GetCommit(ctx context.Context, gitURL string, refType string, re
 			return nil, err
 		}
 		return &git.Commit{
-			ID:      branch.Commit.GetSHA(),
-			Message: branch.Commit.Commit.GetMessage(),
+			ID:          branch.Commit.GetSHA(),
+			Message:     branch.Commit.Commit.GetMessage(),
+			CommittedAt: committedAt(branch.Commit.Commit),
 		}, nil
 	default:
 		return nil, perror.Wrapf(herrors.ErrParamInvalid, "git ref type %s is invalid", refType)
@@ -211,3 +215,12 @@ func (h Helper) GetCommitHistoryLink(gitURL string, commit string) (string, erro
 
 	return fmt.Sprintf("%s/commits/%s", httpLink, commit), nil
 }
+
+// committedAt returns the committer date of the commit, or nil if unknown
+func committedAt(commit *github.Commit) *time.Time {
+	date := commit.GetCommitter().GetDate()
+	if date.IsZero() {
+		return nil
+	}
+	return &date
+}
@@ -100,8 +100,9 @@ func (h Helper) GetCommit(ctx context.Context, gitURL string, refType string, re
 			return nil, err
 		}
 		return &git.Commit{
-			ID:      commit.ID,
-			Message: commit.Message,
+			ID:          commit.ID,
+			Message:     commit.Message,
+			CommittedAt: commit.CommittedDate,
 		}, nil
 	case git.GitRefTypeTag:
 		tag, err := h.client.GetTag(ctx, pid, ref)
@@ -109,8 +110,9 @@ func (h Helper) GetCommit(ctx context.Context, gitURL string, refType string, re
 			return nil, err
 		}
 		return &git.Commit{
-			ID:      tag.Commit.ID,
-			Message: tag.Commit.Message,
+			ID:          tag.Commit.ID,
+			Message:     tag.Commit.Message,
+			CommittedAt: tag.Commit.CommittedDate,
 		}, nil
 	case git.GitRefTypeBranch:
 		branch, err := h.client.GetBranch(ctx, pid, ref)
@@ -118,8 +120,9 @@ func (h Helper) GetCommit(ctx context.Context, gitURL string, refType string, re
 			return nil, err
 		}
 		return &git.Commit{
-			ID:      branch.Commit.ID,
-			Message: branch.Commit.Message,
+			ID:          branch.Commit.ID,
+			Message:     branch.Commit.Message,
+			CommittedAt: branch.Commit.CommittedDate,
 		}, nil
 	default:
 		return nil, perror.Wrapf(herrors.ErrParamInvalid, "git ref type %s is invalid", refType)
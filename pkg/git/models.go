@@ -14,6 +14,8 @@
 
 package git
 
+import "time"
+
 const (
 	GitRefTypeBranch = "branch"
 	GitRefTypeTag    = "tag"
@@ -29,6 +31,8 @@ type Tag struct {
 type Commit struct {
 	ID      string
 	Message string
+	// CommittedAt is nil if unknown
+	CommittedAt *time.Time
 }
 
 // SearchParams contains parameters for searching operation
@@ -0,0 +1,780 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	gitopsstoremanager "github.com/horizoncd/horizon/pkg/gitopsstore/manager"
+	"github.com/horizoncd/horizon/pkg/gitopsstore/models"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+const Kind = "database"
+
+const (
+	_readmeFile    = "README.md"
+	_defaultBranch = "master"
+	_defaultAuthor = "horizon"
+	_defaultEmail  = "horizon@localhost"
+	_branchPrefix  = "refs/heads/"
+	// _writeRetries is the times to retry when the branch is moved by another commit during writing
+	_writeRetries = 3
+)
+
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Gitops stores gitops repos in database. Every revision of a repo is a commit whose ID is computed
+// in the same way as git, so the repos can be mirrored to git with identical commit IDs.
+// Groups are virtual and always exist.
+type Gitops struct {
+	mgr           gitopsstoremanager.Manager
+	defaultBranch string
+	mirror        *Mirror
+}
+
+var _ gitops.Interface = (*Gitops)(nil)
+
+// New creates a database gitops, the mirror is optional
+func New(mgr gitopsstoremanager.Manager, defaultBranch string, mirror *Mirror) *Gitops {
+	if defaultBranch == "" {
+		defaultBranch = _defaultBranch
+	}
+	return &Gitops{
+		mgr:           mgr,
+		defaultBranch: defaultBranch,
+		mirror:        mirror,
+	}
+}
+
+func (g *Gitops) GetGroup(ctx context.Context, path string) (*gitops.Group, error) {
+	path, err := cleanPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return toGroup(path), nil
+}
+
+func (g *Gitops) CreateGroup(ctx context.Context, name, path string,
+	parent *gitops.Group, visibility string) (*gitops.Group, error) {
+	fullPath := path
+	if parent != nil {
+		fullPath = parent.FullPath + "/" + path
+	}
+	fullPath, err := cleanPath(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.mgr.GetRepoByPath(ctx, fullPath); err == nil {
+		return nil, perror.Wrapf(herrors.ErrPathConflict, "project %s already exists", fullPath)
+	} else if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+		return nil, err
+	}
+	return toGroup(fullPath), nil
+}
+
+func (g *Gitops) GetCreatedGroup(ctx context.Context, parent *gitops.Group,
+	name, visibility string) (*gitops.Group, error) {
+	return g.GetGroup(ctx, fmt.Sprintf("%v/%v", parent.FullPath, name))
+}
+
+func (g *Gitops) DeleteGroup(ctx context.Context, path string) error {
+	path, err := cleanPath(path)
+	if err != nil {
+		return err
+	}
+	repos, err := g.mgr.ListReposByPathPrefix(ctx, path)
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := g.mgr.DeleteRepo(ctx, repo.ID); err != nil {
+			return err
+		}
+	}
+	if g.mirror != nil {
+		if err := g.mirror.target.DeleteGroup(ctx, path); err != nil {
+			log.Warningf(ctx, "failed to delete group %s of mirror, err: %v", path, err)
+		}
+	}
+	return nil
+}
+
+func (g *Gitops) GetProject(ctx context.Context, path string) (*gitops.Project, error) {
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return toProject(repo), nil
+}
+
+func (g *Gitops) CreateProject(ctx context.Context, name string,
+	group *gitops.Group, visibility string) (*gitops.Project, error) {
+	const op = "database gitops: create project"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	path, err := cleanPath(fmt.Sprintf("%v/%v", group.FullPath, name))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.mgr.GetRepoByPath(ctx, path); err == nil {
+		return nil, perror.Wrapf(herrors.ErrPathConflict, "project %s already exists", path)
+	} else if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+		return nil, err
+	}
+
+	// initialize default branch with a readme just like gitlab does
+	files, blobs := map[string]string{}, []*models.Blob{}
+	readme := fmt.Sprintf("# %s\n", name)
+	files[_readmeFile] = blobSHA(readme)
+	blobs = append(blobs, &models.Blob{SHA: files[_readmeFile], Content: readme})
+	commit, err := newCommit(ctx, files, nil, "Initial commit")
+	if err != nil {
+		return nil, err
+	}
+	repo, err := g.mgr.CreateRepo(ctx, &models.Repo{
+		Path:          path,
+		DefaultBranch: g.defaultBranch,
+	}, commit, blobs)
+	if err != nil {
+		return nil, err
+	}
+	return toProject(repo), nil
+}
+
+func (g *Gitops) DeleteProject(ctx context.Context, path string) error {
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := g.mgr.DeleteRepo(ctx, repo.ID); err != nil {
+		return err
+	}
+	if g.mirror != nil {
+		if err := g.mirror.target.DeleteProject(ctx, repo.Path); err != nil {
+			log.Warningf(ctx, "failed to delete project %s of mirror, err: %v", repo.Path, err)
+		}
+	}
+	return nil
+}
+
+func (g *Gitops) TransferProject(ctx context.Context, path, groupPath string) error {
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return err
+	}
+	groupPath, err = cleanPath(groupPath)
+	if err != nil {
+		return err
+	}
+	if err := g.moveRepo(ctx, repo, groupPath+"/"+pathBase(repo.Path)); err != nil {
+		return err
+	}
+	if g.mirror != nil {
+		if err := g.mirror.transferProject(ctx, repo.Path, groupPath); err != nil {
+			log.Warningf(ctx, "failed to transfer project %s of mirror, err: %v", repo.Path, err)
+		}
+	}
+	return nil
+}
+
+func (g *Gitops) EditNameAndPathForProject(ctx context.Context, path string, newName, newPath *string) error {
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return err
+	}
+	// the name of a project is always the same as its path
+	if newPath == nil || *newPath == pathBase(repo.Path) {
+		return nil
+	}
+	oldPath := repo.Path
+	if err := g.moveRepo(ctx, repo, pathDir(oldPath)+"/"+*newPath); err != nil {
+		return err
+	}
+	if g.mirror != nil {
+		if err := g.mirror.target.EditNameAndPathForProject(ctx, oldPath, newName, newPath); err != nil {
+			log.Warningf(ctx, "failed to rename project %s of mirror, err: %v", oldPath, err)
+		}
+	}
+	return nil
+}
+
+func (g *Gitops) GetBranch(ctx context.Context, path, branch string) (*gitops.Branch, error) {
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := g.mgr.GetRef(ctx, repo.ID, strings.TrimPrefix(branch, _branchPrefix))
+	if err != nil {
+		return nil, err
+	}
+	commit, err := g.mgr.GetCommit(ctx, repo.ID, ref.CommitSHA)
+	if err != nil {
+		return nil, err
+	}
+	return toBranch(ref.Name, commit), nil
+}
+
+func (g *Gitops) CreateBranch(ctx context.Context, path, branch, fromRef string) (*gitops.Branch, error) {
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	branch = strings.TrimPrefix(branch, _branchPrefix)
+	if _, err := g.mgr.GetRef(ctx, repo.ID, branch); err == nil {
+		return nil, perror.Wrapf(herrors.ErrPathConflict, "branch %s already exists", branch)
+	} else if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+		return nil, err
+	}
+	commit, err := g.resolve(ctx, repo, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.mgr.CreateCommit(ctx, commit, nil, branch, ""); err != nil {
+		return nil, err
+	}
+	return toBranch(branch, commit), nil
+}
+
+func (g *Gitops) GetFile(ctx context.Context, path, ref, filepath string) ([]byte, error) {
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := g.resolve(ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	files, err := commit.Files()
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	sha, ok := files[strings.TrimPrefix(filepath, "/")]
+	if !ok {
+		return nil, herrors.NewErrNotFound(herrors.GitopsBlobInDB,
+			fmt.Sprintf("file %s not found in %s of project %s", filepath, ref, path))
+	}
+	contents, err := g.contents(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(contents[sha]), nil
+}
+
+func (g *Gitops) WriteFiles(ctx context.Context, path, branch, commitMsg string,
+	startBranch *string, actions []gitops.CommitAction) (_ *gitops.Commit, err error) {
+	const op = "database gitops: write files"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	branch = strings.TrimPrefix(branch, _branchPrefix)
+	// the actions are applied onto the latest commit of branch, retry if branch is moved during writing
+	for i := 0; ; i++ {
+		commit, err := g.writeFiles(ctx, repo, branch, commitMsg, startBranch, actions)
+		if err == nil {
+			return &gitops.Commit{ID: commit.SHA, Message: commitMsg}, nil
+		}
+		if perror.Cause(err) != herrors.ErrGitopsRefChanged || i+1 >= _writeRetries {
+			return nil, err
+		}
+	}
+}
+
+func (g *Gitops) writeFiles(ctx context.Context, repo *models.Repo, branch, commitMsg string,
+	startBranch *string, actions []gitops.CommitAction) (*models.Commit, error) {
+	oldSHA := ""
+	ref, err := g.mgr.GetRef(ctx, repo.ID, branch)
+	if err == nil {
+		oldSHA = ref.CommitSHA
+	} else {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok || startBranch == nil {
+			return nil, err
+		}
+		// create the branch from startBranch, then commit on it
+		if ref, err = g.mgr.GetRef(ctx, repo.ID, strings.TrimPrefix(*startBranch, _branchPrefix)); err != nil {
+			return nil, err
+		}
+	}
+	parent, err := g.mgr.GetCommit(ctx, repo.ID, ref.CommitSHA)
+	if err != nil {
+		return nil, err
+	}
+	files, err := parent.Files()
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+
+	blobs := make([]*models.Blob, 0, len(actions))
+	addFile := func(filePath, content string) {
+		sha := blobSHA(content)
+		files[filePath] = sha
+		blobs = append(blobs, &models.Blob{SHA: sha, Content: content})
+	}
+	for _, action := range actions {
+		filePath, err := cleanPath(action.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		_, exists := files[filePath]
+		switch action.Action {
+		case gitops.FileCreate, gitops.FileUpdate:
+			if action.Action == gitops.FileCreate && exists {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid, "file %s already exists", action.FilePath)
+			}
+			if action.Action == gitops.FileUpdate && !exists {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid, "file %s does not exist", action.FilePath)
+			}
+			addFile(filePath, action.Content)
+		case gitops.FileDelete:
+			if !exists {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid, "file %s does not exist", action.FilePath)
+			}
+			delete(files, filePath)
+		case gitops.FileMove:
+			previousPath, err := cleanPath(action.PreviousPath)
+			if err != nil {
+				return nil, err
+			}
+			prevSHA, ok := files[previousPath]
+			if !ok {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid, "file %s does not exist", action.PreviousPath)
+			}
+			if exists {
+				return nil, perror.Wrapf(herrors.ErrParamInvalid, "file %s already exists", action.FilePath)
+			}
+			delete(files, previousPath)
+			if action.Content == "" {
+				files[filePath] = prevSHA
+			} else {
+				addFile(filePath, action.Content)
+			}
+		default:
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "unsupported file action %s", action.Action)
+		}
+	}
+
+	commit, err := newCommit(ctx, files, []*models.Commit{parent}, commitMsg)
+	if err != nil {
+		return nil, err
+	}
+	commit.RepoID = repo.ID
+	if err := g.mgr.CreateCommit(ctx, commit, blobs, branch, oldSHA); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// Compare compares the files of from and to, renamed files are reported as a deletion and a creation.
+func (g *Gitops) Compare(ctx context.Context, path, from, to string, straight bool) (_ []*gitops.Diff, err error) {
+	const op = "database gitops: compare"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	base, err := g.resolve(ctx, repo, from)
+	if err != nil {
+		return nil, err
+	}
+	head, err := g.resolve(ctx, repo, to)
+	if err != nil {
+		return nil, err
+	}
+	if !straight {
+		mergeBase, err := g.mergeBase(ctx, repo, base, head)
+		if err != nil {
+			return nil, err
+		}
+		if mergeBase != nil {
+			base = mergeBase
+		}
+	}
+
+	fromFiles, err := base.Files()
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	toFiles, err := head.Files()
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	diffs := make([]*gitops.Diff, 0)
+	shas := make([]string, 0)
+	for filePath, sha := range toFiles {
+		if fromSHA, ok := fromFiles[filePath]; !ok || fromSHA != sha {
+			diffs = append(diffs, &gitops.Diff{OldPath: filePath, NewPath: filePath, NewFile: !ok})
+			shas = append(shas, sha, fromSHA)
+		}
+	}
+	for filePath, sha := range fromFiles {
+		if _, ok := toFiles[filePath]; !ok {
+			diffs = append(diffs, &gitops.Diff{OldPath: filePath, NewPath: filePath, DeletedFile: true})
+			shas = append(shas, sha)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].NewPath < diffs[j].NewPath
+	})
+
+	contents, err := g.contents(ctx, shas...)
+	if err != nil {
+		return nil, err
+	}
+	for _, diff := range diffs {
+		diff.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:       splitLines(contents[fromFiles[diff.OldPath]]),
+			B:       splitLines(contents[toFiles[diff.NewPath]]),
+			Context: 3,
+		})
+		if err != nil {
+			return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+		}
+	}
+	return diffs, nil
+}
+
+// MergeBranch merges source into target file by file, it fails with ErrGitMergeConflict
+// if a file is changed differently on both branches since their merge base.
+func (g *Gitops) MergeBranch(ctx context.Context, path, source, target, commitMsg string) (_ string, err error) {
+	const op = "database gitops: merge branch"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	repo, err := g.repo(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	target = strings.TrimPrefix(target, _branchPrefix)
+	for i := 0; ; i++ {
+		sha, err := g.mergeBranch(ctx, repo, strings.TrimPrefix(source, _branchPrefix), target, commitMsg)
+		if err == nil {
+			return sha, nil
+		}
+		if perror.Cause(err) != herrors.ErrGitopsRefChanged || i+1 >= _writeRetries {
+			return "", err
+		}
+	}
+}
+
+func (g *Gitops) mergeBranch(ctx context.Context, repo *models.Repo,
+	source, target, commitMsg string) (string, error) {
+	srcRef, err := g.mgr.GetRef(ctx, repo.ID, source)
+	if err != nil {
+		return "", err
+	}
+	dstRef, err := g.mgr.GetRef(ctx, repo.ID, target)
+	if err != nil {
+		return "", err
+	}
+	src, err := g.mgr.GetCommit(ctx, repo.ID, srcRef.CommitSHA)
+	if err != nil {
+		return "", err
+	}
+	dst, err := g.mgr.GetCommit(ctx, repo.ID, dstRef.CommitSHA)
+	if err != nil {
+		return "", err
+	}
+	base, err := g.mergeBase(ctx, repo, src, dst)
+	if err != nil {
+		return "", err
+	}
+	// nothing to merge
+	if base != nil && base.SHA == src.SHA {
+		return dst.SHA, nil
+	}
+
+	baseFiles := map[string]string{}
+	if base != nil {
+		if baseFiles, err = base.Files(); err != nil {
+			return "", perror.Wrap(herrors.ErrParamInvalid, err.Error())
+		}
+	}
+	srcFiles, err := src.Files()
+	if err != nil {
+		return "", perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	dstFiles, err := dst.Files()
+	if err != nil {
+		return "", perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	paths := map[string]struct{}{}
+	for _, files := range []map[string]string{baseFiles, srcFiles, dstFiles} {
+		for filePath := range files {
+			paths[filePath] = struct{}{}
+		}
+	}
+	merged := make(map[string]string, len(paths))
+	for filePath := range paths {
+		baseSHA, srcSHA, dstSHA := baseFiles[filePath], srcFiles[filePath], dstFiles[filePath]
+		sha := srcSHA
+		switch {
+		case srcSHA == dstSHA, dstSHA == baseSHA:
+		case srcSHA == baseSHA:
+			sha = dstSHA
+		default:
+			return "", perror.Wrapf(herrors.ErrGitMergeConflict,
+				"failed to merge %s into %s for project %s: conflict in %s", source, target, repo.Path, filePath)
+		}
+		if sha != "" {
+			merged[filePath] = sha
+		}
+	}
+
+	commit, err := newCommit(ctx, merged, []*models.Commit{dst, src}, commitMsg)
+	if err != nil {
+		return "", err
+	}
+	commit.RepoID = repo.ID
+	if err := g.mgr.CreateCommit(ctx, commit, nil, target, dst.SHA); err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
+
+// GetRepoURL returns the URL of the mirror, which is empty if the mirror is not configured.
+func (g *Gitops) GetRepoURL(ctx context.Context, path string) string {
+	if g.mirror == nil {
+		return ""
+	}
+	return g.mirror.target.GetRepoURL(ctx, path)
+}
+
+func (g *Gitops) repo(ctx context.Context, path string) (*models.Repo, error) {
+	path, err := cleanPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return g.mgr.GetRepoByPath(ctx, path)
+}
+
+func (g *Gitops) moveRepo(ctx context.Context, repo *models.Repo, target string) error {
+	target, err := cleanPath(target)
+	if err != nil {
+		return err
+	}
+	if _, err := g.mgr.GetRepoByPath(ctx, target); err == nil {
+		return perror.Wrapf(herrors.ErrPathConflict, "project %s already exists", target)
+	} else if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+		return err
+	}
+	return g.mgr.UpdateRepoPath(ctx, repo.ID, target)
+}
+
+// resolve resolves ref, which is the name of a branch or a full commit ID, to a commit
+func (g *Gitops) resolve(ctx context.Context, repo *models.Repo, ref string) (*models.Commit, error) {
+	branch, err := g.mgr.GetRef(ctx, repo.ID, strings.TrimPrefix(ref, _branchPrefix))
+	if err == nil {
+		return g.mgr.GetCommit(ctx, repo.ID, branch.CommitSHA)
+	}
+	if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok || !shaPattern.MatchString(ref) {
+		return nil, err
+	}
+	return g.mgr.GetCommit(ctx, repo.ID, ref)
+}
+
+// mergeBase finds the best common ancestor of a and b by walking commits from the highest generation,
+// it returns nil if they have no common ancestor
+func (g *Gitops) mergeBase(ctx context.Context, repo *models.Repo, a, b *models.Commit) (*models.Commit, error) {
+	const (
+		fromA = 1 << iota
+		fromB
+	)
+	if a.SHA == b.SHA {
+		return a, nil
+	}
+	flags := map[string]int{a.SHA: fromA, b.SHA: fromB}
+	queue := &commitQueue{a, b}
+	heap.Init(queue)
+	for queue.Len() > 0 {
+		commit := heap.Pop(queue).(*models.Commit)
+		flag := flags[commit.SHA]
+		if flag == fromA|fromB {
+			return commit, nil
+		}
+		for _, sha := range commit.ParentSHAs() {
+			parentFlag, visited := flags[sha]
+			if parentFlag|flag == parentFlag {
+				continue
+			}
+			flags[sha] = parentFlag | flag
+			if visited {
+				// the parent is still in the queue, its flag is updated in place
+				continue
+			}
+			parent, err := g.mgr.GetCommit(ctx, repo.ID, sha)
+			if err != nil {
+				return nil, err
+			}
+			heap.Push(queue, parent)
+		}
+	}
+	return nil, nil
+}
+
+// contents gets the contents of blobs, empty sha is ignored
+func (g *Gitops) contents(ctx context.Context, shas ...string) (map[string]string, error) {
+	query := make([]string, 0, len(shas))
+	for _, sha := range shas {
+		if sha != "" {
+			query = append(query, sha)
+		}
+	}
+	blobs, err := g.mgr.GetBlobs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[string]string, len(blobs))
+	for _, blob := range blobs {
+		contents[blob.SHA] = blob.Content
+	}
+	for _, sha := range query {
+		if _, ok := contents[sha]; !ok {
+			return nil, herrors.NewErrNotFound(herrors.GitopsBlobInDB, fmt.Sprintf("blob %s not found", sha))
+		}
+	}
+	return contents, nil
+}
+
+// newCommit creates a commit of files on parents, authored by the current user
+func newCommit(ctx context.Context, files map[string]string,
+	parents []*models.Commit, message string) (*models.Commit, error) {
+	tree, err := json.Marshal(files)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	name, email := _defaultAuthor, _defaultEmail
+	if user, err := common.UserFromContext(ctx); err == nil {
+		if n := sanitizeIdent(user.GetName()); n != "" {
+			name = n
+		}
+		if e := sanitizeIdent(user.GetEmail()); e != "" {
+			email = e
+		}
+	}
+	commit := &models.Commit{
+		Tree:        string(tree),
+		Message:     normalizeMessage(message),
+		AuthorName:  name,
+		AuthorEmail: email,
+		AuthorTime:  time.Now().Unix(),
+	}
+	parentSHAs := make([]string, 0, len(parents))
+	for _, parent := range parents {
+		parentSHAs = append(parentSHAs, parent.SHA)
+		if parent.Generation >= commit.Generation {
+			commit.Generation = parent.Generation + 1
+		}
+	}
+	commit.Parents = strings.Join(parentSHAs, " ")
+	if commit.SHA, err = writeCommit(commit, hashObject); err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	return commit, nil
+}
+
+// commitQueue pops the commit with the highest generation first
+type commitQueue []*models.Commit
+
+func (q commitQueue) Len() int           { return len(q) }
+func (q commitQueue) Less(i, j int) bool { return q[i].Generation > q[j].Generation }
+func (q commitQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x interface{}) {
+	*q = append(*q, x.(*models.Commit))
+}
+
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// cleanPath trims slashes of path and rejects relative segments
+func cleanPath(p string) (string, error) {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return "", perror.Wrap(herrors.ErrParamInvalid, "path cannot be empty")
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", perror.Wrapf(herrors.ErrParamInvalid, "path %s is invalid", p)
+		}
+	}
+	return p, nil
+}
+
+// splitLines splits s into lines with their line breaks, difflib.SplitLines appends an extra empty line
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func pathBase(p string) string {
+	return path.Base(p)
+}
+
+func pathDir(p string) string {
+	return path.Dir(p)
+}
+
+func toGroup(fullPath string) *gitops.Group {
+	return &gitops.Group{
+		Name:     path.Base(fullPath),
+		Path:     path.Base(fullPath),
+		FullPath: fullPath,
+	}
+}
+
+func toProject(repo *models.Repo) *gitops.Project {
+	return &gitops.Project{
+		ID:            int(repo.ID),
+		Name:          path.Base(repo.Path),
+		Path:          path.Base(repo.Path),
+		FullPath:      repo.Path,
+		DefaultBranch: repo.DefaultBranch,
+	}
+}
+
+func toBranch(name string, commit *models.Commit) *gitops.Branch {
+	return &gitops.Branch{
+		Name: name,
+		Commit: &gitops.Commit{
+			ID:      commit.SHA,
+			Message: strings.TrimSpace(commit.Message),
+		},
+	}
+}
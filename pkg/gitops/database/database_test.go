@@ -0,0 +1,289 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	_ "github.com/horizoncd/horizon/pkg/gitops/filesystem"
+	gitopsstoremanager "github.com/horizoncd/horizon/pkg/gitopsstore/manager"
+	"github.com/horizoncd/horizon/pkg/gitopsstore/models"
+)
+
+func isNotFound(err error) bool {
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	return ok
+}
+
+func createGitops(t *testing.T, mirror *gitlabconfig.GitopsMirrorConfig) (*Gitops,
+	gitopsstoremanager.Manager) {
+	db, err := orm.NewSqliteDB("")
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&models.Repo{}, &models.Ref{}, &models.Commit{}, &models.Blob{}))
+	mgr := gitopsstoremanager.New(db)
+
+	var m *Mirror
+	if mirror != nil {
+		m, err = NewMirror(context.Background(), mgr, mirror)
+		assert.Nil(t, err)
+	}
+	return New(mgr, "master", m), mgr
+}
+
+func Test(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name:  "Tony",
+		Email: "tony@horizon.com",
+	})
+	g, _ := createGitops(t, nil)
+
+	root, err := gitops.GetRootGroup(ctx, g, "root", "private")
+	assert.Nil(t, err)
+	app, err := g.GetCreatedGroup(ctx, root, "app", "private")
+	assert.Nil(t, err)
+	assert.Equal(t, "root/app", app.FullPath)
+
+	project, err := g.CreateProject(ctx, "cluster", app, "private")
+	assert.Nil(t, err)
+	assert.Equal(t, "master", project.DefaultBranch)
+	pid := project.FullPath
+	_, err = g.CreateProject(ctx, "cluster", app, "private")
+	assert.NotNil(t, err)
+	readme, err := g.GetFile(ctx, pid, "master", "README.md")
+	assert.Nil(t, err)
+	assert.Equal(t, "# cluster\n", string(readme))
+
+	_, err = g.CreateBranch(ctx, pid, "gitops", "master")
+	assert.Nil(t, err)
+	commit, err := g.WriteFiles(ctx, pid, "gitops", "add files", nil, []gitops.CommitAction{
+		{Action: gitops.FileCreate, FilePath: "application.yaml", Content: "replicas: 1\n"},
+		{Action: gitops.FileCreate, FilePath: "env/env.yaml", Content: "env: test\n"},
+	})
+	assert.Nil(t, err)
+	branch, err := g.GetBranch(ctx, pid, "gitops")
+	assert.Nil(t, err)
+	assert.Equal(t, commit.ID, branch.Commit.ID)
+	assert.Equal(t, "add files", branch.Commit.Message)
+
+	// invalid actions
+	_, err = g.WriteFiles(ctx, pid, "gitops", "create again", nil, []gitops.CommitAction{
+		{Action: gitops.FileCreate, FilePath: "application.yaml", Content: "replicas: 2\n"},
+	})
+	assert.NotNil(t, err)
+	_, err = g.WriteFiles(ctx, pid, "gitops", "delete missing", nil, []gitops.CommitAction{
+		{Action: gitops.FileDelete, FilePath: "missing.yaml"},
+	})
+	assert.NotNil(t, err)
+
+	// write to a new branch from start branch
+	startBranch := "master"
+	_, err = g.WriteFiles(ctx, pid, "restart", "restart", &startBranch, []gitops.CommitAction{
+		{Action: gitops.FileCreate, FilePath: "restart.yaml", Content: "restartTime: now\n"},
+	})
+	assert.Nil(t, err)
+	restart, err := g.GetFile(ctx, pid, "restart", "restart.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "restartTime: now\n", string(restart))
+
+	diffs, err := g.Compare(ctx, pid, "master", "gitops", false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(diffs))
+	assert.Equal(t, "application.yaml", diffs[0].NewPath)
+	assert.True(t, diffs[0].NewFile)
+	assert.Equal(t, "@@ -0,0 +1 @@\n+replicas: 1\n", diffs[0].Diff)
+
+	// the commit can be used as ref
+	content, err := g.GetFile(ctx, pid, commit.ID, "application.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 1\n", string(content))
+
+	// master is changed after gitops branched from it, the change is kept after merging
+	_, err = g.MergeBranch(ctx, pid, "restart", "master", "merge restart")
+	assert.Nil(t, err)
+	merged, err := g.MergeBranch(ctx, pid, "gitops", "master", "merge gitops")
+	assert.Nil(t, err)
+	for file, expected := range map[string]string{
+		"application.yaml": "replicas: 1\n",
+		"env/env.yaml":     "env: test\n",
+		"restart.yaml":     "restartTime: now\n",
+	} {
+		content, err := g.GetFile(ctx, pid, merged, file)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+	diffs, err = g.Compare(ctx, pid, "master", "gitops", false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diffs))
+	// merging again does nothing
+	sha, err := g.MergeBranch(ctx, pid, "gitops", "master", "merge gitops")
+	assert.Nil(t, err)
+	assert.Equal(t, merged, sha)
+
+	// conflict
+	_, err = g.WriteFiles(ctx, pid, "gitops", "update replicas", nil, []gitops.CommitAction{
+		{Action: gitops.FileUpdate, FilePath: "application.yaml", Content: "replicas: 2\n"},
+	})
+	assert.Nil(t, err)
+	_, err = g.WriteFiles(ctx, pid, "master", "update replicas", nil, []gitops.CommitAction{
+		{Action: gitops.FileUpdate, FilePath: "application.yaml", Content: "replicas: 3\n"},
+	})
+	assert.Nil(t, err)
+	_, err = g.MergeBranch(ctx, pid, "gitops", "master", "merge gitops")
+	assert.Equal(t, herrors.ErrGitMergeConflict, perror.Cause(err))
+
+	// rollback to a former commit by writing its files
+	_, err = g.WriteFiles(ctx, pid, "master", "rollback", nil, []gitops.CommitAction{
+		{Action: gitops.FileUpdate, FilePath: "application.yaml", Content: "replicas: 1\n"},
+	})
+	assert.Nil(t, err)
+	diffs, err = g.Compare(ctx, pid, merged, "master", true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diffs))
+
+	// move and delete
+	newPath := "cluster2"
+	assert.Nil(t, g.EditNameAndPathForProject(ctx, pid, &newPath, &newPath))
+	_, err = g.GetProject(ctx, pid)
+	assert.True(t, isNotFound(err))
+	assert.Nil(t, g.TransferProject(ctx, "root/app/cluster2", "root/other"))
+	project, err = g.GetProject(ctx, "root/other/cluster2")
+	assert.Nil(t, err)
+	assert.Nil(t, g.DeleteGroup(ctx, "root/other"))
+	_, err = g.GetProject(ctx, project.FullPath)
+	assert.True(t, isNotFound(err))
+}
+
+func TestObjectSHA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitops")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	git := func(stdin string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"--git-dir", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Tony", "GIT_AUTHOR_EMAIL=tony@horizon.com",
+			"GIT_AUTHOR_DATE=1700000000 +0000", "GIT_COMMITTER_NAME=Tony",
+			"GIT_COMMITTER_EMAIL=tony@horizon.com", "GIT_COMMITTER_DATE=1700000000 +0000")
+		cmd.Stdin = strings.NewReader(stdin)
+		out, err := cmd.Output()
+		assert.Nil(t, err)
+		return strings.TrimSpace(string(out))
+	}
+	git("", "init", "--bare", "--quiet")
+
+	files := map[string]string{}
+	index := ""
+	for path, content := range map[string]string{
+		"a.yaml":      "a: 1\n",
+		"a/b.yaml":    "b: 1\n",
+		"a-b/c.yaml":  "c: 1\n",
+		"a/d/e.yaml":  "e: 1\n",
+		"README.md":   "# readme\n",
+		"a/b/c/d.txt": "",
+	} {
+		sha := git(content, "hash-object", "-w", "--stdin")
+		assert.Equal(t, sha, blobSHA(content))
+		files[path] = sha
+		index += "100644 " + sha + "\t" + path + "\n"
+	}
+	env := "GIT_INDEX_FILE=" + dir + "/index"
+	cmd := exec.Command("git", "--git-dir", dir, "update-index", "--index-info")
+	cmd.Env = append(os.Environ(), env)
+	cmd.Stdin = strings.NewReader(index)
+	assert.Nil(t, cmd.Run())
+	cmd = exec.Command("git", "--git-dir", dir, "write-tree")
+	cmd.Env = append(os.Environ(), env)
+	out, err := cmd.Output()
+	assert.Nil(t, err)
+	tree, err := writeTree(files, hashObject)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), tree)
+
+	commit := &models.Commit{
+		Tree:        "",
+		Message:     normalizeMessage("init"),
+		AuthorName:  "Tony",
+		AuthorEmail: "tony@horizon.com",
+		AuthorTime:  1700000000,
+	}
+	sha, err := writeCommit(commit, hashObject)
+	assert.Nil(t, err)
+	assert.Equal(t, git("", "commit-tree", "4b825dc642cb6eb9a060e54bf8d69288fbee4904", "-m", "init"), sha)
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "gitops")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	g, mgr := createGitops(t, &gitlabconfig.GitopsMirrorConfig{
+		GitopsRepoConfig: gitlabconfig.GitopsRepoConfig{
+			Kind:          "filesystem",
+			RootDir:       dir + "/mirror",
+			DefaultBranch: "master",
+		},
+		CacheDir: dir + "/cache",
+	})
+	app, err := g.GetGroup(ctx, "root/app")
+	assert.Nil(t, err)
+	project, err := g.CreateProject(ctx, "cluster", app, "private")
+	assert.Nil(t, err)
+	commit, err := g.WriteFiles(ctx, project.FullPath, "master", "add files", nil, []gitops.CommitAction{
+		{Action: gitops.FileCreate, FilePath: "application.yaml", Content: "replicas: 1\n"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "file://"+dir+"/mirror/root/app/cluster.git", g.GetRepoURL(ctx, project.FullPath))
+
+	mirror := func() {
+		refs, err := mgr.ListUnmirroredRefs(ctx, 0, 10)
+		assert.Nil(t, err)
+		for _, ref := range refs {
+			assert.Nil(t, g.mirror.Sync(ctx, ref))
+		}
+	}
+	mirror()
+	branch, err := g.mirror.target.GetBranch(ctx, project.FullPath, "master")
+	assert.Nil(t, err)
+	assert.Equal(t, commit.ID, branch.Commit.ID)
+	content, err := g.mirror.target.GetFile(ctx, project.FullPath, "master", "application.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 1\n", string(content))
+	refs, err := mgr.ListUnmirroredRefs(ctx, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(refs))
+
+	// only the new commits are pushed
+	commit, err = g.WriteFiles(ctx, project.FullPath, "master", "update", nil, []gitops.CommitAction{
+		{Action: gitops.FileUpdate, FilePath: "application.yaml", Content: "replicas: 2\n"},
+	})
+	assert.Nil(t, err)
+	mirror()
+	branch, err = g.mirror.target.GetBranch(ctx, project.FullPath, "master")
+	assert.Nil(t, err)
+	assert.Equal(t, commit.ID, branch.Commit.ID)
+}
@@ -0,0 +1,271 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	gitopsstoremanager "github.com/horizoncd/horizon/pkg/gitopsstore/manager"
+	"github.com/horizoncd/horizon/pkg/gitopsstore/models"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+const _cacheRepo = "objects.git"
+
+// Mirror pushes the branches of database gitops repos to git repos with the same paths.
+// The git objects are rebuilt from database in a local bare repo and then force pushed,
+// so the mirrored commits have the same IDs as the ones in database.
+type Mirror struct {
+	mgr      gitopsstoremanager.Manager
+	target   gitops.Interface
+	config   *gitlabconfig.GitopsMirrorConfig
+	cacheDir string
+}
+
+func NewMirror(ctx context.Context, mgr gitopsstoremanager.Manager,
+	config *gitlabconfig.GitopsMirrorConfig) (*Mirror, error) {
+	target, err := gitops.New(ctx, &config.GitopsRepoConfig)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir, err := filepath.Abs(filepath.Join(config.CacheDir, _cacheRepo))
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	m := &Mirror{
+		mgr:      mgr,
+		target:   target,
+		config:   config,
+		cacheDir: cacheDir,
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "objects")); err != nil {
+		if _, err := m.git(ctx, "", "init", "--bare", "--quiet", cacheDir); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Sync pushes the commit of ref to the mirror, and records it as the mirrored commit of ref.
+func (m *Mirror) Sync(ctx context.Context, ref *models.Ref) (err error) {
+	const op = "database gitops mirror: sync"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	repo, err := m.mgr.GetRepoByID(ctx, ref.RepoID)
+	if err != nil {
+		return err
+	}
+	if err := m.ensureProject(ctx, repo.Path); err != nil {
+		return err
+	}
+	if err := m.writeObjects(ctx, repo, ref.CommitSHA); err != nil {
+		return err
+	}
+	pushURL, err := m.pushURL(ctx, repo.Path)
+	if err != nil {
+		return err
+	}
+	if _, err := m.git(ctx, m.cacheDir, "push", "--force", "--quiet",
+		pushURL, fmt.Sprintf("%s:refs/heads/%s", ref.CommitSHA, ref.Name)); err != nil {
+		return err
+	}
+	return m.mgr.UpdateRefMirroredSHA(ctx, ref.ID, ref.CommitSHA)
+}
+
+// ensureProject creates the project and its groups in the mirror if not exist
+func (m *Mirror) ensureProject(ctx context.Context, path string) error {
+	_, err := m.target.GetProject(ctx, path)
+	if err == nil {
+		return nil
+	}
+	if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+		return err
+	}
+	group, err := m.ensureGroup(ctx, pathDir(path))
+	if err != nil {
+		return err
+	}
+	_, err = m.target.CreateProject(ctx, pathBase(path), group, m.config.DefaultVisibility)
+	return err
+}
+
+func (m *Mirror) ensureGroup(ctx context.Context, path string) (*gitops.Group, error) {
+	segments := strings.Split(path, "/")
+	group, err := gitops.GetRootGroup(ctx, m.target, segments[0], m.config.DefaultVisibility)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range segments[1:] {
+		if group, err = m.target.GetCreatedGroup(ctx, group, segment, m.config.DefaultVisibility); err != nil {
+			return nil, err
+		}
+	}
+	return group, nil
+}
+
+func (m *Mirror) transferProject(ctx context.Context, path, groupPath string) error {
+	if _, err := m.ensureGroup(ctx, groupPath); err != nil {
+		return err
+	}
+	return m.target.TransferProject(ctx, path, groupPath)
+}
+
+// writeObjects writes the commit and its ancestors into the cache repo,
+// a commit is written after its parents, so an existing commit means its ancestors exist too
+func (m *Mirror) writeObjects(ctx context.Context, repo *models.Repo, sha string) error {
+	if m.hasObject(sha) {
+		return nil
+	}
+	commit, err := m.mgr.GetCommit(ctx, repo.ID, sha)
+	if err != nil {
+		return err
+	}
+	for _, parent := range commit.ParentSHAs() {
+		if err := m.writeObjects(ctx, repo, parent); err != nil {
+			return err
+		}
+	}
+
+	files, err := commit.Files()
+	if err != nil {
+		return perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	shas := make([]string, 0, len(files))
+	for _, blob := range files {
+		if !m.hasObject(blob) {
+			shas = append(shas, blob)
+		}
+	}
+	blobs, err := m.mgr.GetBlobs(ctx, shas)
+	if err != nil {
+		return err
+	}
+	for _, blob := range blobs {
+		if _, err := m.writeObject(_objectBlob, []byte(blob.Content)); err != nil {
+			return err
+		}
+	}
+	written, err := writeCommit(commit, m.writeObject)
+	if err != nil {
+		return err
+	}
+	if written != sha {
+		return perror.Wrapf(herrors.ErrGitCommandFailed,
+			"commit %s of project %s is rebuilt as %s", sha, repo.Path, written)
+	}
+	return nil
+}
+
+// writeObject writes a loose object into the cache repo
+func (m *Mirror) writeObject(kind string, data []byte) (string, error) {
+	sha, _ := hashObject(kind, data)
+	if m.hasObject(sha) {
+		return sha, nil
+	}
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, _ = fmt.Fprintf(w, "%s %d\x00", kind, len(data))
+	_, _ = w.Write(data)
+	if err := w.Close(); err != nil {
+		return "", perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+
+	dir := filepath.Join(m.cacheDir, "objects", sha[:2])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	// write to a temporary file first, so a partial object is never visible
+	tmp, err := ioutil.TempFile(dir, "tmp_obj_")
+	if err != nil {
+		return "", perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return "", perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return "", perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), m.objectPath(sha)); err != nil {
+		return "", perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	return sha, nil
+}
+
+func (m *Mirror) hasObject(sha string) bool {
+	_, err := os.Stat(m.objectPath(sha))
+	return err == nil
+}
+
+func (m *Mirror) objectPath(sha string) string {
+	return filepath.Join(m.cacheDir, "objects", sha[:2], sha[2:])
+}
+
+// pushURL returns the URL of the mirror project with credentials
+func (m *Mirror) pushURL(ctx context.Context, path string) (string, error) {
+	repoURL := m.target.GetRepoURL(ctx, path)
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	if (u.Scheme == "http" || u.Scheme == "https") && m.config.Token != "" {
+		u.User = url.UserPassword(m.config.Username, m.config.Token)
+	}
+	return u.String(), nil
+}
+
+func (m *Mirror) git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	command := args[0]
+	if dir != "" {
+		args = append([]string{"--git-dir", dir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// the arguments are not printed as they may contain the token
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return nil, perror.Wrapf(herrors.ErrGitCommandFailed, "git %s: %v: %s",
+				command, err, strings.TrimSpace(m.redact(stderr.String())))
+		}
+		return nil, perror.Wrapf(herrors.ErrGitCommandFailed, "git %s: %v", command, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (m *Mirror) redact(s string) string {
+	if m.config.Token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, m.config.Token, "******")
+}
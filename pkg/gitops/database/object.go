@@ -0,0 +1,163 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"crypto/sha1" // nolint: gosec
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/horizoncd/horizon/pkg/gitopsstore/models"
+)
+
+const (
+	_objectBlob   = "blob"
+	_objectTree   = "tree"
+	_objectCommit = "commit"
+
+	_modeFile = "100644"
+	_modeDir  = "40000"
+)
+
+// objectWriter stores a git object and returns its sha
+type objectWriter func(kind string, data []byte) (string, error)
+
+// hashObject returns the git sha of the object without storing it
+func hashObject(kind string, data []byte) (string, error) {
+	h := sha1.New() // nolint: gosec
+	_, _ = fmt.Fprintf(h, "%s %d\x00", kind, len(data))
+	_, _ = h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func blobSHA(content string) string {
+	sha, _ := hashObject(_objectBlob, []byte(content))
+	return sha
+}
+
+type treeNode struct {
+	files map[string]string
+	dirs  map[string]*treeNode
+}
+
+type treeEntry struct {
+	mode string
+	name string
+	sha  string
+}
+
+// writeTree writes the nested git trees of files, which maps file path to blob sha,
+// and returns the sha of the root tree
+func writeTree(files map[string]string, write objectWriter) (string, error) {
+	root := &treeNode{files: map[string]string{}, dirs: map[string]*treeNode{}}
+	for path, sha := range files {
+		node := root
+		segments := strings.Split(path, "/")
+		for _, dir := range segments[:len(segments)-1] {
+			child, ok := node.dirs[dir]
+			if !ok {
+				child = &treeNode{files: map[string]string{}, dirs: map[string]*treeNode{}}
+				node.dirs[dir] = child
+			}
+			node = child
+		}
+		node.files[segments[len(segments)-1]] = sha
+	}
+	return root.write(write)
+}
+
+func (t *treeNode) write(write objectWriter) (string, error) {
+	entries := make([]treeEntry, 0, len(t.files)+len(t.dirs))
+	for name, sha := range t.files {
+		entries = append(entries, treeEntry{mode: _modeFile, name: name, sha: sha})
+	}
+	for name, dir := range t.dirs {
+		sha, err := dir.write(write)
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, treeEntry{mode: _modeDir, name: name, sha: sha})
+	}
+	// git sorts the entries as if the names of directories end with a slash
+	sortKey := func(e treeEntry) string {
+		if e.mode == _modeDir {
+			return e.name + "/"
+		}
+		return e.name
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return sortKey(entries[i]) < sortKey(entries[j])
+	})
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		raw, err := hex.DecodeString(e.sha)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(e.mode + " " + e.name + "\x00")
+		buf.Write(raw)
+	}
+	return write(_objectTree, buf.Bytes())
+}
+
+// encodeCommit encodes the commit in the same way as git commit-tree
+func encodeCommit(commit *models.Commit, tree string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("tree " + tree + "\n")
+	for _, parent := range commit.ParentSHAs() {
+		buf.WriteString("parent " + parent + "\n")
+	}
+	ident := fmt.Sprintf("%s <%s> %d +0000", commit.AuthorName, commit.AuthorEmail, commit.AuthorTime)
+	buf.WriteString("author " + ident + "\n")
+	buf.WriteString("committer " + ident + "\n")
+	buf.WriteString("\n")
+	buf.WriteString(commit.Message)
+	return buf.Bytes()
+}
+
+// writeCommit writes the trees and the commit, and returns the sha of the commit
+func writeCommit(commit *models.Commit, write objectWriter) (string, error) {
+	files, err := commit.Files()
+	if err != nil {
+		return "", err
+	}
+	tree, err := writeTree(files, write)
+	if err != nil {
+		return "", err
+	}
+	return write(_objectCommit, encodeCommit(commit, tree))
+}
+
+// normalizeMessage ends the message with a newline just like git commit-tree -m
+func normalizeMessage(message string) string {
+	if message != "" && !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+	return message
+}
+
+// sanitizeIdent removes the characters that are not allowed in the name and email of git identity
+func sanitizeIdent(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '<' || r == '>' || r == '\n' {
+			return -1
+		}
+		return r
+	}, s))
+}
@@ -0,0 +1,712 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+const Kind = "filesystem"
+
+const (
+	_repoSuffix    = ".git"
+	_fileMode      = "100644"
+	_readmeFile    = "README.md"
+	_defaultBranch = "master"
+	_defaultAuthor = "horizon"
+	_defaultEmail  = "horizon@localhost"
+)
+
+func init() {
+	gitops.Register(Kind, New)
+}
+
+// Gitops stores groups as directories and projects as bare repos under rootDir.
+// All writes are done with git plumbing commands, so no working tree is needed.
+type Gitops struct {
+	rootDir       string
+	url           string
+	defaultBranch string
+	locks         sync.Map
+}
+
+var _ gitops.Interface = (*Gitops)(nil)
+
+func New(ctx context.Context, config *gitlabconfig.GitopsRepoConfig) (gitops.Interface, error) {
+	if config.RootDir == "" {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "rootDir of filesystem gitops repo cannot be empty")
+	}
+	rootDir, err := filepath.Abs(config.RootDir)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+	}
+	if err := os.MkdirAll(rootDir, 0755); err != nil {
+		return nil, perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	defaultBranch := config.DefaultBranch
+	if defaultBranch == "" {
+		defaultBranch = _defaultBranch
+	}
+	return &Gitops{
+		rootDir:       rootDir,
+		url:           strings.TrimSuffix(config.URL, "/"),
+		defaultBranch: defaultBranch,
+	}, nil
+}
+
+func (g *Gitops) GetGroup(ctx context.Context, path string) (*gitops.Group, error) {
+	path, err := cleanPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isDir(g.groupDir(path)) {
+		return nil, herrors.NewErrNotFound(herrors.GitRepoInFilesystem,
+			fmt.Sprintf("group %s not found", path))
+	}
+	return toGroup(path), nil
+}
+
+func (g *Gitops) CreateGroup(ctx context.Context, name, path string,
+	parent *gitops.Group, visibility string) (*gitops.Group, error) {
+	fullPath := path
+	if parent != nil {
+		if !isDir(g.groupDir(parent.FullPath)) {
+			return nil, herrors.NewErrNotFound(herrors.GitRepoInFilesystem,
+				fmt.Sprintf("group %s not found", parent.FullPath))
+		}
+		fullPath = parent.FullPath + "/" + path
+	}
+	fullPath, err := cleanPath(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	dir := g.groupDir(fullPath)
+	if exists(dir) || exists(dir+_repoSuffix) {
+		return nil, perror.Wrapf(herrors.ErrPathConflict, "group %s already exists", fullPath)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	return toGroup(fullPath), nil
+}
+
+func (g *Gitops) GetCreatedGroup(ctx context.Context, parent *gitops.Group,
+	name, visibility string) (*gitops.Group, error) {
+	group, err := g.GetGroup(ctx, fmt.Sprintf("%v/%v", parent.FullPath, name))
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		return g.CreateGroup(ctx, name, name, parent, visibility)
+	}
+	return group, nil
+}
+
+func (g *Gitops) DeleteGroup(ctx context.Context, path string) error {
+	group, err := g.GetGroup(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(g.groupDir(group.FullPath)); err != nil {
+		return perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	return nil
+}
+
+func (g *Gitops) GetProject(ctx context.Context, path string) (*gitops.Project, error) {
+	path, dir, err := g.project(path)
+	if err != nil {
+		return nil, err
+	}
+	head, err := g.git(ctx, dir, nil, nil, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return &gitops.Project{
+		Name:          filepath.Base(path),
+		Path:          filepath.Base(path),
+		FullPath:      path,
+		DefaultBranch: strings.TrimSpace(string(head)),
+	}, nil
+}
+
+func (g *Gitops) CreateProject(ctx context.Context, name string,
+	group *gitops.Group, visibility string) (_ *gitops.Project, err error) {
+	const op = "filesystem gitops: create project"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	if !isDir(g.groupDir(group.FullPath)) {
+		return nil, herrors.NewErrNotFound(herrors.GitRepoInFilesystem,
+			fmt.Sprintf("group %s not found", group.FullPath))
+	}
+	path, err := cleanPath(fmt.Sprintf("%v/%v", group.FullPath, name))
+	if err != nil {
+		return nil, err
+	}
+	dir := g.projectDir(path)
+	if exists(dir) || exists(g.groupDir(path)) {
+		return nil, perror.Wrapf(herrors.ErrPathConflict, "project %s already exists", path)
+	}
+
+	if _, err := g.git(ctx, "", nil, nil, "init", "--bare", "--quiet", dir); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+	if _, err := g.git(ctx, dir, nil, nil, "symbolic-ref", "HEAD", "refs/heads/"+g.defaultBranch); err != nil {
+		return nil, err
+	}
+	// initialize default branch with a readme just like gitlab does
+	if _, err := g.commitFiles(ctx, dir, g.defaultBranch, "", "Initial commit",
+		[]gitops.CommitAction{{
+			Action:   gitops.FileCreate,
+			FilePath: _readmeFile,
+			Content:  fmt.Sprintf("# %s\n", name),
+		}}); err != nil {
+		return nil, err
+	}
+	return g.GetProject(ctx, path)
+}
+
+func (g *Gitops) DeleteProject(ctx context.Context, path string) error {
+	path, dir, err := g.project(path)
+	if err != nil {
+		return err
+	}
+	unlock := g.lock(path)
+	defer unlock()
+
+	if err := os.RemoveAll(dir); err != nil {
+		return perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	return nil
+}
+
+func (g *Gitops) TransferProject(ctx context.Context, path, groupPath string) error {
+	path, dir, err := g.project(path)
+	if err != nil {
+		return err
+	}
+	group, err := g.GetGroup(ctx, groupPath)
+	if err != nil {
+		return err
+	}
+	return g.moveProject(path, dir, g.projectDir(group.FullPath+"/"+filepath.Base(path)))
+}
+
+func (g *Gitops) EditNameAndPathForProject(ctx context.Context, path string, newName, newPath *string) error {
+	path, dir, err := g.project(path)
+	if err != nil {
+		return err
+	}
+	// the name of a project is always the same as its path
+	if newPath == nil || *newPath == filepath.Base(path) {
+		return nil
+	}
+	target, err := cleanPath(filepath.Dir(path) + "/" + *newPath)
+	if err != nil {
+		return err
+	}
+	return g.moveProject(path, dir, g.projectDir(target))
+}
+
+func (g *Gitops) GetBranch(ctx context.Context, path, branch string) (*gitops.Branch, error) {
+	_, dir, err := g.project(path)
+	if err != nil {
+		return nil, err
+	}
+	return g.getBranch(ctx, dir, branch)
+}
+
+func (g *Gitops) CreateBranch(ctx context.Context, path, branch, fromRef string) (*gitops.Branch, error) {
+	path, dir, err := g.project(path)
+	if err != nil {
+		return nil, err
+	}
+	unlock := g.lock(path)
+	defer unlock()
+
+	sha, err := g.resolve(ctx, dir, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.git(ctx, dir, nil, nil, "update-ref", "refs/heads/"+branch, sha, ""); err != nil {
+		return nil, err
+	}
+	return g.getBranch(ctx, dir, branch)
+}
+
+func (g *Gitops) GetFile(ctx context.Context, path, ref, filepath string) ([]byte, error) {
+	_, dir, err := g.project(path)
+	if err != nil {
+		return nil, err
+	}
+	sha, err := g.resolve(ctx, dir, ref)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := g.git(ctx, dir, nil, nil, "rev-parse", "--verify", "--quiet",
+		fmt.Sprintf("%s:%s", sha, strings.TrimPrefix(filepath, "/")))
+	if err != nil {
+		return nil, herrors.NewErrNotFound(herrors.GitRepoInFilesystem,
+			fmt.Sprintf("file %s not found in %s of project %s", filepath, ref, path))
+	}
+	return g.git(ctx, dir, nil, nil, "cat-file", "blob", strings.TrimSpace(string(blob)))
+}
+
+func (g *Gitops) WriteFiles(ctx context.Context, path, branch, commitMsg string,
+	startBranch *string, actions []gitops.CommitAction) (_ *gitops.Commit, err error) {
+	const op = "filesystem gitops: write files"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	path, dir, err := g.project(path)
+	if err != nil {
+		return nil, err
+	}
+	unlock := g.lock(path)
+	defer unlock()
+
+	parent, err := g.resolve(ctx, dir, "refs/heads/"+branch)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok || startBranch == nil {
+			return nil, err
+		}
+		parent, err = g.resolve(ctx, dir, "refs/heads/"+*startBranch)
+		if err != nil {
+			return nil, err
+		}
+		// create the branch from startBranch, then commit on it
+		if _, err := g.git(ctx, dir, nil, nil, "update-ref", "refs/heads/"+branch, parent, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	sha, err := g.commitFiles(ctx, dir, branch, parent, commitMsg, actions)
+	if err != nil {
+		return nil, err
+	}
+	return &gitops.Commit{ID: sha, Message: commitMsg}, nil
+}
+
+func (g *Gitops) Compare(ctx context.Context, path, from, to string, straight bool) (_ []*gitops.Diff, err error) {
+	const op = "filesystem gitops: compare"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	_, dir, err := g.project(path)
+	if err != nil {
+		return nil, err
+	}
+	base, err := g.resolve(ctx, dir, from)
+	if err != nil {
+		return nil, err
+	}
+	head, err := g.resolve(ctx, dir, to)
+	if err != nil {
+		return nil, err
+	}
+	if !straight {
+		if mergeBase, err := g.git(ctx, dir, nil, nil, "merge-base", base, head); err == nil {
+			base = strings.TrimSpace(string(mergeBase))
+		}
+	}
+
+	out, err := g.git(ctx, dir, nil, nil, "diff", "--name-status", "-z", "-M", base, head)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	diffs := make([]*gitops.Diff, 0)
+	for i := 0; i+1 < len(fields); {
+		status := fields[i]
+		diff := &gitops.Diff{OldPath: fields[i+1], NewPath: fields[i+1]}
+		i += 2
+		switch status[0] {
+		case 'A', 'C':
+			diff.NewFile = true
+		case 'D':
+			diff.DeletedFile = true
+		case 'R':
+			if i >= len(fields) {
+				return nil, perror.Wrapf(herrors.ErrGitCommandFailed, "unexpected diff output: %q", out)
+			}
+			diff.NewPath = fields[i]
+			diff.RenamedFile = true
+			i++
+		}
+		if status[0] == 'C' {
+			// copied file is reported with its source path, use the copy only
+			if i >= len(fields) {
+				return nil, perror.Wrapf(herrors.ErrGitCommandFailed, "unexpected diff output: %q", out)
+			}
+			diff.OldPath, diff.NewPath = fields[i], fields[i]
+			i++
+		}
+
+		patch, err := g.git(ctx, dir, nil, nil, "diff", "-M", base, head, "--", diff.OldPath, diff.NewPath)
+		if err != nil {
+			return nil, err
+		}
+		diff.Diff = stripDiffHeader(string(patch))
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func (g *Gitops) MergeBranch(ctx context.Context, path, source, target, commitMsg string) (_ string, err error) {
+	const op = "filesystem gitops: merge branch"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	path, dir, err := g.project(path)
+	if err != nil {
+		return "", err
+	}
+	unlock := g.lock(path)
+	defer unlock()
+
+	src, err := g.resolve(ctx, dir, "refs/heads/"+source)
+	if err != nil {
+		return "", err
+	}
+	dst, err := g.resolve(ctx, dir, "refs/heads/"+target)
+	if err != nil {
+		return "", err
+	}
+	// nothing to merge
+	if _, err := g.git(ctx, dir, nil, nil, "merge-base", "--is-ancestor", src, dst); err == nil {
+		return dst, nil
+	}
+
+	tree, err := g.git(ctx, dir, nil, nil, "merge-tree", "--write-tree", "--no-messages", dst, src)
+	if err != nil {
+		return "", perror.Wrapf(herrors.ErrGitMergeConflict,
+			"failed to merge %s into %s for project %s: %v", source, target, path, err)
+	}
+	treeID := strings.SplitN(strings.TrimSpace(string(tree)), "\n", 2)[0]
+	sha, err := g.git(ctx, dir, authorEnv(ctx), nil,
+		"commit-tree", treeID, "-p", dst, "-p", src, "-m", commitMsg)
+	if err != nil {
+		return "", err
+	}
+	commit := strings.TrimSpace(string(sha))
+	if _, err := g.git(ctx, dir, nil, nil, "update-ref", "refs/heads/"+target, commit, dst); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+func (g *Gitops) GetRepoURL(ctx context.Context, path string) string {
+	if g.url != "" {
+		return fmt.Sprintf("%v/%v%v", g.url, path, _repoSuffix)
+	}
+	return "file://" + g.projectDir(path)
+}
+
+// commitFiles applies actions onto the tree of parent with a temporary index,
+// and moves branch to the new commit if it still points to parent.
+func (g *Gitops) commitFiles(ctx context.Context, dir, branch, parent, commitMsg string,
+	actions []gitops.CommitAction) (string, error) {
+	index, err := ioutil.TempFile("", "horizon-gitops-index-")
+	if err != nil {
+		return "", perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	indexFile := index.Name()
+	_ = index.Close()
+	// git refuses to read an empty index file
+	_ = os.Remove(indexFile)
+	defer os.Remove(indexFile)
+	env := []string{"GIT_INDEX_FILE=" + indexFile}
+
+	if parent == "" {
+		_, err = g.git(ctx, dir, env, nil, "read-tree", "--empty")
+	} else {
+		_, err = g.git(ctx, dir, env, nil, "read-tree", parent)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, action := range actions {
+		mode, blob, err := g.indexEntry(ctx, dir, env, action.FilePath)
+		if err != nil {
+			return "", err
+		}
+		switch action.Action {
+		case gitops.FileCreate, gitops.FileUpdate:
+			if action.Action == gitops.FileCreate && blob != "" {
+				return "", perror.Wrapf(herrors.ErrParamInvalid, "file %s already exists", action.FilePath)
+			}
+			if action.Action == gitops.FileUpdate && blob == "" {
+				return "", perror.Wrapf(herrors.ErrParamInvalid, "file %s does not exist", action.FilePath)
+			}
+			if mode == "" {
+				mode = _fileMode
+			}
+			if err := g.addFile(ctx, dir, env, mode, action.FilePath, action.Content); err != nil {
+				return "", err
+			}
+		case gitops.FileDelete:
+			if blob == "" {
+				return "", perror.Wrapf(herrors.ErrParamInvalid, "file %s does not exist", action.FilePath)
+			}
+			if err := g.removeFile(ctx, dir, env, action.FilePath); err != nil {
+				return "", err
+			}
+		case gitops.FileMove:
+			prevMode, prevBlob, err := g.indexEntry(ctx, dir, env, action.PreviousPath)
+			if err != nil {
+				return "", err
+			}
+			if prevBlob == "" {
+				return "", perror.Wrapf(herrors.ErrParamInvalid, "file %s does not exist", action.PreviousPath)
+			}
+			if blob != "" {
+				return "", perror.Wrapf(herrors.ErrParamInvalid, "file %s already exists", action.FilePath)
+			}
+			if err := g.removeFile(ctx, dir, env, action.PreviousPath); err != nil {
+				return "", err
+			}
+			if action.Content == "" {
+				_, err = g.git(ctx, dir, env, nil, "update-index", "--add", "--cacheinfo",
+					fmt.Sprintf("%s,%s,%s", prevMode, prevBlob, action.FilePath))
+			} else {
+				err = g.addFile(ctx, dir, env, prevMode, action.FilePath, action.Content)
+			}
+			if err != nil {
+				return "", err
+			}
+		default:
+			return "", perror.Wrapf(herrors.ErrParamInvalid, "unsupported file action %s", action.Action)
+		}
+	}
+
+	tree, err := g.git(ctx, dir, env, nil, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", strings.TrimSpace(string(tree)), "-m", commitMsg}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	sha, err := g.git(ctx, dir, authorEnv(ctx), nil, args...)
+	if err != nil {
+		return "", err
+	}
+	commit := strings.TrimSpace(string(sha))
+	if _, err := g.git(ctx, dir, nil, nil, "update-ref", "refs/heads/"+branch, commit, parent); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// indexEntry returns the mode and blob of file in the index, both are empty if file does not exist
+func (g *Gitops) indexEntry(ctx context.Context, dir string, env []string, file string) (string, string, error) {
+	out, err := g.git(ctx, dir, env, nil, "ls-files", "--stage", "--", file)
+	if err != nil {
+		return "", "", err
+	}
+	// <mode> <object> <stage>\t<file>
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return "", "", nil
+	}
+	return fields[0], fields[1], nil
+}
+
+func (g *Gitops) addFile(ctx context.Context, dir string, env []string, mode, file, content string) error {
+	blob, err := g.git(ctx, dir, nil, []byte(content), "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+	_, err = g.git(ctx, dir, env, nil, "update-index", "--add", "--cacheinfo",
+		fmt.Sprintf("%s,%s,%s", mode, strings.TrimSpace(string(blob)), file))
+	return err
+}
+
+// removeFile removes file from the index, update-index --force-remove needs a work tree so use index-info instead
+func (g *Gitops) removeFile(ctx context.Context, dir string, env []string, file string) error {
+	_, err := g.git(ctx, dir, env, []byte(fmt.Sprintf("0 %s\t%s\n", strings.Repeat("0", 40), file)),
+		"update-index", "--index-info")
+	return err
+}
+
+func (g *Gitops) getBranch(ctx context.Context, dir, branch string) (*gitops.Branch, error) {
+	sha, err := g.resolve(ctx, dir, "refs/heads/"+branch)
+	if err != nil {
+		return nil, err
+	}
+	message, err := g.git(ctx, dir, nil, nil, "show", "-s", "--format=%B", sha)
+	if err != nil {
+		return nil, err
+	}
+	return &gitops.Branch{
+		Name: branch,
+		Commit: &gitops.Commit{
+			ID:      sha,
+			Message: strings.TrimSpace(string(message)),
+		},
+	}, nil
+}
+
+// resolve resolves ref to a commit ID
+func (g *Gitops) resolve(ctx context.Context, dir, ref string) (string, error) {
+	sha, err := g.git(ctx, dir, nil, nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", herrors.NewErrNotFound(herrors.GitRepoInFilesystem,
+			fmt.Sprintf("ref %s not found in %s", ref, dir))
+	}
+	return strings.TrimSpace(string(sha)), nil
+}
+
+func (g *Gitops) git(ctx context.Context, dir string, env []string,
+	stdin []byte, args ...string) ([]byte, error) {
+	if dir != "" {
+		args = append([]string{"--git-dir", dir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = append(cmd.Env, env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() == 0 {
+			return nil, perror.Wrapf(herrors.ErrGitCommandFailed, "git %s: %v", strings.Join(args, " "), err)
+		}
+		return nil, perror.Wrapf(herrors.ErrGitCommandFailed, "git %s: %v: %s",
+			strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (g *Gitops) project(path string) (string, string, error) {
+	path, err := cleanPath(path)
+	if err != nil {
+		return "", "", err
+	}
+	dir := g.projectDir(path)
+	if !isDir(dir) {
+		return "", "", herrors.NewErrNotFound(herrors.GitRepoInFilesystem,
+			fmt.Sprintf("project %s not found", path))
+	}
+	return path, dir, nil
+}
+
+func (g *Gitops) moveProject(path, dir, target string) error {
+	unlock := g.lock(path)
+	defer unlock()
+
+	if exists(target) {
+		return perror.Wrapf(herrors.ErrPathConflict, "project %s already exists",
+			strings.TrimPrefix(strings.TrimSuffix(target, _repoSuffix), g.rootDir+"/"))
+	}
+	if err := os.Rename(dir, target); err != nil {
+		return perror.Wrap(herrors.ErrGitCommandFailed, err.Error())
+	}
+	return nil
+}
+
+func (g *Gitops) lock(path string) func() {
+	l, _ := g.locks.LoadOrStore(path, &sync.Mutex{})
+	mu := l.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (g *Gitops) groupDir(path string) string {
+	return filepath.Join(g.rootDir, filepath.FromSlash(path))
+}
+
+func (g *Gitops) projectDir(path string) string {
+	return g.groupDir(path) + _repoSuffix
+}
+
+func authorEnv(ctx context.Context) []string {
+	name, email := _defaultAuthor, _defaultEmail
+	if user, err := common.UserFromContext(ctx); err == nil {
+		if user.GetName() != "" {
+			name = user.GetName()
+		}
+		if user.GetEmail() != "" {
+			email = user.GetEmail()
+		}
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email,
+	}
+}
+
+// cleanPath trims slashes of path and rejects relative segments to keep it under rootDir
+func cleanPath(path string) (string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", perror.Wrap(herrors.ErrParamInvalid, "path cannot be empty")
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", perror.Wrapf(herrors.ErrParamInvalid, "path %s is invalid", path)
+		}
+	}
+	return path, nil
+}
+
+// stripDiffHeader keeps hunks of a patch only, which is the same as the diff returned by gitlab
+func stripDiffHeader(patch string) string {
+	if index := strings.Index(patch, "\n@@"); index >= 0 {
+		return patch[index+1:]
+	}
+	return ""
+}
+
+func toGroup(path string) *gitops.Group {
+	return &gitops.Group{
+		Name:     filepath.Base(path),
+		Path:     filepath.Base(path),
+		FullPath: path,
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
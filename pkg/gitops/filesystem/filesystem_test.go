@@ -0,0 +1,148 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	"github.com/stretchr/testify/assert"
+)
+
+func isNotFound(err error) bool {
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	return ok
+}
+
+func Test(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.UserContextKey(), &userauth.DefaultInfo{
+		Name:  "Tony",
+		Email: "tony@horizon.com",
+	})
+	dir, err := ioutil.TempDir("", "gitops")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	g, err := gitops.New(ctx, &gitlabconfig.GitopsRepoConfig{
+		Kind:          Kind,
+		RootDir:       dir,
+		DefaultBranch: "master",
+	})
+	assert.Nil(t, err)
+
+	// groups
+	_, err = g.GetGroup(ctx, "root")
+	assert.True(t, isNotFound(err))
+	root, err := gitops.GetRootGroup(ctx, g, "root", "private")
+	assert.Nil(t, err)
+	assert.Equal(t, "root", root.FullPath)
+	clusters, err := g.GetCreatedGroup(ctx, root, "clusters", "private")
+	assert.Nil(t, err)
+	assert.Equal(t, "root/clusters", clusters.FullPath)
+	app, err := g.GetCreatedGroup(ctx, clusters, "app", "private")
+	assert.Nil(t, err)
+	_, err = g.GetGroup(ctx, "root/../..")
+	assert.NotNil(t, err)
+
+	// projects
+	project, err := g.CreateProject(ctx, "cluster", app, "private")
+	assert.Nil(t, err)
+	assert.Equal(t, "master", project.DefaultBranch)
+	pid := project.FullPath
+	readme, err := g.GetFile(ctx, pid, "master", "README.md")
+	assert.Nil(t, err)
+	assert.Equal(t, "# cluster\n", string(readme))
+
+	_, err = g.CreateBranch(ctx, pid, "gitops", "master")
+	assert.Nil(t, err)
+	commit, err := g.WriteFiles(ctx, pid, "gitops", "add files", nil, []gitops.CommitAction{
+		{Action: gitops.FileCreate, FilePath: "application.yaml", Content: "replicas: 1\n"},
+		{Action: gitops.FileCreate, FilePath: "env.yaml", Content: "env: test\n"},
+	})
+	assert.Nil(t, err)
+	_, err = g.WriteFiles(ctx, pid, "gitops", "add again", nil, []gitops.CommitAction{
+		{Action: gitops.FileCreate, FilePath: "env.yaml", Content: "env: test\n"},
+	})
+	assert.NotNil(t, err)
+
+	_, err = g.WriteFiles(ctx, pid, "gitops", "update files", nil, []gitops.CommitAction{
+		{Action: gitops.FileUpdate, FilePath: "application.yaml", Content: "replicas: 2\n"},
+		{Action: gitops.FileMove, FilePath: "base.yaml", PreviousPath: "env.yaml"},
+	})
+	assert.Nil(t, err)
+	content, err := g.GetFile(ctx, pid, "gitops", "application.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 2\n", string(content))
+	content, err = g.GetFile(ctx, pid, commit.ID, "application.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 1\n", string(content))
+	_, err = g.GetFile(ctx, pid, "gitops", "env.yaml")
+	assert.True(t, isNotFound(err))
+
+	diffs, err := g.Compare(ctx, pid, "gitops", commit.ID, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(diffs))
+	for _, diff := range diffs {
+		switch diff.NewPath {
+		case "application.yaml":
+			assert.Contains(t, diff.Diff, "-replicas: 2")
+			assert.Contains(t, diff.Diff, "+replicas: 1")
+		case "env.yaml":
+			assert.True(t, diff.RenamedFile)
+			assert.Equal(t, "base.yaml", diff.OldPath)
+		default:
+			t.Fatalf("unexpected diff: %+v", diff)
+		}
+	}
+
+	// merge gitops into master
+	sha, err := g.MergeBranch(ctx, pid, "gitops", "master", "git merge gitops into master")
+	assert.Nil(t, err)
+	master, err := g.GetBranch(ctx, pid, "master")
+	assert.Nil(t, err)
+	assert.Equal(t, sha, master.Commit.ID)
+	assert.Equal(t, "git merge gitops into master", master.Commit.Message)
+	diffs, err = g.Compare(ctx, pid, "master", "gitops", false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diffs))
+	again, err := g.MergeBranch(ctx, pid, "gitops", "master", "git merge gitops into master")
+	assert.Nil(t, err)
+	assert.Equal(t, sha, again)
+
+	// recycle and delete
+	recycling, err := g.GetCreatedGroup(ctx, root, "recycling-clusters", "private")
+	assert.Nil(t, err)
+	_, err = g.CreateGroup(ctx, "app", "app", recycling, "private")
+	assert.Nil(t, err)
+	newName := "cluster-1"
+	assert.Nil(t, g.EditNameAndPathForProject(ctx, pid, &newName, &newName))
+	assert.Nil(t, g.TransferProject(ctx, "root/clusters/app/cluster-1", "root/recycling-clusters/app"))
+	_, err = g.GetProject(ctx, pid)
+	assert.True(t, isNotFound(err))
+	_, err = g.GetProject(ctx, "root/recycling-clusters/app/cluster-1")
+	assert.Nil(t, err)
+	assert.Nil(t, g.DeleteProject(ctx, "root/recycling-clusters/app/cluster-1"))
+	assert.Nil(t, g.DeleteGroup(ctx, "root/clusters/app"))
+	_, err = g.GetGroup(ctx, "root/clusters/app")
+	assert.True(t, isNotFound(err))
+}
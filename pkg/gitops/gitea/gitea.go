@@ -0,0 +1,630 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const Kind = "gitea"
+
+const (
+	// _separator joins the nested group path into a repo name, since gitea organizations cannot be nested
+	_separator = "."
+	_pageSize  = 50
+)
+
+func init() {
+	gitops.Register(Kind, New)
+}
+
+// Gitops stores gitops repos in gitea.
+// The first segment of a path is an organization, and the rest segments are flattened into the repo name,
+// so groups below an organization are virtual and always exist.
+type Gitops struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+var _ gitops.Interface = (*Gitops)(nil)
+
+func New(ctx context.Context, config *gitlabconfig.GitopsRepoConfig) (gitops.Interface, error) {
+	if config.URL == "" {
+		return nil, perror.Wrap(herrors.ErrParamInvalid, "url of gitea gitops repo cannot be empty")
+	}
+	return &Gitops{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		url:   strings.TrimSuffix(config.URL, "/"),
+		token: config.Token,
+	}, nil
+}
+
+type organization struct {
+	ID       int    `json:"id"`
+	UserName string `json:"username"`
+}
+
+type repository struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+type branch struct {
+	Name   string `json:"name"`
+	Commit struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"commit"`
+}
+
+type contents struct {
+	SHA string `json:"sha"`
+}
+
+type changeFileOperation struct {
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Content   string `json:"content,omitempty"`
+	FromPath  string `json:"from_path,omitempty"`
+	SHA       string `json:"sha,omitempty"`
+}
+
+type fileResponse struct {
+	Commit struct {
+		SHA     string `json:"sha"`
+		Message string `json:"message"`
+	} `json:"commit"`
+}
+
+type treeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+type tree struct {
+	Entries   []treeEntry `json:"tree"`
+	Truncated bool        `json:"truncated"`
+}
+
+type pullRequest struct {
+	Index          int    `json:"number"`
+	MergeCommitSHA string `json:"merge_commit_sha"`
+	Head           struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (g *Gitops) GetGroup(ctx context.Context, path string) (*gitops.Group, error) {
+	org, rest := splitPath(path)
+	var o organization
+	if err := g.do(ctx, http.MethodGet, fmt.Sprintf("/orgs/%s", url.PathEscape(org)), nil, nil, &o); err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return toGroup(0, path), nil
+	}
+	return toGroup(o.ID, path), nil
+}
+
+func (g *Gitops) CreateGroup(ctx context.Context, name, path string,
+	parent *gitops.Group, visibility string) (*gitops.Group, error) {
+	if parent != nil {
+		return toGroup(0, parent.FullPath+"/"+path), nil
+	}
+	var o organization
+	if err := g.do(ctx, http.MethodPost, "/orgs", nil, map[string]string{
+		"username":   path,
+		"full_name":  name,
+		"visibility": orgVisibility(visibility),
+	}, &o); err != nil {
+		return nil, err
+	}
+	return toGroup(o.ID, path), nil
+}
+
+func (g *Gitops) GetCreatedGroup(ctx context.Context, parent *gitops.Group,
+	name, visibility string) (*gitops.Group, error) {
+	return toGroup(0, fmt.Sprintf("%v/%v", parent.FullPath, name)), nil
+}
+
+func (g *Gitops) DeleteGroup(ctx context.Context, path string) error {
+	org, rest := splitPath(path)
+	if rest == "" {
+		return g.do(ctx, http.MethodDelete, fmt.Sprintf("/orgs/%s", url.PathEscape(org)), nil, nil, nil)
+	}
+
+	// delete all repos of the virtual group
+	prefix := strings.ReplaceAll(rest, "/", _separator) + _separator
+	var names []string
+	for page := 1; ; page++ {
+		var repos []repository
+		if err := g.do(ctx, http.MethodGet, fmt.Sprintf("/orgs/%s/repos", url.PathEscape(org)), url.Values{
+			"page":  {fmt.Sprint(page)},
+			"limit": {fmt.Sprint(_pageSize)},
+		}, nil, &repos); err != nil {
+			return err
+		}
+		for _, repo := range repos {
+			if strings.HasPrefix(repo.Name, prefix) {
+				names = append(names, repo.Name)
+			}
+		}
+		if len(repos) < _pageSize {
+			break
+		}
+	}
+	for _, name := range names {
+		if err := g.do(ctx, http.MethodDelete, repoAPI(org, name), nil, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Gitops) GetProject(ctx context.Context, path string) (*gitops.Project, error) {
+	owner, name := repoName(path)
+	var repo repository
+	if err := g.do(ctx, http.MethodGet, repoAPI(owner, name), nil, nil, &repo); err != nil {
+		return nil, err
+	}
+	return toProject(&repo, path), nil
+}
+
+func (g *Gitops) CreateProject(ctx context.Context, name string,
+	group *gitops.Group, visibility string) (*gitops.Project, error) {
+	path := fmt.Sprintf("%v/%v", group.FullPath, name)
+	owner, repoName := repoName(path)
+	var repo repository
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("/orgs/%s/repos", url.PathEscape(owner)), nil,
+		map[string]interface{}{
+			"name":      repoName,
+			"private":   visibility != "public",
+			"auto_init": true,
+			"readme":    "Default",
+		}, &repo); err != nil {
+		return nil, err
+	}
+	return toProject(&repo, path), nil
+}
+
+func (g *Gitops) DeleteProject(ctx context.Context, path string) error {
+	owner, name := repoName(path)
+	return g.do(ctx, http.MethodDelete, repoAPI(owner, name), nil, nil, nil)
+}
+
+func (g *Gitops) TransferProject(ctx context.Context, path, groupPath string) error {
+	owner, name := repoName(path)
+	newOwner, newName := repoName(groupPath + "/" + path[strings.LastIndex(path, "/")+1:])
+	if newName != name {
+		if err := g.do(ctx, http.MethodPatch, repoAPI(owner, name), nil,
+			map[string]string{"name": newName}, nil); err != nil {
+			return err
+		}
+	}
+	if newOwner != owner {
+		return g.do(ctx, http.MethodPost, repoAPI(owner, newName)+"/transfer", nil,
+			map[string]string{"new_owner": newOwner}, nil)
+	}
+	return nil
+}
+
+func (g *Gitops) EditNameAndPathForProject(ctx context.Context, path string, newName, newPath *string) error {
+	// the name of a repo is always the same as its path
+	if newPath == nil {
+		return nil
+	}
+	owner, name := repoName(path)
+	_, target := repoName(path[:strings.LastIndex(path, "/")+1] + *newPath)
+	return g.do(ctx, http.MethodPatch, repoAPI(owner, name), nil, map[string]string{"name": target}, nil)
+}
+
+func (g *Gitops) GetBranch(ctx context.Context, path, branchName string) (*gitops.Branch, error) {
+	owner, name := repoName(path)
+	var b branch
+	if err := g.do(ctx, http.MethodGet, fmt.Sprintf("%s/branches/%s", repoAPI(owner, name),
+		url.PathEscape(branchName)), nil, nil, &b); err != nil {
+		return nil, err
+	}
+	return toBranch(&b), nil
+}
+
+func (g *Gitops) CreateBranch(ctx context.Context, path, branchName, fromRef string) (*gitops.Branch, error) {
+	owner, name := repoName(path)
+	var b branch
+	if err := g.do(ctx, http.MethodPost, repoAPI(owner, name)+"/branches", nil, map[string]string{
+		"new_branch_name": branchName,
+		"old_branch_name": fromRef,
+		"old_ref_name":    fromRef,
+	}, &b); err != nil {
+		return nil, err
+	}
+	return toBranch(&b), nil
+}
+
+func (g *Gitops) GetFile(ctx context.Context, path, ref, filepath string) ([]byte, error) {
+	owner, name := repoName(path)
+	return g.request(ctx, http.MethodGet, fmt.Sprintf("%s/raw/%s", repoAPI(owner, name), escapePath(filepath)),
+		url.Values{"ref": {ref}}, nil)
+}
+
+func (g *Gitops) WriteFiles(ctx context.Context, path, branchName, commitMsg string,
+	startBranch *string, actions []gitops.CommitAction) (_ *gitops.Commit, err error) {
+	const op = "gitea: write files"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	owner, name := repoName(path)
+	body := map[string]interface{}{
+		"branch":  branchName,
+		"message": commitMsg,
+	}
+	ref := branchName
+	if startBranch != nil {
+		if _, err := g.GetBranch(ctx, path, branchName); err != nil {
+			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+				return nil, err
+			}
+			body["branch"], body["new_branch"] = *startBranch, branchName
+			ref = *startBranch
+		}
+	}
+
+	files := make([]changeFileOperation, 0, len(actions))
+	for _, action := range actions {
+		file := changeFileOperation{
+			Path:    action.FilePath,
+			Content: base64.StdEncoding.EncodeToString([]byte(action.Content)),
+		}
+		switch action.Action {
+		case gitops.FileCreate:
+			file.Operation = "create"
+		case gitops.FileUpdate, gitops.FileDelete:
+			file.Operation = string(action.Action)
+			if file.SHA, err = g.fileSHA(ctx, owner, name, ref, action.FilePath); err != nil {
+				return nil, err
+			}
+			if action.Action == gitops.FileDelete {
+				file.Content = ""
+			}
+		case gitops.FileMove:
+			file.Operation, file.FromPath = "update", action.PreviousPath
+			if file.SHA, err = g.fileSHA(ctx, owner, name, ref, action.PreviousPath); err != nil {
+				return nil, err
+			}
+			if action.Content == "" {
+				content, err := g.GetFile(ctx, path, ref, action.PreviousPath)
+				if err != nil {
+					return nil, err
+				}
+				file.Content = base64.StdEncoding.EncodeToString(content)
+			}
+		default:
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "unsupported file action %s", action.Action)
+		}
+		files = append(files, file)
+	}
+	body["files"] = files
+
+	var resp fileResponse
+	if err := g.do(ctx, http.MethodPost, repoAPI(owner, name)+"/contents", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &gitops.Commit{ID: resp.Commit.SHA, Message: resp.Commit.Message}, nil
+}
+
+// Compare compares the trees of from and to directly, as gitea does not provide the merge base,
+// so straight is ignored and renamed files are reported as a deletion and a creation.
+func (g *Gitops) Compare(ctx context.Context, path, from, to string, straight bool) (_ []*gitops.Diff, err error) {
+	const op = "gitea: compare"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	owner, name := repoName(path)
+	fromTree, err := g.tree(ctx, owner, name, from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := g.tree(ctx, owner, name, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]*gitops.Diff, 0)
+	for filePath, sha := range toTree {
+		if fromSHA, ok := fromTree[filePath]; !ok || fromSHA != sha {
+			diffs = append(diffs, &gitops.Diff{OldPath: filePath, NewPath: filePath, NewFile: !ok})
+		}
+	}
+	for filePath := range fromTree {
+		if _, ok := toTree[filePath]; !ok {
+			diffs = append(diffs, &gitops.Diff{OldPath: filePath, NewPath: filePath, DeletedFile: true})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].NewPath < diffs[j].NewPath
+	})
+
+	for _, diff := range diffs {
+		var fromContent, toContent []byte
+		if !diff.NewFile {
+			if fromContent, err = g.GetFile(ctx, path, from, diff.OldPath); err != nil {
+				return nil, err
+			}
+		}
+		if !diff.DeletedFile {
+			if toContent, err = g.GetFile(ctx, path, to, diff.NewPath); err != nil {
+				return nil, err
+			}
+		}
+		diff.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:       difflib.SplitLines(string(fromContent)),
+			B:       difflib.SplitLines(string(toContent)),
+			Context: 3,
+		})
+		if err != nil {
+			return nil, perror.Wrap(herrors.ErrGiteaInternal, err.Error())
+		}
+	}
+	return diffs, nil
+}
+
+func (g *Gitops) MergeBranch(ctx context.Context, path, source, target, commitMsg string) (_ string, err error) {
+	const op = "gitea: merge branch"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	owner, name := repoName(path)
+	var pulls []pullRequest
+	if err := g.do(ctx, http.MethodGet, repoAPI(owner, name)+"/pulls", url.Values{
+		"state": {"open"},
+		"limit": {fmt.Sprint(_pageSize)},
+	}, nil, &pulls); err != nil {
+		return "", perror.WithMessage(err, "failed to list pull requests")
+	}
+	var pr *pullRequest
+	for i := range pulls {
+		if pulls[i].Head.Ref == source && pulls[i].Base.Ref == target {
+			pr = &pulls[i]
+			break
+		}
+	}
+	if pr == nil {
+		pr = &pullRequest{}
+		if err := g.do(ctx, http.MethodPost, repoAPI(owner, name)+"/pulls", nil, map[string]string{
+			"head":  source,
+			"base":  target,
+			"title": commitMsg,
+		}, pr); err != nil {
+			return "", perror.WithMessage(err, "failed to create new pull request")
+		}
+	}
+
+	pullAPI := fmt.Sprintf("%s/pulls/%d", repoAPI(owner, name), pr.Index)
+	for i := 0; i < 20; i++ {
+		err = g.do(ctx, http.MethodPost, pullAPI+"/merge", nil, map[string]interface{}{
+			"Do":                        "merge",
+			"MergeTitleField":           commitMsg,
+			"delete_branch_after_merge": false,
+		}, nil)
+		if err == nil || perror.Cause(err) != herrors.ErrGiteaPRNotReady {
+			break
+		}
+		log.Warningf(ctx, "pull request %d of %s is not ready to merge, retry", pr.Index, path)
+		time.Sleep(time.Second)
+	}
+	if err != nil {
+		return "", perror.WithMessage(err, "failed to merge pull request")
+	}
+
+	if err := g.do(ctx, http.MethodGet, pullAPI, nil, nil, pr); err != nil {
+		return "", err
+	}
+	return pr.MergeCommitSHA, nil
+}
+
+func (g *Gitops) GetRepoURL(ctx context.Context, path string) string {
+	owner, name := repoName(path)
+	return fmt.Sprintf("%v/%v/%v.git", g.url, owner, name)
+}
+
+func (g *Gitops) fileSHA(ctx context.Context, owner, name, ref, filepath string) (string, error) {
+	var c contents
+	if err := g.do(ctx, http.MethodGet, fmt.Sprintf("%s/contents/%s", repoAPI(owner, name), escapePath(filepath)),
+		url.Values{"ref": {ref}}, nil, &c); err != nil {
+		return "", err
+	}
+	return c.SHA, nil
+}
+
+// tree lists all blobs of ref recursively
+func (g *Gitops) tree(ctx context.Context, owner, name, ref string) (map[string]string, error) {
+	blobs := make(map[string]string)
+	for page := 1; ; page++ {
+		var t tree
+		if err := g.do(ctx, http.MethodGet, fmt.Sprintf("%s/git/trees/%s", repoAPI(owner, name),
+			url.PathEscape(ref)), url.Values{
+			"recursive": {"true"},
+			"page":      {fmt.Sprint(page)},
+		}, nil, &t); err != nil {
+			return nil, err
+		}
+		for _, entry := range t.Entries {
+			if entry.Type == "blob" {
+				blobs[entry.Path] = entry.SHA
+			}
+		}
+		if !t.Truncated || len(t.Entries) == 0 {
+			break
+		}
+	}
+	return blobs, nil
+}
+
+func (g *Gitops) do(ctx context.Context, method, api string, query url.Values,
+	body interface{}, result interface{}) error {
+	data, err := g.request(ctx, method, api, query, body)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return perror.Wrapf(herrors.ErrGiteaInternal, "failed to unmarshal response of %s: %v", api, err)
+	}
+	return nil
+}
+
+func (g *Gitops) request(ctx context.Context, method, api string, query url.Values,
+	body interface{}) ([]byte, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, perror.Wrap(herrors.ErrParamInvalid, err.Error())
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	reqURL := fmt.Sprintf("%s/api/v1%s", g.url, api)
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reader)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrHTTPRequestFailed, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrHTTPRequestFailed, err.Error())
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, perror.Wrap(herrors.ErrHTTPRequestFailed, err.Error())
+	}
+
+	switch {
+	case resp.StatusCode < http.StatusBadRequest:
+		return data, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, herrors.NewErrNotFound(herrors.GiteaResource,
+			fmt.Sprintf("%s %s: %s", method, api, string(data)))
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		// https://docs.gitea.com/api/1.20/#tag/repository/operation/repoMergePullRequest
+		return nil, perror.Wrap(herrors.ErrGiteaPRNotReady, string(data))
+	default:
+		return nil, perror.Wrapf(herrors.ErrGiteaInternal, "%s %s: status = %d, body = %s",
+			method, api, resp.StatusCode, string(data))
+	}
+}
+
+// splitPath splits path into the organization and the rest path
+func splitPath(fullPath string) (string, string) {
+	fullPath = strings.Trim(fullPath, "/")
+	if index := strings.Index(fullPath, "/"); index >= 0 {
+		return fullPath[:index], fullPath[index+1:]
+	}
+	return fullPath, ""
+}
+
+// repoName converts the path of a project into its owner and flattened repo name
+func repoName(fullPath string) (string, string) {
+	owner, rest := splitPath(fullPath)
+	return owner, strings.ReplaceAll(rest, "/", _separator)
+}
+
+func repoAPI(owner, name string) string {
+	return fmt.Sprintf("/repos/%s/%s", url.PathEscape(owner), url.PathEscape(name))
+}
+
+func escapePath(filepath string) string {
+	segments := strings.Split(strings.TrimPrefix(filepath, "/"), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+func orgVisibility(visibility string) string {
+	if visibility == "internal" {
+		return "limited"
+	}
+	return visibility
+}
+
+func toGroup(id int, fullPath string) *gitops.Group {
+	fullPath = strings.Trim(fullPath, "/")
+	return &gitops.Group{
+		ID:       id,
+		Name:     path.Base(fullPath),
+		Path:     path.Base(fullPath),
+		FullPath: fullPath,
+	}
+}
+
+func toProject(repo *repository, fullPath string) *gitops.Project {
+	return &gitops.Project{
+		ID:            repo.ID,
+		Name:          path.Base(fullPath),
+		Path:          path.Base(fullPath),
+		FullPath:      fullPath,
+		DefaultBranch: repo.DefaultBranch,
+	}
+}
+
+func toBranch(b *branch) *gitops.Branch {
+	return &gitops.Branch{
+		Name: b.Name,
+		Commit: &gitops.Commit{
+			ID:      b.Commit.ID,
+			Message: b.Commit.Message,
+		},
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitea
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test(t *testing.T) {
+	ctx := context.Background()
+
+	var changes map[string]interface{}
+	mergeAttempts := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/orgs/root", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 1, "username": "root"}`))
+	})
+	mux.HandleFunc("/api/v1/repos/root/clusters.app.cluster", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 2, "name": "clusters.app.cluster", "default_branch": "master"}`))
+	})
+	mux.HandleFunc("/api/v1/repos/root/clusters.app.cluster/raw/application.yaml",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("ref") != "gitops" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte("replicas: 1\n"))
+		})
+	mux.HandleFunc("/api/v1/repos/root/clusters.app.cluster/contents/application.yaml",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"sha": "blob"}`))
+		})
+	mux.HandleFunc("/api/v1/repos/root/clusters.app.cluster/contents", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&changes))
+		_, _ = w.Write([]byte(`{"commit": {"sha": "commit", "message": "update"}}`))
+	})
+	mux.HandleFunc("/api/v1/repos/root/clusters.app.cluster/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`[{"number": 3, "head": {"ref": "other"}, "base": {"ref": "master"}}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"number": 4}`))
+	})
+	mux.HandleFunc("/api/v1/repos/root/clusters.app.cluster/pulls/4/merge", func(w http.ResponseWriter, r *http.Request) {
+		mergeAttempts++
+		if mergeAttempts == 1 {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/v1/repos/root/clusters.app.cluster/pulls/4", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"number": 4, "merge_commit_sha": "merged"}`))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	g, err := gitops.New(ctx, &gitlabconfig.GitopsRepoConfig{
+		Kind:  Kind,
+		URL:   server.URL,
+		Token: "secret",
+	})
+	assert.Nil(t, err)
+
+	root, err := g.GetGroup(ctx, "root")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, root.ID)
+	app, err := g.GetCreatedGroup(ctx, root, "clusters", "private")
+	assert.Nil(t, err)
+	app, err = g.GetCreatedGroup(ctx, app, "app", "private")
+	assert.Nil(t, err)
+	assert.Equal(t, "root/clusters/app", app.FullPath)
+
+	pid := "root/clusters/app/cluster"
+	project, err := g.GetProject(ctx, pid)
+	assert.Nil(t, err)
+	assert.Equal(t, "cluster", project.Name)
+	assert.Equal(t, "master", project.DefaultBranch)
+	assert.Equal(t, fmt.Sprintf("%s/root/clusters.app.cluster.git", server.URL), g.GetRepoURL(ctx, pid))
+
+	content, err := g.GetFile(ctx, pid, "gitops", "application.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 1\n", string(content))
+	_, err = g.GetFile(ctx, pid, "master", "application.yaml")
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	commit, err := g.WriteFiles(ctx, pid, "gitops", "update", nil, []gitops.CommitAction{
+		{Action: gitops.FileUpdate, FilePath: "application.yaml", Content: "replicas: 2\n"},
+		{Action: gitops.FileCreate, FilePath: "env.yaml", Content: "env: test\n"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "commit", commit.ID)
+	assert.Equal(t, "gitops", changes["branch"])
+	files := changes["files"].([]interface{})
+	assert.Equal(t, 2, len(files))
+	update := files[0].(map[string]interface{})
+	assert.Equal(t, "update", update["operation"])
+	assert.Equal(t, "blob", update["sha"])
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("replicas: 2\n")), update["content"])
+	assert.Equal(t, "create", files[1].(map[string]interface{})["operation"])
+
+	sha, err := g.MergeBranch(ctx, pid, "gitops", "master", "git merge gitops into master")
+	assert.Nil(t, err)
+	assert.Equal(t, "merged", sha)
+	assert.Equal(t, 2, mergeAttempts)
+}
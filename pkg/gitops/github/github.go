@@ -0,0 +1,533 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/oauth2"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	"github.com/horizoncd/horizon/pkg/util/wlog"
+)
+
+const Kind = "github"
+
+const (
+	// _separator joins the nested group path into a repo name, since github organizations cannot be nested
+	_separator = "."
+	_pageSize  = 100
+	_githubURL = "https://github.com"
+	_fileMode  = "100644"
+)
+
+func init() {
+	gitops.Register(Kind, New)
+}
+
+// Gitops stores gitops repos in github or github enterprise server.
+// The first segment of a path is an organization, and the rest segments are flattened into the repo name,
+// so groups below an organization are virtual and always exist.
+// Organizations of github.com cannot be created by api, so the root group must be created beforehand.
+type Gitops struct {
+	client *github.Client
+	url    string
+}
+
+var _ gitops.Interface = (*Gitops)(nil)
+
+func New(ctx context.Context, config *gitlabconfig.GitopsRepoConfig) (gitops.Interface, error) {
+	var httpClient *http.Client
+	if config.Token != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: config.Token}))
+	}
+	url := strings.TrimSuffix(config.URL, "/")
+	if url == "" || url == _githubURL {
+		return &Gitops{client: github.NewClient(httpClient), url: _githubURL}, nil
+	}
+	// github enterprise server serves the api under /api/v3
+	client, err := github.NewEnterpriseClient(url, url, httpClient)
+	if err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid url of github gitops repo: %v", err)
+	}
+	return &Gitops{client: client, url: url}, nil
+}
+
+func (g *Gitops) GetGroup(ctx context.Context, path string) (*gitops.Group, error) {
+	org, rest := splitPath(path)
+	o, resp, err := g.client.Organizations.Get(ctx, org)
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get organization %s", org)
+	}
+	if rest != "" {
+		return toGroup(0, path), nil
+	}
+	return toGroup(int(o.GetID()), path), nil
+}
+
+func (g *Gitops) CreateGroup(ctx context.Context, name, path string,
+	parent *gitops.Group, visibility string) (*gitops.Group, error) {
+	if parent != nil {
+		return toGroup(0, parent.FullPath+"/"+path), nil
+	}
+	if g.url == _githubURL {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"organization %s of github.com cannot be created by api, please create it beforehand", path)
+	}
+	// only site administrators of github enterprise server can create organizations
+	user, resp, err := g.client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get current user")
+	}
+	o, resp, err := g.client.Admin.CreateOrg(ctx, &github.Organization{
+		Login: github.String(path),
+		Name:  github.String(name),
+	}, user.GetLogin())
+	if err != nil {
+		return nil, convertError(resp, err, "failed to create organization %s", path)
+	}
+	return toGroup(int(o.GetID()), path), nil
+}
+
+func (g *Gitops) GetCreatedGroup(ctx context.Context, parent *gitops.Group,
+	name, visibility string) (*gitops.Group, error) {
+	return toGroup(0, fmt.Sprintf("%v/%v", parent.FullPath, name)), nil
+}
+
+func (g *Gitops) DeleteGroup(ctx context.Context, path string) error {
+	org, rest := splitPath(path)
+	if rest == "" {
+		return perror.Wrapf(herrors.ErrParamInvalid, "organization %s cannot be deleted by api", org)
+	}
+
+	// delete all repos of the virtual group
+	prefix := strings.ReplaceAll(rest, "/", _separator) + _separator
+	var names []string
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: _pageSize}}
+	for {
+		repos, resp, err := g.client.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return convertError(resp, err, "failed to list repos of organization %s", org)
+		}
+		for _, repo := range repos {
+			if strings.HasPrefix(repo.GetName(), prefix) {
+				names = append(names, repo.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	for _, name := range names {
+		if resp, err := g.client.Repositories.Delete(ctx, org, name); err != nil {
+			return convertError(resp, err, "failed to delete repo %s/%s", org, name)
+		}
+	}
+	return nil
+}
+
+func (g *Gitops) GetProject(ctx context.Context, path string) (*gitops.Project, error) {
+	owner, name := repoName(path)
+	repo, resp, err := g.client.Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get repo %s/%s", owner, name)
+	}
+	return toProject(repo, path), nil
+}
+
+func (g *Gitops) CreateProject(ctx context.Context, name string,
+	group *gitops.Group, visibility string) (*gitops.Project, error) {
+	path := fmt.Sprintf("%v/%v", group.FullPath, name)
+	owner, repoName := repoName(path)
+	repo, resp, err := g.client.Repositories.Create(ctx, owner, &github.Repository{
+		Name:     github.String(repoName),
+		Private:  github.Bool(visibility != "public"),
+		AutoInit: github.Bool(true),
+	})
+	if err != nil {
+		return nil, convertError(resp, err, "failed to create repo %s/%s", owner, repoName)
+	}
+	return toProject(repo, path), nil
+}
+
+func (g *Gitops) DeleteProject(ctx context.Context, path string) error {
+	owner, name := repoName(path)
+	resp, err := g.client.Repositories.Delete(ctx, owner, name)
+	return convertError(resp, err, "failed to delete repo %s/%s", owner, name)
+}
+
+func (g *Gitops) TransferProject(ctx context.Context, path, groupPath string) error {
+	owner, name := repoName(path)
+	newOwner, newName := repoName(groupPath + "/" + path[strings.LastIndex(path, "/")+1:])
+	if newName != name {
+		if _, resp, err := g.client.Repositories.Edit(ctx, owner, name, &github.Repository{
+			Name: github.String(newName),
+		}); err != nil {
+			return convertError(resp, err, "failed to rename repo %s/%s", owner, name)
+		}
+	}
+	if newOwner != owner {
+		if _, resp, err := g.client.Repositories.Transfer(ctx, owner, newName, github.TransferRequest{
+			NewOwner: newOwner,
+		}); err != nil {
+			// the transfer is accepted and performed asynchronously
+			if _, ok := err.(*github.AcceptedError); !ok {
+				return convertError(resp, err, "failed to transfer repo %s/%s", owner, newName)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Gitops) EditNameAndPathForProject(ctx context.Context, path string, newName, newPath *string) error {
+	// the name of a repo is always the same as its path
+	if newPath == nil {
+		return nil
+	}
+	owner, name := repoName(path)
+	_, target := repoName(path[:strings.LastIndex(path, "/")+1] + *newPath)
+	_, resp, err := g.client.Repositories.Edit(ctx, owner, name, &github.Repository{Name: github.String(target)})
+	return convertError(resp, err, "failed to rename repo %s/%s", owner, name)
+}
+
+func (g *Gitops) GetBranch(ctx context.Context, path, branchName string) (*gitops.Branch, error) {
+	owner, name := repoName(path)
+	b, resp, err := g.client.Repositories.GetBranch(ctx, owner, name, branchName, true)
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get branch %s of repo %s/%s", branchName, owner, name)
+	}
+	return &gitops.Branch{
+		Name: b.GetName(),
+		Commit: &gitops.Commit{
+			ID:      b.GetCommit().GetSHA(),
+			Message: b.GetCommit().GetCommit().GetMessage(),
+		},
+	}, nil
+}
+
+func (g *Gitops) CreateBranch(ctx context.Context, path, branchName, fromRef string) (*gitops.Branch, error) {
+	owner, name := repoName(path)
+	sha, resp, err := g.client.Repositories.GetCommitSHA1(ctx, owner, name, fromRef, "")
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get commit of %s in repo %s/%s", fromRef, owner, name)
+	}
+	if _, resp, err := g.client.Git.CreateRef(ctx, owner, name, &github.Reference{
+		Ref:    github.String("refs/heads/" + branchName),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}); err != nil {
+		return nil, convertError(resp, err, "failed to create branch %s of repo %s/%s", branchName, owner, name)
+	}
+	return g.GetBranch(ctx, path, branchName)
+}
+
+func (g *Gitops) GetFile(ctx context.Context, path, ref, filepath string) ([]byte, error) {
+	owner, name := repoName(path)
+	file, _, resp, err := g.client.Repositories.GetContents(ctx, owner, name, filepath,
+		&github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get file %s of %s in repo %s/%s",
+			filepath, ref, owner, name)
+	}
+	if file == nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "%s of repo %s/%s is not a file", filepath, owner, name)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, perror.Wrapf(herrors.ErrGithubInternal, "failed to decode file %s: %v", filepath, err)
+	}
+	return []byte(content), nil
+}
+
+// WriteFiles creates a commit with all the actions by the git database api,
+// as the contents api of github changes only one file in a commit
+func (g *Gitops) WriteFiles(ctx context.Context, path, branchName, commitMsg string,
+	startBranch *string, actions []gitops.CommitAction) (_ *gitops.Commit, err error) {
+	const op = "github: write files"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	owner, name := repoName(path)
+	ref, newBranch := branchName, false
+	if startBranch != nil {
+		if _, err := g.GetBranch(ctx, path, branchName); err != nil {
+			if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+				return nil, err
+			}
+			ref, newBranch = *startBranch, true
+		}
+	}
+	head, resp, err := g.client.Git.GetRef(ctx, owner, name, "heads/"+ref)
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get branch %s of repo %s/%s", ref, owner, name)
+	}
+	parent, resp, err := g.client.Git.GetCommit(ctx, owner, name, head.GetObject().GetSHA())
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get commit of branch %s", ref)
+	}
+
+	entries := make([]*github.TreeEntry, 0, len(actions))
+	for _, action := range actions {
+		content := action.Content
+		switch action.Action {
+		case gitops.FileCreate, gitops.FileUpdate:
+		case gitops.FileDelete:
+			entries = append(entries, deletedEntry(action.FilePath))
+			continue
+		case gitops.FileMove:
+			if content == "" {
+				data, err := g.GetFile(ctx, path, ref, action.PreviousPath)
+				if err != nil {
+					return nil, err
+				}
+				content = string(data)
+			}
+			entries = append(entries, deletedEntry(action.PreviousPath))
+		default:
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "unsupported file action %s", action.Action)
+		}
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.String(strings.TrimPrefix(action.FilePath, "/")),
+			Mode:    github.String(_fileMode),
+			Type:    github.String("blob"),
+			Content: github.String(content),
+		})
+	}
+	tree, resp, err := g.client.Git.CreateTree(ctx, owner, name, parent.GetTree().GetSHA(), entries)
+	if err != nil {
+		return nil, convertError(resp, err, "failed to create tree in repo %s/%s", owner, name)
+	}
+	commit, resp, err := g.client.Git.CreateCommit(ctx, owner, name, &github.Commit{
+		Message: github.String(commitMsg),
+		Tree:    &github.Tree{SHA: tree.SHA},
+		Parents: []*github.Commit{{SHA: parent.SHA}},
+	})
+	if err != nil {
+		return nil, convertError(resp, err, "failed to create commit in repo %s/%s", owner, name)
+	}
+
+	branchRef := &github.Reference{
+		Ref:    github.String("refs/heads/" + branchName),
+		Object: &github.GitObject{SHA: commit.SHA},
+	}
+	if newBranch {
+		_, resp, err = g.client.Git.CreateRef(ctx, owner, name, branchRef)
+	} else {
+		// not forced, so that the commits pushed by others in the meantime are never discarded
+		_, resp, err = g.client.Git.UpdateRef(ctx, owner, name, branchRef, false)
+	}
+	if err != nil {
+		return nil, convertError(resp, err, "failed to update branch %s of repo %s/%s", branchName, owner, name)
+	}
+	return &gitops.Commit{ID: commit.GetSHA(), Message: commit.GetMessage()}, nil
+}
+
+// Compare uses the compare api of github, which computes the diffs from the merge base.
+// If straight is true, the trees of from and to are compared directly,
+// and renamed files are reported as a deletion and a creation.
+func (g *Gitops) Compare(ctx context.Context, path, from, to string, straight bool) (_ []*gitops.Diff, err error) {
+	const op = "github: compare"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	owner, name := repoName(path)
+	if straight {
+		return g.compareTrees(ctx, path, from, to)
+	}
+	comparison, resp, err := g.client.Repositories.CompareCommits(ctx, owner, name, from, to,
+		&github.ListOptions{PerPage: _pageSize})
+	if err != nil {
+		return nil, convertError(resp, err, "failed to compare %s and %s in repo %s/%s", from, to, owner, name)
+	}
+	diffs := make([]*gitops.Diff, 0, len(comparison.Files))
+	for _, file := range comparison.Files {
+		diff := &gitops.Diff{
+			OldPath:     file.GetFilename(),
+			NewPath:     file.GetFilename(),
+			NewFile:     file.GetStatus() == "added",
+			RenamedFile: file.GetStatus() == "renamed",
+			DeletedFile: file.GetStatus() == "removed",
+			Diff:        file.GetPatch(),
+		}
+		if file.GetPreviousFilename() != "" {
+			diff.OldPath = file.GetPreviousFilename()
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func (g *Gitops) compareTrees(ctx context.Context, path, from, to string) (_ []*gitops.Diff, err error) {
+	owner, name := repoName(path)
+	fromTree, err := g.tree(ctx, owner, name, from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := g.tree(ctx, owner, name, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]*gitops.Diff, 0)
+	for filePath, sha := range toTree {
+		if fromSHA, ok := fromTree[filePath]; !ok || fromSHA != sha {
+			diffs = append(diffs, &gitops.Diff{OldPath: filePath, NewPath: filePath, NewFile: !ok})
+		}
+	}
+	for filePath := range fromTree {
+		if _, ok := toTree[filePath]; !ok {
+			diffs = append(diffs, &gitops.Diff{OldPath: filePath, NewPath: filePath, DeletedFile: true})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].NewPath < diffs[j].NewPath
+	})
+
+	for _, diff := range diffs {
+		var fromContent, toContent []byte
+		if !diff.NewFile {
+			if fromContent, err = g.GetFile(ctx, path, from, diff.OldPath); err != nil {
+				return nil, err
+			}
+		}
+		if !diff.DeletedFile {
+			if toContent, err = g.GetFile(ctx, path, to, diff.NewPath); err != nil {
+				return nil, err
+			}
+		}
+		diff.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:       difflib.SplitLines(string(fromContent)),
+			B:       difflib.SplitLines(string(toContent)),
+			Context: 3,
+		})
+		if err != nil {
+			return nil, perror.Wrap(herrors.ErrGithubInternal, err.Error())
+		}
+	}
+	return diffs, nil
+}
+
+// MergeBranch merges source into target by the merges api of github, which creates a merge commit directly
+func (g *Gitops) MergeBranch(ctx context.Context, path, source, target, commitMsg string) (_ string, err error) {
+	const op = "github: merge branch"
+	defer wlog.Start(ctx, op).StopPrint()
+
+	owner, name := repoName(path)
+	commit, resp, err := g.client.Repositories.Merge(ctx, owner, name, &github.RepositoryMergeRequest{
+		Base:          github.String(target),
+		Head:          github.String(source),
+		CommitMessage: github.String(commitMsg),
+	})
+	if err != nil {
+		return "", convertError(resp, err, "failed to merge %s into %s in repo %s/%s", source, target, owner, name)
+	}
+	// nothing to merge, target already contains source
+	if resp.StatusCode == http.StatusNoContent {
+		branch, err := g.GetBranch(ctx, path, target)
+		if err != nil {
+			return "", err
+		}
+		return branch.Commit.ID, nil
+	}
+	return commit.GetSHA(), nil
+}
+
+func (g *Gitops) GetRepoURL(ctx context.Context, path string) string {
+	owner, name := repoName(path)
+	return fmt.Sprintf("%v/%v/%v.git", g.url, owner, name)
+}
+
+// tree lists all blobs of ref recursively
+func (g *Gitops) tree(ctx context.Context, owner, name, ref string) (map[string]string, error) {
+	t, resp, err := g.client.Git.GetTree(ctx, owner, name, ref, true)
+	if err != nil {
+		return nil, convertError(resp, err, "failed to get tree of %s in repo %s/%s", ref, owner, name)
+	}
+	if t.GetTruncated() {
+		return nil, perror.Wrapf(herrors.ErrGithubInternal,
+			"tree of %s in repo %s/%s is too large to be listed", ref, owner, name)
+	}
+	blobs := make(map[string]string)
+	for _, entry := range t.Entries {
+		if entry.GetType() == "blob" {
+			blobs[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+	return blobs, nil
+}
+
+// convertError converts the error returned by github api into horizon errors
+func convertError(resp *github.Response, err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	msg := fmt.Sprintf(format, args...)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return herrors.NewErrNotFound(herrors.GithubResource, fmt.Sprintf("%s: %v", msg, err))
+	}
+	return perror.Wrapf(herrors.ErrGithubInternal, "%s: %v", msg, err)
+}
+
+// deletedEntry returns the tree entry which deletes filepath, whose sha is null
+func deletedEntry(filepath string) *github.TreeEntry {
+	return &github.TreeEntry{
+		Path: github.String(strings.TrimPrefix(filepath, "/")),
+		Mode: github.String(_fileMode),
+		Type: github.String("blob"),
+	}
+}
+
+// splitPath splits path into the organization and the rest path
+func splitPath(fullPath string) (string, string) {
+	fullPath = strings.Trim(fullPath, "/")
+	if index := strings.Index(fullPath, "/"); index >= 0 {
+		return fullPath[:index], fullPath[index+1:]
+	}
+	return fullPath, ""
+}
+
+// repoName converts the path of a project into its owner and flattened repo name
+func repoName(fullPath string) (string, string) {
+	owner, rest := splitPath(fullPath)
+	return owner, strings.ReplaceAll(rest, "/", _separator)
+}
+
+func toGroup(id int, fullPath string) *gitops.Group {
+	fullPath = strings.Trim(fullPath, "/")
+	return &gitops.Group{
+		ID:       id,
+		Name:     path.Base(fullPath),
+		Path:     path.Base(fullPath),
+		FullPath: fullPath,
+	}
+}
+
+func toProject(repo *github.Repository, fullPath string) *gitops.Project {
+	return &gitops.Project{
+		ID:            int(repo.GetID()),
+		Name:          path.Base(fullPath),
+		Path:          path.Base(fullPath),
+		FullPath:      fullPath,
+		DefaultBranch: repo.GetDefaultBranch(),
+	}
+}
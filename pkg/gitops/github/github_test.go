@@ -0,0 +1,155 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		tree      map[string]interface{}
+		updateRef map[string]interface{}
+	)
+	repoAPI := "/api/v3/repos/root/clusters.app.cluster"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/root", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 1, "login": "root"}`))
+	})
+	mux.HandleFunc(repoAPI, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 2, "name": "clusters.app.cluster", "default_branch": "master"}`))
+	})
+	mux.HandleFunc(repoAPI+"/contents/application.yaml", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ref") != "gitops" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "content": "%s"}`,
+			base64.StdEncoding.EncodeToString([]byte("replicas: 1\n")))
+	})
+	mux.HandleFunc(repoAPI+"/git/ref/heads/gitops", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/gitops", "object": {"sha": "parent"}}`))
+	})
+	mux.HandleFunc(repoAPI+"/git/commits/parent", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"sha": "parent", "tree": {"sha": "base"}}`))
+	})
+	mux.HandleFunc(repoAPI+"/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&tree))
+		_, _ = w.Write([]byte(`{"sha": "tree"}`))
+	})
+	mux.HandleFunc(repoAPI+"/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		var commit map[string]interface{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&commit))
+		assert.Equal(t, "tree", commit["tree"])
+		assert.Equal(t, []interface{}{"parent"}, commit["parents"])
+		_, _ = w.Write([]byte(`{"sha": "commit", "message": "update"}`))
+	})
+	mux.HandleFunc(repoAPI+"/git/refs/heads/gitops", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&updateRef))
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/gitops", "object": {"sha": "commit"}}`))
+	})
+	mux.HandleFunc(repoAPI+"/merges", func(w http.ResponseWriter, r *http.Request) {
+		var merge map[string]interface{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&merge))
+		assert.Equal(t, "master", merge["base"])
+		assert.Equal(t, "gitops", merge["head"])
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"sha": "merged"}`))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	// organizations of github.com cannot be created
+	g, err := gitops.New(ctx, &gitlabconfig.GitopsRepoConfig{Kind: Kind})
+	assert.Nil(t, err)
+	_, err = g.CreateGroup(ctx, "root", "root", nil, "private")
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+
+	g, err = gitops.New(ctx, &gitlabconfig.GitopsRepoConfig{
+		Kind:  Kind,
+		URL:   server.URL,
+		Token: "secret",
+	})
+	assert.Nil(t, err)
+
+	root, err := g.GetGroup(ctx, "root")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, root.ID)
+	app, err := g.GetCreatedGroup(ctx, root, "clusters", "private")
+	assert.Nil(t, err)
+	app, err = g.GetCreatedGroup(ctx, app, "app", "private")
+	assert.Nil(t, err)
+	assert.Equal(t, "root/clusters/app", app.FullPath)
+
+	pid := "root/clusters/app/cluster"
+	project, err := g.GetProject(ctx, pid)
+	assert.Nil(t, err)
+	assert.Equal(t, "cluster", project.Name)
+	assert.Equal(t, "master", project.DefaultBranch)
+	assert.Equal(t, fmt.Sprintf("%s/root/clusters.app.cluster.git", server.URL), g.GetRepoURL(ctx, pid))
+
+	content, err := g.GetFile(ctx, pid, "gitops", "application.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 1\n", string(content))
+	_, err = g.GetFile(ctx, pid, "master", "application.yaml")
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	commit, err := g.WriteFiles(ctx, pid, "gitops", "update", nil, []gitops.CommitAction{
+		{Action: gitops.FileUpdate, FilePath: "application.yaml", Content: "replicas: 2\n"},
+		{Action: gitops.FileCreate, FilePath: "env.yaml", Content: "env: test\n"},
+		{Action: gitops.FileDelete, FilePath: "tags.yaml"},
+		{Action: gitops.FileMove, FilePath: "pipeline.yaml", PreviousPath: "build.yaml", Content: "build: {}\n"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "commit", commit.ID)
+	assert.Equal(t, "base", tree["base_tree"])
+	entries := tree["tree"].([]interface{})
+	assert.Equal(t, 5, len(entries))
+	update := entries[0].(map[string]interface{})
+	assert.Equal(t, "application.yaml", update["path"])
+	assert.Equal(t, "replicas: 2\n", update["content"])
+	deleted := entries[2].(map[string]interface{})
+	assert.Equal(t, "tags.yaml", deleted["path"])
+	sha, ok := deleted["sha"]
+	assert.True(t, ok)
+	assert.Nil(t, sha)
+	assert.Equal(t, "build.yaml", entries[3].(map[string]interface{})["path"])
+	assert.Equal(t, "pipeline.yaml", entries[4].(map[string]interface{})["path"])
+	assert.Equal(t, "commit", updateRef["sha"])
+	assert.Equal(t, false, updateRef["force"])
+
+	mergedSHA, err := g.MergeBranch(ctx, pid, "gitops", "master", "git merge gitops into master")
+	assert.Nil(t, err)
+	assert.Equal(t, "merged", mergedSHA)
+}
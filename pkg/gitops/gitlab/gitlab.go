@@ -0,0 +1,257 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/horizoncd/horizon/core/common"
+	gitlablib "github.com/horizoncd/horizon/lib/gitlab"
+	gitlabconfig "github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitops"
+	"github.com/horizoncd/horizon/pkg/util/log"
+	"github.com/xanzy/go-gitlab"
+)
+
+const Kind = "gitlab"
+
+func init() {
+	gitops.Register(Kind, New)
+}
+
+type Gitops struct {
+	gitlabLib gitlablib.Interface
+}
+
+var _ gitops.Interface = (*Gitops)(nil)
+
+func New(ctx context.Context, config *gitlabconfig.GitopsRepoConfig) (gitops.Interface, error) {
+	gitlabLib, err := gitlablib.New(config.Token, config.URL)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithLib(gitlabLib), nil
+}
+
+// NewWithLib wraps an existing gitlab client
+func NewWithLib(gitlabLib gitlablib.Interface) *Gitops {
+	return &Gitops{gitlabLib: gitlabLib}
+}
+
+func (g *Gitops) GetGroup(ctx context.Context, path string) (*gitops.Group, error) {
+	group, err := g.gitlabLib.GetGroup(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return toGroup(group), nil
+}
+
+func (g *Gitops) CreateGroup(ctx context.Context, name, path string,
+	parent *gitops.Group, visibility string) (*gitops.Group, error) {
+	var parentID *int
+	if parent != nil {
+		parentID = &parent.ID
+	}
+	group, err := g.gitlabLib.CreateGroup(ctx, name, path, parentID, visibility)
+	if err != nil {
+		return nil, err
+	}
+	return toGroup(group), nil
+}
+
+func (g *Gitops) GetCreatedGroup(ctx context.Context, parent *gitops.Group,
+	name, visibility string) (*gitops.Group, error) {
+	group, err := g.gitlabLib.GetCreatedGroup(ctx, parent.ID, parent.FullPath, name, visibility)
+	if err != nil {
+		return nil, err
+	}
+	return toGroup(group), nil
+}
+
+func (g *Gitops) DeleteGroup(ctx context.Context, path string) error {
+	return g.gitlabLib.DeleteGroup(ctx, path)
+}
+
+func (g *Gitops) GetProject(ctx context.Context, path string) (*gitops.Project, error) {
+	project, err := g.gitlabLib.GetProject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return toProject(project), nil
+}
+
+func (g *Gitops) CreateProject(ctx context.Context, name string,
+	group *gitops.Group, visibility string) (*gitops.Project, error) {
+	project, err := g.gitlabLib.CreateProject(ctx, name, group.ID, visibility)
+	if err != nil {
+		return nil, err
+	}
+	return toProject(project), nil
+}
+
+func (g *Gitops) DeleteProject(ctx context.Context, path string) error {
+	return g.gitlabLib.DeleteProject(ctx, path)
+}
+
+func (g *Gitops) TransferProject(ctx context.Context, path, groupPath string) error {
+	return g.gitlabLib.TransferProject(ctx, path, groupPath)
+}
+
+func (g *Gitops) EditNameAndPathForProject(ctx context.Context, path string, newName, newPath *string) error {
+	return g.gitlabLib.EditNameAndPathForProject(ctx, path, newName, newPath)
+}
+
+func (g *Gitops) GetBranch(ctx context.Context, path, branch string) (*gitops.Branch, error) {
+	b, err := g.gitlabLib.GetBranch(ctx, path, branch)
+	if err != nil {
+		return nil, err
+	}
+	return toBranch(b), nil
+}
+
+func (g *Gitops) CreateBranch(ctx context.Context, path, branch, fromRef string) (*gitops.Branch, error) {
+	b, err := g.gitlabLib.CreateBranch(ctx, path, branch, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	return toBranch(b), nil
+}
+
+func (g *Gitops) GetFile(ctx context.Context, path, ref, filepath string) ([]byte, error) {
+	return g.gitlabLib.GetFile(ctx, path, ref, filepath)
+}
+
+func (g *Gitops) WriteFiles(ctx context.Context, path, branch, commitMsg string,
+	startBranch *string, actions []gitops.CommitAction) (*gitops.Commit, error) {
+	gitlabActions := make([]gitlablib.CommitAction, 0, len(actions))
+	for _, action := range actions {
+		gitlabActions = append(gitlabActions, gitlablib.CommitAction{
+			Action:       gitlablib.FileAction(action.Action),
+			FilePath:     action.FilePath,
+			Content:      action.Content,
+			PreviousPath: action.PreviousPath,
+		})
+	}
+	commit, err := g.gitlabLib.WriteFiles(ctx, path, branch, commitMsg, startBranch, gitlabActions)
+	if err != nil {
+		return nil, err
+	}
+	return toCommit(commit), nil
+}
+
+func (g *Gitops) Compare(ctx context.Context, path, from, to string, straight bool) ([]*gitops.Diff, error) {
+	var straightPtr *bool
+	if straight {
+		straightPtr = &straight
+	}
+	compare, err := g.gitlabLib.Compare(ctx, path, from, to, straightPtr)
+	if err != nil {
+		return nil, err
+	}
+	diffs := make([]*gitops.Diff, 0, len(compare.Diffs))
+	for _, diff := range compare.Diffs {
+		diffs = append(diffs, &gitops.Diff{
+			OldPath:     diff.OldPath,
+			NewPath:     diff.NewPath,
+			NewFile:     diff.NewFile,
+			RenamedFile: diff.RenamedFile,
+			DeletedFile: diff.DeletedFile,
+			Diff:        diff.Diff,
+		})
+	}
+	return diffs, nil
+}
+
+func (g *Gitops) MergeBranch(ctx context.Context, path, source, target, commitMsg string) (string, error) {
+	removeSourceBranch := false
+
+	var mr *gitlab.MergeRequest
+	mrs, err := g.gitlabLib.ListMRs(ctx, path, source, target, common.GitopsMergeRequestStateOpen)
+	if err != nil {
+		return "", perror.WithMessage(err, "failed to list merge requests")
+	}
+	if len(mrs) > 0 {
+		// merge old mr when it is existed, because given specified source and target, gitlab only allows 1 mr to exist
+		mr = mrs[0]
+
+		// close the redundant mrs
+		// gitlab has a bug for when concurrency create merge request(will exist 2 more merge request for the same
+		// (source,target), caused we can't merge anymore)
+		if len(mrs) >= 2 {
+			log.Warningf(ctx, "there %d mrs for (src:%s, des:%s), here will kill redundant mrs",
+				len(mrs), source, target)
+			for i := 1; i < len(mrs); i++ {
+				_, err := g.gitlabLib.CloseMR(ctx, path, mrs[i].IID)
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+	} else {
+		// create new mr
+		mr, err = g.gitlabLib.CreateMR(ctx, path, source, target, commitMsg)
+		if err != nil {
+			return "", perror.WithMessage(err, "failed to create new merge request")
+		}
+	}
+
+	mr, err = g.gitlabLib.AcceptMR(ctx, path, mr.IID, &commitMsg, &removeSourceBranch)
+	if err != nil {
+		return "", perror.WithMessage(err, "failed to accept merge request")
+	}
+	return mr.MergeCommitSHA, nil
+}
+
+func (g *Gitops) GetRepoURL(ctx context.Context, path string) string {
+	return fmt.Sprintf("%v/%v.git", g.gitlabLib.GetHTTPURL(ctx), path)
+}
+
+func toGroup(group *gitlab.Group) *gitops.Group {
+	return &gitops.Group{
+		ID:       group.ID,
+		Name:     group.Name,
+		Path:     group.Path,
+		FullPath: group.FullPath,
+	}
+}
+
+func toProject(project *gitlab.Project) *gitops.Project {
+	return &gitops.Project{
+		ID:            project.ID,
+		Name:          project.Name,
+		Path:          project.Path,
+		FullPath:      project.PathWithNamespace,
+		DefaultBranch: project.DefaultBranch,
+	}
+}
+
+func toCommit(commit *gitlab.Commit) *gitops.Commit {
+	if commit == nil {
+		return nil
+	}
+	return &gitops.Commit{
+		ID:      commit.ID,
+		Message: commit.Message,
+	}
+}
+
+func toBranch(branch *gitlab.Branch) *gitops.Branch {
+	return &gitops.Branch{
+		Name:   branch.Name,
+		Commit: toCommit(branch.Commit),
+	}
+}
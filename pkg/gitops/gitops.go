@@ -0,0 +1,114 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import (
+	"context"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/config/gitlab"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+)
+
+// Interface is a provider-neutral storage for gitops repos.
+// Groups and projects are addressed by their full path such as first/second/third,
+// and every implementation must return a HorizonErrNotFound error for missing resources.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mock/pkg/gitops/mock_gitops.go -package=mock_gitops
+type Interface interface {
+	// GetGroup gets a group with the given full path.
+	GetGroup(ctx context.Context, path string) (*Group, error)
+
+	// CreateGroup creates a group with the given name and path.
+	// The parent is alternative, if you specify the parent, it will create a subgroup of this parent.
+	CreateGroup(ctx context.Context, name, path string, parent *Group, visibility string) (*Group, error)
+
+	// GetCreatedGroup gets the subgroup of parent with the given name, creates it if not exists.
+	GetCreatedGroup(ctx context.Context, parent *Group, name, visibility string) (*Group, error)
+
+	// DeleteGroup deletes a group with the given full path, including the projects under it.
+	DeleteGroup(ctx context.Context, path string) error
+
+	// GetProject gets a project with the given full path.
+	GetProject(ctx context.Context, path string) (*Project, error)
+
+	// CreateProject creates a project under the specified group.
+	// The project is initialized with a commit on its default branch.
+	CreateProject(ctx context.Context, name string, group *Group, visibility string) (*Project, error)
+
+	// DeleteProject deletes a project with the given full path.
+	DeleteProject(ctx context.Context, path string) error
+
+	// TransferProject transfers a project to the group with the given full path.
+	TransferProject(ctx context.Context, path, groupPath string) error
+
+	// EditNameAndPathForProject updates name and path for a specified project.
+	EditNameAndPathForProject(ctx context.Context, path string, newName, newPath *string) error
+
+	// GetBranch gets a branch of the specified project.
+	GetBranch(ctx context.Context, path, branch string) (*Branch, error)
+
+	// CreateBranch creates a branch from fromRef for the specified project.
+	CreateBranch(ctx context.Context, path, branch, fromRef string) (*Branch, error)
+
+	// GetFile gets the content of filepath in the specified project with the ref.
+	// The ref can be the name of branch, tag or commit.
+	GetFile(ctx context.Context, path, ref, filepath string) ([]byte, error)
+
+	// WriteFiles commits multiple file actions to branch of the specified project.
+	// If branch does not exist, it is created from startBranch.
+	WriteFiles(ctx context.Context, path, branch, commitMsg string,
+		startBranch *string, actions []CommitAction) (*Commit, error)
+
+	// Compare returns the file diffs between from and to.
+	// If straight is false, the diffs are computed from the merge base of from and to.
+	Compare(ctx context.Context, path, from, to string, straight bool) ([]*Diff, error)
+
+	// MergeBranch merges source into target with a merge commit and returns the commit ID of target.
+	MergeBranch(ctx context.Context, path, source, target, commitMsg string) (string, error)
+
+	// GetRepoURL returns the URL that CD tools use to clone the specified project.
+	GetRepoURL(ctx context.Context, path string) string
+}
+
+type Constructor func(ctx context.Context, config *gitlab.GitopsRepoConfig) (Interface, error)
+
+var factory = make(map[string]Constructor)
+
+func Register(kind string, constructor Constructor) {
+	factory[kind] = constructor
+}
+
+func New(ctx context.Context, config *gitlab.GitopsRepoConfig) (Interface, error) {
+	for kind, constructor := range factory {
+		if kind == config.Kind {
+			return constructor(ctx, config)
+		}
+	}
+	return nil, perror.Wrapf(herrors.ErrParamInvalid,
+		"gitops repo initializes failed, kind = %v is not implement", config.Kind)
+}
+
+// GetRootGroup gets the root group of gitops repos, creates it if not exists.
+func GetRootGroup(ctx context.Context, gitops Interface, path, visibility string) (*Group, error) {
+	group, err := gitops.GetGroup(ctx, path)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return nil, err
+		}
+		return gitops.CreateGroup(ctx, path, path, nil, visibility)
+	}
+	return group, nil
+}
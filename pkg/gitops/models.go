@@ -0,0 +1,68 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+type Group struct {
+	ID       int
+	Name     string
+	Path     string
+	FullPath string
+}
+
+type Project struct {
+	ID            int
+	Name          string
+	Path          string
+	FullPath      string
+	DefaultBranch string
+}
+
+type Commit struct {
+	ID      string
+	Message string
+}
+
+type Branch struct {
+	Name   string
+	Commit *Commit
+}
+
+// Diff is the change of a single file between two revisions.
+type Diff struct {
+	OldPath     string
+	NewPath     string
+	NewFile     bool
+	RenamedFile bool
+	DeletedFile bool
+	Diff        string
+}
+
+type FileAction string
+
+// The available file actions.
+const (
+	FileCreate FileAction = "create"
+	FileUpdate FileAction = "update"
+	FileDelete FileAction = "delete"
+	FileMove   FileAction = "move"
+)
+
+// CommitAction represents a single file action within a commit.
+type CommitAction struct {
+	Action       FileAction
+	FilePath     string
+	Content      string
+	PreviousPath string
+}
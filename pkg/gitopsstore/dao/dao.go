@@ -0,0 +1,245 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/gitopsstore/models"
+)
+
+type DAO interface {
+	CreateRepo(ctx context.Context, repo *models.Repo, commit *models.Commit,
+		blobs []*models.Blob) (*models.Repo, error)
+	GetRepoByPath(ctx context.Context, path string) (*models.Repo, error)
+	GetRepoByID(ctx context.Context, id uint) (*models.Repo, error)
+	ListReposByPathPrefix(ctx context.Context, prefix string) ([]*models.Repo, error)
+	UpdateRepoPath(ctx context.Context, id uint, path string) error
+	DeleteRepo(ctx context.Context, id uint) error
+	GetRef(ctx context.Context, repoID uint, name string) (*models.Ref, error)
+	CreateCommit(ctx context.Context, commit *models.Commit, blobs []*models.Blob,
+		refName, oldSHA string) error
+	GetCommit(ctx context.Context, repoID uint, sha string) (*models.Commit, error)
+	GetBlobs(ctx context.Context, shas []string) ([]*models.Blob, error)
+	ListUnmirroredRefs(ctx context.Context, idThan uint, limit int) ([]*models.Ref, error)
+	UpdateRefMirroredSHA(ctx context.Context, id uint, sha string) error
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) CreateRepo(ctx context.Context, repo *models.Repo, commit *models.Commit,
+	blobs []*models.Blob) (*models.Repo, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(repo).Error; err != nil {
+			return herrors.NewErrInsertFailed(herrors.GitopsRepoInDB, err.Error())
+		}
+		commit.RepoID = repo.ID
+		return createCommit(tx, commit, blobs, repo.DefaultBranch, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (d *dao) GetRepoByPath(ctx context.Context, path string) (*models.Repo, error) {
+	var repo models.Repo
+	if err := d.db.WithContext(ctx).Where("path = ?", path).First(&repo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.GitopsRepoInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.GitopsRepoInDB, err.Error())
+	}
+	return &repo, nil
+}
+
+func (d *dao) GetRepoByID(ctx context.Context, id uint) (*models.Repo, error) {
+	var repo models.Repo
+	if err := d.db.WithContext(ctx).First(&repo, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.GitopsRepoInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.GitopsRepoInDB, err.Error())
+	}
+	return &repo, nil
+}
+
+func (d *dao) ListReposByPathPrefix(ctx context.Context, prefix string) ([]*models.Repo, error) {
+	var repos []*models.Repo
+	if err := d.db.WithContext(ctx).Where("path LIKE ?", prefix+"/%").
+		Order("id asc").Find(&repos).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.GitopsRepoInDB, err.Error())
+	}
+	return repos, nil
+}
+
+func (d *dao) UpdateRepoPath(ctx context.Context, id uint, path string) error {
+	if err := d.db.WithContext(ctx).Model(&models.Repo{}).Where("id = ?", id).
+		Update("path", path).Error; err != nil {
+		return herrors.NewErrUpdateFailed(herrors.GitopsRepoInDB, err.Error())
+	}
+	return nil
+}
+
+// DeleteRepo deletes the repo with its refs and commits, the blobs are shared and kept
+func (d *dao) DeleteRepo(ctx context.Context, id uint) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_id = ?", id).Delete(&models.Ref{}).Error; err != nil {
+			return herrors.NewErrDeleteFailed(herrors.GitopsRefInDB, err.Error())
+		}
+		if err := tx.Where("repo_id = ?", id).Delete(&models.Commit{}).Error; err != nil {
+			return herrors.NewErrDeleteFailed(herrors.GitopsCommitInDB, err.Error())
+		}
+		if err := tx.Delete(&models.Repo{}, id).Error; err != nil {
+			return herrors.NewErrDeleteFailed(herrors.GitopsRepoInDB, err.Error())
+		}
+		return nil
+	})
+}
+
+func (d *dao) GetRef(ctx context.Context, repoID uint, name string) (*models.Ref, error) {
+	var ref models.Ref
+	if err := d.db.WithContext(ctx).Where("repo_id = ? AND name = ?", repoID, name).
+		First(&ref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.GitopsRefInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.GitopsRefInDB, err.Error())
+	}
+	return &ref, nil
+}
+
+func (d *dao) CreateCommit(ctx context.Context, commit *models.Commit, blobs []*models.Blob,
+	refName, oldSHA string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createCommit(tx, commit, blobs, refName, oldSHA)
+	})
+}
+
+// createCommit saves the commit with its new blobs and moves the ref from oldSHA to the commit,
+// the ref is created when oldSHA is empty
+func createCommit(tx *gorm.DB, commit *models.Commit, blobs []*models.Blob,
+	refName, oldSHA string) error {
+	if len(blobs) > 0 {
+		shas := make([]string, 0, len(blobs))
+		for _, blob := range blobs {
+			shas = append(shas, blob.SHA)
+		}
+		var existed []string
+		if err := tx.Model(&models.Blob{}).Where("sha IN ?", shas).
+			Pluck("sha", &existed).Error; err != nil {
+			return herrors.NewErrGetFailed(herrors.GitopsBlobInDB, err.Error())
+		}
+		existedSet := make(map[string]struct{}, len(existed))
+		for _, sha := range existed {
+			existedSet[sha] = struct{}{}
+		}
+		missing := make([]*models.Blob, 0, len(blobs))
+		for _, blob := range blobs {
+			if _, ok := existedSet[blob.SHA]; !ok {
+				missing = append(missing, blob)
+				existedSet[blob.SHA] = struct{}{}
+			}
+		}
+		if len(missing) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(missing).Error; err != nil {
+				return herrors.NewErrInsertFailed(herrors.GitopsBlobInDB, err.Error())
+			}
+		}
+	}
+
+	var count int64
+	if err := tx.Model(&models.Commit{}).Where("repo_id = ? AND sha = ?", commit.RepoID, commit.SHA).
+		Count(&count).Error; err != nil {
+		return herrors.NewErrGetFailed(herrors.GitopsCommitInDB, err.Error())
+	}
+	if count == 0 {
+		if err := tx.Create(commit).Error; err != nil {
+			return herrors.NewErrInsertFailed(herrors.GitopsCommitInDB, err.Error())
+		}
+	}
+
+	if oldSHA == "" {
+		ref := &models.Ref{RepoID: commit.RepoID, Name: refName, CommitSHA: commit.SHA}
+		if err := tx.Create(ref).Error; err != nil {
+			return herrors.NewErrInsertFailed(herrors.GitopsRefInDB, err.Error())
+		}
+		return nil
+	}
+	result := tx.Model(&models.Ref{}).
+		Where("repo_id = ? AND name = ? AND commit_sha = ?", commit.RepoID, refName, oldSHA).
+		Update("commit_sha", commit.SHA)
+	if result.Error != nil {
+		return herrors.NewErrUpdateFailed(herrors.GitopsRefInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return perror.Wrapf(herrors.ErrGitopsRefChanged,
+			"ref %s is no longer at %s", refName, oldSHA)
+	}
+	return nil
+}
+
+func (d *dao) GetCommit(ctx context.Context, repoID uint, sha string) (*models.Commit, error) {
+	var commit models.Commit
+	if err := d.db.WithContext(ctx).Where("repo_id = ? AND sha = ?", repoID, sha).
+		First(&commit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.GitopsCommitInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.GitopsCommitInDB, err.Error())
+	}
+	return &commit, nil
+}
+
+func (d *dao) GetBlobs(ctx context.Context, shas []string) ([]*models.Blob, error) {
+	var blobs []*models.Blob
+	if len(shas) == 0 {
+		return blobs, nil
+	}
+	if err := d.db.WithContext(ctx).Where("sha IN ?", shas).Find(&blobs).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.GitopsBlobInDB, err.Error())
+	}
+	return blobs, nil
+}
+
+func (d *dao) ListUnmirroredRefs(ctx context.Context, idThan uint, limit int) ([]*models.Ref, error) {
+	var refs []*models.Ref
+	if err := d.db.WithContext(ctx).Where("id > ? AND commit_sha <> mirrored_sha", idThan).
+		Order("id asc").Limit(limit).Find(&refs).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.GitopsRefInDB, err.Error())
+	}
+	return refs, nil
+}
+
+func (d *dao) UpdateRefMirroredSHA(ctx context.Context, id uint, sha string) error {
+	if err := d.db.WithContext(ctx).Model(&models.Ref{}).Where("id = ?", id).
+		Update("mirrored_sha", sha).Error; err != nil {
+		return herrors.NewErrUpdateFailed(herrors.GitopsRefInDB, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/pkg/gitopsstore/dao"
+	"github.com/horizoncd/horizon/pkg/gitopsstore/models"
+)
+
+type Manager interface {
+	// CreateRepo creates a repo with its initial commit on the default branch
+	CreateRepo(ctx context.Context, repo *models.Repo, commit *models.Commit,
+		blobs []*models.Blob) (*models.Repo, error)
+	// GetRepoByPath gets a repo by its full path
+	GetRepoByPath(ctx context.Context, path string) (*models.Repo, error)
+	// GetRepoByID gets a repo by ID
+	GetRepoByID(ctx context.Context, id uint) (*models.Repo, error)
+	// ListReposByPathPrefix lists the repos under the group path
+	ListReposByPathPrefix(ctx context.Context, prefix string) ([]*models.Repo, error)
+	// UpdateRepoPath moves a repo to another path
+	UpdateRepoPath(ctx context.Context, id uint, path string) error
+	// DeleteRepo deletes a repo with its refs and commits
+	DeleteRepo(ctx context.Context, id uint) error
+	// GetRef gets a branch of the repo
+	GetRef(ctx context.Context, repoID uint, name string) (*models.Ref, error)
+	// CreateCommit saves the commit and its blobs, then moves the ref from oldSHA to the commit.
+	// The ref is created if oldSHA is empty, ErrGitopsRefChanged is returned if the ref is not at oldSHA.
+	CreateCommit(ctx context.Context, commit *models.Commit, blobs []*models.Blob,
+		refName, oldSHA string) error
+	// GetCommit gets a commit of the repo by sha
+	GetCommit(ctx context.Context, repoID uint, sha string) (*models.Commit, error)
+	// GetBlobs gets the blobs by shas
+	GetBlobs(ctx context.Context, shas []string) ([]*models.Blob, error)
+	// ListUnmirroredRefs lists the refs whose commit has not been pushed to the mirror
+	ListUnmirroredRefs(ctx context.Context, idThan uint, limit int) ([]*models.Ref, error)
+	// UpdateRefMirroredSHA records the commit pushed to the mirror
+	UpdateRefMirroredSHA(ctx context.Context, id uint, sha string) error
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) CreateRepo(ctx context.Context, repo *models.Repo, commit *models.Commit,
+	blobs []*models.Blob) (*models.Repo, error) {
+	return m.dao.CreateRepo(ctx, repo, commit, blobs)
+}
+
+func (m *manager) GetRepoByPath(ctx context.Context, path string) (*models.Repo, error) {
+	return m.dao.GetRepoByPath(ctx, path)
+}
+
+func (m *manager) GetRepoByID(ctx context.Context, id uint) (*models.Repo, error) {
+	return m.dao.GetRepoByID(ctx, id)
+}
+
+func (m *manager) ListReposByPathPrefix(ctx context.Context, prefix string) ([]*models.Repo, error) {
+	return m.dao.ListReposByPathPrefix(ctx, prefix)
+}
+
+func (m *manager) UpdateRepoPath(ctx context.Context, id uint, path string) error {
+	return m.dao.UpdateRepoPath(ctx, id, path)
+}
+
+func (m *manager) DeleteRepo(ctx context.Context, id uint) error {
+	return m.dao.DeleteRepo(ctx, id)
+}
+
+func (m *manager) GetRef(ctx context.Context, repoID uint, name string) (*models.Ref, error) {
+	return m.dao.GetRef(ctx, repoID, name)
+}
+
+func (m *manager) CreateCommit(ctx context.Context, commit *models.Commit, blobs []*models.Blob,
+	refName, oldSHA string) error {
+	return m.dao.CreateCommit(ctx, commit, blobs, refName, oldSHA)
+}
+
+func (m *manager) GetCommit(ctx context.Context, repoID uint, sha string) (*models.Commit, error) {
+	return m.dao.GetCommit(ctx, repoID, sha)
+}
+
+func (m *manager) GetBlobs(ctx context.Context, shas []string) ([]*models.Blob, error) {
+	return m.dao.GetBlobs(ctx, shas)
+}
+
+func (m *manager) ListUnmirroredRefs(ctx context.Context, idThan uint, limit int) ([]*models.Ref, error) {
+	return m.dao.ListUnmirroredRefs(ctx, idThan, limit)
+}
+
+func (m *manager) UpdateRefMirroredSHA(ctx context.Context, id uint, sha string) error {
+	return m.dao.UpdateRefMirroredSHA(ctx, id, sha)
+}
@@ -0,0 +1,103 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Repo is a gitops repo stored in database
+type Repo struct {
+	ID            uint
+	Path          string
+	DefaultBranch string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Repo) TableName() string {
+	return "tb_gitops_repo"
+}
+
+// Ref is a branch of a repo
+type Ref struct {
+	ID     uint
+	RepoID uint
+	Name   string
+	// CommitSHA is the commit the ref points to
+	CommitSHA string
+	// MirroredSHA is the commit last pushed to the mirror, empty if never mirrored
+	MirroredSHA string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (Ref) TableName() string {
+	return "tb_gitops_ref"
+}
+
+// Commit is a revision of a repo, its SHA is computed in the same way as git,
+// so that it is identical to the commit pushed to the mirror
+type Commit struct {
+	ID     uint
+	RepoID uint
+	SHA    string
+	// Tree is a json map from file path to blob sha
+	Tree string
+	// Parents are the parent commits separated by space
+	Parents string
+	// Generation is one more than the max generation of parents, used to find the merge base
+	Generation  int
+	Message     string
+	AuthorName  string
+	AuthorEmail string
+	// AuthorTime is the unix timestamp of the commit
+	AuthorTime int64
+	CreatedAt  time.Time
+}
+
+func (Commit) TableName() string {
+	return "tb_gitops_commit"
+}
+
+// Files returns the file path to blob sha of the commit
+func (c *Commit) Files() (map[string]string, error) {
+	files := make(map[string]string)
+	if c.Tree == "" {
+		return files, nil
+	}
+	if err := json.Unmarshal([]byte(c.Tree), &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (c *Commit) ParentSHAs() []string {
+	return strings.Fields(c.Parents)
+}
+
+// Blob is the content of a file, shared by all the repos
+type Blob struct {
+	ID        uint
+	SHA       string
+	Content   string
+	CreatedAt time.Time
+}
+
+func (Blob) TableName() string {
+	return "tb_gitops_blob"
+}
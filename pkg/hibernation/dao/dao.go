@@ -0,0 +1,140 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/hibernation/models"
+)
+
+type DAO interface {
+	GetSchedule(ctx context.Context, resourceType string, resourceID uint) (*models.Schedule, error)
+	UpsertSchedule(ctx context.Context, schedule *models.Schedule) (*models.Schedule, error)
+	DeleteSchedule(ctx context.Context, resourceType string, resourceID uint) error
+	ListEnabledSchedules(ctx context.Context) ([]*models.Schedule, error)
+	GetHibernation(ctx context.Context, clusterID uint) (*models.Hibernation, error)
+	UpsertHibernation(ctx context.Context, hibernation *models.Hibernation) (*models.Hibernation, error)
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) GetSchedule(ctx context.Context, resourceType string, resourceID uint) (*models.Schedule, error) {
+	var schedule models.Schedule
+	if err := d.db.WithContext(ctx).Where("resource_type = ? and resource_id = ?", resourceType, resourceID).
+		First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.HibernationScheduleInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.HibernationScheduleInDB, err.Error())
+	}
+	return &schedule, nil
+}
+
+func (d *dao) UpsertSchedule(ctx context.Context, schedule *models.Schedule) (*models.Schedule, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existed models.Schedule
+		err := tx.Where("resource_type = ? and resource_id = ?", schedule.ResourceType, schedule.ResourceID).
+			First(&existed).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return herrors.NewErrGetFailed(herrors.HibernationScheduleInDB, err.Error())
+			}
+			if err := tx.Create(schedule).Error; err != nil {
+				return herrors.NewErrInsertFailed(herrors.HibernationScheduleInDB, err.Error())
+			}
+			return nil
+		}
+		// enabled is selected explicitly, so that it can be updated to false
+		if err := tx.Model(&existed).Select("enabled", "hibernate_cron", "wake_cron", "updated_by").
+			Updates(schedule).Error; err != nil {
+			return herrors.NewErrUpdateFailed(herrors.HibernationScheduleInDB, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.GetSchedule(ctx, schedule.ResourceType, schedule.ResourceID)
+}
+
+func (d *dao) DeleteSchedule(ctx context.Context, resourceType string, resourceID uint) error {
+	result := d.db.WithContext(ctx).Where("resource_type = ? and resource_id = ?", resourceType, resourceID).
+		Delete(&models.Schedule{})
+	if result.Error != nil {
+		return herrors.NewErrDeleteFailed(herrors.HibernationScheduleInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return herrors.NewErrNotFound(herrors.HibernationScheduleInDB, "hibernation schedule not found")
+	}
+	return nil
+}
+
+func (d *dao) ListEnabledSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	var schedules []*models.Schedule
+	if err := d.db.WithContext(ctx).Where("enabled = ?", true).Order("id").
+		Find(&schedules).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.HibernationScheduleInDB, err.Error())
+	}
+	return schedules, nil
+}
+
+func (d *dao) GetHibernation(ctx context.Context, clusterID uint) (*models.Hibernation, error) {
+	var hibernation models.Hibernation
+	if err := d.db.WithContext(ctx).Where("cluster_id = ?", clusterID).
+		First(&hibernation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.ClusterHibernationInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.ClusterHibernationInDB, err.Error())
+	}
+	return &hibernation, nil
+}
+
+func (d *dao) UpsertHibernation(ctx context.Context,
+	hibernation *models.Hibernation) (*models.Hibernation, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existed models.Hibernation
+		err := tx.Where("cluster_id = ?", hibernation.ClusterID).First(&existed).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return herrors.NewErrGetFailed(herrors.ClusterHibernationInDB, err.Error())
+			}
+			if err := tx.Create(hibernation).Error; err != nil {
+				return herrors.NewErrInsertFailed(herrors.ClusterHibernationInDB, err.Error())
+			}
+			return nil
+		}
+		if err := tx.Model(&existed).Select("status", "workloads", "hibernated_at", "hibernated_by",
+			"woken_at", "woken_by").Updates(hibernation).Error; err != nil {
+			return herrors.NewErrUpdateFailed(herrors.ClusterHibernationInDB, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.GetHibernation(ctx, hibernation.ClusterID)
+}
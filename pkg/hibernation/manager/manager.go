@@ -0,0 +1,71 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/pkg/hibernation/dao"
+	"github.com/horizoncd/horizon/pkg/hibernation/models"
+)
+
+type Manager interface {
+	// GetSchedule gets the hibernation schedule of an environment or a cluster
+	GetSchedule(ctx context.Context, resourceType string, resourceID uint) (*models.Schedule, error)
+	// UpsertSchedule creates or updates the hibernation schedule of an environment or a cluster
+	UpsertSchedule(ctx context.Context, schedule *models.Schedule) (*models.Schedule, error)
+	DeleteSchedule(ctx context.Context, resourceType string, resourceID uint) error
+	ListEnabledSchedules(ctx context.Context) ([]*models.Schedule, error)
+	// GetHibernation gets the hibernation state of the cluster
+	GetHibernation(ctx context.Context, clusterID uint) (*models.Hibernation, error)
+	// UpsertHibernation saves the hibernation state of the cluster
+	UpsertHibernation(ctx context.Context, hibernation *models.Hibernation) (*models.Hibernation, error)
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) GetSchedule(ctx context.Context, resourceType string,
+	resourceID uint) (*models.Schedule, error) {
+	return m.dao.GetSchedule(ctx, resourceType, resourceID)
+}
+
+func (m *manager) UpsertSchedule(ctx context.Context, schedule *models.Schedule) (*models.Schedule, error) {
+	return m.dao.UpsertSchedule(ctx, schedule)
+}
+
+func (m *manager) DeleteSchedule(ctx context.Context, resourceType string, resourceID uint) error {
+	return m.dao.DeleteSchedule(ctx, resourceType, resourceID)
+}
+
+func (m *manager) ListEnabledSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	return m.dao.ListEnabledSchedules(ctx)
+}
+
+func (m *manager) GetHibernation(ctx context.Context, clusterID uint) (*models.Hibernation, error) {
+	return m.dao.GetHibernation(ctx, clusterID)
+}
+
+func (m *manager) UpsertHibernation(ctx context.Context,
+	hibernation *models.Hibernation) (*models.Hibernation, error) {
+	return m.dao.UpsertHibernation(ctx, hibernation)
+}
@@ -0,0 +1,83 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+const (
+	ResourceTypeEnvironment = "environments"
+	ResourceTypeCluster     = "clusters"
+)
+
+// Schedule is the cron schedules to hibernate and wake up clusters of an environment or a cluster,
+// the schedule of a cluster takes precedence over the one of its environment
+type Schedule struct {
+	global.Model
+
+	ResourceType string
+	ResourceID   uint
+	Enabled      bool
+	// HibernateCron and WakeCron are standard cron expressions,
+	// which can be prefixed with CRON_TZ=<location> to specify the time zone
+	HibernateCron string
+	WakeCron      string
+	CreatedBy     uint
+	UpdatedBy     uint
+}
+
+func (Schedule) TableName() string {
+	return "tb_hibernation_schedule"
+}
+
+type Status string
+
+const (
+	StatusHibernated Status = "hibernated"
+	// StatusHibernating means some workloads failed to be scaled to zero, hibernating again retries them
+	StatusHibernating Status = "hibernating"
+	StatusAwake       Status = "awake"
+)
+
+// Hibernation is the hibernation state of a cluster, whose workloads are scaled to zero while hibernated
+type Hibernation struct {
+	global.Model
+
+	ClusterID uint
+	Status    Status
+	// Workloads is the json of workloads with their replicas before hibernation
+	Workloads    string
+	HibernatedAt *time.Time
+	HibernatedBy uint
+	WokenAt      *time.Time
+	WokenBy      uint
+}
+
+func (Hibernation) TableName() string {
+	return "tb_cluster_hibernation"
+}
+
+// Workload is a workload scaled to zero with its replicas before hibernation
+type Workload struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Replicas  int32  `json:"replicas"`
+}
@@ -0,0 +1,110 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/hpa/models"
+)
+
+type DAO interface {
+	Create(ctx context.Context, override *models.Override) (*models.Override, error)
+	GetByID(ctx context.Context, id uint) (*models.Override, error)
+	GetActive(ctx context.Context, clusterID uint, name string) (*models.Override, error)
+	ListByClusterID(ctx context.Context, clusterID uint, activeOnly bool) ([]*models.Override, error)
+	ListExpired(ctx context.Context, now time.Time) ([]*models.Override, error)
+	UpdateReverted(ctx context.Context, id uint, revertedAt time.Time, revertedBy uint) error
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Create(ctx context.Context, override *models.Override) (*models.Override, error) {
+	if err := d.db.WithContext(ctx).Create(override).Error; err != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.HPAOverrideInDB, err.Error())
+	}
+	return override, nil
+}
+
+func (d *dao) GetByID(ctx context.Context, id uint) (*models.Override, error) {
+	var override models.Override
+	if err := d.db.WithContext(ctx).Where("id = ?", id).First(&override).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.HPAOverrideInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.HPAOverrideInDB, err.Error())
+	}
+	return &override, nil
+}
+
+func (d *dao) GetActive(ctx context.Context, clusterID uint, name string) (*models.Override, error) {
+	var override models.Override
+	if err := d.db.WithContext(ctx).Where("cluster_id = ? and name = ? and reverted_at is null",
+		clusterID, name).First(&override).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.HPAOverrideInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.HPAOverrideInDB, err.Error())
+	}
+	return &override, nil
+}
+
+func (d *dao) ListByClusterID(ctx context.Context, clusterID uint, activeOnly bool) ([]*models.Override, error) {
+	var overrides []*models.Override
+	query := d.db.WithContext(ctx).Where("cluster_id = ?", clusterID)
+	if activeOnly {
+		query = query.Where("reverted_at is null")
+	}
+	if err := query.Order("id desc").Find(&overrides).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.HPAOverrideInDB, err.Error())
+	}
+	return overrides, nil
+}
+
+func (d *dao) ListExpired(ctx context.Context, now time.Time) ([]*models.Override, error) {
+	var overrides []*models.Override
+	if err := d.db.WithContext(ctx).Where("reverted_at is null and expire_at <= ?", now).
+		Order("id").Find(&overrides).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.HPAOverrideInDB, err.Error())
+	}
+	return overrides, nil
+}
+
+func (d *dao) UpdateReverted(ctx context.Context, id uint, revertedAt time.Time, revertedBy uint) error {
+	result := d.db.WithContext(ctx).Model(&models.Override{}).
+		Where("id = ? and reverted_at is null", id).
+		Updates(map[string]interface{}{
+			"reverted_at": revertedAt,
+			"reverted_by": revertedBy,
+		})
+	if result.Error != nil {
+		return herrors.NewErrUpdateFailed(herrors.HPAOverrideInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return herrors.NewErrNotFound(herrors.HPAOverrideInDB, "active hpa override not found")
+	}
+	return nil
+}
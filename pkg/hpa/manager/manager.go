@@ -0,0 +1,71 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/pkg/hpa/dao"
+	"github.com/horizoncd/horizon/pkg/hpa/models"
+)
+
+type Manager interface {
+	Create(ctx context.Context, override *models.Override) (*models.Override, error)
+	GetByID(ctx context.Context, id uint) (*models.Override, error)
+	// GetActive gets the override of the HorizontalPodAutoscaler which has not been reverted
+	GetActive(ctx context.Context, clusterID uint, name string) (*models.Override, error)
+	// ListByClusterID lists the overrides of cluster, the latest first
+	ListByClusterID(ctx context.Context, clusterID uint, activeOnly bool) ([]*models.Override, error)
+	// ListExpired lists the active overrides which expire before now
+	ListExpired(ctx context.Context, now time.Time) ([]*models.Override, error)
+	// UpdateReverted marks the active override as reverted
+	UpdateReverted(ctx context.Context, id uint, revertedAt time.Time, revertedBy uint) error
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) Create(ctx context.Context, override *models.Override) (*models.Override, error) {
+	return m.dao.Create(ctx, override)
+}
+
+func (m *manager) GetByID(ctx context.Context, id uint) (*models.Override, error) {
+	return m.dao.GetByID(ctx, id)
+}
+
+func (m *manager) GetActive(ctx context.Context, clusterID uint, name string) (*models.Override, error) {
+	return m.dao.GetActive(ctx, clusterID, name)
+}
+
+func (m *manager) ListByClusterID(ctx context.Context, clusterID uint,
+	activeOnly bool) ([]*models.Override, error) {
+	return m.dao.ListByClusterID(ctx, clusterID, activeOnly)
+}
+
+func (m *manager) ListExpired(ctx context.Context, now time.Time) ([]*models.Override, error) {
+	return m.dao.ListExpired(ctx, now)
+}
+
+func (m *manager) UpdateReverted(ctx context.Context, id uint, revertedAt time.Time, revertedBy uint) error {
+	return m.dao.UpdateReverted(ctx, id, revertedAt, revertedBy)
+}
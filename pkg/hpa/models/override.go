@@ -0,0 +1,48 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+// Override is a temporary override of min and max replicas of a HorizontalPodAutoscaler,
+// the original replicas are restored when it expires or is reverted
+type Override struct {
+	global.Model
+
+	ClusterID uint
+	Namespace string
+	// Name is the name of HorizontalPodAutoscaler
+	Name                string
+	MinReplicas         *int32
+	MaxReplicas         int32
+	OriginalMinReplicas *int32
+	OriginalMaxReplicas int32
+	Reason              string
+	ExpireAt            time.Time
+	// RevertedAt is nil while the override is active
+	RevertedAt *time.Time
+	// RevertedBy is 0 if the override is reverted automatically after expiration
+	RevertedBy uint
+	CreatedBy  uint
+	UpdatedBy  uint
+}
+
+func (Override) TableName() string {
+	return "tb_hpa_override"
+}
@@ -24,6 +24,7 @@ import (
 	perror "github.com/horizoncd/horizon/pkg/errors"
 	"github.com/horizoncd/horizon/pkg/idp/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DAO interface {
@@ -34,6 +35,9 @@ type DAO interface {
 	GetByID(ctx context.Context, id uint) (*models.IdentityProvider, error)
 	Update(ctx context.Context, id uint, param *models.IdentityProvider) (*models.IdentityProvider, error)
 	GetByCondition(ctx context.Context, condition q.Query) (*models.IdentityProvider, error)
+	ListLDAPGrants(ctx context.Context, groupID uint) ([]*models.LDAPGrant, error)
+	UpsertLDAPGrant(ctx context.Context, grant *models.LDAPGrant) error
+	DeleteLDAPGrant(ctx context.Context, groupID, userID uint) error
 }
 
 type dao struct {
@@ -148,3 +152,29 @@ func (d *dao) Update(ctx context.Context,
 	}
 	return res, nil
 }
+
+func (d *dao) ListLDAPGrants(ctx context.Context, groupID uint) ([]*models.LDAPGrant, error) {
+	grants := make([]*models.LDAPGrant, 0)
+	if err := d.db.WithContext(ctx).Where("group_id = ?", groupID).Find(&grants).Error; err != nil {
+		return nil, herrors.NewErrGetFailed(herrors.LDAPGrantInDB, err.Error())
+	}
+	return grants, nil
+}
+
+func (d *dao) UpsertLDAPGrant(ctx context.Context, grant *models.LDAPGrant) error {
+	if err := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "group_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(grant).Error; err != nil {
+		return herrors.NewErrInsertFailed(herrors.LDAPGrantInDB, err.Error())
+	}
+	return nil
+}
+
+func (d *dao) DeleteLDAPGrant(ctx context.Context, groupID, userID uint) error {
+	if err := d.db.WithContext(ctx).Where("group_id = ? and user_id = ?", groupID, userID).
+		Delete(&models.LDAPGrant{}).Error; err != nil {
+		return herrors.NewErrDeleteFailed(herrors.LDAPGrantInDB, err.Error())
+	}
+	return nil
+}
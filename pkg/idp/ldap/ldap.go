@@ -0,0 +1,230 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/idp/models"
+	"github.com/horizoncd/horizon/pkg/idp/utils"
+)
+
+const (
+	dialTimeout    = 10 * time.Second
+	requestTimeout = 30 * time.Second
+
+	defaultNameAttribute        = "cn"
+	defaultEmailAttribute       = "mail"
+	defaultGroupMemberAttribute = "member"
+
+	// memberOfAttribute lists the groups of users in active directory and openldap with the memberof overlay,
+	// which is exposed as the memberOf claim for claim mappings
+	memberOfAttribute = "memberOf"
+)
+
+// Client authenticates users and lists members of groups in the ldap server
+type Client interface {
+	// Authenticate binds as the user found by the username to verify the password
+	Authenticate(ctx context.Context, username, password string) (*utils.Claims, error)
+	// ListGroupMembers lists the users in the group, nested groups are not expanded
+	ListGroupMembers(ctx context.Context, groupDN string) ([]*utils.Claims, error)
+}
+
+// NewClient connects to the ldap server for each call, since calls are rare and connections are not reusable
+// after binding as users
+func NewClient(config *models.LDAPConfig) Client {
+	c := &client{
+		config:         *config,
+		nameAttribute:  config.NameAttribute,
+		emailAttribute: config.EmailAttribute,
+		memberAttr:     config.GroupMemberAttribute,
+	}
+	if c.nameAttribute == "" {
+		c.nameAttribute = defaultNameAttribute
+	}
+	if c.emailAttribute == "" {
+		c.emailAttribute = defaultEmailAttribute
+	}
+	if c.memberAttr == "" {
+		c.memberAttr = defaultGroupMemberAttribute
+	}
+	return c
+}
+
+type client struct {
+	config         models.LDAPConfig
+	nameAttribute  string
+	emailAttribute string
+	memberAttr     string
+}
+
+func (c *client) Authenticate(ctx context.Context, username, password string) (*utils.Claims, error) {
+	// an empty password results in an unauthenticated bind, which always succeeds, ref: rfc4513#section-5.1.2
+	if username == "" || password == "" {
+		return nil, perror.Wrap(herrors.ErrLDAPInvalidCredentials, "username and password should not be empty")
+	}
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	result, err := conn.Search(goldap.NewSearchRequest(c.config.UserBaseDN,
+		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(c.config.UserFilter, goldap.EscapeFilter(username)),
+		c.userAttributes(), nil))
+	if err != nil {
+		return nil, perror.Wrapf(herrors.NewErrGetFailed(herrors.LDAPServer, err.Error()),
+			"failed to search user %s", username)
+	}
+	if len(result.Entries) != 1 {
+		return nil, perror.Wrapf(herrors.ErrLDAPInvalidCredentials,
+			"%d users found by username %s", len(result.Entries), username)
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, perror.Wrapf(herrors.ErrLDAPInvalidCredentials, "failed to bind as %s", entry.DN)
+		}
+		return nil, perror.Wrapf(herrors.NewErrGetFailed(herrors.LDAPServer, err.Error()),
+			"failed to bind as %s", entry.DN)
+	}
+	return c.toClaims(entry), nil
+}
+
+func (c *client) ListGroupMembers(ctx context.Context, groupDN string) ([]*utils.Claims, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	group, err := c.getEntry(conn, groupDN, []string{c.memberAttr})
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, herrors.NewErrNotFound(herrors.LDAPServer, fmt.Sprintf("group %s not found", groupDN))
+	}
+
+	members := make([]*utils.Claims, 0)
+	for _, memberDN := range group.GetAttributeValues(c.memberAttr) {
+		entry, err := c.getEntry(conn, memberDN, c.userAttributes())
+		if err != nil {
+			return nil, err
+		}
+		// the member may be deleted without being removed from the group
+		if entry == nil {
+			continue
+		}
+		members = append(members, c.toClaims(entry))
+	}
+	return members, nil
+}
+
+func (c *client) connect() (*goldap.Conn, error) {
+	u, err := url.Parse(c.config.URL)
+	if err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "invalid ldap url %s: %v", c.config.URL, err)
+	}
+	// nolint:gosec
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: c.config.InsecureSkipVerify,
+	}
+	conn, err := goldap.DialURL(c.config.URL, goldap.DialWithTLSConfig(tlsConfig),
+		goldap.DialWithDialer(&net.Dialer{Timeout: dialTimeout}))
+	if err != nil {
+		return nil, perror.Wrapf(herrors.NewErrGetFailed(herrors.LDAPServer, err.Error()),
+			"failed to connect to %s", c.config.URL)
+	}
+	conn.SetTimeout(requestTimeout)
+
+	if c.config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, perror.Wrapf(herrors.NewErrGetFailed(herrors.LDAPServer, err.Error()),
+				"failed to start tls with %s", c.config.URL)
+		}
+	}
+	if c.config.BindDN != "" {
+		if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+			conn.Close()
+			return nil, perror.Wrapf(herrors.NewErrGetFailed(herrors.LDAPServer, err.Error()),
+				"failed to bind as %s", c.config.BindDN)
+		}
+	}
+	return conn, nil
+}
+
+// getEntry gets the entry by dn, nil is returned if the entry does not exist
+func (c *client) getEntry(conn *goldap.Conn, dn string, attributes []string) (*goldap.Entry, error) {
+	result, err := conn.Search(goldap.NewSearchRequest(dn,
+		goldap.ScopeBaseObject, goldap.NeverDerefAliases, 1, 0, false,
+		"(objectClass=*)", attributes, nil))
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+		return nil, perror.Wrapf(herrors.NewErrGetFailed(herrors.LDAPServer, err.Error()),
+			"failed to get entry %s", dn)
+	}
+	if len(result.Entries) == 0 {
+		return nil, nil
+	}
+	return result.Entries[0], nil
+}
+
+func (c *client) userAttributes() []string {
+	attributes := []string{c.nameAttribute, c.emailAttribute, memberOfAttribute}
+	if c.config.IDAttribute != "" {
+		attributes = append(attributes, c.config.IDAttribute)
+	}
+	return attributes
+}
+
+func (c *client) toClaims(entry *goldap.Entry) *utils.Claims {
+	claims := &utils.Claims{
+		Sub:   entry.DN,
+		Name:  entry.GetAttributeValue(c.nameAttribute),
+		Email: entry.GetAttributeValue(c.emailAttribute),
+		Raw: map[string]interface{}{
+			"dn":              entry.DN,
+			memberOfAttribute: entry.GetAttributeValues(memberOfAttribute),
+		},
+	}
+	if c.config.IDAttribute != "" {
+		// binary ids like objectGUID of active directory are encoded in hex
+		if id := entry.GetRawAttributeValue(c.config.IDAttribute); len(id) > 0 {
+			claims.Sub = string(id)
+			if !utf8.Valid(id) {
+				claims.Sub = hex.EncodeToString(id)
+			}
+		}
+	}
+	return claims
+}
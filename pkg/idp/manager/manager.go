@@ -31,6 +31,11 @@ type Manager interface {
 	GetByID(ctx context.Context, id uint) (*models.IdentityProvider, error)
 	GetByCondition(ctx context.Context, condition q.Query) (*models.IdentityProvider, error)
 	Update(ctx context.Context, id uint, param *models.IdentityProvider) (*models.IdentityProvider, error)
+	// ListLDAPGrants lists the memberships of the group granted by the ldap group sync
+	ListLDAPGrants(ctx context.Context, groupID uint) ([]*models.LDAPGrant, error)
+	// UpsertLDAPGrant records the membership granted by the ldap group sync
+	UpsertLDAPGrant(ctx context.Context, grant *models.LDAPGrant) error
+	DeleteLDAPGrant(ctx context.Context, groupID, userID uint) error
 }
 
 type manager struct {
@@ -73,3 +78,15 @@ func (m *manager) Update(ctx context.Context,
 	id uint, param *models.IdentityProvider) (*models.IdentityProvider, error) {
 	return m.dao.Update(ctx, id, param)
 }
+
+func (m *manager) ListLDAPGrants(ctx context.Context, groupID uint) ([]*models.LDAPGrant, error) {
+	return m.dao.ListLDAPGrants(ctx, groupID)
+}
+
+func (m *manager) UpsertLDAPGrant(ctx context.Context, grant *models.LDAPGrant) error {
+	return m.dao.UpsertLDAPGrant(ctx, grant)
+}
+
+func (m *manager) DeleteLDAPGrant(ctx context.Context, groupID, userID uint) error {
+	return m.dao.DeleteLDAPGrant(ctx, groupID, userID)
+}
@@ -16,7 +16,9 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/horizoncd/horizon/pkg/server/global"
 )
@@ -38,6 +40,161 @@ type IdentityProvider struct {
 	Jwks                    string
 	ClientID                string
 	ClientSecret            string
+
+	// Type is oidc by default, and the endpoints above are ignored by ldap identity providers
+	Type       string
+	LDAPConfig *LDAPConfig
+
+	// ClaimMappings grant the admin flag or group roles to users by their claims at each sign-in
+	ClaimMappings ClaimMappings `gorm:"type:text"`
+}
+
+const (
+	TypeOIDC = "oidc"
+	TypeLDAP = "ldap"
+)
+
+// LDAPConfig describes how to authenticate users and sync groups with a ldap or active directory server
+type LDAPConfig struct {
+	// URL is like ldap://ldap.example.com:389 or ldaps://ldap.example.com:636
+	URL                string `json:"url"`
+	StartTLS           bool   `json:"startTLS,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	// BindDN and BindPassword are the service account to search users and groups
+	BindDN       string `json:"bindDN"`
+	BindPassword string `json:"bindPassword"`
+
+	UserBaseDN string `json:"userBaseDN"`
+	// UserFilter finds the user by the username filled in %s,
+	// such as (&(objectClass=person)(sAMAccountName=%s)) for active directory
+	UserFilter string `json:"userFilter"`
+	// IDAttribute identifies the user links, such as objectGUID or entryUUID, the dn is used if empty
+	IDAttribute    string `json:"idAttribute,omitempty"`
+	NameAttribute  string `json:"nameAttribute,omitempty"`
+	EmailAttribute string `json:"emailAttribute,omitempty"`
+
+	// GroupMemberAttribute holds the dn of members in groups, member by default
+	GroupMemberAttribute string             `json:"groupMemberAttribute,omitempty"`
+	GroupMappings        []LDAPGroupMapping `json:"groupMappings,omitempty"`
+}
+
+// LDAPGroupMapping grants the role of the horizon group to the members of the ldap group
+type LDAPGroupMapping struct {
+	GroupDN string `json:"groupDN"`
+	GroupID uint   `json:"groupID"`
+	Role    string `json:"role"`
+}
+
+// LDAPGrant records the role of the horizon group granted to the user by the ldap group sync,
+// only which is changed or revoked by the sync later
+type LDAPGrant struct {
+	ID        uint `gorm:"primarykey"`
+	GroupID   uint `gorm:"uniqueIndex:idx_group_id_user_id"`
+	UserID    uint `gorm:"uniqueIndex:idx_group_id_user_id"`
+	Role      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (LDAPGrant) TableName() string {
+	return "tb_idp_ldap_grant"
+}
+
+func (c *LDAPConfig) Scan(value interface{}) error {
+	var bts []byte
+	switch v := value.(type) {
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal LDAPConfig from value: %v", value)
+	}
+	return json.Unmarshal(bts, c)
+}
+
+func (c *LDAPConfig) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	bts, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(bts), nil
+}
+
+// ClaimMapping matches users whose claim equals the value or contains it if the claim is a list,
+// such as groups claim containing platform-admins. Matched users are granted the admin flag
+// if Admin is true, or the role of the group otherwise
+type ClaimMapping struct {
+	Claim string `json:"claim"`
+	Value string `json:"value"`
+	Admin bool   `json:"admin,omitempty"`
+	// GroupPath is the full path of the group like /team-x, which is resolved to GroupID when saved
+	GroupPath string `json:"groupPath,omitempty"`
+	GroupID   uint   `json:"groupID,omitempty"`
+	Role      string `json:"role,omitempty"`
+}
+
+// Matches reports whether the claim of the raw claims equals or contains the value
+func (m *ClaimMapping) Matches(claims map[string]interface{}) bool {
+	return matchClaim(claims[m.Claim], m.Value)
+}
+
+func matchClaim(claim interface{}, value string) bool {
+	switch v := claim.(type) {
+	case nil:
+		return false
+	case string:
+		return v == value
+	case []string:
+		for _, item := range v {
+			if item == value {
+				return true
+			}
+		}
+		return false
+	case []interface{}:
+		for _, item := range v {
+			if matchClaim(item, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(v) == value
+	}
+}
+
+type ClaimMappings []ClaimMapping
+
+func (m *ClaimMappings) Scan(value interface{}) error {
+	var bts []byte
+	switch v := value.(type) {
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal ClaimMappings from value: %v", value)
+	}
+	if len(bts) == 0 {
+		*m = nil
+		return nil
+	}
+	return json.Unmarshal(bts, m)
+}
+
+func (m ClaimMappings) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	bts, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(bts), nil
 }
 
 type TokenEndpointAuthMethod uint8
@@ -71,11 +228,15 @@ func (t *TokenEndpointAuthMethod) String() (string, error) {
 }
 
 func (t *TokenEndpointAuthMethod) Scan(value interface{}) error {
-	bts, ok := value.([]byte)
-	if !ok {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
 		return fmt.Errorf("failed to unmarshal TokenEndpointAuthMethod from value: %v", value)
 	}
-	str := string(bts)
 	switch str {
 	case ClientSecretSentAsPostStr:
 		*t = ClientSecretSentAsPost
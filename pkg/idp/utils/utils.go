@@ -30,6 +30,9 @@ type Claims struct {
 	Sub   string `json:"sub"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+
+	// Raw holds all the claims, which are matched by the claim mappings of identity providers
+	Raw map[string]interface{} `json:"-"`
 }
 
 func MakeOuath2Config(ctx context.Context, idp *models.IdentityProvider,
@@ -152,6 +155,11 @@ func HandleOIDC(ctx context.Context, idp *models.IdentityProvider,
 			"failed to parse claims:\n"+
 				"err = %v", err)
 	}
+	if err := userinfo.Claims(&claims.Raw); err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid,
+			"failed to parse claims:\n"+
+				"err = %v", err)
+	}
 
 	return &claims, nil
 }
@@ -0,0 +1,63 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditanchor
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/pkg/audit/exporter"
+	"github.com/horizoncd/horizon/pkg/audit/manager"
+	"github.com/horizoncd/horizon/pkg/config/audit"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run exports the signed head of the audit log chain periodically
+func Run(ctx context.Context, config *audit.Config, auditMgr manager.Manager, exporters []exporter.Exporter) {
+	log.Infof(ctx, "Starting exporting the head of audit logs every %v", config.AnchorInterval)
+	defer log.Infof(ctx, "Stopping exporting the head of audit logs")
+	ticker := time.NewTicker(config.AnchorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			process(ctx, config, auditMgr, exporters)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func process(ctx context.Context, config *audit.Config, auditMgr manager.Manager, exporters []exporter.Exporter) {
+	op := "job: audit anchor"
+	anchor, err := auditMgr.Anchor(ctx, config.SigningKey)
+	if err != nil {
+		log.WithFiled(ctx, "op", op).Errorf("failed to get the head of audit logs, err: %v", err.Error())
+		return
+	}
+	log.WithFiled(ctx, "op", op).Infof("head of audit logs: id %d, hash %s, signature %s",
+		anchor.LogID, anchor.Hash, anchor.Signature)
+	for _, e := range exporters {
+		if err := e.ExportAnchor(anchor); err != nil {
+			log.WithFiled(ctx, "op", op).Warningf("failed to export the head of audit logs, err: %v", err.Error())
+		}
+	}
+}
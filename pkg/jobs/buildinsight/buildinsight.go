@@ -0,0 +1,49 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinsight
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	buildinsightctl "github.com/horizoncd/horizon/core/controller/buildinsight"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/pkg/config/buildinsight"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run detects the build regressions of applications periodically
+func Run(ctx context.Context, jobConfig *buildinsight.Config, buildInsightCtl buildinsightctl.Controller) {
+	log.Infof(ctx, "Starting detecting build regressions every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping detecting build regressions")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			if err := buildInsightCtl.DetectRegressions(ctx); err != nil {
+				log.WithFiled(ctx, "op", "job: build insight").
+					Errorf("failed to detect build regressions, err: %v", err.Error())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
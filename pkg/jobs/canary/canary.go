@@ -0,0 +1,64 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	canaryctl "github.com/horizoncd/horizon/core/controller/canary"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	canarymanager "github.com/horizoncd/horizon/pkg/canary/manager"
+	"github.com/horizoncd/horizon/pkg/config/canary"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run analyzes canaries of clusters periodically
+func Run(ctx context.Context, jobConfig *canary.Config,
+	canaryMgr canarymanager.Manager, canaryCtl canaryctl.Controller) {
+	log.Infof(ctx, "Starting analyzing canaries of clusters every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping analyzing canaries of clusters")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			process(ctx, canaryMgr, canaryCtl)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func process(ctx context.Context, canaryMgr canarymanager.Manager, canaryCtl canaryctl.Controller) {
+	op := "job: canary"
+	analyses, err := canaryMgr.ListEnabledAnalyses(ctx)
+	if err != nil {
+		log.WithFiled(ctx, "op", op).
+			Errorf("failed to list canary analyses, err: %v", err.Error())
+		return
+	}
+	for _, analysis := range analyses {
+		if err := canaryCtl.AnalyzeCluster(ctx, analysis.ClusterID); err != nil {
+			log.WithFiled(ctx, "op", op).
+				Errorf("failed to analyze canary of cluster %d, err: %v", analysis.ClusterID, err.Error())
+		}
+	}
+}
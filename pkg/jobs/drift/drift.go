@@ -0,0 +1,102 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/horizoncd/horizon/core/common"
+	driftctl "github.com/horizoncd/horizon/core/controller/drift"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/lib/q"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	"github.com/horizoncd/horizon/pkg/config/drift"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run detects drift of clusters periodically
+func Run(ctx context.Context, jobConfig *drift.Config, userMgr usermanager.Manager,
+	clusterMgr clustermanager.Manager, driftCtl driftctl.Controller) {
+	// verify account
+	user, err := userMgr.GetUserByID(ctx, jobConfig.AccountID)
+	if err != nil {
+		log.Errorf(ctx, "failed to verify operator, err: %v", err.Error())
+		panic(err)
+	}
+	ctx = common.WithContext(ctx, &userauth.DefaultInfo{
+		Name:     user.Name,
+		FullName: user.FullName,
+		ID:       user.ID,
+		Email:    user.Email,
+		Admin:    user.Admin,
+	})
+
+	// start job
+	log.Infof(ctx, "Starting detecting drift of clusters every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping detecting drift of clusters")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			process(ctx, jobConfig, clusterMgr, driftCtl)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func process(ctx context.Context, jobConfig *drift.Config,
+	clusterMgr clustermanager.Manager, driftCtl driftctl.Controller) {
+	op := "job: drift"
+	query := &q.Query{
+		PageNumber: common.DefaultPageNumber,
+		PageSize:   jobConfig.BatchSize,
+		Keywords:   make(map[string]interface{}),
+	}
+	if len(jobConfig.Environments) > 0 {
+		query.Keywords[common.ClusterQueryEnvironment] = jobConfig.Environments
+	}
+	for {
+		_, clusters, err := clusterMgr.List(ctx, query)
+		if err != nil {
+			log.WithFiled(ctx, "op", op).
+				Errorf("failed to list clusters, err: %v", err.Error())
+			return
+		}
+
+		for _, cluster := range clusters {
+			if cluster.Status != common.ClusterStatusEmpty {
+				continue
+			}
+			if _, err := driftCtl.DetectClusterDrift(ctx, cluster.ID); err != nil {
+				log.WithFiled(ctx, "op", op).
+					Errorf("failed to detect drift of cluster %s, err: %v", cluster.Name, err.Error())
+			}
+		}
+		if len(clusters) < query.PageSize {
+			return
+		}
+		query.PageNumber++
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/horizoncd/horizon/core/common"
+	elevationctl "github.com/horizoncd/horizon/core/controller/elevation"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/config/elevation"
+	elevationmanager "github.com/horizoncd/horizon/pkg/elevation/manager"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run revokes the elevated roles whose elevation has expired periodically
+func Run(ctx context.Context, jobConfig *elevation.Config, userMgr usermanager.Manager,
+	elevationMgr elevationmanager.Manager, elevationCtl elevationctl.Controller) {
+	// verify account
+	user, err := userMgr.GetUserByID(ctx, jobConfig.AccountID)
+	if err != nil {
+		log.Errorf(ctx, "failed to verify operator, err: %v", err.Error())
+		panic(err)
+	}
+	ctx = common.WithContext(ctx, &userauth.DefaultInfo{
+		Name:     user.Name,
+		FullName: user.FullName,
+		ID:       user.ID,
+		Email:    user.Email,
+		Admin:    user.Admin,
+	})
+
+	// start job
+	log.Infof(ctx, "Starting revoking expired elevations automatically every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping revoking expired elevations automatically")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			process(ctx, jobConfig, elevationMgr, elevationCtl)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func process(ctx context.Context, jobConfig *elevation.Config,
+	elevationMgr elevationmanager.Manager, elevationCtl elevationctl.Controller) {
+	op := "job: elevation expiry"
+	for {
+		elevations, err := elevationMgr.ListExpired(ctx, time.Now(), jobConfig.BatchSize)
+		if err != nil {
+			log.WithFiled(ctx, "op", op).
+				Errorf("failed to list expired elevations, err: %v", err.Error())
+			return
+		}
+
+		failed := 0
+		for _, e := range elevations {
+			if err := elevationCtl.ExpireElevation(ctx, e.ID); err != nil {
+				failed++
+				log.WithFiled(ctx, "op", op).
+					Errorf("failed to revoke elevation %d, err: %v", e.ID, err.Error())
+				continue
+			}
+			log.WithFiled(ctx, "op", op).Infof("elevation %d of user %d on %s/%d has been revoked",
+				e.ID, e.UserID, e.ResourceType, e.ResourceID)
+		}
+		// stop when there are no more expired elevations, or all of the batch keep failing
+		if len(elevations) < jobConfig.BatchSize || failed == len(elevations) {
+			return
+		}
+	}
+}
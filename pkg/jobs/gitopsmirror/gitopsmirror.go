@@ -0,0 +1,74 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitopsmirror
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/pkg/config/gitlab"
+	"github.com/horizoncd/horizon/pkg/gitops/database"
+	gitopsstoremanager "github.com/horizoncd/horizon/pkg/gitopsstore/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run pushes the changed branches of database gitops repos to the mirror periodically
+func Run(ctx context.Context, jobConfig *gitlab.GitopsMirrorConfig,
+	gitopsStoreMgr gitopsstoremanager.Manager, mirror *database.Mirror) {
+	log.Infof(ctx, "Starting mirroring gitops repos every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping mirroring gitops repos")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			process(ctx, jobConfig, gitopsStoreMgr, mirror)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func process(ctx context.Context, jobConfig *gitlab.GitopsMirrorConfig,
+	gitopsStoreMgr gitopsstoremanager.Manager, mirror *database.Mirror) {
+	op := "job: gitops mirror"
+	var idThan uint
+	for {
+		refs, err := gitopsStoreMgr.ListUnmirroredRefs(ctx, idThan, jobConfig.BatchSize)
+		if err != nil {
+			log.WithFiled(ctx, "op", op).
+				Errorf("failed to list unmirrored gitops refs, err: %v", err.Error())
+			return
+		}
+
+		for _, ref := range refs {
+			if err := mirror.Sync(ctx, ref); err != nil {
+				log.WithFiled(ctx, "op", op).
+					Errorf("failed to mirror branch %s of gitops repo %d, err: %v",
+						ref.Name, ref.RepoID, err.Error())
+			}
+		}
+		if len(refs) < jobConfig.BatchSize {
+			return
+		}
+		idThan = refs[len(refs)-1].ID
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hibernation
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/horizoncd/horizon/core/common"
+	hibernationctl "github.com/horizoncd/horizon/core/controller/hibernation"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/config/hibernation"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run hibernates and wakes up clusters by their schedules periodically
+func Run(ctx context.Context, jobConfig *hibernation.Config, userMgr usermanager.Manager,
+	hibernationCtl hibernationctl.Controller) {
+	// verify account
+	user, err := userMgr.GetUserByID(ctx, jobConfig.AccountID)
+	if err != nil {
+		log.Errorf(ctx, "failed to verify operator, err: %v", err.Error())
+		panic(err)
+	}
+	ctx = common.WithContext(ctx, &userauth.DefaultInfo{
+		Name:     user.Name,
+		FullName: user.FullName,
+		ID:       user.ID,
+		Email:    user.Email,
+		Admin:    user.Admin,
+	})
+
+	// start job
+	log.Infof(ctx, "Starting running hibernation schedules every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping running hibernation schedules")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	since := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			if err := hibernationCtl.RunSchedules(ctx, since, now); err != nil {
+				log.WithFiled(ctx, "op", "job: hibernation").
+					Errorf("failed to run hibernation schedules, err: %v", err.Error())
+			}
+			since = now
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sevent
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/config/k8sevent"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	"github.com/horizoncd/horizon/pkg/eventaggregation/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+const (
+	defaultAggregationWindow    = 10 * time.Minute
+	defaultAggregationRetention = 7 * 24 * time.Hour
+)
+
+type alertKey struct {
+	clusterID uint
+	rule      string
+}
+
+// aggregator merges kubernetes events of the same reason and object within the window into aggregations,
+// and fires alerts when events occur too many times in clusters.
+// It is used by the saving goroutine of a region only, so the states of alerts are not locked
+type aggregator struct {
+	mgr        *managerparam.Manager
+	window     time.Duration
+	severities map[string]string
+	alerts     []k8sevent.AlertRule
+
+	// occurrences are the times of events matching the alert rules within their windows
+	occurrences map[alertKey][]time.Time
+	firedAt     map[alertKey]time.Time
+}
+
+func newAggregator(config k8sevent.Config, mgr *managerparam.Manager) *aggregator {
+	window := config.AggregationWindow
+	if window <= 0 {
+		window = defaultAggregationWindow
+	}
+	return &aggregator{
+		mgr:         mgr,
+		window:      window,
+		severities:  config.Severities,
+		alerts:      config.Alerts,
+		occurrences: make(map[alertKey][]time.Time),
+		firedAt:     make(map[alertKey]time.Time),
+	}
+}
+
+// aggregate counts the new occurrences of the kubernetes event, and emits the horizon event
+// only if a new aggregation is created
+func (a *aggregator) aggregate(ctx context.Context, regionID uint, event *corev1.Event,
+	horizonEvent *eventmodels.Event) error {
+	obj := event.InvolvedObject
+	clusterID := horizonEvent.ResourceID
+	latest, err := a.mgr.EventAggregationMgr.GetLatest(ctx, clusterID, event.Reason, obj.Kind, obj.Namespace, obj.Name)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); !ok {
+			return err
+		}
+		latest = nil
+	}
+
+	seen, count := eventTime(event), eventCount(event)
+	var previous int32
+	if latest != nil {
+		// events replayed by informers have been counted already
+		if seen.Before(latest.FirstSeen) {
+			return nil
+		}
+		previous = latest.Sources[string(event.UID)]
+	}
+	occurred := count - previous
+	if occurred <= 0 {
+		return nil
+	}
+
+	if latest != nil && seen.Before(latest.FirstSeen.Add(a.window)) {
+		if latest.Sources == nil {
+			latest.Sources = make(models.Sources)
+		}
+		latest.Count += occurred
+		latest.Sources[string(event.UID)] = count
+		latest.Message = event.Message
+		if seen.After(latest.LastSeen) {
+			latest.LastSeen = seen
+		}
+		if err := a.mgr.EventAggregationMgr.Update(ctx, latest); err != nil {
+			return err
+		}
+	} else {
+		severity := a.severity(event)
+		compacted := compactEvent(event)
+		compacted["severity"] = severity
+		compacted["count"] = occurred
+		extra, err := json.Marshal(compacted)
+		if err != nil {
+			return err
+		}
+		extraStr := string(extra)
+		horizonEvent.Extra = &extraStr
+		events, err := a.mgr.EventMgr.CreateEvent(ctx, horizonEvent)
+		if err != nil {
+			return err
+		}
+		if _, err := a.mgr.EventAggregationMgr.Create(ctx, &models.Aggregation{
+			ClusterID: clusterID,
+			RegionID:  regionID,
+			Reason:    event.Reason,
+			Kind:      obj.Kind,
+			Namespace: obj.Namespace,
+			Name:      obj.Name,
+			Type:      event.Type,
+			Severity:  severity,
+			Message:   event.Message,
+			Count:     occurred,
+			FirstSeen: seen,
+			LastSeen:  seen,
+			Sources:   models.Sources{string(event.UID): count},
+			EventID:   events[0].ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if clusterID != 0 {
+		a.evaluate(ctx, clusterID, event, seen, int(occurred))
+	}
+	return nil
+}
+
+// evaluate fires the alert rules matched by the event once within their windows
+func (a *aggregator) evaluate(ctx context.Context, clusterID uint, event *corev1.Event,
+	seen time.Time, occurred int) {
+	for _, rule := range a.alerts {
+		if rule.Reason != event.Reason || (rule.Kind != "" && rule.Kind != event.InvolvedObject.Kind) {
+			continue
+		}
+		key := alertKey{clusterID: clusterID, rule: rule.Name}
+		times := a.occurrences[key]
+		for i := 0; i < occurred; i++ {
+			times = append(times, seen)
+		}
+		start := 0
+		for start < len(times) && !times[start].After(seen.Add(-rule.Window)) {
+			start++
+		}
+		times = times[start:]
+		a.occurrences[key] = times
+
+		if len(times) <= rule.Threshold {
+			continue
+		}
+		if firedAt, ok := a.firedAt[key]; ok && seen.Before(firedAt.Add(rule.Window)) {
+			continue
+		}
+		a.firedAt[key] = seen
+		a.fire(ctx, clusterID, rule, event, times)
+	}
+}
+
+// fire emits the alert event, which notifies the members of cluster by webhooks
+func (a *aggregator) fire(ctx context.Context, clusterID uint, rule k8sevent.AlertRule,
+	event *corev1.Event, times []time.Time) {
+	severity := rule.Severity
+	if severity == "" {
+		severity = models.SeverityCritical
+	}
+	members, err := a.listMemberEmails(ctx, clusterID)
+	if err != nil {
+		log.Errorf(ctx, "failed to list members of cluster %d, err: %v", clusterID, err)
+	}
+	extra, err := json.Marshal(map[string]interface{}{
+		"rule":           rule.Name,
+		"reason":         rule.Reason,
+		"severity":       severity,
+		"threshold":      rule.Threshold,
+		"window":         rule.Window.String(),
+		"count":          len(times),
+		"firstSeen":      times[0],
+		"lastSeen":       times[len(times)-1],
+		"message":        event.Message,
+		"involvedObject": compactEvent(event)["involvedObject"],
+		"members":        members,
+	})
+	if err != nil {
+		log.Errorf(ctx, "failed to marshal alert of cluster %d, err: %v", clusterID, err)
+		return
+	}
+	extraStr := string(extra)
+	if _, err := a.mgr.EventMgr.CreateEvent(ctx, &eventmodels.Event{
+		EventSummary: eventmodels.EventSummary{
+			ResourceType: common.ResourceCluster,
+			ResourceID:   clusterID,
+			EventType:    eventmodels.ClusterKubernetesAlert,
+			Extra:        &extraStr,
+		},
+		ReqID: string(event.UID),
+	}); err != nil {
+		log.Errorf(ctx, "failed to create alert of cluster %d, err: %v", clusterID, err)
+	}
+}
+
+// listMemberEmails lists the emails of direct members of the cluster and its application
+func (a *aggregator) listMemberEmails(ctx context.Context, clusterID uint) ([]string, error) {
+	cluster, err := a.mgr.ClusterMgr.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	clusterMembers, err := a.mgr.MemberMgr.ListDirectMember(ctx, membermodels.TypeApplicationCluster, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	appMembers, err := a.mgr.MemberMgr.ListDirectMember(ctx, membermodels.TypeApplication, cluster.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint, 0, len(clusterMembers)+len(appMembers))
+	for _, member := range append(clusterMembers, appMembers...) {
+		if member.MemberType == membermodels.MemberUser {
+			userIDs = append(userIDs, member.MemberNameID)
+		}
+	}
+	users, err := a.mgr.UserMgr.GetUserByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	emails := make([]string, 0, len(users))
+	for _, user := range users {
+		emails = append(emails, user.Email)
+	}
+	return emails, nil
+}
+
+func (a *aggregator) severity(event *corev1.Event) string {
+	if severity, ok := a.severities[event.Reason]; ok {
+		return severity
+	}
+	if event.Type == corev1.EventTypeWarning {
+		return models.SeverityWarning
+	}
+	return models.SeverityInfo
+}
+
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return time.Now()
+}
+
+func eventCount(event *corev1.Event) int32 {
+	count := event.Count
+	if event.Series != nil && event.Series.Count > count {
+		count = event.Series.Count
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
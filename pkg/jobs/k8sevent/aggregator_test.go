@@ -0,0 +1,129 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sevent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/horizoncd/horizon/core/common"
+	"github.com/horizoncd/horizon/lib/orm"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	"github.com/horizoncd/horizon/pkg/config/k8sevent"
+	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
+	"github.com/horizoncd/horizon/pkg/eventaggregation/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+func TestAggregate(t *testing.T) {
+	db, _ := orm.NewSqliteDB("")
+	assert.Nil(t, db.AutoMigrate(&models.Aggregation{}, &eventmodels.Event{}, &clustermodels.Cluster{},
+		&membermodels.Member{}, &usermodels.User{}))
+	mgr := managerparam.InitManager(db)
+	ctx := context.Background()
+
+	assert.Nil(t, db.Create(&clustermodels.Cluster{Name: "cluster", ApplicationID: 1}).Error)
+	user, err := mgr.UserMgr.Create(ctx, &usermodels.User{Name: "alice", Email: "alice@example.com"})
+	assert.Nil(t, err)
+	_, err = mgr.MemberMgr.Create(ctx, &membermodels.Member{
+		ResourceType: membermodels.TypeApplicationCluster,
+		ResourceID:   1,
+		Role:         "owner",
+		MemberType:   membermodels.MemberUser,
+		MemberNameID: user.ID,
+	})
+	assert.Nil(t, err)
+
+	a := newAggregator(k8sevent.Config{
+		Severities: map[string]string{"OOMKilled": models.SeverityCritical},
+		Alerts: []k8sevent.AlertRule{
+			{Name: "oom", Reason: "OOMKilled", Kind: "Pod", Threshold: 3, Window: 10 * time.Minute},
+		},
+	}, mgr)
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	aggregate := func(uid string, count int32, after time.Duration) {
+		event := &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{UID: types.UID(uid), Name: "pod-1." + uid},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "pod-1"},
+			Reason:         "OOMKilled",
+			Message:        "container is killed",
+			Type:           corev1.EventTypeWarning,
+			Count:          count,
+			LastTimestamp:  metav1.NewTime(start.Add(after)),
+		}
+		horizonEvent := &eventmodels.Event{
+			EventSummary: eventmodels.EventSummary{
+				ResourceType: common.ResourceCluster,
+				ResourceID:   1,
+				EventType:    eventmodels.ClusterKubernetesEvent,
+			},
+			ReqID: uid,
+		}
+		assert.Nil(t, a.aggregate(ctx, 1, event, horizonEvent))
+	}
+	listEvents := func() []*eventmodels.Event {
+		events, err := mgr.EventMgr.ListEventsByRange(ctx, 0, 100)
+		assert.Nil(t, err)
+		return events
+	}
+
+	// repeated and replayed events are merged into one aggregation
+	aggregate("uid-1", 1, 0)
+	aggregate("uid-1", 2, time.Minute)
+	aggregate("uid-1", 2, time.Minute)
+	aggregate("uid-2", 1, 2*time.Minute)
+	aggregations, total, err := mgr.EventAggregationMgr.ListByClusterID(ctx, 1, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, int32(3), aggregations[0].Count)
+	assert.Equal(t, models.SeverityCritical, aggregations[0].Severity)
+	assert.Equal(t, start, aggregations[0].FirstSeen.Local())
+	assert.Equal(t, start.Add(2*time.Minute), aggregations[0].LastSeen.Local())
+	assert.Equal(t, 1, len(listEvents()))
+
+	// fired once if more than 3 times in 10 minutes
+	aggregate("uid-2", 2, 3*time.Minute)
+	aggregate("uid-2", 3, 4*time.Minute)
+	events := listEvents()
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, eventmodels.ClusterKubernetesAlert, events[1].EventType)
+	extra := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal([]byte(*events[1].Extra), &extra))
+	assert.Equal(t, "oom", extra["rule"])
+	assert.Equal(t, float64(4), extra["count"])
+	assert.Equal(t, []interface{}{"alice@example.com"}, extra["members"])
+
+	// a new aggregation is created out of the window, and replayed events before are skipped
+	aggregate("uid-2", 4, 11*time.Minute)
+	aggregate("uid-1", 2, time.Minute)
+	aggregations, total, err = mgr.EventAggregationMgr.ListByClusterID(ctx, 1, models.SeverityCritical, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, int32(1), aggregations[0].Count)
+	assert.Equal(t, int32(5), aggregations[1].Count)
+	events = listEvents()
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, eventmodels.ClusterKubernetesEvent, events[2].EventType)
+}
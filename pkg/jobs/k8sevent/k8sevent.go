@@ -22,19 +22,15 @@ import (
 	"sync"
 	"time"
 
-	"gorm.io/gorm"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/horizoncd/horizon/core/common"
-	"github.com/horizoncd/horizon/lib/q"
 	"github.com/horizoncd/horizon/pkg/config/k8sevent"
-	eventmanager "github.com/horizoncd/horizon/pkg/event/manager"
 	eventmodels "github.com/horizoncd/horizon/pkg/event/models"
 	"github.com/horizoncd/horizon/pkg/param/managerparam"
 	"github.com/horizoncd/horizon/pkg/region/models"
@@ -45,6 +41,7 @@ import (
 const (
 	cacheMax                   = 160
 	savingInterval             = 10 * time.Second
+	pruningInterval            = time.Hour
 	kubernetesInstanceLabelKey = "app.kubernetes.io/instance"
 )
 
@@ -55,20 +52,20 @@ var gvrEvent = schema.GroupVersionResource{
 }
 
 type SuperVisor struct {
+	config    k8sevent.Config
 	filter    *gvkFilter
 	informers *regioninformers.RegionInformers
 	mgr       *managerparam.Manager
-	db        *gorm.DB
 	cacheMax  int
 }
 
 func New(config k8sevent.Config, informers *regioninformers.RegionInformers,
-	mgr *managerparam.Manager, db *gorm.DB) *SuperVisor {
+	mgr *managerparam.Manager) *SuperVisor {
 	v := &SuperVisor{
+		config:    config,
 		filter:    newGVKFilter(config),
 		informers: informers,
 		mgr:       mgr,
-		db:        db,
 		cacheMax:  cacheMax,
 	}
 
@@ -77,10 +74,35 @@ func New(config k8sevent.Config, informers *regioninformers.RegionInformers,
 
 func (v *SuperVisor) Run(ctx context.Context) {
 	v.informers.Register(regioninformers.Resource{GVR: gvrEvent, MakeHandler: v.newEventHandler})
+	go v.prune(ctx)
+}
+
+// prune deletes the aggregations which have not been seen for the retention
+func (v *SuperVisor) prune(ctx context.Context) {
+	retention := v.config.AggregationRetention
+	if retention <= 0 {
+		retention = defaultAggregationRetention
+	}
+	ticker := time.NewTicker(pruningInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := v.mgr.EventAggregationMgr.DeleteLastSeenBefore(ctx, time.Now().Add(-retention))
+			if err != nil {
+				log.Errorf(ctx, "failed to prune event aggregations: %v", err)
+				continue
+			}
+			log.Infof(ctx, "%d event aggregations have been pruned", deleted)
+		}
+	}
 }
 
 type EventWithTime struct {
 	*eventmodels.Event
+	Source        *corev1.Event
 	LastTimestamp time.Time
 }
 
@@ -99,6 +121,7 @@ func (v *SuperVisor) newEventHandler(regionID uint, stopCh <-chan struct{}) (cac
 		eventCh:    make(chan *corev1.Event),
 		cacheMax:   v.cacheMax,
 		stopCh:     stopCh,
+		aggregator: newAggregator(v.config, v.mgr),
 	}
 
 	go func() {
@@ -140,7 +163,8 @@ type eventHandler struct {
 
 	stopCh <-chan struct{}
 
-	eventCh chan *corev1.Event
+	eventCh    chan *corev1.Event
+	aggregator *aggregator
 }
 
 func (e *eventHandler) save(cache []*corev1.Event) error {
@@ -153,8 +177,6 @@ func (e *eventHandler) save(cache []*corev1.Event) error {
 	ctx := context.Background()
 	eventsWithTime := make([]*EventWithTime, len(cache))
 
-	reqIDs := sync.Map{}
-
 	wg := sync.WaitGroup{}
 	for i, event := range cache {
 		wg.Add(1)
@@ -165,23 +187,7 @@ func (e *eventHandler) save(cache []*corev1.Event) error {
 				log.Errorf(ctx, "failed to map event: %v", err)
 				return
 			}
-
-			// skip same reqID
-			if _, ok := reqIDs.Load(horizonEvent.ReqID); ok {
-				return
-			}
-
-			reqIDs.Store(horizonEvent.ReqID, struct{}{})
-
-			sameEvents, _ := e.mgr.EventMgr.ListEvents(ctx, &q.Query{Keywords: q.KeyWords{common.ReqID: horizonEvent.ReqID}})
-			// skip same event
-			for _, sameEvent := range sameEvents {
-				if sameEvent.Extra != nil && horizonEvent.Extra != nil &&
-					*sameEvent.Extra == *horizonEvent.Extra {
-					return
-				}
-			}
-			dst[index] = &EventWithTime{horizonEvent, event.LastTimestamp.Time}
+			dst[index] = &EventWithTime{horizonEvent, event, eventTime(event)}
 		}(i, event, eventsWithTime)
 	}
 	wg.Wait()
@@ -193,39 +199,19 @@ func (e *eventHandler) save(cache []*corev1.Event) error {
 		}
 	}
 
-	if len(eventsWithTime) == 0 {
-		return nil
-	}
-
-	sort.Slice(eventsWithTime, func(i, j int) bool {
+	// events are aggregated in order, since the same kubernetes event may be updated several times in the cache
+	sort.SliceStable(eventsWithTime, func(i, j int) bool {
 		return eventsWithTime[i].LastTimestamp.Before(eventsWithTime[j].LastTimestamp)
 	})
-
-	tx := e.db.WithContext(ctx).Begin()
-	txEventManager := eventmanager.New(tx)
-
-	events := make([]*eventmodels.Event, 0, len(eventsWithTime))
 	for _, event := range eventsWithTime {
-		events = append(events, event.Event)
-	}
-
-	_, err := txEventManager.CreateEvent(ctx, events...)
-	if err != nil {
-		log.Errorf(ctx, "failed to save regionEvents: %v", err)
-		tx.Rollback()
-		return nil
-	}
-
-	err = tx.Commit().Error
-	if err != nil {
-		log.Errorf(ctx, "failed to commit: %v", err)
-		tx.Rollback()
-		return nil
+		if err := e.aggregator.aggregate(ctx, e.regionID, event.Source, event.Event); err != nil {
+			log.Errorf(ctx, "failed to aggregate event %s: %v", event.Source.UID, err)
+		}
 	}
 	return nil
 }
 
-func (*eventHandler) compactEvent(event *corev1.Event) map[string]interface{} {
+func compactEvent(event *corev1.Event) map[string]interface{} {
 	m := make(map[string]interface{})
 
 	m["message"] = event.Message
@@ -251,19 +237,13 @@ var GVKApplication = schema.GroupVersionKind{
 
 func (e *eventHandler) mapEvent(event *corev1.Event) (*eventmodels.Event, error) {
 	if event == nil {
-		return nil, nil
-	}
-	extra, err := json.Marshal(e.compactEvent(event))
-	if err != nil {
-		log.Errorf(context.Background(), "failed to marshal event: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("event is nil")
 	}
-	extraStr := string(extra)
+	// extra is filled when the event is aggregated
 	horizonEvent := &eventmodels.Event{
 		EventSummary: eventmodels.EventSummary{
 			ResourceType: common.ResourceCluster,
 			EventType:    eventmodels.ClusterKubernetesEvent,
-			Extra:        &extraStr,
 		},
 		ReqID: string(event.UID),
 	}
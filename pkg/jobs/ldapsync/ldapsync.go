@@ -0,0 +1,67 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldapsync
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/horizoncd/horizon/core/common"
+	idpctl "github.com/horizoncd/horizon/core/controller/idp"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	"github.com/horizoncd/horizon/pkg/config/ldapsync"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run syncs members of ldap groups into horizon groups periodically
+func Run(ctx context.Context, jobConfig *ldapsync.Config, userMgr usermanager.Manager, idpCtl idpctl.Controller) {
+	// verify account
+	user, err := userMgr.GetUserByID(ctx, jobConfig.AccountID)
+	if err != nil {
+		log.Errorf(ctx, "failed to verify operator, err: %v", err.Error())
+		panic(err)
+	}
+	ctx = common.WithContext(ctx, &userauth.DefaultInfo{
+		Name:     user.Name,
+		FullName: user.FullName,
+		ID:       user.ID,
+		Email:    user.Email,
+		Admin:    user.Admin,
+	})
+
+	// start job
+	log.Infof(ctx, "Starting syncing ldap groups every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping syncing ldap groups")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			if err := idpCtl.SyncLDAPGroups(ctx); err != nil {
+				log.WithFiled(ctx, "op", "job: ldap sync").
+					Errorf("failed to sync ldap groups, err: %v", err.Error())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package releaseplan
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	releaseplanctl "github.com/horizoncd/horizon/core/controller/releaseplan"
+	"github.com/horizoncd/horizon/core/middleware/requestid"
+	"github.com/horizoncd/horizon/pkg/config/releaseplan"
+	releaseplanmanager "github.com/horizoncd/horizon/pkg/releaseplan/manager"
+	"github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Run progresses the running release plans periodically
+func Run(ctx context.Context, jobConfig *releaseplan.Config,
+	releasePlanMgr releaseplanmanager.Manager, releasePlanCtl releaseplanctl.Controller) {
+	log.Infof(ctx, "Starting progressing release plans every %v", jobConfig.JobInterval)
+	defer log.Infof(ctx, "Stopping progressing release plans")
+	ticker := time.NewTicker(jobConfig.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rid := uuid.NewV4().String()
+			// nolint
+			ctx = context.WithValue(ctx, requestid.HeaderXRequestID, rid)
+			process(ctx, jobConfig, releasePlanMgr, releasePlanCtl)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func process(ctx context.Context, jobConfig *releaseplan.Config,
+	releasePlanMgr releaseplanmanager.Manager, releasePlanCtl releaseplanctl.Controller) {
+	op := "job: release plan"
+	var idThan uint
+	for {
+		plans, err := releasePlanMgr.ListRunning(ctx, idThan, jobConfig.BatchSize)
+		if err != nil {
+			log.WithFiled(ctx, "op", op).
+				Errorf("failed to list running release plans, err: %v", err.Error())
+			return
+		}
+
+		for _, plan := range plans {
+			if err := releasePlanCtl.ProgressReleasePlan(ctx, plan.ID); err != nil {
+				log.WithFiled(ctx, "op", op).
+					Errorf("failed to progress release plan %d, err: %v", plan.ID, err.Error())
+			}
+		}
+		if len(plans) < jobConfig.BatchSize {
+			return
+		}
+		idThan = plans[len(plans)-1].ID
+	}
+}
@@ -22,10 +22,13 @@ import (
 
 	"github.com/horizoncd/horizon/core/common"
 	herror "github.com/horizoncd/horizon/core/errors"
+	admissionmanager "github.com/horizoncd/horizon/pkg/admission/manager"
 	applicationmanager "github.com/horizoncd/horizon/pkg/application/manager"
 	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
+	changerequestmanager "github.com/horizoncd/horizon/pkg/changerequest/manager"
 	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
 	memberctx "github.com/horizoncd/horizon/pkg/context"
+	elevationmanager "github.com/horizoncd/horizon/pkg/elevation/manager"
 	perror "github.com/horizoncd/horizon/pkg/errors"
 	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
 	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
@@ -35,6 +38,7 @@ import (
 	"github.com/horizoncd/horizon/pkg/param/managerparam"
 	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
 	roleservice "github.com/horizoncd/horizon/pkg/rbac/role"
+	releaseplanmanager "github.com/horizoncd/horizon/pkg/releaseplan/manager"
 	templatemanager "github.com/horizoncd/horizon/pkg/template/manager"
 	templatereleasemanager "github.com/horizoncd/horizon/pkg/templaterelease/manager"
 	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
@@ -75,6 +79,10 @@ type service struct {
 	oauthManager              oauthmanager.Manager
 	userManager               usermanager.Manager
 	webhookManager            webhookmanager.Manager
+	elevationManager          elevationmanager.Manager
+	admissionPolicyManager    admissionmanager.Manager
+	releasePlanManager        releaseplanmanager.Manager
+	changeRequestManager      changerequestmanager.Manager
 }
 
 func NewService(roleService roleservice.Service, oauthManager oauthmanager.Manager,
@@ -91,6 +99,10 @@ func NewService(roleService roleservice.Service, oauthManager oauthmanager.Manag
 		oauthManager:              oauthManager,
 		userManager:               manager.UserMgr,
 		webhookManager:            manager.WebhookMgr,
+		elevationManager:          manager.ElevationMgr,
+		admissionPolicyManager:    manager.AdmissionPolicyMgr,
+		releasePlanManager:        manager.ReleasePlanMgr,
+		changeRequestManager:      manager.ChangeRequestMgr,
 	}
 }
 
@@ -244,6 +256,50 @@ func (s *service) listWebhookLogMember(ctx context.Context, id uint) ([]models.M
 	return s.listWebhookMember(ctx, webhookLog.WebhookID)
 }
 
+func (s *service) listElevationMember(ctx context.Context, id uint) ([]models.Member, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	elevation, err := s.elevationManager.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListMember(ctx, elevation.ResourceType, elevation.ResourceID)
+}
+
+func (s *service) listAdmissionPolicyMember(ctx context.Context, id uint) ([]models.Member, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	policy, err := s.admissionPolicyManager.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListMember(ctx, common.ResourceGroup, policy.GroupID)
+}
+
+func (s *service) listReleasePlanMember(ctx context.Context, id uint) ([]models.Member, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	plan, err := s.releasePlanManager.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListMember(ctx, common.ResourceApplication, plan.ApplicationID)
+}
+
+func (s *service) listChangeRequestMember(ctx context.Context, id uint) ([]models.Member, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	changeRequest, err := s.changeRequestManager.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListMember(ctx, common.ResourceCluster, changeRequest.ClusterID)
+}
+
 func (s *service) GetMemberOfResource(ctx context.Context,
 	resourceType string, resourceIDStr string) (*models.Member, error) {
 	var currentUser userauth.User
@@ -371,6 +427,14 @@ func (s *service) ListMember(ctx context.Context, resourceType string, resourceI
 		allMembers, err = s.listWebhookMember(ctx, resourceID)
 	case common.ResourceWebhookLog:
 		allMembers, err = s.listWebhookLogMember(ctx, resourceID)
+	case common.ResourceElevation:
+		allMembers, err = s.listElevationMember(ctx, resourceID)
+	case common.ResourceAdmissionPolicy:
+		allMembers, err = s.listAdmissionPolicyMember(ctx, resourceID)
+	case common.ResourceReleasePlan:
+		allMembers, err = s.listReleasePlanMember(ctx, resourceID)
+	case common.ResourceChangeRequest:
+		allMembers, err = s.listChangeRequestMember(ctx, resourceID)
 	default:
 		err = errors.New("unsupported resourceType")
 	}
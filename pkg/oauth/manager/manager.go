@@ -15,6 +15,9 @@
 package manager
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"net/http"
 	"strings"
 	"time"
@@ -32,6 +35,29 @@ import (
 	"k8s.io/apimachinery/pkg/util/rand"
 )
 
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// PKCE code challenge methods, ref: rfc7636#section-4.2
+const (
+	CodeChallengeMethodPlain = "plain"
+	CodeChallengeMethodS256  = "S256"
+
+	codeVerifierMinLength = 43
+	codeVerifierMaxLength = 128
+)
+
+const (
+	// DevicePollInterval is the minimum seconds the device should wait between polling requests
+	DevicePollInterval = 5
+	// deviceCodeDenied is the state of the device code denied by the user
+	deviceCodeDenied = "denied"
+)
+
 type AuthorizeGenerateRequest struct {
 	ClientID    string
 	RedirectURL string
@@ -40,14 +66,33 @@ type AuthorizeGenerateRequest struct {
 	Scope        string
 	UserIdentify uint
 	Request      *http.Request
+
+	// CodeChallenge and CodeChallengeMethod are provided by PKCE clients
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce is provided by OpenID Connect clients
+	Nonce string
+}
+
+type DeviceAuthorizationRequest struct {
+	ClientID string
+	Scope    string
+	Request  *http.Request
 }
 
 type OauthTokensRequest struct {
+	// GrantType defaults to authorization_code
+	GrantType    string
 	ClientID     string
 	ClientSecret string
 	Code         string // authorization code
 	RefreshToken string // refresh token
 	RedirectURL  string
+	CodeVerifier string // PKCE code verifier
+	DeviceCode   string // device code
+	Scope        string // scope requested by client_credentials grant
+	// ClientUserID is the user the client acts as in client_credentials grant
+	ClientUserID uint
 
 	Request *http.Request
 
@@ -56,8 +101,18 @@ type OauthTokensRequest struct {
 }
 
 type OauthTokensResponse struct {
-	AccessToken  *tokenmodels.Token
+	AccessToken *tokenmodels.Token
+	// RefreshToken is nil for client_credentials grant
 	RefreshToken *tokenmodels.Token
+	// Nonce of the authorization request, which should be passed to the id token
+	Nonce string
+}
+
+// ClientTokenRequest is sent by the client to introspect or revoke a token, ref: rfc7662 and rfc7009
+type ClientTokenRequest struct {
+	ClientID     string
+	ClientSecret string
+	Token        string
 }
 
 type CreateOAuthAppReq struct {
@@ -87,10 +142,25 @@ type Manager interface {
 	CreateSecret(ctx context.Context, clientID string) (*models.OauthClientSecret, error)
 	DeleteSecret(ctx context.Context, ClientID string, clientSecretID uint) error
 	ListSecret(ctx context.Context, ClientID string) ([]models.OauthClientSecret, error)
+	// CheckClientSecret checks that the secret is one of the secrets of the client
+	CheckClientSecret(ctx context.Context, clientID, clientSecret string) error
 
 	GenAuthorizeCode(ctx context.Context, req *AuthorizeGenerateRequest) (*tokenmodels.Token, error)
+	// GenDeviceCode generates the device code and the user code of device authorization grant, ref: rfc8628
+	GenDeviceCode(ctx context.Context, req *DeviceAuthorizationRequest) (*tokenmodels.Token, error)
+	// GetDeviceCode gets the device code waiting for the user to authorize by the user code
+	GetDeviceCode(ctx context.Context, userCode string) (*tokenmodels.Token, error)
+	// AuthorizeDeviceCode records the decision of the user on the device code
+	AuthorizeDeviceCode(ctx context.Context, userCode string, userID uint, approved bool) error
+	// GenOauthTokens exchanges the grant for tokens, supporting authorization_code with optional PKCE,
+	// client_credentials and device_code grants
 	GenOauthTokens(ctx context.Context, req *OauthTokensRequest) (*OauthTokensResponse, error)
 	RefreshOauthTokens(ctx context.Context, req *OauthTokensRequest) (*OauthTokensResponse, error)
+	// IntrospectToken gets the active access or refresh token issued by oauth apps,
+	// nil is returned if the token is not active, ref: rfc7662
+	IntrospectToken(ctx context.Context, req *ClientTokenRequest) (*tokenmodels.Token, error)
+	// RevokeToken revokes the access or refresh token issued to the client, ref: rfc7009
+	RevokeToken(ctx context.Context, req *ClientTokenRequest) error
 }
 
 var _ Manager = &OauthManager{}
@@ -264,6 +334,10 @@ func (m *OauthManager) NewAuthorizationToken(req *AuthorizeGenerateRequest) *tok
 		ExpiresIn:   m.authorizeCodeExpireTime,
 		Scope:       req.Scope,
 		UserID:      req.UserIdentify,
+
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
 	}
 	token.Code = m.authorizationCodeGenerator.Generate(&generator.CodeGenerateInfo{
 		Token:   *token,
@@ -314,6 +388,18 @@ func (m *OauthManager) GenAuthorizeCode(ctx context.Context,
 		log.Warningf(ctx, "redirect URL not match")
 		return nil, perror.Wrapf(herrors.ErrOAuthReqNotValid, "redirect URL not match")
 	}
+	if req.CodeChallenge != "" {
+		// the method defaults to plain, ref: rfc7636#section-4.3
+		if req.CodeChallengeMethod == "" {
+			req.CodeChallengeMethod = CodeChallengeMethodPlain
+		}
+		if req.CodeChallengeMethod != CodeChallengeMethodPlain && req.CodeChallengeMethod != CodeChallengeMethodS256 {
+			return nil, perror.Wrapf(herrors.ErrOAuthReqNotValid,
+				"code challenge method %s not supported", req.CodeChallengeMethod)
+		}
+	} else if req.CodeChallengeMethod != "" {
+		return nil, perror.Wrap(herrors.ErrOAuthReqNotValid, "code challenge not exist")
+	}
 
 	authorizationToken := m.NewAuthorizationToken(req)
 	_, err = m.tokenStore.Create(ctx, authorizationToken)
@@ -321,6 +407,10 @@ func (m *OauthManager) GenAuthorizeCode(ctx context.Context,
 }
 
 func (m *OauthManager) checkByAuthorizationCode(req *OauthTokensRequest, codeToken *tokenmodels.Token) error {
+	if req.ClientID != codeToken.ClientID {
+		return perror.Wrapf(herrors.ErrOAuthReqNotValid,
+			"req client id = %s, code client id = %s", req.ClientID, codeToken.ClientID)
+	}
 	if req.RedirectURL != codeToken.RedirectURI {
 		return perror.Wrapf(herrors.ErrOAuthReqNotValid,
 			"req redirect url = %s, code redirect url = %s", req.RedirectURL, codeToken.RedirectURI)
@@ -332,12 +422,20 @@ func (m *OauthManager) checkByAuthorizationCode(req *OauthTokensRequest, codeTok
 }
 
 func (m *OauthManager) GenOauthTokens(ctx context.Context, req *OauthTokensRequest) (*OauthTokensResponse, error) {
-	// check client secret
-	err := m.checkClientSecret(ctx, req)
-	if err != nil {
-		return nil, err
+	switch req.GrantType {
+	case GrantTypeClientCredentials:
+		return m.genClientCredentialsTokens(ctx, req)
+	case GrantTypeDeviceCode:
+		return m.genDeviceCodeTokens(ctx, req)
+	case GrantTypeAuthorizationCode, "":
+		return m.genAuthorizationCodeTokens(ctx, req)
+	default:
+		return nil, perror.Wrapf(herrors.ErrOAuthReqNotValid, "grant type %s not supported", req.GrantType)
 	}
+}
 
+func (m *OauthManager) genAuthorizationCodeTokens(ctx context.Context,
+	req *OauthTokensRequest) (*OauthTokensResponse, error) {
 	// get authorize token, and check by it
 	authorizationCodeToken, err := m.tokenStore.GetByCode(ctx, req.Code)
 	if err != nil {
@@ -347,6 +445,13 @@ func (m *OauthManager) GenOauthTokens(ctx context.Context, req *OauthTokensReque
 		return nil, err
 	}
 
+	// check client secret, public clients using PKCE are verified by the code verifier instead
+	if authorizationCodeToken.CodeChallenge == "" || req.ClientSecret != "" {
+		if err := m.checkClientSecret(ctx, req.ClientID, req.ClientSecret); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := m.checkByAuthorizationCode(req, authorizationCodeToken); err != nil {
 		if perror.Cause(err) == herrors.ErrOAuthCodeExpired {
 			if delErr := m.tokenStore.DeleteByCode(ctx, req.Code); delErr != nil {
@@ -355,9 +460,28 @@ func (m *OauthManager) GenOauthTokens(ctx context.Context, req *OauthTokensReque
 		}
 		return nil, err
 	}
+	if err := checkCodeVerifier(authorizationCodeToken, req.CodeVerifier); err != nil {
+		return nil, err
+	}
+
+	tokens, err := m.genAccessAndRefreshTokens(ctx, authorizationCodeToken, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// delete authorize code
+	err = m.tokenStore.DeleteByCode(ctx, req.Code)
+	if err != nil {
+		log.Warningf(ctx, "Delete Authorization token error, code = %s, error = %v", req.Code, err)
+	}
+	return tokens, nil
+}
 
+// genAccessAndRefreshTokens generates access token and refresh token by the authorized grant token
+func (m *OauthManager) genAccessAndRefreshTokens(ctx context.Context, grantToken *tokenmodels.Token,
+	req *OauthTokensRequest) (*OauthTokensResponse, error) {
 	// generate access token and store
-	accessToken := m.NewAccessToken(authorizationCodeToken, req)
+	accessToken := m.NewAccessToken(grantToken, req)
 	accessTokenInDB, err := m.tokenStore.Create(ctx, accessToken)
 	if err != nil {
 		return nil, err
@@ -371,22 +495,165 @@ func (m *OauthManager) GenOauthTokens(ctx context.Context, req *OauthTokensReque
 		return nil, err
 	}
 
-	// delete authorize code
-	err = m.tokenStore.DeleteByCode(ctx, req.Code)
-	if err != nil {
-		log.Warningf(ctx, "Delete Authorization token error, code = %s, error = %v", req.Code, err)
-	}
-
 	return &OauthTokensResponse{
 		AccessToken:  accessTokenInDB,
 		RefreshToken: refreshTokenInDB,
+		Nonce:        grantToken.Nonce,
 	}, nil
 }
 
+// checkCodeVerifier checks the PKCE code verifier against the code challenge, ref: rfc7636#section-4.6
+func checkCodeVerifier(codeToken *tokenmodels.Token, codeVerifier string) error {
+	if codeToken.CodeChallenge == "" {
+		return nil
+	}
+	if len(codeVerifier) < codeVerifierMinLength || len(codeVerifier) > codeVerifierMaxLength {
+		return perror.Wrapf(herrors.ErrOAuthReqNotValid,
+			"code verifier length must be between %d and %d", codeVerifierMinLength, codeVerifierMaxLength)
+	}
+	challenge := codeVerifier
+	if codeToken.CodeChallengeMethod == CodeChallengeMethodS256 {
+		sum := sha256.Sum256([]byte(codeVerifier))
+		challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(codeToken.CodeChallenge)) != 1 {
+		return perror.Wrap(herrors.ErrOAuthReqNotValid, "code verifier not match")
+	}
+	return nil
+}
+
+// genClientCredentialsTokens generates the access token for the client acting on its own behalf,
+// no refresh token is issued as the client can request a new one anytime, ref: rfc6749#section-4.4
+func (m *OauthManager) genClientCredentialsTokens(ctx context.Context,
+	req *OauthTokensRequest) (*OauthTokensResponse, error) {
+	if err := m.checkClientSecret(ctx, req.ClientID, req.ClientSecret); err != nil {
+		return nil, err
+	}
+	if req.ClientUserID == 0 {
+		return nil, perror.Wrapf(herrors.ErrOAuthInternal, "user of client %s not specified", req.ClientID)
+	}
+
+	accessToken := m.NewAccessToken(&tokenmodels.Token{
+		Scope:  req.Scope,
+		UserID: req.ClientUserID,
+	}, req)
+	accessTokenInDB, err := m.tokenStore.Create(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &OauthTokensResponse{
+		AccessToken: accessTokenInDB,
+	}, nil
+}
+
+func (m *OauthManager) GenDeviceCode(ctx context.Context,
+	req *DeviceAuthorizationRequest) (*tokenmodels.Token, error) {
+	if _, err := m.oauthAppDAO.GetApp(ctx, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	token := &tokenmodels.Token{
+		ClientID:  req.ClientID,
+		CreatedAt: time.Now(),
+		ExpiresIn: m.authorizeCodeExpireTime,
+		Scope:     req.Scope,
+	}
+	info := &generator.CodeGenerateInfo{
+		Token:   *token,
+		Request: req.Request,
+	}
+	token.Code = generator.NewDeviceCodeGenerator().Generate(info)
+	token.UserCode = generator.NewUserCodeGenerator().Generate(info)
+	return m.tokenStore.Create(ctx, token)
+}
+
+func (m *OauthManager) GetDeviceCode(ctx context.Context, userCode string) (*tokenmodels.Token, error) {
+	// user codes are case-insensitive, ref: rfc8628#section-6.1
+	userCode = strings.ToUpper(strings.TrimSpace(userCode))
+	if userCode == "" {
+		return nil, perror.Wrap(herrors.ErrOAuthReqNotValid, "user code is empty")
+	}
+	token, err := m.tokenStore.GetByUserCode(ctx, userCode)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			return nil, perror.Wrap(err, "user code not exist")
+		}
+		return nil, err
+	}
+	if token.CreatedAt.Add(m.authorizeCodeExpireTime).Before(time.Now()) {
+		if delErr := m.tokenStore.DeleteByCode(ctx, token.Code); delErr != nil {
+			log.Warningf(ctx, "delete expired device code error, err = %v", delErr)
+		}
+		return nil, perror.Wrap(herrors.ErrOAuthCodeExpired, "")
+	}
+	if token.UserID != 0 || token.State == deviceCodeDenied {
+		return nil, perror.Wrap(herrors.ErrOAuthReqNotValid, "user code has been used")
+	}
+	return token, nil
+}
+
+func (m *OauthManager) AuthorizeDeviceCode(ctx context.Context, userCode string,
+	userID uint, approved bool) error {
+	token, err := m.GetDeviceCode(ctx, userCode)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return m.tokenStore.UpdateAuthorization(ctx, token.ID, 0, deviceCodeDenied)
+	}
+	return m.tokenStore.UpdateAuthorization(ctx, token.ID, userID, "")
+}
+
+// genDeviceCodeTokens exchanges the device code authorized by the user for tokens, ref: rfc8628#section-3.4
+func (m *OauthManager) genDeviceCodeTokens(ctx context.Context,
+	req *OauthTokensRequest) (*OauthTokensResponse, error) {
+	deviceCodeToken, err := m.tokenStore.GetByCode(ctx, req.DeviceCode)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			return nil, perror.Wrap(err, "device code not exist")
+		}
+		return nil, err
+	}
+	if deviceCodeToken.UserCode == "" || deviceCodeToken.ClientID != req.ClientID {
+		return nil, perror.Wrapf(herrors.ErrOAuthReqNotValid,
+			"device code not issued to client %s", req.ClientID)
+	}
+	// devices are usually public clients, so the client secret is optional
+	if req.ClientSecret != "" {
+		if err := m.checkClientSecret(ctx, req.ClientID, req.ClientSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	deleteDeviceCode := func() {
+		if delErr := m.tokenStore.DeleteByCode(ctx, req.DeviceCode); delErr != nil {
+			log.Warningf(ctx, "delete device code error, err = %v", delErr)
+		}
+	}
+	if deviceCodeToken.CreatedAt.Add(m.authorizeCodeExpireTime).Before(time.Now()) {
+		deleteDeviceCode()
+		return nil, perror.Wrap(herrors.ErrOAuthCodeExpired, "")
+	}
+	if deviceCodeToken.State == deviceCodeDenied {
+		deleteDeviceCode()
+		return nil, perror.Wrap(herrors.ErrOAuthAccessDenied, "")
+	}
+	if deviceCodeToken.UserID == 0 {
+		return nil, perror.Wrap(herrors.ErrOAuthAuthorizationPending, "")
+	}
+
+	tokens, err := m.genAccessAndRefreshTokens(ctx, deviceCodeToken, req)
+	if err != nil {
+		return nil, err
+	}
+	deleteDeviceCode()
+	return tokens, nil
+}
+
 func (m *OauthManager) RefreshOauthTokens(ctx context.Context,
 	req *OauthTokensRequest) (*OauthTokensResponse, error) {
 	// check client secret
-	err := m.checkClientSecret(ctx, req)
+	err := m.checkClientSecret(ctx, req.ClientID, req.ClientSecret)
 	if err != nil {
 		return nil, err
 	}
@@ -419,18 +686,89 @@ func (m *OauthManager) RefreshOauthTokens(ctx context.Context,
 	}, nil
 }
 
-func (m *OauthManager) checkClientSecret(ctx context.Context, req *OauthTokensRequest) error {
-	secrets, err := m.oauthAppDAO.ListSecret(ctx, req.ClientID)
+func (m *OauthManager) CheckClientSecret(ctx context.Context, clientID, clientSecret string) error {
+	return m.checkClientSecret(ctx, clientID, clientSecret)
+}
+
+func (m *OauthManager) checkClientSecret(ctx context.Context, clientID, clientSecret string) error {
+	secrets, err := m.oauthAppDAO.ListSecret(ctx, clientID)
 	if err != nil {
 		return err
 	}
 	for _, secret := range secrets {
-		if secret.ClientSecret == req.ClientSecret {
+		if secret.ClientSecret == clientSecret {
 			return nil
 		}
 	}
 	return perror.Wrapf(herrors.ErrOAuthSecretNotValid,
-		"clientId = %s, secret = %s", req.ClientID, req.ClientSecret)
+		"clientId = %s, secret = %s", clientID, clientSecret)
+}
+
+// getClientToken gets the access or refresh token issued by oauth apps,
+// nil is returned if the token does not exist, or is an authorization code, device code or personal token
+func (m *OauthManager) getClientToken(ctx context.Context, code string) (*tokenmodels.Token, error) {
+	isClientToken := false
+	for _, prefix := range []string{
+		generator.HorizonAppUserToServerAccessTokenPrefix,
+		generator.OauthAPPAccessTokenPrefix,
+		generator.ClientCredentialsAccessTokenPrefix,
+		generator.RefreshTokenPrefix,
+	} {
+		if strings.HasPrefix(code, prefix) {
+			isClientToken = true
+			break
+		}
+	}
+	if !isClientToken {
+		return nil, nil
+	}
+	token, err := m.tokenStore.GetByCode(ctx, code)
+	if err != nil {
+		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if token.ClientID == "" {
+		return nil, nil
+	}
+	return token, nil
+}
+
+func (m *OauthManager) IntrospectToken(ctx context.Context, req *ClientTokenRequest) (*tokenmodels.Token, error) {
+	if err := m.checkClientSecret(ctx, req.ClientID, req.ClientSecret); err != nil {
+		return nil, err
+	}
+	token, err := m.getClientToken(ctx, req.Token)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	if token.ExpiresIn > 0 && token.CreatedAt.Add(token.ExpiresIn).Before(time.Now()) {
+		return nil, nil
+	}
+	return token, nil
+}
+
+func (m *OauthManager) RevokeToken(ctx context.Context, req *ClientTokenRequest) error {
+	if err := m.checkClientSecret(ctx, req.ClientID, req.ClientSecret); err != nil {
+		return err
+	}
+	// invalid tokens do not cause an error, since the purpose of the client is already achieved
+	token, err := m.getClientToken(ctx, req.Token)
+	if err != nil || token == nil {
+		return err
+	}
+	if token.ClientID != req.ClientID {
+		return perror.Wrapf(herrors.ErrOAuthReqNotValid,
+			"token is not issued to client %s", req.ClientID)
+	}
+	// the access token is invalidated together with the refresh token
+	if strings.HasPrefix(token.Code, generator.RefreshTokenPrefix) && token.RefID != 0 {
+		if err := m.tokenStore.DeleteByID(ctx, token.RefID); err != nil {
+			return err
+		}
+	}
+	return m.tokenStore.DeleteByID(ctx, token.ID)
 }
 
 func (m *OauthManager) checkRefreshToken(ctx context.Context,
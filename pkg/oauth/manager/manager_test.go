@@ -15,8 +15,11 @@
 package manager
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -513,6 +516,247 @@ func TestUpdateToken(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func createAppWithSecret(t *testing.T) (*models.OauthApp, *models.OauthClientSecret) {
+	oauthApp, err := oauthManager.CreateOauthApp(ctx, &CreateOAuthAppReq{
+		Name:        "OauthTest",
+		RedirectURI: "https://example.com/oauth/redirect",
+		HomeURL:     "https://example.com",
+		OwnerType:   models.GroupOwnerType,
+		OwnerID:     1,
+		APPType:     models.DirectOAuthAPP,
+	})
+	assert.Nil(t, err)
+	secret, err := oauthManager.CreateSecret(ctx, oauthApp.ClientID)
+	assert.Nil(t, err)
+	return oauthApp, secret
+}
+
+func TestOauthPKCE(t *testing.T) {
+	oauthApp, _ := createAppWithSecret(t)
+	defer func() {
+		assert.Nil(t, oauthManager.DeleteOAuthApp(ctx, oauthApp.ClientID))
+	}()
+
+	// unsupported method
+	_, err := oauthManager.GenAuthorizeCode(ctx, &AuthorizeGenerateRequest{
+		ClientID:            oauthApp.ClientID,
+		RedirectURL:         oauthApp.RedirectURL,
+		UserIdentify:        43,
+		CodeChallenge:       "challenge",
+		CodeChallengeMethod: "S512",
+	})
+	assert.Equal(t, herrors.ErrOAuthReqNotValid, perror.Cause(err))
+
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	codeToken, err := oauthManager.GenAuthorizeCode(ctx, &AuthorizeGenerateRequest{
+		ClientID:            oauthApp.ClientID,
+		RedirectURL:         oauthApp.RedirectURL,
+		UserIdentify:        43,
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: CodeChallengeMethodS256,
+	})
+	assert.Nil(t, err)
+
+	// public client without secret, but the verifier not match
+	req := &OauthTokensRequest{
+		GrantType:             GrantTypeAuthorizationCode,
+		ClientID:              oauthApp.ClientID,
+		Code:                  codeToken.Code,
+		RedirectURL:           oauthApp.RedirectURL,
+		CodeVerifier:          verifier[1:] + "x",
+		AccessTokenGenerator:  generator.NewOauthAccessGenerator(),
+		RefreshTokenGenerator: generator.NewRefreshTokenGenerator(),
+	}
+	_, err = oauthManager.GenOauthTokens(ctx, req)
+	assert.Equal(t, herrors.ErrOAuthReqNotValid, perror.Cause(err))
+
+	// the code is issued to another client
+	anotherClient := *req
+	anotherClient.ClientID = "another"
+	anotherClient.CodeVerifier = verifier
+	_, err = oauthManager.GenOauthTokens(ctx, &anotherClient)
+	assert.Equal(t, herrors.ErrOAuthReqNotValid, perror.Cause(err))
+
+	req.CodeVerifier = verifier
+	tokens, err := oauthManager.GenOauthTokens(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(43), tokens.AccessToken.UserID)
+	assert.NotNil(t, tokens.RefreshToken)
+
+	// the code can only be used once
+	_, err = oauthManager.GenOauthTokens(ctx, req)
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+}
+
+func TestOauthClientCredentials(t *testing.T) {
+	oauthApp, secret := createAppWithSecret(t)
+	defer func() {
+		assert.Nil(t, oauthManager.DeleteOAuthApp(ctx, oauthApp.ClientID))
+	}()
+
+	req := &OauthTokensRequest{
+		GrantType:            GrantTypeClientCredentials,
+		ClientID:             oauthApp.ClientID,
+		ClientSecret:         "err-secret",
+		Scope:                "applications:read-only",
+		ClientUserID:         100,
+		AccessTokenGenerator: generator.NewClientCredentialsAccessGenerator(),
+	}
+	_, err := oauthManager.GenOauthTokens(ctx, req)
+	assert.Equal(t, herrors.ErrOAuthSecretNotValid, perror.Cause(err))
+	err = oauthManager.CheckClientSecret(ctx, req.ClientID, req.ClientSecret)
+	assert.Equal(t, herrors.ErrOAuthSecretNotValid, perror.Cause(err))
+
+	req.ClientSecret = secret.ClientSecret
+	assert.Nil(t, oauthManager.CheckClientSecret(ctx, req.ClientID, req.ClientSecret))
+	tokens, err := oauthManager.GenOauthTokens(ctx, req)
+	assert.Nil(t, err)
+	assert.Nil(t, tokens.RefreshToken)
+	assert.True(t, strings.HasPrefix(tokens.AccessToken.Code, generator.ClientCredentialsAccessTokenPrefix))
+	assert.Equal(t, uint(100), tokens.AccessToken.UserID)
+	assert.Equal(t, req.Scope, tokens.AccessToken.Scope)
+	assert.Equal(t, accessTokenExpireIn, tokens.AccessToken.ExpiresIn)
+}
+
+func TestOauthDeviceCode(t *testing.T) {
+	oauthApp, _ := createAppWithSecret(t)
+	defer func() {
+		assert.Nil(t, oauthManager.DeleteOAuthApp(ctx, oauthApp.ClientID))
+	}()
+
+	_, err := oauthManager.GenDeviceCode(ctx, &DeviceAuthorizationRequest{ClientID: "not-exist"})
+	_, ok := perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	deviceToken, err := oauthManager.GenDeviceCode(ctx, &DeviceAuthorizationRequest{
+		ClientID: oauthApp.ClientID,
+		Scope:    "applications:read-write",
+	})
+	assert.Nil(t, err)
+	assert.Regexp(t, "^[A-Z]{4}-[A-Z]{4}$", deviceToken.UserCode)
+
+	req := &OauthTokensRequest{
+		GrantType:             GrantTypeDeviceCode,
+		ClientID:              oauthApp.ClientID,
+		DeviceCode:            deviceToken.Code,
+		AccessTokenGenerator:  generator.NewOauthAccessGenerator(),
+		RefreshTokenGenerator: generator.NewRefreshTokenGenerator(),
+	}
+	_, err = oauthManager.GenOauthTokens(ctx, req)
+	assert.Equal(t, herrors.ErrOAuthAuthorizationPending, perror.Cause(err))
+
+	// user codes are case-insensitive
+	got, err := oauthManager.GetDeviceCode(ctx, strings.ToLower(deviceToken.UserCode))
+	assert.Nil(t, err)
+	assert.Equal(t, deviceToken.Scope, got.Scope)
+	_, err = oauthManager.GetDeviceCode(ctx, "")
+	assert.Equal(t, herrors.ErrOAuthReqNotValid, perror.Cause(err))
+
+	assert.Nil(t, oauthManager.AuthorizeDeviceCode(ctx, deviceToken.UserCode, 43, true))
+	// the user code can only be used once
+	err = oauthManager.AuthorizeDeviceCode(ctx, deviceToken.UserCode, 44, true)
+	assert.Equal(t, herrors.ErrOAuthReqNotValid, perror.Cause(err))
+
+	tokens, err := oauthManager.GenOauthTokens(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(43), tokens.AccessToken.UserID)
+	assert.Equal(t, deviceToken.Scope, tokens.AccessToken.Scope)
+	assert.Equal(t, tokens.AccessToken.ID, tokens.RefreshToken.RefID)
+	_, err = oauthManager.GenOauthTokens(ctx, req)
+	_, ok = perror.Cause(err).(*herrors.HorizonErrNotFound)
+	assert.True(t, ok)
+
+	// denied by the user
+	deviceToken, err = oauthManager.GenDeviceCode(ctx, &DeviceAuthorizationRequest{ClientID: oauthApp.ClientID})
+	assert.Nil(t, err)
+	assert.Nil(t, oauthManager.AuthorizeDeviceCode(ctx, deviceToken.UserCode, 43, false))
+	req.DeviceCode = deviceToken.Code
+	_, err = oauthManager.GenOauthTokens(ctx, req)
+	assert.Equal(t, herrors.ErrOAuthAccessDenied, perror.Cause(err))
+
+	// expired
+	deviceToken, err = oauthManager.GenDeviceCode(ctx, &DeviceAuthorizationRequest{ClientID: oauthApp.ClientID})
+	assert.Nil(t, err)
+	time.Sleep(authorizeCodeExpireIn)
+	req.DeviceCode = deviceToken.Code
+	_, err = oauthManager.GenOauthTokens(ctx, req)
+	assert.Equal(t, herrors.ErrOAuthCodeExpired, perror.Cause(err))
+}
+
+func TestIntrospectAndRevokeToken(t *testing.T) {
+	oauthApp, secret := createAppWithSecret(t)
+	defer func() {
+		assert.Nil(t, oauthManager.DeleteOAuthApp(ctx, oauthApp.ClientID))
+	}()
+
+	deviceToken, err := oauthManager.GenDeviceCode(ctx, &DeviceAuthorizationRequest{
+		ClientID: oauthApp.ClientID,
+		Scope:    "applications:read-only",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, oauthManager.AuthorizeDeviceCode(ctx, deviceToken.UserCode, 43, true))
+	tokens, err := oauthManager.GenOauthTokens(ctx, &OauthTokensRequest{
+		GrantType:             GrantTypeDeviceCode,
+		ClientID:              oauthApp.ClientID,
+		DeviceCode:            deviceToken.Code,
+		AccessTokenGenerator:  generator.NewOauthAccessGenerator(),
+		RefreshTokenGenerator: generator.NewRefreshTokenGenerator(),
+	})
+	assert.Nil(t, err)
+
+	req := &ClientTokenRequest{
+		ClientID:     oauthApp.ClientID,
+		ClientSecret: "err-secret",
+		Token:        tokens.AccessToken.Code,
+	}
+	_, err = oauthManager.IntrospectToken(ctx, req)
+	assert.Equal(t, herrors.ErrOAuthSecretNotValid, perror.Cause(err))
+	assert.Equal(t, herrors.ErrOAuthSecretNotValid, perror.Cause(oauthManager.RevokeToken(ctx, req)))
+
+	req.ClientSecret = secret.ClientSecret
+	token, err := oauthManager.IntrospectToken(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, tokens.AccessToken.ID, token.ID)
+	assert.Equal(t, uint(43), token.UserID)
+
+	// unknown tokens and tokens not issued by oauth apps are not active
+	for _, code := range []string{"ho_not_exist", deviceToken.Code, "ha_personal"} {
+		token, err = oauthManager.IntrospectToken(ctx, &ClientTokenRequest{
+			ClientID:     oauthApp.ClientID,
+			ClientSecret: secret.ClientSecret,
+			Token:        code,
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, token)
+	}
+
+	// tokens can only be revoked by the client they are issued to
+	anotherApp, anotherSecret := createAppWithSecret(t)
+	defer func() {
+		assert.Nil(t, oauthManager.DeleteOAuthApp(ctx, anotherApp.ClientID))
+	}()
+	err = oauthManager.RevokeToken(ctx, &ClientTokenRequest{
+		ClientID:     anotherApp.ClientID,
+		ClientSecret: anotherSecret.ClientSecret,
+		Token:        tokens.RefreshToken.Code,
+	})
+	assert.Equal(t, herrors.ErrOAuthReqNotValid, perror.Cause(err))
+
+	// revoking the refresh token revokes the access token too
+	req.Token = tokens.RefreshToken.Code
+	assert.Nil(t, oauthManager.RevokeToken(ctx, req))
+	for _, code := range []string{tokens.RefreshToken.Code, tokens.AccessToken.Code} {
+		req.Token = code
+		token, err = oauthManager.IntrospectToken(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, token)
+		// revoking again does not cause an error
+		assert.Nil(t, oauthManager.RevokeToken(ctx, req))
+	}
+}
+
 func TestMain(m *testing.M) {
 	db, _ = orm.NewSqliteDB("")
 	if err := db.AutoMigrate(&tokenmodels.Token{}, &models.OauthApp{}, &models.OauthClientSecret{}); err != nil {
@@ -0,0 +1,175 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/horizoncd/horizon/pkg/config/oauth"
+)
+
+// JSONWebKey is the public part of a signing key, ref: rfc7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	// RSA public key
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// ECDSA public key
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// KeySet signs the id tokens by the first key, and publishes all the keys
+type KeySet struct {
+	keys []*signingKey
+}
+
+func NewKeySet(keys []oauth.SigningKey) (*KeySet, error) {
+	keySet := &KeySet{}
+	for _, key := range keys {
+		content, err := ioutil.ReadFile(key.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key %s: %v", key.KeyID, err)
+		}
+		k, err := parseSigningKey(key.KeyID, content)
+		if err != nil {
+			return nil, err
+		}
+		keySet.keys = append(keySet.keys, k)
+	}
+	return keySet, nil
+}
+
+func parseSigningKey(id string, content []byte) (*signingKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not in pem format", id)
+	}
+	var (
+		private interface{}
+		err     error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %v", id, err)
+	}
+
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, private: k}, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("signing key %s must be on curve P-256 for ES256", id)
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodES256, private: k}, nil
+	default:
+		return nil, fmt.Errorf("signing key %s must be a RSA or ECDSA key", id)
+	}
+}
+
+func (s *KeySet) Empty() bool {
+	return len(s.keys) == 0
+}
+
+// Sign signs the claims by the first key
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if s.Empty() {
+		return "", fmt.Errorf("no signing key")
+	}
+	key := s.keys[0]
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// Algorithms returns the distinct algorithms of the keys
+func (s *KeySet) Algorithms() []string {
+	algorithms := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, key := range s.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algorithms = append(algorithms, alg)
+		}
+	}
+	return algorithms
+}
+
+// JWKS returns the public keys to verify the id tokens
+func (s *KeySet) JWKS() *JSONWebKeySet {
+	keySet := &JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := JSONWebKey{
+			Use:       "sig",
+			KeyID:     key.id,
+			Algorithm: key.method.Alg(),
+		}
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = encodeBigInt(public.N, 0)
+			jwk.E = encodeBigInt(big.NewInt(int64(public.E)), 0)
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = public.Curve.Params().Name
+			jwk.X = encodeBigInt(public.X, size)
+			jwk.Y = encodeBigInt(public.Y, size)
+		}
+		keySet.Keys = append(keySet.Keys, jwk)
+	}
+	return keySet
+}
+
+// encodeBigInt encodes the integer in base64url, left padded with zeros to size bytes
+func encodeBigInt(n *big.Int, size int) string {
+	b := n.Bytes()
+	if len(b) < size {
+		b = append(make([]byte, size-len(b)), b...)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
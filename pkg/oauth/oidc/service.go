@@ -0,0 +1,230 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/config/oauth"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	groupservice "github.com/horizoncd/horizon/pkg/group/service"
+	membermanager "github.com/horizoncd/horizon/pkg/member/manager"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+// standard scopes of OpenID Connect, and groups scope for the groups claim
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopeGroups  = "groups"
+)
+
+// Scopes are the scopes supported by the provider
+var Scopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopeGroups}
+
+// Claims are the claims supported by the provider
+var Claims = []string{"iss", "sub", "aud", "exp", "iat", "nonce", "azp",
+	"name", "preferred_username", "email", "groups"}
+
+// UserClaims are the claims of the user released by scopes
+type UserClaims struct {
+	// Name and PreferredUsername are released by profile scope
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	// Email is released by email scope
+	Email string `json:"email,omitempty"`
+	// Groups are full paths of the groups the user is a member of, released by groups scope
+	Groups []string `json:"groups,omitempty"`
+}
+
+// UserInfo is the response of userinfo endpoint
+type UserInfo struct {
+	Subject string `json:"sub"`
+	UserClaims
+}
+
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string `json:"nonce,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+	UserClaims
+}
+
+type IDTokenRequest struct {
+	ClientID string
+	UserID   uint
+	Scope    string
+	Nonce    string
+}
+
+type Service interface {
+	// Enabled tells whether any signing key is configured
+	Enabled() bool
+	Issuer() string
+	// Algorithms returns the algorithms signing the id tokens
+	Algorithms() []string
+	JWKS() *JSONWebKeySet
+	// GenIDToken generates the id token if the scope contains openid, otherwise returns empty
+	GenIDToken(ctx context.Context, req *IDTokenRequest) (string, error)
+	// GetUserInfo gets the claims of the user released by the scope
+	GetUserInfo(ctx context.Context, userID uint, scope string) (*UserInfo, error)
+}
+
+type service struct {
+	config    oauth.OIDCProvider
+	keySet    *KeySet
+	userMgr   usermanager.Manager
+	memberMgr membermanager.Manager
+	groupSvc  groupservice.Service
+}
+
+var _ Service = (*service)(nil)
+
+func NewService(config oauth.OIDCProvider, manager *managerparam.Manager) (Service, error) {
+	keySet, err := NewKeySet(config.SigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &service{
+		config:    config,
+		keySet:    keySet,
+		userMgr:   manager.UserMgr,
+		memberMgr: manager.MemberMgr,
+		groupSvc:  groupservice.NewService(manager),
+	}, nil
+}
+
+func (s *service) Enabled() bool {
+	return !s.keySet.Empty()
+}
+
+func (s *service) Issuer() string {
+	return strings.TrimSuffix(s.config.Issuer, "/")
+}
+
+func (s *service) Algorithms() []string {
+	return s.keySet.Algorithms()
+}
+
+func (s *service) JWKS() *JSONWebKeySet {
+	return s.keySet.JWKS()
+}
+
+func (s *service) GenIDToken(ctx context.Context, req *IDTokenRequest) (string, error) {
+	scopes := strings.Fields(req.Scope)
+	if !s.Enabled() || !containsScope(scopes, ScopeOpenID) {
+		return "", nil
+	}
+	userClaims, err := s.getUserClaims(ctx, req.UserID, scopes)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return s.keySet.Sign(&IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.Issuer(),
+			Subject:   strconv.Itoa(int(req.UserID)),
+			Audience:  jwt.ClaimStrings{req.ClientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.IDTokenExpireIn)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Nonce:           req.Nonce,
+		AuthorizedParty: req.ClientID,
+		UserClaims:      *userClaims,
+	})
+}
+
+func (s *service) GetUserInfo(ctx context.Context, userID uint, scope string) (*UserInfo, error) {
+	scopes := strings.Fields(scope)
+	if !containsScope(scopes, ScopeOpenID) {
+		return nil, perror.Wrap(herrors.ErrForbidden, "openid scope is required for userinfo")
+	}
+	userClaims, err := s.getUserClaims(ctx, userID, scopes)
+	if err != nil {
+		return nil, err
+	}
+	return &UserInfo{
+		Subject:    strconv.Itoa(int(userID)),
+		UserClaims: *userClaims,
+	}, nil
+}
+
+func (s *service) getUserClaims(ctx context.Context, userID uint, scopes []string) (*UserClaims, error) {
+	user, err := s.userMgr.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Banned {
+		return nil, perror.Wrapf(herrors.ErrForbidden, "user %d is banned", userID)
+	}
+
+	claims := &UserClaims{}
+	if containsScope(scopes, ScopeProfile) {
+		claims.Name = user.FullName
+		claims.PreferredUsername = user.Name
+	}
+	if containsScope(scopes, ScopeEmail) {
+		claims.Email = user.Email
+	}
+	if containsScope(scopes, ScopeGroups) {
+		claims.Groups, err = s.getGroups(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// getGroups returns the full paths of the groups the user is a direct member of
+func (s *service) getGroups(ctx context.Context, user *usermodels.User) ([]string, error) {
+	groupIDs, err := s.memberMgr.ListResourceOfMemberInfo(ctx, membermodels.TypeGroup, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]string, 0, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return groups, nil
+	}
+	children, err := s.groupSvc.GetChildrenByIDs(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		groups = append(groups, child.FullPath)
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
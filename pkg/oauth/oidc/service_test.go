@@ -0,0 +1,163 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/orm"
+	"github.com/horizoncd/horizon/pkg/config/oauth"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
+	membermodels "github.com/horizoncd/horizon/pkg/member/models"
+	"github.com/horizoncd/horizon/pkg/param/managerparam"
+	usermodels "github.com/horizoncd/horizon/pkg/user/models"
+)
+
+func writeKeys(t *testing.T, dir string) []oauth.SigningKey {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	rsaFile := filepath.Join(dir, "rsa.pem")
+	assert.Nil(t, ioutil.WriteFile(rsaFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+	}), 0600))
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	ecBytes, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	assert.Nil(t, err)
+	ecFile := filepath.Join(dir, "ec.pem")
+	assert.Nil(t, ioutil.WriteFile(ecFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: ecBytes,
+	}), 0600))
+
+	return []oauth.SigningKey{
+		{KeyID: "new", PrivateKeyFile: rsaFile},
+		{KeyID: "old", PrivateKeyFile: ecFile},
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, _ := orm.NewSqliteDB("")
+	assert.Nil(t, db.AutoMigrate(&usermodels.User{}, &groupmodels.Group{}, &membermodels.Member{}))
+	manager := managerparam.InitManager(db)
+
+	dir, err := ioutil.TempDir("", "oidc")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// disabled without signing keys
+	s, err := NewService(oauth.OIDCProvider{}, manager)
+	assert.Nil(t, err)
+	assert.False(t, s.Enabled())
+	idToken, err := s.GenIDToken(ctx, &IDTokenRequest{ClientID: "client", UserID: 1, Scope: ScopeOpenID})
+	assert.Nil(t, err)
+	assert.Empty(t, idToken)
+
+	s, err = NewService(oauth.OIDCProvider{
+		Issuer:          "https://horizon.example.com/",
+		IDTokenExpireIn: 3600e9,
+		SigningKeys:     writeKeys(t, dir),
+	}, manager)
+	assert.Nil(t, err)
+	assert.True(t, s.Enabled())
+	assert.Equal(t, "https://horizon.example.com", s.Issuer())
+	assert.Equal(t, []string{"RS256", "ES256"}, s.Algorithms())
+
+	user := &usermodels.User{Name: "tony", FullName: "Tony", Email: "tony@example.com"}
+	assert.Nil(t, db.Create(user).Error)
+	group := &groupmodels.Group{Name: "horizon", Path: "horizon"}
+	assert.Nil(t, db.Create(group).Error)
+	assert.Nil(t, db.Model(group).Update("traversal_ids", strconv.Itoa(int(group.ID))).Error)
+	assert.Nil(t, db.Create(&membermodels.Member{
+		ResourceType: membermodels.TypeGroup,
+		ResourceID:   group.ID,
+		Role:         "owner",
+		MemberType:   membermodels.MemberUser,
+		MemberNameID: user.ID,
+	}).Error)
+
+	// no id token without openid scope
+	idToken, err = s.GenIDToken(ctx, &IDTokenRequest{ClientID: "client", UserID: user.ID, Scope: ScopeProfile})
+	assert.Nil(t, err)
+	assert.Empty(t, idToken)
+
+	idToken, err = s.GenIDToken(ctx, &IDTokenRequest{
+		ClientID: "client",
+		UserID:   user.ID,
+		Scope:    "openid profile groups",
+		Nonce:    "n-0S6_WzA2Mj",
+	})
+	assert.Nil(t, err)
+
+	// the id token is verified by the published key
+	jwks := s.JWKS()
+	assert.Equal(t, 2, len(jwks.Keys))
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "EC", jwks.Keys[1].KeyType)
+	assert.Equal(t, "P-256", jwks.Keys[1].Curve)
+	claims := &IDTokenClaims{}
+	token, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		for _, key := range jwks.Keys {
+			if key.KeyID == token.Header["kid"] {
+				n, _ := base64.RawURLEncoding.DecodeString(key.N)
+				e, _ := base64.RawURLEncoding.DecodeString(key.E)
+				return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+			}
+		}
+		return nil, jwt.ErrTokenUnverifiable
+	})
+	assert.Nil(t, err)
+	assert.True(t, token.Valid)
+	assert.Equal(t, "new", token.Header["kid"])
+	assert.Equal(t, "https://horizon.example.com", claims.Issuer)
+	assert.Equal(t, strconv.Itoa(int(user.ID)), claims.Subject)
+	assert.Equal(t, jwt.ClaimStrings{"client"}, claims.Audience)
+	assert.Equal(t, "n-0S6_WzA2Mj", claims.Nonce)
+	assert.Equal(t, "Tony", claims.Name)
+	assert.Equal(t, "tony", claims.PreferredUsername)
+	assert.Empty(t, claims.Email)
+	assert.Equal(t, []string{"/horizon"}, claims.Groups)
+
+	userInfo, err := s.GetUserInfo(ctx, user.ID, "openid email")
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(int(user.ID)), userInfo.Subject)
+	assert.Equal(t, "tony@example.com", userInfo.Email)
+	assert.Empty(t, userInfo.Name)
+	assert.Nil(t, userInfo.Groups)
+
+	_, err = s.GetUserInfo(ctx, user.ID, "applications:read-only")
+	assert.Equal(t, herrors.ErrForbidden, perror.Cause(err))
+}
@@ -21,24 +21,36 @@ import (
 	collectionmanager "github.com/horizoncd/horizon/pkg/collection/manager"
 
 	accesstokenmanager "github.com/horizoncd/horizon/pkg/accesstoken/manager"
+	admissionmanager "github.com/horizoncd/horizon/pkg/admission/manager"
 	applicationmanager "github.com/horizoncd/horizon/pkg/application/manager"
 	applicationregionmanager "github.com/horizoncd/horizon/pkg/applicationregion/manager"
+	auditmanager "github.com/horizoncd/horizon/pkg/audit/manager"
 	badgemanager "github.com/horizoncd/horizon/pkg/badge/manager"
+	canarymanager "github.com/horizoncd/horizon/pkg/canary/manager"
+	changerequestmanager "github.com/horizoncd/horizon/pkg/changerequest/manager"
 	clustermanager "github.com/horizoncd/horizon/pkg/cluster/manager"
+	driftmanager "github.com/horizoncd/horizon/pkg/drift/manager"
+	elevationmanager "github.com/horizoncd/horizon/pkg/elevation/manager"
 	envmanager "github.com/horizoncd/horizon/pkg/environment/manager"
 	environmentregionmanager "github.com/horizoncd/horizon/pkg/environmentregion/manager"
 	eventManager "github.com/horizoncd/horizon/pkg/event/manager"
+	eventaggregationmanager "github.com/horizoncd/horizon/pkg/eventaggregation/manager"
+	gitopsstoremanager "github.com/horizoncd/horizon/pkg/gitopsstore/manager"
 	groupmanager "github.com/horizoncd/horizon/pkg/group/manager"
+	hibernationmanager "github.com/horizoncd/horizon/pkg/hibernation/manager"
+	hpamanager "github.com/horizoncd/horizon/pkg/hpa/manager"
 	idpmanager "github.com/horizoncd/horizon/pkg/idp/manager"
 	prmanager "github.com/horizoncd/horizon/pkg/pr/manager"
 	pipelinemanager "github.com/horizoncd/horizon/pkg/pr/pipeline/manager"
 	regionmanager "github.com/horizoncd/horizon/pkg/region/manager"
 	registrymanager "github.com/horizoncd/horizon/pkg/registry/manager"
+	releaseplanmanager "github.com/horizoncd/horizon/pkg/releaseplan/manager"
 	tagmanager "github.com/horizoncd/horizon/pkg/tag/manager"
 	templatemanager "github.com/horizoncd/horizon/pkg/template/manager"
 	trmanager "github.com/horizoncd/horizon/pkg/templaterelease/manager"
 	templateschematagmanager "github.com/horizoncd/horizon/pkg/templateschematag/manager"
 	trtmanager "github.com/horizoncd/horizon/pkg/templateschematag/manager"
+	terminalsessionmanager "github.com/horizoncd/horizon/pkg/terminalsession/manager"
 	tokenmanager "github.com/horizoncd/horizon/pkg/token/manager"
 	usermanager "github.com/horizoncd/horizon/pkg/user/manager"
 	linkmanager "github.com/horizoncd/horizon/pkg/userlink/manager"
@@ -72,6 +84,18 @@ type Manager struct {
 	EventMgr             eventManager.Manager
 	TokenMgr             tokenmanager.Manager
 	BadgeMgr             badgemanager.Manager
+	ElevationMgr         elevationmanager.Manager
+	AdmissionPolicyMgr   admissionmanager.Manager
+	ReleasePlanMgr       releaseplanmanager.Manager
+	GitopsStoreMgr       gitopsstoremanager.Manager
+	ChangeRequestMgr     changerequestmanager.Manager
+	ClusterDriftMgr      driftmanager.Manager
+	CanaryMgr            canarymanager.Manager
+	HPAOverrideMgr       hpamanager.Manager
+	HibernationMgr       hibernationmanager.Manager
+	TerminalSessionMgr   terminalsessionmanager.Manager
+	EventAggregationMgr  eventaggregationmanager.Manager
+	AuditMgr             auditmanager.Manager
 }
 
 func InitManager(db *gorm.DB) *Manager {
@@ -102,5 +126,17 @@ func InitManager(db *gorm.DB) *Manager {
 		EventMgr:             eventManager.New(db),
 		TokenMgr:             tokenmanager.New(db),
 		BadgeMgr:             badgemanager.New(db),
+		ElevationMgr:         elevationmanager.New(db),
+		AdmissionPolicyMgr:   admissionmanager.New(db),
+		ReleasePlanMgr:       releaseplanmanager.New(db),
+		GitopsStoreMgr:       gitopsstoremanager.New(db),
+		ChangeRequestMgr:     changerequestmanager.New(db),
+		ClusterDriftMgr:      driftmanager.New(db),
+		CanaryMgr:            canarymanager.New(db),
+		HPAOverrideMgr:       hpamanager.New(db),
+		HibernationMgr:       hibernationmanager.New(db),
+		TerminalSessionMgr:   terminalsessionmanager.New(db),
+		EventAggregationMgr:  eventaggregationmanager.New(db),
+		AuditMgr:             auditmanager.New(db),
 	}
 }
@@ -15,6 +15,7 @@
 package param
 
 import (
+	admissionmodels "github.com/horizoncd/horizon/pkg/admission/models"
 	applicationgitrepo "github.com/horizoncd/horizon/pkg/application/gitrepo"
 	applicationservice "github.com/horizoncd/horizon/pkg/application/service"
 	"github.com/horizoncd/horizon/pkg/cd"
@@ -29,6 +30,7 @@ import (
 	"github.com/horizoncd/horizon/pkg/hook/hook"
 	memberservice "github.com/horizoncd/horizon/pkg/member/service"
 	oauthmanager "github.com/horizoncd/horizon/pkg/oauth/manager"
+	"github.com/horizoncd/horizon/pkg/oauth/oidc"
 	"github.com/horizoncd/horizon/pkg/oauth/scope"
 	"github.com/horizoncd/horizon/pkg/param/managerparam"
 	prservice "github.com/horizoncd/horizon/pkg/pr/service"
@@ -58,10 +60,12 @@ type Param struct {
 	RoleService    role.Service
 	PRService      prservice.Service
 	ScopeService   scope.Service
+	OIDCService    oidc.Service
 	GrafanaService grafana.Service
 
 	// others
 	Hook                 hook.Hook
+	Admit                admissionmodels.AdmitFunc
 	ApplicationGitRepo   applicationgitrepo.ApplicationGitRepo
 	TemplateSchemaGetter templateschema.Getter
 	CD                   cd.CD
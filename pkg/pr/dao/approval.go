@@ -0,0 +1,64 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/pkg/pr/models"
+)
+
+type ApprovalDAO interface {
+	// Create saves the approval, which replaces the former approval of the same reviewer
+	Create(ctx context.Context, approval *models.Approval) (*models.Approval, error)
+	// List lists approvals of the pipelinerun order by id asc
+	List(ctx context.Context, pipelinerunID uint) ([]*models.Approval, error)
+}
+
+type approvalDAO struct{ db *gorm.DB }
+
+func NewApprovalDAO(db *gorm.DB) ApprovalDAO {
+	return &approvalDAO{db: db}
+}
+
+func (d *approvalDAO) Create(ctx context.Context, approval *models.Approval) (*models.Approval, error) {
+	result := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{
+				Name: "pipelinerun_id",
+			}, {
+				Name: "created_by",
+			},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"approved", "comment", "created_at"}),
+	}).Create(approval)
+	if result.Error != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.PipelinerunApprovalInDB, result.Error.Error())
+	}
+	return approval, nil
+}
+
+func (d *approvalDAO) List(ctx context.Context, pipelinerunID uint) ([]*models.Approval, error) {
+	var approvals []*models.Approval
+	if err := d.db.WithContext(ctx).Where("pipelinerun_id = ?", pipelinerunID).
+		Order("id asc").Find(&approvals).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.PipelinerunApprovalInDB, err.Error())
+	}
+	return approvals, nil
+}
@@ -47,6 +47,8 @@ type PipelineRunDAO interface {
 	GetLatestSuccessByClusterID(ctx context.Context, clusterID uint) (*models.Pipelinerun, error)
 	GetFirstCanRollbackPipelinerun(ctx context.Context, clusterID uint) (*models.Pipelinerun, error)
 	UpdateColumns(ctx context.Context, id uint, columns map[string]interface{}) error
+	// ListDeployments lists the deployments ordered by cluster and finished time
+	ListDeployments(ctx context.Context, query *models.DeploymentQuery) ([]*models.Deployment, error)
 }
 
 type pipelinerunDAO struct{ db *gorm.DB }
@@ -250,3 +252,38 @@ func (d *pipelinerunDAO) UpdateColumns(ctx context.Context, id uint, columns map
 	}
 	return res.Error
 }
+
+func (d *pipelinerunDAO) ListDeployments(ctx context.Context,
+	query *models.DeploymentQuery) ([]*models.Deployment, error) {
+	statement := d.db.WithContext(ctx).Table("tb_pipelinerun as pr").
+		Select("pr.id, pr.cluster_id, c.application_id, c.environment_name as environment, "+
+			"pr.action, pr.status, pr.git_commit_time, pr.finished_at").
+		Joins("join tb_cluster as c on c.id = pr.cluster_id").
+		Where("pr.action in ?", []string{models.ActionBuildDeploy, models.ActionDeploy, models.ActionRollback}).
+		Where("pr.status in ?", []string{string(models.StatusOK), string(models.StatusFailed)}).
+		Where("pr.finished_at is not null")
+	if !query.Start.IsZero() {
+		statement = statement.Where("pr.finished_at >= ?", query.Start)
+	}
+	if !query.End.IsZero() {
+		statement = statement.Where("pr.finished_at < ?", query.End)
+	}
+	if query.ApplicationID != 0 {
+		statement = statement.Where("c.application_id = ?", query.ApplicationID)
+	}
+	if query.Environment != "" {
+		statement = statement.Where("c.environment_name = ?", query.Environment)
+	}
+	if query.GroupTraversalIDs != "" {
+		groups := d.db.Table("tb_group").Select("id").Where("traversal_ids = ? or traversal_ids like ?",
+			query.GroupTraversalIDs, query.GroupTraversalIDs+",%")
+		statement = statement.Joins("join tb_application as a on a.id = c.application_id").
+			Where("a.group_id in (?)", groups)
+	}
+
+	var deployments []*models.Deployment
+	if err := statement.Order("pr.cluster_id, pr.finished_at, pr.id").Scan(&deployments).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.PipelinerunInDB, err.Error())
+	}
+	return deployments, nil
+}
@@ -0,0 +1,200 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dora
+
+import (
+	"sort"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/pr/models"
+)
+
+// Metrics are the DORA metrics of the deployments within a time window. Rollbacks are deployments
+// restoring failures rather than changes, so they are counted as restores only
+type Metrics struct {
+	// Deployments is the number of changes deployed successfully
+	Deployments int `json:"deployments"`
+	// DeploymentFrequency is the successful deployments per day
+	DeploymentFrequency float64 `json:"deploymentFrequency"`
+	// LeadTimeSeconds is the median time from committed to deployed of the successful deployments,
+	// nil if the commit time of none of them is known
+	LeadTimeSeconds *float64 `json:"leadTimeSeconds"`
+	// Changes is the number of changes deployed, either successfully or not
+	Changes int `json:"changes"`
+	// Failures is the number of changes failed to deploy or rolled back after deployed
+	Failures int `json:"failures"`
+	// ChangeFailureRate is failures divided by changes, nil if no changes
+	ChangeFailureRate *float64 `json:"changeFailureRate"`
+	// Restores is the number of failures restored by a later successful deployment of the same cluster
+	Restores int `json:"restores"`
+	// TimeToRestoreSeconds is the mean time from failures to their restores, nil if no restores
+	TimeToRestoreSeconds *float64 `json:"timeToRestoreSeconds"`
+}
+
+type Bucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	*Metrics
+}
+
+type Report struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	*Metrics
+	// Environments are the metrics of each environment
+	Environments map[string]*Metrics `json:"environments"`
+	// Series are the metrics of each step from the start, empty if step is 0
+	Series []*Bucket `json:"series"`
+}
+
+// Compute computes the DORA metrics of the deployments in [start, end), which are ordered by cluster
+// and finished time. Deployments are attributed to buckets by their finished time, and failures are
+// restored by the deployments within the range only
+func Compute(deployments []*models.Deployment, start, end time.Time, step time.Duration) *Report {
+	total := &accumulator{}
+	environments := make(map[string]*accumulator)
+	var buckets []*accumulator
+	if step > 0 {
+		for t := start; t.Before(end); t = t.Add(step) {
+			buckets = append(buckets, &accumulator{})
+		}
+	}
+
+	// walk backwards to find whether each deployment is rolled back and when it is restored
+	// by the next successful deployment of the same cluster
+	outcomes := make([]outcome, len(deployments))
+	var nextOK *time.Time
+	for i := len(deployments) - 1; i >= 0; i-- {
+		d := deployments[i]
+		if i == len(deployments)-1 || deployments[i+1].ClusterID != d.ClusterID {
+			nextOK = nil
+		} else {
+			outcomes[i].rolledBack = deployments[i+1].Action == models.ActionRollback
+		}
+		outcomes[i].restoredAt = nextOK
+		if d.Status == string(models.StatusOK) {
+			finishedAt := d.FinishedAt
+			nextOK = &finishedAt
+		}
+	}
+
+	for i, d := range deployments {
+		accumulators := []*accumulator{total}
+		if _, ok := environments[d.Environment]; !ok {
+			environments[d.Environment] = &accumulator{}
+		}
+		accumulators = append(accumulators, environments[d.Environment])
+		if step > 0 {
+			if index := int(d.FinishedAt.Sub(start) / step); index >= 0 && index < len(buckets) {
+				accumulators = append(accumulators, buckets[index])
+			}
+		}
+		for _, a := range accumulators {
+			a.add(d, outcomes[i])
+		}
+	}
+
+	report := &Report{
+		Start:        start,
+		End:          end,
+		Metrics:      total.metrics(end.Sub(start)),
+		Environments: make(map[string]*Metrics, len(environments)),
+	}
+	for environment, a := range environments {
+		report.Environments[environment] = a.metrics(end.Sub(start))
+	}
+	for i, a := range buckets {
+		bucketStart := start.Add(time.Duration(i) * step)
+		bucketEnd := bucketStart.Add(step)
+		if bucketEnd.After(end) {
+			bucketEnd = end
+		}
+		report.Series = append(report.Series, &Bucket{
+			Start:   bucketStart,
+			End:     bucketEnd,
+			Metrics: a.metrics(bucketEnd.Sub(bucketStart)),
+		})
+	}
+	return report
+}
+
+type accumulator struct {
+	deployments  int
+	leadTimes    []float64
+	changes      int
+	failures     int
+	restoreTimes []float64
+}
+
+type outcome struct {
+	rolledBack bool
+	// restoredAt is the finished time of the next successful deployment of the same cluster
+	restoredAt *time.Time
+}
+
+func (a *accumulator) add(d *models.Deployment, o outcome) {
+	if d.Action == models.ActionRollback {
+		return
+	}
+	a.changes++
+	succeeded := d.Status == string(models.StatusOK)
+	if succeeded {
+		a.deployments++
+		if d.GitCommitTime != nil && d.FinishedAt.After(*d.GitCommitTime) {
+			a.leadTimes = append(a.leadTimes, d.FinishedAt.Sub(*d.GitCommitTime).Seconds())
+		}
+	}
+	if succeeded && !o.rolledBack {
+		return
+	}
+	a.failures++
+	if o.restoredAt != nil {
+		a.restoreTimes = append(a.restoreTimes, o.restoredAt.Sub(d.FinishedAt).Seconds())
+	}
+}
+
+func (a *accumulator) metrics(window time.Duration) *Metrics {
+	m := &Metrics{
+		Deployments: a.deployments,
+		Changes:     a.changes,
+		Failures:    a.failures,
+		Restores:    len(a.restoreTimes),
+	}
+	if days := window.Hours() / 24; days > 0 {
+		m.DeploymentFrequency = float64(a.deployments) / days
+	}
+	if len(a.leadTimes) > 0 {
+		sort.Float64s(a.leadTimes)
+		middle := len(a.leadTimes) / 2
+		median := a.leadTimes[middle]
+		if len(a.leadTimes)%2 == 0 {
+			median = (a.leadTimes[middle-1] + a.leadTimes[middle]) / 2
+		}
+		m.LeadTimeSeconds = &median
+	}
+	if a.changes > 0 {
+		rate := float64(a.failures) / float64(a.changes)
+		m.ChangeFailureRate = &rate
+	}
+	if len(a.restoreTimes) > 0 {
+		var sum float64
+		for _, t := range a.restoreTimes {
+			sum += t
+		}
+		mean := sum / float64(len(a.restoreTimes))
+		m.TimeToRestoreSeconds = &mean
+	}
+	return m
+}
@@ -0,0 +1,84 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dora
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/horizoncd/horizon/pkg/pr/models"
+)
+
+func TestCompute(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time {
+		return start.Add(time.Duration(hours) * time.Hour)
+	}
+	commitAt := func(hours int) *time.Time {
+		t := at(hours)
+		return &t
+	}
+	ok, failed := string(models.StatusOK), string(models.StatusFailed)
+	deployments := []*models.Deployment{
+		{ClusterID: 1, Environment: "online", Action: models.ActionBuildDeploy, Status: ok,
+			GitCommitTime: commitAt(-1), FinishedAt: at(1)},
+		// rolled back
+		{ClusterID: 1, Environment: "online", Action: models.ActionDeploy, Status: ok,
+			GitCommitTime: commitAt(1), FinishedAt: at(2)},
+		{ClusterID: 1, Environment: "online", Action: models.ActionRollback, Status: ok, FinishedAt: at(3)},
+		{ClusterID: 1, Environment: "online", Action: models.ActionDeploy, Status: failed, FinishedAt: at(26)},
+		{ClusterID: 1, Environment: "online", Action: models.ActionDeploy, Status: ok, FinishedAt: at(30)},
+		// not restored
+		{ClusterID: 2, Environment: "test", Action: models.ActionDeploy, Status: failed, FinishedAt: at(5)},
+	}
+
+	report := Compute(deployments, start, at(48), 24*time.Hour)
+	assert.Equal(t, 3, report.Deployments)
+	assert.Equal(t, 1.5, report.DeploymentFrequency)
+	assert.Equal(t, 5400.0, *report.LeadTimeSeconds)
+	assert.Equal(t, 5, report.Changes)
+	assert.Equal(t, 3, report.Failures)
+	assert.Equal(t, 0.6, *report.ChangeFailureRate)
+	assert.Equal(t, 2, report.Restores)
+	assert.Equal(t, 9000.0, *report.TimeToRestoreSeconds)
+
+	assert.Equal(t, 2, len(report.Environments))
+	test := report.Environments["test"]
+	assert.Equal(t, 0, test.Deployments)
+	assert.Nil(t, test.LeadTimeSeconds)
+	assert.Equal(t, 1.0, *test.ChangeFailureRate)
+	assert.Nil(t, test.TimeToRestoreSeconds)
+	assert.Equal(t, 4, report.Environments["online"].Changes)
+
+	assert.Equal(t, 2, len(report.Series))
+	first, second := report.Series[0], report.Series[1]
+	assert.Equal(t, at(24), first.End)
+	assert.Equal(t, 2, first.Deployments)
+	assert.Equal(t, 2.0, first.DeploymentFrequency)
+	assert.Equal(t, 3, first.Changes)
+	assert.Equal(t, 2, first.Failures)
+	assert.Equal(t, 3600.0, *first.TimeToRestoreSeconds)
+	assert.Equal(t, at(24), second.Start)
+	assert.Equal(t, 1, second.Deployments)
+	assert.Nil(t, second.LeadTimeSeconds)
+	assert.Equal(t, 14400.0, *second.TimeToRestoreSeconds)
+
+	report = Compute(nil, start, at(48), 0)
+	assert.Equal(t, 0, report.Deployments)
+	assert.Nil(t, report.ChangeFailureRate)
+	assert.Empty(t, report.Series)
+}
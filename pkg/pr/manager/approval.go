@@ -0,0 +1,49 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/pkg/pr/dao"
+	"github.com/horizoncd/horizon/pkg/pr/models"
+)
+
+type ApprovalManager interface {
+	// Create saves the approval, which replaces the former approval of the same reviewer
+	Create(ctx context.Context, approval *models.Approval) (*models.Approval, error)
+	// List lists approvals of the pipelinerun order by id asc
+	List(ctx context.Context, pipelinerunID uint) ([]*models.Approval, error)
+}
+
+type approvalManager struct {
+	dao dao.ApprovalDAO
+}
+
+func NewApprovalManager(db *gorm.DB) ApprovalManager {
+	return &approvalManager{
+		dao: dao.NewApprovalDAO(db),
+	}
+}
+
+func (m *approvalManager) Create(ctx context.Context, approval *models.Approval) (*models.Approval, error) {
+	return m.dao.Create(ctx, approval)
+}
+
+func (m *approvalManager) List(ctx context.Context, pipelinerunID uint) ([]*models.Approval, error) {
+	return m.dao.List(ctx, pipelinerunID)
+}
@@ -48,6 +48,9 @@ type PipelineRunManager interface {
 	// UpdateResultByID  update the pipelinerun restore result
 	UpdateResultByID(ctx context.Context, pipelinerunID uint, result *models.Result) error
 	UpdateColumns(ctx context.Context, pipelinerunID uint, columns map[string]interface{}) error
+	// ListDeployments lists the finished builddeploy, deploy and rollback pipelineruns,
+	// ordered by cluster and finished time
+	ListDeployments(ctx context.Context, query *models.DeploymentQuery) ([]*models.Deployment, error)
 }
 
 type pipelinerunManager struct {
@@ -131,3 +134,8 @@ func (m *pipelinerunManager) UpdateColumns(ctx context.Context,
 	pipelinerunID uint, columns map[string]interface{}) error {
 	return m.dao.UpdateColumns(ctx, pipelinerunID, columns)
 }
+
+func (m *pipelinerunManager) ListDeployments(ctx context.Context,
+	query *models.DeploymentQuery) ([]*models.Deployment, error) {
+	return m.dao.ListDeployments(ctx, query)
+}
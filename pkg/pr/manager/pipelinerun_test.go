@@ -17,6 +17,7 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -24,8 +25,11 @@ import (
 	"github.com/horizoncd/horizon/core/common"
 	"github.com/horizoncd/horizon/lib/orm"
 	"github.com/horizoncd/horizon/lib/q"
+	appmodels "github.com/horizoncd/horizon/pkg/application/models"
 	userauth "github.com/horizoncd/horizon/pkg/authentication/user"
 	codemodels "github.com/horizoncd/horizon/pkg/cluster/code"
+	clustermodels "github.com/horizoncd/horizon/pkg/cluster/models"
+	groupmodels "github.com/horizoncd/horizon/pkg/group/models"
 	"github.com/horizoncd/horizon/pkg/pr/models"
 
 	"github.com/stretchr/testify/assert"
@@ -257,9 +261,89 @@ func TestGetFirstCanRollbackPipelinerun(t *testing.T) {
 	assert.Nil(t, pipelinerun)
 }
 
+func TestListDeployments(t *testing.T) {
+	assert.Nil(t, db.Create(&groupmodels.Group{Name: "a", Path: "a", TraversalIDs: "100"}).Error)
+	assert.Nil(t, db.Create(&groupmodels.Group{Name: "b", Path: "b", TraversalIDs: "100,101"}).Error)
+	assert.Nil(t, db.Create(&groupmodels.Group{Name: "c", Path: "c", TraversalIDs: "1000"}).Error)
+	var groups []*groupmodels.Group
+	assert.Nil(t, db.Order("id").Find(&groups).Error)
+	assert.Nil(t, db.Model(groups[0]).Update("traversal_ids", fmt.Sprintf("%d", groups[0].ID)).Error)
+	assert.Nil(t, db.Model(groups[1]).Update("traversal_ids", fmt.Sprintf("%d,%d", groups[0].ID, groups[1].ID)).Error)
+	assert.Nil(t, db.Model(groups[2]).Update("traversal_ids", fmt.Sprintf("%d", groups[2].ID)).Error)
+
+	apps := []*appmodels.Application{
+		{Name: "app1", GroupID: groups[0].ID},
+		{Name: "app2", GroupID: groups[1].ID},
+		{Name: "app3", GroupID: groups[2].ID},
+	}
+	for _, app := range apps {
+		assert.Nil(t, db.Create(app).Error)
+	}
+	clusters := []*clustermodels.Cluster{
+		{Name: "cluster1", ApplicationID: apps[0].ID, EnvironmentName: "online"},
+		{Name: "cluster2", ApplicationID: apps[1].ID, EnvironmentName: "test"},
+		{Name: "cluster3", ApplicationID: apps[2].ID, EnvironmentName: "online"},
+	}
+	for _, cluster := range clusters {
+		assert.Nil(t, db.Create(cluster).Error)
+	}
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		t := start.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+	for _, pr := range []*models.Pipelinerun{
+		{ClusterID: clusters[0].ID, Action: models.ActionBuildDeploy, Status: string(models.StatusOK),
+			GitCommitTime: at(0), FinishedAt: at(2)},
+		{ClusterID: clusters[0].ID, Action: models.ActionRestart, Status: string(models.StatusOK), FinishedAt: at(3)},
+		{ClusterID: clusters[0].ID, Action: models.ActionDeploy, Status: string(models.StatusRunning)},
+		{ClusterID: clusters[1].ID, Action: models.ActionRollback, Status: string(models.StatusFailed),
+			FinishedAt: at(1)},
+		{ClusterID: clusters[2].ID, Action: models.ActionDeploy, Status: string(models.StatusOK), FinishedAt: at(4)},
+		{ClusterID: clusters[2].ID, Action: models.ActionDeploy, Status: string(models.StatusOK), FinishedAt: at(48)},
+	} {
+		_, err := mgr.Create(ctx, pr)
+		assert.Nil(t, err)
+	}
+
+	query := &models.DeploymentQuery{Start: start, End: *at(24)}
+	deployments, err := mgr.ListDeployments(ctx, query)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(deployments))
+	assert.Equal(t, clusters[0].ID, deployments[0].ClusterID)
+	assert.Equal(t, apps[0].ID, deployments[0].ApplicationID)
+	assert.Equal(t, "online", deployments[0].Environment)
+	assert.Equal(t, models.ActionBuildDeploy, deployments[0].Action)
+	assert.True(t, at(0).Equal(*deployments[0].GitCommitTime))
+	assert.True(t, at(2).Equal(deployments[0].FinishedAt))
+	assert.Equal(t, models.ActionRollback, deployments[1].Action)
+	assert.Equal(t, clusters[2].ID, deployments[2].ClusterID)
+
+	query.Environment = "online"
+	deployments, err = mgr.ListDeployments(ctx, query)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(deployments))
+
+	// subgroups are included
+	query.Environment = ""
+	query.GroupTraversalIDs = groups[0].TraversalIDs
+	deployments, err = mgr.ListDeployments(ctx, query)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(deployments))
+
+	query.GroupTraversalIDs = ""
+	query.ApplicationID = apps[1].ID
+	deployments, err = mgr.ListDeployments(ctx, query)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(deployments))
+	assert.Equal(t, clusters[1].ID, deployments[0].ClusterID)
+}
+
 func TestMain(m *testing.M) {
 	if err := db.AutoMigrate(&models.Pipelinerun{}, &models.Check{},
-		&models.CheckRun{}, &models.PRMessage{}); err != nil {
+		&models.CheckRun{}, &models.PRMessage{}, &groupmodels.Group{},
+		&appmodels.Application{}, &clustermodels.Cluster{}); err != nil {
 		panic(err)
 	}
 	ctx = context.TODO()
@@ -8,6 +8,7 @@ type PRManager struct {
 	PipelineRun PipelineRunManager
 	Message     PRMessageManager
 	Check       CheckManager
+	Approval    ApprovalManager
 }
 
 func NewPRManager(db *gorm.DB) *PRManager {
@@ -15,5 +16,6 @@ func NewPRManager(db *gorm.DB) *PRManager {
 		PipelineRun: NewPipelineRunManager(db),
 		Message:     NewPRMessageManager(db),
 		Check:       NewCheckManager(db),
+		Approval:    NewApprovalManager(db),
 	}
 }
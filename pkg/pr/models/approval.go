@@ -0,0 +1,31 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// Approval is the approval or rejection of a pipelinerun by a cluster member
+type Approval struct {
+	ID            uint
+	PipelinerunID uint `gorm:"uniqueIndex:uk_pipelinerun_reviewer"`
+	Approved      bool
+	Comment       string
+	CreatedAt     time.Time
+	CreatedBy     uint `gorm:"uniqueIndex:uk_pipelinerun_reviewer"`
+}
+
+func (Approval) TableName() string {
+	return "tb_pipelinerun_approval"
+}
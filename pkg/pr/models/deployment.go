@@ -0,0 +1,41 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// Deployment is a finished pipelinerun deploying a cluster, which is the unit of DORA metrics
+type Deployment struct {
+	ID            uint
+	ClusterID     uint
+	ApplicationID uint
+	Environment   string
+	// Action is builddeploy, deploy or rollback
+	Action string
+	// Status is ok or failed
+	Status        string
+	GitCommitTime *time.Time
+	FinishedAt    time.Time
+}
+
+// DeploymentQuery filters deployments finished in [Start, End), zero values match all
+type DeploymentQuery struct {
+	ApplicationID uint
+	// GroupTraversalIDs selects the deployments of the group and its subgroups
+	GroupTraversalIDs string
+	Environment       string
+	Start             time.Time
+	End               time.Time
+}
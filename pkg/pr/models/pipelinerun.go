@@ -40,6 +40,8 @@ const (
 	StatusFailed    PipelineStatus = "failed"
 	StatusCancelled PipelineStatus = "cancelled"
 	StatusUnknown   PipelineStatus = "unknown"
+	// StatusRejected means the pipeline is rejected by a reviewer and cannot be executed
+	StatusRejected PipelineStatus = "rejected"
 )
 
 type Pipelinerun struct {
@@ -63,6 +65,8 @@ type Pipelinerun struct {
 	GitRefType string
 	// GitCommit the git commit this pipelinerun to build with, can be empty when action is not builddeploy
 	GitCommit string
+	// GitCommitTime the commit time of GitCommit, nil if unknown
+	GitCommitTime *time.Time
 	// ImageURL image url of this pipelinerun to build or deploy image
 	ImageURL string
 	// the two commit used to compare the config difference of this pipelinerun
@@ -82,6 +86,8 @@ type Pipelinerun struct {
 	FinishedAt *time.Time
 	// RollbackFrom which pipelinerun this pipelinerun rollback from
 	RollbackFrom *uint
+	// RequiredApprovals the number of approvals required by the environment of cluster before executed
+	RequiredApprovals int
 	// CIEventID event id returned from tekton-trigger EventListener
 	CIEventID string
 	CreatedAt time.Time
@@ -127,6 +133,8 @@ type PipelineBasic struct {
 	FinishedAt *time.Time `json:"finishedAt"`
 	// CanRollback can this pipelinerun be rollback, default is false
 	CanRollback bool `json:"canRollback"`
+	// RequiredApprovals the number of approvals required before executed, 0 if no approval is required
+	RequiredApprovals int `json:"requiredApprovals"`
 	// createInfo
 	CreatedBy UserInfo `json:"createdBy"`
 }
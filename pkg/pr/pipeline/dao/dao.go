@@ -16,6 +16,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -37,6 +38,12 @@ type DAO interface {
 	Create(ctx context.Context, results *tekton.PipelineResults, data *global.HorizonMetaData) error
 	// ListPipelineStats list pipeline stats by query struct
 	ListPipelineStats(ctx context.Context, query *q.Query) ([]*models.PipelineStats, int64, error)
+	// ListSteps lists the steps of the application started in [start, end), the earliest first
+	ListSteps(ctx context.Context, application string, start, end time.Time) ([]*models.Step, error)
+	// ListTasks lists the tasks of the application started in [start, end), the earliest first
+	ListTasks(ctx context.Context, application string, start, end time.Time) ([]*models.Task, error)
+	// ListApplications lists the names of applications built since the time
+	ListApplications(ctx context.Context, since time.Time) ([]string, error)
 }
 
 type dao struct{ db *gorm.DB }
@@ -82,6 +89,7 @@ func (d dao) Create(ctx context.Context, results *tekton.PipelineResults, data *
 				StartedAt:     trResult.StartTime.Time,
 				FinishedAt:    trResult.CompletionTime.Time,
 				Duration:      uint(trResult.DurationSeconds),
+				CacheHit:      trResult.CacheHit,
 			}
 			result = tx.Create(t)
 			if result.Error != nil {
@@ -203,6 +211,40 @@ func formatPipelineStats(pipelines []*models.Pipeline, tasks []*models.Task,
 	return stats
 }
 
+func (d *dao) ListSteps(ctx context.Context, application string,
+	start, end time.Time) ([]*models.Step, error) {
+	var steps []*models.Step
+	result := d.db.WithContext(ctx).
+		Where("application = ? and started_at >= ? and started_at < ?", application, start, end).
+		Order("started_at").Find(&steps)
+	if result.Error != nil {
+		return nil, herrors.NewErrListFailed(herrors.StepInDB, result.Error.Error())
+	}
+	return steps, nil
+}
+
+func (d *dao) ListTasks(ctx context.Context, application string,
+	start, end time.Time) ([]*models.Task, error) {
+	var tasks []*models.Task
+	result := d.db.WithContext(ctx).
+		Where("application = ? and started_at >= ? and started_at < ?", application, start, end).
+		Order("started_at").Find(&tasks)
+	if result.Error != nil {
+		return nil, herrors.NewErrListFailed(herrors.TaskInDB, result.Error.Error())
+	}
+	return tasks, nil
+}
+
+func (d *dao) ListApplications(ctx context.Context, since time.Time) ([]string, error) {
+	var applications []string
+	result := d.db.WithContext(ctx).Model(&models.Pipeline{}).
+		Where("started_at >= ?", since).Distinct().Pluck("application", &applications)
+	if result.Error != nil {
+		return nil, herrors.NewErrListFailed(herrors.PipelineInDB, result.Error.Error())
+	}
+	return applications, nil
+}
+
 func NewDAO(db *gorm.DB) DAO {
 	return &dao{db: db}
 }
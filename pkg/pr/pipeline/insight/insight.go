@@ -0,0 +1,295 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package insight
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	"github.com/horizoncd/horizon/pkg/pr/pipeline/models"
+)
+
+// SlowestRunsLimit is the max number of the slowest pipelineruns kept for each step
+const SlowestRunsLimit = 5
+
+// Durations are the statistics of durations in seconds
+type Durations struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+// Run is a run of a step within a pipelinerun
+type Run struct {
+	PipelinerunID uint      `json:"pipelinerunID"`
+	Duration      uint      `json:"duration"`
+	StartedAt     time.Time `json:"startedAt"`
+}
+
+type Bucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	*Durations
+}
+
+// StepInsight is the insight of a step of a task in a pipeline
+type StepInsight struct {
+	Pipeline string `json:"pipeline"`
+	Task     string `json:"task"`
+	Step     string `json:"step"`
+	*Durations
+	// SlowestRuns are the runs taking the longest, the slowest first
+	SlowestRuns []*Run `json:"slowestRuns"`
+	// Series are the durations of each step from the start, empty if step is 0
+	Series []*Bucket `json:"series"`
+}
+
+// CacheInsight is the build cache insight of a task reporting whether the cache is hit
+type CacheInsight struct {
+	Pipeline string `json:"pipeline"`
+	Task     string `json:"task"`
+	Hits     int    `json:"hits"`
+	Misses   int    `json:"misses"`
+	// HitRatio is hits divided by hits and misses, nil if neither
+	HitRatio *float64 `json:"hitRatio"`
+	// HitP50 and MissP50 are the median durations in seconds of the task when the cache is hit or missed
+	HitP50  *float64 `json:"hitP50"`
+	MissP50 *float64 `json:"missP50"`
+}
+
+type Report struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Steps are ordered by the median duration descending, so the first is the slowest
+	Steps  []*StepInsight  `json:"steps"`
+	Caches []*CacheInsight `json:"caches"`
+}
+
+// Regression is a step whose median duration grows past the threshold
+type Regression struct {
+	Pipeline      string  `json:"pipeline"`
+	Task          string  `json:"task"`
+	Step          string  `json:"step"`
+	BaselineP50   float64 `json:"baselineP50"`
+	BaselineCount int     `json:"baselineCount"`
+	RecentP50     float64 `json:"recentP50"`
+	RecentCount   int     `json:"recentCount"`
+	// Increase is the growth ratio of the median duration, e.g. 0.5 for 50%
+	Increase float64 `json:"increase"`
+}
+
+type RegressionOptions struct {
+	// Threshold is the min growth ratio of the median duration to report
+	Threshold float64
+	// MinSamples is the min number of successful runs in both windows
+	MinSamples int
+	// MinDuration is the min recent median duration, which filters out noise of short steps
+	MinDuration time.Duration
+}
+
+type stepKey struct {
+	pipeline, task, step string
+}
+
+type taskKey struct {
+	pipeline, task string
+}
+
+// Compute computes the insight of the steps and tasks in [start, end), which are ordered by started time.
+// Only successful runs are counted, and runs are attributed to buckets by their started time
+func Compute(steps []*models.Step, tasks []*models.Task, start, end time.Time, step time.Duration) *Report {
+	report := &Report{Start: start, End: end, Steps: []*StepInsight{}, Caches: []*CacheInsight{}}
+
+	var buckets int
+	if step > 0 {
+		for t := start; t.Before(end); t = t.Add(step) {
+			buckets++
+		}
+	}
+	insights := make(map[stepKey]*StepInsight)
+	durations := make(map[stepKey][]uint)
+	bucketDurations := make(map[stepKey][][]uint)
+	for _, s := range steps {
+		if s.Result != string(prmodels.StatusOK) || s.StartedAt.Before(start) || !s.StartedAt.Before(end) {
+			continue
+		}
+		key := stepKey{s.Pipeline, s.Task, s.Step}
+		insight, ok := insights[key]
+		if !ok {
+			insight = &StepInsight{Pipeline: s.Pipeline, Task: s.Task, Step: s.Step}
+			insights[key] = insight
+			bucketDurations[key] = make([][]uint, buckets)
+			report.Steps = append(report.Steps, insight)
+		}
+		durations[key] = append(durations[key], s.Duration)
+		insight.SlowestRuns = append(insight.SlowestRuns,
+			&Run{PipelinerunID: s.PipelinerunID, Duration: s.Duration, StartedAt: s.StartedAt})
+		if buckets > 0 {
+			i := int(s.StartedAt.Sub(start) / step)
+			bucketDurations[key][i] = append(bucketDurations[key][i], s.Duration)
+		}
+	}
+	for key, insight := range insights {
+		insight.Durations = stats(durations[key])
+		sort.SliceStable(insight.SlowestRuns, func(i, j int) bool {
+			return insight.SlowestRuns[i].Duration > insight.SlowestRuns[j].Duration
+		})
+		if len(insight.SlowestRuns) > SlowestRunsLimit {
+			insight.SlowestRuns = insight.SlowestRuns[:SlowestRunsLimit]
+		}
+		insight.Series = make([]*Bucket, 0, buckets)
+		for i, ds := range bucketDurations[key] {
+			bucketStart := start.Add(time.Duration(i) * step)
+			bucketEnd := bucketStart.Add(step)
+			if bucketEnd.After(end) {
+				bucketEnd = end
+			}
+			insight.Series = append(insight.Series, &Bucket{Start: bucketStart, End: bucketEnd, Durations: stats(ds)})
+		}
+	}
+	sort.SliceStable(report.Steps, func(i, j int) bool {
+		return report.Steps[i].P50 > report.Steps[j].P50
+	})
+
+	caches := make(map[taskKey]*CacheInsight)
+	hitDurations := make(map[taskKey][]uint)
+	missDurations := make(map[taskKey][]uint)
+	for _, t := range tasks {
+		if t.CacheHit == nil || t.Result != string(prmodels.StatusOK) ||
+			t.StartedAt.Before(start) || !t.StartedAt.Before(end) {
+			continue
+		}
+		key := taskKey{t.Pipeline, t.Task}
+		cache, ok := caches[key]
+		if !ok {
+			cache = &CacheInsight{Pipeline: t.Pipeline, Task: t.Task}
+			caches[key] = cache
+			report.Caches = append(report.Caches, cache)
+		}
+		if *t.CacheHit {
+			cache.Hits++
+			hitDurations[key] = append(hitDurations[key], t.Duration)
+		} else {
+			cache.Misses++
+			missDurations[key] = append(missDurations[key], t.Duration)
+		}
+	}
+	for key, cache := range caches {
+		ratio := float64(cache.Hits) / float64(cache.Hits+cache.Misses)
+		cache.HitRatio = &ratio
+		if cache.Hits > 0 {
+			p50 := percentile(sorted(hitDurations[key]), 50)
+			cache.HitP50 = &p50
+		}
+		if cache.Misses > 0 {
+			p50 := percentile(sorted(missDurations[key]), 50)
+			cache.MissP50 = &p50
+		}
+	}
+	return report
+}
+
+// DetectRegressions compares the median durations of the successful steps in the recent window
+// with those in the baseline window, and reports the steps growing past the threshold,
+// the most regressed first
+func DetectRegressions(baseline, recent []*models.Step, opts RegressionOptions) []*Regression {
+	baselineDurations, recentDurations := group(baseline), group(recent)
+	regressions := []*Regression{}
+	for key, ds := range recentDurations {
+		bs := baselineDurations[key]
+		if len(ds) < opts.MinSamples || len(bs) < opts.MinSamples || len(bs) == 0 {
+			continue
+		}
+		recentP50, baselineP50 := percentile(sorted(ds), 50), percentile(sorted(bs), 50)
+		if recentP50 < opts.MinDuration.Seconds() || baselineP50 <= 0 {
+			continue
+		}
+		increase := (recentP50 - baselineP50) / baselineP50
+		if increase < opts.Threshold {
+			continue
+		}
+		regressions = append(regressions, &Regression{
+			Pipeline:      key.pipeline,
+			Task:          key.task,
+			Step:          key.step,
+			BaselineP50:   baselineP50,
+			BaselineCount: len(bs),
+			RecentP50:     recentP50,
+			RecentCount:   len(ds),
+			Increase:      increase,
+		})
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].Increase != regressions[j].Increase {
+			return regressions[i].Increase > regressions[j].Increase
+		}
+		a, b := regressions[i], regressions[j]
+		return a.Pipeline+"/"+a.Task+"/"+a.Step < b.Pipeline+"/"+b.Task+"/"+b.Step
+	})
+	return regressions
+}
+
+func group(steps []*models.Step) map[stepKey][]uint {
+	durations := make(map[stepKey][]uint)
+	for _, s := range steps {
+		if s.Result != string(prmodels.StatusOK) {
+			continue
+		}
+		key := stepKey{s.Pipeline, s.Task, s.Step}
+		durations[key] = append(durations[key], s.Duration)
+	}
+	return durations
+}
+
+func stats(durations []uint) *Durations {
+	if len(durations) == 0 {
+		return &Durations{}
+	}
+	ds := sorted(durations)
+	var sum float64
+	for _, d := range ds {
+		sum += float64(d)
+	}
+	return &Durations{
+		Count: len(ds),
+		Mean:  sum / float64(len(ds)),
+		P50:   percentile(ds, 50),
+		P90:   percentile(ds, 90),
+		P99:   percentile(ds, 99),
+	}
+}
+
+func sorted(durations []uint) []uint {
+	ds := make([]uint, len(durations))
+	copy(ds, durations)
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	return ds
+}
+
+// percentile returns the p-th percentile of the sorted durations by the nearest-rank method
+func percentile(sorted []uint, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1])
+}
@@ -0,0 +1,114 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package insight
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	prmodels "github.com/horizoncd/horizon/pkg/pr/models"
+	"github.com/horizoncd/horizon/pkg/pr/pipeline/models"
+)
+
+func TestCompute(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time {
+		return start.Add(time.Duration(hours) * time.Hour)
+	}
+	ok, failed := string(prmodels.StatusOK), string(prmodels.StatusFailed)
+	step := func(id uint, name string, duration uint, result string, hours int) *models.Step {
+		return &models.Step{PipelinerunID: id, Pipeline: "default", Task: "build", Step: name,
+			Result: result, Duration: duration, StartedAt: at(hours)}
+	}
+	steps := []*models.Step{
+		step(1, "compile", 100, ok, 1),
+		step(1, "image", 40, ok, 1),
+		step(2, "compile", 300, ok, 2),
+		step(2, "image", 60, ok, 2),
+		step(3, "compile", 1000, failed, 3),
+		step(4, "compile", 200, ok, 30),
+		// out of range
+		step(5, "compile", 5000, ok, 50),
+	}
+	hit, miss := true, false
+	task := func(cacheHit *bool, duration uint) *models.Task {
+		return &models.Task{Pipeline: "default", Task: "build", Result: ok, Duration: duration,
+			CacheHit: cacheHit, StartedAt: at(1)}
+	}
+	tasks := []*models.Task{task(&hit, 100), task(&hit, 120), task(&miss, 400), task(nil, 1000)}
+
+	report := Compute(steps, tasks, start, at(48), 24*time.Hour)
+	assert.Equal(t, 2, len(report.Steps))
+	compile := report.Steps[0]
+	assert.Equal(t, "compile", compile.Step)
+	assert.Equal(t, 3, compile.Count)
+	assert.Equal(t, 200.0, compile.Mean)
+	assert.Equal(t, 200.0, compile.P50)
+	assert.Equal(t, 300.0, compile.P90)
+	assert.Equal(t, 300.0, compile.P99)
+	assert.Equal(t, []uint{2, 4, 1}, []uint{compile.SlowestRuns[0].PipelinerunID,
+		compile.SlowestRuns[1].PipelinerunID, compile.SlowestRuns[2].PipelinerunID})
+	assert.Equal(t, 2, len(compile.Series))
+	assert.Equal(t, 2, compile.Series[0].Count)
+	assert.Equal(t, 100.0, compile.Series[0].P50)
+	assert.Equal(t, 1, compile.Series[1].Count)
+	assert.Equal(t, 200.0, compile.Series[1].P50)
+	assert.Equal(t, "image", report.Steps[1].Step)
+	assert.Equal(t, 40.0, report.Steps[1].P50)
+
+	assert.Equal(t, 1, len(report.Caches))
+	cache := report.Caches[0]
+	assert.Equal(t, 2, cache.Hits)
+	assert.Equal(t, 1, cache.Misses)
+	assert.InDelta(t, 2.0/3, *cache.HitRatio, 1e-9)
+	assert.Equal(t, 100.0, *cache.HitP50)
+	assert.Equal(t, 400.0, *cache.MissP50)
+
+	empty := Compute(nil, nil, start, at(48), 0)
+	assert.Empty(t, empty.Steps)
+	assert.Empty(t, empty.Caches)
+}
+
+func TestDetectRegressions(t *testing.T) {
+	steps := func(name string, durations ...uint) []*models.Step {
+		var steps []*models.Step
+		for _, d := range durations {
+			steps = append(steps, &models.Step{Pipeline: "default", Task: "build", Step: name,
+				Result: string(prmodels.StatusOK), Duration: d})
+		}
+		return steps
+	}
+	baseline := append(steps("compile", 100, 100, 100), steps("image", 40, 40, 40)...)
+	baseline = append(baseline, steps("test", 2, 2, 2)...)
+	recent := append(steps("compile", 200, 200, 200), steps("image", 50, 50, 50)...)
+	recent = append(recent, steps("test", 8, 8, 8)...)
+	recent = append(recent, steps("new", 500, 500, 500)...)
+
+	regressions := DetectRegressions(baseline, recent, RegressionOptions{
+		Threshold:   0.5,
+		MinSamples:  3,
+		MinDuration: 10 * time.Second,
+	})
+	assert.Equal(t, 1, len(regressions))
+	assert.Equal(t, "compile", regressions[0].Step)
+	assert.Equal(t, 100.0, regressions[0].BaselineP50)
+	assert.Equal(t, 200.0, regressions[0].RecentP50)
+	assert.Equal(t, 1.0, regressions[0].Increase)
+
+	regressions = DetectRegressions(baseline, recent, RegressionOptions{Threshold: 0.5, MinSamples: 4})
+	assert.Empty(t, regressions)
+}
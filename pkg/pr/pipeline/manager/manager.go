@@ -16,6 +16,7 @@ package manager
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -36,6 +37,12 @@ type Manager interface {
 	Create(ctx context.Context, results *tekton.PipelineResults, data *global.HorizonMetaData) error
 	ListPipelineStats(ctx context.Context, application, cluster string, pageNumber, pageSize int) (
 		[]*models.PipelineStats, int64, error)
+	// ListSteps lists the steps of the application started in [start, end), the earliest first
+	ListSteps(ctx context.Context, application string, start, end time.Time) ([]*models.Step, error)
+	// ListTasks lists the tasks of the application started in [start, end), the earliest first
+	ListTasks(ctx context.Context, application string, start, end time.Time) ([]*models.Task, error)
+	// ListApplications lists the names of applications built since the time
+	ListApplications(ctx context.Context, since time.Time) ([]string, error)
 }
 
 type manager struct {
@@ -63,6 +70,20 @@ func (m manager) Create(ctx context.Context, results *tekton.PipelineResults, da
 	return m.dao.Create(ctx, results, data)
 }
 
+func (m manager) ListSteps(ctx context.Context, application string,
+	start, end time.Time) ([]*models.Step, error) {
+	return m.dao.ListSteps(ctx, application, start, end)
+}
+
+func (m manager) ListTasks(ctx context.Context, application string,
+	start, end time.Time) ([]*models.Task, error) {
+	return m.dao.ListTasks(ctx, application, start, end)
+}
+
+func (m manager) ListApplications(ctx context.Context, since time.Time) ([]string, error) {
+	return m.dao.ListApplications(ctx, since)
+}
+
 func New(db *gorm.DB) Manager {
 	return &manager{
 		dao: dao.NewDAO(db),
@@ -26,8 +26,10 @@ type Task struct {
 	Task          string
 	Result        string
 	Duration      uint
-	StartedAt     time.Time
-	FinishedAt    time.Time
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	// CacheHit tells whether the build cache is hit, nil if the task does not report it
+	CacheHit   *bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
@@ -55,21 +55,22 @@ func (s *service) OfPipelineBasic(ctx context.Context,
 	}()
 
 	prBasic := &models.PipelineBasic{
-		ID:               pr.ID,
-		Title:            pr.Title,
-		Description:      pr.Description,
-		Action:           pr.Action,
-		Status:           pr.Status,
-		GitURL:           pr.GitURL,
-		GitCommit:        pr.GitCommit,
-		ImageURL:         pr.ImageURL,
-		LastConfigCommit: pr.LastConfigCommit,
-		ConfigCommit:     pr.ConfigCommit,
-		CreatedAt:        pr.CreatedAt,
-		UpdatedAt:        pr.UpdatedAt,
-		StartedAt:        pr.StartedAt,
-		FinishedAt:       pr.FinishedAt,
-		CanRollback:      canRollback,
+		ID:                pr.ID,
+		Title:             pr.Title,
+		Description:       pr.Description,
+		Action:            pr.Action,
+		Status:            pr.Status,
+		GitURL:            pr.GitURL,
+		GitCommit:         pr.GitCommit,
+		ImageURL:          pr.ImageURL,
+		LastConfigCommit:  pr.LastConfigCommit,
+		ConfigCommit:      pr.ConfigCommit,
+		CreatedAt:         pr.CreatedAt,
+		UpdatedAt:         pr.UpdatedAt,
+		StartedAt:         pr.StartedAt,
+		FinishedAt:        pr.FinishedAt,
+		CanRollback:       canRollback,
+		RequiredApprovals: pr.RequiredApprovals,
 		CreatedBy: models.UserInfo{
 			UserID:   pr.CreatedBy,
 			UserName: user.Name,
@@ -575,3 +575,20 @@ func (f *RegionInformers) GVK2GVR(regionID uint, GVK schema.GroupVersionKind) (s
 	}
 	return mapping.Resource, nil
 }
+
+// Namespaced tells whether the resource of GVK is namespaced in the region
+func (f *RegionInformers) Namespaced(regionID uint, GVK schema.GroupVersionKind) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	client, ok := f.clients[regionID]
+	if !ok {
+		return false, herrors.NewErrNotFound(herrors.RegionInDB, fmt.Sprintf("region %d", regionID))
+	}
+
+	mapping, err := client.mapper.RESTMapping(GVK.GroupKind(), GVK.Version)
+	if err != nil {
+		return false, herrors.NewErrNotFound(herrors.ResourceInK8S, fmt.Sprintf("mapping for %s: %s", GVK, err))
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
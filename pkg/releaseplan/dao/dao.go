@@ -0,0 +1,139 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/core/common"
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/releaseplan/models"
+)
+
+type DAO interface {
+	Create(ctx context.Context, plan *models.ReleasePlan,
+		clusters []*models.ReleasePlanCluster) (*models.ReleasePlan, error)
+	GetByID(ctx context.Context, id uint) (*models.ReleasePlan, error)
+	ListByApplicationID(ctx context.Context, applicationID uint,
+		query *q.Query) ([]*models.ReleasePlan, int64, error)
+	ListRunning(ctx context.Context, idThan uint, limit int) ([]*models.ReleasePlan, error)
+	ListClusters(ctx context.Context, planID uint) ([]*models.ReleasePlanCluster, error)
+	UpdateByID(ctx context.Context, id uint, plan *models.ReleasePlan) (*models.ReleasePlan, error)
+	UpdateClusterByID(ctx context.Context, id uint, cluster *models.ReleasePlanCluster) error
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Create(ctx context.Context, plan *models.ReleasePlan,
+	clusters []*models.ReleasePlanCluster) (*models.ReleasePlan, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(plan).Error; err != nil {
+			return herrors.NewErrInsertFailed(herrors.ReleasePlanInDB, err.Error())
+		}
+		for _, cluster := range clusters {
+			cluster.ReleasePlanID = plan.ID
+		}
+		if err := tx.Create(clusters).Error; err != nil {
+			return herrors.NewErrInsertFailed(herrors.ReleasePlanClusterInDB, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (d *dao) GetByID(ctx context.Context, id uint) (*models.ReleasePlan, error) {
+	var plan models.ReleasePlan
+	if err := d.db.WithContext(ctx).First(&plan, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.ReleasePlanInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.ReleasePlanInDB, err.Error())
+	}
+	return &plan, nil
+}
+
+func (d *dao) ListByApplicationID(ctx context.Context, applicationID uint,
+	query *q.Query) ([]*models.ReleasePlan, int64, error) {
+	sql := d.db.WithContext(ctx).Model(&models.ReleasePlan{}).
+		Where("application_id = ?", applicationID)
+	if query != nil {
+		if status, ok := query.Keywords[common.ReleasePlanQueryByStatus]; ok {
+			sql = sql.Where("status = ?", status)
+		}
+	} else {
+		query = &q.Query{}
+	}
+
+	var total int64
+	if err := sql.Count(&total).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.ReleasePlanInDB, err.Error())
+	}
+
+	var plans []*models.ReleasePlan
+	if err := sql.Order("id desc").Limit(query.Limit()).Offset(query.Offset()).
+		Find(&plans).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.ReleasePlanInDB, err.Error())
+	}
+	return plans, total, nil
+}
+
+func (d *dao) ListRunning(ctx context.Context, idThan uint, limit int) ([]*models.ReleasePlan, error) {
+	var plans []*models.ReleasePlan
+	if err := d.db.WithContext(ctx).
+		Where("status = ? AND id > ?", models.StatusRunning, idThan).
+		Order("id asc").Limit(limit).Find(&plans).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.ReleasePlanInDB, err.Error())
+	}
+	return plans, nil
+}
+
+func (d *dao) ListClusters(ctx context.Context, planID uint) ([]*models.ReleasePlanCluster, error) {
+	var clusters []*models.ReleasePlanCluster
+	if err := d.db.WithContext(ctx).Where("release_plan_id = ?", planID).
+		Order("wave asc, id asc").Find(&clusters).Error; err != nil {
+		return nil, herrors.NewErrListFailed(herrors.ReleasePlanClusterInDB, err.Error())
+	}
+	return clusters, nil
+}
+
+func (d *dao) UpdateByID(ctx context.Context, id uint,
+	plan *models.ReleasePlan) (*models.ReleasePlan, error) {
+	where := d.db.WithContext(ctx).Model(&models.ReleasePlan{}).Where("id = ?", id)
+	if err := where.Updates(plan).Error; err != nil {
+		return nil, herrors.NewErrUpdateFailed(herrors.ReleasePlanInDB, err.Error())
+	}
+	return d.GetByID(ctx, id)
+}
+
+func (d *dao) UpdateClusterByID(ctx context.Context, id uint, cluster *models.ReleasePlanCluster) error {
+	where := d.db.WithContext(ctx).Model(&models.ReleasePlanCluster{}).Where("id = ?", id)
+	if err := where.Updates(cluster).Error; err != nil {
+		return herrors.NewErrUpdateFailed(herrors.ReleasePlanClusterInDB, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/releaseplan/dao"
+	"github.com/horizoncd/horizon/pkg/releaseplan/models"
+)
+
+type Manager interface {
+	// Create creates a release plan with the clusters to deploy
+	Create(ctx context.Context, plan *models.ReleasePlan,
+		clusters []*models.ReleasePlanCluster) (*models.ReleasePlan, error)
+	// GetByID gets a release plan by ID
+	GetByID(ctx context.Context, id uint) (*models.ReleasePlan, error)
+	// ListByApplicationID lists the release plans of the application, filtered by status in query keywords
+	ListByApplicationID(ctx context.Context, applicationID uint,
+		query *q.Query) ([]*models.ReleasePlan, int64, error)
+	// ListRunning lists the running release plans whose id is greater than idThan
+	ListRunning(ctx context.Context, idThan uint, limit int) ([]*models.ReleasePlan, error)
+	// ListClusters lists the clusters of the release plan ordered by wave
+	ListClusters(ctx context.Context, planID uint) ([]*models.ReleasePlanCluster, error)
+	// UpdateByID updates the non-zero fields of the release plan
+	UpdateByID(ctx context.Context, id uint, plan *models.ReleasePlan) (*models.ReleasePlan, error)
+	// UpdateClusterByID updates the non-zero fields of the cluster of a release plan
+	UpdateClusterByID(ctx context.Context, id uint, cluster *models.ReleasePlanCluster) error
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) Create(ctx context.Context, plan *models.ReleasePlan,
+	clusters []*models.ReleasePlanCluster) (*models.ReleasePlan, error) {
+	return m.dao.Create(ctx, plan, clusters)
+}
+
+func (m *manager) GetByID(ctx context.Context, id uint) (*models.ReleasePlan, error) {
+	return m.dao.GetByID(ctx, id)
+}
+
+func (m *manager) ListByApplicationID(ctx context.Context, applicationID uint,
+	query *q.Query) ([]*models.ReleasePlan, int64, error) {
+	return m.dao.ListByApplicationID(ctx, applicationID, query)
+}
+
+func (m *manager) ListRunning(ctx context.Context, idThan uint, limit int) ([]*models.ReleasePlan, error) {
+	return m.dao.ListRunning(ctx, idThan, limit)
+}
+
+func (m *manager) ListClusters(ctx context.Context, planID uint) ([]*models.ReleasePlanCluster, error) {
+	return m.dao.ListClusters(ctx, planID)
+}
+
+func (m *manager) UpdateByID(ctx context.Context, id uint,
+	plan *models.ReleasePlan) (*models.ReleasePlan, error) {
+	return m.dao.UpdateByID(ctx, id, plan)
+}
+
+func (m *manager) UpdateClusterByID(ctx context.Context, id uint, cluster *models.ReleasePlanCluster) error {
+	return m.dao.UpdateClusterByID(ctx, id, cluster)
+}
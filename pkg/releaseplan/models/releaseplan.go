@@ -0,0 +1,70 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+type Status string
+
+const (
+	// StatusPending the cluster is waiting for its wave
+	StatusPending Status = "pending"
+	// StatusDeploying the cluster is deployed, and waiting for the pipelinerun to finish and to be healthy
+	StatusDeploying Status = "deploying"
+	// StatusRunning the release plan is deploying its waves one by one
+	StatusRunning Status = "running"
+	// StatusSucceeded all the clusters (of the plan) have been deployed and are healthy
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed the deployment failed, the release plan halts once any of its clusters fails
+	StatusFailed Status = "failed"
+	// StatusCancelled the release plan is cancelled by user
+	StatusCancelled Status = "cancelled"
+)
+
+// ReleasePlan deploys a set of clusters of an application in waves,
+// a wave starts only when all the clusters of previous waves are healthy
+type ReleasePlan struct {
+	global.Model
+
+	ApplicationID uint
+	Title         string
+	Description   string
+	// ImageTag the image tag to deploy for the clusters deployed by image
+	ImageTag string
+	// Wave the index of the wave being deployed
+	Wave    int
+	Status  Status
+	Message string
+
+	CreatedBy uint
+	UpdatedBy uint
+}
+
+// ReleasePlanCluster is a cluster deployed by the release plan
+type ReleasePlanCluster struct {
+	global.Model
+
+	ReleasePlanID uint
+	ClusterID     uint
+	Wave          int
+	PipelinerunID uint
+	Status        Status
+	Message       string
+	StartedAt     *time.Time
+}
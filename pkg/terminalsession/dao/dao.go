@@ -0,0 +1,78 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/terminalsession/models"
+)
+
+type DAO interface {
+	Create(ctx context.Context, session *models.TerminalSession) (*models.TerminalSession, error)
+	GetByID(ctx context.Context, id uint) (*models.TerminalSession, error)
+	ListByClusterID(ctx context.Context, clusterID uint, query *q.Query) ([]*models.TerminalSession, int64, error)
+}
+
+type dao struct {
+	db *gorm.DB
+}
+
+func NewDAO(db *gorm.DB) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) Create(ctx context.Context, session *models.TerminalSession) (*models.TerminalSession, error) {
+	if err := d.db.WithContext(ctx).Create(session).Error; err != nil {
+		return nil, herrors.NewErrInsertFailed(herrors.TerminalSessionInDB, err.Error())
+	}
+	return session, nil
+}
+
+func (d *dao) GetByID(ctx context.Context, id uint) (*models.TerminalSession, error) {
+	var session models.TerminalSession
+	if err := d.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.TerminalSessionInDB, err.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.TerminalSessionInDB, err.Error())
+	}
+	return &session, nil
+}
+
+func (d *dao) ListByClusterID(ctx context.Context, clusterID uint,
+	query *q.Query) ([]*models.TerminalSession, int64, error) {
+	if query == nil {
+		query = &q.Query{}
+	}
+	sql := d.db.WithContext(ctx).Model(&models.TerminalSession{}).Where("cluster_id = ?", clusterID)
+
+	var total int64
+	if err := sql.Count(&total).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.TerminalSessionInDB, err.Error())
+	}
+
+	var sessions []*models.TerminalSession
+	if err := sql.Order("id desc").Limit(query.Limit()).Offset(query.Offset()).
+		Find(&sessions).Error; err != nil {
+		return nil, 0, herrors.NewErrListFailed(herrors.TerminalSessionInDB, err.Error())
+	}
+	return sessions, total, nil
+}
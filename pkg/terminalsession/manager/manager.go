@@ -0,0 +1,53 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/horizoncd/horizon/lib/q"
+	"github.com/horizoncd/horizon/pkg/terminalsession/dao"
+	"github.com/horizoncd/horizon/pkg/terminalsession/models"
+)
+
+type Manager interface {
+	Create(ctx context.Context, session *models.TerminalSession) (*models.TerminalSession, error)
+	GetByID(ctx context.Context, id uint) (*models.TerminalSession, error)
+	// ListByClusterID lists the terminal sessions of cluster, the latest first
+	ListByClusterID(ctx context.Context, clusterID uint, query *q.Query) ([]*models.TerminalSession, int64, error)
+}
+
+type manager struct {
+	dao dao.DAO
+}
+
+func New(db *gorm.DB) Manager {
+	return &manager{dao: dao.NewDAO(db)}
+}
+
+func (m *manager) Create(ctx context.Context, session *models.TerminalSession) (*models.TerminalSession, error) {
+	return m.dao.Create(ctx, session)
+}
+
+func (m *manager) GetByID(ctx context.Context, id uint) (*models.TerminalSession, error) {
+	return m.dao.GetByID(ctx, id)
+}
+
+func (m *manager) ListByClusterID(ctx context.Context, clusterID uint,
+	query *q.Query) ([]*models.TerminalSession, int64, error) {
+	return m.dao.ListByClusterID(ctx, clusterID, query)
+}
@@ -0,0 +1,42 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
+
+// TerminalSession is a shell session opened in a container of cluster,
+// whose recording is stored by the collector of the environment
+type TerminalSession struct {
+	global.Model
+
+	ClusterID     uint
+	PodName       string
+	ContainerName string
+	// Object is the recording object in storage, it is empty if the recording failed to be stored
+	Object    string
+	Size      int
+	Truncated bool
+	StartedAt time.Time
+	EndedAt   time.Time
+	CreatedBy uint
+}
+
+func (TerminalSession) TableName() string {
+	return "tb_terminal_session"
+}
@@ -16,13 +16,15 @@ package generator
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/base64"
+	"math/big"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"k8s.io/apimachinery/pkg/util/rand"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
 )
 
 // ref: https://github.blog/2021-04-05-behind-githubs-new-authentication-token-formats/
@@ -31,6 +33,13 @@ const (
 	OauthAPPAccessTokenPrefix               = "ho_"
 	AccessTokenPrefix                       = "ha_"
 	RefreshTokenPrefix                      = "hr_"
+	ClientCredentialsAccessTokenPrefix      = "hc_"
+)
+
+// user code is short and case-insensitive for typing, and excludes vowels to avoid words, ref: rfc8628#section-6.1
+const (
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
 )
 
 func NewAuthorizeGenerator() CodeGenerator {
@@ -53,12 +62,28 @@ func NewRefreshTokenGenerator() CodeGenerator {
 	return &basicTokenGenerator{prefix: RefreshTokenPrefix}
 }
 
+func NewClientCredentialsAccessGenerator() CodeGenerator {
+	return &basicTokenGenerator{prefix: ClientCredentialsAccessTokenPrefix}
+}
+
+// NewDeviceCodeGenerator generates the device_code polled by the device, which is as secret as authorize_code
+func NewDeviceCodeGenerator() CodeGenerator {
+	return &authorizationCodeGenerator{}
+}
+
+// NewUserCodeGenerator generates the user_code like WDJB-MJHT, entered by the user to authorize a device
+func NewUserCodeGenerator() CodeGenerator {
+	return &userCodeGenerator{}
+}
+
 type authorizationCodeGenerator struct{}
 
 type basicTokenGenerator struct {
 	prefix string
 }
 
+type userCodeGenerator struct{}
+
 func (g *authorizationCodeGenerator) Generate(info *CodeGenerateInfo) string {
 	buf := bytes.NewBufferString(info.Token.ClientID)
 	buf.WriteString(strconv.Itoa(int(info.Token.UserID)))
@@ -73,7 +98,7 @@ func (g *basicTokenGenerator) Generate(info *CodeGenerateInfo) string {
 		if info.Token.ClientID != "" {
 			return info.Token.ClientID
 		}
-		return rand.String(20)
+		return utilrand.String(20)
 	}(info)
 	buf := bytes.NewBufferString(clientID)
 	buf.WriteString(strconv.Itoa(int(info.Token.UserID)))
@@ -81,3 +106,19 @@ func (g *basicTokenGenerator) Generate(info *CodeGenerateInfo) string {
 	access := base64.URLEncoding.EncodeToString([]byte(uuid.NewMD5(uuid.Must(uuid.NewRandom()), buf.Bytes()).String()))
 	return g.prefix + strings.ToUpper(strings.TrimRight(access, "="))
 }
+
+func (g *userCodeGenerator) Generate(info *CodeGenerateInfo) string {
+	var buf strings.Builder
+	max := big.NewInt(int64(len(userCodeCharset)))
+	for i := 0; i < userCodeLength; i++ {
+		if i == userCodeLength/2 {
+			buf.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		buf.WriteByte(userCodeCharset[n.Int64()])
+	}
+	return buf.String()
+}
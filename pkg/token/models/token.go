@@ -39,4 +39,14 @@ type Token struct {
 	RefID uint `gorm:"column:ref_id"`
 
 	UserID uint `gorm:"column:user_id"`
+
+	// PKCE code challenge of authorize_code, ref: rfc7636
+	CodeChallenge       string `gorm:"column:code_challenge"`
+	CodeChallengeMethod string `gorm:"column:code_challenge_method"`
+
+	// UserCode is entered by the user to authorize a device_code, ref: rfc8628
+	UserCode string `gorm:"column:user_code"`
+
+	// Nonce of authorize_code is passed to the id token, ref: OpenID Connect Core 1.0
+	Nonce string `gorm:"column:nonce"`
 }
@@ -17,6 +17,7 @@ package store
 import (
 	"context"
 	goerrors "errors"
+	"fmt"
 
 	herrors "github.com/horizoncd/horizon/core/errors"
 	"github.com/horizoncd/horizon/pkg/common"
@@ -61,6 +62,18 @@ func (s *store) GetByCode(ctx context.Context, code string) (*models.Token, erro
 	return &token, nil
 }
 
+func (s *store) GetByUserCode(ctx context.Context, userCode string) (*models.Token, error) {
+	var token models.Token
+	result := s.db.WithContext(ctx).Model(token).Where("user_code = ?", userCode).First(&token)
+	if result.Error != nil {
+		if goerrors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.TokenInDB, result.Error.Error())
+		}
+		return nil, herrors.NewErrGetFailed(herrors.TokenInDB, result.Error.Error())
+	}
+	return &token, nil
+}
+
 func (s *store) UpdateByID(ctx context.Context, id uint, token *models.Token) error {
 	tokenInDB, err := s.GetByID(ctx, id)
 	if err != nil {
@@ -77,6 +90,18 @@ func (s *store) UpdateByID(ctx context.Context, id uint, token *models.Token) er
 	return nil
 }
 
+func (s *store) UpdateAuthorization(ctx context.Context, id uint, userID uint, state string) error {
+	result := s.db.WithContext(ctx).Model(&models.Token{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"user_id": userID, "state": state})
+	if result.Error != nil {
+		return herrors.NewErrUpdateFailed(herrors.TokenInDB, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return herrors.NewErrNotFound(herrors.TokenInDB, fmt.Sprintf("token %d not found", id))
+	}
+	return nil
+}
+
 func (s *store) DeleteByID(ctx context.Context, id uint) error {
 	result := s.db.WithContext(ctx).Exec(common.DeleteTokenByID, id)
 	return result.Error
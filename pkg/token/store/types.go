@@ -24,7 +24,10 @@ type Store interface {
 	Create(ctx context.Context, token *models.Token) (*models.Token, error)
 	GetByID(ctx context.Context, id uint) (*models.Token, error)
 	GetByCode(ctx context.Context, code string) (*models.Token, error)
+	GetByUserCode(ctx context.Context, userCode string) (*models.Token, error)
 	UpdateByID(ctx context.Context, id uint, token *models.Token) error
+	// UpdateAuthorization updates the user and the state of a device_code once the user decides
+	UpdateAuthorization(ctx context.Context, id uint, userID uint, state string) error
 	DeleteByID(ctx context.Context, id uint) error
 	DeleteByCode(ctx context.Context, code string) error
 	DeleteByClientID(ctx context.Context, clientID string) error
@@ -32,6 +32,7 @@ type DAO interface {
 	DeleteByID(ctx context.Context, id uint) error
 	CreateLink(ctx context.Context, link *models.UserLink) (*models.UserLink, error)
 	GetByIDPAndSub(ctx context.Context, id uint, sub string) (*models.UserLink, error)
+	UpdateClaimGrants(ctx context.Context, id uint, grants *models.ClaimGrants) error
 }
 
 // NewDAO returns an instance of the default DAO
@@ -114,3 +115,15 @@ func (d dao) CreateLink(ctx context.Context, link *models.UserLink) (*models.Use
 	}
 	return link, err
 }
+
+func (d dao) UpdateClaimGrants(ctx context.Context, id uint, grants *models.ClaimGrants) error {
+	err := d.db.WithContext(ctx).
+		Table("tb_idp_user").
+		Where("id = ?", id).
+		Update("claim_grants", grants).Error
+	if err != nil {
+		return perror.Wrapf(herrors.NewErrUpdateFailed(herrors.UserLinkInDB, err.Error()),
+			"failed to update claim grants of link: id = %d", id)
+	}
+	return nil
+}
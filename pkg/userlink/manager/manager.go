@@ -34,6 +34,8 @@ type Manager interface {
 	GetByID(ctx context.Context, id uint) (*models.UserLink, error)
 	GetByIDPAndSub(ctx context.Context, idpID uint, sub string) (*models.UserLink, error)
 	DeleteByID(ctx context.Context, id uint) error
+	// UpdateClaimGrants records what the claim mappings have granted the user of the link
+	UpdateClaimGrants(ctx context.Context, id uint, grants *models.ClaimGrants) error
 }
 
 type manager struct {
@@ -76,3 +78,7 @@ func (m *manager) GetByIDPAndSub(ctx context.Context,
 func (m *manager) DeleteByID(ctx context.Context, id uint) error {
 	return m.dao.DeleteByID(ctx, id)
 }
+
+func (m *manager) UpdateClaimGrants(ctx context.Context, id uint, grants *models.ClaimGrants) error {
+	return m.dao.UpdateClaimGrants(ctx, id, grants)
+}
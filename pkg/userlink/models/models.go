@@ -14,7 +14,13 @@
 
 package models
 
-import "github.com/horizoncd/horizon/pkg/server/global"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/horizoncd/horizon/pkg/server/global"
+)
 
 type UserLink struct {
 	global.Model
@@ -25,6 +31,40 @@ type UserLink struct {
 	Name      string
 	Email     string
 	Deletable bool
+
+	// ClaimGrants is what the claim mappings of the identity provider have granted the user,
+	// only which are revoked when the claims disappear
+	ClaimGrants *ClaimGrants
+}
+
+type ClaimGrants struct {
+	Admin bool `json:"admin,omitempty"`
+	// Groups maps group ids to the granted roles
+	Groups map[uint]string `json:"groups,omitempty"`
+}
+
+func (g *ClaimGrants) Scan(value interface{}) error {
+	var bts []byte
+	switch v := value.(type) {
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal ClaimGrants from value: %v", value)
+	}
+	return json.Unmarshal(bts, g)
+}
+
+func (g *ClaimGrants) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+	bts, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(bts), nil
 }
 
 func (UserLink) TableName() string {
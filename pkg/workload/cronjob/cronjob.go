@@ -0,0 +1,272 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronjob
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+	"github.com/horizoncd/horizon/pkg/util/kube"
+	"github.com/horizoncd/horizon/pkg/workload"
+	"github.com/horizoncd/horizon/pkg/workload/job"
+)
+
+const (
+	ActionSuspend = "suspend"
+	ActionResume  = "resume"
+
+	// _instantiateAnnotation marks the job created manually, the same as kubectl create job --from
+	_instantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+)
+
+var (
+	GVRCronJob = schema.GroupVersionResource{
+		Group:    "batch",
+		Version:  "v1",
+		Resource: "cronjobs",
+	}
+	// GVRCronJobV1beta1 is served by kubernetes before 1.21
+	GVRCronJobV1beta1 = schema.GroupVersionResource{
+		Group:    "batch",
+		Version:  "v1beta1",
+		Resource: "cronjobs",
+	}
+)
+
+func init() {
+	workload.Register(ability, GVRCronJob, GVRCronJobV1beta1, job.GVRJob, job.GVRPod)
+}
+
+// please refer to github.com/horizoncd/horizon/pkg/cluster/cd/workload/workload.go
+var ability = &cronjob{}
+
+type cronjob struct{}
+
+func (*cronjob) MatchGK(gk schema.GroupKind) bool {
+	return gk.Group == "batch" && gk.Kind == "CronJob"
+}
+
+func gvr(node *v1alpha1.ResourceNode) schema.GroupVersionResource {
+	if node.Version == GVRCronJobV1beta1.Version {
+		return GVRCronJobV1beta1
+	}
+	return GVRCronJob
+}
+
+// fromUnstructured converts cronjob of batch/v1 or batch/v1beta1, whose fields used are the same
+func fromUnstructured(un *unstructured.Unstructured) (*batchv1beta1.CronJob, error) {
+	instance := &batchv1beta1.CronJob{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(un.Object, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+func (*cronjob) getCronJob(node *v1alpha1.ResourceNode,
+	factory dynamicinformer.DynamicSharedInformerFactory) (*batchv1beta1.CronJob, error) {
+	obj, err := factory.ForResource(gvr(node)).Lister().ByNamespace(node.Namespace).Get(node.Name)
+	if err != nil {
+		return nil,
+			herrors.NewErrGetFailed(herrors.ResourceInK8S,
+				fmt.Sprintf("failed to get cronjob in k8s: cronjob = %s, ns = %v, err = %v",
+					node.Name, node.Namespace, err),
+			)
+	}
+	un, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil,
+			herrors.NewErrGetFailed(herrors.ResourceInK8S,
+				fmt.Sprintf("failed to convert obj into unstructured: name = %s, ns = %v",
+					node.Name, node.Namespace),
+			)
+	}
+	instance, err := fromUnstructured(un)
+	if err != nil {
+		return nil,
+			herrors.NewErrGetFailed(herrors.ResourceInK8S,
+				fmt.Sprintf("failed to convert unstructured into cronjob: name = %s, ns = %v, err = %v",
+					node.Name, node.Namespace, err),
+			)
+	}
+	return instance, nil
+}
+
+func (*cronjob) getCronJobByNode(node *v1alpha1.ResourceNode, client *kube.Client) (*batchv1beta1.CronJob, error) {
+	un, err := client.Dynamic.Resource(gvr(node)).Namespace(node.Namespace).
+		Get(context.TODO(), node.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, perror.Wrapf(
+			herrors.NewErrGetFailed(herrors.ResourceInK8S,
+				"failed to get cronjob in k8s"),
+			"failed to get cronjob in k8s: cronjob = %s, ns = %v, err = %v", node.Name, node.Namespace, err)
+	}
+	return fromUnstructured(un)
+}
+
+// IsHealthy returns false if the latest job of the cronjob failed
+func (c *cronjob) IsHealthy(node *v1alpha1.ResourceNode,
+	client *kube.Client) (bool, error) {
+	instance, err := c.getCronJobByNode(node, client)
+	if err != nil {
+		return true, err
+	}
+	jobList, err := client.Basic.BatchV1().Jobs(node.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return true, err
+	}
+	jobs := make([]*batchv1.Job, 0, len(jobList.Items))
+	for i := range jobList.Items {
+		jobs = append(jobs, &jobList.Items[i])
+	}
+	jobs = OwnedJobs(instance.UID, jobs)
+	if len(jobs) == 0 {
+		return true, nil
+	}
+	return job.Status(jobs[0]) != job.StatusFailed, nil
+}
+
+// ListPods lists the pods of all the jobs created by the cronjob
+func (c *cronjob) ListPods(node *v1alpha1.ResourceNode,
+	factory dynamicinformer.DynamicSharedInformerFactory) ([]corev1.Pod, error) {
+	instance, err := c.getCronJob(node, factory)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := ListJobs(instance.Namespace, instance.UID, factory)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]corev1.Pod, 0)
+	for _, j := range jobs {
+		jobPods, err := job.ListPods(j, factory)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, jobPods...)
+	}
+	return pods, nil
+}
+
+func (*cronjob) Action(actionName string, un *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	switch actionName {
+	case ActionSuspend:
+		if err := unstructured.SetNestedField(un.Object, true, "spec", "suspend"); err != nil {
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "failed to suspend cronjob: %v", err)
+		}
+	case ActionResume:
+		if err := unstructured.SetNestedField(un.Object, false, "spec", "suspend"); err != nil {
+			return nil, perror.Wrapf(herrors.ErrParamInvalid, "failed to resume cronjob: %v", err)
+		}
+	default:
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "unsupported action: %v", actionName)
+	}
+	return un, nil
+}
+
+// TriggerJob creates a job from the job template of cronjob, like kubectl create job --from=cronjob
+func (*cronjob) TriggerJob(un *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	instance, err := fromUnstructured(un)
+	if err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "convert to cronjob failed: %v", err)
+	}
+
+	// the name of job is at most 63 characters
+	name := instance.Name
+	suffix := fmt.Sprintf("-manual-%d", time.Now().Unix())
+	if len(name)+len(suffix) > 63 {
+		name = name[:63-len(suffix)]
+	}
+	annotations := map[string]string{_instantiateAnnotation: "manual"}
+	for k, v := range instance.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	controller := true
+	newJob := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name + suffix,
+			Namespace:   instance.Namespace,
+			Labels:      instance.Spec.JobTemplate.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: un.GetAPIVersion(),
+				Kind:       un.GetKind(),
+				Name:       instance.Name,
+				UID:        instance.UID,
+				Controller: &controller,
+			}},
+		},
+		Spec: instance.Spec.JobTemplate.Spec,
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newJob)
+	if err != nil {
+		return nil, perror.Wrapf(herrors.ErrParamInvalid, "convert job to unstructured failed: %v", err)
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
+
+// ListJobs lists the jobs created by the cronjob, the latest first
+func ListJobs(namespace string, uid types.UID,
+	factory dynamicinformer.DynamicSharedInformerFactory) ([]*batchv1.Job, error) {
+	objs, err := factory.ForResource(job.GVRJob).Lister().ByNamespace(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*batchv1.Job, 0, len(objs))
+	for _, obj := range objs {
+		j := &batchv1.Job{}
+		if err := workload.ObjUnmarshal(obj, j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return OwnedJobs(uid, jobs), nil
+}
+
+// OwnedJobs filters the jobs owned by uid, and sorts them by creation time, the latest first
+func OwnedJobs(uid types.UID, jobs []*batchv1.Job) []*batchv1.Job {
+	owned := make([]*batchv1.Job, 0)
+	for _, j := range jobs {
+		for _, owner := range j.OwnerReferences {
+			if owner.UID == uid {
+				owned = append(owned, j)
+				break
+			}
+		}
+	}
+	sort.SliceStable(owned, func(i, k int) bool {
+		return owned[k].CreationTimestamp.Before(&owned[i].CreationTimestamp)
+	})
+	return owned
+}
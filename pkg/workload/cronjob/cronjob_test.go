@@ -0,0 +1,114 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronjob
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	herrors "github.com/horizoncd/horizon/core/errors"
+	perror "github.com/horizoncd/horizon/pkg/errors"
+)
+
+func newCronJob(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1beta1",
+		"kind":       "CronJob",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+			"uid":       "cronjob-uid",
+		},
+		"spec": map[string]interface{}{
+			"schedule": "*/5 * * * *",
+			"jobTemplate": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": name},
+				},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": []interface{}{
+								map[string]interface{}{"name": "job", "image": "busybox"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func TestAction(t *testing.T) {
+	un, err := ability.Action(ActionSuspend, newCronJob("cron"))
+	assert.Nil(t, err)
+	suspend, _, _ := unstructured.NestedBool(un.Object, "spec", "suspend")
+	assert.True(t, suspend)
+
+	un, err = ability.Action(ActionResume, un)
+	assert.Nil(t, err)
+	suspend, _, _ = unstructured.NestedBool(un.Object, "spec", "suspend")
+	assert.False(t, suspend)
+
+	_, err = ability.Action("promote", un)
+	assert.Equal(t, herrors.ErrParamInvalid, perror.Cause(err))
+}
+
+func TestTriggerJob(t *testing.T) {
+	un, err := ability.TriggerJob(newCronJob("cron"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Job", un.GetKind())
+	assert.Equal(t, "batch/v1", un.GetAPIVersion())
+	assert.True(t, strings.HasPrefix(un.GetName(), "cron-manual-"))
+	assert.Equal(t, "default", un.GetNamespace())
+	assert.Equal(t, "manual", un.GetAnnotations()[_instantiateAnnotation])
+	assert.Equal(t, "cron", un.GetLabels()["app"])
+	assert.Equal(t, 1, len(un.GetOwnerReferences()))
+	assert.Equal(t, "CronJob", un.GetOwnerReferences()[0].Kind)
+	containers, _, _ := unstructured.NestedSlice(un.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, 1, len(containers))
+
+	// name of job is truncated to 63 characters
+	un, err = ability.TriggerJob(newCronJob(strings.Repeat("a", 60)))
+	assert.Nil(t, err)
+	assert.Equal(t, 63, len(un.GetName()))
+}
+
+func TestOwnedJobs(t *testing.T) {
+	now := time.Now()
+	newJob := func(name string, created time.Time) *batchv1.Job {
+		return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			OwnerReferences:   []metav1.OwnerReference{{UID: "cronjob-uid"}},
+		}}
+	}
+	other := newJob("other", now)
+	other.OwnerReferences = nil
+	jobs := OwnedJobs("cronjob-uid", []*batchv1.Job{
+		newJob("first", now.Add(-2*time.Minute)),
+		other,
+		newJob("second", now.Add(-time.Minute)),
+	})
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, "second", jobs[0].Name)
+	assert.Equal(t, "first", jobs[1].Name)
+}
@@ -272,6 +272,7 @@ func (r *rollout) GetSteps(node *v1alpha1.ResourceNode, client *kube.Client) (*w
 		Total:        len(incrementReplicasList),
 		Replicas:     incrementReplicasList,
 		ManualPaused: instance.Spec.Paused,
+		Paused:       len(instance.Status.PauseConditions) > 0,
 		AutoPromote:  autoPromote,
 		Extra:        &extra,
 	}, nil
@@ -311,6 +312,8 @@ func (r *rollout) Action(actionName string, un *unstructured.Unstructured) (*uns
 		spec["paused"] = false
 	case "cancel-auto-promote":
 		delete(status, "autoPromote")
+	case "abort":
+		status["abort"] = true
 	default:
 		return nil, perror.Wrapf(herrors.ErrParamInvalid, "unsupported action: %v", actionName)
 	}
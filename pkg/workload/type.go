@@ -23,6 +23,7 @@ type Step struct {
 	Total        int
 	Replicas     []int
 	ManualPaused bool
+	Paused       bool
 	AutoPromote  bool
 	Extra        *string
 }
@@ -80,6 +80,16 @@ type PodsLister interface {
 		factory dynamicinformer.DynamicSharedInformerFactory) ([]corev1.Pod, error)
 }
 
+// ActionTrigger runs a job immediately, it is supported by workloads implementing JobTrigger
+const ActionTrigger = "trigger"
+
+// JobTrigger creates jobs on demand, such as running a cronjob immediately
+type JobTrigger interface {
+	Workload
+	// TriggerJob returns the job of batch/v1 to create for the object
+	TriggerJob(un *unstructured.Unstructured) (*unstructured.Unstructured, error)
+}
+
 type HealthStatusGetter interface {
 	Workload
 	IsHealthy(node *v1alpha1.ResourceNode, client *kube.Client) (bool, error)